@@ -0,0 +1,513 @@
+// Absences Module for PocketBase
+//
+// This module handles the days without a target besides the days off of the work schedule: the
+// public holidays in the holidays collection, which apply to all clocks, and the absences of a
+// clock in the absences collection, e.g. vacation or sick days. Neither has a target, so they
+// don't count as missing days and don't lower the balance in the daily summaries.
+//
+// An absence covers the whole day unless it is partial: a half day absence halves the target of
+// the day, and an absence with a number of minutes reduces the target by that amount, e.g. for a
+// doctor's appointment. The target reduced this way is used by all aggregate calculations.
+//
+// Absences are stored per day. An absence spanning several days, e.g. two weeks of vacation, can
+// be entered with a single request, which creates one absence per workday of the range. Days off
+// according to the work schedule, days outside of the employment, holidays and days the clock is
+// already absent are skipped, so the absence only uses up the days that would have been worked.
+// The created days share a series, so they can be found together later.
+//
+// Vacation and sick days are meant to be free of work, so recording work time on them is
+// usually a mistake: clocking in and entering sessions on such a day requires a confirmation with
+// 'confirm_absence=true' (see validateNotAbsent). The other way around, a vacation or sick day
+// entered for a day with recorded work is flagged as 'absence_conflict' event and reported by the
+// range endpoint, so the overlap can be resolved.
+//
+// Whenever an absence or holiday is created, modified or deleted, the daily summaries from its
+// day on are recomputed.
+package backend
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/core"
+)
+
+// maxAbsenceRangeDays is the maximum number of days of an absence entered as range.
+const maxAbsenceRangeDays = 366
+
+// absenceKinds are the valid kinds of absences.
+var absenceKinds = []string{"vacation", "sick", "other"}
+
+// workFreeAbsenceKinds are the kinds of absences recording work time on requires a confirmation.
+var workFreeAbsenceKinds = []string{"vacation", "sick"}
+
+// absenceRangeRequest is the JSON body of the absence range endpoint.
+type absenceRangeRequest struct {
+	From    string `json:"from"`     // First day of the absence (YYYY-MM-DD)
+	To      string `json:"to"`       // Last day of the absence (YYYY-MM-DD), inclusive
+	Kind    string `json:"kind"`     // Kind of the absence ('vacation', 'sick' or 'other')
+	HalfDay bool   `json:"half_day"` // Whether the absence only covers half of each day
+	Minutes int    `json:"minutes"`  // Minutes of each day covered by the absence, 0 for the whole day
+	Note    string `json:"note"`     // Optional note of the absence
+	Clock   string `json:"clock"`    // Optional name of the clock, the default clock is used without it
+}
+
+// AbsenceRange is the result of entering an absence spanning a range of days.
+type AbsenceRange struct {
+	Series    string              `json:"series"`    // Common series of the created absences, empty if none were created
+	Created   []string            `json:"created"`   // Days an absence was created for (YYYY-MM-DD)
+	Skipped   []SkippedAbsenceDay `json:"skipped"`   // Days of the range without a created absence
+	Conflicts []string            `json:"conflicts"` // Created vacation or sick days with recorded work (YYYY-MM-DD)
+}
+
+// SkippedAbsenceDay is a day of an absence range that doesn't need an absence.
+type SkippedAbsenceDay struct {
+	Date   string `json:"date"`   // Day (YYYY-MM-DD)
+	Reason string `json:"reason"` // Why the day was skipped ('not_employed', 'day_off', 'holiday' or 'absent')
+}
+
+// dayOff is a holiday or an absence of a clock.
+type dayOff struct {
+	Kind    string        // Kind of the absence, or 'holiday'
+	HalfDay bool          // Whether only half of the target is covered
+	Covered time.Duration // Time of the target covered, 0 for the whole day
+}
+
+// full reports whether the day off covers the whole target of the day.
+//
+// Returns:
+// - True for holidays and absences that are neither half days nor limited to a number of minutes
+func (d dayOff) full() bool {
+	return d.Kind != "" && !d.HalfDay && d.Covered <= 0
+}
+
+// remaining returns the target of a day that is not covered by the day off.
+//
+// Parameters:
+// - target: The target of the day according to the work schedule
+//
+// Returns:
+// - The remaining target, the full target if the day is not off
+func (d dayOff) remaining(target time.Duration) time.Duration {
+	switch {
+	case d.Kind == "":
+		return target
+	case d.HalfDay:
+		return target / 2
+	case d.Covered > 0:
+		return max(target-d.Covered, 0)
+	default:
+		return 0
+	}
+}
+
+// daysOff are the holidays and absences of a clock by their day (YYYY-MM-DD).
+type daysOff map[string]dayOff
+
+// target returns the target of a day reduced by the holiday or absence on it.
+//
+// Parameters:
+// - schedules: The work schedules of the clock
+// - day: The local midnight of the day
+//
+// Returns:
+// - The target of the day, 0 if the day is not a workday or off
+func (o daysOff) target(schedules workSchedules, day time.Time) time.Duration {
+	return o[day.Format(time.DateOnly)].remaining(schedules.target(day))
+}
+
+// RegisterAbsencesAPI registers the absence range endpoint and the hooks recomputing the daily
+// summaries of changed absences and holidays with the PocketBase server.
+// It creates the following route:
+// - POST /api/work_clock/absences - Creates an absence for each workday of a range
+//
+// The endpoint expects a JSON body like:
+//
+//	{
+//	  "from": "2025-08-04",
+//	  "to": "2025-08-15",
+//	  "kind": "vacation",
+//	  "half_day": false,
+//	  "minutes": 0,
+//	  "note": "Summer vacation",
+//	  "clock": "side-project"
+//	}
+//
+// Parameters:
+// - app: The PocketBase application instance
+func RegisterAbsencesAPI(app core.App) {
+	app.OnServe().BindFunc(func(se *core.ServeEvent) error {
+		se.Router.POST("/api/work_clock/absences", func(e *core.RequestEvent) error {
+			var request absenceRangeRequest
+			if err := e.BindBody(&request); err != nil {
+				return e.Error(http.StatusBadRequest, "Invalid request body", err)
+			}
+
+			from, to, err := parseAbsenceRange(request.From, request.To)
+			if err != nil {
+				return e.Error(http.StatusBadRequest, err.Error(), nil)
+			}
+			if !slices.Contains(absenceKinds, request.Kind) {
+				return e.Error(http.StatusBadRequest, fmt.Sprintf("invalid 'kind' value '%s'. Expected one of: %s", request.Kind, strings.Join(absenceKinds, ", ")), nil)
+			}
+			if request.Minutes < 0 || request.Minutes > 24*60 {
+				return e.Error(http.StatusBadRequest, "invalid 'minutes' value. Expected a value between 0 and 1440", nil)
+			}
+			if request.HalfDay && request.Minutes > 0 {
+				return e.Error(http.StatusBadRequest, "'half_day' and 'minutes' can't be combined", nil)
+			}
+
+			clockID, err := findClockID(app, request.Clock)
+			if err != nil {
+				return e.Error(http.StatusBadRequest, err.Error(), nil)
+			}
+
+			absence := dayOff{Kind: request.Kind, HalfDay: request.HalfDay, Covered: time.Duration(request.Minutes) * time.Minute}
+			result, err := createAbsenceRange(app, clockID, from, to, absence, strings.TrimSpace(request.Note))
+			if err != nil {
+				return e.Error(http.StatusInternalServerError, fmt.Sprintf("Failed to create absences: %v", err), err)
+			}
+
+			return e.JSON(http.StatusOK, result)
+		})
+
+		return se.Next()
+	})
+
+	updateAbsence := func(e *core.RecordEvent) error {
+		started := time.Now()
+		days, err := updateDailySummariesFromRecordDate(e.App, e.Record, "date")
+		recordDailySummaryUpdate(started, days, err)
+		if err != nil {
+			// The absence change itself succeeded, a rebuild repairs the summaries
+			e.App.Logger().Error("failed to update daily summaries", "absence", e.Record.Id, "error", err)
+		}
+		return e.Next()
+	}
+
+	checkAbsence := func(e *core.RecordEvent) error {
+		if err := flagAbsenceConflict(e.App, e.Record); err != nil {
+			e.App.Logger().Error("failed to check absence for recorded work", "absence", e.Record.Id, "error", err)
+		}
+		return updateAbsence(e)
+	}
+
+	app.OnRecordAfterCreateSuccess("absences").BindFunc(checkAbsence)
+	app.OnRecordAfterUpdateSuccess("absences").BindFunc(checkAbsence)
+	app.OnRecordAfterDeleteSuccess("absences").BindFunc(updateAbsence)
+
+	updateHoliday := func(e *core.RecordEvent) error {
+		started := time.Now()
+		days, err := updateDailySummariesOfHoliday(e.App, e.Record)
+		recordDailySummaryUpdate(started, days, err)
+		if err != nil {
+			// The holiday change itself succeeded, a rebuild repairs the summaries
+			e.App.Logger().Error("failed to update daily summaries", "holiday", e.Record.Id, "error", err)
+		}
+		return e.Next()
+	}
+
+	app.OnRecordAfterCreateSuccess("holidays").BindFunc(updateHoliday)
+	app.OnRecordAfterUpdateSuccess("holidays").BindFunc(updateHoliday)
+	app.OnRecordAfterDeleteSuccess("holidays").BindFunc(updateHoliday)
+}
+
+// parseAbsenceRange parses the first and last day of an absence range.
+//
+// Parameters:
+// - fromValue: The first day (YYYY-MM-DD)
+// - toValue: The last day (YYYY-MM-DD), inclusive
+//
+// Returns:
+// - The local midnight of the first day
+// - The local midnight of the last day
+// - An error if a day is missing or invalid, or if the range is empty or too long
+func parseAbsenceRange(fromValue, toValue string) (time.Time, time.Time, error) {
+	var days [2]time.Time
+	for i, param := range []struct{ Name, Value string }{{"from", fromValue}, {"to", toValue}} {
+		if param.Value == "" {
+			return time.Time{}, time.Time{}, fmt.Errorf("missing '%s' (string) parameter", param.Name)
+		}
+
+		day, err := time.ParseInLocation(time.DateOnly, param.Value, time.Local)
+		if err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("invalid '%s' format. Expected YYYY-MM-DD", param.Name)
+		}
+		days[i] = day
+	}
+
+	if days[1].Before(days[0]) {
+		return time.Time{}, time.Time{}, fmt.Errorf("'to' must not be before 'from'")
+	}
+	if days[0].AddDate(0, 0, maxAbsenceRangeDays).Before(days[1]) {
+		return time.Time{}, time.Time{}, fmt.Errorf("an absence must not span more than %d days", maxAbsenceRangeDays)
+	}
+
+	return days[0], days[1], nil
+}
+
+// createAbsenceRange creates an absence for each workday of a range within a single transaction.
+//
+// Parameters:
+// - app: The App interface (typically a PocketBase instance or transaction)
+// - clockID: The ID of the clock, an empty string for the default clock
+// - from: The local midnight of the first day
+// - to: The local midnight of the last day, inclusive
+// - absence: The kind and the covered part of each day of the absences
+// - note: The note of the absences
+//
+// Returns:
+// - The created and skipped days, and the created days with recorded work
+// - An error if the schedules, employment, days off or sessions could not be retrieved or an absence could not be saved
+func createAbsenceRange(app core.App, clockID string, from, to time.Time, absence dayOff, note string) (*AbsenceRange, error) {
+	result := &AbsenceRange{Created: []string{}, Skipped: []SkippedAbsenceDay{}, Conflicts: []string{}}
+
+	err := app.RunInTransaction(func(txApp core.App) error {
+		schedules, err := findWorkSchedules(txApp, clockID)
+		if err != nil {
+			return err
+		}
+		employment, err := findEmploymentPeriods(txApp, clockID)
+		if err != nil {
+			return err
+		}
+		off, err := findDaysOff(txApp, clockID, from, to.AddDate(0, 0, 1))
+		if err != nil {
+			return err
+		}
+		sessions, err := findWorkSessions(txApp, clockID, from, to.AddDate(0, 0, 1))
+		if err != nil {
+			return err
+		}
+		recorded := recordedDays(sessions)
+
+		collection, err := txApp.FindCollectionByNameOrId("absences")
+		if err != nil {
+			return fmt.Errorf("failed to find absences collection: %w", err)
+		}
+
+		series := core.GenerateDefaultRandomId()
+		for day := from; !day.After(to); day = day.AddDate(0, 0, 1) {
+			date := day.Format(time.DateOnly)
+
+			reason := ""
+			switch {
+			case !employment.includes(day):
+				reason = "not_employed"
+			case schedules.target(day) <= 0:
+				reason = "day_off"
+			case off[date].Kind == "holiday":
+				reason = "holiday"
+			case off[date].Kind != "":
+				reason = "absent"
+			}
+			if reason != "" {
+				result.Skipped = append(result.Skipped, SkippedAbsenceDay{Date: date, Reason: reason})
+				continue
+			}
+
+			record := core.NewRecord(collection)
+			record.Set("clock", clockID)
+			record.Set("date", date)
+			record.Set("kind", absence.Kind)
+			record.Set("half_day", absence.HalfDay)
+			record.Set("minutes", int(absence.Covered.Minutes()))
+			record.Set("series", series)
+			record.Set("note", note)
+			if err := txApp.Save(record); err != nil {
+				return fmt.Errorf("failed to save absence of %s: %w", date, err)
+			}
+			result.Created = append(result.Created, date)
+			if recorded[date] && absence.full() && slices.Contains(workFreeAbsenceKinds, absence.Kind) {
+				result.Conflicts = append(result.Conflicts, date)
+			}
+		}
+
+		if len(result.Created) > 0 {
+			result.Series = series
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// validateNotAbsent ensures that no work time is recorded on a vacation or sick day by accident.
+// Partial absences leave time to work, so they don't require a confirmation.
+// The client can confirm the work time with 'confirm_absence=true', e.g. if the user was called in
+// during their vacation.
+//
+// Parameters:
+// - app: The App interface used to find the absences
+// - e: The RequestEvent from the HTTP handler
+// - clockID: The ID of the clock, an empty string for the default clock
+// - timestamps: The starts of the recorded sessions
+//
+// Returns:
+// - An API error if a session starts on a vacation or sick day and the work time was not confirmed
+func validateNotAbsent(app core.App, e *core.RequestEvent, clockID string, timestamps ...time.Time) error {
+	if confirmValue := e.Request.FormValue("confirm_absence"); confirmValue != "" {
+		confirmed, err := parseBoolParam(confirmValue, "confirm_absence")
+		if err != nil {
+			return e.Error(http.StatusBadRequest, err.Error(), nil)
+		}
+		if confirmed {
+			return nil
+		}
+	}
+
+	for _, timestamp := range timestamps {
+		day := startOfLocalDay(timestamp)
+		off, err := findDaysOff(app, clockID, day, day.AddDate(0, 0, 1))
+		if err != nil {
+			return e.Error(http.StatusInternalServerError, fmt.Sprintf("Failed to find absences: %v", err), err)
+		}
+
+		date := day.Format(time.DateOnly)
+		if absence := off[date]; absence.full() && slices.Contains(workFreeAbsenceKinds, absence.Kind) {
+			return e.Error(http.StatusConflict, fmt.Sprintf("%s is a %s day. Confirm the work time with 'confirm_absence=true'", date, absence.Kind), nil)
+		}
+	}
+
+	return nil
+}
+
+// flagAbsenceConflict records an 'absence_conflict' event if a full vacation or sick day has
+// recorded work.
+//
+// Parameters:
+// - app: The App interface used to find the sessions and save the event
+// - record: The created or modified absence
+//
+// Returns:
+// - An error if the day is invalid or the sessions could not be retrieved
+func flagAbsenceConflict(app core.App, record *core.Record) error {
+	if !absenceOf(record).full() || !slices.Contains(workFreeAbsenceKinds, record.GetString("kind")) {
+		return nil
+	}
+
+	day, err := time.ParseInLocation(time.DateOnly, record.GetString("date"), time.Local)
+	if err != nil {
+		return err
+	}
+
+	sessions, err := findWorkSessions(app, record.GetString("clock"), day, day.AddDate(0, 0, 1))
+	if err != nil {
+		return err
+	}
+	if len(sessions) == 0 {
+		return nil
+	}
+
+	recordEvent(app, "absence_conflict", "warning", fmt.Sprintf("The %s day %s has %d recorded sessions", record.GetString("kind"), record.GetString("date"), len(sessions)), map[string]any{
+		"absence":  record.Id,
+		"clock":    record.GetString("clock"),
+		"date":     record.GetString("date"),
+		"kind":     record.GetString("kind"),
+		"sessions": len(sessions),
+	})
+	return nil
+}
+
+// findDaysOff finds the holidays and the absences of a clock within a range.
+//
+// Parameters:
+// - app: The App interface (typically a PocketBase instance or transaction)
+// - clockID: The ID of the clock, an empty string for the default clock
+// - from: The local midnight of the first day (inclusive)
+// - to: The local midnight of the day after the range (exclusive)
+//
+// Returns:
+// - The days off, a holiday takes precedence over an absence on the same day
+// - An error if the database query fails
+func findDaysOff(app core.App, clockID string, from, to time.Time) (daysOff, error) {
+	params := dbx.Params{
+		"clock": clockParam(clockID),
+		"from":  from.Format(time.DateOnly),
+		"to":    to.Format(time.DateOnly),
+	}
+
+	holidays, err := app.FindRecordsByFilter("holidays", "date >= {:from} && date < {:to}", "", 0, 0, params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find holidays: %w", err)
+	}
+	absences, err := app.FindRecordsByFilter("absences", "clock = {:clock} && date >= {:from} && date < {:to}", "", 0, 0, params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find absences: %w", err)
+	}
+
+	off := daysOff{}
+	for _, absence := range absences {
+		off[absence.GetString("date")] = absenceOf(absence)
+	}
+	for _, holiday := range holidays {
+		off[holiday.GetString("date")] = dayOff{Kind: "holiday"}
+	}
+	return off, nil
+}
+
+// absenceOf converts an absences record to a day off.
+//
+// Parameters:
+// - record: The absences record
+//
+// Returns:
+// - The day off
+func absenceOf(record *core.Record) dayOff {
+	return dayOff{
+		Kind:    record.GetString("kind"),
+		HalfDay: record.GetBool("half_day"),
+		Covered: time.Duration(record.GetInt("minutes")) * time.Minute,
+	}
+}
+
+// updateDailySummariesOfHoliday recomputes the summaries of all clocks from the day of a changed
+// holiday on. Modified holidays also affect the days from their former day.
+//
+// Parameters:
+// - app: The App interface (typically a PocketBase instance or transaction)
+// - record: The created, modified or deleted holiday
+//
+// Returns:
+// - The number of saved summaries
+// - An error if the clocks could not be retrieved or recomputing a summary or a balance fails
+func updateDailySummariesOfHoliday(app core.App, record *core.Record) (int, error) {
+	var earliest time.Time
+	for _, version := range []*core.Record{record, record.Original()} {
+		day, err := time.ParseInLocation(time.DateOnly, version.GetString("date"), time.Local)
+		if err == nil && (earliest.IsZero() || day.Before(earliest)) {
+			earliest = day
+		}
+	}
+	if earliest.IsZero() {
+		return 0, nil
+	}
+
+	clocks, err := app.FindAllRecords("clocks")
+	if err != nil {
+		return 0, fmt.Errorf("failed to find clocks: %w", err)
+	}
+
+	clockIDs := []string{""}
+	for _, clock := range clocks {
+		clockIDs = append(clockIDs, clock.Id)
+	}
+
+	saved := 0
+	for _, clockID := range clockIDs {
+		updated, err := updateDailySummariesFrom(app, clockID, earliest)
+		saved += updated
+		if err != nil {
+			return saved, err
+		}
+	}
+
+	return saved, nil
+}
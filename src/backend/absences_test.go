@@ -0,0 +1,210 @@
+package backend
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/yerTools/simple-frontend-stack/src/backend/backendtest"
+)
+
+func TestAbsenceRange(t *testing.T) {
+	app := backendtest.NewApp(t)
+	RegisterAbsencesAPI(app)
+	handler := backendtest.NewHandler(t, app)
+
+	holidays, err := app.FindCollectionByNameOrId("holidays")
+	if err != nil {
+		t.Fatalf("failed to find holidays collection: %v", err)
+	}
+	holiday := core.NewRecord(holidays)
+	holiday.Set("date", "2025-04-18")
+	holiday.Set("name", "Good Friday")
+	if err := app.Save(holiday); err != nil {
+		t.Fatalf("failed to save holiday: %v", err)
+	}
+
+	post := func(body string) *httptest.ResponseRecorder {
+		request := httptest.NewRequest(http.MethodPost, "/api/work_clock/absences", strings.NewReader(body))
+		request.Header.Set("Content-Type", "application/json")
+
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, request)
+		return recorder
+	}
+
+	// Monday to the following Monday, with a weekend and a holiday in between
+	recorder := post(`{"from": "2025-04-14", "to": "2025-04-21", "kind": "vacation", "note": "Easter"}`)
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", recorder.Code, recorder.Body.String())
+	}
+	var result AbsenceRange
+	if err := json.Unmarshal(recorder.Body.Bytes(), &result); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if expected := []string{"2025-04-14", "2025-04-15", "2025-04-16", "2025-04-17", "2025-04-21"}; !slices.Equal(result.Created, expected) {
+		t.Errorf("expected absences on %v, got %v", expected, result.Created)
+	}
+	if expected := []SkippedAbsenceDay{{Date: "2025-04-18", Reason: "holiday"}, {Date: "2025-04-19", Reason: "day_off"}, {Date: "2025-04-20", Reason: "day_off"}}; !slices.Equal(result.Skipped, expected) {
+		t.Errorf("expected skipped days %v, got %v", expected, result.Skipped)
+	}
+
+	absences, err := app.FindRecordsByFilter("absences", "series = {:series}", "", 0, 0, dbx.Params{"series": result.Series})
+	if err != nil || len(absences) != 5 {
+		t.Fatalf("expected 5 absences of the series, got %d (%v)", len(absences), err)
+	}
+
+	// Days already absent are not entered twice
+	recorder = post(`{"from": "2025-04-17", "to": "2025-04-22", "kind": "sick"}`)
+	if err := json.Unmarshal(recorder.Body.Bytes(), &result); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if expected := []string{"2025-04-22"}; !slices.Equal(result.Created, expected) {
+		t.Errorf("expected absences on %v, got %v", expected, result.Created)
+	}
+
+	if recorder := post(`{"from": "2025-04-22", "to": "2025-04-14", "kind": "vacation"}`); recorder.Code != http.StatusBadRequest {
+		t.Errorf("expected status 400 for an empty range, got %d", recorder.Code)
+	}
+	if recorder := post(`{"from": "2025-04-14", "to": "2025-04-14", "kind": "party"}`); recorder.Code != http.StatusBadRequest {
+		t.Errorf("expected status 400 for an invalid kind, got %d", recorder.Code)
+	}
+}
+
+func TestDaysOffHaveNoTarget(t *testing.T) {
+	app := backendtest.NewApp(t)
+	RegisterAbsencesAPI(app)
+
+	monday := time.Date(2025, time.April, 14, 0, 0, 0, 0, time.Local)
+	if err := updateDailySummary(app, "", monday); err != nil {
+		t.Fatalf("failed to update daily summary: %v", err)
+	}
+
+	absences, err := app.FindCollectionByNameOrId("absences")
+	if err != nil {
+		t.Fatalf("failed to find absences collection: %v", err)
+	}
+	absence := core.NewRecord(absences)
+	absence.Set("date", "2025-04-14")
+	absence.Set("kind", "sick")
+	if err := app.Save(absence); err != nil {
+		t.Fatalf("failed to save absence: %v", err)
+	}
+
+	summaries, err := findDailySummaries(app, "", monday, monday.AddDate(0, 0, 1))
+	if err != nil || len(summaries) != 1 {
+		t.Fatalf("expected one summary, got %d (%v)", len(summaries), err)
+	}
+	if summaries[0].TargetSeconds != 0 || summaries[0].BalanceSeconds != 0 {
+		t.Errorf("expected no target on a sick day, got %+v", summaries[0])
+	}
+
+	missing, err := findMissingDays(app, "", monday, monday.AddDate(0, 0, 2), time.Now())
+	if err != nil {
+		t.Fatalf("failed to find missing days: %v", err)
+	}
+	if expected := []string{"2025-04-15"}; !slices.Equal(missing, expected) {
+		t.Errorf("expected %v, got %v", expected, missing)
+	}
+}
+
+func TestAbsenceOverlap(t *testing.T) {
+	app := backendtest.NewApp(t)
+	RegisterAbsencesAPI(app)
+	RegisterWorkClockAPI(app)
+	handler := backendtest.NewHandler(t, app)
+
+	absences, err := app.FindCollectionByNameOrId("absences")
+	if err != nil {
+		t.Fatalf("failed to find absences collection: %v", err)
+	}
+	absence := core.NewRecord(absences)
+	absence.Set("date", "2025-04-14")
+	absence.Set("kind", "vacation")
+	if err := app.Save(absence); err != nil {
+		t.Fatalf("failed to save absence: %v", err)
+	}
+
+	addPair := func(query string) int {
+		form := url.Values{"clock_in_timestamp": {"2025-04-14T09:00:00Z"}, "clock_out_timestamp": {"2025-04-14T11:00:00Z"}}
+		request := httptest.NewRequest(http.MethodPost, "/api/work_clock/add_clock_in_out_pair"+query, strings.NewReader(form.Encode()))
+		request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, request)
+		return recorder.Code
+	}
+
+	if code := addPair(""); code != http.StatusConflict {
+		t.Errorf("expected work time on a vacation day to require a confirmation, got %d", code)
+	}
+	if code := addPair("?confirm_absence=true"); code != http.StatusOK {
+		t.Errorf("expected confirmed work time to be recorded, got %d", code)
+	}
+
+	// A sick day entered for a day with recorded work is flagged
+	backendtest.AddRecords(t, app, backendtest.ClockIn("2025-04-15T09:00:00Z"), backendtest.ClockOut("2025-04-15T10:00:00Z"))
+	sick := core.NewRecord(absences)
+	sick.Set("date", "2025-04-15")
+	sick.Set("kind", "sick")
+	if err := app.Save(sick); err != nil {
+		t.Fatalf("failed to save absence: %v", err)
+	}
+
+	events, err := app.FindRecordsByFilter("events", "type = 'absence_conflict'", "", 0, 0)
+	if err != nil {
+		t.Fatalf("failed to find events: %v", err)
+	}
+	if len(events) != 1 || !strings.Contains(events[0].GetString("message"), "2025-04-15") {
+		t.Errorf("expected a single conflict on 2025-04-15, got %v", events)
+	}
+}
+
+func TestPartialAbsences(t *testing.T) {
+	for _, test := range []struct {
+		Off      dayOff
+		Expected time.Duration
+	}{
+		{Off: dayOff{}, Expected: 8 * time.Hour},
+		{Off: dayOff{Kind: "vacation"}, Expected: 0},
+		{Off: dayOff{Kind: "vacation", HalfDay: true}, Expected: 4 * time.Hour},
+		{Off: dayOff{Kind: "other", Covered: 90 * time.Minute}, Expected: 6*time.Hour + 30*time.Minute},
+		{Off: dayOff{Kind: "other", Covered: 10 * time.Hour}, Expected: 0},
+	} {
+		if remaining := test.Off.remaining(8 * time.Hour); remaining != test.Expected {
+			t.Errorf("expected %s remaining for %+v, got %s", test.Expected, test.Off, remaining)
+		}
+	}
+
+	app := backendtest.NewApp(t)
+	RegisterAbsencesAPI(app)
+
+	monday := time.Date(2025, time.April, 14, 0, 0, 0, 0, time.Local)
+	if _, err := createAbsenceRange(app, "", monday, monday, dayOff{Kind: "vacation", HalfDay: true}, ""); err != nil {
+		t.Fatalf("failed to create absence: %v", err)
+	}
+	if err := updateDailySummary(app, "", monday); err != nil {
+		t.Fatalf("failed to update daily summary: %v", err)
+	}
+
+	summaries, err := findDailySummaries(app, "", monday, monday.AddDate(0, 0, 1))
+	if err != nil || len(summaries) != 1 {
+		t.Fatalf("expected one summary, got %d (%v)", len(summaries), err)
+	}
+	if expected := int64(defaultWorkSchedule().Workday.Seconds()) / 2; summaries[0].TargetSeconds != expected {
+		t.Errorf("expected a half day absence to halve the target to %d, got %d", expected, summaries[0].TargetSeconds)
+	}
+
+	// Half of the day is still to be worked
+	missing, err := findMissingDays(app, "", monday, monday.AddDate(0, 0, 1), time.Now())
+	if err != nil || !slices.Equal(missing, []string{"2025-04-14"}) {
+		t.Errorf("expected the half day to be missing, got %v (%v)", missing, err)
+	}
+}
@@ -0,0 +1,127 @@
+// Admin Overview Module for PocketBase
+//
+// This module provides a summary of the instance for the home screen of the admin UI, so the
+// state of the instance can be shown with a single request instead of querying every module.
+package backend
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/pocketbase/pocketbase/apis"
+	"github.com/pocketbase/pocketbase/core"
+)
+
+// adminOverviewImports is the number of import runs listed in the admin overview.
+const adminOverviewImports = 5
+
+// OpenClock is a clock that is currently clocked in.
+type OpenClock struct {
+	Clock string    `json:"clock"` // Name of the clock, empty for the default clock
+	Since time.Time `json:"since"` // Start of the open session
+}
+
+// AdminOverview is the response of the admin overview endpoint.
+type AdminOverview struct {
+	OpenClocks       []OpenClock        `json:"open_clocks"`       // Clocks that are currently clocked in
+	CommentedRecords int                `json:"commented_records"` // Number of work clock records with a comment thread
+	Backups          int                `json:"backups"`           // Number of stored backups
+	LastBackup       *time.Time         `json:"last_backup"`       // Time of the latest backup, null without backups
+	LatestImports    []ImportRunEntry   `json:"latest_imports"`    // Latest import runs, newest first
+	DailySummaries   DailySummaryStatus `json:"daily_summaries"`   // Status of the daily summary recomputations
+}
+
+// RegisterAdminOverviewAPI registers the admin overview endpoint with the PocketBase server.
+// It creates the following route:
+// - GET /api/admin/overview - Summarizes the state of the instance, only accessible for superusers
+//
+// Parameters:
+// - app: The PocketBase application instance
+func RegisterAdminOverviewAPI(app core.App) {
+	app.OnServe().BindFunc(func(se *core.ServeEvent) error {
+		se.Router.GET("/api/admin/overview", func(e *core.RequestEvent) error {
+			overview, err := getAdminOverview(app)
+			if err != nil {
+				return e.Error(http.StatusInternalServerError, fmt.Sprintf("Failed to create admin overview: %v", err), err)
+			}
+
+			return e.JSON(http.StatusOK, overview)
+		}).Bind(apis.RequireSuperuserAuth())
+
+		return se.Next()
+	})
+}
+
+// getAdminOverview collects the summary of the instance.
+//
+// Parameters:
+// - app: The App interface (typically a PocketBase instance or transaction)
+//
+// Returns:
+// - The admin overview
+// - An error if a database query fails or the backups can't be listed
+func getAdminOverview(app core.App) (*AdminOverview, error) {
+	overview := &AdminOverview{OpenClocks: []OpenClock{}}
+
+	clocks, err := app.FindAllRecords("clocks")
+	if err != nil {
+		return nil, fmt.Errorf("failed to find clocks: %w", err)
+	}
+
+	clockNames := map[string]string{"": ""}
+	clockIDs := []string{""}
+	for _, clock := range clocks {
+		clockNames[clock.Id] = clock.GetString("name")
+		clockIDs = append(clockIDs, clock.Id)
+	}
+
+	for _, clockID := range clockIDs {
+		record, err := workClockServiceOf(app).LatestRecord(clockID)
+		if err != nil {
+			return nil, err
+		}
+		if record != nil && record.GetBool("clock_in") {
+			overview.OpenClocks = append(overview.OpenClocks, OpenClock{
+				Clock: clockNames[clockID],
+				Since: record.GetDateTime("timestamp").Time(),
+			})
+		}
+	}
+
+	var commented []struct {
+		Record string `db:"record"`
+	}
+	err = app.RecordQuery("record_comments").Select("record").Distinct(true).All(&commented)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count commented records: %w", err)
+	}
+	overview.CommentedRecords = len(commented)
+
+	fsys, err := app.NewBackupsFilesystem()
+	if err != nil {
+		return nil, fmt.Errorf("failed to open backups filesystem: %w", err)
+	}
+	defer fsys.Close()
+
+	backups, err := fsys.List("")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list backups: %w", err)
+	}
+	overview.Backups = len(backups)
+	for _, backup := range backups {
+		if overview.LastBackup == nil || backup.ModTime.After(*overview.LastBackup) {
+			modTime := backup.ModTime
+			overview.LastBackup = &modTime
+		}
+	}
+
+	overview.LatestImports, err = findImportRuns(app, adminOverviewImports)
+	if err != nil {
+		return nil, err
+	}
+
+	overview.DailySummaries = getDailySummaryStatus()
+
+	return overview, nil
+}
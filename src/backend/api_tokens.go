@@ -0,0 +1,373 @@
+// API Tokens Module for PocketBase
+//
+// This module provides API tokens for scripts and devices such as wallboards, which should not
+// hold the password or a full session of a user. A token is sent like a PocketBase auth token,
+//
+//	Authorization: Bearer sfs_...
+//
+// and acts on behalf of the user or superuser who created it, but only for the routes covered by
+// its scopes and only until it expires:
+// - 'clock:read': Reading the clock state, sessions, journal and calendar
+// - 'clock:write': Clocking in and out and changing sessions, includes 'clock:read'
+// - 'reports:read': Reports, exports, statistics and the Grafana data source
+// - 'admin:*': Everything else, including the collections API, imports and the admin endpoints
+//
+// Routes not covered by any other scope require 'admin:*', so a leaked wallboard token with
+// 'clock:read' and 'reports:read' can't be used to rewrite history. Only superusers can create
+// 'admin:*' tokens. Tokens are managed through the /api/api_tokens endpoints; like shortcut
+// tokens, only their hash is stored and the token itself is only returned once on creation.
+package backend
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/apis"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tools/hook"
+)
+
+// apiTokenPrefix distinguishes API tokens from the auth tokens of PocketBase.
+const apiTokenPrefix = "sfs_"
+
+// apiTokenScopes are the valid scopes of API tokens.
+var apiTokenScopes = []string{"clock:read", "clock:write", "reports:read", "admin:*"}
+
+// apiTokenLastUseInterval is the minimum time between two updates of the last use of a token,
+// so a wallboard polling every few seconds doesn't write to the database on every request.
+const apiTokenLastUseInterval = time.Minute
+
+// apiTokenRoute is a group of routes requiring the same scope.
+type apiTokenRoute struct {
+	Scope    string   // Scope required for the routes
+	Methods  []string // HTTP methods of the routes, all methods if empty
+	Prefixes []string // Path prefixes of the routes
+}
+
+// apiTokenRoutes are the route groups accessible with API tokens. A request belongs to the first
+// group with a matching method and path prefix, all other requests require 'admin:*'.
+var apiTokenRoutes = []apiTokenRoute{
+	{
+		// These routes change the clock state although they are requested with GET
+		Scope:    "clock:write",
+		Methods:  []string{http.MethodGet, http.MethodHead},
+		Prefixes: []string{"/api/work_clock/clock_in", "/api/work_clock/clock_out", "/api/work_clock/toggle"},
+	},
+	{
+		Scope:    "admin:*",
+		Prefixes: []string{"/api/work_clock/instance_import", "/api/work_clock/daily_summary/rebuild"},
+	},
+	{
+		Scope: "reports:read",
+		Prefixes: []string{
+			"/api/work_clock/report", "/api/work_clock/export", "/api/work_clock/stats", "/api/work_clock/feed.atom",
+			"/api/work_clock/missing", "/api/work_clock/vacation", "/api/work_clock/ledger/verify", "/api/grafana",
+			"/api/projects/budgets",
+		},
+	},
+	{
+		Scope:    "reports:read",
+		Methods:  []string{http.MethodGet, http.MethodHead},
+		Prefixes: []string{"/api/reports", "/api/teams"},
+	},
+	{
+		Scope:    "clock:read",
+		Methods:  []string{http.MethodGet, http.MethodHead},
+		Prefixes: []string{"/api/work_clock", "/api/compact", "/api/journal", "/api/calendar/events", "/api/search", "/api/features", "/api/version"},
+	},
+	{
+		Scope:    "clock:write",
+		Prefixes: []string{"/api/work_clock", "/api/compact", "/api/journal"},
+	},
+}
+
+// APIToken is an API token as listed by the API, without the token itself.
+type APIToken struct {
+	ID         string   `json:"id"`           // Record ID of the token
+	Name       string   `json:"name"`         // Name describing where the token is used
+	Scopes     []string `json:"scopes"`       // Scopes granted to the token
+	ExpiresAt  string   `json:"expires_at"`   // Timestamp after which the token is rejected, empty if it never expires
+	LastUsedAt string   `json:"last_used_at"` // Timestamp of the last use, empty if it was never used
+	Created    string   `json:"created"`      // Timestamp when the token was created
+}
+
+// RegisterAPITokensAPI registers the API token endpoints and the middleware authenticating
+// requests with API tokens.
+// It creates the following routes, which require authentication:
+// - GET /api/api_tokens - Lists the tokens of the authenticated user
+// - POST /api/api_tokens - Creates a token with the given 'name', 'scopes' (comma separated) and optional 'expires_at' (RFC3339)
+// - DELETE /api/api_tokens/{id} - Revokes a token of the authenticated user
+//
+// Parameters:
+// - app: The PocketBase application instance
+func RegisterAPITokensAPI(app core.App) {
+	app.OnServe().BindFunc(func(se *core.ServeEvent) error {
+		// Runs before the auth token of PocketBase is loaded, which is skipped once e.Auth is set
+		se.Router.Bind(&hook.Handler[*core.RequestEvent]{
+			Id:       "apiTokenAuth",
+			Priority: apis.DefaultLoadAuthTokenMiddlewarePriority - 1,
+			Func: func(e *core.RequestEvent) error {
+				token := strings.TrimPrefix(e.Request.Header.Get("Authorization"), "Bearer ")
+				if !strings.HasPrefix(token, apiTokenPrefix) {
+					return e.Next()
+				}
+
+				if err := authenticateAPIToken(app, e, token, time.Now()); err != nil {
+					return err
+				}
+				return e.Next()
+			},
+		})
+
+		group := se.Router.Group("/api/api_tokens")
+		group.Bind(apis.RequireAuth())
+
+		group.GET("", func(e *core.RequestEvent) error {
+			records, err := app.FindRecordsByFilter("api_tokens", "auth_collection = {:collection} && auth_record = {:record}", "-created", 0, 0, dbx.Params{
+				"collection": e.Auth.Collection().Name,
+				"record":     e.Auth.Id,
+			})
+			if err != nil {
+				return e.Error(http.StatusInternalServerError, fmt.Sprintf("Failed to find API tokens: %v", err), err)
+			}
+
+			tokens := make([]APIToken, 0, len(records))
+			for _, record := range records {
+				tokens = append(tokens, APIToken{
+					ID:         record.Id,
+					Name:       record.GetString("name"),
+					Scopes:     record.GetStringSlice("scopes"),
+					ExpiresAt:  formatOptionalDateTime(record, "expires_at"),
+					LastUsedAt: formatOptionalDateTime(record, "last_used_at"),
+					Created:    formatOptionalDateTime(record, "created"),
+				})
+			}
+			return e.JSON(http.StatusOK, tokens)
+		})
+
+		group.POST("", func(e *core.RequestEvent) error {
+			name := strings.TrimSpace(e.Request.FormValue("name"))
+			if name == "" {
+				return e.Error(http.StatusBadRequest, "Missing 'name' (string) parameter", nil)
+			}
+
+			scopes, err := parseAPITokenScopes(e.Request.FormValue("scopes"))
+			if err != nil {
+				return e.Error(http.StatusBadRequest, err.Error(), nil)
+			}
+			if slices.Contains(scopes, "admin:*") && !e.HasSuperuserAuth() {
+				return e.Error(http.StatusForbidden, "Only superusers can create tokens with the 'admin:*' scope", nil)
+			}
+
+			var expiresAt time.Time
+			if expiresValue := e.Request.FormValue("expires_at"); expiresValue != "" {
+				expiresAt, err = parseTimeParam(expiresValue, "expires_at")
+				if err != nil {
+					return e.Error(http.StatusBadRequest, err.Error(), nil)
+				}
+				if !expiresAt.After(time.Now()) {
+					return e.Error(http.StatusBadRequest, "'expires_at' must be in the future", nil)
+				}
+			}
+
+			record, token, err := createAPIToken(app, e.Auth, name, scopes, expiresAt)
+			if err != nil {
+				return e.Error(http.StatusInternalServerError, fmt.Sprintf("Failed to create API token: %v", err), err)
+			}
+
+			return e.JSON(http.StatusOK, map[string]any{
+				"id":         record.Id,
+				"name":       name,
+				"scopes":     scopes,
+				"expires_at": formatOptionalDateTime(record, "expires_at"),
+				"token":      token,
+			})
+		})
+
+		group.DELETE("/{id}", func(e *core.RequestEvent) error {
+			record, err := app.FindRecordById("api_tokens", e.Request.PathValue("id"))
+			if err != nil || record.GetString("auth_collection") != e.Auth.Collection().Name || record.GetString("auth_record") != e.Auth.Id {
+				return e.Error(http.StatusNotFound, "Unknown API token", nil)
+			}
+
+			if err := app.Delete(record); err != nil {
+				return e.Error(http.StatusInternalServerError, fmt.Sprintf("Failed to revoke API token: %v", err), err)
+			}
+			return e.NoContent(http.StatusNoContent)
+		})
+
+		return se.Next()
+	})
+}
+
+// authenticateAPIToken checks an API token against the route of a request and authenticates
+// the request as the user or superuser who created the token.
+//
+// Parameters:
+// - app: The App interface used to look up the token
+// - e: The RequestEvent from the HTTP handler
+// - token: The API token sent with the request
+// - now: The time of the request
+//
+// Returns:
+// - An error response if the token is unknown, expired, or lacks the scope of the route
+func authenticateAPIToken(app core.App, e *core.RequestEvent, token string, now time.Time) error {
+	record, err := app.FindFirstRecordByFilter("api_tokens", "token_hash = {:hash}", dbx.Params{
+		"hash": hashShortcutToken(token),
+	})
+	if err != nil {
+		return e.Error(http.StatusUnauthorized, "Unknown or revoked API token", nil)
+	}
+
+	if expiresAt := record.GetDateTime("expires_at"); !expiresAt.IsZero() && !now.Before(expiresAt.Time()) {
+		return e.Error(http.StatusUnauthorized, "The API token expired", nil)
+	}
+
+	scope := requiredAPITokenScope(e.Request)
+	if !grantsAPITokenScope(record.GetStringSlice("scopes"), scope) {
+		return e.Error(http.StatusForbidden, fmt.Sprintf("The API token lacks the '%s' scope", scope), nil)
+	}
+
+	auth, err := app.FindRecordById(record.GetString("auth_collection"), record.GetString("auth_record"))
+	if err != nil {
+		return e.Error(http.StatusUnauthorized, "Unknown or revoked API token", nil)
+	}
+	e.Auth = auth
+
+	if lastUsedAt := record.GetDateTime("last_used_at"); lastUsedAt.IsZero() || now.Sub(lastUsedAt.Time()) >= apiTokenLastUseInterval {
+		record.Set("last_used_at", now)
+		if err := app.Save(record); err != nil {
+			app.Logger().Error("failed to save last use of API token", "token", record.Id, "error", err)
+		}
+	}
+	return nil
+}
+
+// requiredAPITokenScope determines the scope an API token needs for a request.
+//
+// Parameters:
+// - request: The HTTP request
+//
+// Returns:
+// - The scope of the first matching route group, 'admin:*' if no group matches
+func requiredAPITokenScope(request *http.Request) string {
+	for _, route := range apiTokenRoutes {
+		if len(route.Methods) > 0 && !slices.Contains(route.Methods, request.Method) {
+			continue
+		}
+		for _, prefix := range route.Prefixes {
+			if strings.HasPrefix(request.URL.Path, prefix) {
+				return route.Scope
+			}
+		}
+	}
+	return "admin:*"
+}
+
+// grantsAPITokenScope checks whether the scopes of a token grant a required scope.
+// 'admin:*' grants every scope and 'clock:write' also grants 'clock:read'.
+//
+// Parameters:
+// - scopes: The scopes of the token
+// - required: The scope required for the request
+//
+// Returns:
+// - Whether the token may access the request
+func grantsAPITokenScope(scopes []string, required string) bool {
+	for _, scope := range scopes {
+		if scope == required || scope == "admin:*" || (scope == "clock:write" && required == "clock:read") {
+			return true
+		}
+	}
+	return false
+}
+
+// parseAPITokenScopes parses and validates the comma separated scopes of a new token.
+//
+// Parameters:
+// - value: The value of the 'scopes' parameter
+//
+// Returns:
+// - The distinct scopes
+// - An error if no scope or an unknown scope is given
+func parseAPITokenScopes(value string) ([]string, error) {
+	var scopes []string
+	for _, scope := range strings.Split(value, ",") {
+		scope = strings.TrimSpace(scope)
+		if scope == "" || slices.Contains(scopes, scope) {
+			continue
+		}
+		if !slices.Contains(apiTokenScopes, scope) {
+			return nil, fmt.Errorf("invalid scope '%s'. Expected one of: %s", scope, strings.Join(apiTokenScopes, ", "))
+		}
+		scopes = append(scopes, scope)
+	}
+
+	if len(scopes) == 0 {
+		return nil, fmt.Errorf("missing 'scopes' (string) parameter")
+	}
+	return scopes, nil
+}
+
+// createAPIToken generates a new API token and stores its hash.
+//
+// Parameters:
+// - app: The App interface (typically a PocketBase instance or transaction)
+// - auth: The user or superuser the token acts for
+// - name: The name describing where the token is used
+// - scopes: The scopes granted to the token
+// - expiresAt: The time after which the token is rejected, the zero time if it never expires
+//
+// Returns:
+// - The created api_tokens record
+// - The token, which cannot be retrieved again later
+// - An error if generating or saving the token fails
+func createAPIToken(app core.App, auth *core.Record, name string, scopes []string, expiresAt time.Time) (*core.Record, string, error) {
+	collection, err := app.FindCollectionByNameOrId("api_tokens")
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to find API tokens collection: %w", err)
+	}
+
+	tokenBytes := make([]byte, 32)
+	if _, err := rand.Read(tokenBytes); err != nil {
+		return nil, "", fmt.Errorf("failed to generate token: %w", err)
+	}
+	token := apiTokenPrefix + hex.EncodeToString(tokenBytes)
+
+	record := core.NewRecord(collection)
+	record.Set("name", name)
+	record.Set("token_hash", hashShortcutToken(token))
+	record.Set("scopes", scopes)
+	record.Set("auth_collection", auth.Collection().Name)
+	record.Set("auth_record", auth.Id)
+	if !expiresAt.IsZero() {
+		record.Set("expires_at", expiresAt)
+	}
+	if err := app.Save(record); err != nil {
+		return nil, "", fmt.Errorf("failed to save API token: %w", err)
+	}
+
+	return record, token, nil
+}
+
+// formatOptionalDateTime formats a date field of a record as RFC3339.
+//
+// Parameters:
+// - record: The record
+// - field: The name of the date field
+//
+// Returns:
+// - The formatted timestamp, empty if the field is not set
+func formatOptionalDateTime(record *core.Record, field string) string {
+	dateTime := record.GetDateTime(field)
+	if dateTime.IsZero() {
+		return ""
+	}
+	return dateTime.Time().Format(time.RFC3339)
+}
@@ -0,0 +1,114 @@
+package backend
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/yerTools/simple-frontend-stack/src/backend/backendtest"
+)
+
+func TestAPITokenScopes(t *testing.T) {
+	app := backendtest.NewApp(t)
+	RegisterAPITokensAPI(app)
+	RegisterWorkClockAPI(app)
+	RegisterWorkClockStatusAPI(app)
+	handler := backendtest.NewHandler(t, app)
+
+	user := backendtest.AddUser(t, app, "wallboard@example.com")
+	userToken, err := user.NewAuthToken()
+	if err != nil {
+		t.Fatalf("failed to create token: %v", err)
+	}
+
+	request := func(method string, path string, authorization string, body io.Reader) *httptest.ResponseRecorder {
+		request := httptest.NewRequest(method, path, body)
+		request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		if authorization != "" {
+			request.Header.Set("Authorization", authorization)
+		}
+
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, request)
+		return recorder
+	}
+	create := func(form url.Values) *httptest.ResponseRecorder {
+		return request(http.MethodPost, "/api/api_tokens", userToken, strings.NewReader(form.Encode()))
+	}
+
+	if recorder := create(url.Values{"name": {"Admin"}, "scopes": {"admin:*"}}); recorder.Code != http.StatusForbidden {
+		t.Errorf("expected users to be rejected creating admin tokens with 403, got %d", recorder.Code)
+	}
+	if recorder := create(url.Values{"name": {"Wallboard"}, "scopes": {"clock:rewrite"}}); recorder.Code != http.StatusBadRequest {
+		t.Errorf("expected an unknown scope to be rejected with 400, got %d", recorder.Code)
+	}
+
+	recorder := create(url.Values{"name": {"Wallboard"}, "scopes": {"clock:read, reports:read"}})
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", recorder.Code, recorder.Body.String())
+	}
+	var created struct {
+		ID    string `json:"id"`
+		Token string `json:"token"`
+	}
+	if err := json.Unmarshal(recorder.Body.Bytes(), &created); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	wallboard := "Bearer " + created.Token
+
+	if recorder := request(http.MethodGet, "/api/work_clock/status", wallboard, nil); recorder.Code != http.StatusOK {
+		t.Errorf("expected the token to read the status, got %d: %s", recorder.Code, recorder.Body.String())
+	}
+	if recorder := request(http.MethodPost, "/api/work_clock", wallboard, strings.NewReader("clock_in=true")); recorder.Code != http.StatusForbidden {
+		t.Errorf("expected the token to be rejected clocking in with 403, got %d", recorder.Code)
+	}
+	if recorder := request(http.MethodGet, "/api/work_clock/toggle", wallboard, nil); recorder.Code != http.StatusForbidden {
+		t.Errorf("expected the token to be rejected toggling with 403, got %d", recorder.Code)
+	}
+	if recorder := request(http.MethodGet, "/api/collections/work_clock/records", wallboard, nil); recorder.Code != http.StatusForbidden {
+		t.Errorf("expected the token to be rejected listing records with 403, got %d", recorder.Code)
+	}
+	for _, tc := range []struct {
+		method string
+		path   string
+		scope  string
+	}{
+		{http.MethodGet, "/api/teams/t1/overtime", "reports:read"},
+		{http.MethodPost, "/api/teams/t1/members", "admin:*"},
+		{http.MethodDelete, "/api/teams/t1", "admin:*"},
+	} {
+		if scope := requiredAPITokenScope(httptest.NewRequest(tc.method, tc.path, nil)); scope != tc.scope {
+			t.Errorf("expected %s %s to require the '%s' scope, got '%s'", tc.method, tc.path, tc.scope, scope)
+		}
+	}
+	if recorder := request(http.MethodGet, "/api/work_clock/status", "Bearer sfs_unknown", nil); recorder.Code != http.StatusUnauthorized {
+		t.Errorf("expected an unknown token to be rejected with 401, got %d", recorder.Code)
+	}
+
+	record, err := app.FindRecordById("api_tokens", created.ID)
+	if err != nil {
+		t.Fatalf("failed to find API token: %v", err)
+	}
+	if record.GetDateTime("last_used_at").IsZero() {
+		t.Errorf("expected the last use of the token to be recorded")
+	}
+	record.Set("expires_at", time.Now().Add(-time.Minute))
+	if err := app.Save(record); err != nil {
+		t.Fatalf("failed to save API token: %v", err)
+	}
+	if recorder := request(http.MethodGet, "/api/work_clock/status", wallboard, nil); recorder.Code != http.StatusUnauthorized {
+		t.Errorf("expected an expired token to be rejected with 401, got %d", recorder.Code)
+	}
+
+	if recorder := request(http.MethodDelete, "/api/api_tokens/"+created.ID, userToken, nil); recorder.Code != http.StatusNoContent {
+		t.Errorf("expected the token to be revoked, got %d", recorder.Code)
+	}
+	if _, err := app.FindRecordById("api_tokens", created.ID); err == nil {
+		t.Errorf("expected the revoked token to be deleted")
+	}
+}
@@ -0,0 +1,225 @@
+// Package backendtest provides helpers for testing the backend modules against an ephemeral
+// PocketBase instance. Each instance lives in a temporary directory with all migrations applied
+// and is removed when the test finishes, so tests neither need a running server nor share state.
+//
+// A typical test looks like:
+//
+//	func TestSomething(t *testing.T) {
+//		app := backendtest.NewApp(t)
+//		backendtest.AddRecords(t, app,
+//			backendtest.ClockIn("2025-04-01T09:00:00Z"),
+//			backendtest.ClockOut("2025-04-01T17:00:00Z"),
+//		)
+//
+//		// ... call the function under test with app ...
+//
+//		backendtest.AssertAlternating(t, app)
+//	}
+package backendtest
+
+import (
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/pocketbase/pocketbase"
+	"github.com/pocketbase/pocketbase/apis"
+	"github.com/pocketbase/pocketbase/core"
+
+	_ "github.com/yerTools/simple-frontend-stack/src/backend/migrations"
+	"github.com/yerTools/simple-frontend-stack/src/backend/pairing"
+)
+
+// Record describes a work clock record to create with AddRecords.
+type Record struct {
+	Timestamp time.Time // Timestamp of the record
+	ClockIn   bool      // true for a clock in record, false for a clock out record
+}
+
+// ClockIn describes a clock in record at an RFC3339 timestamp. It panics if the timestamp is invalid.
+func ClockIn(timestamp string) Record {
+	return Record{Timestamp: MustParseTime(timestamp), ClockIn: true}
+}
+
+// ClockOut describes a clock out record at an RFC3339 timestamp. It panics if the timestamp is invalid.
+func ClockOut(timestamp string) Record {
+	return Record{Timestamp: MustParseTime(timestamp), ClockIn: false}
+}
+
+// MustParseTime parses an RFC3339 timestamp and panics if it is invalid.
+func MustParseTime(timestamp string) time.Time {
+	parsed, err := time.Parse(time.RFC3339, timestamp)
+	if err != nil {
+		panic(err)
+	}
+	return parsed
+}
+
+// NewApp creates a PocketBase instance in a temporary directory with all migrations applied.
+// The instance is shut down and its directory removed when the test finishes.
+//
+// Parameters:
+// - tb: The test or benchmark using the instance
+//
+// Returns:
+// - The bootstrapped PocketBase instance
+func NewApp(tb testing.TB) *pocketbase.PocketBase {
+	tb.Helper()
+
+	app := pocketbase.NewWithConfig(pocketbase.Config{
+		DefaultDataDir:  tb.TempDir(),
+		HideStartBanner: true,
+	})
+
+	if err := app.Bootstrap(); err != nil {
+		tb.Fatalf("failed to bootstrap app: %v", err)
+	}
+	if err := app.RunAllMigrations(); err != nil {
+		tb.Fatalf("failed to run migrations: %v", err)
+	}
+
+	tb.Cleanup(func() {
+		_ = app.ResetBootstrapState()
+	})
+
+	return app
+}
+
+// NewHandler builds the HTTP handler of an instance, including all routes registered in its
+// OnServe hooks, without starting a server. Register the modules under test before calling it.
+//
+// Parameters:
+// - tb: The test or benchmark using the handler
+// - app: The PocketBase instance
+//
+// Returns:
+// - The handler serving the API of the instance, e.g. for use with httptest
+func NewHandler(tb testing.TB, app core.App) http.Handler {
+	tb.Helper()
+
+	router, err := apis.NewRouter(app)
+	if err != nil {
+		tb.Fatalf("failed to create router: %v", err)
+	}
+
+	var handler http.Handler
+	serveEvent := &core.ServeEvent{App: app, Router: router}
+	err = app.OnServe().Trigger(serveEvent, func(e *core.ServeEvent) error {
+		mux, err := e.Router.BuildMux()
+		handler = mux
+		return err
+	})
+	if err != nil {
+		tb.Fatalf("failed to build router: %v", err)
+	}
+
+	return handler
+}
+
+// AddRecords creates work clock records without any validation, so tests can set up
+// arbitrary (including invalid) sequences.
+//
+// Parameters:
+// - tb: The test or benchmark using the records
+// - app: The PocketBase instance
+// - records: The records to create
+//
+// Returns:
+// - The created records in the given order
+func AddRecords(tb testing.TB, app core.App, records ...Record) []*core.Record {
+	tb.Helper()
+
+	collection, err := app.FindCollectionByNameOrId("work_clock")
+	if err != nil {
+		tb.Fatalf("failed to find work clock collection: %v", err)
+	}
+
+	created := make([]*core.Record, 0, len(records))
+	for _, record := range records {
+		workClockRecord := core.NewRecord(collection)
+		workClockRecord.Set("timestamp", record.Timestamp)
+		workClockRecord.Set("clock_in", record.ClockIn)
+
+		if err := app.Save(workClockRecord); err != nil {
+			tb.Fatalf("failed to save work clock record at %s: %v", record.Timestamp.Format(time.RFC3339), err)
+		}
+		created = append(created, workClockRecord)
+	}
+
+	return created
+}
+
+// AddUser creates a user of the users collection with the password "password123".
+//
+// Parameters:
+// - tb: The test or benchmark using the user
+// - app: The PocketBase instance
+// - email: The email address of the user
+//
+// Returns:
+// - The created user
+func AddUser(tb testing.TB, app core.App, email string) *core.Record {
+	tb.Helper()
+
+	collection, err := app.FindCollectionByNameOrId("users")
+	if err != nil {
+		tb.Fatalf("failed to find users collection: %v", err)
+	}
+
+	user := core.NewRecord(collection)
+	user.SetEmail(email)
+	user.SetPassword("password123")
+	if err := app.Save(user); err != nil {
+		tb.Fatalf("failed to save user %s: %v", email, err)
+	}
+
+	return user
+}
+
+// Records returns all work clock records sorted by their timestamp.
+//
+// Parameters:
+// - tb: The test or benchmark using the records
+// - app: The PocketBase instance
+//
+// Returns:
+// - The records as Record values
+func Records(tb testing.TB, app core.App) []Record {
+	tb.Helper()
+
+	records, err := app.FindRecordsByFilter("work_clock", "", "+timestamp", 0, 0)
+	if err != nil {
+		tb.Fatalf("failed to find work clock records: %v", err)
+	}
+
+	result := make([]Record, 0, len(records))
+	for _, record := range records {
+		result = append(result, Record{
+			Timestamp: record.GetDateTime("timestamp").Time(),
+			ClockIn:   record.GetBool("clock_in"),
+		})
+	}
+
+	return result
+}
+
+// AssertAlternating fails the test if the work clock records do not alternate between
+// clock in and clock out, starting with a clock in record.
+//
+// Parameters:
+// - tb: The test or benchmark
+// - app: The PocketBase instance
+func AssertAlternating(tb testing.TB, app core.App) {
+	tb.Helper()
+
+	records := Records(tb, app)
+	pairingRecords := make([]pairing.Record, len(records))
+	for i, record := range records {
+		pairingRecords[i] = pairing.Record{ID: strconv.Itoa(i), Timestamp: record.Timestamp, ClockIn: record.ClockIn}
+	}
+
+	if _, issues := pairing.PairRecords(pairingRecords); len(issues) > 0 {
+		tb.Fatalf("record %s at %s breaks the alternation of clock in and clock out records (%s)", issues[0].RecordID, issues[0].Timestamp.Format(time.RFC3339Nano), issues[0].Code)
+	}
+}
@@ -0,0 +1,474 @@
+// Calendar Import Module for PocketBase
+//
+// This module imports meetings from a calendar as work clock sessions, which saves a lot of
+// manual entries on meeting-heavy days. The calendar is read from the ICS URL configured through
+// the integrations API or in CALENDAR_ICS_URL (Google Calendar provides one as "secret address in
+// iCal format"), so no OAuth client or service account is required.
+//
+// Importing is a two step process: the events of a range are listed first, so the user can
+// confirm which of them should be imported. The selected events are then created as clock
+// in/out pairs, with the event summary as session description and an optional project.
+//
+// Recurring events are not expanded, only their first occurrence is listed. All-day events
+// are skipped, since they do not describe working time.
+package backend
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pocketbase/pocketbase/core"
+)
+
+// calendarFetchTimeout is the maximum duration for downloading the calendar.
+const calendarFetchTimeout = 30 * time.Second
+
+// calendarMaxSize is the maximum size of the downloaded calendar in bytes.
+const calendarMaxSize = 20 * 1024 * 1024
+
+// CalendarConfig is the configuration of the calendar integration.
+type CalendarConfig struct {
+	ICSURL string `json:"ics_url"` // The iCalendar URL meetings are imported from
+}
+
+// validate checks that the ICS URL is a valid URL.
+func (c CalendarConfig) validate() error {
+	return validateIntegrationURL(c.ICSURL, "ics_url", "https", "http", "webcal")
+}
+
+// CalendarEvent is a timed event of the configured calendar.
+type CalendarEvent struct {
+	UID     string    `json:"uid"`     // Unique ID of the event within the calendar
+	Summary string    `json:"summary"` // Title of the event
+	Start   time.Time `json:"start"`   // Start of the event
+	End     time.Time `json:"end"`     // End of the event
+}
+
+// calendarImportRequest is the JSON body of the calendar import endpoint.
+type calendarImportRequest struct {
+	From      string   `json:"from"`       // Start of the range the events were listed for (RFC3339)
+	To        string   `json:"to"`         // End of the range the events were listed for (RFC3339)
+	UIDs      []string `json:"uids"`       // UIDs of the events to import
+	ProjectID string   `json:"project_id"` // Optional project of the imported sessions
+	Clock     string   `json:"clock"`      // Optional name of the clock of the imported sessions
+}
+
+// RegisterCalendarAPI registers the calendar import endpoints with the PocketBase server.
+// It creates the following routes:
+// - GET /api/calendar/events?from=&to= - Lists the timed events of the calendar starting within the range
+// - POST /api/calendar/import - Imports the selected events as sessions
+//
+// The import endpoint expects a JSON body like:
+//
+//	{
+//	  "from": "2025-04-21T00:00:00+02:00",
+//	  "to": "2025-04-22T00:00:00+02:00",
+//	  "uids": ["abc123@google.com", "def456@google.com"],
+//	  "project_id": "w8k3n2m5p7q9r1t",
+//	  "clock": "side-project"
+//	}
+//
+// Parameters:
+// - app: The PocketBase application instance
+func RegisterCalendarAPI(app core.App) {
+	app.OnServe().BindFunc(func(se *core.ServeEvent) error {
+		se.Router.GET("/api/calendar/events", func(e *core.RequestEvent) error {
+			from, to, err := parseTimeRangeParams(e.Request.URL.Query().Get("from"), e.Request.URL.Query().Get("to"))
+			if err != nil {
+				return e.Error(http.StatusBadRequest, err.Error(), nil)
+			}
+
+			events, err := fetchCalendarEvents(e.Request.Context(), app, from, to)
+			if err != nil {
+				return e.Error(http.StatusBadGateway, fmt.Sprintf("Failed to read calendar: %v", err), err)
+			}
+
+			return e.JSON(http.StatusOK, events)
+		})
+
+		se.Router.POST("/api/calendar/import", func(e *core.RequestEvent) error {
+			var request calendarImportRequest
+			if err := e.BindBody(&request); err != nil {
+				return e.Error(http.StatusBadRequest, "Invalid request body", err)
+			}
+
+			from, to, err := parseTimeRangeParams(request.From, request.To)
+			if err != nil {
+				return e.Error(http.StatusBadRequest, err.Error(), nil)
+			}
+			if len(request.UIDs) == 0 {
+				return e.Error(http.StatusBadRequest, "Missing 'uids' (string array) parameter", nil)
+			}
+
+			clockID, err := findClockID(app, request.Clock)
+			if err != nil {
+				return e.Error(http.StatusBadRequest, err.Error(), nil)
+			}
+
+			events, err := fetchCalendarEvents(e.Request.Context(), app, from, to)
+			if err != nil {
+				return e.Error(http.StatusBadGateway, fmt.Sprintf("Failed to read calendar: %v", err), err)
+			}
+
+			selectedEvents, err := selectCalendarEvents(events, request.UIDs)
+			if err != nil {
+				return e.Error(http.StatusBadRequest, err.Error(), nil)
+			}
+
+			for _, event := range selectedEvents {
+				if err := validateNotInFuture(e, fmt.Sprintf("event '%s'", event.UID), event.End); err != nil {
+					return err
+				}
+			}
+
+			run := startImportRun(e, "calendar", "")
+			if err := importCalendarEvents(e.Request.Context(), app, clockID, run.ID, selectedEvents, request.ProjectID); err != nil {
+				run.finish(app, err)
+				return e.Error(http.StatusInternalServerError, fmt.Sprintf("Failed to import calendar events: %v", err), err)
+			}
+
+			// Each imported event consists of a clock in and a clock out record
+			run.Records = 2 * len(selectedEvents)
+			run.finish(app, nil)
+			return callSucceeded(e)
+		}).Bind(trackedRequest("calendar_import"))
+
+		return se.Next()
+	})
+}
+
+// fetchCalendarEvents downloads the configured calendar and returns its timed events starting within a range.
+//
+// Parameters:
+// - ctx: The context of the request, used to cancel the download
+// - app: The App interface used to load the calendar configuration
+// - from: The start of the range (inclusive)
+// - to: The end of the range (exclusive)
+//
+// Returns:
+// - The events sorted by their start
+// - An error if no calendar is configured, or it could not be downloaded or parsed
+func fetchCalendarEvents(ctx context.Context, app core.App, from, to time.Time) ([]CalendarEvent, error) {
+	config, ok := integrationConfig[CalendarConfig](app, "calendar")
+	if !ok {
+		return nil, fmt.Errorf("no calendar configured, enable the calendar integration or set CALENDAR_ICS_URL")
+	}
+	icsURL := strings.Replace(config.ICSURL, "webcal://", "https://", 1)
+
+	ctx, cancel := context.WithTimeout(ctx, calendarFetchTimeout)
+	defer cancel()
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, icsURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	response, err := outboundClient().Do(request)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download calendar: %w", err)
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode < 200 || response.StatusCode >= 300 {
+		return nil, fmt.Errorf("calendar responded with status %d", response.StatusCode)
+	}
+
+	events, err := parseCalendarEvents(io.LimitReader(response.Body, calendarMaxSize))
+	if err != nil {
+		return nil, err
+	}
+
+	result := []CalendarEvent{}
+	for _, event := range events {
+		if !event.Start.Before(from) && event.Start.Before(to) {
+			result = append(result, event)
+		}
+	}
+
+	sort.Slice(result, func(a, b int) bool {
+		return result[a].Start.Before(result[b].Start)
+	})
+
+	return result, nil
+}
+
+// parseCalendarEvents parses the timed events of an iCalendar (RFC 5545) document.
+//
+// Parameters:
+// - reader: The iCalendar document
+//
+// Returns:
+// - The timed events of the document; all-day events and events without an end are skipped
+// - An error if the document could not be read
+func parseCalendarEvents(reader io.Reader) ([]CalendarEvent, error) {
+	var events []CalendarEvent
+	var event *CalendarEvent
+	var valid bool
+
+	lines, err := unfoldCalendarLines(reader)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, line := range lines {
+		name, params, value := splitCalendarLine(line)
+
+		switch {
+		case name == "BEGIN" && value == "VEVENT":
+			event = &CalendarEvent{}
+			valid = true
+		case event == nil:
+			continue
+		case name == "END" && value == "VEVENT":
+			if valid && event.UID != "" && !event.Start.IsZero() && event.End.After(event.Start) {
+				events = append(events, *event)
+			}
+			event = nil
+		case name == "UID":
+			event.UID = value
+		case name == "SUMMARY":
+			event.Summary = unescapeCalendarText(value)
+		case name == "DTSTART" || name == "DTEND":
+			timestamp, err := parseCalendarTime(params, value)
+			if err != nil {
+				// All-day or malformed events are skipped instead of failing the whole calendar
+				valid = false
+				continue
+			}
+
+			if name == "DTSTART" {
+				event.Start = timestamp
+			} else {
+				event.End = timestamp
+			}
+		}
+	}
+
+	return events, nil
+}
+
+// unfoldCalendarLines reads the content lines of an iCalendar document, joining folded lines.
+//
+// Parameters:
+// - reader: The iCalendar document
+//
+// Returns:
+// - The unfolded content lines
+// - An error if the document could not be read
+func unfoldCalendarLines(reader io.Reader) ([]string, error) {
+	var lines []string
+
+	// Folded lines are collected in a builder, so a line folded many times isn't copied for each fold
+	var current strings.Builder
+	started := false
+
+	scanner := bufio.NewScanner(reader)
+	scanner.Buffer(make([]byte, 64*1024), calendarMaxSize)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+
+		// Lines starting with a space or tab continue the previous line
+		if started && (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) {
+			current.WriteString(line[1:])
+			continue
+		}
+
+		if started {
+			lines = append(lines, current.String())
+			current.Reset()
+		}
+		current.WriteString(line)
+		started = true
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read calendar: %w", err)
+	}
+
+	if started {
+		lines = append(lines, current.String())
+	}
+
+	return lines, nil
+}
+
+// splitCalendarLine splits an iCalendar content line like "DTSTART;TZID=Europe/Berlin:20250421T090000".
+//
+// Parameters:
+// - line: The unfolded content line
+//
+// Returns:
+// - The upper case property name
+// - The property parameters by their upper case name
+// - The raw property value
+func splitCalendarLine(line string) (string, map[string]string, string) {
+	head, value, _ := strings.Cut(line, ":")
+
+	parts := strings.Split(head, ";")
+	params := map[string]string{}
+	for _, param := range parts[1:] {
+		key, paramValue, _ := strings.Cut(param, "=")
+		params[strings.ToUpper(key)] = strings.Trim(paramValue, `"`)
+	}
+
+	return strings.ToUpper(parts[0]), params, value
+}
+
+// parseCalendarTime parses the value of a DTSTART or DTEND property.
+//
+// Parameters:
+// - params: The property parameters, TZID selects the timezone of local times
+// - value: The property value, e.g. "20250421T090000Z" or "20250421T090000"
+//
+// Returns:
+// - The parsed time
+// - An error for all-day dates or invalid values
+func parseCalendarTime(params map[string]string, value string) (time.Time, error) {
+	if params["VALUE"] == "DATE" || len(value) == len("20060102") {
+		return time.Time{}, fmt.Errorf("all-day event")
+	}
+
+	if strings.HasSuffix(value, "Z") {
+		return time.Parse("20060102T150405Z", value)
+	}
+
+	location := time.Local
+	if timezone := params["TZID"]; timezone != "" {
+		if loadedLocation, err := time.LoadLocation(timezone); err == nil {
+			location = loadedLocation
+		}
+	}
+
+	return time.ParseInLocation("20060102T150405", value, location)
+}
+
+// unescapeCalendarText resolves the escape sequences of an iCalendar text value.
+//
+// Parameters:
+// - value: The escaped text value
+//
+// Returns:
+// - The unescaped text
+func unescapeCalendarText(value string) string {
+	return strings.NewReplacer(`\n`, " ", `\N`, " ", `\,`, ",", `\;`, ";", `\\`, `\`).Replace(value)
+}
+
+// selectCalendarEvents picks the events with the given UIDs.
+//
+// Parameters:
+// - events: The listed events
+// - uids: The UIDs of the events to select
+//
+// Returns:
+// - The selected events in the order of the listed events
+// - An error if an UID does not match any event within the range
+func selectCalendarEvents(events []CalendarEvent, uids []string) ([]CalendarEvent, error) {
+	eventsByUID := map[string]CalendarEvent{}
+	for _, event := range events {
+		eventsByUID[event.UID] = event
+	}
+
+	selected := map[string]struct{}{}
+	for _, uid := range uids {
+		if _, ok := eventsByUID[uid]; !ok {
+			return nil, fmt.Errorf("no event with uid '%s' within the range", uid)
+		}
+		selected[uid] = struct{}{}
+	}
+
+	var result []CalendarEvent
+	for _, event := range events {
+		if _, ok := selected[event.UID]; ok {
+			result = append(result, event)
+		}
+	}
+
+	return result, nil
+}
+
+// importCalendarEvents creates a session for each calendar event.
+//
+// Parameters:
+// - ctx: The context cancelling the operation, e.g. of the request
+// - app: The App interface (typically a PocketBase instance or transaction)
+// - clockID: The ID of the clock of the sessions, an empty string for the default clock
+// - importRunID: The ID of the import run, the created records are tagged with
+// - events: The events to import, sorted by their start
+// - projectID: The ID of the project of the sessions, an empty string creates sessions without project
+//
+// Returns:
+// - An error if the project does not exist, the operation fails, or if an event overlaps
+// an existing session (including one starting at the same time) or another imported event
+//
+// The operation is performed within a single transaction, so either all or none of the events are imported.
+func importCalendarEvents(ctx context.Context, app core.App, clockID string, importRunID string, events []CalendarEvent, projectID string) error {
+	unlock, err := workClockServiceOf(app).WithContext(ctx).lock(clockID)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	err = app.RunInTransaction(func(txApp core.App) error {
+		if projectID != "" {
+			if _, err := txApp.FindRecordById("projects", projectID); err != nil {
+				return fmt.Errorf("failed to find project with id '%s': %w", projectID, err)
+			}
+		}
+
+		collection, err := txApp.FindCollectionByNameOrId("work_clock")
+		if err != nil {
+			return fmt.Errorf("failed to find work clock collection: %w", err)
+		}
+
+		var recordIDs []string
+		for _, event := range events {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+
+			clockInRecord, err := createImportedWorkClockRecord(txApp, collection, clockID, importRunID, event.Start, true)
+			if err != nil {
+				return fmt.Errorf("failed to create clock in record of event '%s': %w", event.UID, err)
+			}
+
+			// An existing clock in at the start of the event belongs to a session outside of the import.
+			// It must not be changed, since a rollback would keep it with the values of the event
+			if clockInRecord.GetString("import_run") != importRunID {
+				return fmt.Errorf("event '%s' starts at the same time as the existing session with clock in id '%s'", event.UID, clockInRecord.Id)
+			}
+
+			clockInRecord.Set("description", event.Summary)
+			clockInRecord.Set("project", projectID)
+			if err := txApp.Save(clockInRecord); err != nil {
+				return fmt.Errorf("failed to save clock in record of event '%s': %w", event.UID, err)
+			}
+
+			clockOutRecord, err := createImportedWorkClockRecord(txApp, collection, clockID, importRunID, event.End, false)
+			if err != nil {
+				return fmt.Errorf("failed to create clock out record of event '%s': %w", event.UID, err)
+			}
+
+			recordIDs = append(recordIDs, clockInRecord.Id, clockOutRecord.Id)
+		}
+
+		for _, recordID := range recordIDs {
+			if err := checkValidity(txApp, recordID); err != nil {
+				return fmt.Errorf("imported work clock record with id '%s' is not valid: %w", recordID, err)
+			}
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return fmt.Errorf("failed to import %d calendar events: %w", len(events), err)
+	}
+
+	return nil
+}
@@ -0,0 +1,95 @@
+package backend
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/yerTools/simple-frontend-stack/src/backend/backendtest"
+)
+
+const testCalendar = "BEGIN:VCALENDAR\r\n" +
+	"BEGIN:VEVENT\r\n" +
+	"UID:planning@example.com\r\n" +
+	"SUMMARY:Sprint planning\\, team\r\n" +
+	"  A\r\n" +
+	"DTSTART:20250421T090000Z\r\n" +
+	"DTEND:20250421T103000Z\r\n" +
+	"END:VEVENT\r\n" +
+	"BEGIN:VEVENT\r\n" +
+	"UID:holiday@example.com\r\n" +
+	"SUMMARY:Holiday\r\n" +
+	"DTSTART;VALUE=DATE:20250422\r\n" +
+	"DTEND;VALUE=DATE:20250423\r\n" +
+	"END:VEVENT\r\n" +
+	"END:VCALENDAR\r\n"
+
+func TestParseCalendarEvents(t *testing.T) {
+	events, err := parseCalendarEvents(strings.NewReader(testCalendar))
+	if err != nil {
+		t.Fatalf("failed to parse calendar: %v", err)
+	}
+
+	if len(events) != 1 {
+		t.Fatalf("expected the all-day event to be skipped, got %d events", len(events))
+	}
+
+	event := events[0]
+	if event.UID != "planning@example.com" || event.Summary != "Sprint planning, team A" {
+		t.Errorf("unexpected event: %+v", event)
+	}
+	if !event.Start.Equal(backendtest.MustParseTime("2025-04-21T09:00:00Z")) || !event.End.Equal(backendtest.MustParseTime("2025-04-21T10:30:00Z")) {
+		t.Errorf("unexpected event times: %s - %s", event.Start, event.End)
+	}
+}
+
+func TestImportCalendarEventsKeepsExistingSessions(t *testing.T) {
+	app := backendtest.NewApp(t)
+
+	// The user clocked in at the start of the meeting
+	records := backendtest.AddRecords(t, app, backendtest.ClockIn("2025-04-21T09:00:00Z"))
+	records[0].Set("description", "Own work")
+	if err := app.Save(records[0]); err != nil {
+		t.Fatalf("failed to save description: %v", err)
+	}
+
+	events := []CalendarEvent{{
+		UID:     "planning@example.com",
+		Summary: "Sprint planning",
+		Start:   backendtest.MustParseTime("2025-04-21T09:00:00Z"),
+		End:     backendtest.MustParseTime("2025-04-21T10:30:00Z"),
+	}}
+	if err := importCalendarEvents(t.Context(), app, "", "calendarrun0001", events, ""); err == nil {
+		t.Fatal("expected an event starting with an existing session to be rejected")
+	}
+
+	record, err := app.FindRecordById("work_clock", records[0].Id)
+	if err != nil {
+		t.Fatalf("failed to find existing record: %v", err)
+	}
+	if record.GetString("description") != "Own work" || record.GetString("import_run") != "" {
+		t.Errorf("expected the existing record to be unchanged, got description %q and import run %q", record.GetString("description"), record.GetString("import_run"))
+	}
+	if records := backendtest.Records(t, app); len(records) != 1 {
+		t.Errorf("expected no imported records, got %d records", len(records))
+	}
+}
+
+func FuzzParseCalendarEvents(f *testing.F) {
+	f.Add(testCalendar)
+	f.Add("BEGIN:VEVENT\nUID:x\nDTSTART;TZID=\"Europe/Berlin\":20250421T090000\nDTEND;TZID=../../etc/passwd:20250421T100000\nEND:VEVENT")
+	f.Add("BEGIN:VEVENT\n \n\t\nDTSTART:2025\nEND:VEVENT\nEND:VEVENT")
+	f.Add(";;;:\n:\n=")
+
+	f.Fuzz(func(t *testing.T, data string) {
+		events, err := parseCalendarEvents(strings.NewReader(data))
+		if err != nil {
+			return
+		}
+
+		for _, event := range events {
+			if event.UID == "" || !event.End.After(event.Start) {
+				t.Fatalf("parsed an invalid event: %+v", event)
+			}
+		}
+	})
+}
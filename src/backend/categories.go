@@ -0,0 +1,197 @@
+// Session Categories Module for PocketBase
+//
+// This module classifies sessions that are not regular work, such as on-call standby or travel.
+// Classified sessions are tracked like any other session, but reported separately, and only a
+// configurable share of their duration counts as work time (see Settings.OnCallFactor and
+// Settings.TravelFactor), as required by common compensation and travel policies. Sessions
+// without a category are regular work and count completely.
+package backend
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/pocketbase/pocketbase/core"
+)
+
+// sessionCategories are the categories a session can be classified as, with the functions
+// returning the share of their duration that counts as work time in percent.
+var sessionCategories = map[string]func() float64{
+	"on_call": func() float64 { return settings.OnCallFactor },
+	"travel":  func() float64 { return settings.TravelFactor },
+}
+
+// CategoryReportEntry contains the time spent on sessions of a specific category.
+type CategoryReportEntry struct {
+	Category        string  `json:"category"`         // Category of the sessions, empty for regular work
+	Factor          float64 `json:"factor"`           // Share of the duration that counts as work time in percent
+	DurationSeconds int64   `json:"duration_seconds"` // Total duration of the sessions of this category
+	Duration        string  `json:"duration"`         // Total duration formatted in the configured duration format
+	CountedSeconds  int64   `json:"counted_seconds"`  // Duration that counts as work time
+	Counted         string  `json:"counted"`          // Counted duration formatted in the configured duration format
+	Sessions        int     `json:"sessions"`         // Number of sessions of this category
+}
+
+// CategoryReport is the response of the category report endpoint.
+type CategoryReport struct {
+	From           time.Time             `json:"from"`            // Start of the reported range
+	To             time.Time             `json:"to"`              // End of the reported range
+	Categories     []CategoryReportEntry `json:"categories"`      // Time per category, regular work first
+	CountedSeconds int64                 `json:"counted_seconds"` // Total duration that counts as work time
+	Counted        string                `json:"counted"`         // Total counted duration formatted in the configured duration format
+}
+
+// RegisterCategoriesAPI registers the category endpoints with the PocketBase server.
+// It creates the following routes:
+// - POST /api/work_clock/category - Sets the 'category' of a session, by default of the open session
+// - GET /api/work_clock/report/categories?from=&to=&clock= - Aggregates the time per category for sessions starting within the range, supports conditional requests
+//
+// Parameters:
+// - app: The PocketBase application instance
+func RegisterCategoriesAPI(app core.App) {
+	app.OnServe().BindFunc(func(se *core.ServeEvent) error {
+		se.Router.POST("/api/work_clock/category", func(e *core.RequestEvent) error {
+			// An empty clock in ID refers to the currently open session
+			clockInID := e.Request.FormValue("clock_in_id")
+
+			// An empty category classifies the session as regular work
+			category := e.Request.FormValue("category")
+			if _, ok := sessionCategories[category]; !ok && category != "" {
+				return e.Error(http.StatusBadRequest, fmt.Sprintf("Invalid 'category' (string) parameter. Expected one of: '%s'", strings.Join(slices.Sorted(maps.Keys(sessionCategories)), "', '")), nil)
+			}
+
+			clockID, err := requestClock(app, e)
+			if err != nil {
+				return err
+			}
+
+			if err := setSessionCategory(e.Request.Context(), app, clockID, clockInID, category); err != nil {
+				return e.Error(http.StatusInternalServerError, fmt.Sprintf("Failed to set category of session: %v", err), err)
+			}
+			return callSucceeded(e)
+		})
+
+		se.Router.GET("/api/work_clock/report/categories", func(e *core.RequestEvent) error {
+			from, to, err := parseTimeRangeParams(e.Request.URL.Query().Get("from"), e.Request.URL.Query().Get("to"))
+			if err != nil {
+				return e.Error(http.StatusBadRequest, err.Error(), nil)
+			}
+
+			clockID, err := requestClock(app, e)
+			if err != nil {
+				return err
+			}
+
+			cacheKey := fmt.Sprintf("categories|%s|%s|%s", clockID, from.Format(time.RFC3339Nano), to.Format(time.RFC3339Nano))
+			report, err := cachedReport(app, cacheKey, func(now time.Time) (*CategoryReport, error) {
+				return getCategoryReport(app, clockID, from, to, now)
+			})
+			if err != nil {
+				return e.Error(http.StatusInternalServerError, fmt.Sprintf("Failed to create category report: %v", err), err)
+			}
+
+			return respondConditionalJSON(e, report, workClockLastModified(app))
+		})
+
+		return se.Next()
+	})
+}
+
+// categoryFactor returns the share of a session's duration that counts as work time.
+//
+// Parameters:
+// - category: The category of the session, empty for regular work
+//
+// Returns:
+// - The factor the duration is multiplied with, 1 for regular work and unknown categories
+func categoryFactor(category string) float64 {
+	factor, ok := sessionCategories[category]
+	if !ok {
+		return 1
+	}
+	return factor() / 100
+}
+
+// setSessionCategory classifies the session started by a clock in record.
+//
+// Parameters:
+// - ctx: The context cancelling the operation, e.g. of the request
+// - app: The App interface (typically a PocketBase instance or transaction)
+// - clockID: The ID of the clock of the open session, only used if clockInID is empty
+// - clockInID: The ID of the clock in record starting the session, an empty string refers to the open session
+// - category: The category of the session, an empty string classifies it as regular work
+//
+// Returns:
+// - An error if the record is not a clock in record, there is no open session, or saving fails
+func setSessionCategory(ctx context.Context, app core.App, clockID string, clockInID string, category string) error {
+	unlock, err := workClockServiceOf(app).WithContext(ctx).lockSession(clockID, clockInID)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	record, err := findClockInRecord(app, clockID, clockInID)
+	if err != nil {
+		return err
+	}
+
+	record.Set("category", category)
+	if err := app.Save(record); err != nil {
+		return fmt.Errorf("failed to save work clock record: %w", err)
+	}
+
+	return nil
+}
+
+// getCategoryReport aggregates the time per category for all sessions of a clock starting within a range.
+//
+// Parameters:
+// - app: The App interface (typically a PocketBase instance or transaction)
+// - clockID: The ID of the clock, an empty string for the default clock
+// - from: The start of the range (inclusive)
+// - to: The end of the range (exclusive)
+// - now: The reference time used as end of an open session
+//
+// Returns:
+// - The category report
+// - An error if the sessions could not be retrieved
+func getCategoryReport(app core.App, clockID string, from, to, now time.Time) (*CategoryReport, error) {
+	sessions, err := findWorkSessions(app, clockID, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := map[string]*CategoryReportEntry{}
+	for _, session := range sessions {
+		category := session.ClockIn.GetString("category")
+
+		entry, ok := entries[category]
+		if !ok {
+			entry = &CategoryReportEntry{Category: category, Factor: categoryFactor(category) * 100}
+			entries[category] = entry
+		}
+		entry.DurationSeconds += int64(session.Duration(now).Seconds())
+		entry.Sessions++
+	}
+
+	report := &CategoryReport{From: from, To: to, Categories: []CategoryReportEntry{}}
+
+	// Regular work has the empty category, so it is sorted first
+	for _, category := range slices.Sorted(maps.Keys(entries)) {
+		entry := entries[category]
+		entry.CountedSeconds = int64(float64(entry.DurationSeconds) * entry.Factor / 100)
+		entry.Duration = formatResponseDuration(entry.DurationSeconds)
+		entry.Counted = formatResponseDuration(entry.CountedSeconds)
+
+		report.CountedSeconds += entry.CountedSeconds
+		report.Categories = append(report.Categories, *entry)
+	}
+	report.Counted = formatResponseDuration(report.CountedSeconds)
+
+	return report, nil
+}
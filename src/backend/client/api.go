@@ -0,0 +1,260 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// Status is the state of a clock, see GET /api/work_clock/status.
+type Status struct {
+	ClockedIn         bool       `json:"clocked_in"`          // Whether a session is currently open
+	Since             *time.Time `json:"since"`               // Timestamp of the latest work clock record
+	ClockInID         string     `json:"clock_in_id"`         // ID of the clock in record of the open session
+	Description       string     `json:"description"`         // Description of the open session
+	DurationSeconds   int64      `json:"duration_seconds"`    // Duration of the open session so far
+	Duration          string     `json:"duration"`            // Duration formatted in the configured duration format
+	Stale             bool       `json:"stale"`               // Whether the open session is longer than a workday
+	SuggestedClockOut *time.Time `json:"suggested_clock_out"` // Suggested end of a stale session
+}
+
+// Session is a session of a clock, see GET /api/work_clock/sessions.
+type Session struct {
+	ClockInID       string     `json:"clock_in_id"`      // ID of the clock in record starting the session
+	ClockOutID      string     `json:"clock_out_id"`     // ID of the clock out record, empty for an open session
+	ClockID         string     `json:"clock_id"`         // ID of the clock of the session, empty for the default clock
+	Start           time.Time  `json:"start"`            // Start of the session
+	End             *time.Time `json:"end"`              // End of the session, nil for an open session
+	DurationSeconds int64      `json:"duration_seconds"` // Duration of the session, open sessions last until now
+	Duration        string     `json:"duration"`         // Duration formatted in the configured duration format
+	Description     string     `json:"description"`      // Description of the session
+	ProjectID       string     `json:"project_id"`       // ID of the project of the session
+	TagIDs          []string   `json:"tag_ids"`          // IDs of the tags of the session
+	Issue           string     `json:"issue"`            // Issue reference of the session
+	Category        string     `json:"category"`         // Category of the session, empty for regular work
+}
+
+// SessionIssue is a record that can't be paired into a proper session.
+type SessionIssue struct {
+	Code      string    `json:"code"`      // 'orphan_clock_out' or 'overlapping_session'
+	RecordID  string    `json:"record_id"` // ID of the affected record
+	Timestamp time.Time `json:"timestamp"` // Timestamp of the affected record
+}
+
+// SessionsPage is a page of sessions.
+type SessionsPage struct {
+	Sessions   []Session      `json:"sessions"`    // Sessions of the page, sorted by their start
+	Issues     []SessionIssue `json:"issues"`      // Records of the page that can't be paired properly
+	NextCursor string         `json:"next_cursor"` // Cursor of the next page, empty on the last page
+}
+
+// DailySummary is the summary of a day, see GET /api/work_clock/report/daily.
+type DailySummary struct {
+	Date            string `json:"date"`             // Day of the summary (YYYY-MM-DD)
+	WorkedSeconds   int64  `json:"worked_seconds"`   // Time that counts as work time
+	Worked          string `json:"worked"`           // Worked time formatted in the configured duration format
+	BreakSeconds    int64  `json:"break_seconds"`    // Time between the sessions of the day
+	TargetSeconds   int64  `json:"target_seconds"`   // Time that should be worked on the day
+	OvertimeSeconds int64  `json:"overtime_seconds"` // Worked minus target time, negative for undertime
+	Overtime        string `json:"overtime"`         // Overtime formatted in the configured duration format
+	BalanceSeconds  int64  `json:"balance_seconds"`  // Overtime accumulated up to and including the day
+	Balance         string `json:"balance"`          // Balance formatted in the configured duration format
+	Sessions        int    `json:"sessions"`         // Number of closed sessions starting on the day
+}
+
+// PresenceClock is the state of a clock owned by a queried user.
+type PresenceClock struct {
+	Clock     string     `json:"clock"`      // Name of the clock
+	ClockedIn bool       `json:"clocked_in"` // Whether a session is currently open
+	Since     *time.Time `json:"since"`      // Timestamp of the latest work clock record, nil without records
+	Stale     bool       `json:"stale"`      // Whether the open session is longer than a workday
+}
+
+// PresenceEntry is the state of a queried user, see POST /api/presence/query.
+type PresenceEntry struct {
+	User    string          `json:"user"`    // ID of the user
+	Found   bool            `json:"found"`   // Whether the user exists and is visible to the requester
+	Present bool            `json:"present"` // Whether any clock of the user is clocked in
+	Since   *time.Time      `json:"since"`   // Start of the earliest open session, nil if not present
+	Clocks  []PresenceClock `json:"clocks"`  // States of the clocks owned by the user, sorted by name
+}
+
+// ClockInRequest describes a clock in.
+type ClockInRequest struct {
+	Clock          string // Name of the clock, empty for the default clock
+	ConfirmAbsence bool   // Whether to clock in on a vacation or sick day
+}
+
+// ClockOutRequest describes a clock out.
+type ClockOutRequest struct {
+	Clock   string    // Name of the clock, empty for the default clock
+	Confirm bool      // Whether to confirm a session longer than the maximum session duration
+	End     time.Time // Actual end of the session, zero for now
+}
+
+// SessionsQuery selects the sessions of a clock.
+type SessionsQuery struct {
+	Clock  string    // Name of the clock, empty for the default clock
+	From   time.Time // Start of the range (inclusive), zero for unbounded
+	To     time.Time // End of the range (exclusive), zero for unbounded
+	Cursor string    // Cursor of the page, empty for the first page
+	Limit  int       // Maximum number of sessions of the page, zero for the default of the server
+}
+
+// ClockIn opens a session.
+//
+// Parameters:
+// - ctx: The context of the request
+// - r: The clock in
+//
+// Returns:
+// - An APIError if the clock is already clocked in, doesn't exist or the day is an absence
+func (c *Client) ClockIn(ctx context.Context, r ClockInRequest) error {
+	form := url.Values{"clock_in": {"true"}, "clock": {r.Clock}}
+	if r.ConfirmAbsence {
+		form.Set("confirm_absence", "true")
+	}
+	return c.do(ctx, request{method: http.MethodPost, path: "/api/work_clock", form: form}, nil)
+}
+
+// ClockOut closes the open session.
+//
+// Parameters:
+// - ctx: The context of the request
+// - r: The clock out
+//
+// Returns:
+// - An APIError if the clock is not clocked in, or with status 409 if the session is too long to be
+// closed without confirmation or end
+func (c *Client) ClockOut(ctx context.Context, r ClockOutRequest) error {
+	form := url.Values{"clock_in": {"false"}, "clock": {r.Clock}}
+	if r.Confirm {
+		form.Set("confirm", "true")
+	}
+	if !r.End.IsZero() {
+		form.Set("end", r.End.Format(time.RFC3339))
+	}
+	return c.do(ctx, request{method: http.MethodPost, path: "/api/work_clock", form: form}, nil)
+}
+
+// Status returns the state of a clock.
+//
+// Parameters:
+// - ctx: The context of the request
+// - clock: The name of the clock, empty for the default clock
+//
+// Returns:
+// - The state of the clock
+// - An error if the request fails
+func (c *Client) Status(ctx context.Context, clock string) (Status, error) {
+	var status Status
+	err := c.do(ctx, request{method: http.MethodGet, path: "/api/work_clock/status", query: clockQuery(clock), idempotent: true}, &status)
+	return status, err
+}
+
+// Sessions returns a page of the sessions of a clock.
+//
+// Parameters:
+// - ctx: The context of the request
+// - q: The query, with the cursor of the previous page for further pages
+//
+// Returns:
+// - The page
+// - An error if the request fails
+func (c *Client) Sessions(ctx context.Context, q SessionsQuery) (SessionsPage, error) {
+	query := clockQuery(q.Clock)
+	setTime(query, "from", q.From)
+	setTime(query, "to", q.To)
+	if q.Cursor != "" {
+		query.Set("cursor", q.Cursor)
+	}
+	if q.Limit > 0 {
+		query.Set("limit", strconv.Itoa(q.Limit))
+	}
+
+	var page SessionsPage
+	err := c.do(ctx, request{method: http.MethodGet, path: "/api/work_clock/sessions", query: query, idempotent: true}, &page)
+	return page, err
+}
+
+// AllSessions returns all sessions of a clock, requesting page after page.
+//
+// Parameters:
+// - ctx: The context of the requests
+// - q: The query, starting at its cursor
+//
+// Returns:
+// - The sessions sorted by their start
+// - An error if a request fails
+func (c *Client) AllSessions(ctx context.Context, q SessionsQuery) ([]Session, error) {
+	sessions := []Session{}
+	for {
+		page, err := c.Sessions(ctx, q)
+		if err != nil {
+			return nil, err
+		}
+		sessions = append(sessions, page.Sessions...)
+
+		if page.NextCursor == "" {
+			return sessions, nil
+		}
+		q.Cursor = page.NextCursor
+	}
+}
+
+// DailySummaries returns the summaries of the days within a range.
+//
+// Parameters:
+// - ctx: The context of the request
+// - clock: The name of the clock, empty for the default clock
+// - from: The start of the range (inclusive)
+// - to: The end of the range (exclusive)
+//
+// Returns:
+// - The summaries sorted by their date
+// - An error if the request fails
+func (c *Client) DailySummaries(ctx context.Context, clock string, from, to time.Time) ([]DailySummary, error) {
+	query := clockQuery(clock)
+	setTime(query, "from", from)
+	setTime(query, "to", to)
+
+	var summaries []DailySummary
+	err := c.do(ctx, request{method: http.MethodGet, path: "/api/work_clock/report/daily", query: query, idempotent: true}, &summaries)
+	return summaries, err
+}
+
+// Presence returns which of the users are currently clocked in.
+//
+// Parameters:
+// - ctx: The context of the request
+// - users: The IDs of the users
+//
+// Returns:
+// - The state of each user, in the order of users
+// - An error if the request fails
+func (c *Client) Presence(ctx context.Context, users ...string) ([]PresenceEntry, error) {
+	body := map[string][]string{"users": users}
+
+	// The query doesn't change anything, so it is retried like a GET request
+	var entries []PresenceEntry
+	err := c.do(ctx, request{method: http.MethodPost, path: "/api/presence/query", json: body, idempotent: true}, &entries)
+	return entries, err
+}
+
+// clockQuery creates the query parameters selecting a clock.
+func clockQuery(clock string) url.Values {
+	query := url.Values{}
+	if clock != "" {
+		query.Set("clock", clock)
+	}
+	return query
+}
+
+// setTime sets a timestamp query parameter, zero timestamps are left out.
+func setTime(query url.Values, name string, value time.Time) {
+	if !value.IsZero() {
+		query.Set(name, value.Format(time.RFC3339Nano))
+	}
+}
@@ -0,0 +1,255 @@
+// Package client is a Go client of the work clock HTTP API, so tools can clock in and out and
+// query sessions, reports and presence without hand-writing requests.
+//
+//	c := client.New("https://clock.example.com", client.WithToken("sfs_..."))
+//	if err := c.ClockIn(ctx, client.ClockInRequest{Clock: "alice"}); err != nil {
+//		...
+//	}
+//	status, err := c.Status(ctx, "alice")
+//
+// Requests are authenticated with an API token (see the API tokens module) or a PocketBase auth
+// token. Failed requests are retried with exponential backoff, honoring Retry-After:
+// - Queries are retried after network errors and 429, 502, 503 and 504 responses
+// - Clock changes are only retried if the request didn't reach the server or was rejected with
+// 429 or 503. The server refuses to clock in twice, so a retried clock change can't create a
+// duplicate record, but it can fail with "already clocked in" if the first attempt went through.
+//
+// The package has no dependency on PocketBase or the backend, so tools can import it without
+// pulling in the server.
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Defaults of the retries.
+const (
+	DefaultMaxRetries = 3
+	DefaultRetryDelay = 500 * time.Millisecond
+)
+
+// maxRetryDelay is the maximum delay between two attempts, also for longer Retry-After values.
+const maxRetryDelay = 30 * time.Second
+
+// APIError is an error response of the server.
+type APIError struct {
+	StatusCode int    // HTTP status code of the response
+	Message    string // Message of the server
+}
+
+// Error implements the error interface.
+func (e *APIError) Error() string {
+	return fmt.Sprintf("%d %s: %s", e.StatusCode, http.StatusText(e.StatusCode), e.Message)
+}
+
+// Client is a client of the work clock API. It is safe for concurrent use.
+type Client struct {
+	baseURL    string        // URL of the server without trailing slash
+	token      string        // Token sent as bearer token, empty for anonymous requests
+	httpClient *http.Client  // Client sending the requests
+	maxRetries int           // Number of retries after the first attempt
+	retryDelay time.Duration // Delay before the first retry, doubled for every further retry
+}
+
+// Option configures a Client.
+type Option func(*Client)
+
+// WithToken authenticates the requests with an API token or a PocketBase auth token.
+func WithToken(token string) Option {
+	return func(c *Client) {
+		c.token = token
+	}
+}
+
+// WithHTTPClient sends the requests with a custom HTTP client, e.g. with a timeout or proxy.
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(c *Client) {
+		c.httpClient = httpClient
+	}
+}
+
+// WithRetries configures the retries: the number of retries after the first attempt (0 disables
+// them) and the delay before the first retry, which doubles with every further retry.
+func WithRetries(maxRetries int, delay time.Duration) Option {
+	return func(c *Client) {
+		c.maxRetries = max(maxRetries, 0)
+		c.retryDelay = delay
+	}
+}
+
+// New creates a client.
+//
+// Parameters:
+// - baseURL: The URL of the server, e.g. "https://clock.example.com"
+// - options: Options such as WithToken
+//
+// Returns:
+// - The client
+func New(baseURL string, options ...Option) *Client {
+	c := &Client{
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		httpClient: http.DefaultClient,
+		maxRetries: DefaultMaxRetries,
+		retryDelay: DefaultRetryDelay,
+	}
+	for _, option := range options {
+		option(c)
+	}
+	return c
+}
+
+// request describes a request to the API.
+type request struct {
+	method     string     // HTTP method
+	path       string     // Path of the endpoint, e.g. "/api/work_clock/status"
+	query      url.Values // Query parameters, nil for none
+	form       url.Values // Form body, nil for none
+	json       any        // JSON body, nil for none
+	idempotent bool       // Whether the request can be repeated without side effects
+}
+
+// do sends a request, retrying it where safe, and decodes the JSON response.
+//
+// Parameters:
+// - ctx: The context of the request, which also cancels the retries
+// - r: The request
+// - result: The value the response is decoded into, nil to discard the response
+//
+// Returns:
+// - An APIError for error responses, or the error of the last attempt
+func (c *Client) do(ctx context.Context, r request, result any) error {
+	var body []byte
+	contentType := ""
+	switch {
+	case r.form != nil:
+		body, contentType = []byte(r.form.Encode()), "application/x-www-form-urlencoded"
+	case r.json != nil:
+		var err error
+		if body, err = json.Marshal(r.json); err != nil {
+			return err
+		}
+		contentType = "application/json"
+	}
+
+	target := c.baseURL + r.path
+	if len(r.query) > 0 {
+		target += "?" + r.query.Encode()
+	}
+
+	for attempt := 0; ; attempt++ {
+		request, err := http.NewRequestWithContext(ctx, r.method, target, bytes.NewReader(body))
+		if err != nil {
+			return err
+		}
+		if contentType != "" {
+			request.Header.Set("Content-Type", contentType)
+		}
+		request.Header.Set("Accept", "application/json")
+		if c.token != "" {
+			request.Header.Set("Authorization", "Bearer "+c.token)
+		}
+
+		response, err := c.httpClient.Do(request)
+		var retryAfter time.Duration
+		if err == nil {
+			retryAfter, err = decodeResponse(response, result)
+		}
+		if err == nil || attempt >= c.maxRetries || !retryable(err, r.idempotent) {
+			return err
+		}
+
+		delay := c.retryDelay << attempt
+		if retryAfter > 0 {
+			delay = retryAfter
+		}
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(min(delay, maxRetryDelay)):
+		}
+	}
+}
+
+// decodeResponse decodes a response and closes its body.
+//
+// Parameters:
+// - response: The response
+// - result: The value a successful response is decoded into, nil to discard it
+//
+// Returns:
+// - The delay requested with Retry-After, zero if none
+// - An APIError for error responses, or an error if decoding fails
+func decodeResponse(response *http.Response, result any) (time.Duration, error) {
+	defer response.Body.Close()
+
+	if response.StatusCode >= 400 {
+		apiErr := &APIError{StatusCode: response.StatusCode}
+		var body struct {
+			Message string `json:"message"`
+		}
+		data, _ := io.ReadAll(io.LimitReader(response.Body, 64*1024))
+		if json.Unmarshal(data, &body) == nil && body.Message != "" {
+			apiErr.Message = body.Message
+		} else {
+			apiErr.Message = strings.TrimSpace(string(data))
+		}
+
+		var retryAfter time.Duration
+		if seconds, err := strconv.Atoi(response.Header.Get("Retry-After")); err == nil && seconds > 0 {
+			retryAfter = time.Duration(seconds) * time.Second
+		}
+		return retryAfter, apiErr
+	}
+
+	if result == nil || response.StatusCode == http.StatusNoContent {
+		_, _ = io.Copy(io.Discard, response.Body)
+		return 0, nil
+	}
+	if err := json.NewDecoder(response.Body).Decode(result); err != nil {
+		return 0, fmt.Errorf("failed to decode response: %w", err)
+	}
+	return 0, nil
+}
+
+// retryable reports whether a failed request can be retried.
+//
+// Parameters:
+// - err: The error of the attempt
+// - idempotent: Whether the request can be repeated without side effects
+//
+// Returns:
+// - Whether the request should be retried
+func retryable(err error, idempotent bool) bool {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		switch apiErr.StatusCode {
+		case http.StatusTooManyRequests, http.StatusServiceUnavailable:
+			return true
+		case http.StatusBadGateway, http.StatusGatewayTimeout:
+			return idempotent
+		}
+		return false
+	}
+
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	if idempotent {
+		return true
+	}
+
+	// A request that failed to connect never reached the server
+	var opErr *net.OpError
+	return errors.As(err, &opErr) && opErr.Op == "dial"
+}
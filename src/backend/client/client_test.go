@@ -0,0 +1,121 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/yerTools/simple-frontend-stack/src/backend"
+	"github.com/yerTools/simple-frontend-stack/src/backend/backendtest"
+)
+
+func TestClient(t *testing.T) {
+	app := backendtest.NewApp(t)
+	backend.RegisterWorkClockAPI(app)
+	backend.RegisterWorkClockStatusAPI(app)
+	backend.RegisterWorkClockSessionsAPI(app)
+	backend.RegisterDailySummaryAPI(app)
+	server := httptest.NewServer(backendtest.NewHandler(t, app))
+	t.Cleanup(server.Close)
+
+	ctx := context.Background()
+	c := New(server.URL+"/", WithRetries(0, 0))
+
+	backendtest.AddRecords(t, app,
+		backendtest.ClockIn("2025-03-10T08:00:00Z"), backendtest.ClockOut("2025-03-10T12:00:00Z"),
+		backendtest.ClockIn("2025-03-10T13:00:00Z"), backendtest.ClockOut("2025-03-10T17:00:00Z"),
+		backendtest.ClockIn("2025-03-11T09:00:00Z"), backendtest.ClockOut("2025-03-11T11:00:00Z"),
+	)
+
+	if err := c.ClockIn(ctx, ClockInRequest{}); err != nil {
+		t.Fatalf("failed to clock in: %v", err)
+	}
+	status, err := c.Status(ctx, "")
+	if err != nil || !status.ClockedIn || status.ClockInID == "" {
+		t.Fatalf("expected to be clocked in, got %+v: %v", status, err)
+	}
+
+	var apiErr *APIError
+	if err := c.ClockIn(ctx, ClockInRequest{}); !errors.As(err, &apiErr) || apiErr.Message == "" {
+		t.Errorf("expected an API error when clocking in twice, got %v", err)
+	}
+	if _, err := c.Status(ctx, "missing"); !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusBadRequest {
+		t.Errorf("expected status 400 for a missing clock, got %v", err)
+	}
+
+	if err := c.ClockOut(ctx, ClockOutRequest{}); err != nil {
+		t.Fatalf("failed to clock out: %v", err)
+	}
+	if status, err := c.Status(ctx, ""); err != nil || status.ClockedIn {
+		t.Errorf("expected to be clocked out, got %+v: %v", status, err)
+	}
+
+	// The sessions are requested page by page
+	march := SessionsQuery{
+		From:  backendtest.MustParseTime("2025-03-01T00:00:00Z"),
+		To:    backendtest.MustParseTime("2025-04-01T00:00:00Z"),
+		Limit: 2,
+	}
+	page, err := c.Sessions(ctx, march)
+	if err != nil || len(page.Sessions) != 2 || page.NextCursor == "" {
+		t.Fatalf("expected a first page of two sessions, got %+v: %v", page, err)
+	}
+	sessions, err := c.AllSessions(ctx, march)
+	if err != nil || len(sessions) != 3 {
+		t.Fatalf("expected three sessions, got %d: %v", len(sessions), err)
+	}
+	if sessions[2].DurationSeconds != 2*60*60 || sessions[2].End == nil {
+		t.Errorf("expected the closed session of March 11, got %+v", sessions[2])
+	}
+
+	summaries, err := c.DailySummaries(ctx, "", time.Date(2025, 3, 10, 0, 0, 0, 0, time.Local), time.Date(2025, 3, 12, 0, 0, 0, 0, time.Local))
+	if err != nil || len(summaries) != 2 || summaries[0].WorkedSeconds != 8*60*60 {
+		t.Errorf("expected the summaries of two days, got %+v: %v", summaries, err)
+	}
+}
+
+func TestClientRetries(t *testing.T) {
+	var attempts atomic.Int32
+	statuses := []int{http.StatusServiceUnavailable, http.StatusBadGateway, http.StatusOK}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		status := statuses[min(int(attempts.Add(1))-1, len(statuses)-1)]
+		w.Header().Set("Content-Type", "application/json")
+		if status != http.StatusOK {
+			w.WriteHeader(status)
+			w.Write([]byte(`{"status":503,"message":"The server is shutting down, please retry later","data":{}}`))
+			return
+		}
+		w.Write([]byte(`{"clocked_in":true}`))
+	}))
+	t.Cleanup(server.Close)
+
+	ctx := context.Background()
+	c := New(server.URL, WithRetries(3, time.Millisecond))
+
+	// Queries are retried after 503 and 502
+	status, err := c.Status(ctx, "")
+	if err != nil || !status.ClockedIn || attempts.Load() != 3 {
+		t.Fatalf("expected the status after three attempts, got %+v after %d: %v", status, attempts.Load(), err)
+	}
+
+	// Clock changes are retried after 503, but not after 502, since the server may have processed them
+	attempts.Store(0)
+	var apiErr *APIError
+	err = c.ClockIn(ctx, ClockInRequest{})
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusBadGateway || attempts.Load() != 2 {
+		t.Errorf("expected the clock in to fail with 502 after two attempts, got %v after %d", err, attempts.Load())
+	}
+	if apiErr != nil && apiErr.Message != "The server is shutting down, please retry later" {
+		t.Errorf("expected the message of the server, got %q", apiErr.Message)
+	}
+
+	// Without retries, the first failure is returned
+	attempts.Store(0)
+	if _, err := New(server.URL, WithRetries(0, 0)).Status(ctx, ""); err == nil || attempts.Load() != 1 {
+		t.Errorf("expected a single failed attempt, got %v after %d", err, attempts.Load())
+	}
+}
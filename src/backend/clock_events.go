@@ -0,0 +1,131 @@
+// Clock Events Module for PocketBase
+//
+// This module provides an event bus for clock events, so other Go modules can react to them
+// without being hard-coded into the work clock module. Integrations (e.g. Slack or MQTT) register
+// their handlers on startup, in the same way handlers are bound to the hooks of PocketBase:
+//
+//	backend.OnClockIn().BindFunc(func(e *backend.ClockEvent) error {
+//		notify(e.Record.GetDateTime("timestamp"))
+//		return e.Next()
+//	})
+//
+// The following events are triggered after a work clock record was created and committed:
+// - OnClockIn - A clock in record was created
+// - OnClockOut - A clock out record was created
+// - OnSessionClosed - A clock out record was created that closes a session
+//
+// Events are triggered for every created record, including records added retroactively or by
+// imports. Handlers are called synchronously, so long running work should be done in the
+// background. A handler error stops the remaining handlers and is logged, the clock operation
+// itself is not affected.
+package backend
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tools/hook"
+)
+
+// ClockEvent is the event of a created clock in or clock out record.
+type ClockEvent struct {
+	hook.Event
+
+	App    core.App     // The application the record was created in
+	Record *core.Record // The created work clock record
+}
+
+// SessionClosedEvent is the event of a session closed by a clock out record.
+type SessionClosedEvent struct {
+	hook.Event
+
+	App     core.App         // The application the record was created in
+	Session WorkSessionEntry // The closed session
+}
+
+// ClockEvents is a bus of clock event hooks. The hooks of the package level accessors belong to
+// the default bus, which is used by every WorkClockService unless it is created with another bus.
+type ClockEvents struct {
+	// ClockIn is triggered after a clock in record was created
+	ClockIn *hook.Hook[*ClockEvent]
+
+	// ClockOut is triggered after a clock out record was created
+	ClockOut *hook.Hook[*ClockEvent]
+
+	// SessionClosed is triggered after a clock out record closing a session was created
+	SessionClosed *hook.Hook[*SessionClosedEvent]
+}
+
+// NewClockEvents creates a bus without any handlers.
+//
+// Returns:
+// - The bus
+func NewClockEvents() *ClockEvents {
+	return &ClockEvents{
+		ClockIn:       &hook.Hook[*ClockEvent]{},
+		ClockOut:      &hook.Hook[*ClockEvent]{},
+		SessionClosed: &hook.Hook[*SessionClosedEvent]{},
+	}
+}
+
+// defaultClockEvents is the bus the package level accessors bind to.
+var defaultClockEvents = NewClockEvents()
+
+// OnClockIn returns the hook triggered after a clock in record was created.
+func OnClockIn() *hook.Hook[*ClockEvent] {
+	return defaultClockEvents.ClockIn
+}
+
+// OnClockOut returns the hook triggered after a clock out record was created.
+func OnClockOut() *hook.Hook[*ClockEvent] {
+	return defaultClockEvents.ClockOut
+}
+
+// OnSessionClosed returns the hook triggered after a clock out record closing a session was created.
+// It is triggered after the OnClockOut hook of the same record.
+func OnSessionClosed() *hook.Hook[*SessionClosedEvent] {
+	return defaultClockEvents.SessionClosed
+}
+
+// RegisterClockEventHooks connects the clock event hooks to the work clock records of the PocketBase server.
+//
+// Parameters:
+// - app: The PocketBase application instance
+func RegisterClockEventHooks(app core.App) {
+	app.OnRecordAfterCreateSuccess("work_clock").BindFunc(func(e *core.RecordEvent) error {
+		if err := triggerClockEvents(e.App, e.Record); err != nil {
+			e.App.Logger().Error("clock event handler failed", "record", e.Record.Id, "error", err)
+		}
+		return e.Next()
+	})
+}
+
+// triggerClockEvents triggers the clock event hooks for a created work clock record on the bus of
+// the app's WorkClockService.
+//
+// Parameters:
+// - app: The App interface the record was created in
+// - record: The created work clock record
+//
+// Returns:
+// - The first error returned by a handler, or an error if the preceding record can't be loaded
+func triggerClockEvents(app core.App, record *core.Record) error {
+	service := workClockServiceOf(app)
+	if record.GetBool("clock_in") {
+		return service.events.ClockIn.Trigger(&ClockEvent{App: app, Record: record})
+	}
+
+	if err := service.events.ClockOut.Trigger(&ClockEvent{App: app, Record: record}); err != nil {
+		return err
+	}
+
+	if service.events.SessionClosed.Length() == 0 {
+		return nil
+	}
+
+	clockIn, err := findSessionClockIn(app, record)
+	if err != nil || clockIn == nil {
+		return err
+	}
+
+	session := workSession{ClockIn: clockIn, ClockOut: record}
+	return service.events.SessionClosed.Trigger(&SessionClosedEvent{App: app, Session: newWorkSessionEntry(session, service.Now())})
+}
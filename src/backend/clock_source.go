@@ -0,0 +1,120 @@
+// Clock Source Module for PocketBase
+//
+// This module abstracts the current time behind the Clock interface, so the clock operations and
+// the scheduled jobs don't read time.Now directly. The WorkClockService of an app carries its
+// clock, which is the system clock unless another one is registered:
+//
+//	clock := backend.NewSimulatedClock(start, 0)
+//	backend.RegisterWorkClockService(app, backend.NewWorkClockService(app).WithClock(clock))
+//	clock.Advance(24 * time.Hour) // simulate a day passing
+//
+// A simulated clock with a speed of 0 only moves when it is advanced, which lets tests simulate
+// days passing. A positive speed lets it run that many times faster than real time, which is used
+// by the demo mode (DEMO_CLOCK_SPEED) to fast-forward through days and weeks. The cron schedules
+// of the scheduled jobs still follow the real time, only the time the jobs work with is simulated.
+package backend
+
+import (
+	"sync"
+	"time"
+
+	"github.com/pocketbase/pocketbase/core"
+)
+
+// Clock is the source of the current time.
+type Clock interface {
+	// Now returns the current time.
+	Now() time.Time
+}
+
+// SystemClock is the clock returning the real current time.
+type SystemClock struct{}
+
+// Now returns the real current time.
+func (SystemClock) Now() time.Time {
+	return time.Now()
+}
+
+// SimulatedClock is a clock that can be set and advanced manually, and optionally runs faster
+// than real time. It is safe for concurrent use.
+type SimulatedClock struct {
+	mutex   sync.Mutex
+	anchor  time.Time // The simulated time at the real time realNow
+	realNow time.Time // The real time the anchor was set at
+	speed   float64   // The factor the simulated time passes faster than real time, 0 for a stopped clock
+}
+
+// NewSimulatedClock creates a simulated clock.
+//
+// Parameters:
+// - start: The simulated time the clock starts at
+// - speed: The factor the simulated time passes faster than real time, 0 for a clock that only moves when advanced
+//
+// Returns:
+// - The clock
+func NewSimulatedClock(start time.Time, speed float64) *SimulatedClock {
+	return &SimulatedClock{anchor: start, realNow: time.Now(), speed: max(speed, 0)}
+}
+
+// Now returns the simulated current time.
+func (c *SimulatedClock) Now() time.Time {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	return c.now()
+}
+
+// Set sets the simulated current time.
+//
+// Parameters:
+// - now: The new simulated time
+func (c *SimulatedClock) Set(now time.Time) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.anchor = now
+	c.realNow = time.Now()
+}
+
+// Advance moves the simulated time forward.
+//
+// Parameters:
+// - duration: The duration to move the time by
+func (c *SimulatedClock) Advance(duration time.Duration) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.anchor = c.now().Add(duration)
+	c.realNow = time.Now()
+}
+
+// now returns the simulated current time, the caller has to hold the mutex.
+func (c *SimulatedClock) now() time.Time {
+	if c.speed == 0 {
+		return c.anchor
+	}
+	return c.anchor.Add(time.Duration(float64(time.Since(c.realNow)) * c.speed))
+}
+
+// defaultClock returns the clock configured by the settings: the system clock, or a clock running
+// DEMO_CLOCK_SPEED times faster than real time in demo mode.
+//
+// Returns:
+// - The clock
+func defaultClock() Clock {
+	if settings.DemoClockSpeed > 0 {
+		return NewSimulatedClock(time.Now(), settings.DemoClockSpeed)
+	}
+	return SystemClock{}
+}
+
+// clockNow returns the current time of the clock of an app's WorkClockService.
+//
+// Parameters:
+// - app: The App interface (typically a PocketBase instance or transaction)
+//
+// Returns:
+// - The current time
+func clockNow(app core.App) time.Time {
+	return workClockServiceOf(app).Now()
+}
@@ -0,0 +1,69 @@
+package backend
+
+import (
+	"testing"
+	"time"
+
+	"github.com/yerTools/simple-frontend-stack/src/backend/backendtest"
+)
+
+func TestSimulatedClock(t *testing.T) {
+	start := backendtest.MustParseTime("2025-04-01T08:00:00Z")
+
+	clock := NewSimulatedClock(start, 0)
+	if !clock.Now().Equal(start) {
+		t.Fatalf("expected a stopped clock to stay at %s, got %s", start, clock.Now())
+	}
+	clock.Advance(36 * time.Hour)
+	if expected := start.Add(36 * time.Hour); !clock.Now().Equal(expected) {
+		t.Errorf("expected the advanced clock at %s, got %s", expected, clock.Now())
+	}
+	clock.Set(start)
+	if !clock.Now().Equal(start) {
+		t.Errorf("expected the clock to be set to %s, got %s", start, clock.Now())
+	}
+
+	fastClock := NewSimulatedClock(start, 3600)
+	time.Sleep(10 * time.Millisecond)
+	if elapsed := fastClock.Now().Sub(start); elapsed < 36*time.Second {
+		t.Errorf("expected a clock running 3600 times faster to pass at least 36s in 10ms, got %s", elapsed)
+	}
+}
+
+func TestSimulatedDaysPassing(t *testing.T) {
+	app := backendtest.NewApp(t)
+
+	clock := NewSimulatedClock(backendtest.MustParseTime("2025-04-07T08:00:00Z"), 0)
+	RegisterWorkClockService(app, NewWorkClockService(app).WithClock(clock))
+
+	if err := workClockServiceOf(app).ClockInOut("", true, false); err != nil {
+		t.Fatalf("failed to clock in: %v", err)
+	}
+
+	status, err := getWorkClockStatus(app, "", clockNow(app))
+	if err != nil {
+		t.Fatalf("failed to get status: %v", err)
+	}
+	if !status.ClockedIn || status.Stale {
+		t.Fatalf("expected a fresh open session, got %+v", status)
+	}
+
+	// The session is forgotten over the following two days
+	clock.Advance(48 * time.Hour)
+
+	status, err = getWorkClockStatus(app, "", clockNow(app))
+	if err != nil {
+		t.Fatalf("failed to get status: %v", err)
+	}
+	if !status.Stale {
+		t.Errorf("expected the session to be stale two days later, got %+v", status)
+	}
+
+	if err := workClockServiceOf(app).ClockInOut("", false, true); err != nil {
+		t.Fatalf("failed to clock out: %v", err)
+	}
+	records := backendtest.Records(t, app)
+	if len(records) != 2 || !records[1].Timestamp.Equal(clock.Now()) {
+		t.Errorf("expected the clock out at the simulated time %s, got %v", clock.Now(), records)
+	}
+}
@@ -0,0 +1,102 @@
+// Clocks Module for PocketBase
+//
+// This module resolves the clock of a request. Besides the default clock, any number of named
+// clocks can be created in the clocks collection, e.g. to track a main job and a side project in
+// parallel. Each clock has its own alternating sequence of clock in and out records, so its state,
+// sessions and reports are independent of the other clocks.
+//
+// All work clock endpoints accept an optional 'clock' parameter with the name of the clock. Without
+// the parameter the default clock is used, which contains the records without a clock. A clock
+// can belong to a user, who may delegate access to it (see the delegation module).
+package backend
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/pocketbase/pocketbase/core"
+)
+
+// requestClock resolves the 'clock' parameter of a request.
+//
+// Parameters:
+// - app: The App interface used to look up the clock
+// - e: The RequestEvent from the HTTP handler
+//
+// Returns:
+// - The ID of the clock, an empty string for the default clock
+// - An API error if the clock does not exist
+func requestClock(app core.App, e *core.RequestEvent) (string, error) {
+	clockID, err := findClockID(app, e.Request.FormValue("clock"))
+	if err != nil {
+		return "", e.Error(http.StatusBadRequest, err.Error(), nil)
+	}
+	return clockID, nil
+}
+
+// findClockID finds the ID of a clock by its name.
+//
+// Parameters:
+// - app: The App interface used to look up the clock
+// - name: The name of the clock, an empty string refers to the default clock
+//
+// Returns:
+// - The ID of the clock, an empty string for the default clock
+// - An error if the clock does not exist
+func findClockID(app core.App, name string) (string, error) {
+	if name == "" {
+		return "", nil
+	}
+
+	record, err := app.FindFirstRecordByData("clocks", "name", name)
+	if err != nil {
+		return "", fmt.Errorf("clock '%s' does not exist", name)
+	}
+
+	return record.Id, nil
+}
+
+// clockParam converts a clock ID into a filter parameter. PocketBase quotes an empty string
+// parameter as '""' in record filters, so the default clock is passed as null instead, which
+// matches the records without a clock.
+//
+// Parameters:
+// - clockID: The ID of the clock, an empty string for the default clock
+//
+// Returns:
+// - The filter parameter of the clock
+func clockParam(clockID string) any {
+	if clockID == "" {
+		return nil
+	}
+	return clockID
+}
+
+// hasOpenSession checks whether any clock currently has an open session.
+//
+// Parameters:
+// - app: The App interface (typically a PocketBase instance or transaction)
+//
+// Returns:
+// - true if at least one clock is clocked in
+// - An error if the database query fails
+func hasOpenSession(app core.App) (bool, error) {
+	clocks, err := app.FindAllRecords("clocks")
+	if err != nil {
+		return false, fmt.Errorf("failed to find clocks: %w", err)
+	}
+
+	clockIDs := []string{""}
+	for _, clock := range clocks {
+		clockIDs = append(clockIDs, clock.Id)
+	}
+
+	for _, clockID := range clockIDs {
+		clockedIn, err := workClockServiceOf(app).IsClockedIn(clockID)
+		if err != nil || clockedIn {
+			return clockedIn, err
+		}
+	}
+
+	return false, nil
+}
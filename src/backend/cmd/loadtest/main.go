@@ -0,0 +1,307 @@
+// Load Test Command
+//
+// This command simulates kiosk polling plus concurrent toggles against a temporary PocketBase
+// instance with all backend modules registered, and reports the latency of each endpoint.
+// It is meant to catch performance regressions in the clock path before a release:
+//
+//	go run ./src/backend/cmd/loadtest -duration 30s -kiosks 20 -togglers 4
+//
+// Toggles of different clients race for the same clock state, so some of them are expectedly
+// rejected. After the run, the work clock records are checked to still alternate between clock in
+// and clock out. The command exits with a non-zero status if they do not.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/pocketbase/pocketbase"
+	"github.com/pocketbase/pocketbase/apis"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/yerTools/simple-frontend-stack/src/backend"
+	"github.com/yerTools/simple-frontend-stack/src/backend/pairing"
+
+	_ "github.com/yerTools/simple-frontend-stack/src/backend/migrations"
+)
+
+// endpointStats collects the results of the requests to a single endpoint.
+type endpointStats struct {
+	mutex     sync.Mutex
+	latencies []time.Duration
+	statuses  map[int]int
+	failures  int
+}
+
+// record adds the result of a request.
+func (s *endpointStats) record(latency time.Duration, status int, err error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if err != nil {
+		s.failures++
+		return
+	}
+
+	s.latencies = append(s.latencies, latency)
+	s.statuses[status]++
+}
+
+// print writes a summary of the collected results.
+func (s *endpointStats) print(name string, duration time.Duration) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	fmt.Printf("%s\n", name)
+	fmt.Printf("  requests: %d (%.1f/s), transport failures: %d\n", len(s.latencies), float64(len(s.latencies))/duration.Seconds(), s.failures)
+
+	statusCodes := make([]int, 0, len(s.statuses))
+	for status := range s.statuses {
+		statusCodes = append(statusCodes, status)
+	}
+	sort.Ints(statusCodes)
+	for _, status := range statusCodes {
+		fmt.Printf("  status %d: %d\n", status, s.statuses[status])
+	}
+
+	if len(s.latencies) == 0 {
+		return
+	}
+
+	sort.Slice(s.latencies, func(a, b int) bool { return s.latencies[a] < s.latencies[b] })
+	percentile := func(p float64) time.Duration {
+		return s.latencies[int(float64(len(s.latencies)-1)*p)]
+	}
+	fmt.Printf("  latency p50: %s, p95: %s, p99: %s, max: %s\n", percentile(0.5), percentile(0.95), percentile(0.99), s.latencies[len(s.latencies)-1])
+}
+
+func main() {
+	duration := flag.Duration("duration", 30*time.Second, "duration of the load test")
+	kiosks := flag.Int("kiosks", 20, "number of simulated kiosks polling the status")
+	pollInterval := flag.Duration("poll-interval", time.Second, "status polling interval of each kiosk")
+	togglers := flag.Int("togglers", 4, "number of clients toggling the clock concurrently")
+	toggleInterval := flag.Duration("toggle-interval", 250*time.Millisecond, "toggle interval of each toggling client")
+	seedDays := flag.Int("seed-days", 3*365, "number of days of historical sessions created before the test")
+	flag.Parse()
+
+	dataDir, err := os.MkdirTemp("", "loadtest_pb_data_")
+	if err != nil {
+		log.Fatalf("failed to create temporary data directory: %v", err)
+	}
+	defer os.RemoveAll(dataDir)
+
+	app, handler, err := newTestServer(dataDir)
+	if err != nil {
+		log.Fatalf("failed to start test instance: %v", err)
+	}
+
+	if err := seedWorkClock(app, *seedDays); err != nil {
+		log.Fatalf("failed to seed work clock records: %v", err)
+	}
+
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	statusStats := &endpointStats{statuses: map[int]int{}}
+	toggleStats := &endpointStats{statuses: map[int]int{}}
+
+	fmt.Printf("running load test for %s with %d kiosks and %d togglers against %s\n", *duration, *kiosks, *togglers, server.URL)
+
+	deadline := time.Now().Add(*duration)
+	var wg sync.WaitGroup
+
+	for range *kiosks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			// Kiosks poll with If-None-Match like the frontend does
+			etag := ""
+			for time.Now().Before(deadline) {
+				request, _ := http.NewRequest(http.MethodGet, server.URL+"/api/work_clock/status", nil)
+				if etag != "" {
+					request.Header.Set("If-None-Match", etag)
+				}
+
+				status, responseETag, latency, err := doRequest(request)
+				statusStats.record(latency, status, err)
+				if responseETag != "" {
+					etag = responseETag
+				}
+
+				time.Sleep(*pollInterval)
+			}
+		}()
+	}
+
+	for range *togglers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			for time.Now().Before(deadline) {
+				request, _ := http.NewRequest(http.MethodGet, server.URL+"/api/work_clock/toggle?confirm=true", nil)
+
+				status, _, latency, err := doRequest(request)
+				toggleStats.record(latency, status, err)
+
+				time.Sleep(*toggleInterval)
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	statusStats.print("GET /api/work_clock/status", *duration)
+	toggleStats.print("GET /api/work_clock/toggle", *duration)
+
+	if err := verifyAlternation(app); err != nil {
+		fmt.Printf("sequence check failed: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Println("sequence check passed: all records alternate between clock in and clock out")
+}
+
+// newTestServer creates a PocketBase instance in the given directory with all migrations
+// applied and all backend modules registered.
+//
+// Parameters:
+// - dataDir: The data directory of the instance
+//
+// Returns:
+// - The PocketBase instance
+// - The HTTP handler serving the API
+// - An error if bootstrapping the instance or building the router fails
+func newTestServer(dataDir string) (*pocketbase.PocketBase, http.Handler, error) {
+	app := pocketbase.NewWithConfig(pocketbase.Config{
+		DefaultDataDir:  dataDir,
+		HideStartBanner: true,
+	})
+
+	if err := app.Bootstrap(); err != nil {
+		return nil, nil, fmt.Errorf("failed to bootstrap: %w", err)
+	}
+	if err := app.RunAllMigrations(); err != nil {
+		return nil, nil, fmt.Errorf("failed to run migrations: %w", err)
+	}
+
+	backend.RegisterAPIs(app)
+
+	router, err := apis.NewRouter(app)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create router: %w", err)
+	}
+
+	// The serve event is triggered manually, so the modules register their routes without starting a server
+	var handler http.Handler
+	serveEvent := &core.ServeEvent{App: app, Router: router}
+	err = app.OnServe().Trigger(serveEvent, func(e *core.ServeEvent) error {
+		mux, err := e.Router.BuildMux()
+		handler = mux
+		return err
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to build router: %w", err)
+	}
+
+	return app, handler, nil
+}
+
+// seedWorkClock creates a 9:00 to 17:00 session for each of the given number of days before today.
+//
+// Parameters:
+// - app: The App interface (typically a PocketBase instance or transaction)
+// - days: The number of days to create sessions for
+//
+// Returns:
+// - An error if creating a record fails
+func seedWorkClock(app core.App, days int) error {
+	collection, err := app.FindCollectionByNameOrId("work_clock")
+	if err != nil {
+		return err
+	}
+
+	today := time.Now().Truncate(24 * time.Hour)
+	return app.RunInTransaction(func(txApp core.App) error {
+		for day := days; day > 0; day-- {
+			date := today.AddDate(0, 0, -day)
+
+			for _, clockIn := range []bool{true, false} {
+				timestamp := date.Add(9 * time.Hour)
+				if !clockIn {
+					timestamp = date.Add(17 * time.Hour)
+				}
+
+				record := core.NewRecord(collection)
+				record.Set("timestamp", timestamp)
+				record.Set("clock_in", clockIn)
+				if err := txApp.Save(record); err != nil {
+					return err
+				}
+			}
+		}
+
+		return nil
+	})
+}
+
+// doRequest executes a request and drains its response.
+//
+// Parameters:
+// - request: The request to execute
+//
+// Returns:
+// - The status code of the response
+// - The ETag of the response
+// - The latency of the request
+// - An error if the request failed on the transport level
+func doRequest(request *http.Request) (int, string, time.Duration, error) {
+	start := time.Now()
+
+	response, err := http.DefaultClient.Do(request)
+	if err != nil {
+		return 0, "", time.Since(start), err
+	}
+	defer response.Body.Close()
+
+	buffer := make([]byte, 4096)
+	for {
+		if _, err := response.Body.Read(buffer); err != nil {
+			break
+		}
+	}
+
+	return response.StatusCode, response.Header.Get("ETag"), time.Since(start), nil
+}
+
+// verifyAlternation checks that all work clock records alternate between clock in and clock out,
+// starting with a clock in record.
+//
+// Parameters:
+// - app: The App interface (typically a PocketBase instance or transaction)
+//
+// Returns:
+// - An error describing the first violation
+func verifyAlternation(app core.App) error {
+	records, err := app.FindRecordsByFilter("work_clock", "", "+timestamp", 0, 0)
+	if err != nil {
+		return err
+	}
+
+	pairingRecords := make([]pairing.Record, len(records))
+	for i, record := range records {
+		pairingRecords[i] = pairing.Record{ID: record.Id, Timestamp: record.GetDateTime("timestamp").Time(), ClockIn: record.GetBool("clock_in")}
+	}
+
+	if _, issues := pairing.PairRecords(pairingRecords); len(issues) > 0 {
+		return fmt.Errorf("record with id '%s' at %s breaks the alternation (%s)", issues[0].RecordID, issues[0].Timestamp.Format(time.RFC3339Nano), issues[0].Code)
+	}
+
+	return nil
+}
@@ -0,0 +1,128 @@
+// Compact API Module for PocketBase
+//
+// This module provides a minimal status and toggle API for watch apps and low-power widgets,
+// which poll over flaky connections. The responses only contain the clock state and the unix
+// timestamp of the latest record, e.g.
+//
+//	{"c":1,"s":1713520000}
+//
+// so clients calculate the running duration locally. The status carries an ETag that only
+// changes when the clock state changes, so polling clients usually receive an empty
+// 304 Not Modified response.
+package backend
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/pocketbase/pocketbase/core"
+)
+
+// compactStatus is the minimal representation of the work clock state.
+type compactStatus struct {
+	ClockedIn int   `json:"c"` // 1 if a session is open, 0 otherwise
+	Since     int64 `json:"s"` // Unix timestamp of the latest work clock record, 0 if there is none
+}
+
+// RegisterCompactAPI registers the compact endpoints with the PocketBase server.
+// It creates the following routes:
+// - GET /api/compact/status - Returns the compact status, supports If-None-Match
+// - POST /api/compact/toggle - Toggles the clock state and returns the new compact status
+//
+// Parameters:
+// - app: The PocketBase application instance
+func RegisterCompactAPI(app core.App) {
+	app.OnServe().BindFunc(func(se *core.ServeEvent) error {
+		se.Router.GET("/api/compact/status", func(e *core.RequestEvent) error {
+			clockID, err := requestClock(app, e)
+			if err != nil {
+				return err
+			}
+
+			status, err := getCompactStatus(app, clockID)
+			if err != nil {
+				return e.Error(http.StatusInternalServerError, fmt.Sprintf("Failed to get work clock status: %v", err), err)
+			}
+
+			return writeCompactStatus(e, status)
+		})
+
+		se.Router.POST("/api/compact/toggle", func(e *core.RequestEvent) error {
+			clockID, err := requestClock(app, e)
+			if err != nil {
+				return err
+			}
+
+			clockedIn, err := workClockServiceOf(app).IsClockedIn(clockID)
+			if err != nil {
+				return e.Error(http.StatusInternalServerError, fmt.Sprintf("Failed to check current clock status: %v", err), err)
+			}
+
+			if err := requestWorkClockService(app, e).ClockInOut(clockID, !clockedIn, false); err != nil {
+				var tooLongErr *sessionTooLongError
+				if errors.As(err, &tooLongErr) {
+					return e.Error(http.StatusConflict, fmt.Sprintf("Failed to toggle clock status: %v", tooLongErr), nil)
+				}
+				return e.Error(http.StatusInternalServerError, fmt.Sprintf("Failed to toggle clock status: %v", err), err)
+			}
+
+			status, err := getCompactStatus(app, clockID)
+			if err != nil {
+				return e.Error(http.StatusInternalServerError, fmt.Sprintf("Failed to get work clock status: %v", err), err)
+			}
+
+			return writeCompactStatus(e, status)
+		})
+
+		return se.Next()
+	})
+}
+
+// getCompactStatus determines the compact representation of the current state of a clock.
+//
+// Parameters:
+// - app: The App interface (typically a PocketBase instance or transaction)
+// - clockID: The ID of the clock, an empty string for the default clock
+//
+// Returns:
+// - The compact status
+// - An error if the latest work clock record could not be retrieved
+func getCompactStatus(app core.App, clockID string) (compactStatus, error) {
+	status, err := getWorkClockStatus(app, clockID, clockNow(app))
+	if err != nil {
+		return compactStatus{}, err
+	}
+
+	compact := compactStatus{}
+	if status.ClockedIn {
+		compact.ClockedIn = 1
+	}
+	if status.Since != nil {
+		compact.Since = status.Since.Unix()
+	}
+
+	return compact, nil
+}
+
+// writeCompactStatus writes the compact status with its ETag, or an empty 304 response
+// if the client already has the current status.
+//
+// Parameters:
+// - e: The RequestEvent from the HTTP handler
+// - status: The compact status to write
+//
+// Returns:
+// - An error if writing the response fails
+func writeCompactStatus(e *core.RequestEvent, status compactStatus) error {
+	etag := fmt.Sprintf(`"%d-%d"`, status.ClockedIn, status.Since)
+
+	e.Response.Header().Set("ETag", etag)
+	e.Response.Header().Set("Cache-Control", "no-cache")
+
+	if e.Request.Header.Get("If-None-Match") == etag {
+		return e.NoContent(http.StatusNotModified)
+	}
+
+	return e.JSON(http.StatusOK, status)
+}
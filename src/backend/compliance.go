@@ -0,0 +1,253 @@
+// Compliance Rules Module for PocketBase
+//
+// This module holds the rules profiles days are checked against, and resolves the profile of a
+// day from the rule_periods collection. Without a rule period, days are checked against the
+// 'standard' profile, which follows the German Working Hours Act (Arbeitszeitgesetz):
+// - 'over_10_hours': More than 10 hours were worked
+// - 'missing_break': More than 6 hours were worked with less than 30 minutes of breaks, or more
+// than 9 hours with less than 45 minutes
+//
+// The 'youth' profile follows the stricter limits of the German Youth Employment Protection Act
+// (Jugendarbeitsschutzgesetz) for apprentices under 18:
+// - 'over_8_hours': More than 8 hours were worked
+// - 'missing_break': More than 4.5 hours were worked with less than 30 minutes of breaks, or more
+// than 6 hours with less than 60 minutes
+// - 'late_work': Work lasted past 20:00
+//
+// A rule period overrides the rules of a clock for a range of days, e.g. the probation period of
+// an apprentice. It selects the profile and whether overtime accrues; with 'no_overtime', days
+// only count towards the balance with their undertime. Rule periods are managed through the
+// regular collection API; a change recomputes the daily summaries from the start of the period.
+package backend
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/core"
+)
+
+// complianceBreakRule requires a minimum of breaks once a day's worked time exceeds a limit.
+type complianceBreakRule struct {
+	After time.Duration // Worked time the breaks are required after
+	Min   time.Duration // Minimum breaks of the day
+}
+
+// complianceProfile is a set of rules days are checked against.
+type complianceProfile struct {
+	MaxDailyWork time.Duration         // Maximum worked time of a day, flagged as 'over_<hours>_hours'
+	Breaks       []complianceBreakRule // Required breaks, flagged as 'missing_break'
+	LatestEnd    time.Duration         // Latest end of work after midnight, flagged as 'late_work', 0 for no limit
+}
+
+// complianceProfiles are the profiles rule periods can select.
+var complianceProfiles = map[string]complianceProfile{
+	"standard": {
+		MaxDailyWork: 10 * time.Hour,
+		Breaks: []complianceBreakRule{
+			{After: 6 * time.Hour, Min: 30 * time.Minute},
+			{After: 9 * time.Hour, Min: 45 * time.Minute},
+		},
+	},
+	"youth": {
+		MaxDailyWork: 8 * time.Hour,
+		Breaks: []complianceBreakRule{
+			{After: 4*time.Hour + 30*time.Minute, Min: 30 * time.Minute},
+			{After: 6 * time.Hour, Min: 60 * time.Minute},
+		},
+		LatestEnd: 20 * time.Hour,
+	},
+}
+
+// flags checks a day against the profile.
+//
+// Parameters:
+// - dayStart: The midnight starting the day, in the timezone the day is checked in
+// - worked: The time worked on the day
+// - breaks: The breaks of the day
+// - end: The end of the last session of the day, zero without sessions
+//
+// Returns:
+// - The compliance flags of the day, empty if the day is fine
+func (p complianceProfile) flags(dayStart time.Time, worked, breaks time.Duration, end time.Time) []string {
+	var flags []string
+	if worked > p.MaxDailyWork {
+		flags = append(flags, fmt.Sprintf("over_%d_hours", int(p.MaxDailyWork.Hours())))
+	}
+	for _, rule := range p.Breaks {
+		if worked > rule.After && breaks < rule.Min {
+			flags = append(flags, "missing_break")
+			break
+		}
+	}
+	if p.LatestEnd > 0 && end.After(dayStart.Add(p.LatestEnd)) {
+		flags = append(flags, "late_work")
+	}
+	return flags
+}
+
+// rulePeriod overrides the rules of a clock for a range of days.
+type rulePeriod struct {
+	Start      string // First day of the period (YYYY-MM-DD), empty for the default rules
+	End        string // Last day of the period (YYYY-MM-DD), empty for an open-ended period
+	Profile    string // Name of the rules profile, see complianceProfiles
+	NoOvertime bool   // Whether overtime doesn't accrue
+}
+
+// profile returns the rules profile of the period.
+func (p rulePeriod) profile() complianceProfile {
+	if profile, ok := complianceProfiles[p.Profile]; ok {
+		return profile
+	}
+	return complianceProfiles["standard"]
+}
+
+// overtime applies the period's overtime rule to the overtime of a day.
+//
+// Parameters:
+// - overtime: The worked minus the target time of the day
+//
+// Returns:
+// - The overtime counting towards the balance
+func (p rulePeriod) overtime(overtime time.Duration) time.Duration {
+	if p.NoOvertime {
+		return min(overtime, 0)
+	}
+	return overtime
+}
+
+// rulePeriods are the rule periods of a clock, sorted by their start.
+type rulePeriods []rulePeriod
+
+// on returns the rule period of a day.
+//
+// Parameters:
+// - day: The local midnight of the day
+//
+// Returns:
+// - The latest starting period including the day, the default rules if there is none
+func (p rulePeriods) on(day time.Time) rulePeriod {
+	date := day.Format(time.DateOnly)
+	for i := len(p) - 1; i >= 0; i-- {
+		if p[i].Start <= date && (p[i].End == "" || date <= p[i].End) {
+			return p[i]
+		}
+	}
+	return rulePeriod{Profile: "standard"}
+}
+
+// RegisterComplianceHooks registers the hooks validating rule periods and recomputing the daily
+// summaries of changed periods with the PocketBase server.
+//
+// Parameters:
+// - app: The PocketBase application instance
+func RegisterComplianceHooks(app core.App) {
+	app.OnRecordCreate("rule_periods").BindFunc(validatePeriodDates)
+	app.OnRecordUpdate("rule_periods").BindFunc(validatePeriodDates)
+
+	update := func(e *core.RecordEvent) error {
+		started := time.Now()
+		days, err := updateDailySummariesFromRecordDate(e.App, e.Record, "start")
+		recordDailySummaryUpdate(started, days, err)
+		if err != nil {
+			// The period change itself succeeded, a rebuild repairs the summaries
+			e.App.Logger().Error("failed to update daily summaries", "rule_period", e.Record.Id, "error", err)
+		}
+		return e.Next()
+	}
+
+	app.OnRecordAfterCreateSuccess("rule_periods").BindFunc(update)
+	app.OnRecordAfterUpdateSuccess("rule_periods").BindFunc(update)
+	app.OnRecordAfterDeleteSuccess("rule_periods").BindFunc(update)
+}
+
+// findRulePeriods finds the rule periods of a clock.
+//
+// Parameters:
+// - app: The App interface (typically a PocketBase instance or transaction)
+// - clockID: The ID of the clock, an empty string for the default clock
+//
+// Returns:
+// - The periods sorted by their start
+// - An error if the database query fails
+func findRulePeriods(app core.App, clockID string) (rulePeriods, error) {
+	records, err := app.FindRecordsByFilter("rule_periods", "clock = {:clock}", "+start", 0, 0, dbx.Params{
+		"clock": clockParam(clockID),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to find rule periods: %w", err)
+	}
+
+	periods := make(rulePeriods, 0, len(records))
+	for _, record := range records {
+		periods = append(periods, rulePeriod{
+			Start:      record.GetString("start"),
+			End:        record.GetString("end"),
+			Profile:    record.GetString("profile"),
+			NoOvertime: record.GetBool("no_overtime"),
+		})
+	}
+
+	return periods, nil
+}
+
+// ComplianceDay is a day violating the rules of its profile.
+type ComplianceDay struct {
+	Date  string   `json:"date"`  // Day (YYYY-MM-DD)
+	Flags []string `json:"flags"` // Compliance flags of the day
+}
+
+// findComplianceDays checks the days of a clock within a range against their rules profiles.
+// Like in the journal, days outside of the employment are not checked.
+//
+// Parameters:
+// - app: The App interface (typically a PocketBase instance or transaction)
+// - clockID: The ID of the clock, an empty string for the default clock
+// - from: The start of the range (inclusive), rounded down to the local midnight
+// - to: The end of the range (exclusive)
+// - now: The reference time used as end of an open session
+//
+// Returns:
+// - The days with compliance flags in ascending order
+// - An error if the sessions, employment or rule periods could not be retrieved
+func findComplianceDays(app core.App, clockID string, from, to, now time.Time) ([]ComplianceDay, error) {
+	sessions, err := findWorkSessions(app, clockID, startOfLocalDay(from), to)
+	if err != nil {
+		return nil, err
+	}
+	employment, err := findEmploymentPeriods(app, clockID)
+	if err != nil {
+		return nil, err
+	}
+	rules, err := findRulePeriods(app, clockID)
+	if err != nil {
+		return nil, err
+	}
+
+	days := []ComplianceDay{}
+	for len(sessions) > 0 {
+		day := startOfLocalDay(sessions[0].Start())
+		next := day.AddDate(0, 0, 1)
+
+		var worked, presence time.Duration
+		first, last := sessions[0].Start(), sessions[0].End(now)
+		for len(sessions) > 0 && sessions[0].Start().Before(next) {
+			duration := sessions[0].Duration(now)
+			worked += time.Duration(float64(duration) * categoryFactor(sessions[0].ClockIn.GetString("category")))
+			presence += duration
+			last = sessions[0].End(now)
+			sessions = sessions[1:]
+		}
+
+		if !employment.includes(day) {
+			continue
+		}
+		breaks := max(last.Sub(first)-presence, 0)
+		if flags := rules.on(day).profile().flags(day, worked, breaks, last); len(flags) > 0 {
+			days = append(days, ComplianceDay{Date: day.Format(time.DateOnly), Flags: flags})
+		}
+	}
+
+	return days, nil
+}
@@ -0,0 +1,62 @@
+package backend
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestComplianceProfileFlags(t *testing.T) {
+	profile := complianceProfiles["standard"]
+
+	for _, test := range []struct {
+		Worked   time.Duration
+		Breaks   time.Duration
+		Expected []string
+	}{
+		{Worked: 6 * time.Hour, Breaks: 0, Expected: nil},
+		{Worked: 7 * time.Hour, Breaks: 30 * time.Minute, Expected: nil},
+		{Worked: 7 * time.Hour, Breaks: 15 * time.Minute, Expected: []string{"missing_break"}},
+		{Worked: 9*time.Hour + time.Minute, Breaks: 30 * time.Minute, Expected: []string{"missing_break"}},
+		{Worked: 11 * time.Hour, Breaks: time.Hour, Expected: []string{"over_10_hours"}},
+	} {
+		day := time.Date(2025, time.April, 1, 0, 0, 0, 0, time.UTC)
+		if flags := profile.flags(day, test.Worked, test.Breaks, day.Add(18*time.Hour)); !slices.Equal(flags, test.Expected) {
+			t.Errorf("expected %v for %s worked with %s breaks, got %v", test.Expected, test.Worked, test.Breaks, flags)
+		}
+	}
+}
+
+func TestYouthComplianceProfileFlags(t *testing.T) {
+	profile := complianceProfiles["youth"]
+	day := time.Date(2025, time.April, 1, 0, 0, 0, 0, time.UTC)
+
+	flags := profile.flags(day, 8*time.Hour+30*time.Minute, 45*time.Minute, day.Add(20*time.Hour+15*time.Minute))
+	if expected := []string{"over_8_hours", "missing_break", "late_work"}; !slices.Equal(flags, expected) {
+		t.Errorf("expected %v, got %v", expected, flags)
+	}
+
+	if flags := profile.flags(day, 5*time.Hour, 30*time.Minute, day.Add(20*time.Hour)); len(flags) > 0 {
+		t.Errorf("expected no flags, got %v", flags)
+	}
+}
+
+func TestRulePeriodsWithoutOvertime(t *testing.T) {
+	periods := rulePeriods{{Start: "2025-04-01", End: "2025-04-30", Profile: "standard", NoOvertime: true}}
+
+	for date, expected := range map[string]time.Duration{
+		"2025-03-31": time.Hour,
+		"2025-04-15": 0,
+		"2025-05-01": time.Hour,
+	} {
+		day, _ := time.ParseInLocation(time.DateOnly, date, time.Local)
+		if overtime := periods.on(day).overtime(time.Hour); overtime != expected {
+			t.Errorf("expected %s of overtime on %s, got %s", expected, date, overtime)
+		}
+	}
+
+	day, _ := time.ParseInLocation(time.DateOnly, "2025-04-15", time.Local)
+	if undertime := periods.on(day).overtime(-time.Hour); undertime != -time.Hour {
+		t.Errorf("expected undertime to count during the period, got %s", undertime)
+	}
+}
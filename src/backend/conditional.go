@@ -0,0 +1,158 @@
+// Conditional Requests Module for PocketBase
+//
+// This module adds ETag/If-None-Match and Last-Modified/If-Modified-Since support to the read
+// endpoints, so polling clients (such as kiosks on metered links) receive an empty
+// 304 Not Modified response instead of the full payload if nothing changed.
+//
+// The ETag is a hash of the response body. The Last-Modified time is the time of the latest
+// record change, which is tracked by record hooks. While a session is open, responses depend
+// on the current time as well, so no Last-Modified time is sent and only the ETag applies.
+package backend
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pocketbase/pocketbase/core"
+)
+
+// dataLastModified is the time of the latest record change and is guarded by dataLastModifiedMutex.
+// It is initialized with the start time of the server, since earlier changes are not tracked.
+var dataLastModified = time.Now()
+var dataLastModifiedMutex = sync.RWMutex{}
+
+// RegisterConditionalRequestHooks registers the hooks tracking the time of the latest record change.
+//
+// Parameters:
+// - app: The PocketBase application instance
+func RegisterConditionalRequestHooks(app core.App) {
+	touch := func(e *core.RecordEvent) error {
+		dataLastModifiedMutex.Lock()
+		dataLastModified = time.Now()
+		dataLastModifiedMutex.Unlock()
+
+		return e.Next()
+	}
+
+	app.OnRecordAfterCreateSuccess().BindFunc(touch)
+	app.OnRecordAfterUpdateSuccess().BindFunc(touch)
+	app.OnRecordAfterDeleteSuccess().BindFunc(touch)
+}
+
+// workClockLastModified returns the time the stored data was last modified.
+//
+// Parameters:
+// - app: The App interface (typically a PocketBase instance or transaction)
+//
+// Returns:
+// - The time of the latest record change, or the zero time while a session is open,
+// since responses then change with the current time as well
+func workClockLastModified(app core.App) time.Time {
+	clockedIn, err := hasOpenSession(app)
+	if err != nil || clockedIn {
+		return time.Time{}
+	}
+
+	dataLastModifiedMutex.RLock()
+	defer dataLastModifiedMutex.RUnlock()
+
+	return dataLastModified
+}
+
+// jsonETag calculates a strong ETag from the JSON representation of the data.
+//
+// Parameters:
+// - data: The data to calculate the ETag of, must be serializable to JSON
+//
+// Returns:
+// - The quoted ETag
+// - An error if the data could not be serialized
+func jsonETag(data any) (string, error) {
+	body, err := json.Marshal(data)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode response: %w", err)
+	}
+
+	hash := sha256.Sum256(body)
+	return `"` + hex.EncodeToString(hash[:16]) + `"`, nil
+}
+
+// checkNotModified sets the validator headers of a response and checks the conditional request headers.
+//
+// Parameters:
+// - e: The RequestEvent from the HTTP handler
+// - etag: The quoted ETag of the response
+// - lastModified: The time the response data was last modified, zero if unknown
+//
+// Returns:
+// - Whether the client already has the current response
+//
+// If-None-Match takes precedence over If-Modified-Since, as required by RFC 9110.
+func checkNotModified(e *core.RequestEvent, etag string, lastModified time.Time) bool {
+	header := e.Response.Header()
+	header.Set("ETag", etag)
+	header.Set("Cache-Control", "no-cache")
+	if !lastModified.IsZero() {
+		header.Set("Last-Modified", lastModified.UTC().Format(http.TimeFormat))
+	}
+
+	if ifNoneMatch := e.Request.Header.Get("If-None-Match"); ifNoneMatch != "" {
+		return matchesETag(ifNoneMatch, etag)
+	}
+
+	if ifModifiedSince := e.Request.Header.Get("If-Modified-Since"); ifModifiedSince != "" && !lastModified.IsZero() {
+		since, err := http.ParseTime(ifModifiedSince)
+		if err == nil && !lastModified.Truncate(time.Second).After(since) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// matchesETag checks whether an If-None-Match header contains an ETag.
+//
+// Parameters:
+// - ifNoneMatch: The value of the If-None-Match header, a comma separated list of ETags or "*"
+// - etag: The quoted ETag
+//
+// Returns:
+// - Whether the header contains the ETag or "*"
+func matchesETag(ifNoneMatch string, etag string) bool {
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == etag || candidate == "*" {
+			return true
+		}
+	}
+	return false
+}
+
+// respondConditionalJSON writes the data as JSON response, or an empty 304 response
+// if the client already has the current data.
+//
+// Parameters:
+// - e: The RequestEvent from the HTTP handler
+// - data: The response data, must be serializable to JSON
+// - lastModified: The time the response data was last modified, zero if unknown
+//
+// Returns:
+// - An error if the data could not be serialized or writing the response fails
+func respondConditionalJSON(e *core.RequestEvent, data any, lastModified time.Time) error {
+	etag, err := jsonETag(data)
+	if err != nil {
+		return e.Error(http.StatusInternalServerError, err.Error(), err)
+	}
+
+	if checkNotModified(e, etag, lastModified) {
+		return e.NoContent(http.StatusNotModified)
+	}
+
+	return e.JSON(http.StatusOK, data)
+}
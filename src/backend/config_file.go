@@ -0,0 +1,243 @@
+// Config File Module for PocketBase
+//
+// This module reads the settings from a config file, so an installation can keep its whole
+// configuration in one versioned file instead of a long list of environment variables. The file
+// is read from the path in CONFIG_FILE, or from config.toml in the working directory if it exists.
+// Environment variables override the values of the file, so single values can still be changed
+// per container.
+//
+// The file uses the TOML syntax. Its keys are the names of the environment variables in lower
+// case, tables prefix the keys of their section:
+//
+//	work_clock_workday_duration = "8h"
+//	webhook_urls = ["https://example.com/hook", "https://example.org/hook"]
+//	week_start = "monday"
+//	fiscal_year_start = 4
+//
+//	[otel]
+//	exporter_otlp_endpoint = "http://otel-collector:4318"
+//	service_name = "work-clock"
+//
+// The file is decoded by a TOML parser, so the whole TOML syntax can be used. Settings holding a
+// list take an array of strings, all other settings a string, number or boolean. Unknown keys and
+// invalid values are reported at startup together with the file and key they were read from,
+// syntax errors with their line, and the server refuses to start.
+package backend
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+)
+
+// defaultConfigFile is the config file read if CONFIG_FILE is unset and the file exists.
+const defaultConfigFile = "config.toml"
+
+// configValue is a value of the config file.
+type configValue struct {
+	Value any    // The decoded value, e.g. a string, int64, float64, bool or []any
+	Key   string // Key of the value in the file, prefixed with the names of its tables
+}
+
+// settingsLoader reads settings from the environment and the config file and collects the problems.
+type settingsLoader struct {
+	file   string                 // Path of the config file, empty if none is read
+	values map[string]configValue // Values of the config file by the name of their setting
+	used   map[string]bool        // Names of the settings that were read
+	errors []error                // Invalid values and other problems
+}
+
+// newSettingsLoader creates a loader, reading the config file if one is configured or present.
+// Problems reading the file are reported by finish.
+//
+// Returns:
+// - The loader
+func newSettingsLoader() *settingsLoader {
+	loader := &settingsLoader{values: map[string]configValue{}, used: map[string]bool{}}
+
+	file := strings.TrimSpace(os.Getenv("CONFIG_FILE"))
+	if file == "" {
+		if _, err := os.Stat(defaultConfigFile); err != nil {
+			return loader
+		}
+		file = defaultConfigFile
+	}
+	loader.file = file
+
+	content, err := os.ReadFile(file)
+	if err != nil {
+		loader.errors = append(loader.errors, fmt.Errorf("failed to read config file: %w", err))
+		return loader
+	}
+
+	values, err := parseConfigFile(content)
+	if err != nil {
+		loader.errors = append(loader.errors, fmt.Errorf("%s: %w", file, err))
+		return loader
+	}
+	loader.values = values
+
+	return loader
+}
+
+// lookup reads a setting from the environment or, if unset there, from the config file.
+// Values of the file that are no string, number or boolean are reported as invalid.
+//
+// Parameters:
+// - name: The name of the setting, which is the name of its environment variable
+//
+// Returns:
+// - The trimmed value
+// - Whether the setting is set
+func (l *settingsLoader) lookup(name string) (string, bool) {
+	l.used[name] = true
+
+	if value := strings.TrimSpace(os.Getenv(name)); value != "" {
+		return value, true
+	}
+
+	configValue, ok := l.values[name]
+	if !ok {
+		return "", false
+	}
+
+	var value string
+	switch typed := configValue.Value.(type) {
+	case string:
+		value = strings.TrimSpace(typed)
+	case int64:
+		value = strconv.FormatInt(typed, 10)
+	case float64:
+		value = strconv.FormatFloat(typed, 'f', -1, 64)
+	case bool:
+		value = strconv.FormatBool(typed)
+	default:
+		l.invalid(name, fmt.Sprint(typed), "a string, number or boolean")
+		return "", false
+	}
+	return value, value != ""
+}
+
+// lookupList reads a list setting from the environment as comma separated list or, if unset there,
+// from the config file as array of strings. A string in the config file is split like the
+// environment variable.
+//
+// Parameters:
+// - name: The name of the setting, which is the name of its environment variable
+//
+// Returns:
+// - The untrimmed entries
+// - Whether the setting is set
+func (l *settingsLoader) lookupList(name string) ([]string, bool) {
+	array, isArray := l.values[name].Value.([]any)
+	if !isArray || strings.TrimSpace(os.Getenv(name)) != "" {
+		value, ok := l.lookup(name)
+		if !ok {
+			return nil, false
+		}
+		return strings.Split(value, ","), true
+	}
+
+	l.used[name] = true
+	entries := make([]string, 0, len(array))
+	for _, entry := range array {
+		text, ok := entry.(string)
+		if !ok {
+			l.invalid(name, fmt.Sprint(array), "an array of strings")
+			return nil, false
+		}
+		entries = append(entries, text)
+	}
+	return entries, true
+}
+
+// invalid records an invalid value, naming where it was read from.
+//
+// Parameters:
+// - name: The name of the setting
+// - value: The invalid value
+// - expected: A description of the valid values
+func (l *settingsLoader) invalid(name string, value string, expected string) {
+	source := "environment variable " + name
+	if strings.TrimSpace(os.Getenv(name)) == "" {
+		source = fmt.Sprintf("%s: %s", l.file, l.values[name].Key)
+	}
+	l.errors = append(l.errors, fmt.Errorf("%s: invalid value '%s', expected %s", source, value, expected))
+}
+
+// finish reports the keys of the config file that are no settings, and returns all problems.
+//
+// Returns:
+// - An error listing all problems, nil if there are none
+func (l *settingsLoader) finish() error {
+	var unknown []string
+	for name := range l.values {
+		if !l.used[name] {
+			unknown = append(unknown, l.values[name].Key)
+		}
+	}
+	slices.Sort(unknown)
+
+	for _, key := range unknown {
+		l.errors = append(l.errors, fmt.Errorf("%s: unknown setting '%s'", l.file, key))
+	}
+
+	return errors.Join(l.errors...)
+}
+
+// parseConfigFile decodes a config file and flattens its tables into the names of the settings.
+//
+// Parameters:
+// - content: The content of the config file
+//
+// Returns:
+// - The values by the name of their setting
+// - An error naming the line if the content is no valid TOML, or naming the keys of a setting set twice
+func parseConfigFile(content []byte) (map[string]configValue, error) {
+	var data map[string]any
+	if _, err := toml.Decode(string(content), &data); err != nil {
+		return nil, err
+	}
+
+	values := map[string]configValue{}
+	if err := flattenConfigTable(values, data, ""); err != nil {
+		return nil, err
+	}
+	return values, nil
+}
+
+// flattenConfigTable adds the values of a table to the values of the settings. The names of the
+// tables prefix the keys of their values, e.g. exporter_otlp_endpoint in the table otel is the
+// setting OTEL_EXPORTER_OTLP_ENDPOINT.
+//
+// Parameters:
+// - values: The values by the name of their setting, which are extended
+// - table: The decoded table
+// - prefix: The key of the table followed by a dot, empty for the root table
+//
+// Returns:
+// - An error if a setting is set by two keys, e.g. otel_service_name and service_name in the table otel
+func flattenConfigTable(values map[string]configValue, table map[string]any, prefix string) error {
+	// The keys are sorted, so the same key is reported as duplicate on every start
+	for _, key := range slices.Sorted(maps.Keys(table)) {
+		if subtable, ok := table[key].(map[string]any); ok {
+			if err := flattenConfigTable(values, subtable, prefix+key+"."); err != nil {
+				return err
+			}
+			continue
+		}
+
+		name := strings.ToUpper(strings.NewReplacer(".", "_", "-", "_").Replace(prefix + key))
+		if existing, exists := values[name]; exists {
+			return fmt.Errorf("'%s' and '%s' are the same setting", existing.Key, prefix+key)
+		}
+		values[name] = configValue{Value: table[key], Key: prefix + key}
+	}
+	return nil
+}
@@ -0,0 +1,87 @@
+package backend
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLoadSettingsFromConfigFile(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "config.toml")
+	content := `# Work clock
+work_clock_workday_duration = "7h30m" # part-time
+webhook_urls = [
+  "https://example.com/hook?events=clock_in,clock_out",
+  'https://example.org/hook',
+]
+fiscal_year_start = 4
+vacation_carry_over_limit = 2.5
+week_start = "friday"
+email_allowed_senders = ["boss@example.com", 42]
+webhook_url = "https://example.com/typo"
+
+[otel]
+service_name = "work-clock"
+`
+	if err := os.WriteFile(file, []byte(content), 0o600); err != nil {
+		t.Fatalf("failed to write config file: %v", err)
+	}
+	t.Setenv("CONFIG_FILE", file)
+	t.Setenv("FISCAL_YEAR_START", "10")
+
+	loaded, err := LoadSettings()
+	if loaded.WorkdayDuration != 7*time.Hour+30*time.Minute {
+		t.Errorf("expected the workday duration of the file, got %s", loaded.WorkdayDuration)
+	}
+	// Entries of arrays are not split at their commas
+	if len(loaded.WebhookURLs) != 2 || loaded.WebhookURLs[0] != "https://example.com/hook?events=clock_in,clock_out" || loaded.WebhookURLs[1] != "https://example.org/hook" {
+		t.Errorf("expected the webhook URLs of the file, got %v", loaded.WebhookURLs)
+	}
+	if loaded.VacationCarryOverLimit != 2.5 {
+		t.Errorf("expected the carry-over limit of the file, got %v", loaded.VacationCarryOverLimit)
+	}
+	if loaded.FiscalYearStart != time.October {
+		t.Errorf("expected the environment to override the file, got %s", loaded.FiscalYearStart)
+	}
+	if loaded.TracingServiceName != "work-clock" {
+		t.Errorf("expected the service name of the otel table, got %s", loaded.TracingServiceName)
+	}
+	if loaded.WeekStart != time.Monday {
+		t.Errorf("expected the default week start for an invalid value, got %s", loaded.WeekStart)
+	}
+
+	if err == nil {
+		t.Fatal("expected the invalid value and the unknown key to be reported")
+	}
+	for _, expected := range []string{
+		"config.toml: week_start: invalid value 'friday'",
+		"config.toml: email_allowed_senders: invalid value '[boss@example.com 42]', expected an array of strings",
+		"config.toml: unknown setting 'webhook_url'",
+	} {
+		if !strings.Contains(err.Error(), expected) {
+			t.Errorf("expected the error to contain %q, got %v", expected, err)
+		}
+	}
+}
+
+func TestParseConfigFileErrors(t *testing.T) {
+	tests := map[string]string{
+		"key":                      "line 1: unexpected EOF; expected key separator '='",
+		"key = \"unterminated":     "line 1 (last key \"key\"): unexpected EOF",
+		"[table":                   "line 1: expected '.' or ']' to end table name",
+		"key = [\"a\" \"b\"]":      "line 1 (last key \"key\"): expected a comma (',') or array terminator (']')",
+		"a = 1\na = 2":             "line 2 (last key \"a\"): Key 'a' has already been defined",
+		"key = two words":          "line 1 (last key \"key\"): expected value",
+		"key = \"value\" trailing": "line 1: expected a top-level item to end with a newline",
+
+		"otel_service_name = 'a'\n[otel]\nservice_name = 'b'": "'otel.service_name' and 'otel_service_name' are the same setting",
+	}
+
+	for content, expected := range tests {
+		if _, err := parseConfigFile([]byte(content)); err == nil || !strings.Contains(err.Error(), expected) {
+			t.Errorf("expected %q to fail with %q, got %v", content, expected, err)
+		}
+	}
+}
@@ -0,0 +1,179 @@
+// Cost Centers Module for PocketBase
+//
+// This module rolls up the worked time per cost center and month for the allocation by the
+// finance department. Users and projects are assigned to a cost center with the cost center code
+// in their 'cost_center' field. A session is allocated to the cost center of its project, or, if
+// the project has none, to the cost center of the owner of its clock (see the delegation module).
+// Sessions without either are listed as unassigned, so the rollup always adds up to the total.
+//
+// Sessions are allocated to the month they start in and count with their category factor, just
+// like in the daily summaries. Open sessions count up to now.
+//
+// The cost center of a user can only be changed by superusers, otherwise users could move their
+// time to another cost center themselves.
+package backend
+
+import (
+	"cmp"
+	"fmt"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/pocketbase/pocketbase/apis"
+	"github.com/pocketbase/pocketbase/core"
+)
+
+// CostCenterMonth is the time allocated to a cost center in a month.
+type CostCenterMonth struct {
+	Month         string `json:"month"`          // Month (YYYY-MM)
+	WorkedSeconds int64  `json:"worked_seconds"` // Time that counts as work time
+	Worked        string `json:"worked"`         // Worked time formatted in the configured duration format
+	Sessions      int    `json:"sessions"`       // Number of sessions starting in the month
+}
+
+// CostCenterRollup is the time allocated to a cost center.
+type CostCenterRollup struct {
+	CostCenter    string            `json:"cost_center"`    // Code of the cost center, empty for unassigned time
+	WorkedSeconds int64             `json:"worked_seconds"` // Time that counts as work time within the range
+	Worked        string            `json:"worked"`         // Worked time formatted in the configured duration format
+	Months        []CostCenterMonth `json:"months"`         // Time per month with sessions, sorted by month
+}
+
+// RegisterCostCentersAPI registers the cost center rollup endpoint and the hooks protecting the
+// cost centers of users with the PocketBase server.
+// It creates the following route, only accessible for superusers:
+// - GET /api/cost_centers/rollup?from=&to= - Lists the CostCenterRollup of each cost center with time in the range, sorted by code with the unassigned time last
+//
+// Parameters:
+// - app: The PocketBase application instance
+func RegisterCostCentersAPI(app core.App) {
+	app.OnRecordCreateRequest("users").BindFunc(func(e *core.RecordRequestEvent) error {
+		if !e.HasSuperuserAuth() && e.Record.GetString("cost_center") != "" {
+			return e.Error(http.StatusForbidden, "Only superusers can change the cost center of a user", nil)
+		}
+		return e.Next()
+	})
+	app.OnRecordUpdateRequest("users").BindFunc(func(e *core.RecordRequestEvent) error {
+		if !e.HasSuperuserAuth() && e.Record.GetString("cost_center") != e.Record.Original().GetString("cost_center") {
+			return e.Error(http.StatusForbidden, "Only superusers can change the cost center of a user", nil)
+		}
+		return e.Next()
+	})
+
+	app.OnServe().BindFunc(func(se *core.ServeEvent) error {
+		se.Router.GET("/api/cost_centers/rollup", func(e *core.RequestEvent) error {
+			from, to, err := parseTimeRangeParams(e.Request.URL.Query().Get("from"), e.Request.URL.Query().Get("to"))
+			if err != nil {
+				return e.Error(http.StatusBadRequest, err.Error(), nil)
+			}
+
+			rollups, err := getCostCenterRollups(app, from, to, clockNow(app))
+			if err != nil {
+				return e.Error(http.StatusInternalServerError, fmt.Sprintf("Failed to create cost center rollup: %v", err), err)
+			}
+
+			return e.JSON(http.StatusOK, rollups)
+		}).Bind(apis.RequireSuperuserAuth())
+
+		return se.Next()
+	})
+}
+
+// getCostCenterRollups allocates the sessions of all clocks within a range to the cost centers.
+//
+// Parameters:
+// - app: The App interface (typically a PocketBase instance or transaction)
+// - from: The start of the range (inclusive)
+// - to: The end of the range (exclusive)
+// - now: The current time, the end of open sessions
+//
+// Returns:
+// - The rollup of each cost center with time in the range, the unassigned time last
+// - An error if a query fails
+func getCostCenterRollups(app core.App, from, to, now time.Time) ([]CostCenterRollup, error) {
+	projects, err := app.FindAllRecords("projects")
+	if err != nil {
+		return nil, fmt.Errorf("failed to find projects: %w", err)
+	}
+	clocks, err := app.FindAllRecords("clocks")
+	if err != nil {
+		return nil, fmt.Errorf("failed to find clocks: %w", err)
+	}
+
+	projectCostCenters := map[string]string{}
+	for _, project := range projects {
+		projectCostCenters[project.Id] = project.GetString("cost_center")
+	}
+
+	// The default clock has no owner, so only the projects of its sessions are allocated
+	clockCostCenters := map[string]string{"": ""}
+	for _, clock := range clocks {
+		clockCostCenters[clock.Id] = ""
+		if owner := clock.GetString("owner"); owner != "" {
+			if user, err := app.FindRecordById("users", owner); err == nil {
+				clockCostCenters[clock.Id] = user.GetString("cost_center")
+			}
+		}
+	}
+
+	months := map[string]map[string]*CostCenterMonth{}
+	for clockID, clockCostCenter := range clockCostCenters {
+		sessions, err := findWorkSessions(app, clockID, from, to)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, session := range sessions {
+			costCenter := cmp.Or(projectCostCenters[session.ClockIn.GetString("project")], clockCostCenter)
+			month := session.Start().In(time.Local).Format("2006-01")
+			worked := time.Duration(float64(session.Duration(now)) * categoryFactor(session.ClockIn.GetString("category")))
+
+			if months[costCenter] == nil {
+				months[costCenter] = map[string]*CostCenterMonth{}
+			}
+			if months[costCenter][month] == nil {
+				months[costCenter][month] = &CostCenterMonth{Month: month}
+			}
+			months[costCenter][month].WorkedSeconds += int64(worked.Seconds())
+			months[costCenter][month].Sessions++
+		}
+	}
+
+	rollups := make([]CostCenterRollup, 0, len(months))
+	for costCenter, costCenterMonths := range months {
+		rollups = append(rollups, newCostCenterRollup(costCenter, costCenterMonths))
+	}
+
+	// The unassigned time has an empty code and is listed last
+	slices.SortFunc(rollups, func(a, b CostCenterRollup) int {
+		if (a.CostCenter == "") != (b.CostCenter == "") {
+			return cmp.Compare(b.CostCenter, a.CostCenter)
+		}
+		return cmp.Compare(a.CostCenter, b.CostCenter)
+	})
+
+	return rollups, nil
+}
+
+// newCostCenterRollup creates the rollup of a cost center from its months.
+//
+// Parameters:
+// - costCenter: The code of the cost center, empty for unassigned time
+// - months: The time of the cost center by month (YYYY-MM)
+//
+// Returns:
+// - The rollup with the months sorted and formatted
+func newCostCenterRollup(costCenter string, months map[string]*CostCenterMonth) CostCenterRollup {
+	rollup := CostCenterRollup{CostCenter: costCenter, Months: []CostCenterMonth{}}
+	for _, month := range months {
+		month.Worked = formatResponseDuration(month.WorkedSeconds)
+		rollup.WorkedSeconds += month.WorkedSeconds
+		rollup.Months = append(rollup.Months, *month)
+	}
+	slices.SortFunc(rollup.Months, func(a, b CostCenterMonth) int {
+		return cmp.Compare(a.Month, b.Month)
+	})
+	rollup.Worked = formatResponseDuration(rollup.WorkedSeconds)
+	return rollup
+}
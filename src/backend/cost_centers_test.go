@@ -0,0 +1,115 @@
+package backend
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/yerTools/simple-frontend-stack/src/backend/backendtest"
+)
+
+func TestCostCenterRollup(t *testing.T) {
+	app := backendtest.NewApp(t)
+	RegisterCostCentersAPI(app)
+	handler := backendtest.NewHandler(t, app)
+
+	newRecord := func(collection string, values map[string]any) *core.Record {
+		c, err := app.FindCollectionByNameOrId(collection)
+		if err != nil {
+			t.Fatalf("failed to find %s collection: %v", collection, err)
+		}
+		record := core.NewRecord(c)
+		record.Load(values)
+		if c.IsAuth() {
+			record.SetPassword("password123")
+		}
+		if err := app.Save(record); err != nil {
+			t.Fatalf("failed to save %s record: %v", collection, err)
+		}
+		return record
+	}
+
+	user := newRecord("users", map[string]any{"email": "user@example.com", "cost_center": "4100"})
+	project := newRecord("projects", map[string]any{"name": "Prototype", "cost_center": "4200"})
+	clock := newRecord("clocks", map[string]any{"name": "user", "owner": user.Id})
+	superuser := newRecord(core.CollectionNameSuperusers, map[string]any{"email": "admin@example.com"})
+
+	service := workClockServiceOf(app)
+	add := func(clockID string, start time.Time, hours int) {
+		if err := service.AddClockInOutPair(clockID, start, start.Add(time.Duration(hours)*time.Hour)); err != nil {
+			t.Fatalf("failed to add session: %v", err)
+		}
+	}
+	add(clock.Id, time.Date(2025, 3, 3, 9, 0, 0, 0, time.Local), 4)
+	add(clock.Id, time.Date(2025, 4, 1, 9, 0, 0, 0, time.Local), 2)
+	add(clock.Id, time.Date(2025, 4, 2, 9, 0, 0, 0, time.Local), 3)
+	add("", time.Date(2025, 4, 3, 9, 0, 0, 0, time.Local), 1)
+
+	// The project takes precedence over the cost center of the user
+	sessions, err := findWorkSessions(app, clock.Id, time.Date(2025, 4, 2, 0, 0, 0, 0, time.Local), time.Time{})
+	if err != nil || len(sessions) != 1 {
+		t.Fatalf("expected one session, got %d: %v", len(sessions), err)
+	}
+	sessions[0].ClockIn.Set("project", project.Id)
+	if err := app.Save(sessions[0].ClockIn); err != nil {
+		t.Fatalf("failed to set project: %v", err)
+	}
+
+	request := func(method string, path string, body string, auth *core.Record) *httptest.ResponseRecorder {
+		request := httptest.NewRequest(method, path, strings.NewReader(body))
+		request.Header.Set("Content-Type", "application/json")
+		token, err := auth.NewAuthToken()
+		if err != nil {
+			t.Fatalf("failed to create token: %v", err)
+		}
+		request.Header.Set("Authorization", token)
+
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, request)
+		return recorder
+	}
+
+	query := url.Values{
+		"from": {time.Date(2025, 3, 1, 0, 0, 0, 0, time.Local).Format(time.RFC3339)},
+		"to":   {time.Date(2025, 5, 1, 0, 0, 0, 0, time.Local).Format(time.RFC3339)},
+	}
+	if recorder := request(http.MethodGet, "/api/cost_centers/rollup?"+query.Encode(), "", user); recorder.Code != http.StatusForbidden {
+		t.Errorf("expected users to be rejected with 403, got %d", recorder.Code)
+	}
+
+	recorder := request(http.MethodGet, "/api/cost_centers/rollup?"+query.Encode(), "", superuser)
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", recorder.Code, recorder.Body.String())
+	}
+	var rollups []CostCenterRollup
+	if err := json.Unmarshal(recorder.Body.Bytes(), &rollups); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+
+	if len(rollups) != 3 || rollups[0].CostCenter != "4100" || rollups[1].CostCenter != "4200" || rollups[2].CostCenter != "" {
+		t.Fatalf("expected 4100, 4200 and the unassigned time, got %+v", rollups)
+	}
+	if months := rollups[0].Months; rollups[0].WorkedSeconds != 6*3600 || len(months) != 2 || months[0].WorkedSeconds != 4*3600 || months[1].WorkedSeconds != 2*3600 {
+		t.Errorf("expected 4 hours in March and 2 hours in April for the user's cost center, got %+v", rollups[0])
+	}
+	if rollups[1].WorkedSeconds != 3*3600 || len(rollups[1].Months) != 1 || rollups[1].Months[0].Month != "2025-04" {
+		t.Errorf("expected 3 hours in April for the project's cost center, got %+v", rollups[1])
+	}
+	if rollups[2].WorkedSeconds != 3600 || rollups[2].Months[0].Sessions != 1 {
+		t.Errorf("expected the hour of the default clock to be unassigned, got %+v", rollups[2])
+	}
+
+	// Users can't move their time to another cost center
+	path := "/api/collections/users/records/" + user.Id
+	if recorder := request(http.MethodPatch, path, `{"cost_center":"4200"}`, user); recorder.Code != http.StatusForbidden {
+		t.Errorf("expected users changing their cost center to be rejected with 403, got %d: %s", recorder.Code, recorder.Body.String())
+	}
+	if recorder := request(http.MethodPatch, path, `{"cost_center":"4200"}`, superuser); recorder.Code != http.StatusOK {
+		t.Errorf("expected superusers to change the cost center, got %d: %s", recorder.Code, recorder.Body.String())
+	}
+}
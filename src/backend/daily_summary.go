@@ -0,0 +1,673 @@
+// Daily Summary Module for PocketBase
+//
+// This module maintains the daily_summary collection: the worked time, breaks, target and overtime
+// of each day and clock. Whenever a work clock record is created, modified or deleted, only the
+// summaries of the affected days are recomputed, so reports over long ranges can read one
+// summary per day instead of pairing all raw records.
+//
+// A day's summary covers the closed sessions starting on that day (in local time). The worked
+// time is the time that counts as work time (see categoryFactor), breaks are the gaps between the
+// sessions of the day, and the target is the one of the work schedule valid on the day (see the
+// work schedules module), or none outside of the employment (see the employment periods module)
+// and reduced by holidays and absences (see the absences module).
+// Overtime doesn't accrue during rule periods without overtime (see the compliance rules module).
+// Open sessions are summarized once they are closed.
+//
+// Each summary also carries the flextime balance, the overtime accumulated up to and including
+// the day. A change to a past record only recomputes the affected days and then walks the
+// balances forward, saving the summaries whose balance actually changed. Days without records
+// between two summaries are filled in, so their target counts towards the balance. The records of
+// an import or of its rollback are collected instead and recomputed once the import run is
+// recorded, from the earliest to the latest day the run changed. The status of the latest
+// recomputation is kept in memory and shown in the admin overview.
+package backend
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/apis"
+	"github.com/pocketbase/pocketbase/core"
+)
+
+// DailySummaryEntry is the summary of a day as returned by the daily report endpoint.
+type DailySummaryEntry struct {
+	Date            string `json:"date"`             // Day of the summary (YYYY-MM-DD)
+	WorkedSeconds   int64  `json:"worked_seconds"`   // Time that counts as work time
+	Worked          string `json:"worked"`           // Worked time formatted in the configured duration format
+	BreakSeconds    int64  `json:"break_seconds"`    // Time between the sessions of the day
+	TargetSeconds   int64  `json:"target_seconds"`   // Time that should be worked on the day
+	OvertimeSeconds int64  `json:"overtime_seconds"` // Worked minus target time, negative for undertime
+	Overtime        string `json:"overtime"`         // Overtime formatted in the configured duration format
+	BalanceSeconds  int64  `json:"balance_seconds"`  // Overtime accumulated up to and including the day
+	Balance         string `json:"balance"`          // Balance formatted in the configured duration format
+	Sessions        int    `json:"sessions"`         // Number of closed sessions starting on the day
+}
+
+// DailySummaryStatus describes the recomputations of the daily summaries since the server started.
+type DailySummaryStatus struct {
+	Updated    *time.Time `json:"updated"`     // Time of the latest recomputation, null if none happened yet
+	Days       int        `json:"days"`        // Number of summaries saved by the latest recomputation
+	DurationMs int64      `json:"duration_ms"` // Duration of the latest recomputation in milliseconds
+	Failures   int        `json:"failures"`    // Number of failed recomputations
+	LastError  string     `json:"last_error"`  // Error of the latest failed recomputation, empty without failures
+}
+
+// dailySummaryStatus is the status of the recomputations, guarded by dailySummaryStatusMutex.
+var dailySummaryStatus = DailySummaryStatus{}
+var dailySummaryStatusMutex = sync.Mutex{}
+
+// importRunChanges contains the range of records changed by each running import or rollback per
+// clock, by the ID of the import run, guarded by importRunChangesMutex.
+var importRunChanges = map[string]map[string]*importRunChange{}
+var importRunChangesMutex = sync.Mutex{}
+
+// importRunChange is the range of records of a clock changed by an import run.
+type importRunChange struct {
+	First *core.Record // Earliest changed record
+	Last  *core.Record // Latest changed record
+}
+
+// RegisterDailySummaryAPI registers the hooks maintaining the daily summaries and the daily
+// summary endpoints with the PocketBase server.
+// It creates the following routes:
+// - GET /api/work_clock/report/daily?from=&to=&clock= - Lists the summaries of the days within the range, supports conditional requests
+// - POST /api/work_clock/daily_summary/rebuild - Recomputes all summaries of the clock 'clock', only accessible for superusers
+//
+// Parameters:
+// - app: The PocketBase application instance
+func RegisterDailySummaryAPI(app core.App) {
+	update := func(e *core.RecordEvent) error {
+		if isImportRunChange(e.App, e.Record) {
+			rememberImportRunChange(e.Record)
+			return e.Next()
+		}
+
+		started := time.Now()
+		days, err := updateDailySummariesOfRecord(e.App, e.Record)
+		recordDailySummaryUpdate(started, days, err)
+		if err != nil {
+			// The record change itself succeeded, a rebuild repairs the summaries
+			e.App.Logger().Error("failed to update daily summaries", "record", e.Record.Id, "error", err)
+		}
+		return e.Next()
+	}
+
+	app.OnRecordAfterCreateSuccess("work_clock").BindFunc(update)
+	app.OnRecordAfterUpdateSuccess("work_clock").BindFunc(update)
+	app.OnRecordAfterDeleteSuccess("work_clock").BindFunc(update)
+
+	updateImportRun := func(e *core.RecordEvent) error {
+		importRunChangesMutex.Lock()
+		changes := importRunChanges[e.Record.Id]
+		delete(importRunChanges, e.Record.Id)
+		importRunChangesMutex.Unlock()
+
+		if len(changes) > 0 {
+			started := time.Now()
+			days, err := updateDailySummariesOfImportRun(e.App, changes)
+			recordDailySummaryUpdate(started, days, err)
+			if err != nil {
+				e.App.Logger().Error("failed to update daily summaries", "import_run", e.Record.Id, "error", err)
+			}
+		}
+		return e.Next()
+	}
+	app.OnRecordAfterCreateSuccess("import_runs").BindFunc(updateImportRun)
+	app.OnRecordAfterUpdateSuccess("import_runs").BindFunc(updateImportRun)
+
+	app.OnServe().BindFunc(func(se *core.ServeEvent) error {
+		se.Router.GET("/api/work_clock/report/daily", func(e *core.RequestEvent) error {
+			from, to, err := parseTimeRangeParams(e.Request.URL.Query().Get("from"), e.Request.URL.Query().Get("to"))
+			if err != nil {
+				return e.Error(http.StatusBadRequest, err.Error(), nil)
+			}
+
+			clockID, err := requestClock(app, e)
+			if err != nil {
+				return err
+			}
+
+			summaries, err := findDailySummaries(app, clockID, from, to)
+			if err != nil {
+				return e.Error(http.StatusInternalServerError, fmt.Sprintf("Failed to find daily summaries: %v", err), err)
+			}
+
+			return respondConditionalJSON(e, summaries, workClockLastModified(app))
+		})
+
+		se.Router.POST("/api/work_clock/daily_summary/rebuild", func(e *core.RequestEvent) error {
+			clockID, err := requestClock(app, e)
+			if err != nil {
+				return err
+			}
+
+			started := time.Now()
+			days, err := rebuildDailySummaries(app, clockID, started)
+			recordDailySummaryUpdate(started, days, err)
+			if err != nil {
+				return e.Error(http.StatusInternalServerError, fmt.Sprintf("Failed to rebuild daily summaries: %v", err), err)
+			}
+			return callSucceeded(e)
+		}).Bind(apis.RequireSuperuserAuth())
+
+		return se.Next()
+	})
+}
+
+// updateDailySummariesOfRecord recomputes the summaries of the days a changed work clock record
+// affects: the day of the record and the day its session starts, since a confirmed session can
+// span several days. Modified records also affect the days of their former timestamp and clock.
+// Afterwards, the balances of each affected clock are walked forward from its earliest affected day.
+//
+// Parameters:
+// - app: The App interface (typically a PocketBase instance or transaction)
+// - record: The created, modified or deleted work clock record
+//
+// Returns:
+// - The number of saved summaries
+// - An error if recomputing a summary or a balance fails
+func updateDailySummariesOfRecord(app core.App, record *core.Record) (int, error) {
+	// Days are identified by their date, so the same day is recomputed only once
+	type clockDay struct {
+		ClockID string
+		Date    string
+	}
+
+	updated := map[clockDay]bool{}
+	earliest := map[string]time.Time{}
+	saved := 0
+	for _, version := range []*core.Record{record, record.Original()} {
+		// The original of a created record is still empty
+		if version.GetDateTime("timestamp").IsZero() {
+			continue
+		}
+
+		clockID := version.GetString("clock")
+		days := []time.Time{startOfLocalDay(version.GetDateTime("timestamp").Time())}
+
+		// A clock out record ends the session of the preceding clock in record, whose day the session counts for
+		clockIn, err := findSessionClockIn(app, version)
+		if err != nil {
+			return saved, err
+		}
+		if clockIn != nil {
+			days = append(days, startOfLocalDay(clockIn.GetDateTime("timestamp").Time()))
+		}
+
+		for _, d := range days {
+			if first, ok := earliest[clockID]; !ok || d.Before(first) {
+				earliest[clockID] = d
+			}
+
+			key := clockDay{ClockID: clockID, Date: d.Format(time.DateOnly)}
+			if updated[key] {
+				continue
+			}
+			updated[key] = true
+
+			if err := updateDailySummary(app, clockID, d); err != nil {
+				return saved, err
+			}
+			saved++
+		}
+	}
+
+	for clockID, day := range earliest {
+		filled, err := fillDailySummaryGap(app, clockID, day)
+		saved += filled
+		if err != nil {
+			return saved, err
+		}
+
+		if filled > 0 {
+			day = day.AddDate(0, 0, -filled)
+		}
+
+		balances, err := updateDailySummaryBalances(app, clockID, day)
+		saved += balances
+		if err != nil {
+			return saved, err
+		}
+	}
+
+	return saved, nil
+}
+
+// rememberImportRunChange extends the range of records changed by the import run of a record,
+// so the summaries are recomputed once the import run is recorded.
+//
+// Parameters:
+// - record: The created, modified or deleted work clock record
+func rememberImportRunChange(record *core.Record) {
+	importRunChangesMutex.Lock()
+	defer importRunChangesMutex.Unlock()
+
+	runID := record.GetString("import_run")
+	for _, version := range []*core.Record{record, record.Original()} {
+		timestamp := version.GetDateTime("timestamp").Time()
+		if timestamp.IsZero() {
+			continue
+		}
+
+		if importRunChanges[runID] == nil {
+			importRunChanges[runID] = map[string]*importRunChange{}
+		}
+		clockID := version.GetString("clock")
+		change, ok := importRunChanges[runID][clockID]
+		if !ok {
+			importRunChanges[runID][clockID] = &importRunChange{First: version, Last: version}
+			continue
+		}
+		if timestamp.Before(change.First.GetDateTime("timestamp").Time()) {
+			change.First = version
+		}
+		if timestamp.After(change.Last.GetDateTime("timestamp").Time()) {
+			change.Last = version
+		}
+	}
+}
+
+// updateDailySummariesOfImportRun recomputes the summaries of the days an import run changed:
+// every day from the session of its earliest record to its latest record. Afterwards, the
+// balances of each clock are walked forward from its earliest day.
+//
+// Parameters:
+// - app: The App interface (typically a PocketBase instance or transaction)
+// - changes: The range of changed records by the ID of their clock
+//
+// Returns:
+// - The number of saved summaries
+// - An error if recomputing a summary or a balance fails
+func updateDailySummariesOfImportRun(app core.App, changes map[string]*importRunChange) (int, error) {
+	saved := 0
+	for clockID, change := range changes {
+		first := startOfLocalDay(change.First.GetDateTime("timestamp").Time())
+
+		// The earliest record may be a clock out ending a session that started before it
+		clockIn, err := findSessionClockIn(app, change.First)
+		if err != nil {
+			return saved, err
+		}
+		if clockIn != nil {
+			first = startOfLocalDay(clockIn.GetDateTime("timestamp").Time())
+		}
+
+		filled, err := fillDailySummaryGap(app, clockID, first)
+		saved += filled
+		if err != nil {
+			return saved, err
+		}
+
+		last := startOfLocalDay(change.Last.GetDateTime("timestamp").Time())
+		for day := first; !day.After(last); day = day.AddDate(0, 0, 1) {
+			if err := updateDailySummary(app, clockID, day); err != nil {
+				return saved, err
+			}
+			saved++
+		}
+
+		balances, err := updateDailySummaryBalances(app, clockID, first.AddDate(0, 0, -filled))
+		saved += balances
+		if err != nil {
+			return saved, err
+		}
+	}
+
+	return saved, nil
+}
+
+// updateDailySummariesFrom recomputes the existing summaries of a clock from a day on and walks
+// their balances forward, e.g. after the targets of these days changed.
+//
+// Parameters:
+// - app: The App interface (typically a PocketBase instance or transaction)
+// - clockID: The ID of the clock, an empty string for the default clock
+// - from: The local midnight of the first recomputed day
+//
+// Returns:
+// - The number of saved summaries
+// - An error if the summaries could not be retrieved or recomputing a summary or a balance fails
+func updateDailySummariesFrom(app core.App, clockID string, from time.Time) (int, error) {
+	summaries, err := app.FindRecordsByFilter("daily_summary", "clock = {:clock} && date >= {:from}", "+date", 0, 0, dbx.Params{
+		"clock": clockParam(clockID),
+		"from":  dateTimeParam(from),
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to find daily summaries: %w", err)
+	}
+
+	saved := 0
+	for _, summary := range summaries {
+		if err := updateDailySummary(app, clockID, startOfLocalDay(summary.GetDateTime("date").Time())); err != nil {
+			return saved, err
+		}
+		saved++
+	}
+
+	balances, err := updateDailySummaryBalances(app, clockID, from)
+	return saved + balances, err
+}
+
+// updateDailySummariesFromRecordDate recomputes the summaries of the days a changed record with
+// a date field affects, e.g. a work schedule: all summarized days of its clock from its date on.
+// Modified records also affect the days from their former date and clock.
+//
+// Parameters:
+// - app: The App interface (typically a PocketBase instance or transaction)
+// - record: The created, modified or deleted record
+// - field: The name of the date field (YYYY-MM-DD) of the record
+//
+// Returns:
+// - The number of saved summaries
+// - An error if recomputing a summary or a balance fails
+func updateDailySummariesFromRecordDate(app core.App, record *core.Record, field string) (int, error) {
+	earliest := map[string]time.Time{}
+	for _, version := range []*core.Record{record, record.Original()} {
+		day, err := time.ParseInLocation(time.DateOnly, version.GetString(field), time.Local)
+		if err != nil {
+			continue
+		}
+
+		clockID := version.GetString("clock")
+		if first, ok := earliest[clockID]; !ok || day.Before(first) {
+			earliest[clockID] = day
+		}
+	}
+
+	saved := 0
+	for clockID, day := range earliest {
+		updated, err := updateDailySummariesFrom(app, clockID, day)
+		saved += updated
+		if err != nil {
+			return saved, err
+		}
+	}
+
+	return saved, nil
+}
+
+// fillDailySummaryGap creates the missing summaries between the latest summary before a day and
+// the day, so workdays without records count towards the balance.
+//
+// Parameters:
+// - app: The App interface (typically a PocketBase instance or transaction)
+// - clockID: The ID of the clock, an empty string for the default clock
+// - day: The local midnight of the day the gap ends before
+//
+// Returns:
+// - The number of created summaries, which are the days directly before the day
+// - An error if the latest summary could not be retrieved or a summary could not be saved
+func fillDailySummaryGap(app core.App, clockID string, day time.Time) (int, error) {
+	previous, err := app.FindRecordsByFilter("daily_summary", "clock = {:clock} && date < {:day}", "-date", 1, 0, dbx.Params{
+		"clock": clockParam(clockID),
+		"day":   dateTimeParam(day),
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to find previous daily summary: %w", err)
+	}
+	if len(previous) == 0 {
+		return 0, nil
+	}
+
+	filled := 0
+	for d := startOfLocalDay(previous[0].GetDateTime("date").Time()).AddDate(0, 0, 1); d.Before(day); d = d.AddDate(0, 0, 1) {
+		if err := updateDailySummary(app, clockID, d); err != nil {
+			return filled, err
+		}
+		filled++
+	}
+
+	return filled, nil
+}
+
+// updateDailySummaryBalances walks the balances of a clock forward from a day. The balance of a
+// day is the balance of the previous summary plus the overtime of the day. Only summaries whose
+// balance changed are saved, so the walk is cheap if a change didn't affect the overtime.
+//
+// Parameters:
+// - app: The App interface (typically a PocketBase instance or transaction)
+// - clockID: The ID of the clock, an empty string for the default clock
+// - from: The local midnight of the first day whose balance is recomputed
+//
+// Returns:
+// - The number of saved summaries
+// - An error if the summaries could not be retrieved or saved
+func updateDailySummaryBalances(app core.App, clockID string, from time.Time) (int, error) {
+	params := dbx.Params{
+		"clock": clockParam(clockID),
+		"from":  dateTimeParam(from),
+	}
+
+	previous, err := app.FindRecordsByFilter("daily_summary", "clock = {:clock} && date < {:from}", "-date", 1, 0, params)
+	if err != nil {
+		return 0, fmt.Errorf("failed to find previous daily summary: %w", err)
+	}
+
+	var balance int64
+	if len(previous) > 0 {
+		balance = int64(previous[0].GetInt("balance_seconds"))
+	}
+
+	records, err := app.FindRecordsByFilter("daily_summary", "clock = {:clock} && date >= {:from}", "+date", 0, 0, params)
+	if err != nil {
+		return 0, fmt.Errorf("failed to find daily summaries: %w", err)
+	}
+
+	saved := 0
+	for _, record := range records {
+		balance += int64(record.GetInt("overtime_seconds"))
+		if int64(record.GetInt("balance_seconds")) == balance {
+			continue
+		}
+
+		record.Set("balance_seconds", balance)
+		if err := app.Save(record); err != nil {
+			return saved, fmt.Errorf("failed to save balance of daily summary with id '%s': %w", record.Id, err)
+		}
+		saved++
+	}
+
+	return saved, nil
+}
+
+// recordDailySummaryUpdate records the outcome of a recomputation in the daily summary status.
+//
+// Parameters:
+// - started: The start of the recomputation
+// - days: The number of saved summaries
+// - err: The error the recomputation failed with, nil if it succeeded
+func recordDailySummaryUpdate(started time.Time, days int, err error) {
+	dailySummaryStatusMutex.Lock()
+	defer dailySummaryStatusMutex.Unlock()
+
+	dailySummaryStatus.Updated = &started
+	dailySummaryStatus.Days = days
+	dailySummaryStatus.DurationMs = time.Since(started).Milliseconds()
+	if err != nil {
+		dailySummaryStatus.Failures++
+		dailySummaryStatus.LastError = err.Error()
+	}
+}
+
+// getDailySummaryStatus returns the status of the recomputations.
+//
+// Returns:
+// - A copy of the daily summary status
+func getDailySummaryStatus() DailySummaryStatus {
+	dailySummaryStatusMutex.Lock()
+	defer dailySummaryStatusMutex.Unlock()
+
+	return dailySummaryStatus
+}
+
+// updateDailySummary recomputes the summary of a day and clock.
+//
+// Parameters:
+// - app: The App interface (typically a PocketBase instance or transaction)
+// - clockID: The ID of the clock, an empty string for the default clock
+// - day: The local midnight starting the day
+//
+// Returns:
+// - An error if the sessions, work schedules, employment, rule periods or days off could not be retrieved or the summary could not be saved
+func updateDailySummary(app core.App, clockID string, day time.Time) error {
+	sessions, err := findWorkSessions(app, clockID, day, day.AddDate(0, 0, 1))
+	if err != nil {
+		return err
+	}
+
+	var worked, presence time.Duration
+	var firstStart, lastEnd time.Time
+	count := 0
+	for _, session := range sessions {
+		if session.ClockOut == nil {
+			continue
+		}
+
+		start, end := session.Start(), session.End(time.Time{})
+		duration := end.Sub(start)
+		worked += time.Duration(float64(duration) * categoryFactor(session.ClockIn.GetString("category")))
+		presence += duration
+		if count == 0 {
+			firstStart = start
+		}
+		lastEnd = end
+		count++
+	}
+
+	var breaks time.Duration
+	if count > 0 {
+		breaks = max(lastEnd.Sub(firstStart)-presence, 0)
+	}
+
+	schedules, err := findWorkSchedules(app, clockID)
+	if err != nil {
+		return err
+	}
+	employment, err := findEmploymentPeriods(app, clockID)
+	if err != nil {
+		return err
+	}
+
+	rules, err := findRulePeriods(app, clockID)
+	if err != nil {
+		return err
+	}
+
+	off, err := findDaysOff(app, clockID, day, day.AddDate(0, 0, 1))
+	if err != nil {
+		return err
+	}
+
+	var target time.Duration
+	if employment.includes(day) {
+		target = off.target(schedules, day)
+	}
+	overtime := rules.on(day).overtime(worked - target)
+
+	record, err := app.FindFirstRecordByFilter("daily_summary", "clock = {:clock} && date = {:date}", dbx.Params{
+		"clock": clockParam(clockID),
+		"date":  dateTimeParam(day),
+	})
+	if err != nil {
+		collection, err := app.FindCollectionByNameOrId("daily_summary")
+		if err != nil {
+			return fmt.Errorf("failed to find daily_summary collection: %w", err)
+		}
+
+		record = core.NewRecord(collection)
+		record.Set("clock", clockID)
+		record.Set("date", dateTimeParam(day))
+	}
+
+	record.Set("worked_seconds", int64(worked.Seconds()))
+	record.Set("break_seconds", int64(breaks.Seconds()))
+	record.Set("target_seconds", int64(target.Seconds()))
+	record.Set("overtime_seconds", int64(overtime.Seconds()))
+	record.Set("sessions", count)
+	if err := app.Save(record); err != nil {
+		return fmt.Errorf("failed to save daily summary of %s: %w", day.Format(time.DateOnly), err)
+	}
+
+	return nil
+}
+
+// rebuildDailySummaries recomputes the summaries and balances of all days of a clock, from the
+// day of its first record up to today.
+//
+// Parameters:
+// - app: The App interface (typically a PocketBase instance or transaction)
+// - clockID: The ID of the clock, an empty string for the default clock
+// - now: The current time
+//
+// Returns:
+// - The number of saved summaries
+// - An error if the records could not be retrieved or a summary could not be saved
+func rebuildDailySummaries(app core.App, clockID string, now time.Time) (int, error) {
+	records, err := app.FindRecordsByFilter("work_clock", "clock = {:clock}", "+timestamp", 1, 0, dbx.Params{"clock": clockParam(clockID)})
+	if err != nil {
+		return 0, fmt.Errorf("failed to find first work clock record: %w", err)
+	}
+	if len(records) == 0 {
+		return 0, nil
+	}
+
+	first := startOfLocalDay(records[0].GetDateTime("timestamp").Time())
+	today := startOfLocalDay(now)
+	saved := 0
+	for day := first; !day.After(today); day = day.AddDate(0, 0, 1) {
+		if err := updateDailySummary(app, clockID, day); err != nil {
+			return saved, err
+		}
+		saved++
+	}
+
+	balances, err := updateDailySummaryBalances(app, clockID, first)
+	return saved + balances, err
+}
+
+// findDailySummaries finds the summaries of the days of a clock within a range.
+//
+// Parameters:
+// - app: The App interface (typically a PocketBase instance or transaction)
+// - clockID: The ID of the clock, an empty string for the default clock
+// - from: The start of the range (inclusive)
+// - to: The end of the range (exclusive)
+//
+// Returns:
+// - The summaries sorted by their date
+// - An error if the database query fails
+func findDailySummaries(app core.App, clockID string, from, to time.Time) ([]DailySummaryEntry, error) {
+	records, err := app.FindRecordsByFilter("daily_summary", "clock = {:clock} && date >= {:from} && date < {:to}", "+date", 0, 0, dbx.Params{
+		"clock": clockParam(clockID),
+		"from":  dateTimeParam(from),
+		"to":    dateTimeParam(to),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to find daily summaries: %w", err)
+	}
+
+	summaries := make([]DailySummaryEntry, 0, len(records))
+	for _, record := range records {
+		worked := int64(record.GetInt("worked_seconds"))
+		overtime := int64(record.GetInt("overtime_seconds"))
+		balance := int64(record.GetInt("balance_seconds"))
+		summaries = append(summaries, DailySummaryEntry{
+			Date:            record.GetDateTime("date").Time().In(time.Local).Format(time.DateOnly),
+			WorkedSeconds:   worked,
+			Worked:          formatResponseDuration(worked),
+			BreakSeconds:    int64(record.GetInt("break_seconds")),
+			TargetSeconds:   int64(record.GetInt("target_seconds")),
+			OvertimeSeconds: overtime,
+			Overtime:        formatResponseDuration(overtime),
+			BalanceSeconds:  balance,
+			Balance:         formatResponseDuration(balance),
+			Sessions:        record.GetInt("sessions"),
+		})
+	}
+
+	return summaries, nil
+}
@@ -0,0 +1,172 @@
+// Daily Summary Feed Module for PocketBase
+//
+// This module publishes the daily summaries as an Atom feed, so people who live in their feed
+// reader get an automatic work journal. Each entry is a completed day with at least one session:
+// its worked time, breaks, overtime and balance, followed by the descriptions of its sessions.
+// Today is left out until it is over, so entries don't change after they were read.
+//
+// Feed readers can't authenticate, so the feed is public unless a token is configured (see
+// Settings.FeedToken), in which case the subscribed URL has to contain it.
+package backend
+
+import (
+	"crypto/subtle"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/pocketbase/pocketbase/core"
+)
+
+// dailySummaryFeedDays is the number of days before today the feed covers.
+const dailySummaryFeedDays = 30
+
+// atomFeed is an Atom feed (RFC 4287).
+type atomFeed struct {
+	XMLName xml.Name    `xml:"http://www.w3.org/2005/Atom feed"`
+	ID      string      `xml:"id"`
+	Title   string      `xml:"title"`
+	Updated string      `xml:"updated"`
+	Link    atomLink    `xml:"link"`
+	Author  atomAuthor  `xml:"author"`
+	Entries []atomEntry `xml:"entry"`
+}
+
+// atomLink is the link of an Atom feed to itself.
+type atomLink struct {
+	Rel  string `xml:"rel,attr"`
+	Href string `xml:"href,attr"`
+}
+
+// atomAuthor is the author of an Atom feed.
+type atomAuthor struct {
+	Name string `xml:"name"`
+}
+
+// atomEntry is an entry of an Atom feed.
+type atomEntry struct {
+	ID      string      `xml:"id"`
+	Title   string      `xml:"title"`
+	Updated string      `xml:"updated"`
+	Content atomContent `xml:"content"`
+}
+
+// atomContent is the plain text content of an Atom entry.
+type atomContent struct {
+	Type string `xml:"type,attr"`
+	Text string `xml:",chardata"`
+}
+
+// RegisterDailySummaryFeedAPI registers the daily summary feed endpoint with the PocketBase server.
+// It creates the following route:
+// - GET /api/work_clock/feed.atom?token=&clock= - Lists the completed days of the last 30 days as Atom feed, 'token' is required if configured
+//
+// Parameters:
+// - app: The PocketBase application instance
+func RegisterDailySummaryFeedAPI(app core.App) {
+	app.OnServe().BindFunc(func(se *core.ServeEvent) error {
+		se.Router.GET("/api/work_clock/feed.atom", func(e *core.RequestEvent) error {
+			if settings.FeedToken != "" && subtle.ConstantTimeCompare([]byte(e.Request.URL.Query().Get("token")), []byte(settings.FeedToken)) != 1 {
+				return e.Error(http.StatusForbidden, "Invalid feed token", nil)
+			}
+
+			clockID, err := requestClock(app, e)
+			if err != nil {
+				return err
+			}
+
+			// The ID of the feed must not change with the token, while the self link is the subscribed URL
+			baseURL := strings.TrimSuffix(app.Settings().Meta.AppURL, "/")
+			feedID := baseURL + e.Request.URL.Path
+			if clock := e.Request.URL.Query().Get("clock"); clock != "" {
+				feedID += "?clock=" + url.QueryEscape(clock)
+			}
+
+			feed, err := getDailySummaryFeed(app, clockID, feedID, baseURL+e.Request.URL.RequestURI(), requestLocale(e), clockNow(app))
+			if err != nil {
+				return e.Error(http.StatusInternalServerError, fmt.Sprintf("Failed to create feed: %v", err), err)
+			}
+
+			data, err := xml.MarshalIndent(feed, "", "  ")
+			if err != nil {
+				return e.Error(http.StatusInternalServerError, fmt.Sprintf("Failed to create feed: %v", err), err)
+			}
+
+			return e.Blob(http.StatusOK, "application/atom+xml; charset=utf-8", append([]byte(xml.Header), data...))
+		})
+
+		return se.Next()
+	})
+}
+
+// getDailySummaryFeed creates the feed of the completed days of a clock.
+//
+// Parameters:
+// - app: The App interface (typically a PocketBase instance or transaction)
+// - clockID: The ID of the clock, an empty string for the default clock
+// - feedID: The ID of the feed, the entry IDs are derived from it
+// - selfURL: The URL the feed was requested with
+// - locale: The locale the entries are written in
+// - now: The current time
+//
+// Returns:
+// - The feed, newest day first
+// - An error if the summaries or sessions could not be retrieved
+func getDailySummaryFeed(app core.App, clockID string, feedID string, selfURL string, locale string, now time.Time) (*atomFeed, error) {
+	today := startOfLocalDay(now)
+	from := today.AddDate(0, 0, -dailySummaryFeedDays)
+
+	summaries, err := findDailySummaries(app, clockID, from, today)
+	if err != nil {
+		return nil, err
+	}
+
+	sessions, err := findWorkSessions(app, clockID, from, today)
+	if err != nil {
+		return nil, err
+	}
+
+	descriptions := map[string][]string{}
+	for _, session := range sessions {
+		if description := session.ClockIn.GetString("description"); description != "" {
+			date := startOfLocalDay(session.Start()).Format(time.DateOnly)
+			descriptions[date] = append(descriptions[date], description)
+		}
+	}
+
+	feed := &atomFeed{
+		ID:      feedID,
+		Title:   translate(locale, "work journal"),
+		Updated: today.Format(time.RFC3339),
+		Link:    atomLink{Rel: "self", Href: selfURL},
+		Author:  atomAuthor{Name: app.Settings().Meta.AppName},
+	}
+
+	for i := len(summaries) - 1; i >= 0; i-- {
+		summary := summaries[i]
+		if summary.Sessions == 0 {
+			continue
+		}
+
+		day, err := time.ParseInLocation(time.DateOnly, summary.Date, time.Local)
+		if err != nil {
+			return nil, fmt.Errorf("invalid date of daily summary '%s': %w", summary.Date, err)
+		}
+
+		lines := []string{translate(locale, fmt.Sprintf("worked %s, breaks %s, overtime %s, balance %s",
+			summary.Worked, formatResponseDuration(summary.BreakSeconds), summary.Overtime, summary.Balance))}
+		lines = append(lines, descriptions[summary.Date]...)
+
+		feed.Entries = append(feed.Entries, atomEntry{
+			ID:      feedID + "#" + summary.Date,
+			Title:   translate(locale, fmt.Sprintf("%s: %s worked", summary.Date, summary.Worked)),
+			Updated: day.AddDate(0, 0, 1).Format(time.RFC3339),
+			Content: atomContent{Type: "text", Text: strings.Join(lines, "\n")},
+		})
+	}
+
+	return feed, nil
+}
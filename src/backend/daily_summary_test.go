@@ -0,0 +1,146 @@
+package backend
+
+import (
+	"testing"
+	"time"
+
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/yerTools/simple-frontend-stack/src/backend/backendtest"
+)
+
+func TestDailySummariesOfChangedRecords(t *testing.T) {
+	app := backendtest.NewApp(t)
+	RegisterDailySummaryAPI(app)
+
+	// Monday, April 7th 2025 in local time, since days are local
+	monday := time.Date(2025, time.April, 7, 0, 0, 0, 0, time.Local)
+	at := func(day int, hour int) time.Time {
+		return monday.AddDate(0, 0, day).Add(time.Duration(hour) * time.Hour)
+	}
+
+	records := backendtest.AddRecords(t, app,
+		backendtest.Record{Timestamp: at(0, 9), ClockIn: true}, backendtest.Record{Timestamp: at(0, 17)},
+		backendtest.Record{Timestamp: at(1, 9), ClockIn: true}, backendtest.Record{Timestamp: at(1, 17)},
+		backendtest.Record{Timestamp: at(2, 9), ClockIn: true}, backendtest.Record{Timestamp: at(2, 17)},
+	)
+
+	// summaries returns the summaries from Monday to Sunday and checks that the balances are walked forward
+	summaries := func() []DailySummaryEntry {
+		t.Helper()
+		entries, err := findDailySummaries(app, "", monday, monday.AddDate(0, 0, 7))
+		if err != nil {
+			t.Fatalf("failed to find daily summaries: %v", err)
+		}
+		var balance int64
+		for _, entry := range entries {
+			balance += entry.OvertimeSeconds
+			if entry.BalanceSeconds != balance {
+				t.Errorf("expected a balance of %ds on %s, got %ds", balance, entry.Date, entry.BalanceSeconds)
+			}
+		}
+		return entries
+	}
+	worked := func(entries []DailySummaryEntry, day int) time.Duration {
+		t.Helper()
+		date := monday.AddDate(0, 0, day).Format(time.DateOnly)
+		for _, entry := range entries {
+			if entry.Date == date {
+				return time.Duration(entry.WorkedSeconds) * time.Second
+			}
+		}
+		t.Fatalf("expected a summary of %s", date)
+		return 0
+	}
+
+	before := summaries()
+	if len(before) != 3 || worked(before, 0) != 8*time.Hour {
+		t.Fatalf("expected three summaries of 8 hours, got %+v", before)
+	}
+
+	// Modifying Monday walks the balance of the following days forward
+	records[1].Set("timestamp", at(0, 18))
+	if err := app.Save(records[1]); err != nil {
+		t.Fatalf("failed to modify clock out: %v", err)
+	}
+	after := summaries()
+	if worked(after, 0) != 9*time.Hour || after[2].BalanceSeconds != before[2].BalanceSeconds+60*60 {
+		t.Errorf("expected Monday to count 9 hours and Wednesday's balance to grow by an hour, got %+v", after)
+	}
+
+	// Deleting Tuesday's session removes its time from the balance of Wednesday
+	for _, record := range []*core.Record{records[3], records[2]} {
+		if err := app.Delete(record); err != nil {
+			t.Fatalf("failed to delete record: %v", err)
+		}
+	}
+	deleted := summaries()
+	if worked(deleted, 1) != 0 || deleted[2].BalanceSeconds != after[2].BalanceSeconds-8*60*60 {
+		t.Errorf("expected Tuesday to be empty and Wednesday's balance to shrink by 8 hours, got %+v", deleted)
+	}
+
+	// A confirmed session from Thursday to Saturday counts for Thursday, which is recomputed when its
+	// end is corrected two days later
+	long := backendtest.AddRecords(t, app,
+		backendtest.Record{Timestamp: at(3, 8), ClockIn: true},
+		backendtest.Record{Timestamp: at(5, 10)},
+	)
+	if got := worked(summaries(), 3); got != 50*time.Hour {
+		t.Fatalf("expected the long session to count 50 hours on Thursday, got %s", got)
+	}
+	long[1].Set("timestamp", at(5, 12))
+	if err := app.Save(long[1]); err != nil {
+		t.Fatalf("failed to modify clock out: %v", err)
+	}
+	if got := worked(summaries(), 3); got != 52*time.Hour {
+		t.Errorf("expected the corrected session to count 52 hours on Thursday, got %s", got)
+	}
+}
+
+func TestDailySummariesOfImportRuns(t *testing.T) {
+	app := backendtest.NewApp(t)
+	RegisterDailySummaryAPI(app)
+
+	// Monday, April 7th 2025 in local time, since days are local
+	monday := time.Date(2025, time.April, 7, 0, 0, 0, 0, time.Local)
+	summaries := func() []DailySummaryEntry {
+		t.Helper()
+		entries, err := findDailySummaries(app, "", monday, monday.AddDate(0, 0, 7))
+		if err != nil {
+			t.Fatalf("failed to find daily summaries: %v", err)
+		}
+		return entries
+	}
+
+	events := []CalendarEvent{
+		{UID: "a", Summary: "Workshop", Start: monday.Add(9 * time.Hour), End: monday.Add(17 * time.Hour)},
+		{UID: "b", Summary: "Workshop", Start: monday.AddDate(0, 0, 2).Add(9 * time.Hour), End: monday.AddDate(0, 0, 2).Add(13 * time.Hour)},
+	}
+	run := &importRun{ID: core.GenerateDefaultRandomId(), Source: "calendar", Started: time.Now()}
+	if err := importCalendarEvents(t.Context(), app, "", run.ID, events, ""); err != nil {
+		t.Fatalf("failed to import calendar events: %v", err)
+	}
+
+	// The records of a running import are not summarized one by one
+	if entries := summaries(); len(entries) != 0 {
+		t.Fatalf("expected the summaries to be recomputed after the import, got %+v", entries)
+	}
+
+	run.Records = 2 * len(events)
+	run.finish(app, nil)
+	imported := summaries()
+	if len(imported) != 3 || imported[0].WorkedSeconds != 8*60*60 || imported[1].WorkedSeconds != 0 || imported[2].WorkedSeconds != 4*60*60 {
+		t.Fatalf("expected the imported days and the day between them to be summarized, got %+v", imported)
+	}
+	if imported[2].BalanceSeconds != imported[0].OvertimeSeconds+imported[1].OvertimeSeconds+imported[2].OvertimeSeconds {
+		t.Errorf("expected the balances to be walked forward, got %+v", imported)
+	}
+
+	if _, err := rollbackImportRun(t.Context(), app, run.ID); err != nil {
+		t.Fatalf("failed to roll back import: %v", err)
+	}
+	for _, entry := range summaries() {
+		if entry.WorkedSeconds != 0 || entry.Sessions != 0 {
+			t.Errorf("expected the rollback to remove the sessions of %s, got %+v", entry.Date, entry)
+		}
+	}
+}
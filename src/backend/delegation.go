@@ -0,0 +1,369 @@
+// Delegation Module for PocketBase
+//
+// This module enforces the owners of clocks. A clock with an owner can only be changed by that
+// user, by superusers, and by the users the owner delegated access to in the delegations
+// collection, e.g. an assistant managing the clock of a manager. A delegation grants access to
+// all clocks of the owner from its first to its last day. Clocks without an owner, including the
+// default clock, can be changed by everyone as before.
+//
+// The access is checked by a middleware for all mutating requests of the work clock endpoints,
+// including the clock in, clock out and toggle GET endpoints, the journal, the compact API, the
+// imports and their rollback. The affected clocks are determined from the 'clock' and 'to_clock'
+// parameters (form values or JSON body), from the work clock records referenced by 'clock_in_id',
+// 'work_clock_id' and 'record_id', and from the records created by the import run 'run_id'.
+// The records API of the collections belonging to a clock applies the same access in its rules.
+// Every successful change made through a delegation is recorded as 'delegated_change' event,
+// naming both the delegate and the owner, so the owner can review what was done on their behalf.
+//
+// Owned clocks can also only be read by the owner, superusers, active delegates and the leads of
+// the teams the owner is a member of (see the teams module). The middleware checks the clocks of
+// the GET endpoints of the work clock, the journal, the compact API, the search and the status
+// badge the same way, the Grafana endpoints and saved reports check the clocks they evaluate, and
+// the records API applies the same access in its list and view rules.
+//
+// Shortcut URLs act on behalf of the user who created their token and check the same access
+// themselves (see the shortcuts module). The email gateway and the Matrix bot authenticate with
+// their own secrets and are not affected by the owners of clocks, nor are the feed and the status
+// badge once their token is configured.
+package backend
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/apis"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tools/hook"
+)
+
+// delegationPrefixes are the path prefixes of the routes changing clocks.
+var delegationPrefixes = []string{"/api/work_clock", "/api/journal", "/api/compact", "/api/calendar/import", "/api/legacy_import", "/api/import/"}
+
+// delegationGetRoutes are the paths of the GET routes changing clocks, e.g. for simple clients like shortcuts.
+var delegationGetRoutes = []string{"/api/work_clock/clock_in", "/api/work_clock/clock_out", "/api/work_clock/toggle"}
+
+// clockReadPrefixes are the path prefixes of the GET routes reading clocks.
+var clockReadPrefixes = []string{"/api/work_clock", "/api/journal", "/api/compact", "/api/search", "/api/badge"}
+
+// clockAccess is the result of checking the access to an owned clock.
+type clockAccess struct {
+	ClockID    string // ID of the clock
+	Owner      string // ID of the owner of the clock
+	Delegation string // ID of the delegation granting the access, empty if not delegated
+}
+
+// RegisterDelegationHooks registers the middleware enforcing the owners of clocks.
+//
+// Parameters:
+// - app: The PocketBase application instance
+func RegisterDelegationHooks(app core.App) {
+	app.OnServe().BindFunc(func(se *core.ServeEvent) error {
+		se.Router.Bind(&hook.Handler[*core.RequestEvent]{
+			Id:       "clockOwnerAccess",
+			Priority: apis.DefaultBodyLimitMiddlewarePriority + 1,
+			Func: func(e *core.RequestEvent) error {
+				if isClockReadRequest(e.Request) {
+					clockIDs, err := requestClockIDs(app, e)
+					if err != nil {
+						return e.Error(http.StatusBadRequest, "Failed to determine the clocks of the request", err)
+					}
+					for _, clockID := range clockIDs {
+						if err := checkClockReadAccess(app, e, clockID, clockNow(app)); err != nil {
+							return err
+						}
+					}
+					return e.Next()
+				}
+
+				if !isDelegationRequest(e.Request) {
+					return e.Next()
+				}
+
+				clockIDs, err := requestClockIDs(app, e)
+				if err != nil {
+					return e.Error(http.StatusBadRequest, "Failed to determine the clocks of the request", err)
+				}
+
+				var delegated []clockAccess
+				for _, clockID := range clockIDs {
+					access, err := checkClockAccess(app, e, e.Auth, clockID, clockNow(app))
+					if err != nil {
+						return err
+					}
+					if access.Delegation != "" {
+						delegated = append(delegated, access)
+					}
+				}
+
+				if err := e.Next(); err != nil {
+					return err
+				}
+
+				for _, access := range delegated {
+					recordDelegatedChange(app, e.Auth, access, e.Request.Method, e.Request.URL.Path)
+				}
+				return nil
+			},
+		})
+
+		return se.Next()
+	})
+}
+
+// isDelegationRequest checks whether a request may change a clock.
+//
+// Parameters:
+// - request: The HTTP request
+//
+// Returns:
+// - Whether the request is a mutating request of a route changing clocks
+func isDelegationRequest(request *http.Request) bool {
+	switch request.Method {
+	case http.MethodGet:
+		if !slices.Contains(delegationGetRoutes, request.URL.Path) {
+			return false
+		}
+	case http.MethodHead, http.MethodOptions:
+		return false
+	}
+
+	for _, prefix := range delegationPrefixes {
+		if strings.HasPrefix(request.URL.Path, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// isClockReadRequest checks whether a request reads a clock.
+//
+// Parameters:
+// - request: The HTTP request
+//
+// Returns:
+// - Whether the request is a GET request of a route reading clocks without its own token
+func isClockReadRequest(request *http.Request) bool {
+	if (request.Method != http.MethodGet && request.Method != http.MethodHead) || slices.Contains(delegationGetRoutes, request.URL.Path) {
+		return false
+	}
+
+	switch request.URL.Path {
+	case "/api/work_clock/feed.atom":
+		return settings.FeedToken == ""
+	case "/api/badge/status.svg":
+		return settings.BadgeToken == ""
+	}
+
+	for _, prefix := range clockReadPrefixes {
+		if strings.HasPrefix(request.URL.Path, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// requestClockIDs determines the clocks a request changes. Unknown clocks and records are
+// skipped, since the endpoint itself reports them.
+//
+// Parameters:
+// - app: The App interface used to look up the clocks and records
+// - e: The RequestEvent from the HTTP handler
+//
+// Returns:
+// - The distinct IDs of the affected clocks, without the default clock
+// - An error if the body can't be read or the clocks of the import run can't be queried
+func requestClockIDs(app core.App, e *core.RequestEvent) ([]string, error) {
+	var names []string
+	var recordIDs []string
+
+	if strings.HasPrefix(e.Request.Header.Get("Content-Type"), "application/json") {
+		var body struct {
+			Clock       string `json:"clock"`
+			ToClock     string `json:"to_clock"`
+			ClockInID   string `json:"clock_in_id"`
+			WorkClockID string `json:"work_clock_id"`
+			RecordID    string `json:"record_id"`
+		}
+		if err := e.BindBody(&body); err != nil {
+			return nil, err
+		}
+		names = append(names, body.Clock, body.ToClock, e.Request.URL.Query().Get("clock"))
+		recordIDs = append(recordIDs, body.ClockInID, body.WorkClockID, body.RecordID)
+	} else {
+		for _, name := range []string{"clock", "to_clock"} {
+			names = append(names, e.Request.FormValue(name))
+		}
+		for _, name := range []string{"clock_in_id", "work_clock_id", "record_id"} {
+			recordIDs = append(recordIDs, e.Request.FormValue(name))
+		}
+	}
+
+	var clockIDs []string
+	for _, name := range names {
+		if clockID, err := findClockID(app, name); err == nil && clockID != "" {
+			clockIDs = append(clockIDs, clockID)
+		}
+	}
+	for _, recordID := range recordIDs {
+		if recordID == "" {
+			continue
+		}
+		if record, err := app.FindRecordById("work_clock", recordID); err == nil && record.GetString("clock") != "" {
+			clockIDs = append(clockIDs, record.GetString("clock"))
+		}
+	}
+
+	if runID := e.Request.PathValue("run_id"); runID != "" {
+		var runClockIDs []string
+		err := app.RecordQuery("work_clock").Select("clock").Distinct(true).
+			AndWhere(dbx.HashExp{"import_run": runID}).AndWhere(dbx.Not(dbx.HashExp{"clock": ""})).Column(&runClockIDs)
+		if err != nil {
+			return nil, err
+		}
+		clockIDs = append(clockIDs, runClockIDs...)
+	}
+
+	slices.Sort(clockIDs)
+	return slices.Compact(clockIDs), nil
+}
+
+// checkClockAccess checks whether a user may change a clock.
+//
+// Parameters:
+// - app: The App interface used to look up the clock and the delegations
+// - e: The RequestEvent from the HTTP handler
+// - user: The user changing the clock, usually the authenticated user of the request, nil if anonymous
+// - clockID: The ID of the clock
+// - now: The current time, which must be within a delegation
+//
+// Returns:
+// - The access, with the delegation if the user changes the clock on behalf of its owner
+// - An error response if the user may not change the clock
+func checkClockAccess(app core.App, e *core.RequestEvent, user *core.Record, clockID string, now time.Time) (clockAccess, error) {
+	clock, err := app.FindRecordById("clocks", clockID)
+	if err != nil {
+		return clockAccess{}, e.Error(http.StatusInternalServerError, fmt.Sprintf("Failed to find clock: %v", err), err)
+	}
+
+	access := clockAccess{ClockID: clockID, Owner: clock.GetString("owner")}
+	if access.Owner == "" || (user != nil && (user.IsSuperuser() || user.Id == access.Owner)) {
+		return access, nil
+	}
+
+	if user == nil {
+		return clockAccess{}, e.Error(http.StatusUnauthorized, fmt.Sprintf("The clock '%s' belongs to a user, please sign in", clock.GetString("name")), nil)
+	}
+
+	delegation, err := findActiveDelegation(app, access.Owner, user.Id, now)
+	if err != nil {
+		return clockAccess{}, e.Error(http.StatusInternalServerError, fmt.Sprintf("Failed to find delegations: %v", err), err)
+	}
+	if delegation == nil {
+		return clockAccess{}, e.Error(http.StatusForbidden, fmt.Sprintf("You are not allowed to change the clock '%s'", clock.GetString("name")), nil)
+	}
+
+	access.Delegation = delegation.Id
+	return access, nil
+}
+
+// checkClockReadAccess checks whether the user of a request may read a clock.
+//
+// Parameters:
+// - app: The App interface used to look up the clock, the delegations and the teams
+// - e: The RequestEvent from the HTTP handler
+// - clockID: The ID of the clock
+// - now: The current time, which must be within a delegation
+//
+// Returns:
+// - An error response if the user may not read the clock
+func checkClockReadAccess(app core.App, e *core.RequestEvent, clockID string, now time.Time) error {
+	clock, err := app.FindRecordById("clocks", clockID)
+	if err != nil {
+		return e.Error(http.StatusInternalServerError, fmt.Sprintf("Failed to find clock: %v", err), err)
+	}
+
+	owner := clock.GetString("owner")
+	if owner == "" || e.HasSuperuserAuth() || (e.Auth != nil && e.Auth.Id == owner) {
+		return nil
+	}
+
+	if e.Auth == nil {
+		return e.Error(http.StatusUnauthorized, fmt.Sprintf("The clock '%s' belongs to a user, please sign in", clock.GetString("name")), nil)
+	}
+
+	delegation, err := findActiveDelegation(app, owner, e.Auth.Id, now)
+	if err != nil {
+		return e.Error(http.StatusInternalServerError, fmt.Sprintf("Failed to find delegations: %v", err), err)
+	}
+	if delegation != nil {
+		return nil
+	}
+
+	teams, err := app.FindRecordsByFilter("teams", "leads.id ?= {:lead} && members.id ?= {:member}", "", 1, 0, dbx.Params{
+		"lead":   e.Auth.Id,
+		"member": owner,
+	})
+	if err != nil {
+		return e.Error(http.StatusInternalServerError, fmt.Sprintf("Failed to find teams: %v", err), err)
+	}
+	if len(teams) == 0 {
+		return e.Error(http.StatusForbidden, fmt.Sprintf("You are not allowed to see the clock '%s'", clock.GetString("name")), nil)
+	}
+
+	return nil
+}
+
+// findActiveDelegation finds a delegation from an owner to a delegate covering a day.
+//
+// Parameters:
+// - app: The App interface used to query the delegations
+// - owner: The ID of the user granting the access
+// - delegate: The ID of the user the access is granted to
+// - now: The time to check, its local date must be within the delegation
+//
+// Returns:
+// - The delegation, nil if there is none
+// - An error if the query fails
+func findActiveDelegation(app core.App, owner string, delegate string, now time.Time) (*core.Record, error) {
+	day := now.Format(time.DateOnly)
+	delegations, err := app.FindRecordsByFilter("delegations", "owner = {:owner} && delegate = {:delegate} && from <= {:day} && to >= {:day}", "", 1, 0, dbx.Params{
+		"owner":    owner,
+		"delegate": delegate,
+		"day":      day,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(delegations) == 0 {
+		return nil, nil
+	}
+	return delegations[0], nil
+}
+
+// recordDelegatedChange records a change made through a delegation as event.
+//
+// Parameters:
+// - app: The App interface used to look up the users and save the event
+// - delegate: The user who changed the clock
+// - access: The delegated access to the changed clock
+// - method: The HTTP method of the change
+// - path: The path of the change, without secrets like shortcut tokens
+func recordDelegatedChange(app core.App, delegate *core.Record, access clockAccess, method string, path string) {
+	owner := access.Owner
+	if record, err := app.FindRecordById("users", access.Owner); err == nil {
+		owner = record.Email()
+	}
+
+	recordEvent(app, "delegated_change", "info", fmt.Sprintf("%s changed a clock on behalf of %s (%s %s)", delegate.Email(), owner, method, path), map[string]any{
+		"delegate":   delegate.Id,
+		"owner":      access.Owner,
+		"clock":      access.ClockID,
+		"delegation": access.Delegation,
+		"method":     method,
+		"path":       path,
+	})
+}
@@ -0,0 +1,519 @@
+package backend
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/yerTools/simple-frontend-stack/src/backend/backendtest"
+)
+
+func TestClockOwnerAccess(t *testing.T) {
+	app := backendtest.NewApp(t)
+	RegisterDelegationHooks(app)
+	RegisterWorkClockAPI(app)
+	RegisterWorkClockMoveAPI(app)
+	RegisterImportHistoryAPI(app)
+	handler := backendtest.NewHandler(t, app)
+
+	manager := backendtest.AddUser(t, app, "manager@example.com")
+	assistant := backendtest.AddUser(t, app, "assistant@example.com")
+	other := backendtest.AddUser(t, app, "other@example.com")
+
+	clocks, err := app.FindCollectionByNameOrId("clocks")
+	if err != nil {
+		t.Fatalf("failed to find clocks collection: %v", err)
+	}
+	clock := core.NewRecord(clocks)
+	clock.Set("name", "manager")
+	clock.Set("owner", manager.Id)
+	if err := app.Save(clock); err != nil {
+		t.Fatalf("failed to save clock: %v", err)
+	}
+
+	delegations, err := app.FindCollectionByNameOrId("delegations")
+	if err != nil {
+		t.Fatalf("failed to find delegations collection: %v", err)
+	}
+	delegation := core.NewRecord(delegations)
+	delegation.Set("owner", manager.Id)
+	delegation.Set("delegate", assistant.Id)
+	delegation.Set("from", time.Now().AddDate(0, 0, -1).Format(time.DateOnly))
+	delegation.Set("to", time.Now().AddDate(0, 0, 1).Format(time.DateOnly))
+	if err := app.Save(delegation); err != nil {
+		t.Fatalf("failed to save delegation: %v", err)
+	}
+
+	send := func(user *core.Record, method string, path string, contentType string, body string) int {
+		request := httptest.NewRequest(method, path, strings.NewReader(body))
+		request.Header.Set("Content-Type", contentType)
+		if user != nil {
+			token, err := user.NewAuthToken()
+			if err != nil {
+				t.Fatalf("failed to create token: %v", err)
+			}
+			request.Header.Set("Authorization", token)
+		}
+
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, request)
+		return recorder.Code
+	}
+	toggle := func(user *core.Record, clockName string, clockIn bool) int {
+		form := url.Values{"clock_in": {strconv.FormatBool(clockIn)}, "clock": {clockName}}
+		return send(user, http.MethodPost, "/api/work_clock", "application/x-www-form-urlencoded", form.Encode())
+	}
+
+	if code := toggle(nil, "manager", true); code != http.StatusUnauthorized {
+		t.Errorf("expected anonymous access to be rejected with 401, got %d", code)
+	}
+	if code := toggle(other, "manager", true); code != http.StatusForbidden {
+		t.Errorf("expected other users to be rejected with 403, got %d", code)
+	}
+	if code := toggle(manager, "manager", true); code != http.StatusOK {
+		t.Errorf("expected the owner to clock in, got %d", code)
+	}
+	// Timestamps are stored with millisecond precision and must be unique
+	time.Sleep(5 * time.Millisecond)
+	if code := toggle(assistant, "manager", false); code != http.StatusOK {
+		t.Errorf("expected the delegate to clock out, got %d", code)
+	}
+	if code := toggle(nil, "", true); code != http.StatusOK {
+		t.Errorf("expected the default clock to stay open, got %d", code)
+	}
+
+	// Changes through GET routes, JSON bodies and import rollbacks are checked as well
+	if code := send(other, http.MethodGet, "/api/work_clock/toggle?clock=manager", "", ""); code != http.StatusForbidden {
+		t.Errorf("expected other users to be rejected from toggling with 403, got %d", code)
+	}
+	sessions, err := findWorkSessions(app, clock.Id, time.Time{}, time.Time{})
+	if err != nil || len(sessions) != 1 {
+		t.Fatalf("expected a single session, got %d: %v", len(sessions), err)
+	}
+	if code := send(other, http.MethodPost, "/api/work_clock/move", "application/json", `{"clock_in_id":"`+sessions[0].ClockIn.Id+`","to_clock":""}`); code != http.StatusForbidden {
+		t.Errorf("expected other users to be rejected from moving sessions with 403, got %d", code)
+	}
+
+	run := &importRun{ID: core.GenerateDefaultRandomId(), Source: "calendar", Started: time.Now()}
+	calendarEvents := []CalendarEvent{{UID: "a", Summary: "Workshop", Start: backendtest.MustParseTime("2025-04-01T08:00:00Z"), End: backendtest.MustParseTime("2025-04-01T16:00:00Z")}}
+	if err := importCalendarEvents(t.Context(), app, clock.Id, run.ID, calendarEvents, ""); err != nil {
+		t.Fatalf("failed to import calendar events: %v", err)
+	}
+	run.Records = 2
+	run.finish(app, nil)
+	if code := send(other, http.MethodPost, "/api/import/"+run.ID+"/rollback", "", ""); code != http.StatusForbidden {
+		t.Errorf("expected other users to be rejected from rolling back imports with 403, got %d", code)
+	}
+	if code := send(manager, http.MethodPost, "/api/import/"+run.ID+"/rollback", "", ""); code != http.StatusOK {
+		t.Errorf("expected the owner to roll back the import, got %d", code)
+	}
+
+	events, err := app.FindRecordsByFilter("events", "type = 'delegated_change'", "", 0, 0)
+	if err != nil {
+		t.Fatalf("failed to find events: %v", err)
+	}
+	if len(events) != 1 || !strings.Contains(events[0].GetString("message"), "assistant@example.com") || !strings.Contains(events[0].GetString("message"), "manager@example.com") {
+		t.Errorf("expected a single delegated change naming both users, got %v", events)
+	}
+
+	delegation.Set("to", time.Now().AddDate(0, 0, -1).Format(time.DateOnly))
+	if err := app.Save(delegation); err != nil {
+		t.Fatalf("failed to save delegation: %v", err)
+	}
+	if code := toggle(assistant, "manager", true); code != http.StatusForbidden {
+		t.Errorf("expected an expired delegation to be rejected with 403, got %d", code)
+	}
+}
+
+func TestClockOwnerRecordRules(t *testing.T) {
+	app := backendtest.NewApp(t)
+	handler := backendtest.NewHandler(t, app)
+
+	manager := backendtest.AddUser(t, app, "manager@example.com")
+	assistant := backendtest.AddUser(t, app, "assistant@example.com")
+	other := backendtest.AddUser(t, app, "other@example.com")
+
+	newRecord := func(collection string, values map[string]any) *core.Record {
+		t.Helper()
+		c, err := app.FindCollectionByNameOrId(collection)
+		if err != nil {
+			t.Fatalf("failed to find %s collection: %v", collection, err)
+		}
+		record := core.NewRecord(c)
+		record.Load(values)
+		if err := app.Save(record); err != nil {
+			t.Fatalf("failed to save %s record: %v", collection, err)
+		}
+		return record
+	}
+	managerClock := newRecord("clocks", map[string]any{"name": "manager", "owner": manager.Id})
+	otherClock := newRecord("clocks", map[string]any{"name": "other", "owner": other.Id})
+	newRecord("delegations", map[string]any{
+		"owner":    manager.Id,
+		"delegate": assistant.Id,
+		"from":     time.Now().UTC().AddDate(0, 0, -1).Format(time.DateOnly),
+		"to":       time.Now().UTC().Format(time.DateOnly),
+	})
+
+	send := func(user *core.Record, method string, path string, body map[string]any) int {
+		t.Helper()
+		encoded, err := json.Marshal(body)
+		if err != nil {
+			t.Fatalf("failed to encode body: %v", err)
+		}
+		request := httptest.NewRequest(method, path, bytes.NewReader(encoded))
+		request.Header.Set("Content-Type", "application/json")
+		if user != nil {
+			token, err := user.NewAuthToken()
+			if err != nil {
+				t.Fatalf("failed to create token: %v", err)
+			}
+			request.Header.Set("Authorization", token)
+		}
+
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, request)
+		return recorder.Code
+	}
+
+	// Each collection is changed with values that are unique per created record
+	testCases := []struct {
+		collection string
+		values     func(i int) map[string]any
+	}{
+		{"employment_periods", func(i int) map[string]any {
+			return map[string]any{"start": time.Date(2025, time.January, i, 0, 0, 0, 0, time.UTC).Format(time.DateOnly)}
+		}},
+		{"rule_periods", func(i int) map[string]any {
+			return map[string]any{"start": time.Date(2025, time.January, i, 0, 0, 0, 0, time.UTC).Format(time.DateOnly), "profile": "standard"}
+		}},
+		{"absences", func(i int) map[string]any {
+			return map[string]any{"date": time.Date(2025, time.January, i, 0, 0, 0, 0, time.UTC).Format(time.DateOnly), "kind": "sick"}
+		}},
+		{"expenses", func(i int) map[string]any {
+			return map[string]any{"amount": i}
+		}},
+		{"work_schedules", func(i int) map[string]any {
+			return map[string]any{"valid_from": time.Date(2025, time.January, i, 0, 0, 0, 0, time.UTC).Format(time.DateOnly)}
+		}},
+		{"vacation_entitlements", func(i int) map[string]any {
+			return map[string]any{"year": 2000 + i, "days": 30}
+		}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.collection, func(t *testing.T) {
+			path := "/api/collections/" + tc.collection + "/records"
+			created := 0
+			create := func(user *core.Record, clockID string) int {
+				created++
+				body := tc.values(created)
+				body["clock"] = clockID
+				return send(user, http.MethodPost, path, body)
+			}
+
+			if code := create(nil, managerClock.Id); code == http.StatusOK {
+				t.Error("expected anonymous users not to create records of an owned clock")
+			}
+			if code := create(other, managerClock.Id); code == http.StatusOK {
+				t.Error("expected other users not to create records of an owned clock")
+			}
+			for _, user := range []*core.Record{manager, assistant} {
+				if code := create(user, managerClock.Id); code != http.StatusOK {
+					t.Errorf("expected the owner and the delegate to create records, got %d", code)
+				}
+			}
+			if code := create(nil, ""); code != http.StatusOK {
+				t.Errorf("expected the default clock to stay open, got %d", code)
+			}
+
+			values := tc.values(100)
+			values["clock"] = managerClock.Id
+			record := newRecord(tc.collection, values)
+			recordPath := path + "/" + record.Id
+			if code := send(other, http.MethodPatch, recordPath, map[string]any{}); code == http.StatusOK {
+				t.Error("expected other users not to update records of an owned clock")
+			}
+			if code := send(manager, http.MethodPatch, recordPath, map[string]any{"clock": otherClock.Id}); code == http.StatusOK {
+				t.Error("expected the owner not to move records to a clock of another user")
+			}
+			if code := send(assistant, http.MethodPatch, recordPath, map[string]any{"clock": managerClock.Id}); code != http.StatusOK {
+				t.Errorf("expected the delegate to update records, got %d", code)
+			}
+			if code := send(other, http.MethodDelete, recordPath, nil); code == http.StatusNoContent {
+				t.Error("expected other users not to delete records of an owned clock")
+			}
+			if code := send(manager, http.MethodDelete, recordPath, nil); code != http.StatusNoContent {
+				t.Errorf("expected the owner to delete records, got %d", code)
+			}
+		})
+	}
+
+	// Owners can only be assigned by superusers
+	if code := send(other, http.MethodPost, "/api/collections/clocks/records", map[string]any{"name": "claimed", "owner": manager.Id}); code == http.StatusOK {
+		t.Error("expected users not to create clocks owned by others")
+	}
+	unowned := newRecord("clocks", map[string]any{"name": "shared"})
+	if code := send(other, http.MethodPatch, "/api/collections/clocks/records/"+unowned.Id, map[string]any{"owner": other.Id}); code == http.StatusOK {
+		t.Error("expected users not to claim clocks without an owner")
+	}
+	if code := send(manager, http.MethodPatch, "/api/collections/clocks/records/"+managerClock.Id, map[string]any{"owner": other.Id}); code == http.StatusOK {
+		t.Error("expected owners not to hand their clock to another user")
+	}
+	if code := send(manager, http.MethodPatch, "/api/collections/clocks/records/"+managerClock.Id, map[string]any{"name": "boss"}); code != http.StatusOK {
+		t.Errorf("expected owners to rename their clock, got %d", code)
+	}
+}
+
+func TestClockOwnerReadAccess(t *testing.T) {
+	app := backendtest.NewApp(t)
+	RegisterDelegationHooks(app)
+	RegisterWorkClockStatusAPI(app)
+	RegisterDailySummaryAPI(app)
+	RegisterGrafanaAPI(app)
+	RegisterSavedReportsAPI(app)
+	handler := backendtest.NewHandler(t, app)
+
+	manager := backendtest.AddUser(t, app, "manager@example.com")
+	assistant := backendtest.AddUser(t, app, "assistant@example.com")
+	lead := backendtest.AddUser(t, app, "lead@example.com")
+	other := backendtest.AddUser(t, app, "other@example.com")
+
+	newRecord := func(collection string, values map[string]any) *core.Record {
+		t.Helper()
+		c, err := app.FindCollectionByNameOrId(collection)
+		if err != nil {
+			t.Fatalf("failed to find %s collection: %v", collection, err)
+		}
+		record := core.NewRecord(c)
+		record.Load(values)
+		if err := app.Save(record); err != nil {
+			t.Fatalf("failed to save %s record: %v", collection, err)
+		}
+		return record
+	}
+	clock := newRecord("clocks", map[string]any{"name": "manager", "owner": manager.Id})
+	newRecord("delegations", map[string]any{
+		"owner":    manager.Id,
+		"delegate": assistant.Id,
+		"from":     time.Now().UTC().AddDate(0, 0, -1).Format(time.DateOnly),
+		"to":       time.Now().UTC().Format(time.DateOnly),
+	})
+	newRecord("teams", map[string]any{"name": "Management", "leads": []string{lead.Id}, "members": []string{manager.Id}})
+	clockIn := newRecord("work_clock", map[string]any{"timestamp": time.Now().Add(-time.Hour), "clock_in": true, "clock": clock.Id})
+
+	// A report of a clock its owner can't read, e.g. saved during an expired delegation
+	report, err := saveReport(app, other.Id, "Manager", reportSpec{Period: "last_month", Metrics: []string{"worked"}, Clock: "manager"})
+	if err != nil {
+		t.Fatalf("failed to save report: %v", err)
+	}
+
+	send := func(user *core.Record, method string, path string, body string) (int, string) {
+		t.Helper()
+		request := httptest.NewRequest(method, path, strings.NewReader(body))
+		request.Header.Set("Content-Type", "application/json")
+		if user != nil {
+			token, err := user.NewAuthToken()
+			if err != nil {
+				t.Fatalf("failed to create token: %v", err)
+			}
+			request.Header.Set("Authorization", token)
+		}
+
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, request)
+		return recorder.Code, recorder.Body.String()
+	}
+	get := func(user *core.Record, path string) (int, string) {
+		t.Helper()
+		return send(user, http.MethodGet, path, "")
+	}
+
+	for _, path := range []string{
+		"/api/work_clock/status?clock=manager",
+		"/api/work_clock/report/daily?from=1735689600&to=1738368000&clock=manager",
+		"/api/grafana/series?from=1735689600&to=1738368000&clock=manager",
+	} {
+		if code, _ := get(nil, path); code != http.StatusUnauthorized {
+			t.Errorf("expected anonymous reads of %s to be rejected with 401, got %d", path, code)
+		}
+		if code, _ := get(other, path); code != http.StatusForbidden {
+			t.Errorf("expected other users to be rejected from %s with 403, got %d", path, code)
+		}
+		for _, user := range []*core.Record{manager, assistant, lead} {
+			if code, body := get(user, path); code != http.StatusOK {
+				t.Errorf("expected %s to read %s, got %d: %s", user.Email(), path, code, body)
+			}
+		}
+	}
+	if code, _ := get(nil, "/api/work_clock/status"); code != http.StatusOK {
+		t.Errorf("expected the default clock to stay readable, got %d", code)
+	}
+
+	if code, _ := get(other, "/api/reports/"+report.Id); code != http.StatusForbidden {
+		t.Errorf("expected reports of clocks the user can't read to be rejected with 403, got %d", code)
+	}
+
+	query := `{"range":{"from":"2025-01-01T00:00:00Z","to":"2025-02-01T00:00:00Z"},"targets":[{"target":"worked_hours","payload":{"clock":"manager"}}]}`
+	if code, _ := send(other, http.MethodPost, "/api/grafana/query", query); code != http.StatusForbidden {
+		t.Errorf("expected other users to be rejected from querying Grafana metrics with 403, got %d", code)
+	}
+	if code, body := send(lead, http.MethodPost, "/api/grafana/query", query); code != http.StatusOK {
+		t.Errorf("expected the lead to query Grafana metrics, got %d: %s", code, body)
+	}
+
+	// The records API hides the records of the clock from everyone else
+	recordPath := "/api/collections/work_clock/records/" + clockIn.Id
+	if code, _ := get(other, recordPath); code != http.StatusNotFound {
+		t.Errorf("expected the record to be hidden from other users, got %d", code)
+	}
+	if code, body := get(other, "/api/collections/work_clock/records"); code != http.StatusOK || strings.Contains(body, clockIn.Id) {
+		t.Errorf("expected the list of other users to leave out the record, got %d: %s", code, body)
+	}
+	for _, user := range []*core.Record{manager, assistant, lead} {
+		if code, body := get(user, recordPath); code != http.StatusOK {
+			t.Errorf("expected %s to view the record, got %d: %s", user.Email(), code, body)
+		}
+	}
+}
+
+func TestShortcutClockOwnerAccess(t *testing.T) {
+	app := backendtest.NewApp(t)
+	RegisterDelegationHooks(app)
+	RegisterShortcutsAPI(app)
+	handler := backendtest.NewHandler(t, app)
+
+	manager := backendtest.AddUser(t, app, "manager@example.com")
+	assistant := backendtest.AddUser(t, app, "assistant@example.com")
+	other := backendtest.AddUser(t, app, "other@example.com")
+
+	clocks, err := app.FindCollectionByNameOrId("clocks")
+	if err != nil {
+		t.Fatalf("failed to find clocks collection: %v", err)
+	}
+	clock := core.NewRecord(clocks)
+	clock.Set("name", "manager")
+	clock.Set("owner", manager.Id)
+	if err := app.Save(clock); err != nil {
+		t.Fatalf("failed to save clock: %v", err)
+	}
+
+	delegations, err := app.FindCollectionByNameOrId("delegations")
+	if err != nil {
+		t.Fatalf("failed to find delegations collection: %v", err)
+	}
+	delegation := core.NewRecord(delegations)
+	delegation.Set("owner", manager.Id)
+	delegation.Set("delegate", assistant.Id)
+	delegation.Set("from", time.Now().AddDate(0, 0, -1).Format(time.DateOnly))
+	delegation.Set("to", time.Now().AddDate(0, 0, 1).Format(time.DateOnly))
+	if err := app.Save(delegation); err != nil {
+		t.Fatalf("failed to save delegation: %v", err)
+	}
+
+	send := func(user *core.Record, method string, path string, body string) *httptest.ResponseRecorder {
+		request := httptest.NewRequest(method, path, strings.NewReader(body))
+		request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		if user != nil {
+			token, err := user.NewAuthToken()
+			if err != nil {
+				t.Fatalf("failed to create token: %v", err)
+			}
+			request.Header.Set("Authorization", token)
+		}
+
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, request)
+		return recorder
+	}
+	createToken := func(user *core.Record) string {
+		t.Helper()
+		response := send(user, http.MethodPost, "/api/shortcut_tokens", "name=phone")
+		var created struct {
+			Token string `json:"token"`
+		}
+		if response.Code != http.StatusOK || json.Unmarshal(response.Body.Bytes(), &created) != nil {
+			t.Fatalf("failed to create shortcut token: %d %s", response.Code, response.Body.String())
+		}
+		return created.Token
+	}
+
+	if code := send(nil, http.MethodPost, "/api/shortcut_tokens", "name=phone").Code; code != http.StatusUnauthorized {
+		t.Errorf("expected anonymous users to be rejected from creating tokens with 401, got %d", code)
+	}
+
+	if code := send(nil, http.MethodGet, "/c/"+createToken(other)+"/in?clock=manager", "").Code; code != http.StatusForbidden {
+		t.Errorf("expected the token of another user to be rejected with 403, got %d", code)
+	}
+	if response := send(nil, http.MethodGet, "/c/"+createToken(manager)+"/in?clock=manager", ""); response.Code != http.StatusOK {
+		t.Errorf("expected the token of the owner to clock in, got %d: %s", response.Code, response.Body.String())
+	}
+	// Timestamps are stored with millisecond precision and must be unique
+	time.Sleep(5 * time.Millisecond)
+	if response := send(nil, http.MethodGet, "/c/"+createToken(assistant)+"/out?clock=manager", ""); response.Code != http.StatusOK {
+		t.Errorf("expected the token of the delegate to clock out, got %d: %s", response.Code, response.Body.String())
+	}
+
+	events, err := app.FindRecordsByFilter("events", "type = 'delegated_change'", "", 0, 0)
+	if err != nil {
+		t.Fatalf("failed to find events: %v", err)
+	}
+	if len(events) != 1 || !strings.Contains(events[0].GetString("message"), "/c/{token}/out") {
+		t.Errorf("expected a single delegated change without the token, got %v", events)
+	}
+}
+
+func TestDelegationFollowsServiceClock(t *testing.T) {
+	app := backendtest.NewApp(t)
+	clock := NewSimulatedClock(backendtest.MustParseTime("2025-04-07T12:00:00Z"), 0)
+	RegisterWorkClockService(app, NewWorkClockService(app).WithClock(clock))
+	RegisterDelegationHooks(app)
+	RegisterWorkClockStatusAPI(app)
+	handler := backendtest.NewHandler(t, app)
+
+	manager := backendtest.AddUser(t, app, "manager@example.com")
+	assistant := backendtest.AddUser(t, app, "assistant@example.com")
+
+	for collection, values := range map[string]map[string]any{
+		"clocks":      {"name": "manager", "owner": manager.Id},
+		"delegations": {"owner": manager.Id, "delegate": assistant.Id, "from": "2025-04-07", "to": "2025-04-07"},
+	} {
+		c, err := app.FindCollectionByNameOrId(collection)
+		if err != nil {
+			t.Fatalf("failed to find %s collection: %v", collection, err)
+		}
+		record := core.NewRecord(c)
+		record.Load(values)
+		if err := app.Save(record); err != nil {
+			t.Fatalf("failed to save %s record: %v", collection, err)
+		}
+	}
+
+	token, err := assistant.NewAuthToken()
+	if err != nil {
+		t.Fatalf("failed to create token: %v", err)
+	}
+	status := func() int {
+		request := httptest.NewRequest(http.MethodGet, "/api/work_clock/status?clock=manager", nil)
+		request.Header.Set("Authorization", token)
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, request)
+		return recorder.Code
+	}
+
+	// The delegation is checked against the day of the service clock, not the wall clock
+	if code := status(); code != http.StatusOK {
+		t.Errorf("expected the delegate to read the clock on the simulated day, got %d", code)
+	}
+	clock.Advance(24 * time.Hour)
+	if code := status(); code != http.StatusForbidden {
+		t.Errorf("expected the delegation to expire on the following simulated day, got %d", code)
+	}
+}
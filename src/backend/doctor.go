@@ -0,0 +1,361 @@
+// Doctor Module for PocketBase
+//
+// This module checks an installation for the problems that otherwise only show up as confusing
+// errors later on, e.g. after restoring a backup, copying the database between machines or running
+// the binary in a minimal container. It verifies that:
+// - All migrations are applied
+// - The collections of the work clock exist, together with their indexes
+// - The records of every clock alternate between clock in and clock out, starting with a clock in
+// - The timezone database is available, which the reports and imports need to resolve timezones
+// - The enabled integrations can be reached, through the outbound proxy if one is configured
+//
+// The checks run with the 'doctor' command, which prints every finding with a hint on how to fix
+// it and exits with a non-zero code if a check failed:
+//
+//	./simple_frontend_stack doctor
+//
+// The checks that don't need the network also run on every start of the server, logging their
+// problems as warnings, so they are noticed even if nobody runs the command.
+package backend
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tools/dbutils"
+	"github.com/spf13/cobra"
+)
+
+// doctorDialTimeout is the maximum duration to connect to an integration.
+const doctorDialTimeout = 5 * time.Second
+
+// doctorCollections are the collections the backend requires.
+var doctorCollections = []string{
+	"work_clock", "work_clock_ledger", "work_clock_templates", "clocks", "projects", "tags",
+	"daily_summary", "day_notes", "events", "expenses", "export_profiles", "import_runs",
+	"integrations", "record_comments", "rule_periods", "saved_reports", "shortcut_tokens",
+	"webhook_deliveries", "employment_periods", "work_schedules", "delegations", "teams", "holidays",
+	"absences", "vacation_entitlements", "api_tokens",
+}
+
+// doctorFinding is the result of a single check.
+type doctorFinding struct {
+	Check   string // Name of the check, e.g. 'collections'
+	Status  string // 'ok', 'warning' or 'error'
+	Message string // What was found
+	Hint    string // How to fix the problem, empty if there is none
+}
+
+// RegisterDoctor registers the doctor command and the self-check on startup with the PocketBase server.
+//
+// Parameters:
+// - app: The PocketBase application instance
+func RegisterDoctor(app *pocketbase.PocketBase) {
+	app.RootCmd.AddCommand(&cobra.Command{
+		Use:   "doctor",
+		Short: "Checks the installation and prints how to fix the problems found",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			failed := 0
+			for _, finding := range runDoctor(app, true) {
+				fmt.Fprintf(cmd.OutOrStdout(), "[%s] %s: %s\n", strings.ToUpper(finding.Status), finding.Check, finding.Message)
+				if finding.Hint != "" {
+					fmt.Fprintf(cmd.OutOrStdout(), "        %s\n", finding.Hint)
+				}
+				if finding.Status == "error" {
+					failed++
+				}
+			}
+
+			if failed > 0 {
+				cmd.SilenceUsage = true
+				return fmt.Errorf("%d check(s) failed", failed)
+			}
+			return nil
+		},
+	})
+
+	app.OnServe().BindFunc(func(se *core.ServeEvent) error {
+		backgroundJobs.goJob("startup_check", func() {
+			for _, finding := range runDoctor(app, false) {
+				if finding.Status != "ok" {
+					app.Logger().Warn("startup check: "+finding.Message, "check", finding.Check, "hint", finding.Hint)
+				}
+			}
+		})
+
+		return se.Next()
+	})
+}
+
+// runDoctor runs all checks.
+//
+// Parameters:
+// - app: The App interface used to access the database
+// - network: Whether to check the connections to the integrations
+//
+// Returns:
+// - The findings of all checks
+func runDoctor(app core.App, network bool) []doctorFinding {
+	var findings []doctorFinding
+	findings = append(findings, checkMigrations(app))
+	findings = append(findings, checkCollections(app)...)
+	findings = append(findings, checkSequences(app)...)
+	findings = append(findings, checkTimezoneData())
+	if network {
+		findings = append(findings, checkIntegrations(app)...)
+	}
+	return findings
+}
+
+// checkMigrations verifies that all registered migrations are applied.
+//
+// Parameters:
+// - app: The App interface used to access the database
+//
+// Returns:
+// - The finding of the check
+func checkMigrations(app core.App) doctorFinding {
+	var applied []string
+	err := app.DB().Select("file").From(core.DefaultMigrationsTable).Column(&applied)
+	if err != nil {
+		return doctorFinding{Check: "migrations", Status: "error", Message: fmt.Sprintf("failed to read the applied migrations: %v", err),
+			Hint: "Check that pb_data contains the database of this installation."}
+	}
+
+	var pending []string
+	for _, migration := range core.AppMigrations.Items() {
+		if !slices.Contains(applied, migration.File) {
+			pending = append(pending, migration.File)
+		}
+	}
+
+	if len(pending) > 0 {
+		return doctorFinding{Check: "migrations", Status: "error", Message: fmt.Sprintf("%d migration(s) are not applied: %s", len(pending), strings.Join(pending, ", ")),
+			Hint: "Run './simple_frontend_stack migrate up', or start the server once, which applies them automatically."}
+	}
+	return doctorFinding{Check: "migrations", Status: "ok", Message: fmt.Sprintf("all %d migrations are applied", len(applied))}
+}
+
+// checkCollections verifies that the required collections and their indexes exist.
+//
+// Parameters:
+// - app: The App interface used to access the database
+//
+// Returns:
+// - The findings of the check, one per problem or a single one if there is none
+func checkCollections(app core.App) []doctorFinding {
+	var findings []doctorFinding
+	indexes := 0
+
+	for _, name := range doctorCollections {
+		collection, err := app.FindCollectionByNameOrId(name)
+		if err != nil {
+			findings = append(findings, doctorFinding{Check: "collections", Status: "error", Message: fmt.Sprintf("collection '%s' does not exist", name),
+				Hint: "Run './simple_frontend_stack migrate up'. If the migrations are applied, the collection was deleted in the dashboard and must be restored from a backup."})
+			continue
+		}
+
+		var existing []string
+		err = app.DB().Select("name").From("sqlite_master").
+			Where(dbx.HashExp{"type": "index", "tbl_name": collection.Name}).Column(&existing)
+		if err != nil {
+			findings = append(findings, doctorFinding{Check: "collections", Status: "error", Message: fmt.Sprintf("failed to read the indexes of collection '%s': %v", name, err)})
+			continue
+		}
+
+		for _, index := range collection.Indexes {
+			indexName := dbutils.ParseIndex(index).IndexName
+			indexes++
+			if !slices.Contains(existing, indexName) {
+				findings = append(findings, doctorFinding{Check: "collections", Status: "error", Message: fmt.Sprintf("index '%s' of collection '%s' does not exist", indexName, name),
+					Hint: "Open the collection in the dashboard and save it without changes, which recreates its indexes. A missing unique index may also mean that duplicates were inserted, which must be removed first."})
+			}
+		}
+	}
+
+	if len(findings) == 0 {
+		findings = append(findings, doctorFinding{Check: "collections", Status: "ok", Message: fmt.Sprintf("all %d collections and %d indexes exist", len(doctorCollections), indexes)})
+	}
+	return findings
+}
+
+// sequenceViolation is a work clock record that does not alternate with its predecessor.
+type sequenceViolation struct {
+	ID      string `db:"id"`
+	Clock   string `db:"clock"`
+	ClockIn bool   `db:"clock_in"`
+}
+
+// checkSequences verifies that the records of every clock alternate between clock in and clock
+// out, starting with a clock in.
+//
+// Parameters:
+// - app: The App interface used to access the database
+//
+// Returns:
+// - The findings of the check, one per clock with violations or a single one if there is none
+func checkSequences(app core.App) []doctorFinding {
+	var violations []sequenceViolation
+	err := app.DB().NewQuery(`
+		SELECT id, clock, clock_in FROM (
+			SELECT id, clock, clock_in, LAG(clock_in) OVER (PARTITION BY clock ORDER BY timestamp, id) AS previous
+			FROM work_clock
+		)
+		WHERE previous = clock_in OR (previous IS NULL AND clock_in = FALSE)
+		ORDER BY clock, id`).All(&violations)
+	if err != nil {
+		return []doctorFinding{{Check: "sequence", Status: "error", Message: fmt.Sprintf("failed to check the work clock records: %v", err)}}
+	}
+
+	if len(violations) == 0 {
+		return []doctorFinding{{Check: "sequence", Status: "ok", Message: "the records of all clocks alternate between clock in and clock out"}}
+	}
+
+	byClock := map[string][]string{}
+	var clocks []string
+	for _, violation := range violations {
+		if _, ok := byClock[violation.Clock]; !ok {
+			clocks = append(clocks, violation.Clock)
+		}
+		byClock[violation.Clock] = append(byClock[violation.Clock], violation.ID)
+	}
+
+	findings := make([]doctorFinding, 0, len(clocks))
+	for _, clock := range clocks {
+		name := "the default clock"
+		if clock != "" {
+			name = fmt.Sprintf("clock '%s'", clock)
+		}
+
+		ids := byClock[clock]
+		listed := ids[:min(len(ids), 10)]
+		message := fmt.Sprintf("%d record(s) of %s repeat the type of the preceding record or start with a clock out: %s", len(ids), name, strings.Join(listed, ", "))
+		if len(ids) > len(listed) {
+			message += ", ..."
+		}
+
+		findings = append(findings, doctorFinding{Check: "sequence", Status: "error", Message: message,
+			Hint: "Delete the duplicated records or add the missing ones in the day editor, otherwise clocking in and out on this clock fails."})
+	}
+	return findings
+}
+
+// checkTimezoneData verifies that the timezone database is available.
+//
+// Returns:
+// - The finding of the check
+func checkTimezoneData() doctorFinding {
+	hint := "Install the timezone database (e.g. 'apk add tzdata' in Alpine containers) or build the binary with '-tags timetzdata'."
+
+	if _, err := time.LoadLocation("Europe/Berlin"); err != nil {
+		return doctorFinding{Check: "timezone", Status: "error", Message: fmt.Sprintf("the timezone database is not available: %v", err), Hint: hint}
+	}
+
+	if tz := os.Getenv("TZ"); tz != "" {
+		if _, err := time.LoadLocation(strings.TrimPrefix(tz, ":")); err != nil {
+			return doctorFinding{Check: "timezone", Status: "warning", Message: fmt.Sprintf("the timezone '%s' of the TZ environment variable is unknown, using UTC", tz),
+				Hint: "Set TZ to a name of the timezone database, e.g. 'Europe/Berlin'."}
+		}
+	}
+
+	return doctorFinding{Check: "timezone", Status: "ok", Message: fmt.Sprintf("the timezone database is available, the local timezone is %s", time.Local)}
+}
+
+// checkIntegrations verifies that the hosts of the enabled integrations can be connected to.
+//
+// Parameters:
+// - app: The App interface used to load the integration configurations
+//
+// Returns:
+// - The findings of the check, one per host
+func checkIntegrations(app core.App) []doctorFinding {
+	states := loadIntegrationStates(app)
+	names := make([]string, 0, len(states))
+	for name := range states {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	var findings []doctorFinding
+	for _, name := range names {
+		state := states[name]
+		if !state.Enabled {
+			continue
+		}
+
+		for _, address := range integrationAddresses(state.Config) {
+			check := "integration " + name
+			ctx, cancel := context.WithTimeout(context.Background(), doctorDialTimeout)
+			conn, err := dialOutbound(ctx, &net.Dialer{}, address)
+			cancel()
+
+			if err != nil {
+				findings = append(findings, doctorFinding{Check: check, Status: "error", Message: fmt.Sprintf("failed to connect to %s: %v", address, err),
+					Hint: "Check the configuration of the integration, the DNS resolution and the firewall. Networks that require a proxy need OUTBOUND_PROXY."})
+				continue
+			}
+			conn.Close()
+			findings = append(findings, doctorFinding{Check: check, Status: "ok", Message: fmt.Sprintf("%s is reachable", address)})
+		}
+	}
+
+	if len(findings) == 0 {
+		findings = append(findings, doctorFinding{Check: "integrations", Status: "ok", Message: "no integration is enabled"})
+	}
+	return findings
+}
+
+// integrationAddresses determines the hosts an integration connects to.
+//
+// Parameters:
+// - config: The configuration of the integration, e.g. WebhooksConfig
+//
+// Returns:
+// - The distinct addresses (host:port)
+func integrationAddresses(config any) []string {
+	var urls []string
+	switch config := config.(type) {
+	case WebhooksConfig:
+		urls = config.URLs
+	case SlackConfig:
+		urls = []string{config.WebhookURL}
+	case MQTTConfig:
+		urls = []string{config.Broker}
+	case CalendarConfig:
+		urls = []string{config.ICSURL}
+	case PushConfig:
+		urls = []string{config.URL}
+	case MatrixConfig:
+		urls = []string{config.Homeserver}
+	}
+
+	defaultPorts := map[string]string{"http": "80", "https": "443", "webcal": "443", "tcp": "1883", "tls": "8883"}
+
+	var addresses []string
+	for _, value := range urls {
+		parsed, err := url.Parse(value)
+		if err != nil || parsed.Hostname() == "" {
+			continue
+		}
+
+		port := parsed.Port()
+		if port == "" {
+			port = defaultPorts[parsed.Scheme]
+		}
+
+		address := net.JoinHostPort(parsed.Hostname(), port)
+		if !slices.Contains(addresses, address) {
+			addresses = append(addresses, address)
+		}
+	}
+	return addresses
+}
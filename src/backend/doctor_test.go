@@ -0,0 +1,89 @@
+package backend
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/pocketbase/pocketbase/tools/dbutils"
+	"github.com/yerTools/simple-frontend-stack/src/backend/backendtest"
+)
+
+func TestRunDoctor(t *testing.T) {
+	app := backendtest.NewApp(t)
+	backendtest.AddRecords(t, app,
+		backendtest.ClockIn("2025-04-01T09:00:00Z"),
+		backendtest.ClockOut("2025-04-01T17:00:00Z"),
+	)
+
+	for _, finding := range runDoctor(app, false) {
+		if finding.Status != "ok" {
+			t.Errorf("expected no problems on a fresh instance, got %+v", finding)
+		}
+	}
+}
+
+func TestCheckSequences(t *testing.T) {
+	app := backendtest.NewApp(t)
+	records := backendtest.AddRecords(t, app,
+		backendtest.ClockOut("2025-04-01T08:00:00Z"),
+		backendtest.ClockIn("2025-04-01T09:00:00Z"),
+		backendtest.ClockOut("2025-04-01T12:00:00Z"),
+		backendtest.ClockOut("2025-04-01T17:00:00Z"),
+	)
+
+	findings := checkSequences(app)
+	if len(findings) != 1 || findings[0].Status != "error" {
+		t.Fatalf("expected a single error, got %+v", findings)
+	}
+	for _, index := range []int{0, 3} {
+		if !strings.Contains(findings[0].Message, records[index].Id) {
+			t.Errorf("expected record %d to be reported, got %q", index, findings[0].Message)
+		}
+	}
+	for _, index := range []int{1, 2} {
+		if strings.Contains(findings[0].Message, records[index].Id) {
+			t.Errorf("expected record %d not to be reported, got %q", index, findings[0].Message)
+		}
+	}
+}
+
+func TestCheckCollectionsMissingIndex(t *testing.T) {
+	app := backendtest.NewApp(t)
+
+	collection, err := app.FindCollectionByNameOrId("work_clock")
+	if err != nil {
+		t.Fatalf("failed to find work clock collection: %v", err)
+	}
+	if len(collection.Indexes) == 0 {
+		t.Fatal("expected the work clock collection to have indexes")
+	}
+	index := dbutils.ParseIndex(collection.Indexes[0]).IndexName
+	if _, err := app.DB().NewQuery("DROP INDEX `" + index + "`").Execute(); err != nil {
+		t.Fatalf("failed to drop index: %v", err)
+	}
+
+	findings := checkCollections(app)
+	if len(findings) != 1 || findings[0].Status != "error" || !strings.Contains(findings[0].Message, index) {
+		t.Errorf("expected the missing index to be reported, got %+v", findings)
+	}
+}
+
+func TestIntegrationAddresses(t *testing.T) {
+	tests := []struct {
+		config any
+		want   []string
+	}{
+		{WebhooksConfig{URLs: []string{"https://a.example.com/hook", "https://a.example.com/other", "http://b.example.com:8080/"}}, []string{"a.example.com:443", "b.example.com:8080"}},
+		{MQTTConfig{Broker: "tls://mqtt.example.com"}, []string{"mqtt.example.com:8883"}},
+		{MQTTConfig{Broker: "tcp://mqtt.local"}, []string{"mqtt.local:1883"}},
+		{CalendarConfig{ICSURL: "webcal://calendar.example.com/work.ics"}, []string{"calendar.example.com:443"}},
+		{PushConfig{Service: "ntfy", URL: "https://ntfy.sh/topic"}, []string{"ntfy.sh:443"}},
+	}
+
+	for _, test := range tests {
+		if got := integrationAddresses(test.config); !slices.Equal(got, test.want) {
+			t.Errorf("integrationAddresses(%+v) = %v, want %v", test.config, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,57 @@
+// Durations Module for PocketBase
+//
+// This module formats durations for API responses and exports. Report endpoints return every
+// duration both in seconds and as a string formatted according to DURATION_FORMAT, so all
+// frontends display durations the same way without implementing the formatting themselves:
+// - 'hours_minutes' (default): 7:30
+// - 'decimal': 7.50
+// - 'units': 7h30m
+package backend
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// durationFormats are the supported duration formats.
+var durationFormats = []string{"hours_minutes", "decimal", "units"}
+
+// formatDuration formats a duration as hours.
+//
+// Parameters:
+// - duration: The duration to format, rounded to minutes for the hours and minutes formats
+// - format: The duration format ('hours_minutes', 'decimal' or 'units')
+// - decimalSeparator: The separator of the decimal format
+//
+// Returns:
+// - The formatted duration
+func formatDuration(duration time.Duration, format string, decimalSeparator string) string {
+	minutes := int64(duration.Round(time.Minute).Minutes())
+
+	sign := ""
+	if minutes < 0 {
+		sign, minutes = "-", -minutes
+	}
+
+	switch format {
+	case "decimal":
+		return strings.Replace(strconv.FormatFloat(duration.Hours(), 'f', 2, 64), ".", decimalSeparator, 1)
+	case "units":
+		return fmt.Sprintf("%s%dh%02dm", sign, minutes/60, minutes%60)
+	default:
+		return fmt.Sprintf("%s%d:%02d", sign, minutes/60, minutes%60)
+	}
+}
+
+// formatResponseDuration formats a duration in seconds for API responses in the configured format.
+//
+// Parameters:
+// - seconds: The duration in seconds
+//
+// Returns:
+// - The formatted duration
+func formatResponseDuration(seconds int64) string {
+	return formatDuration(time.Duration(seconds)*time.Second, settings.DurationFormat, ".")
+}
@@ -0,0 +1,181 @@
+// Email Gateway Module for PocketBase
+//
+// This module allows clocking in and out by email, for environments where only email passes
+// the firewall. Inbound emails are delivered to the gateway endpoint by a mail provider's
+// inbound webhook (such as Mailgun Routes, Postmark or SendGrid Inbound Parse).
+//
+// The command is read from the subject or, if the subject contains none, from the first
+// non-empty line of the body:
+// - "clock in" (or "in", "start")
+// - "clock out" (or "out", "stop")
+// - "toggle"
+//
+// Since the sender of an email can be forged, the endpoint is additionally protected by the
+// secret configured in EMAIL_GATEWAY_SECRET, which must be part of the webhook URL. Only
+// emails from the addresses in EMAIL_ALLOWED_SENDERS are accepted.
+package backend
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/mail"
+	"slices"
+	"strings"
+
+	"github.com/pocketbase/pocketbase/core"
+)
+
+// emailCommands maps the accepted commands to the action they trigger ("in", "out" or "toggle").
+var emailCommands = map[string]string{
+	"clock in":  "in",
+	"in":        "in",
+	"start":     "in",
+	"clock out": "out",
+	"out":       "out",
+	"stop":      "out",
+	"toggle":    "toggle",
+}
+
+// RegisterEmailGatewayAPI registers the inbound email endpoint with the PocketBase server.
+// It creates the following route:
+// - POST /api/email_gateway/{secret} - Receives an inbound email from a mail provider
+//
+// The email is read from the form fields 'from' (or 'sender'), 'subject' and 'text'
+// (or 'body-plain'), which covers the formats of the common mail providers.
+//
+// Parameters:
+// - app: The PocketBase application instance
+func RegisterEmailGatewayAPI(app core.App) {
+	app.OnServe().BindFunc(func(se *core.ServeEvent) error {
+		se.Router.POST("/api/email_gateway/{secret}", func(e *core.RequestEvent) error {
+			if settings.EmailGatewaySecret == "" {
+				return e.Error(http.StatusNotFound, "The email gateway is not configured", nil)
+			}
+			if subtle.ConstantTimeCompare([]byte(e.Request.PathValue("secret")), []byte(settings.EmailGatewaySecret)) != 1 {
+				return e.Error(http.StatusForbidden, "Invalid email gateway secret", nil)
+			}
+
+			sender, err := parseEmailSender(firstFormValue(e, "from", "sender"))
+			if err != nil {
+				return e.Error(http.StatusBadRequest, err.Error(), nil)
+			}
+			if !slices.ContainsFunc(settings.EmailAllowedSenders, func(allowed string) bool { return strings.EqualFold(allowed, sender) }) {
+				app.Logger().Warn("rejected email from unknown sender", "sender", sender)
+				return e.Error(http.StatusForbidden, "The sender is not allowed to use the email gateway", nil)
+			}
+
+			action := parseEmailCommand(e.Request.FormValue("subject"), firstFormValue(e, "text", "body-plain"))
+			if action == "" {
+				return e.Error(http.StatusBadRequest, "The email contains no known command", nil)
+			}
+
+			if err := handleEmailCommand(e.Request.Context(), app, action); err != nil {
+				var tooLongErr *sessionTooLongError
+				if errors.As(err, &tooLongErr) {
+					return e.Error(http.StatusConflict, fmt.Sprintf("Failed to execute email command: %v", tooLongErr), nil)
+				}
+				return e.Error(http.StatusInternalServerError, fmt.Sprintf("Failed to execute email command: %v", err), err)
+			}
+
+			app.Logger().Info("executed email command", "sender", sender, "action", action)
+			return callSucceeded(e)
+		})
+
+		return se.Next()
+	})
+}
+
+// firstFormValue returns the first non-empty value of the given form fields.
+//
+// Parameters:
+// - e: The RequestEvent from the HTTP handler
+// - names: The names of the form fields in order of preference
+//
+// Returns:
+// - The first non-empty value or an empty string
+func firstFormValue(e *core.RequestEvent, names ...string) string {
+	for _, name := range names {
+		if value := e.Request.FormValue(name); value != "" {
+			return value
+		}
+	}
+
+	return ""
+}
+
+// parseEmailSender extracts the email address of a sender like "Jane Doe <jane@example.com>".
+//
+// Parameters:
+// - from: The sender of the email
+//
+// Returns:
+// - The lower case email address
+// - An error if the sender is missing or invalid
+func parseEmailSender(from string) (string, error) {
+	if from == "" {
+		return "", fmt.Errorf("missing 'from' (string) parameter")
+	}
+
+	address, err := mail.ParseAddress(from)
+	if err != nil {
+		return "", fmt.Errorf("invalid sender '%s': %w", from, err)
+	}
+
+	return strings.ToLower(address.Address), nil
+}
+
+// parseEmailCommand finds the command of an email in its subject or the first non-empty line of its body.
+//
+// Parameters:
+// - subject: The subject of the email
+// - body: The plain text body of the email
+//
+// Returns:
+// - The action of the command ("in", "out" or "toggle"), an empty string if no known command was found
+func parseEmailCommand(subject string, body string) string {
+	candidates := []string{subject}
+	for _, line := range strings.Split(body, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			candidates = append(candidates, line)
+			break
+		}
+	}
+
+	for _, candidate := range candidates {
+		command := strings.Join(strings.Fields(strings.ToLower(candidate)), " ")
+		command = strings.TrimRight(command, ".!")
+
+		if action, ok := emailCommands[command]; ok {
+			return action
+		}
+	}
+
+	return ""
+}
+
+// handleEmailCommand clocks the default clock in or out as requested by an email.
+//
+// Parameters:
+// - ctx: The context cancelling the operation, e.g. of the request
+// - app: The App interface (typically a PocketBase instance or transaction)
+// - action: The action of the command ("in", "out" or "toggle")
+//
+// Returns:
+// - An error if clocking in or out fails
+// - A *sessionTooLongError if clocking out would close a session longer than the maximum session duration
+func handleEmailCommand(ctx context.Context, app core.App, action string) error {
+	service := workClockServiceOf(app).WithContext(ctx)
+	if action != "toggle" {
+		return service.ClockInOut("", action == "in", false)
+	}
+
+	clockedIn, err := service.IsClockedIn("")
+	if err != nil {
+		return err
+	}
+
+	return service.ClockInOut("", !clockedIn, false)
+}
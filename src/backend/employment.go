@@ -0,0 +1,139 @@
+// Employment Periods Module for PocketBase
+//
+// This module resolves whether a day lies within the employment of a clock's owner, based on the
+// hire and termination dates stored in the employment_periods collection. Periods are managed
+// through the regular collection API; a period without end lasts until further notice.
+//
+// Days outside of all periods have no target in the daily summaries, the report builder and the
+// balance forecast, and the journal shows no compliance flags for them. The backfill tools (the
+// day editor and work clock templates) refuse to create sessions on them. A clock without any
+// period counts as employed on all days, so setups without employment data behave as before.
+package backend
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/core"
+)
+
+// employmentPeriod is a period of employment from the hire to the termination date.
+type employmentPeriod struct {
+	Start string // First day of the employment (YYYY-MM-DD)
+	End   string // Last day of the employment (YYYY-MM-DD), empty while employed
+}
+
+// employmentPeriods are the employment periods of a clock.
+type employmentPeriods []employmentPeriod
+
+// includes reports whether a day lies within the employment.
+//
+// Parameters:
+// - day: The local midnight of the day
+//
+// Returns:
+// - True if the day lies within one of the periods or there are no periods at all
+func (p employmentPeriods) includes(day time.Time) bool {
+	if len(p) == 0 {
+		return true
+	}
+
+	date := day.Format(time.DateOnly)
+	for _, period := range p {
+		if period.Start <= date && (period.End == "" || date <= period.End) {
+			return true
+		}
+	}
+	return false
+}
+
+// RegisterEmploymentHooks registers the hooks validating employment periods and recomputing the
+// daily summaries of changed periods with the PocketBase server.
+//
+// Parameters:
+// - app: The PocketBase application instance
+func RegisterEmploymentHooks(app core.App) {
+	app.OnRecordCreate("employment_periods").BindFunc(validatePeriodDates)
+	app.OnRecordUpdate("employment_periods").BindFunc(validatePeriodDates)
+
+	update := func(e *core.RecordEvent) error {
+		started := time.Now()
+		days, err := updateDailySummariesFromRecordDate(e.App, e.Record, "start")
+		recordDailySummaryUpdate(started, days, err)
+		if err != nil {
+			// The period change itself succeeded, a rebuild repairs the summaries
+			e.App.Logger().Error("failed to update daily summaries", "employment_period", e.Record.Id, "error", err)
+		}
+		return e.Next()
+	}
+
+	app.OnRecordAfterCreateSuccess("employment_periods").BindFunc(update)
+	app.OnRecordAfterUpdateSuccess("employment_periods").BindFunc(update)
+	app.OnRecordAfterDeleteSuccess("employment_periods").BindFunc(update)
+}
+
+// validatePeriodDates rejects periods with 'start' and 'end' dates that end before they start.
+//
+// Parameters:
+// - e: The RecordEvent of the saved period
+//
+// Returns:
+// - An error if the period ends before it starts or saving fails
+func validatePeriodDates(e *core.RecordEvent) error {
+	if end := e.Record.GetString("end"); end != "" && end < e.Record.GetString("start") {
+		return fmt.Errorf("the period must not end before it starts")
+	}
+	return e.Next()
+}
+
+// findEmploymentPeriods finds the employment periods of a clock.
+//
+// Parameters:
+// - app: The App interface (typically a PocketBase instance or transaction)
+// - clockID: The ID of the clock, an empty string for the default clock
+//
+// Returns:
+// - The periods sorted by their start
+// - An error if the database query fails
+func findEmploymentPeriods(app core.App, clockID string) (employmentPeriods, error) {
+	records, err := app.FindRecordsByFilter("employment_periods", "clock = {:clock}", "+start", 0, 0, dbx.Params{
+		"clock": clockParam(clockID),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to find employment periods: %w", err)
+	}
+
+	periods := make(employmentPeriods, 0, len(records))
+	for _, record := range records {
+		periods = append(periods, employmentPeriod{
+			Start: record.GetString("start"),
+			End:   record.GetString("end"),
+		})
+	}
+
+	return periods, nil
+}
+
+// checkEmployedDuringSessions rejects sessions starting on days outside the employment.
+//
+// Parameters:
+// - app: The App interface (typically a PocketBase instance or transaction)
+// - clockID: The ID of the clock, an empty string for the default clock
+// - sessions: The sessions to create
+//
+// Returns:
+// - An error if a session starts outside the employment or the periods could not be retrieved
+func checkEmployedDuringSessions(app core.App, clockID string, sessions []clockInOutPair) error {
+	periods, err := findEmploymentPeriods(app, clockID)
+	if err != nil {
+		return err
+	}
+
+	for _, session := range sessions {
+		if day := startOfLocalDay(session.ClockIn); !periods.includes(day) {
+			return fmt.Errorf("%s lies outside of the employment", day.Format(time.DateOnly))
+		}
+	}
+	return nil
+}
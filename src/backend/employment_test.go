@@ -0,0 +1,45 @@
+package backend
+
+import (
+	"testing"
+
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/yerTools/simple-frontend-stack/src/backend/backendtest"
+)
+
+func TestReplaceWorkClockDayRejectsDaysOutsideEmployment(t *testing.T) {
+	app := backendtest.NewApp(t)
+
+	collection, err := app.FindCollectionByNameOrId("employment_periods")
+	if err != nil {
+		t.Fatalf("failed to find employment_periods collection: %v", err)
+	}
+	period := core.NewRecord(collection)
+	period.Set("start", "2025-04-01")
+	period.Set("end", "2025-04-30")
+	if err := app.Save(period); err != nil {
+		t.Fatalf("failed to save employment period: %v", err)
+	}
+
+	for date, employed := range map[string]bool{
+		"2025-03-31": false,
+		"2025-04-01": true,
+		"2025-04-30": true,
+		"2025-05-01": false,
+	} {
+		dayStart := backendtest.MustParseTime(date + "T00:00:00Z")
+		session := clockInOutPair{
+			ClockIn:  backendtest.MustParseTime(date + "T09:00:00Z"),
+			ClockOut: backendtest.MustParseTime(date + "T17:00:00Z"),
+		}
+
+		err := replaceWorkClockDay(t.Context(), app, "", dayStart, dayStart.AddDate(0, 0, 1), []clockInOutPair{session})
+		if employed && err != nil {
+			t.Errorf("failed to replace %s within the employment: %v", date, err)
+		}
+		if !employed && err == nil {
+			t.Errorf("expected replacing %s outside of the employment to be rejected", date)
+		}
+	}
+	backendtest.AssertAlternating(t, app)
+}
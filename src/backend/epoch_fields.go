@@ -0,0 +1,316 @@
+// Epoch Fields Module for PocketBase
+//
+// This module adds the epoch milliseconds of every timestamp to the JSON responses, so embedded
+// clients with limited date libraries don't have to parse RFC3339. For every object property
+// holding an RFC3339 timestamp, a sibling property with the suffix "_ms" is inserted right after
+// it, e.g.
+//
+//	{"since": "2025-04-01T09:00:00Z", "since_ms": 1743498000000}
+//
+// Properties whose "_ms" sibling already exists are left alone, as are timestamps in arrays and
+// plain dates like "2025-04-01". Only the JSON responses of this backend's routes are rewritten
+// (see epochFieldsRoutePrefixes). The API of PocketBase itself, e.g. /api/collections, files sent
+// as attachment, e.g. the JSON export, and responses that are not JSON, e.g. event streams, are
+// passed through unchanged.
+package backend
+
+import (
+	"bytes"
+	"encoding/json"
+	"mime"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tools/router"
+)
+
+// epochFieldSuffix is the suffix of the inserted epoch milliseconds properties.
+const epochFieldSuffix = "_ms"
+
+// epochFieldsRoutePrefixes are the path prefixes of the routes whose JSON responses get the epoch milliseconds.
+var epochFieldsRoutePrefixes = []string{
+	"/api/admin/",
+	"/api/compact/",
+	"/api/cost_centers/",
+	"/api/features",
+	"/api/import/",
+	"/api/journal/",
+	"/api/legacy_import/",
+	"/api/presence/",
+	"/api/projects/",
+	"/api/reports/",
+	"/api/scheduled_exports/",
+	"/api/search",
+	"/api/shared/",
+	"/api/shortcut_tokens",
+	"/api/version",
+	"/api/work_clock/",
+}
+
+// RegisterEpochFieldsHooks registers the middleware adding the epoch milliseconds to the JSON responses.
+//
+// Parameters:
+// - app: The PocketBase application instance
+func RegisterEpochFieldsHooks(app core.App) {
+	app.OnServe().BindFunc(func(se *core.ServeEvent) error {
+		se.Router.BindFunc(func(e *core.RequestEvent) error {
+			if !slices.ContainsFunc(epochFieldsRoutePrefixes, func(prefix string) bool { return strings.HasPrefix(e.Request.URL.Path, prefix) }) {
+				return e.Next()
+			}
+
+			original := e.Response
+			writer := &epochFieldsWriter{ResponseWriter: original}
+			e.Response = writer
+
+			err := e.Next()
+
+			e.Response = original
+			if flushErr := writer.flush(); flushErr != nil && err == nil {
+				err = flushErr
+			}
+			return err
+		})
+
+		return se.Next()
+	})
+}
+
+// epochFieldsWriter buffers JSON responses to add the epoch milliseconds before they are sent,
+// other responses and attachments are written through.
+type epochFieldsWriter struct {
+	http.ResponseWriter
+
+	status      int          // Status of the buffered response
+	wroteHeader bool         // Whether the status was written
+	buffering   bool         // Whether the response is JSON and buffered
+	buffer      bytes.Buffer // The buffered JSON response
+}
+
+// WriteHeader implements http.ResponseWriter and decides whether the response is buffered.
+func (w *epochFieldsWriter) WriteHeader(status int) {
+	if w.wroteHeader {
+		return
+	}
+	w.wroteHeader = true
+
+	mediaType, _, _ := mime.ParseMediaType(w.Header().Get("Content-Type"))
+	disposition, _, _ := mime.ParseMediaType(w.Header().Get("Content-Disposition"))
+	if mediaType == "application/json" && disposition != "attachment" {
+		w.buffering = true
+		w.status = status
+		return
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+// Write implements http.ResponseWriter.
+func (w *epochFieldsWriter) Write(data []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	if w.buffering {
+		return w.buffer.Write(data)
+	}
+	return w.ResponseWriter.Write(data)
+}
+
+// Written reports whether the response was written, so e.Written() keeps working while buffering.
+func (w *epochFieldsWriter) Written() bool {
+	return w.wroteHeader
+}
+
+// Status reports the status of the response, so e.Status() keeps working while buffering.
+func (w *epochFieldsWriter) Status() int {
+	if w.buffering {
+		return w.status
+	}
+	if tracker, ok := w.ResponseWriter.(router.StatusTracker); ok {
+		return tracker.Status()
+	}
+	return 0
+}
+
+// FlushError implements the flushing of http.ResponseController, which is a no-op while buffering.
+func (w *epochFieldsWriter) FlushError() error {
+	if w.buffering {
+		return nil
+	}
+	return http.NewResponseController(w.ResponseWriter).Flush()
+}
+
+// Unwrap returns the original writer, e.g. for http.ResponseController.
+func (w *epochFieldsWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// flush sends the buffered JSON response with the added epoch milliseconds. A body that can't be
+// parsed is sent unchanged.
+//
+// Returns:
+// - An error if writing the response fails
+func (w *epochFieldsWriter) flush() error {
+	if !w.buffering {
+		return nil
+	}
+
+	body := w.buffer.Bytes()
+	if len(bytes.TrimSpace(body)) > 0 {
+		if rewritten, err := addEpochFields(body); err == nil {
+			body = rewritten
+		}
+	}
+
+	w.Header().Del("Content-Length")
+	w.ResponseWriter.WriteHeader(w.status)
+	_, err := w.ResponseWriter.Write(body)
+	return err
+}
+
+// addEpochFields adds the epoch milliseconds of the timestamps to a JSON value. The order of the
+// properties is kept.
+//
+// Parameters:
+// - data: The JSON value
+//
+// Returns:
+// - The JSON value with the added properties
+// - An error if the value isn't valid JSON
+func addEpochFields(data []byte) ([]byte, error) {
+	var output bytes.Buffer
+	if err := writeEpochFields(&output, json.RawMessage(bytes.TrimSpace(data))); err != nil {
+		return nil, err
+	}
+	if bytes.HasSuffix(data, []byte("\n")) {
+		output.WriteByte('\n')
+	}
+	return output.Bytes(), nil
+}
+
+// writeEpochFields writes a JSON value with the epoch milliseconds of its timestamps.
+//
+// Parameters:
+// - output: The buffer the value is written to
+// - value: The JSON value
+//
+// Returns:
+// - An error if the value isn't valid JSON
+func writeEpochFields(output *bytes.Buffer, value json.RawMessage) error {
+	if len(value) == 0 {
+		return nil
+	}
+
+	switch value[0] {
+	case '{':
+		return writeObjectEpochFields(output, value)
+	case '[':
+		var items []json.RawMessage
+		if err := json.Unmarshal(value, &items); err != nil {
+			return err
+		}
+
+		output.WriteByte('[')
+		for i, item := range items {
+			if i > 0 {
+				output.WriteByte(',')
+			}
+			if err := writeEpochFields(output, item); err != nil {
+				return err
+			}
+		}
+		output.WriteByte(']')
+		return nil
+	default:
+		output.Write(value)
+		return nil
+	}
+}
+
+// writeObjectEpochFields writes a JSON object, inserting the epoch milliseconds after each
+// property holding a timestamp.
+//
+// Parameters:
+// - output: The buffer the object is written to
+// - value: The JSON object
+//
+// Returns:
+// - An error if the object isn't valid JSON
+func writeObjectEpochFields(output *bytes.Buffer, value json.RawMessage) error {
+	decoder := json.NewDecoder(bytes.NewReader(value))
+	if _, err := decoder.Token(); err != nil {
+		return err
+	}
+
+	var keys []string
+	values := map[string]json.RawMessage{}
+	for decoder.More() {
+		token, err := decoder.Token()
+		if err != nil {
+			return err
+		}
+		key, _ := token.(string)
+
+		var propertyValue json.RawMessage
+		if err := decoder.Decode(&propertyValue); err != nil {
+			return err
+		}
+		if _, ok := values[key]; !ok {
+			keys = append(keys, key)
+		}
+		values[key] = propertyValue
+	}
+
+	output.WriteByte('{')
+	for i, key := range keys {
+		if i > 0 {
+			output.WriteByte(',')
+		}
+		encodedKey, _ := json.Marshal(key)
+		output.Write(encodedKey)
+		output.WriteByte(':')
+		if err := writeEpochFields(output, values[key]); err != nil {
+			return err
+		}
+
+		epochKey := key + epochFieldSuffix
+		if _, exists := values[epochKey]; exists {
+			continue
+		}
+		if timestamp, ok := parseJSONTimestamp(values[key]); ok {
+			encodedEpochKey, _ := json.Marshal(epochKey)
+			output.WriteByte(',')
+			output.Write(encodedEpochKey)
+			output.WriteByte(':')
+			output.WriteString(strconv.FormatInt(timestamp.UnixMilli(), 10))
+		}
+	}
+	output.WriteByte('}')
+
+	return nil
+}
+
+// parseJSONTimestamp parses a JSON string holding an RFC3339 timestamp.
+//
+// Parameters:
+// - value: The JSON value
+//
+// Returns:
+// - The timestamp
+// - Whether the value is an RFC3339 timestamp
+func parseJSONTimestamp(value json.RawMessage) (time.Time, bool) {
+	// The shortest RFC3339 timestamp is "2006-01-02T15:04:05Z" including the quotes
+	if len(value) < 22 || value[0] != '"' {
+		return time.Time{}, false
+	}
+
+	var text string
+	if err := json.Unmarshal(value, &text); err != nil {
+		return time.Time{}, false
+	}
+
+	timestamp, err := time.Parse(time.RFC3339Nano, text)
+	return timestamp, err == nil
+}
@@ -0,0 +1,147 @@
+package backend
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/yerTools/simple-frontend-stack/src/backend/backendtest"
+)
+
+func TestAddEpochFields(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{
+			name:     "timestamp",
+			input:    `{"since":"2025-04-01T09:00:00Z","clocked_in":true}`,
+			expected: `{"since":"2025-04-01T09:00:00Z","since_ms":1743498000000,"clocked_in":true}`,
+		},
+		{
+			name:     "offset and fraction",
+			input:    `{"at":"2025-04-01T11:00:00.5+02:00"}`,
+			expected: `{"at":"2025-04-01T11:00:00.5+02:00","at_ms":1743498000500}`,
+		},
+		{
+			name:     "nested",
+			input:    `{"days":[{"start":"2025-04-01T09:00:00Z"}],"total":{"end":"2025-04-01T09:00:01Z"}}`,
+			expected: `{"days":[{"start":"2025-04-01T09:00:00Z","start_ms":1743498000000}],"total":{"end":"2025-04-01T09:00:01Z","end_ms":1743498001000}}`,
+		},
+		{
+			name:     "existing sibling",
+			input:    `{"since":"2025-04-01T09:00:00Z","since_ms":1}`,
+			expected: `{"since":"2025-04-01T09:00:00Z","since_ms":1}`,
+		},
+		{
+			name:     "no timestamps",
+			input:    `{"date":"2025-04-01","since":null,"note":"2025-04-01 09:00","list":["2025-04-01T09:00:00Z"]}`,
+			expected: `{"date":"2025-04-01","since":null,"note":"2025-04-01 09:00","list":["2025-04-01T09:00:00Z"]}`,
+		},
+		{
+			name:     "trailing newline",
+			input:    "[{\"at\":\"2025-04-01T09:00:00Z\"}]\n",
+			expected: "[{\"at\":\"2025-04-01T09:00:00Z\",\"at_ms\":1743498000000}]\n",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			output, err := addEpochFields([]byte(test.input))
+			if err != nil {
+				t.Fatalf("failed to add epoch fields: %v", err)
+			}
+			if string(output) != test.expected {
+				t.Errorf("expected %s, got %s", test.expected, output)
+			}
+		})
+	}
+
+	if _, err := addEpochFields([]byte(`{"since":`)); err == nil {
+		t.Error("expected an error for invalid JSON")
+	}
+}
+
+func TestEpochFieldsResponses(t *testing.T) {
+	app := backendtest.NewApp(t)
+	RegisterEpochFieldsHooks(app)
+	RegisterWorkClockStatusAPI(app)
+	handler := backendtest.NewHandler(t, app)
+
+	backendtest.AddRecords(t, app, backendtest.ClockIn("2025-04-01T09:00:00Z"))
+
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/api/work_clock/status", nil))
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", recorder.Code, recorder.Body.String())
+	}
+
+	var status map[string]any
+	if err := json.Unmarshal(recorder.Body.Bytes(), &status); err != nil {
+		t.Fatalf("failed to decode status: %v", err)
+	}
+	expected := float64(backendtest.MustParseTime("2025-04-01T09:00:00Z").UnixMilli())
+	if status["since_ms"] != expected {
+		t.Errorf("expected since_ms %v, got %v", expected, status["since_ms"])
+	}
+	if _, ok := status["clocked_in_ms"]; ok {
+		t.Error("expected no epoch field for a property that isn't a timestamp")
+	}
+}
+
+func TestEpochFieldsPassThrough(t *testing.T) {
+	app := backendtest.NewApp(t)
+	RegisterEpochFieldsHooks(app)
+	RegisterExportAPI(app)
+	handler := backendtest.NewHandler(t, app)
+
+	backendtest.AddRecords(t, app,
+		backendtest.ClockIn("2025-04-01T09:00:00Z"),
+		backendtest.ClockOut("2025-04-01T17:00:00Z"),
+	)
+
+	superusers, err := app.FindCollectionByNameOrId(core.CollectionNameSuperusers)
+	if err != nil {
+		t.Fatalf("failed to find superusers collection: %v", err)
+	}
+	superuser := core.NewRecord(superusers)
+	superuser.SetEmail("admin@example.com")
+	superuser.SetPassword("password123")
+	if err := app.Save(superuser); err != nil {
+		t.Fatalf("failed to save superuser: %v", err)
+	}
+	token, err := superuser.NewAuthToken()
+	if err != nil {
+		t.Fatalf("failed to create token: %v", err)
+	}
+
+	testCases := []struct {
+		name string
+		path string
+		want string // A property of the unchanged response
+	}{
+		{"json export", "/api/work_clock/export?format=json&month=2025-04", `"start":`},
+		{"records api", "/api/collections/work_clock/records", `"timestamp":`},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			request := httptest.NewRequest(http.MethodGet, tc.path, nil)
+			request.Header.Set("Authorization", token)
+			recorder := httptest.NewRecorder()
+			handler.ServeHTTP(recorder, request)
+			if recorder.Code != http.StatusOK {
+				t.Fatalf("expected status 200, got %d: %s", recorder.Code, recorder.Body.String())
+			}
+
+			body := recorder.Body.String()
+			if !strings.Contains(body, tc.want) || strings.Contains(body, epochFieldSuffix+`":`) {
+				t.Errorf("expected the response to be passed through unchanged, got %s", body)
+			}
+		})
+	}
+}
@@ -0,0 +1,235 @@
+// Events Module for PocketBase
+//
+// This module records what the background subsystems did in the events collection, so admins can
+// see what happened overnight without searching the logs. Each event has a machine-readable type,
+// a level ('info', 'warning' or 'error'), a message and event specific data. The following types
+// are recorded:
+// - 'backup_completed' / 'backup_failed': A backup was created or failed
+// - 'import_completed' / 'import_failed': An import run finished (see the import history module)
+// - 'webhook_failed': A webhook event could not be delivered
+// - 'integration_failed': A Slack, MQTT, push or Matrix message could not be delivered
+// - 'stale_session_reminder': A reminder about a forgotten open session was sent
+// - 'delegated_change': A user changed a clock on behalf of its owner (see the delegation module)
+// - 'absence_conflict': A vacation or sick day was entered for a day with recorded work (see the absences module)
+// - 'vacation_warning': Vacation days are about to expire or exceed the carry-over limit (see the vacation module)
+// - 'scheduled_export_completed' / 'scheduled_export_failed': A scheduled export was delivered or failed (see the scheduled exports module)
+//
+// Recording an event never fails the operation it describes; failures are only logged.
+package backend
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/apis"
+	"github.com/pocketbase/pocketbase/core"
+)
+
+const (
+	// defaultEventsPageSize is the number of events per page if no limit is requested
+	defaultEventsPageSize = 50
+
+	// maxEventsPageSize is the maximum number of events per page
+	maxEventsPageSize = 500
+)
+
+// eventLevels are the supported levels of events.
+var eventLevels = []string{"info", "warning", "error"}
+
+// EventEntry is an event as returned by the events endpoint.
+type EventEntry struct {
+	ID      string         `json:"id"`      // ID of the event
+	Type    string         `json:"type"`    // Machine-readable type of the event, e.g. 'backup_completed'
+	Level   string         `json:"level"`   // 'info', 'warning' or 'error'
+	Message string         `json:"message"` // Human-readable description of the event
+	Data    map[string]any `json:"data"`    // Event specific details
+	Created time.Time      `json:"created"` // Time the event happened
+}
+
+// EventsPage is a page of the events listing.
+type EventsPage struct {
+	Events     []EventEntry `json:"events"`      // Events of the page, newest first
+	NextCursor string       `json:"next_cursor"` // Cursor of the next page, empty on the last page
+}
+
+// eventFilter restricts the listed events.
+type eventFilter struct {
+	Type  string    // Type of the events, empty for all types
+	Level string    // Level of the events, empty for all levels
+	From  time.Time // Start of the range (inclusive), a zero value if unbounded
+	To    time.Time // End of the range (exclusive), a zero value if unbounded
+}
+
+// RegisterEventsAPI registers the hooks recording backups and the events listing with the PocketBase server.
+// It creates the following route:
+// - GET /api/admin/events?type=&level=&from=&to=&cursor=&limit= - Lists the events page by page, newest first,
+// only accessible for superusers
+//
+// The first page is requested without cursor. Each page contains the cursor of the next page,
+// which is empty on the last page.
+//
+// Parameters:
+// - app: The PocketBase application instance
+func RegisterEventsAPI(app core.App) {
+	app.OnBackupCreate().BindFunc(func(e *core.BackupEvent) error {
+		err := e.Next()
+		if err != nil {
+			recordEvent(e.App, "backup_failed", "error", fmt.Sprintf("Backup %s failed: %v", e.Name, err), map[string]any{"name": e.Name})
+		} else {
+			recordEvent(e.App, "backup_completed", "info", fmt.Sprintf("Backup %s completed", e.Name), map[string]any{"name": e.Name})
+		}
+		return err
+	})
+
+	app.OnServe().BindFunc(func(se *core.ServeEvent) error {
+		se.Router.GET("/api/admin/events", func(e *core.RequestEvent) error {
+			query := e.Request.URL.Query()
+
+			from, to, err := parseOptionalTimeRangeParams(query.Get("from"), query.Get("to"))
+			if err != nil {
+				return e.Error(http.StatusBadRequest, err.Error(), nil)
+			}
+
+			filter := eventFilter{Type: query.Get("type"), Level: query.Get("level"), From: from, To: to}
+			if filter.Level != "" && !slices.Contains(eventLevels, filter.Level) {
+				return e.Error(http.StatusBadRequest, fmt.Sprintf("Invalid 'level' value '%s'. Expected one of: %s", filter.Level, strings.Join(eventLevels, ", ")), nil)
+			}
+
+			limit := defaultEventsPageSize
+			if limitValue := query.Get("limit"); limitValue != "" {
+				limit, err = strconv.Atoi(limitValue)
+				if err != nil || limit < 1 || limit > maxEventsPageSize {
+					return e.Error(http.StatusBadRequest, fmt.Sprintf("Invalid 'limit' (integer) parameter. Expected a value between 1 and %d", maxEventsPageSize), nil)
+				}
+			}
+
+			page, err := findEventsPage(app, filter, query.Get("cursor"), limit)
+			if err != nil {
+				return e.Error(http.StatusInternalServerError, fmt.Sprintf("Failed to find events: %v", err), err)
+			}
+
+			return e.JSON(http.StatusOK, page)
+		}).Bind(apis.RequireSuperuserAuth())
+
+		return se.Next()
+	})
+}
+
+// recordEvent records an event. Failing to record it is only logged.
+//
+// Parameters:
+// - app: The App interface used to save the event
+// - eventType: The machine-readable type of the event, e.g. 'backup_completed'
+// - level: The level of the event ('info', 'warning' or 'error')
+// - message: The human-readable description of the event
+// - data: The event specific details, may be nil
+func recordEvent(app core.App, eventType string, level string, message string, data map[string]any) {
+	collection, err := app.FindCollectionByNameOrId("events")
+	if err != nil {
+		app.Logger().Error("failed to find events collection", "error", err)
+		return
+	}
+
+	if data == nil {
+		data = map[string]any{}
+	}
+
+	record := core.NewRecord(collection)
+	record.Set("type", eventType)
+	record.Set("level", level)
+	record.Set("message", message)
+	record.Set("data", data)
+
+	if err := app.Save(record); err != nil {
+		app.Logger().Error("failed to record event", "type", eventType, "error", err)
+	}
+}
+
+// recordIntegrationFailure logs and records a failed delivery of an integration.
+//
+// Parameters:
+// - app: The App interface used for logging and saving the event
+// - integration: The name of the integration, e.g. 'slack'
+// - err: The error the delivery failed with
+func recordIntegrationFailure(app core.App, integration string, err error) {
+	app.Logger().Error("failed to deliver integration message", "integration", integration, "error", err)
+	recordEvent(app, "integration_failed", "error", fmt.Sprintf("Failed to deliver %s message: %v", integration, err), map[string]any{"integration": integration})
+}
+
+// findEventsPage finds a page of the events, newest first.
+//
+// Parameters:
+// - app: The App interface (typically a PocketBase instance or transaction)
+// - filter: The filter of the events
+// - cursor: The ID of the last event of the previous page, an empty string requests the first page
+// - limit: The maximum number of events of the page
+//
+// Returns:
+// - The page of events
+// - An error if the cursor is unknown or the database query fails
+func findEventsPage(app core.App, filter eventFilter, cursor string, limit int) (*EventsPage, error) {
+	var conditions []string
+	params := dbx.Params{}
+
+	if filter.Type != "" {
+		conditions = append(conditions, "type = {:type}")
+		params["type"] = filter.Type
+	}
+	if filter.Level != "" {
+		conditions = append(conditions, "level = {:level}")
+		params["level"] = filter.Level
+	}
+	if !filter.From.IsZero() {
+		conditions = append(conditions, "created >= {:from}")
+		params["from"] = dateTimeParam(filter.From)
+	}
+	if !filter.To.IsZero() {
+		conditions = append(conditions, "created < {:to}")
+		params["to"] = dateTimeParam(filter.To)
+	}
+	if cursor != "" {
+		last, err := app.FindRecordById("events", cursor)
+		if err != nil {
+			return nil, fmt.Errorf("event with id '%s' does not exist", cursor)
+		}
+
+		// Events of the same millisecond are ordered by their ID
+		conditions = append(conditions, "(created < {:cursor_created} || (created = {:cursor_created} && id < {:cursor_id}))")
+		params["cursor_created"] = last.GetDateTime("created")
+		params["cursor_id"] = last.Id
+	}
+
+	records, err := app.FindRecordsByFilter("events", strings.Join(conditions, " && "), "-created,-id", limit+1, 0, params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find events: %w", err)
+	}
+
+	page := &EventsPage{Events: make([]EventEntry, 0, min(len(records), limit))}
+	if len(records) > limit {
+		records = records[:limit]
+		page.NextCursor = records[len(records)-1].Id
+	}
+
+	for _, record := range records {
+		data := map[string]any{}
+		if err := record.UnmarshalJSONField("data", &data); err != nil {
+			return nil, fmt.Errorf("invalid data of event with id '%s': %w", record.Id, err)
+		}
+
+		page.Events = append(page.Events, EventEntry{
+			ID:      record.Id,
+			Type:    record.GetString("type"),
+			Level:   record.GetString("level"),
+			Message: record.GetString("message"),
+			Data:    data,
+			Created: record.GetDateTime("created").Time(),
+		})
+	}
+
+	return page, nil
+}
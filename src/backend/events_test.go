@@ -0,0 +1,42 @@
+package backend
+
+import (
+	"testing"
+
+	"github.com/yerTools/simple-frontend-stack/src/backend/backendtest"
+)
+
+func TestFindEventsPage(t *testing.T) {
+	app := backendtest.NewApp(t)
+
+	for range 3 {
+		recordEvent(app, "webhook_failed", "error", "Failed to deliver clock_in event", map[string]any{"event": "clock_in"})
+	}
+	recordEvent(app, "backup_completed", "info", "Backup completed", nil)
+
+	seen := map[string]bool{}
+	cursor := ""
+	for {
+		page, err := findEventsPage(app, eventFilter{Type: "webhook_failed"}, cursor, 2)
+		if err != nil {
+			t.Fatalf("failed to find events: %v", err)
+		}
+		for _, event := range page.Events {
+			if event.Type != "webhook_failed" || event.Data["event"] != "clock_in" {
+				t.Errorf("unexpected event %+v", event)
+			}
+			if seen[event.ID] {
+				t.Errorf("event %s listed twice", event.ID)
+			}
+			seen[event.ID] = true
+		}
+		if page.NextCursor == "" {
+			break
+		}
+		cursor = page.NextCursor
+	}
+
+	if len(seen) != 3 {
+		t.Errorf("expected 3 webhook failures, got %d", len(seen))
+	}
+}
@@ -0,0 +1,101 @@
+// Expenses Module for PocketBase
+//
+// This module completes the expenses stored in the expenses collection. Expenses such as train
+// tickets or hotel nights are created through the regular collection API, including the upload of
+// a receipt, and are attached either to a session (by its clock in record) or to a day. Expenses
+// of a session inherit the day and clock of the session, so both kinds can be exported by date.
+//
+// Expenses are included in payroll exports (see the 'expenses' column), so work trips don't have
+// to be tracked in a second application.
+package backend
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/core"
+)
+
+// RegisterExpensesAPI registers the hooks completing created and updated expenses.
+//
+// Parameters:
+// - app: The PocketBase application instance
+func RegisterExpensesAPI(app core.App) {
+	app.OnRecordCreate("expenses").BindFunc(completeExpense)
+	app.OnRecordUpdate("expenses").BindFunc(completeExpense)
+}
+
+// completeExpense derives the day and clock of an expense before it is validated and saved.
+// Expenses of a session get the day and clock of the session, the date of other expenses is
+// truncated to the day.
+//
+// Parameters:
+// - e: The RecordEvent of the saved expense
+//
+// Returns:
+// - An error if the session is not a clock in record, the expense has neither session nor date,
+// or saving fails
+func completeExpense(e *core.RecordEvent) error {
+	date := e.Record.GetDateTime("date").Time()
+
+	if sessionID := e.Record.GetString("session"); sessionID != "" {
+		clockIn, err := findClockInRecord(e.App, "", sessionID)
+		if err != nil {
+			return err
+		}
+
+		date = clockIn.GetDateTime("timestamp").Time()
+		e.Record.Set("clock", clockIn.GetString("clock"))
+	} else if date.IsZero() {
+		return fmt.Errorf("expense needs either a 'session' or a 'date'")
+	}
+
+	e.Record.Set("date", dateTimeParam(startOfLocalDay(date)))
+
+	return e.Next()
+}
+
+// startOfLocalDay returns the local midnight of the day containing a point in time.
+func startOfLocalDay(t time.Time) time.Time {
+	local := t.In(time.Local)
+	return time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, time.Local)
+}
+
+// findExpenses finds the expenses of a clock within a range, sorted by their date.
+//
+// Parameters:
+// - app: The App interface (typically a PocketBase instance or transaction)
+// - clockID: The ID of the clock, an empty string for the default clock
+// - from: The start of the range (inclusive), a zero value means unbounded
+// - to: The end of the range (exclusive), a zero value means unbounded
+//
+// Returns:
+// - The expense records
+// - An error if the database query fails
+func findExpenses(app core.App, clockID string, from, to time.Time) ([]*core.Record, error) {
+	conditions := []string{"clock = {:clock}"}
+	params := dbx.Params{"clock": clockParam(clockID)}
+
+	if !from.IsZero() {
+		conditions = append(conditions, "date >= {:from}")
+		params["from"] = dateTimeParam(from)
+	}
+	if !to.IsZero() {
+		conditions = append(conditions, "date < {:to}")
+		params["to"] = dateTimeParam(to)
+	}
+
+	records, err := app.FindRecordsByFilter("expenses", strings.Join(conditions, " && "), "+date", 0, 0, params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find expenses: %w", err)
+	}
+
+	return records, nil
+}
+
+// expenseDate returns the day of an expense in local time.
+func expenseDate(expense *core.Record) time.Time {
+	return startOfLocalDay(expense.GetDateTime("date").Time())
+}
@@ -0,0 +1,345 @@
+// Export Module for PocketBase
+//
+// This module exports the sessions as CSV or JSON file, or in the layout of an export profile or
+// template (see the payroll export and export templates modules). Exports are streamed: the sessions are
+// loaded page by page and written to the response as they are loaded, so exporting a multi-year
+// dataset neither builds the whole file in memory on the server nor requires the client to wait
+// for the complete file. The response is sent with chunked transfer encoding and compressed with
+// gzip if the client supports it.
+package backend
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pocketbase/pocketbase/apis"
+	"github.com/pocketbase/pocketbase/core"
+)
+
+// exportBatchSize is the number of sessions loaded and written at once.
+const exportBatchSize = 500
+
+// exportCSVHeader is the header row of CSV exports.
+var exportCSVHeader = []string{"clock_in_id", "clock_out_id", "start", "end", "duration_seconds", "description", "project_id", "tag_ids", "issue"}
+
+// exportFilter restricts an export to the sessions of a clock and optionally of a project or with certain tags.
+type exportFilter struct {
+	ClockID   string   // Only sessions of this clock, empty for the default clock
+	ProjectID string   // Only sessions of this project, empty for sessions of any project
+	TagIDs    []string // Only sessions with at least one of these tags, empty for sessions with any tags
+}
+
+// exportRequest holds the parsed parameters of an export.
+type exportRequest struct {
+	Format   string         // 'csv', 'json', 'payroll' or 'template'
+	From     time.Time      // Start of the range (inclusive), a zero value means unbounded
+	To       time.Time      // End of the range (exclusive), a zero value means unbounded
+	Filter   exportFilter   // Filter the exported sessions have to match
+	Profile  payrollProfile // Layout of payroll exports
+	Template exportTemplate // Template of template exports
+}
+
+// RegisterExportAPI registers the export endpoint with the PocketBase server.
+// It creates the following route:
+// - GET /api/work_clock/export?format=&from=&to=&month=&clock=&project_id=&tag_ids=&profile_id=&template_id= - Streams
+// the sessions of the clock starting within the optional range (or 'month', e.g. '2025-04') as 'csv' (default), 'json',
+// 'payroll' or 'template' file, optionally only those of a project and those with at least one of the repeated
+// 'tag_ids'. Payroll files are laid out according to the export profile 'profile_id', template files are rendered
+// with the export template 'template_id'.
+//
+// Filters are applied on the server, so e.g. a single client's hours can be exported for invoicing.
+//
+// Parameters:
+// - app: The PocketBase application instance
+func RegisterExportAPI(app core.App) {
+	app.OnServe().BindFunc(func(se *core.ServeEvent) error {
+		se.Router.GET("/api/work_clock/export", func(e *core.RequestEvent) error {
+			request, err := parseExportRequest(app, e)
+			if err != nil {
+				return e.Error(http.StatusBadRequest, err.Error(), nil)
+			}
+
+			header := e.Response.Header()
+			header.Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, request.fileName()))
+			switch {
+			case request.Format == "json":
+				header.Set("Content-Type", "application/json")
+			case request.Format == "template" && request.Template.Extension != "csv":
+				header.Set("Content-Type", "text/plain; charset=utf-8")
+			default:
+				header.Set("Content-Type", "text/csv; charset=utf-8")
+			}
+
+			if err := request.stream(app, e.Response, e.Flush); err != nil {
+				if !e.Written() {
+					return e.Error(http.StatusInternalServerError, fmt.Sprintf("Failed to export sessions: %v", err), err)
+				}
+
+				// Once streaming started, the status code is already sent, so errors can only be logged
+				app.Logger().Error("failed to stream export", "format", request.Format, "error", err)
+			}
+			return nil
+		}).Bind(apis.Gzip())
+
+		return se.Next()
+	})
+}
+
+// parseExportRequest parses and validates the query parameters of an export.
+//
+// Parameters:
+// - app: The App interface (typically a PocketBase instance or transaction)
+// - e: The RequestEvent from the HTTP handler
+//
+// Returns:
+// - The parsed export request
+// - An error describing the invalid parameter
+func parseExportRequest(app core.App, e *core.RequestEvent) (exportRequest, error) {
+	query := e.Request.URL.Query()
+
+	request := exportRequest{
+		Format: query.Get("format"),
+		Filter: exportFilter{ProjectID: query.Get("project_id"), TagIDs: query["tag_ids"]},
+	}
+	if request.Format == "" {
+		request.Format = "csv"
+	}
+	if !slices.Contains([]string{"csv", "json", "payroll", "template"}, request.Format) {
+		return exportRequest{}, fmt.Errorf("invalid 'format' (string) parameter. Expected 'csv', 'json', 'payroll' or 'template'")
+	}
+
+	var err error
+	request.Filter.ClockID, err = findClockID(app, query.Get("clock"))
+	if err != nil {
+		return exportRequest{}, err
+	}
+
+	if month := query.Get("month"); month != "" {
+		monthStart, err := time.ParseInLocation("2006-01", month, time.Local)
+		if err != nil {
+			return exportRequest{}, fmt.Errorf("invalid 'month' (string) parameter. Expected format: YYYY-MM")
+		}
+		request.From, request.To = monthStart, monthStart.AddDate(0, 1, 0)
+	} else {
+		request.From, request.To, err = parseOptionalTimeRangeParams(query.Get("from"), query.Get("to"))
+		if err != nil {
+			return exportRequest{}, err
+		}
+	}
+
+	if err := validateExportFilter(app, request.Filter); err != nil {
+		return exportRequest{}, err
+	}
+
+	if request.Format == "payroll" {
+		request.Profile, err = loadPayrollProfile(app, query.Get("profile_id"))
+		if err != nil {
+			return exportRequest{}, err
+		}
+	}
+	if request.Format == "template" {
+		request.Template, err = loadExportTemplate(app, query.Get("template_id"))
+		if err != nil {
+			return exportRequest{}, err
+		}
+	}
+
+	return request, nil
+}
+
+// fileName returns the name of the exported file.
+func (r exportRequest) fileName() string {
+	extension := r.Format
+	switch r.Format {
+	case "payroll":
+		extension = "csv"
+	case "template":
+		extension = r.Template.Extension
+	}
+
+	if !r.From.IsZero() && r.To.Equal(r.From.AddDate(0, 1, 0)) && r.From.Day() == 1 {
+		return fmt.Sprintf("work_clock_%s.%s", r.From.Format("2006-01"), extension)
+	}
+	return fmt.Sprintf("work_clock_%s.%s", time.Now().Format("2006-01-02"), extension)
+}
+
+// stream writes the exported file to a writer.
+//
+// Parameters:
+// - app: The PocketBase application instance
+// - w: The writer receiving the file, typically the response
+// - flush: Flushes the written data to the client
+//
+// Returns:
+// - An error if loading the sessions or writing the file fails
+func (r exportRequest) stream(app core.App, w io.Writer, flush func() error) error {
+	switch r.Format {
+	case "payroll":
+		return streamPayrollExport(app, w, flush, r.Profile, r.From, r.To, r.Filter)
+	case "template":
+		return streamTemplateExport(app, w, flush, r.Template, r.From, r.To, r.Filter)
+	}
+	return streamSessionsExport(app, w, flush, r.Format, r.From, r.To, r.Filter)
+}
+
+// streamSessionsExport writes the sessions within a range to a writer, flushing after each batch.
+//
+// Parameters:
+// - app: The App interface (typically a PocketBase instance or transaction)
+// - w: The writer receiving the file, typically the response
+// - flush: Flushes the written data to the client
+// - format: The export format ('csv' or 'json')
+// - from: The start of the range (inclusive), a zero value means unbounded
+// - to: The end of the range (exclusive), a zero value means unbounded
+// - filter: The filter the exported sessions have to match
+//
+// Returns:
+// - An error if loading the sessions or writing the file fails
+func streamSessionsExport(app core.App, w io.Writer, flush func() error, format string, from, to time.Time, filter exportFilter) error {
+	csvWriter := csv.NewWriter(w)
+	jsonEncoder := json.NewEncoder(w)
+
+	if format == "csv" {
+		if err := csvWriter.Write(exportCSVHeader); err != nil {
+			return err
+		}
+	} else if _, err := w.Write([]byte("[\n")); err != nil {
+		return err
+	}
+
+	now := clockNow(app)
+	first := true
+	var cursor time.Time
+	for {
+		sessions, _, nextCursor, err := findWorkSessionsPage(app, filter.ClockID, from, to, cursor, exportBatchSize)
+		if err != nil {
+			return err
+		}
+
+		for _, session := range sessions {
+			if !filter.matches(session) {
+				continue
+			}
+
+			entry := newWorkSessionEntry(session, now)
+
+			if format == "csv" {
+				if err := csvWriter.Write(exportCSVRow(entry)); err != nil {
+					return err
+				}
+				continue
+			}
+
+			if !first {
+				if _, err := w.Write([]byte(",")); err != nil {
+					return err
+				}
+			}
+			first = false
+
+			if err := jsonEncoder.Encode(entry); err != nil {
+				return err
+			}
+		}
+
+		csvWriter.Flush()
+		if err := csvWriter.Error(); err != nil {
+			return err
+		}
+		if err := flush(); err != nil {
+			return err
+		}
+
+		if nextCursor.IsZero() {
+			break
+		}
+		cursor = nextCursor
+	}
+
+	if format == "json" {
+		if _, err := w.Write([]byte("]\n")); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// validateExportFilter checks that the project and tags of a filter exist.
+//
+// Parameters:
+// - app: The App interface (typically a PocketBase instance or transaction)
+// - filter: The filter to validate
+//
+// Returns:
+// - An error naming the project or tag that does not exist
+func validateExportFilter(app core.App, filter exportFilter) error {
+	if filter.ProjectID != "" {
+		if _, err := app.FindRecordById("projects", filter.ProjectID); err != nil {
+			return fmt.Errorf("project with id '%s' does not exist", filter.ProjectID)
+		}
+	}
+
+	for _, tagID := range filter.TagIDs {
+		if _, err := app.FindRecordById("tags", tagID); err != nil {
+			return fmt.Errorf("tag with id '%s' does not exist", tagID)
+		}
+	}
+
+	return nil
+}
+
+// matches reports whether a session is part of the filtered export.
+//
+// Parameters:
+// - session: The session to check
+//
+// Returns:
+// - true if the session matches the project and at least one of the tags of the filter
+func (f exportFilter) matches(session workSession) bool {
+	if f.ProjectID != "" && session.ClockIn.GetString("project") != f.ProjectID {
+		return false
+	}
+
+	if len(f.TagIDs) == 0 {
+		return true
+	}
+
+	sessionTagIDs := session.ClockIn.GetStringSlice("tags")
+	return slices.ContainsFunc(f.TagIDs, func(tagID string) bool {
+		return slices.Contains(sessionTagIDs, tagID)
+	})
+}
+
+// exportCSVRow converts a session entry into a CSV row matching exportCSVHeader.
+//
+// Parameters:
+// - entry: The session entry
+//
+// Returns:
+// - The CSV row
+func exportCSVRow(entry WorkSessionEntry) []string {
+	end := ""
+	if entry.End != nil {
+		end = entry.End.Format(time.RFC3339)
+	}
+
+	return []string{
+		entry.ClockInID,
+		entry.ClockOutID,
+		entry.Start.Format(time.RFC3339),
+		end,
+		strconv.FormatInt(entry.DurationSeconds, 10),
+		entry.Description,
+		entry.ProjectID,
+		strings.Join(entry.TagIDs, ";"),
+		entry.Issue,
+	}
+}
@@ -0,0 +1,365 @@
+// Export Templates Module for PocketBase
+//
+// This module renders exports with templates uploaded by superusers, so exotic payroll formats
+// (fixed-width records, XML, files with header and trailer records) can be produced without code
+// changes. A template is a Go text template (see https://pkg.go.dev/text/template) stored in the
+// export_templates collection and rendered with ExportTemplateData: the exported range, the
+// sessions with their project and tag names, the totals per day and the overall totals.
+//
+// Besides the built-in functions of Go templates (e.g. printf), templates can use:
+// - date LAYOUT TIME: Formats a timestamp in local time, e.g. {{.Start | date "02.01.2006"}}
+// - hours FORMAT SEPARATOR SECONDS: Formats a duration as 'decimal', 'hours_minutes' or 'units'
+// - csv DELIMITER FIELDS...: Joins fields into a CSV row, quoting them where necessary
+// - padLeft / padRight WIDTH TEXT: Pads a text with spaces to a fixed width, cutting longer texts
+// - upper / lower TEXT, join SEPARATOR LIST and replace OLD NEW TEXT
+//
+// Templates are checked when they are saved: they have to parse and render sample data, so typos
+// in field names are reported to the uploader instead of failing the next payroll run.
+//
+// Template exports are requested through the export endpoint with format=template and
+// template_id, and can be used by scheduled exports.
+package backend
+
+import (
+	"bytes"
+	"cmp"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strings"
+	"text/template"
+	"time"
+	"unicode/utf8"
+
+	"github.com/pocketbase/pocketbase/core"
+)
+
+// ExportTemplateSession is a session as seen by export templates.
+type ExportTemplateSession struct {
+	WorkSessionEntry
+
+	Project        string   // Name of the project, empty for sessions without (existing) project
+	CostCenter     string   // Cost center code of the project
+	Tags           []string // Names of the tags of the session
+	CountedSeconds int64    // Duration that counts as work time, reduced by the factor of the category
+}
+
+// ExportTemplateDay is the total of the exported sessions of a day.
+type ExportTemplateDay struct {
+	Date            time.Time // Day in local time
+	Sessions        int       // Number of sessions starting on the day
+	DurationSeconds int64     // Duration of the sessions
+	CountedSeconds  int64     // Duration of the sessions that counts as work time
+}
+
+// ExportTemplateData is the data export templates are rendered with.
+type ExportTemplateData struct {
+	From            time.Time               // Start of the exported range, zero if unbounded
+	To              time.Time               // End of the exported range (exclusive), zero if unbounded
+	Generated       time.Time               // Time the export was created
+	Clock           string                  // Name of the exported clock, empty for the default clock
+	Sessions        []ExportTemplateSession // Exported sessions, sorted by their start
+	Days            []ExportTemplateDay     // Totals of the days with sessions, sorted by date
+	DurationSeconds int64                   // Duration of all sessions
+	CountedSeconds  int64                   // Duration of all sessions that counts as work time
+}
+
+// exportTemplate is a parsed template of the export_templates collection.
+type exportTemplate struct {
+	Extension string             // File extension of the rendered file
+	Template  *template.Template // The parsed template
+}
+
+// exportTemplateFuncs are the functions available in export templates.
+var exportTemplateFuncs = template.FuncMap{
+	"date": func(layout string, value any) string {
+		var timestamp time.Time
+		switch v := value.(type) {
+		case time.Time:
+			timestamp = v
+		case *time.Time:
+			if v != nil {
+				timestamp = *v
+			}
+		}
+		if timestamp.IsZero() {
+			return ""
+		}
+		return timestamp.In(time.Local).Format(layout)
+	},
+	"hours": func(format, separator string, seconds int64) string {
+		return formatDuration(time.Duration(seconds)*time.Second, format, separator)
+	},
+	"csv": func(delimiter string, fields ...string) (string, error) {
+		var row strings.Builder
+		writer := csv.NewWriter(&row)
+		writer.Comma, _ = utf8.DecodeRuneInString(delimiter)
+		if err := writer.Write(fields); err != nil {
+			return "", err
+		}
+		writer.Flush()
+		return strings.TrimSuffix(row.String(), "\n"), writer.Error()
+	},
+	"padLeft": func(width int, text string) string {
+		return padText(text, width, true)
+	},
+	"padRight": func(width int, text string) string {
+		return padText(text, width, false)
+	},
+	"upper": strings.ToUpper,
+	"lower": strings.ToLower,
+	"join": func(separator string, items []string) string {
+		return strings.Join(items, separator)
+	},
+	"replace": func(old, replacement, text string) string {
+		return strings.ReplaceAll(text, old, replacement)
+	},
+}
+
+// RegisterExportTemplates registers the hooks checking export templates when they are saved.
+//
+// Parameters:
+// - app: The PocketBase application instance
+func RegisterExportTemplates(app core.App) {
+	app.OnRecordCreate("export_templates").BindFunc(validateExportTemplate)
+	app.OnRecordUpdate("export_templates").BindFunc(validateExportTemplate)
+}
+
+// validateExportTemplate rejects templates that don't parse or fail to render sample data.
+//
+// Parameters:
+// - e: The RecordEvent of the saved template
+//
+// Returns:
+// - An error if the template is invalid or saving fails
+func validateExportTemplate(e *core.RecordEvent) error {
+	parsed, err := parseExportTemplate(e.Record.GetString("template"))
+	if err != nil {
+		return err
+	}
+
+	if err := parsed.Execute(io.Discard, sampleExportTemplateData()); err != nil {
+		return fmt.Errorf("template fails to render sample data: %w", err)
+	}
+	return e.Next()
+}
+
+// parseExportTemplate parses the text of an export template.
+//
+// Parameters:
+// - text: The text of the template
+//
+// Returns:
+// - The parsed template
+// - An error if the template is invalid
+func parseExportTemplate(text string) (*template.Template, error) {
+	parsed, err := template.New("export").Funcs(exportTemplateFuncs).Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("invalid template: %w", err)
+	}
+	return parsed, nil
+}
+
+// sampleExportTemplateData returns data with a single session, which templates are checked with.
+func sampleExportTemplateData() ExportTemplateData {
+	start := time.Date(2025, time.March, 10, 9, 0, 0, 0, time.Local)
+	end := start.Add(8 * time.Hour)
+
+	session := ExportTemplateSession{
+		WorkSessionEntry: WorkSessionEntry{
+			ClockInID:       "sampleclockin00",
+			ClockOutID:      "sampleclockout0",
+			Start:           start,
+			End:             &end,
+			DurationSeconds: 8 * 60 * 60,
+			Duration:        formatResponseDuration(8 * 60 * 60),
+			Description:     "Sample session",
+			TagIDs:          []string{"sampletag000000"},
+		},
+		Project:        "Sample project",
+		CostCenter:     "1000",
+		Tags:           []string{"Sample tag"},
+		CountedSeconds: 8 * 60 * 60,
+	}
+
+	return ExportTemplateData{
+		From:            time.Date(2025, time.March, 1, 0, 0, 0, 0, time.Local),
+		To:              time.Date(2025, time.April, 1, 0, 0, 0, 0, time.Local),
+		Generated:       time.Date(2025, time.April, 1, 6, 0, 0, 0, time.Local),
+		Sessions:        []ExportTemplateSession{session},
+		Days:            []ExportTemplateDay{{Date: startOfLocalDay(start), Sessions: 1, DurationSeconds: 8 * 60 * 60, CountedSeconds: 8 * 60 * 60}},
+		DurationSeconds: 8 * 60 * 60,
+		CountedSeconds:  8 * 60 * 60,
+	}
+}
+
+// loadExportTemplate loads and parses an export template.
+//
+// Parameters:
+// - app: The App interface (typically a PocketBase instance or transaction)
+// - templateID: The ID of the export_templates record
+//
+// Returns:
+// - The parsed template
+// - An error if the template does not exist or is invalid
+func loadExportTemplate(app core.App, templateID string) (exportTemplate, error) {
+	record, err := app.FindRecordById("export_templates", templateID)
+	if err != nil {
+		return exportTemplate{}, fmt.Errorf("export template with id '%s' does not exist", templateID)
+	}
+
+	parsed, err := parseExportTemplate(record.GetString("template"))
+	if err != nil {
+		return exportTemplate{}, fmt.Errorf("export template with id '%s' is invalid: %w", templateID, err)
+	}
+
+	return exportTemplate{Extension: cmp.Or(record.GetString("extension"), "txt"), Template: parsed}, nil
+}
+
+// streamTemplateExport renders the sessions within a range with an export template and writes
+// the result to a writer. The template is rendered completely before it is written, so a failing
+// template doesn't produce a partial file.
+//
+// Parameters:
+// - app: The App interface (typically a PocketBase instance or transaction)
+// - w: The writer receiving the file, typically the response
+// - flush: Flushes the written data to the client
+// - tmpl: The template
+// - from: The start of the range (inclusive), a zero value means unbounded
+// - to: The end of the range (exclusive), a zero value means unbounded
+// - filter: The filter the exported sessions have to match
+//
+// Returns:
+// - An error if loading the sessions, rendering the template or writing the file fails
+func streamTemplateExport(app core.App, w io.Writer, flush func() error, tmpl exportTemplate, from, to time.Time, filter exportFilter) error {
+	data, err := loadExportTemplateData(app, from, to, filter)
+	if err != nil {
+		return err
+	}
+
+	var file bytes.Buffer
+	if err := tmpl.Template.Execute(&file, data); err != nil {
+		return fmt.Errorf("failed to render template: %w", err)
+	}
+
+	if _, err := file.WriteTo(w); err != nil {
+		return err
+	}
+	return flush()
+}
+
+// loadExportTemplateData loads the sessions within a range as data of export templates.
+//
+// Parameters:
+// - app: The App interface (typically a PocketBase instance or transaction)
+// - from: The start of the range (inclusive), a zero value means unbounded
+// - to: The end of the range (exclusive), a zero value means unbounded
+// - filter: The filter the exported sessions have to match
+//
+// Returns:
+// - The data of the template
+// - An error if a query fails
+func loadExportTemplateData(app core.App, from, to time.Time, filter exportFilter) (ExportTemplateData, error) {
+	now := clockNow(app)
+	data := ExportTemplateData{From: from, To: to, Generated: now, Sessions: []ExportTemplateSession{}, Days: []ExportTemplateDay{}}
+
+	if filter.ClockID != "" {
+		clock, err := app.FindRecordById("clocks", filter.ClockID)
+		if err != nil {
+			return ExportTemplateData{}, fmt.Errorf("failed to find clock: %w", err)
+		}
+		data.Clock = clock.GetString("name")
+	}
+
+	tags, err := app.FindAllRecords("tags")
+	if err != nil {
+		return ExportTemplateData{}, fmt.Errorf("failed to find tags: %w", err)
+	}
+	tagNames := map[string]string{}
+	for _, tag := range tags {
+		tagNames[tag.Id] = tag.GetString("name")
+	}
+
+	// Sessions of deleted projects are exported without project
+	projects := map[string]*core.Record{}
+	findProject := func(projectID string) *core.Record {
+		if projectID == "" {
+			return nil
+		}
+		if project, ok := projects[projectID]; ok {
+			return project
+		}
+		project, _ := app.FindRecordById("projects", projectID)
+		projects[projectID] = project
+		return project
+	}
+
+	var cursor time.Time
+	for {
+		sessions, _, nextCursor, err := findWorkSessionsPage(app, filter.ClockID, from, to, cursor, exportBatchSize)
+		if err != nil {
+			return ExportTemplateData{}, err
+		}
+
+		for _, session := range sessions {
+			if !filter.matches(session) {
+				continue
+			}
+
+			entry := ExportTemplateSession{WorkSessionEntry: newWorkSessionEntry(session, now), Tags: []string{}}
+			entry.CountedSeconds = int64(float64(entry.DurationSeconds) * categoryFactor(entry.Category))
+			if project := findProject(entry.ProjectID); project != nil {
+				entry.Project = project.GetString("name")
+				entry.CostCenter = project.GetString("cost_center")
+			}
+			for _, tagID := range entry.TagIDs {
+				if name, ok := tagNames[tagID]; ok {
+					entry.Tags = append(entry.Tags, name)
+				}
+			}
+			data.Sessions = append(data.Sessions, entry)
+
+			// Sessions are sorted by their start, so the sessions of a day follow each other
+			day := startOfLocalDay(entry.Start)
+			if len(data.Days) == 0 || !data.Days[len(data.Days)-1].Date.Equal(day) {
+				data.Days = append(data.Days, ExportTemplateDay{Date: day})
+			}
+			total := &data.Days[len(data.Days)-1]
+			total.Sessions++
+			total.DurationSeconds += entry.DurationSeconds
+			total.CountedSeconds += entry.CountedSeconds
+
+			data.DurationSeconds += entry.DurationSeconds
+			data.CountedSeconds += entry.CountedSeconds
+		}
+
+		if nextCursor.IsZero() {
+			break
+		}
+		cursor = nextCursor
+	}
+
+	return data, nil
+}
+
+// padText pads a text with spaces to a width in characters, cutting longer texts.
+//
+// Parameters:
+// - text: The text
+// - width: The width in characters
+// - left: Whether the spaces are added on the left, aligning the text to the right
+//
+// Returns:
+// - The text with exactly width characters
+func padText(text string, width int, left bool) string {
+	runes := []rune(text)
+	if len(runes) >= width {
+		return string(runes[:max(width, 0)])
+	}
+
+	padding := strings.Repeat(" ", width-len(runes))
+	if left {
+		return padding + text
+	}
+	return text + padding
+}
@@ -0,0 +1,102 @@
+package backend
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/yerTools/simple-frontend-stack/src/backend/backendtest"
+)
+
+func TestExportTemplates(t *testing.T) {
+	app := backendtest.NewApp(t)
+	RegisterExportAPI(app)
+	RegisterExportTemplates(app)
+	handler := backendtest.NewHandler(t, app)
+
+	newRecord := func(collection string, values map[string]any) (*core.Record, error) {
+		c, err := app.FindCollectionByNameOrId(collection)
+		if err != nil {
+			t.Fatalf("failed to find %s collection: %v", collection, err)
+		}
+		record := core.NewRecord(c)
+		record.Load(values)
+		return record, app.Save(record)
+	}
+
+	project, err := newRecord("projects", map[string]any{"name": "Prototype", "cost_center": "4200"})
+	if err != nil {
+		t.Fatalf("failed to save project: %v", err)
+	}
+
+	service := workClockServiceOf(app)
+	for _, start := range []time.Time{
+		time.Date(2025, 3, 10, 8, 0, 0, 0, time.Local),
+		time.Date(2025, 3, 10, 13, 0, 0, 0, time.Local),
+		time.Date(2025, 3, 11, 9, 0, 0, 0, time.Local),
+	} {
+		if err := service.AddClockInOutPair("", start, start.Add(4*time.Hour)); err != nil {
+			t.Fatalf("failed to add session: %v", err)
+		}
+	}
+	sessions, err := findWorkSessions(app, "", time.Time{}, time.Time{})
+	if err != nil || len(sessions) != 3 {
+		t.Fatalf("expected three sessions, got %d: %v", len(sessions), err)
+	}
+	sessions[2].ClockIn.Set("project", project.Id)
+	sessions[2].ClockIn.Set("description", "Review; planning")
+	if err := app.Save(sessions[2].ClockIn); err != nil {
+		t.Fatalf("failed to update session: %v", err)
+	}
+
+	// Templates have to render the sample data
+	if _, err := newRecord("export_templates", map[string]any{"name": "Broken", "template": "{{range .Sessions}}{{.Employee}}{{end}}"}); err == nil {
+		t.Error("expected a template with an unknown field to be rejected")
+	}
+	if _, err := newRecord("export_templates", map[string]any{"name": "Unclosed", "template": "{{range .Sessions}}"}); err == nil {
+		t.Error("expected a template that doesn't parse to be rejected")
+	}
+
+	text := `H{{.From | date "20060102"}}{{.To | date "20060102"}}
+{{range .Days}}D{{.Date | date "20060102"}}{{printf "%05d" .Sessions}}{{hours "decimal" "," .CountedSeconds | padLeft 8}}
+{{end}}{{range .Sessions}}{{csv ";" (.Start | date "02.01.2006 15:04") (.End | date "15:04") (.Project | upper | padRight 6) .CostCenter .Description}}
+{{end}}T{{hours "hours_minutes" "" .DurationSeconds}}
+`
+	exportTemplate, err := newRecord("export_templates", map[string]any{"name": "Fixed width", "template": text, "extension": "dat"})
+	if err != nil {
+		t.Fatalf("failed to save export template: %v", err)
+	}
+
+	request := httptest.NewRequest(http.MethodGet, "/api/work_clock/export?format=template&month=2025-03&template_id="+exportTemplate.Id, nil)
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, request)
+	body, _ := io.ReadAll(recorder.Body)
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", recorder.Code, body)
+	}
+
+	expected := "H2025030120250401\n" +
+		"D2025031000002    8,00\n" +
+		"D2025031100001    4,00\n" +
+		"10.03.2025 08:00;12:00;\"      \";;\n" +
+		"10.03.2025 13:00;17:00;\"      \";;\n" +
+		"11.03.2025 09:00;13:00;PROTOT;4200;\"Review; planning\"\n" +
+		"T12:00\n"
+	if string(body) != expected {
+		t.Errorf("unexpected rendered export:\n%s\nexpected:\n%s", body, expected)
+	}
+	if disposition := recorder.Header().Get("Content-Disposition"); !strings.Contains(disposition, "work_clock_2025-03.dat") {
+		t.Errorf("expected the extension of the template in the file name, got %q", disposition)
+	}
+
+	request = httptest.NewRequest(http.MethodGet, "/api/work_clock/export?format=template&template_id=missing", nil)
+	recorder = httptest.NewRecorder()
+	handler.ServeHTTP(recorder, request)
+	if recorder.Code != http.StatusBadRequest {
+		t.Errorf("expected status 400 for a missing template, got %d", recorder.Code)
+	}
+}
@@ -0,0 +1,75 @@
+// Features Module for PocketBase
+//
+// This module tells clients which optional subsystems are available on this instance, so the
+// frontend can hide what is not configured instead of probing endpoints for errors. Only whether
+// a subsystem is enabled is exposed, never its configuration, so the endpoint is public.
+//
+// The following features are listed:
+// - 'calendar_import': Meetings can be imported from the configured calendar
+// - 'email_gateway': Clock commands can be sent by email (EMAIL_GATEWAY_SECRET)
+// - 'issue_validation': Issue references are validated (WORK_CLOCK_VALIDATE_ISSUES)
+// - 'badge_token': The status badge requires a token (BADGE_TOKEN)
+// - 'feed_token': The daily summary feed requires a token (FEED_TOKEN)
+// - 'tracing': Requests are traced (OTEL_EXPORTER_OTLP_ENDPOINT)
+// - 'compliance', 'expenses', 'signed_exports': Always available, listed for clients that also
+// talk to older instances
+//
+// Besides, the enabled state of every integration is listed.
+package backend
+
+import (
+	"net/http"
+
+	"github.com/pocketbase/pocketbase/core"
+)
+
+// FeaturesResponse lists the optional subsystems of the instance.
+type FeaturesResponse struct {
+	Features     map[string]bool `json:"features"`     // Whether each feature is available, by feature name
+	Integrations map[string]bool `json:"integrations"` // Whether each integration is enabled, by integration name
+}
+
+// RegisterFeaturesAPI registers the features endpoint with the PocketBase server.
+// It creates the following route:
+// - GET /api/features - Returns the FeaturesResponse
+//
+// Parameters:
+// - app: The PocketBase application instance
+func RegisterFeaturesAPI(app core.App) {
+	app.OnServe().BindFunc(func(se *core.ServeEvent) error {
+		se.Router.GET("/api/features", func(e *core.RequestEvent) error {
+			return e.JSON(http.StatusOK, getFeatures(app))
+		})
+
+		return se.Next()
+	})
+}
+
+// getFeatures determines the optional subsystems of the instance.
+//
+// Parameters:
+// - app: The App interface used to load the integration configurations
+//
+// Returns:
+// - The features and integrations with whether they are available
+func getFeatures(app core.App) FeaturesResponse {
+	integrations := map[string]bool{}
+	for name, state := range loadIntegrationStates(app) {
+		integrations[name] = state.Enabled
+	}
+
+	return FeaturesResponse{
+		Features: map[string]bool{
+			"calendar_import":  integrations["calendar"],
+			"email_gateway":    settings.EmailGatewaySecret != "",
+			"issue_validation": settings.ValidateIssues,
+			"badge_token":      settings.BadgeToken != "",
+			"feed_token":       settings.FeedToken != "",
+			"tracing":          settings.TracingEndpoint != "",
+			"compliance":       true,
+			"expenses":         true,
+			"signed_exports":   true,
+		},
+		Integrations: integrations,
+	}
+}
@@ -0,0 +1,31 @@
+package backend
+
+import (
+	"testing"
+
+	"github.com/yerTools/simple-frontend-stack/src/backend/backendtest"
+)
+
+func TestGetFeatures(t *testing.T) {
+	app := backendtest.NewApp(t)
+	invalidateIntegrationStates()
+	t.Cleanup(invalidateIntegrationStates)
+
+	features := getFeatures(app)
+	if features.Integrations["calendar"] || features.Features["calendar_import"] {
+		t.Errorf("expected the calendar to be disabled, got %+v", features)
+	}
+	if len(features.Integrations) != len(integrationDefinitions) {
+		t.Errorf("expected all integrations to be listed, got %v", features.Integrations)
+	}
+
+	if err := saveIntegration(app, "calendar", true, CalendarConfig{ICSURL: "https://calendar.example.com/work.ics"}); err != nil {
+		t.Fatalf("failed to save integration: %v", err)
+	}
+	invalidateIntegrationStates()
+
+	features = getFeatures(app)
+	if !features.Integrations["calendar"] || !features.Features["calendar_import"] {
+		t.Errorf("expected the calendar to be enabled, got %+v", features)
+	}
+}
@@ -0,0 +1,211 @@
+// Forecast Module for PocketBase
+//
+// This module projects the flextime balance at the end of the current month, so users can plan
+// whether they can leave early on a Friday or need to catch up. The projection starts from the
+// balance of the last summarized day before today (see the daily summary module) and assumes
+// that each remaining workday is worked like the recent average, while its target is the one of
+// the work schedule valid on it (see the work schedules module). Planned absences are passed as
+// dates or stored as holidays and absences (see the absences module); they have no target and no
+// worked time, just like the days outside of the employment (see the employment periods module).
+// Partial absences reduce the target and the projected worked time of their day proportionally.
+package backend
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/core"
+)
+
+// forecastRecentDays is the number of days before today the average daily worked time is taken from.
+const forecastRecentDays = 28
+
+// BalanceForecast is the response of the forecast endpoint.
+type BalanceForecast struct {
+	Month                   string `json:"month"`                     // Forecasted month (YYYY-MM)
+	BalanceSeconds          int64  `json:"balance_seconds"`           // Balance at the end of yesterday, counting the workdays not summarized yet
+	Balance                 string `json:"balance"`                   // Balance formatted in the configured duration format
+	RemainingWorkdays       int    `json:"remaining_workdays"`        // Workdays from today to the end of the month, without planned absences
+	PlannedAbsences         int    `json:"planned_absences"`          // Planned absences on the remaining workdays
+	AverageDailySeconds     int64  `json:"average_daily_seconds"`     // Average worked time of the recently worked workdays
+	AverageDaily            string `json:"average_daily"`             // Average worked time formatted in the configured duration format
+	TargetDailySeconds      int64  `json:"target_daily_seconds"`      // Target of a workday of the schedule valid today
+	ProjectedBalanceSeconds int64  `json:"projected_balance_seconds"` // Balance projected for the end of the month
+	ProjectedBalance        string `json:"projected_balance"`         // Projected balance formatted in the configured duration format
+}
+
+// RegisterForecastAPI registers the forecast endpoint with the PocketBase server.
+// It creates the following route:
+// - GET /api/work_clock/report/forecast?absences=&clock= - Projects the balance at the end of the current month, 'absences' is a comma separated list of planned absence dates (YYYY-MM-DD)
+//
+// Parameters:
+// - app: The PocketBase application instance
+func RegisterForecastAPI(app core.App) {
+	app.OnServe().BindFunc(func(se *core.ServeEvent) error {
+		se.Router.GET("/api/work_clock/report/forecast", func(e *core.RequestEvent) error {
+			absences, err := parseAbsencesParam(e.Request.URL.Query().Get("absences"))
+			if err != nil {
+				return e.Error(http.StatusBadRequest, err.Error(), nil)
+			}
+
+			clockID, err := requestClock(app, e)
+			if err != nil {
+				return err
+			}
+
+			forecast, err := getBalanceForecast(app, clockID, absences, clockNow(app))
+			if err != nil {
+				return e.Error(http.StatusInternalServerError, fmt.Sprintf("Failed to forecast balance: %v", err), err)
+			}
+
+			return respondConditionalJSON(e, forecast, workClockLastModified(app))
+		})
+
+		return se.Next()
+	})
+}
+
+// parseAbsencesParam parses the planned absences of a forecast request.
+//
+// Parameters:
+// - value: A comma separated list of dates (YYYY-MM-DD), may be empty
+//
+// Returns:
+// - The planned absence days (local midnight)
+// - An error if a date is invalid
+func parseAbsencesParam(value string) ([]time.Time, error) {
+	var absences []time.Time
+	for _, date := range strings.Split(value, ",") {
+		date = strings.TrimSpace(date)
+		if date == "" {
+			continue
+		}
+
+		day, err := time.ParseInLocation(time.DateOnly, date, time.Local)
+		if err != nil {
+			return nil, fmt.Errorf("invalid absence date '%s'. Expected YYYY-MM-DD", date)
+		}
+		absences = append(absences, day)
+	}
+
+	return absences, nil
+}
+
+// getBalanceForecast projects the balance at the end of the current month.
+//
+// Parameters:
+// - app: The App interface (typically a PocketBase instance or transaction)
+// - clockID: The ID of the clock, an empty string for the default clock
+// - absences: The planned absence days
+// - now: The current time
+//
+// Returns:
+// - The forecast
+// - An error if the daily summaries, work schedules, employment or rule periods could not be retrieved
+func getBalanceForecast(app core.App, clockID string, absences []time.Time, now time.Time) (*BalanceForecast, error) {
+	today := startOfLocalDay(now)
+	monthEnd := time.Date(today.Year(), today.Month(), 1, 0, 0, 0, 0, time.Local).AddDate(0, 1, 0)
+
+	schedules, err := findWorkSchedules(app, clockID)
+	if err != nil {
+		return nil, err
+	}
+	employment, err := findEmploymentPeriods(app, clockID)
+	if err != nil {
+		return nil, err
+	}
+	rules, err := findRulePeriods(app, clockID)
+	if err != nil {
+		return nil, err
+	}
+
+	forecast := &BalanceForecast{Month: today.Format("2006-01"), TargetDailySeconds: int64(schedules.on(today).Workday.Seconds())}
+
+	previous, err := app.FindRecordsByFilter("daily_summary", "clock = {:clock} && date < {:today}", "-date", 1, 0, dbx.Params{
+		"clock": clockParam(clockID),
+		"today": dateTimeParam(today),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to find previous daily summary: %w", err)
+	}
+	if len(previous) > 0 {
+		forecast.BalanceSeconds = int64(previous[0].GetInt("balance_seconds"))
+
+		// Workdays after the last summary are missing their target, as they will once they are summarized
+		gapStart := startOfLocalDay(previous[0].GetDateTime("date").Time()).AddDate(0, 0, 1)
+		off, err := findDaysOff(app, clockID, gapStart, today)
+		if err != nil {
+			return nil, err
+		}
+		for day := gapStart; day.Before(today); day = day.AddDate(0, 0, 1) {
+			if employment.includes(day) && !isPlannedAbsence(day, absences) {
+				forecast.BalanceSeconds -= int64(off.target(schedules, day).Seconds())
+			}
+		}
+	}
+
+	recent, err := findDailySummaries(app, clockID, today.AddDate(0, 0, -forecastRecentDays), today)
+	if err != nil {
+		return nil, err
+	}
+
+	// Only worked workdays are averaged, so absences in the recent days don't lower the average
+	var worked int64
+	workedDays := 0
+	for _, summary := range recent {
+		if summary.TargetSeconds > 0 && summary.Sessions > 0 {
+			worked += summary.WorkedSeconds
+			workedDays++
+		}
+	}
+	forecast.AverageDailySeconds = forecast.TargetDailySeconds
+	if workedDays > 0 {
+		forecast.AverageDailySeconds = worked / int64(workedDays)
+	}
+
+	off, err := findDaysOff(app, clockID, today, monthEnd)
+	if err != nil {
+		return nil, err
+	}
+
+	forecast.ProjectedBalanceSeconds = forecast.BalanceSeconds
+	for day := today; day.Before(monthEnd); day = day.AddDate(0, 0, 1) {
+		scheduled := int64(schedules.target(day).Seconds())
+		if scheduled == 0 || !employment.includes(day) {
+			continue
+		}
+		target := int64(off.target(schedules, day).Seconds())
+		if target == 0 || isPlannedAbsence(day, absences) {
+			forecast.PlannedAbsences++
+			continue
+		}
+		forecast.RemainingWorkdays++
+
+		// On partial absences, only the part of the average day that is not covered is worked
+		worked := forecast.AverageDailySeconds * target / scheduled
+		overtime := rules.on(day).overtime(time.Duration(worked-target) * time.Second)
+		forecast.ProjectedBalanceSeconds += int64(overtime.Seconds())
+	}
+
+	forecast.Balance = formatResponseDuration(forecast.BalanceSeconds)
+	forecast.AverageDaily = formatResponseDuration(forecast.AverageDailySeconds)
+	forecast.ProjectedBalance = formatResponseDuration(forecast.ProjectedBalanceSeconds)
+
+	return forecast, nil
+}
+
+// isPlannedAbsence reports whether a day is one of the planned absences.
+//
+// Parameters:
+// - day: The local midnight of the day
+// - absences: The planned absence days
+//
+// Returns:
+// - True if the day is a planned absence
+func isPlannedAbsence(day time.Time, absences []time.Time) bool {
+	return slices.ContainsFunc(absences, day.Equal)
+}
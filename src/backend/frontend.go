@@ -0,0 +1,176 @@
+// Frontend Module for PocketBase
+//
+// This module serves the built frontend from the binary, so a single binary is a complete
+// deployment, e.g. on a Raspberry Pi without a separate web server. Unknown paths fall back to
+// index.html for the client side routing of the single page application.
+//
+// Vite names the files in assets/ after a hash of their content, so they are cached by browsers
+// for a year, while all other files (such as index.html, which references the current assets) are
+// revalidated on every request. Files that were precompressed with Brotli during the build are sent
+// compressed to clients accepting it.
+//
+// The versions of the running backend and of the served frontend are available at /api/version,
+// so clients can detect an update and reload. The backend version is set at build time with
+// -ldflags "-X github.com/yerTools/simple-frontend-stack/src/backend.Version=<version>".
+package backend
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"io/fs"
+	"net/http"
+	"path"
+	"runtime"
+	"runtime/debug"
+	"strings"
+
+	"github.com/pocketbase/pocketbase"
+	"github.com/pocketbase/pocketbase/apis"
+	"github.com/pocketbase/pocketbase/core"
+)
+
+// Version is the version of the backend, set at build time.
+var Version = "dev"
+
+// frontendAssetsDir is the directory of the content hashed frontend files.
+const frontendAssetsDir = "assets/"
+
+// VersionInfo contains the versions of the running backend and the served frontend.
+type VersionInfo struct {
+	Backend  BackendVersion  `json:"backend"`
+	Frontend FrontendVersion `json:"frontend"`
+}
+
+// BackendVersion describes the build of the running backend.
+type BackendVersion struct {
+	Version    string `json:"version"`    // Version set at build time, "dev" for local builds
+	Revision   string `json:"revision"`   // VCS revision the binary was built from, empty if unknown
+	BuildTime  string `json:"build_time"` // Time of the built revision, empty if unknown
+	Modified   bool   `json:"modified"`   // Whether the working tree had uncommitted changes
+	GoVersion  string `json:"go_version"` // Go version the binary was built with
+	Platform   string `json:"platform"`   // Operating system and architecture, e.g. "linux/arm64"
+	PocketBase string `json:"pocketbase"` // Version of PocketBase
+}
+
+// FrontendVersion describes the served frontend build.
+type FrontendVersion struct {
+	Version string `json:"version"` // Hash of index.html, changes with every build, empty if no frontend is served
+}
+
+// RegisterFrontend registers the frontend and the version endpoint with the PocketBase server.
+// It creates the following routes:
+// - GET /api/version - Returns the VersionInfo
+// - GET /{path...} - Serves the frontend files
+//
+// Parameters:
+// - app: The PocketBase application instance
+// - frontend: The file system of the built frontend
+func RegisterFrontend(app core.App, frontend fs.FS) {
+	app.OnServe().BindFunc(func(se *core.ServeEvent) error {
+		static := apis.Static(frontend, true)
+
+		se.Router.GET("/api/version", func(e *core.RequestEvent) error {
+			return e.JSON(http.StatusOK, VersionInfo{Backend: getBackendVersion(), Frontend: getFrontendVersion(frontend)})
+		})
+
+		se.Router.GET("/{path...}", func(e *core.RequestEvent) error {
+			name := strings.TrimPrefix(path.Clean("/"+e.Request.PathValue(apis.StaticWildcardParam)), "/")
+			// Missing assets fall back to index.html, which must not be cached
+			if _, err := fs.Stat(frontend, name); err == nil && strings.HasPrefix(name, frontendAssetsDir) {
+				e.Response.Header().Set("Cache-Control", "public, max-age=31536000, immutable")
+			} else {
+				e.Response.Header().Set("Cache-Control", "no-cache")
+			}
+
+			if served, err := serveBrotliFile(e, frontend, name); served || err != nil {
+				return err
+			}
+			return static(e)
+		})
+
+		return se.Next()
+	})
+}
+
+// serveBrotliFile sends the Brotli compressed variant of a file, if the client accepts it and it exists.
+//
+// Parameters:
+// - e: The RequestEvent from the HTTP handler
+// - frontend: The file system of the built frontend
+// - name: The cleaned path of the requested file
+//
+// Returns:
+// - Whether the compressed file was sent
+// - An error if sending the file failed
+func serveBrotliFile(e *core.RequestEvent, frontend fs.FS, name string) (bool, error) {
+	if name == "" || !strings.Contains(e.Request.Header.Get("Accept-Encoding"), "br") {
+		return false, nil
+	}
+
+	file, err := frontend.Open(name + ".br")
+	if err != nil {
+		return false, nil
+	}
+	defer file.Close()
+
+	content, ok := file.(io.ReadSeeker)
+	if !ok {
+		return false, nil
+	}
+	info, err := file.Stat()
+	if err != nil || info.IsDir() {
+		return false, nil
+	}
+
+	e.Response.Header().Add("Vary", "Accept-Encoding")
+	e.Response.Header().Set("Content-Encoding", "br")
+	http.ServeContent(e.Response, e.Request, name, info.ModTime(), content)
+	return true, nil
+}
+
+// getBackendVersion reads the version of the running backend from the build information.
+//
+// Returns:
+// - The version of the backend
+func getBackendVersion() BackendVersion {
+	version := BackendVersion{
+		Version:    Version,
+		GoVersion:  runtime.Version(),
+		Platform:   runtime.GOOS + "/" + runtime.GOARCH,
+		PocketBase: pocketbase.Version,
+	}
+
+	if info, ok := debug.ReadBuildInfo(); ok {
+		for _, setting := range info.Settings {
+			switch setting.Key {
+			case "vcs.revision":
+				version.Revision = setting.Value
+			case "vcs.time":
+				version.BuildTime = setting.Value
+			case "vcs.modified":
+				version.Modified = setting.Value == "true"
+			}
+		}
+	}
+
+	return version
+}
+
+// getFrontendVersion identifies the served frontend build by the hash of its index.html, which
+// references the content hashed assets of the build.
+//
+// Parameters:
+// - frontend: The file system of the built frontend
+//
+// Returns:
+// - The version of the frontend, with an empty version if there is no index.html
+func getFrontendVersion(frontend fs.FS) FrontendVersion {
+	index, err := fs.ReadFile(frontend, "index.html")
+	if err != nil {
+		return FrontendVersion{}
+	}
+
+	hash := sha256.Sum256(index)
+	return FrontendVersion{Version: hex.EncodeToString(hash[:6])}
+}
@@ -0,0 +1,54 @@
+package backend
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"testing/fstest"
+
+	"github.com/pocketbase/pocketbase/core"
+)
+
+func TestServeBrotliFile(t *testing.T) {
+	frontend := fstest.MapFS{
+		"index.html":              {Data: []byte("<html></html>")},
+		"assets/index-1a2b.js":    {Data: []byte("console.log(1)")},
+		"assets/index-1a2b.js.br": {Data: []byte("compressed")},
+	}
+
+	serve := func(acceptEncoding string, name string) (*httptest.ResponseRecorder, bool) {
+		e := &core.RequestEvent{}
+		e.Request = httptest.NewRequest(http.MethodGet, "/"+name, nil)
+		e.Request.Header.Set("Accept-Encoding", acceptEncoding)
+		recorder := httptest.NewRecorder()
+		e.Response = recorder
+
+		served, err := serveBrotliFile(e, frontend, name)
+		if err != nil {
+			t.Fatalf("failed to serve %s: %v", name, err)
+		}
+		return recorder, served
+	}
+
+	recorder, served := serve("gzip, br", "assets/index-1a2b.js")
+	if !served || recorder.Header().Get("Content-Encoding") != "br" || recorder.Body.String() != "compressed" {
+		t.Errorf("expected the compressed asset, got %v %v %q", served, recorder.Header(), recorder.Body.String())
+	}
+	if contentType := recorder.Header().Get("Content-Type"); contentType != "text/javascript; charset=utf-8" {
+		t.Errorf("expected the content type of the uncompressed asset, got %s", contentType)
+	}
+
+	if _, served := serve("gzip", "assets/index-1a2b.js"); served {
+		t.Error("expected no compressed asset for clients not accepting Brotli")
+	}
+	if _, served := serve("br", "index.html"); served {
+		t.Error("expected no compressed file without precompressed variant")
+	}
+
+	if version := getFrontendVersion(frontend); len(version.Version) != 12 {
+		t.Errorf("expected a frontend version, got %q", version.Version)
+	}
+	if version := getFrontendVersion(fstest.MapFS{}); version.Version != "" {
+		t.Errorf("expected no frontend version without index.html, got %q", version.Version)
+	}
+}
@@ -0,0 +1,258 @@
+// Grafana Module for PocketBase
+//
+// This module exposes the daily summaries as time series for Grafana, so existing dashboards can
+// chart the work data without an exporter in between. The endpoints follow the protocol of the
+// JSON datasource (https://grafana.com/grafana/plugins/simpod-json-datasource/), whose URL is set
+// to "<server>/api/grafana". For the Infinity datasource, the series endpoint returns one row per
+// day that can be used as plain JSON source.
+//
+// The following metrics are available, in hours per day:
+// - 'worked_hours': Time that counts as work time
+// - 'overtime_hours': Worked minus target time, negative for undertime
+// - 'balance_hours': Overtime accumulated up to and including the day
+//
+// Each data point is placed at the local midnight of its day. The clock of a target can be
+// selected with the payload {"clock": "<name>"}, the default clock is used otherwise.
+package backend
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"time"
+
+	"github.com/pocketbase/pocketbase/core"
+)
+
+// grafanaMetrics are the available metrics with the value of a daily summary they chart.
+var grafanaMetrics = map[string]func(summary DailySummaryEntry) int64{
+	"worked_hours":   func(summary DailySummaryEntry) int64 { return summary.WorkedSeconds },
+	"overtime_hours": func(summary DailySummaryEntry) int64 { return summary.OvertimeSeconds },
+	"balance_hours":  func(summary DailySummaryEntry) int64 { return summary.BalanceSeconds },
+}
+
+// grafanaMetricNames are the names of the available metrics in the order they are listed.
+var grafanaMetricNames = []string{"worked_hours", "overtime_hours", "balance_hours"}
+
+// grafanaQueryRequest is the JSON body of the query endpoint.
+type grafanaQueryRequest struct {
+	Range struct {
+		From time.Time `json:"from"` // Start of the queried range
+		To   time.Time `json:"to"`   // End of the queried range
+	} `json:"range"`
+	Targets []struct {
+		Target  string `json:"target"` // Name of the metric
+		RefID   string `json:"refId"`  // ID of the query in the panel
+		Payload struct {
+			Clock string `json:"clock"` // Name of the clock, empty for the default clock
+		} `json:"payload"`
+	} `json:"targets"`
+}
+
+// GrafanaTimeSeries is a time series of the query endpoint.
+type GrafanaTimeSeries struct {
+	Target     string       `json:"target"`     // Name of the metric
+	RefID      string       `json:"refId"`      // ID of the query in the panel
+	Datapoints [][2]float64 `json:"datapoints"` // Pairs of the value and the Unix timestamp in milliseconds
+}
+
+// GrafanaSeriesRow is a day of the series endpoint.
+type GrafanaSeriesRow struct {
+	Time          time.Time `json:"time"`           // Local midnight of the day
+	Date          string    `json:"date"`           // Day (YYYY-MM-DD)
+	WorkedHours   float64   `json:"worked_hours"`   // Time that counts as work time
+	OvertimeHours float64   `json:"overtime_hours"` // Worked minus target time, negative for undertime
+	BalanceHours  float64   `json:"balance_hours"`  // Overtime accumulated up to and including the day
+}
+
+// grafanaMetricOption is a metric listed by the metrics endpoint.
+type grafanaMetricOption struct {
+	Label string `json:"label"` // Displayed name of the metric
+	Value string `json:"value"` // Name of the metric
+}
+
+// RegisterGrafanaAPI registers the Grafana datasource endpoints with the PocketBase server.
+// It creates the following routes:
+// - GET /api/grafana - Health check of the datasource
+// - POST /api/grafana/search - Lists the metric names
+// - POST /api/grafana/metrics - Lists the metrics as options
+// - POST /api/grafana/query - Returns the time series of the requested metrics and range
+// - GET /api/grafana/series?from=&to=&clock= - Returns all metrics per day of the range, for the Infinity datasource
+//
+// Parameters:
+// - app: The PocketBase application instance
+func RegisterGrafanaAPI(app core.App) {
+	app.OnServe().BindFunc(func(se *core.ServeEvent) error {
+		group := se.Router.Group("/api/grafana")
+
+		group.GET("", func(e *core.RequestEvent) error {
+			return callSucceeded(e)
+		})
+
+		group.POST("/search", func(e *core.RequestEvent) error {
+			return e.JSON(http.StatusOK, grafanaMetricNames)
+		})
+
+		group.POST("/metrics", func(e *core.RequestEvent) error {
+			options := make([]grafanaMetricOption, 0, len(grafanaMetricNames))
+			for _, name := range grafanaMetricNames {
+				options = append(options, grafanaMetricOption{Label: name, Value: name})
+			}
+			return e.JSON(http.StatusOK, options)
+		})
+
+		group.POST("/query", func(e *core.RequestEvent) error {
+			var request grafanaQueryRequest
+			if err := e.BindBody(&request); err != nil {
+				return e.Error(http.StatusBadRequest, "Invalid request body", err)
+			}
+			if !request.Range.From.Before(request.Range.To) {
+				return e.Error(http.StatusBadRequest, "'to' must be after 'from'", nil)
+			}
+
+			series := make([]GrafanaTimeSeries, 0, len(request.Targets))
+			for _, target := range request.Targets {
+				if _, ok := grafanaMetrics[target.Target]; !ok {
+					return e.Error(http.StatusBadRequest, fmt.Sprintf("Unknown metric '%s'", target.Target), nil)
+				}
+
+				clockID, err := findClockID(app, target.Payload.Clock)
+				if err != nil {
+					return e.Error(http.StatusBadRequest, err.Error(), nil)
+				}
+				if clockID != "" {
+					if err := checkClockReadAccess(app, e, clockID, clockNow(app)); err != nil {
+						return err
+					}
+				}
+
+				datapoints, err := getGrafanaDatapoints(app, clockID, target.Target, request.Range.From, request.Range.To)
+				if err != nil {
+					return e.Error(http.StatusInternalServerError, fmt.Sprintf("Failed to query metric: %v", err), err)
+				}
+				series = append(series, GrafanaTimeSeries{Target: target.Target, RefID: target.RefID, Datapoints: datapoints})
+			}
+
+			return e.JSON(http.StatusOK, series)
+		})
+
+		group.GET("/series", func(e *core.RequestEvent) error {
+			from, to, err := parseTimeRangeParams(e.Request.URL.Query().Get("from"), e.Request.URL.Query().Get("to"))
+			if err != nil {
+				return e.Error(http.StatusBadRequest, err.Error(), nil)
+			}
+
+			clockID, err := requestClock(app, e)
+			if err != nil {
+				return err
+			}
+			if clockID != "" {
+				if err := checkClockReadAccess(app, e, clockID, clockNow(app)); err != nil {
+					return err
+				}
+			}
+
+			rows, err := getGrafanaSeries(app, clockID, from, to)
+			if err != nil {
+				return e.Error(http.StatusInternalServerError, fmt.Sprintf("Failed to find daily summaries: %v", err), err)
+			}
+
+			return respondConditionalJSON(e, rows, workClockLastModified(app))
+		})
+
+		return se.Next()
+	})
+}
+
+// findGrafanaSummaries finds the daily summaries of the days overlapping a range.
+//
+// Parameters:
+// - app: The App interface (typically a PocketBase instance or transaction)
+// - clockID: The ID of the clock, an empty string for the default clock
+// - from: The start of the range (inclusive)
+// - to: The end of the range (exclusive)
+//
+// Returns:
+// - The summaries sorted by their date
+// - The local midnight of the day of each summary
+// - An error if the summaries could not be retrieved
+func findGrafanaSummaries(app core.App, clockID string, from, to time.Time) ([]DailySummaryEntry, []time.Time, error) {
+	summaries, err := findDailySummaries(app, clockID, startOfLocalDay(from), startOfLocalDay(to.Add(-time.Nanosecond)).AddDate(0, 0, 1))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	days := make([]time.Time, 0, len(summaries))
+	for _, summary := range summaries {
+		day, err := time.ParseInLocation(time.DateOnly, summary.Date, time.Local)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid date of daily summary '%s': %w", summary.Date, err)
+		}
+		days = append(days, day)
+	}
+
+	return summaries, days, nil
+}
+
+// getGrafanaDatapoints creates the data points of a metric.
+//
+// Parameters:
+// - app: The App interface (typically a PocketBase instance or transaction)
+// - clockID: The ID of the clock, an empty string for the default clock
+// - metric: The name of the metric, see grafanaMetrics
+// - from: The start of the range (inclusive)
+// - to: The end of the range (exclusive)
+//
+// Returns:
+// - Pairs of the value in hours and the Unix timestamp in milliseconds, oldest day first
+// - An error if the summaries could not be retrieved
+func getGrafanaDatapoints(app core.App, clockID string, metric string, from, to time.Time) ([][2]float64, error) {
+	summaries, days, err := findGrafanaSummaries(app, clockID, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	value := grafanaMetrics[metric]
+	datapoints := make([][2]float64, 0, len(summaries))
+	for i, summary := range summaries {
+		datapoints = append(datapoints, [2]float64{secondsToHours(value(summary)), float64(days[i].UnixMilli())})
+	}
+
+	return datapoints, nil
+}
+
+// getGrafanaSeries creates the rows of the series endpoint.
+//
+// Parameters:
+// - app: The App interface (typically a PocketBase instance or transaction)
+// - clockID: The ID of the clock, an empty string for the default clock
+// - from: The start of the range (inclusive)
+// - to: The end of the range (exclusive)
+//
+// Returns:
+// - A row per day with a summary, oldest day first
+// - An error if the summaries could not be retrieved
+func getGrafanaSeries(app core.App, clockID string, from, to time.Time) ([]GrafanaSeriesRow, error) {
+	summaries, days, err := findGrafanaSummaries(app, clockID, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	rows := make([]GrafanaSeriesRow, 0, len(summaries))
+	for i, summary := range summaries {
+		rows = append(rows, GrafanaSeriesRow{
+			Time:          days[i],
+			Date:          summary.Date,
+			WorkedHours:   secondsToHours(summary.WorkedSeconds),
+			OvertimeHours: secondsToHours(summary.OvertimeSeconds),
+			BalanceHours:  secondsToHours(summary.BalanceSeconds),
+		})
+	}
+
+	return rows, nil
+}
+
+// secondsToHours converts seconds into hours, rounded to two decimals.
+func secondsToHours(seconds int64) float64 {
+	return math.Round(float64(seconds)/36) / 100
+}
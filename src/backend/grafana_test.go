@@ -0,0 +1,36 @@
+package backend
+
+import (
+	"testing"
+	"time"
+
+	"github.com/yerTools/simple-frontend-stack/src/backend/backendtest"
+)
+
+func TestGrafanaDatapointsPerDay(t *testing.T) {
+	app := backendtest.NewApp(t)
+
+	day, _ := time.ParseInLocation(time.DateOnly, "2025-04-01", time.Local)
+	if err := workClockServiceOf(app).AddClockInOutPair("", day.Add(9*time.Hour), day.Add(16*time.Hour+30*time.Minute)); err != nil {
+		t.Fatalf("failed to add session: %v", err)
+	}
+	if err := updateDailySummary(app, "", day); err != nil {
+		t.Fatalf("failed to update daily summary: %v", err)
+	}
+
+	datapoints, err := getGrafanaDatapoints(app, "", "worked_hours", day.Add(12*time.Hour), day.AddDate(0, 0, 7))
+	if err != nil {
+		t.Fatalf("failed to get datapoints: %v", err)
+	}
+	if len(datapoints) != 1 || datapoints[0][0] != 7.5 || datapoints[0][1] != float64(day.UnixMilli()) {
+		t.Errorf("expected 7.5 hours at %d, got %v", day.UnixMilli(), datapoints)
+	}
+
+	datapoints, err = getGrafanaDatapoints(app, "", "worked_hours", day.AddDate(0, 0, -1), day)
+	if err != nil {
+		t.Fatalf("failed to get datapoints: %v", err)
+	}
+	if len(datapoints) != 0 {
+		t.Errorf("expected no datapoints before the day, got %v", datapoints)
+	}
+}
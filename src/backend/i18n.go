@@ -0,0 +1,191 @@
+// I18n Module for PocketBase
+//
+// This module localizes the user-facing strings of the backend. The messages are written in
+// English throughout the code; the bundles of the other languages map the English message
+// formats to their translations, e.g. "Failed to clock in: %s" to "Einstempeln fehlgeschlagen: %s".
+// Formatted messages are matched against the formats, and the formatted values are translated
+// as well, so wrapped errors are localized down to the innermost known message. Messages without
+// a translation are returned in English.
+//
+// The locale of a request is resolved in the following order:
+// 1. The 'locale' field of the authenticated user
+// 2. The Accept-Language header
+// 3. The default locale configured in DEFAULT_LOCALE
+//
+// Error responses of all endpoints are translated by a router middleware. Other user-facing
+// strings are translated with translate before they are sent.
+package backend
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tools/router"
+)
+
+// defaultLocale is the locale the messages are written in.
+const defaultLocale = "en"
+
+// translationBundles are the translations of the supported locales besides English, mapping the
+// English message formats to the translated formats. Keys start with a lower case letter and
+// have no trailing period; their verbs (%s, %v, %w, %d) are translated to %s or %[n]s.
+var translationBundles = map[string]map[string]string{
+	"de": germanTranslations,
+}
+
+// localeDecimalSeparators are the decimal separators of the supported locales.
+var localeDecimalSeparators = map[string]string{
+	"en": ".",
+	"de": ",",
+}
+
+// messageFormat is a compiled English message format of a translation bundle.
+type messageFormat struct {
+	pattern *regexp.Regexp // Matches messages of the format and captures the formatted values
+	format  string         // The English message format
+}
+
+// messageFormats are the compiled formats of all bundles, longest format first, so more
+// specific formats are matched before more general ones.
+var messageFormats = sync.OnceValue(func() []messageFormat {
+	verb := regexp.MustCompile(`%[svwd]`)
+
+	var formats []messageFormat
+	seen := map[string]bool{}
+	for _, bundle := range translationBundles {
+		for format := range bundle {
+			if seen[format] {
+				continue
+			}
+			seen[format] = true
+
+			parts := verb.Split(format, -1)
+			for i := range parts {
+				parts[i] = regexp.QuoteMeta(parts[i])
+			}
+			formats = append(formats, messageFormat{
+				pattern: regexp.MustCompile("^(?s)" + strings.Join(parts, "(.+?)") + "$"),
+				format:  format,
+			})
+		}
+	}
+
+	slices.SortFunc(formats, func(a, b messageFormat) int {
+		return cmp.Or(cmp.Compare(len(b.format), len(a.format)), cmp.Compare(a.format, b.format))
+	})
+	return formats
+})
+
+// RegisterI18nHooks registers the middleware translating error responses with the PocketBase server.
+//
+// Parameters:
+// - app: The PocketBase application instance
+func RegisterI18nHooks(app core.App) {
+	app.OnServe().BindFunc(func(se *core.ServeEvent) error {
+		se.Router.BindFunc(func(e *core.RequestEvent) error {
+			err := e.Next()
+
+			var apiErr *router.ApiError
+			if errors.As(err, &apiErr) {
+				if locale := requestLocale(e); locale != defaultLocale {
+					apiErr.Message = translate(locale, apiErr.Message)
+				}
+			}
+			return err
+		})
+
+		return se.Next()
+	})
+}
+
+// requestLocale resolves the locale of a request.
+//
+// Parameters:
+// - e: The RequestEvent of the request
+//
+// Returns:
+// - The locale of the authenticated user, the preferred supported language of the client or the default locale
+func requestLocale(e *core.RequestEvent) string {
+	if e.Auth != nil {
+		if locale := e.Auth.GetString("locale"); isSupportedLocale(locale) {
+			return locale
+		}
+	}
+
+	// Languages are listed in order of preference, quality values are ignored
+	for _, language := range strings.Split(e.Request.Header.Get("Accept-Language"), ",") {
+		language, _, _ = strings.Cut(language, ";")
+		language, _, _ = strings.Cut(strings.TrimSpace(language), "-")
+		if language = strings.ToLower(language); isSupportedLocale(language) {
+			return language
+		}
+	}
+
+	if isSupportedLocale(settings.DefaultLocale) {
+		return settings.DefaultLocale
+	}
+	return defaultLocale
+}
+
+// isSupportedLocale reports whether a locale has a translation bundle or is the default locale.
+func isSupportedLocale(locale string) bool {
+	_, ok := translationBundles[locale]
+	return ok || locale == defaultLocale
+}
+
+// translate translates an English message into a locale.
+//
+// Parameters:
+// - locale: The target locale
+// - message: The English message, optionally capitalized and ending with a period
+//
+// Returns:
+// - The translated message, or the message itself if no translation exists
+func translate(locale string, message string) string {
+	bundle, ok := translationBundles[locale]
+	if !ok || message == "" {
+		return message
+	}
+
+	// Error responses are capitalized and end with a period, the bundles contain the plain formats
+	body, hasPeriod := strings.CutSuffix(message, ".")
+	first, size := utf8.DecodeRuneInString(body)
+	capitalized := unicode.IsUpper(first)
+	body = string(unicode.ToLower(first)) + body[size:]
+
+	for _, format := range messageFormats() {
+		translatedFormat, ok := bundle[format.format]
+		if !ok {
+			continue
+		}
+
+		values := format.pattern.FindStringSubmatch(body)
+		if values == nil {
+			continue
+		}
+
+		args := make([]any, 0, len(values)-1)
+		for _, value := range values[1:] {
+			args = append(args, translate(locale, value))
+		}
+		body = fmt.Sprintf(translatedFormat, args...)
+		break
+	}
+
+	if capitalized {
+		first, size = utf8.DecodeRuneInString(body)
+		body = string(unicode.ToUpper(first)) + body[size:]
+	}
+	if hasPeriod {
+		body += "."
+	}
+	return body
+}
@@ -0,0 +1,302 @@
+// German Translation Bundle for the I18n Module
+package backend
+
+// germanTranslations is the German translation bundle, see translationBundles.
+var germanTranslations = map[string]string{
+	// Request validation
+	"invalid request body":                     "ungültiger Anfrageinhalt",
+	"invalid form data":                        "ungültige Formulardaten",
+	"file too large or invalid multipart form": "Datei zu groß oder ungültiges Multipart-Formular",
+	"failed to get uploaded file":              "die hochgeladene Datei konnte nicht gelesen werden",
+	"missing '%s' (string) parameter":          "fehlender Parameter '%s' (Zeichenkette)",
+	"missing '%s' (bool) parameter":            "fehlender Parameter '%s' (Boolescher Wert)",
+	"missing 'uids' (string array) parameter":  "fehlender Parameter 'uids' (Liste von Zeichenketten)",
+	"invalid '%s' format. Expected RFC3339, epoch seconds or epoch milliseconds":                        "ungültiges Format von '%s'. Erwartet wird RFC3339, Epoch-Sekunden oder Epoch-Millisekunden",
+	"invalid '%s' value. Expected 'true' or 'false'":                                                    "ungültiger Wert von '%s'. Erwartet wird 'true' oder 'false'",
+	"invalid 'date' format. Expected YYYY-MM-DD":                                                        "ungültiges Format von 'date'. Erwartet wird JJJJ-MM-TT",
+	"invalid absence date '%s'. Expected YYYY-MM-DD":                                                    "ungültiges Abwesenheitsdatum '%s'. Erwartet wird JJJJ-MM-TT",
+	"invalid 'month' (string) parameter. Expected format: YYYY-MM":                                      "ungültiger Parameter 'month' (Zeichenkette). Erwartetes Format: JJJJ-MM",
+	"invalid 'format' (string) parameter. Expected 'csv', 'json', 'payroll' or 'template'":              "ungültiger Parameter 'format' (Zeichenkette). Erwartet wird 'csv', 'json', 'payroll' oder 'template'",
+	"invalid 'limit' (integer) parameter. Expected a value between 1 and %d":                            "ungültiger Parameter 'limit' (Ganzzahl). Erwartet wird ein Wert zwischen 1 und %s",
+	"invalid 'days' (integer) parameter. Expected a value between 1 and %d":                             "ungültiger Parameter 'days' (Ganzzahl). Erwartet wird ein Wert zwischen 1 und %s",
+	"'dst_correction' requires 'timezone'":                                                              "'dst_correction' erfordert 'timezone'",
+	"invalid 'timezone' value '%s'":                                                                     "ungültige Zeitzone '%s'",
+	"invalid timestamp '%s'":                                                                            "ungültiger Zeitstempel '%s'",
+	"'to' must be after 'from'":                                                                         "'to' muss nach 'from' liegen",
+	"the request exceeded the timeout of %s":                                                            "die Anfrage hat das Zeitlimit von %s überschritten",
+	"the server is shutting down, please retry later":                                                   "der Server wird heruntergefahren, bitte versuche es später erneut",
+	"'%s' lies more than %s in the future. Superusers can override this check with 'allow_future=true'": "'%s' liegt mehr als %s in der Zukunft. Superuser können diese Prüfung mit 'allow_future=true' übergehen",
+	"only superusers can override the future timestamp check":                                           "nur Superuser können die Prüfung auf Zeitstempel in der Zukunft übergehen",
+	"invalid 'merge_gaps_seconds' (integer) parameter. Expected a non-negative number":                  "ungültiger Parameter 'merge_gaps_seconds' (Ganzzahl). Erwartet wird eine nicht negative Zahl",
+	"invalid '%s' (string) parameter. Expected a period like 'last_month' or YYYY-MM":                   "ungültiger Parameter '%s' (Zeichenkette). Erwartet wird ein Zeitraum wie 'last_month' oder JJJJ-MM",
+
+	// Clocking in and out
+	"failed to clock in/out":                       "Ein-/Ausstempeln fehlgeschlagen",
+	"failed to clock out":                          "Ausstempeln fehlgeschlagen",
+	"failed to toggle clock status":                "Umschalten des Stempelstatus fehlgeschlagen",
+	"failed to clock in: %v":                       "Einstempeln fehlgeschlagen: %s",
+	"failed to clock out: %v":                      "Ausstempeln fehlgeschlagen: %s",
+	"failed to clock in/out: %v":                   "Ein-/Ausstempeln fehlgeschlagen: %s",
+	"failed to clock in at %s: %v":                 "Einstempeln um %s fehlgeschlagen: %s",
+	"failed to clock out at %s: %v":                "Ausstempeln um %s fehlgeschlagen: %s",
+	"failed to toggle clock status: %v":            "Umschalten des Stempelstatus fehlgeschlagen: %s",
+	"failed to get work clock status: %v":          "Abrufen des Stempelstatus fehlgeschlagen: %s",
+	"failed to check current clock status: %v":     "Prüfen des aktuellen Stempelstatus fehlgeschlagen: %s",
+	"failed to add clock in/out pair: %v":          "Hinzufügen des Ein-/Ausstempelpaars fehlgeschlagen: %s",
+	"failed to delete clock in/out pair: %v":       "Löschen des Ein-/Ausstempelpaars fehlgeschlagen: %s",
+	"failed to modify work clock timestamp: %v":    "Ändern des Zeitstempels fehlgeschlagen: %s",
+	"failed to replace work clock day: %v":         "Ersetzen des Arbeitstags fehlgeschlagen: %s",
+	"already clocked in":                           "bereits eingestempelt",
+	"already clocked out":                          "bereits ausgestempelt",
+	"currently not clocked in":                     "derzeit nicht eingestempelt",
+	"only the last session of the day can be open": "nur die letzte Sitzung des Tages darf offen sein",
+	"%s: %v. Confirm the session with 'confirm=true' or supply the actual end time with 'end'":                                           "%s: %s. Bestätige die Sitzung mit 'confirm=true' oder gib das tatsächliche Ende mit 'end' an",
+	"the open session started at %s would last %s, exceeding the maximum session duration of %s":                                         "die um %s begonnene offene Sitzung würde %s dauern und damit die maximale Sitzungsdauer von %s überschreiten",
+	"expected the preceding work clock record with id '%s' to be a clock in record":                                                      "der vorhergehende Stempel mit der ID '%s' muss ein Einstempeln sein",
+	"expected the preceding work clock record with id '%s' to be a clock out record":                                                     "der vorhergehende Stempel mit der ID '%s' muss ein Ausstempeln sein",
+	"expected the succeeding work clock record with id '%s' to be a clock in record":                                                     "der nachfolgende Stempel mit der ID '%s' muss ein Einstempeln sein",
+	"expected the succeeding work clock record with id '%s' to be a clock out record":                                                    "der nachfolgende Stempel mit der ID '%s' muss ein Ausstempeln sein",
+	"expected the work clock record with id '%s' to be a clock in record since the first work clock record cannot be a clock out record": "der Stempel mit der ID '%s' muss ein Einstempeln sein, da der erste Stempel kein Ausstempeln sein kann",
+	"added clock in record at time '%s' is not valid: %w":                                                                                "das hinzugefügte Einstempeln um '%s' ist ungültig: %s",
+	"added clock out record at time '%s' is not valid: %w":                                                                               "das hinzugefügte Ausstempeln um '%s' ist ungültig: %s",
+	"modified work clock record with id '%s' is not valid: %w":                                                                           "der geänderte Stempel mit der ID '%s' ist ungültig: %s",
+	"former neighbor with id '%s' is not valid anymore: %w":                                                                              "der frühere Nachbar mit der ID '%s' ist nicht mehr gültig: %s",
+	"failed to find work clock record with id '%s': %w":                                                                                  "der Stempel mit der ID '%s' wurde nicht gefunden: %s",
+
+	// Employment
+	"%s lies outside of the employment":        "%s liegt außerhalb der Beschäftigung",
+	"the period must not end before it starts": "der Zeitraum darf nicht enden, bevor er beginnt",
+
+	// Absences
+	"invalid '%s' format. Expected YYYY-MM-DD":                          "ungültiges Format von '%s'. Erwartet wird JJJJ-MM-TT",
+	"invalid 'kind' value '%s'. Expected one of: %s":                    "ungültiger Wert '%s' in 'kind'. Erwartet wird einer von: %s",
+	"'to' must not be before 'from'":                                    "'to' darf nicht vor 'from' liegen",
+	"an absence must not span more than %d days":                        "eine Abwesenheit darf höchstens %s Tage umfassen",
+	"failed to create absences: %v":                                     "Erstellen der Abwesenheiten fehlgeschlagen: %s",
+	"failed to find absences: %v":                                       "Suchen der Abwesenheiten fehlgeschlagen: %s",
+	"invalid 'minutes' value. Expected a value between 0 and 1440":      "ungültiger Wert in 'minutes'. Erwartet wird ein Wert zwischen 0 und 1440",
+	"'half_day' and 'minutes' can't be combined":                        "'half_day' und 'minutes' können nicht kombiniert werden",
+	"%s is a %s day. Confirm the work time with 'confirm_absence=true'": "%s ist ein Abwesenheitstag (%s). Bestätige die Arbeitszeit mit 'confirm_absence=true'",
+
+	// Vacation
+	"invalid 'year' (integer) parameter. Expected a year like 2025": "ungültiger Parameter 'year' (Ganzzahl). Erwartet wird eine Jahreszahl wie 2025",
+	"failed to get vacation budget: %v":                             "Abrufen des Urlaubskontingents fehlgeschlagen: %s",
+
+	// Sessions
+	"failed to find sessions: %v":                           "Suchen der Sitzungen fehlgeschlagen: %s",
+	"failed to read sessions: %v":                           "Lesen der Sitzungen fehlgeschlagen: %s",
+	"failed to merge sessions: %v":                          "Zusammenführen der Sitzungen fehlgeschlagen: %s",
+	"failed to set description of session: %v":              "Setzen der Beschreibung der Sitzung fehlgeschlagen: %s",
+	"failed to set project of session: %v":                  "Setzen des Projekts der Sitzung fehlgeschlagen: %s",
+	"failed to set tags of session: %v":                     "Setzen der Tags der Sitzung fehlgeschlagen: %s",
+	"failed to set issue of session: %v":                    "Setzen des Tickets der Sitzung fehlgeschlagen: %s",
+	"failed to set category of session: %v":                 "Setzen der Kategorie der Sitzung fehlgeschlagen: %s",
+	"not all tags exist or tags were passed multiple times": "nicht alle Tags existieren oder Tags wurden mehrfach übergeben",
+	"invalid issue URL '%s'":                                "ungültige Ticket-URL '%s'",
+	"invalid issue reference '%s'. Expected a URL, a Jira key (ABC-123) or a GitHub issue (owner/repo#42)": "ungültige Ticketreferenz '%s'. Erwartet wird eine URL, ein Jira-Schlüssel (ABC-123) oder ein GitHub-Issue (owner/repo#42)",
+	"invalid 'category' (string) parameter. Expected one of: %s":                                           "ungültiger Parameter 'category' (Zeichenkette). Erwartet wird einer von: %s",
+
+	// Templates
+	"template with id '%s' not found":                              "die Vorlage mit der ID '%s' wurde nicht gefunden",
+	"template with id '%s' is invalid: %v":                         "die Vorlage mit der ID '%s' ist ungültig: %s",
+	"template with id '%s' has invalid sessions":                   "die Vorlage mit der ID '%s' enthält ungültige Sitzungen",
+	"templates can only be applied to weeks that are already over": "Vorlagen können nur auf bereits vergangene Wochen angewendet werden",
+	"failed to apply template: %v":                                 "Anwenden der Vorlage fehlgeschlagen: %s",
+	"the week of %s already contains work clock records":           "die Woche vom %s enthält bereits Stempel",
+
+	// Moving sessions
+	"failed to move session: %v":                            "Verschieben der Sitzung fehlgeschlagen: %s",
+	"missing 'to_clock' or 'project_id' (string) parameter": "fehlender Parameter 'to_clock' oder 'project_id' (Zeichenkette)",
+
+	// Comments
+	"failed to find comments: %v": "Suchen der Kommentare fehlgeschlagen: %s",
+	"failed to add comment: %v":   "Hinzufügen des Kommentars fehlgeschlagen: %s",
+
+	// Admin
+	"failed to create admin overview: %v":    "Erstellen der Admin-Übersicht fehlgeschlagen: %s",
+	"failed to find support corrections: %v": "Suchen der Support-Korrekturen fehlgeschlagen: %s",
+
+	// Delegations
+	"the clock '%s' belongs to a user, please sign in": "die Uhr '%s' gehört einem Benutzer, bitte melde dich an",
+	"you are not allowed to change the clock '%s'":     "du darfst die Uhr '%s' nicht ändern",
+	"failed to find clock: %v":                         "Suchen der Uhr fehlgeschlagen: %s",
+	"failed to find delegations: %v":                   "Suchen der Vertretungen fehlgeschlagen: %s",
+	"you are not allowed to see the clock '%s'":        "du darfst die Uhr '%s' nicht sehen",
+	"failed to find teams: %v":                         "Suchen der Teams fehlgeschlagen: %s",
+
+	// Teams
+	"team with id '%s' does not exist":               "das Team mit der ID '%s' existiert nicht",
+	"only the leads of the team can see its reports": "nur die Leitungen des Teams können seine Berichte sehen",
+	"failed to create team report: %v":               "Erstellen des Team-Berichts fehlgeschlagen: %s",
+
+	// Projects, tags and reports
+	"failed to get budget status: %v":       "Abrufen des Budgetstatus fehlgeschlagen: %s",
+	"failed to create tag report: %v":       "Erstellen des Tag-Berichts fehlgeschlagen: %s",
+	"failed to create issue report: %v":     "Erstellen des Ticket-Berichts fehlgeschlagen: %s",
+	"failed to create category report: %v":  "Erstellen des Kategorie-Berichts fehlgeschlagen: %s",
+	"failed to find daily summaries: %v":    "Suchen der Tageszusammenfassungen fehlgeschlagen: %s",
+	"failed to find missing days: %v":       "Suchen der fehlenden Tage fehlgeschlagen: %s",
+	"failed to rebuild daily summaries: %v": "Neuberechnen der Tageszusammenfassungen fehlgeschlagen: %s",
+	"failed to compare periods: %v":         "Vergleichen der Zeiträume fehlgeschlagen: %s",
+	"failed to forecast balance: %v":        "Prognostizieren des Saldos fehlgeschlagen: %s",
+	"failed to get weekday statistics: %v":  "Abrufen der Wochentagsstatistik fehlgeschlagen: %s",
+	"project with id '%s' does not exist":   "das Projekt mit der ID '%s' existiert nicht",
+	"tag with id '%s' does not exist":       "der Tag mit der ID '%s' existiert nicht",
+	"clock '%s' does not exist":             "die Uhr '%s' existiert nicht",
+
+	// Custom reports
+	"failed to create custom report: %v":                 "Erstellen des benutzerdefinierten Berichts fehlgeschlagen: %s",
+	"invalid 'group_by' value '%s'. Expected one of: %s": "ungültiger Wert '%s' in 'group_by'. Erwartet wird einer von: %s",
+	"invalid 'metrics' value '%s'. Expected one of: %s":  "ungültiger Wert '%s' in 'metrics'. Erwartet wird einer von: %s",
+	"'group_by' contains '%s' multiple times":            "'group_by' enthält '%s' mehrfach",
+	"'metrics' contains '%s' multiple times":             "'metrics' enthält '%s' mehrfach",
+	"failed to save report: %v":                          "Speichern des Berichts fehlgeschlagen: %s",
+	"failed to share report: %v":                         "Teilen des Berichts fehlgeschlagen: %s",
+	"failed to revoke share links: %v":                   "Widerrufen der Freigabelinks fehlgeschlagen: %s",
+	"saved report not found":                             "der gespeicherte Bericht wurde nicht gefunden",
+	"only the owner of the report can use it":            "nur der Besitzer des Berichts kann ihn verwenden",
+	"saved report is not valid anymore: %v":              "der gespeicherte Bericht ist nicht mehr gültig: %s",
+	"unknown, expired or revoked share link":             "unbekannter, abgelaufener oder widerrufener Freigabelink",
+
+	// Calendar
+	"failed to read calendar: %v":                                                     "Lesen des Kalenders fehlgeschlagen: %s",
+	"failed to import calendar events: %v":                                            "Importieren der Kalendertermine fehlgeschlagen: %s",
+	"no event with uid '%s' within the range":                                         "kein Termin mit der UID '%s' im Zeitraum",
+	"calendar responded with status %d":                                               "der Kalender antwortete mit Status %s",
+	"no calendar configured, enable the calendar integration or set CALENDAR_ICS_URL": "kein Kalender konfiguriert, aktiviere die Kalenderintegration oder setze CALENDAR_ICS_URL",
+
+	// Exports
+	"failed to export sessions: %v":                        "Exportieren der Sitzungen fehlgeschlagen: %s",
+	"export profile with id '%s' does not exist":           "das Exportprofil mit der ID '%s' existiert nicht",
+	"export profile with id '%s' has an invalid delimiter": "das Exportprofil mit der ID '%s' hat ein ungültiges Trennzeichen",
+	"export profile with id '%s' has no columns":           "das Exportprofil mit der ID '%s' hat keine Spalten",
+	"export profile with id '%s' has unknown column '%s'":  "das Exportprofil mit der ID '%s' hat die unbekannte Spalte '%s'",
+	"failed to load the signing key":                       "Laden des Signaturschlüssels fehlgeschlagen",
+	"failed to encode the public key":                      "Kodieren des öffentlichen Schlüssels fehlgeschlagen",
+	"failed to verify the ledger":                          "Prüfen des Protokolls fehlgeschlagen",
+
+	// Imports
+	"only .db files are allowed":                      "nur .db-Dateien sind erlaubt",
+	"only .json exports and .zip backups are allowed": "nur .json-Exporte und .zip-Backups sind erlaubt",
+	"invalid database file: %v":                       "ungültige Datenbankdatei: %s",
+	"failed to create temporary directory":            "Erstellen des temporären Verzeichnisses fehlgeschlagen",
+	"failed to create temporary file":                 "Erstellen der temporären Datei fehlgeschlagen",
+	"failed to save uploaded file":                    "Speichern der hochgeladenen Datei fehlgeschlagen",
+	"file is larger than %d bytes":                    "die Datei ist größer als %s Bytes",
+	"file is not a SQLite database":                   "die Datei ist keine SQLite-Datenbank",
+	"file is too short to be a SQLite database":       "die Datei ist zu kurz für eine SQLite-Datenbank",
+	"database is corrupt: %s":                         "die Datenbank ist beschädigt: %s",
+	"invalid export: %w":                              "ungültiger Export: %s",
+	"invalid backup: %w":                              "ungültiges Backup: %s",
+	"failed to roll back import: %v":                  "Zurücksetzen des Imports fehlgeschlagen: %s",
+	"failed to find import runs: %v":                  "Suchen der Importläufe fehlgeschlagen: %s",
+
+	// Shortcuts and email gateway
+	"unknown shortcut action":                                       "unbekannte Kurzbefehl-Aktion",
+	"unknown or revoked shortcut token":                             "unbekanntes oder widerrufenes Kurzbefehl-Token",
+	"the shortcut token was used too often, please try again later": "das Kurzbefehl-Token wurde zu oft verwendet, bitte versuche es später erneut",
+	"failed to create shortcut token: %v":                           "Erstellen des Kurzbefehl-Tokens fehlgeschlagen: %s",
+	"failed to execute shortcut: %v":                                "Ausführen des Kurzbefehls fehlgeschlagen: %s",
+	"the email gateway is not configured":                           "das E-Mail-Gateway ist nicht konfiguriert",
+	"invalid email gateway secret":                                  "ungültiges Geheimnis des E-Mail-Gateways",
+	"the sender is not allowed to use the email gateway":            "der Absender darf das E-Mail-Gateway nicht verwenden",
+	"the email contains no known command":                           "die E-Mail enthält keinen bekannten Befehl",
+	"failed to execute email command: %v":                           "Ausführen des E-Mail-Befehls fehlgeschlagen: %s",
+
+	// API tokens
+	"unknown or revoked API token":                               "unbekanntes oder widerrufenes API-Token",
+	"the API token expired":                                      "das API-Token ist abgelaufen",
+	"the API token lacks the '%s' scope":                         "dem API-Token fehlt der Geltungsbereich '%s'",
+	"only superusers can create tokens with the 'admin:*' scope": "nur Superuser können Tokens mit dem Geltungsbereich 'admin:*' erstellen",
+	"invalid scope '%s'. Expected one of: %s":                    "ungültiger Geltungsbereich '%s'. Erwartet wird einer von: %s",
+	"'expires_at' must be in the future":                         "'expires_at' muss in der Zukunft liegen",
+	"failed to find API tokens: %v":                              "Suchen der API-Tokens fehlgeschlagen: %s",
+	"failed to create API token: %v":                             "Erstellen des API-Tokens fehlgeschlagen: %s",
+	"unknown API token":                                          "unbekanntes API-Token",
+	"failed to revoke API token: %v":                             "Widerrufen des API-Tokens fehlgeschlagen: %s",
+
+	// Journal
+	"failed to create journal: %v":     "Erstellen des Journals fehlgeschlagen: %s",
+	"failed to set note of day: %v":    "Setzen der Tagesnotiz fehlgeschlagen: %s",
+	"failed to find note of day: %v":   "Suchen der Tagesnotiz fehlgeschlagen: %s",
+	"failed to save note of day: %v":   "Speichern der Tagesnotiz fehlgeschlagen: %s",
+	"failed to delete note of day: %v": "Löschen der Tagesnotiz fehlgeschlagen: %s",
+
+	// Search
+	"invalid 'kinds' value '%s'. Expected one of: %s": "ungültiger Wert '%s' in 'kinds'. Erwartet wird einer von: %s",
+	"failed to search: %v":                            "Suche fehlgeschlagen: %s",
+
+	// Status badge
+	"invalid badge token": "ungültiges Badge-Token",
+	"work":                "Arbeit",
+	"off":                 "ausgestempelt",
+	"clocked in since %s": "eingestempelt seit %s",
+
+	// Daily summary feed
+	"invalid feed token":        "ungültiges Feed-Token",
+	"failed to create feed: %v": "Erstellen des Feeds fehlgeschlagen: %s",
+	"work journal":              "Arbeitsjournal",
+	"%s: %s worked":             "%s: %s gearbeitet",
+	"worked %s, breaks %s, overtime %s, balance %s": "gearbeitet %s, Pausen %s, Überstunden %s, Saldo %s",
+
+	// Events
+	"invalid 'level' value '%s'. Expected one of: %s": "ungültiger Wert '%s' in 'level'. Erwartet wird einer von: %s",
+	"failed to find events: %v":                       "Suchen der Ereignisse fehlgeschlagen: %s",
+	"event with id '%s' does not exist":               "Ereignis mit der ID '%s' existiert nicht",
+
+	// Grafana
+	"unknown metric '%s'":        "unbekannte Metrik '%s'",
+	"failed to query metric: %v": "Abfragen der Metrik fehlgeschlagen: %s",
+
+	// Integrations
+	"unknown integration '%s'":                                                              "unbekannte Integration '%s'",
+	"failed to save integration: %v":                                                        "Speichern der Integration fehlgeschlagen: %s",
+	"failed to delete integration: %v":                                                      "Löschen der Integration fehlgeschlagen: %s",
+	"missing '%s' (string) configuration":                                                   "fehlende Konfiguration '%s' (Zeichenkette)",
+	"missing 'urls' (string array) configuration":                                           "fehlende Konfiguration 'urls' (Liste von Zeichenketten)",
+	"invalid '%s' (string) configuration. Expected a URL starting with %s://":               "ungültige Konfiguration '%s' (Zeichenkette). Erwartet wird eine URL, die mit %s:// beginnt",
+	"invalid 'service' (string) configuration. Expected 'ntfy' or 'gotify'":                 "ungültige Konfiguration 'service' (Zeichenkette). Erwartet wird 'ntfy' oder 'gotify'",
+	"invalid 'room_id' (string) configuration. Expected a room ID like !abcdef:example.com": "ungültige Konfiguration 'room_id' (Zeichenkette). Erwartet wird eine Raum-ID wie !abcdef:example.com",
+	"invalid 'summary_time' (string) configuration. Expected a time like 18:00":             "ungültige Konfiguration 'summary_time' (Zeichenkette). Erwartet wird eine Uhrzeit wie 18:00",
+
+	// Webhook deliveries
+	"failed to find webhook deliveries: %v":        "Suchen der Webhook-Zustellungen fehlgeschlagen: %s",
+	"webhook delivery with id '%s' does not exist": "Webhook-Zustellung mit der ID '%s' existiert nicht",
+
+	// Long polling
+	"invalid 'wait' parameter '%s'. Expected a duration like 30s": "ungültiger Parameter 'wait' '%s'. Erwartet wird eine Dauer wie 30s",
+
+	// Timestamp formats
+	"ambiguous '%s' value '%s'. Expected epoch seconds with up to 10 digits, epoch milliseconds with 13 digits or a unit suffix like 's' or 'ms'": "mehrdeutiger Wert '%[2]s' in '%[1]s'. Erwartet werden Epoch-Sekunden mit bis zu 10 Ziffern, Epoch-Millisekunden mit 13 Ziffern oder eine Einheit wie 's' oder 'ms'",
+
+	// Presence
+	"missing 'users' (string array) parameter":          "fehlender Parameter 'users' (Liste von Zeichenketten)",
+	"too many users, at most %d can be queried at once": "zu viele Benutzer, höchstens %s können auf einmal abgefragt werden",
+	"failed to query presence: %v":                      "Abfragen der Anwesenheit fehlgeschlagen: %s",
+
+	// Cost centers
+	"only superusers can change the cost center of a user": "nur Superuser können die Kostenstelle eines Benutzers ändern",
+	"failed to create cost center rollup: %v":              "Erstellen der Kostenstellenauswertung fehlgeschlagen: %s",
+
+	// Scheduled exports
+	"scheduled export with id '%s' does not exist": "geplanter Export mit der ID '%s' existiert nicht",
+	"failed to run scheduled export: %v":           "Ausführen des geplanten Exports fehlgeschlagen: %s",
+	"invalid schedule '%s': %v":                    "ungültiger Zeitplan '%s': %s",
+	"invalid recipients: %v":                       "ungültige Empfänger: %s",
+	"payroll exports require an export profile":    "Lohnexporte benötigen ein Exportprofil",
+	"failed to deliver scheduled export '%s': %v":  "Zustellen des geplanten Exports '%s' fehlgeschlagen: %s",
+	"failed to send email: %v":                     "Senden der E-Mail fehlgeschlagen: %s",
+
+	// Scheduled export delivery
+	"the SFTP delivery is not configured":                                   "die SFTP-Zustellung ist nicht konfiguriert",
+	"the SMB delivery is not configured":                                    "die SMB-Zustellung ist nicht konfiguriert",
+	"invalid directory '%s', it has to be a relative path within the share": "ungültiges Verzeichnis '%s', es muss ein relativer Pfad innerhalb der Freigabe sein",
+
+	// Export templates
+	"export template with id '%s' does not exist": "die Exportvorlage mit der ID '%s' existiert nicht",
+	"export template with id '%s' is invalid: %v": "die Exportvorlage mit der ID '%s' ist ungültig: %s",
+	"invalid template: %v":                        "ungültige Vorlage: %s",
+	"template fails to render sample data: %v":    "die Vorlage kann die Beispieldaten nicht darstellen: %s",
+	"failed to render template: %v":               "Darstellen der Vorlage fehlgeschlagen: %s",
+	"template exports require an export template": "Vorlagenexporte benötigen eine Exportvorlage",
+}
@@ -0,0 +1,77 @@
+package backend
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"unicode"
+	"unicode/utf8"
+)
+
+// formatPattern compiles a message format into a pattern matching the messages of the format,
+// the same way messageFormats does.
+func formatPattern(format string) *regexp.Regexp {
+	parts := regexp.MustCompile(`%[svwd]`).Split(format, -1)
+	for i := range parts {
+		parts[i] = regexp.QuoteMeta(parts[i])
+	}
+	return regexp.MustCompile("^(?s)" + strings.Join(parts, "(.+?)") + "$")
+}
+
+func TestTranslationKeysMatchMessageFormats(t *testing.T) {
+	files, err := filepath.Glob("*.go")
+	if err != nil {
+		t.Fatalf("failed to list source files: %v", err)
+	}
+
+	// The string literals of the backend, normalized like the messages passed to translate
+	var formats []string
+	var patterns []*regexp.Regexp
+	for _, file := range files {
+		if strings.HasSuffix(file, "_test.go") || strings.HasPrefix(file, "i18n_") {
+			continue
+		}
+
+		parsed, err := parser.ParseFile(token.NewFileSet(), file, nil, 0)
+		if err != nil {
+			t.Fatalf("failed to parse %s: %v", file, err)
+		}
+		ast.Inspect(parsed, func(node ast.Node) bool {
+			if literal, ok := node.(*ast.BasicLit); ok && literal.Kind == token.STRING {
+				value, err := strconv.Unquote(literal.Value)
+				if err != nil || value == "" {
+					return true
+				}
+				value = strings.TrimSuffix(value, ".")
+				first, size := utf8.DecodeRuneInString(value)
+				format := string(unicode.ToLower(first)) + value[size:]
+				formats = append(formats, format)
+				patterns = append(patterns, formatPattern(format))
+			}
+			return true
+		})
+	}
+
+	// A key matches a format if one of them can produce the other, e.g. "already clocked in"
+	// and "already clocked %s". Formats starting with a value, e.g. "%s: %v", would match any key.
+	for locale, bundle := range translationBundles {
+		for key := range bundle {
+			keyPattern := formatPattern(key)
+			found := false
+			for i, format := range formats {
+				if keyPattern.MatchString(format) || (!strings.HasPrefix(format, "%") && patterns[i].MatchString(key)) {
+					found = true
+					break
+				}
+			}
+			if !found {
+				t.Errorf("expected the %s key %q to match a message format of the backend", locale, key)
+			}
+		}
+	}
+}
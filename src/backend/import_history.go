@@ -0,0 +1,282 @@
+// Import History Module for PocketBase
+//
+// This module records every run of an import in the import_runs collection: the kind of import,
+// the uploaded file, the number of created records and skipped entries, the duration, who started
+// it, and whether it succeeded. The history answers where a set of records came from, even if the
+// import failed and nothing was imported.
+//
+// The work clock records created by an import are tagged with the ID of its run, so a bad import
+// can be rolled back by removing exactly those records, without restoring a backup.
+package backend
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/core"
+)
+
+const (
+	// defaultImportHistoryLimit is the number of import runs listed without 'limit' parameter
+	defaultImportHistoryLimit = 50
+
+	// maxImportHistoryLimit is the maximum number of import runs listed at once
+	maxImportHistoryLimit = 500
+)
+
+// importRun collects the details of a running import until it is recorded.
+type importRun struct {
+	ID       string    // ID of the run, assigned when the import starts, so the created records can be tagged with it
+	Source   string    // 'legacy', 'instance' or 'calendar'
+	FileName string    // Name of the uploaded file, empty for imports without file
+	Records  int       // Number of created work clock records
+	Skipped  int       // Number of entries that were not imported
+	Actor    string    // Who started the import
+	Started  time.Time // Start of the import
+}
+
+// ImportRunEntry is an import run as returned by the import history endpoint.
+type ImportRunEntry struct {
+	ID         string     `json:"id"`          // ID of the import run
+	Source     string     `json:"source"`      // 'legacy', 'instance' or 'calendar'
+	FileName   string     `json:"file_name"`   // Name of the uploaded file, empty for imports without file
+	Records    int        `json:"records"`     // Number of created work clock records
+	Skipped    int        `json:"skipped"`     // Number of entries that were not imported
+	DurationMs int64      `json:"duration_ms"` // Duration of the import in milliseconds
+	Actor      string     `json:"actor"`       // Who started the import
+	Outcome    string     `json:"outcome"`     // 'succeeded' or 'failed'
+	Error      string     `json:"error"`       // Why the import failed, empty for successful imports
+	Created    time.Time  `json:"created"`     // Time the import finished
+	RolledBack *time.Time `json:"rolled_back"` // Time the import was rolled back, null if it wasn't
+}
+
+// ImportRollbackResult is the response of the import rollback endpoint.
+type ImportRollbackResult struct {
+	Deleted int `json:"deleted"` // Number of deleted work clock records
+}
+
+// RegisterImportHistoryAPI registers the import history endpoints with the PocketBase server.
+// It creates the following routes:
+// - GET /api/import/history?limit= - Lists the latest import runs, newest first
+// - POST /api/import/{run_id}/rollback - Deletes the work clock records created by a successful import run
+//
+// Parameters:
+// - app: The PocketBase application instance
+func RegisterImportHistoryAPI(app core.App) {
+	app.OnServe().BindFunc(func(se *core.ServeEvent) error {
+		se.Router.GET("/api/import/history", func(e *core.RequestEvent) error {
+			limit := defaultImportHistoryLimit
+			if limitValue := e.Request.URL.Query().Get("limit"); limitValue != "" {
+				var err error
+				limit, err = strconv.Atoi(limitValue)
+				if err != nil || limit < 1 || limit > maxImportHistoryLimit {
+					return e.Error(http.StatusBadRequest, fmt.Sprintf("Invalid 'limit' (integer) parameter. Expected a value between 1 and %d", maxImportHistoryLimit), nil)
+				}
+			}
+
+			runs, err := findImportRuns(app, limit)
+			if err != nil {
+				return e.Error(http.StatusInternalServerError, fmt.Sprintf("Failed to find import runs: %v", err), err)
+			}
+
+			return e.JSON(http.StatusOK, runs)
+		})
+
+		se.Router.POST("/api/import/{run_id}/rollback", func(e *core.RequestEvent) error {
+			deleted, err := rollbackImportRun(e.Request.Context(), app, e.Request.PathValue("run_id"))
+			if err != nil {
+				return e.Error(http.StatusInternalServerError, fmt.Sprintf("Failed to roll back import: %v", err), err)
+			}
+
+			return e.JSON(http.StatusOK, ImportRollbackResult{Deleted: deleted})
+		})
+
+		return se.Next()
+	})
+}
+
+// startImportRun starts collecting the details of an import.
+//
+// Parameters:
+// - e: The RequestEvent of the import request, used to determine the actor
+// - source: The kind of import ('legacy', 'instance' or 'calendar')
+// - fileName: The name of the uploaded file, empty for imports without file
+//
+// Returns:
+// - The started import run
+func startImportRun(e *core.RequestEvent, source string, fileName string) *importRun {
+	actor := "anonymous"
+	if e.Auth != nil {
+		actor = fmt.Sprintf("%s %s", e.Auth.Collection().Name, e.Auth.Email())
+	}
+
+	return &importRun{ID: core.GenerateDefaultRandomId(), Source: source, FileName: fileName, Actor: actor, Started: time.Now()}
+}
+
+// finish records the import run. Failing to record the run is only logged, since the import
+// itself is already completed or rolled back.
+//
+// Parameters:
+// - app: The App interface used to save the run
+// - importErr: The error the import failed with, nil if it succeeded
+func (r *importRun) finish(app core.App, importErr error) {
+	collection, err := app.FindCollectionByNameOrId("import_runs")
+	if err != nil {
+		app.Logger().Error("failed to find import_runs collection", "error", err)
+		return
+	}
+
+	record := core.NewRecord(collection)
+	record.Id = r.ID
+	record.Set("source", r.Source)
+	record.Set("file_name", r.FileName)
+	record.Set("records", r.Records)
+	record.Set("skipped", r.Skipped)
+	record.Set("duration_ms", time.Since(r.Started).Milliseconds())
+	record.Set("actor", r.Actor)
+	record.Set("outcome", "succeeded")
+	if importErr != nil {
+		record.Set("outcome", "failed")
+		record.Set("error", importErr.Error())
+	}
+
+	if err := app.Save(record); err != nil {
+		app.Logger().Error("failed to record import run", "source", r.Source, "error", err)
+	}
+
+	data := map[string]any{"run_id": r.ID, "source": r.Source, "file_name": r.FileName}
+	if importErr != nil {
+		recordEvent(app, "import_failed", "error", fmt.Sprintf("Import of %s failed: %v", r.Source, importErr), data)
+	} else {
+		recordEvent(app, "import_completed", "info", fmt.Sprintf("Import of %s created %d records", r.Source, r.Records), data)
+	}
+}
+
+// findImportRuns finds the latest import runs.
+//
+// Parameters:
+// - app: The App interface (typically a PocketBase instance or transaction)
+// - limit: The maximum number of runs
+//
+// Returns:
+// - The import runs, newest first
+// - An error if the database query fails
+func findImportRuns(app core.App, limit int) ([]ImportRunEntry, error) {
+	records, err := app.FindRecordsByFilter("import_runs", "", "-created", limit, 0)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find import runs: %w", err)
+	}
+
+	runs := make([]ImportRunEntry, 0, len(records))
+	for _, record := range records {
+		runs = append(runs, ImportRunEntry{
+			ID:         record.Id,
+			Source:     record.GetString("source"),
+			FileName:   record.GetString("file_name"),
+			Records:    record.GetInt("records"),
+			Skipped:    record.GetInt("skipped"),
+			DurationMs: int64(record.GetInt("duration_ms")),
+			Actor:      record.GetString("actor"),
+			Outcome:    record.GetString("outcome"),
+			Error:      record.GetString("error"),
+			Created:    record.GetDateTime("created").Time(),
+		})
+		if rolledBack := record.GetDateTime("rolled_back"); !rolledBack.IsZero() {
+			rolledBackTime := rolledBack.Time()
+			runs[len(runs)-1].RolledBack = &rolledBackTime
+		}
+	}
+
+	return runs, nil
+}
+
+// rollbackImportRun deletes the work clock records created by an import run.
+//
+// Parameters:
+// - ctx: The context cancelling the operation, e.g. of the request
+// - app: The App interface (typically a PocketBase instance or transaction)
+// - runID: The ID of the import run
+//
+// Returns:
+// - The number of deleted records
+// - An error if the run does not exist, failed, was already rolled back, the operation fails, or
+// if the records around the deleted ones don't alternate anymore, e.g. because sessions were
+// added inside an imported session
+//
+// The operation is performed within a transaction. The former neighbors of the deleted records
+// are validated before committing, so a rollback never leaves a broken sequence behind.
+func rollbackImportRun(ctx context.Context, app core.App, runID string) (int, error) {
+	unlock, err := workClockServiceOf(app).WithContext(ctx).lockAll()
+	if err != nil {
+		return 0, err
+	}
+	defer unlock()
+
+	deleted := 0
+	err = app.RunInTransaction(func(txApp core.App) error {
+		run, err := txApp.FindRecordById("import_runs", runID)
+		if err != nil {
+			return fmt.Errorf("import run with id '%s' does not exist", runID)
+		}
+		if run.GetString("outcome") != "succeeded" {
+			return fmt.Errorf("import run with id '%s' did not succeed, so there is nothing to roll back", runID)
+		}
+		if !run.GetDateTime("rolled_back").IsZero() {
+			return fmt.Errorf("import run with id '%s' was already rolled back", runID)
+		}
+
+		records, err := txApp.FindRecordsByFilter("work_clock", "import_run = {:run}", "+timestamp", 0, 0, dbx.Params{"run": runID})
+		if err != nil {
+			return fmt.Errorf("failed to find records of import run: %w", err)
+		}
+
+		deletedIDs := map[string]bool{}
+		for _, record := range records {
+			deletedIDs[record.Id] = true
+		}
+
+		var neighborIDs []string
+		for _, record := range records {
+			ids, err := findNeighborWorkClockRecordIDs(txApp, record)
+			if err != nil {
+				return err
+			}
+			for _, id := range ids {
+				if !deletedIDs[id] {
+					neighborIDs = append(neighborIDs, id)
+				}
+			}
+		}
+
+		for _, record := range records {
+			if err := txApp.Delete(record); err != nil {
+				return fmt.Errorf("failed to delete work clock record with id '%s': %w", record.Id, err)
+			}
+		}
+
+		slices.Sort(neighborIDs)
+		for _, neighborID := range slices.Compact(neighborIDs) {
+			if err := checkValidity(txApp, neighborID); err != nil {
+				return fmt.Errorf("former neighbor with id '%s' is not valid anymore: %w", neighborID, err)
+			}
+		}
+
+		run.Set("rolled_back", time.Now())
+		if err := txApp.Save(run); err != nil {
+			return fmt.Errorf("failed to save import run: %w", err)
+		}
+
+		deleted = len(records)
+		return nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to roll back import run '%s': %w", runID, err)
+	}
+
+	return deleted, nil
+}
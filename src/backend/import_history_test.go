@@ -0,0 +1,48 @@
+package backend
+
+import (
+	"testing"
+	"time"
+
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/yerTools/simple-frontend-stack/src/backend/backendtest"
+)
+
+func TestRollbackImportRun(t *testing.T) {
+	app := backendtest.NewApp(t)
+
+	if err := workClockServiceOf(app).AddClockInOutPair("", backendtest.MustParseTime("2025-03-31T09:00:00Z"), backendtest.MustParseTime("2025-03-31T17:00:00Z")); err != nil {
+		t.Fatalf("failed to add existing session: %v", err)
+	}
+
+	logs := []ActivityLog{
+		{Timestamp: backendtest.MustParseTime("2025-04-01T09:00:00Z"), Active: true},
+		{Timestamp: backendtest.MustParseTime("2025-04-01T17:00:00Z"), Active: false},
+		{Timestamp: backendtest.MustParseTime("2025-04-02T09:00:00Z"), Active: true},
+		{Timestamp: backendtest.MustParseTime("2025-04-02T17:00:00Z"), Active: false},
+	}
+
+	run := &importRun{ID: core.GenerateDefaultRandomId(), Source: "legacy", Started: time.Now()}
+	if err := importActivityLogs(t.Context(), app, "", run.ID, logs); err != nil {
+		t.Fatalf("failed to import activity logs: %v", err)
+	}
+	run.Records = len(logs)
+	run.finish(app, nil)
+
+	deleted, err := rollbackImportRun(t.Context(), app, run.ID)
+	if err != nil {
+		t.Fatalf("failed to roll back import: %v", err)
+	}
+	if deleted != len(logs) {
+		t.Errorf("expected %d deleted records, got %d", len(logs), deleted)
+	}
+
+	if records := backendtest.Records(t, app); len(records) != 2 {
+		t.Fatalf("expected the existing session to be kept, got %d records", len(records))
+	}
+	backendtest.AssertAlternating(t, app)
+
+	if _, err := rollbackImportRun(t.Context(), app, run.ID); err == nil {
+		t.Fatal("expected a second rollback of the same import to be rejected")
+	}
+}
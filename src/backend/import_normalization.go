@@ -0,0 +1,161 @@
+// Import Normalization Module for PocketBase
+//
+// This module normalizes the activity logs read from legacy databases before they are imported.
+// Legacy trackers recorded local times inconsistently: some stored the local wall clock time as
+// if it was UTC, some ignored daylight saving time, and all of them stored nanoseconds, so
+// sessions start at odd fractions of a second. Trackers that polled the activity also split
+// sessions into thousands of pieces separated by gaps of a few seconds. The normalization is
+// selected by the parameters of the legacy import endpoint:
+// - 'timezone': IANA timezone the stored wall clock times are interpreted in (e.g. 'Europe/Berlin')
+// - 'dst_correction': The stored times are standard time of the timezone all year, so the missing
+// daylight saving hour is corrected (requires 'timezone')
+// - 'round_to_minute': Rounds the timestamps to the nearest minute
+// - 'merge_gaps_seconds': Merges sessions separated by gaps shorter than this number of seconds,
+// applied after the timestamps are normalized
+//
+// Without parameters, the activity logs are imported as stored.
+package backend
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/pocketbase/pocketbase/core"
+)
+
+// activityLogNormalization describes how the activity logs of a legacy database are normalized.
+type activityLogNormalization struct {
+	Location      *time.Location // Timezone the stored wall clock times are interpreted in, nil keeps the timestamps as stored
+	DSTCorrection bool           // Whether the stored times are standard time of Location all year
+	RoundToMinute bool           // Whether the timestamps are rounded to the nearest minute
+	MaxMergedGap  time.Duration  // Sessions separated by shorter gaps are merged, zero disables merging
+}
+
+// parseActivityLogNormalization parses the normalization parameters of an import request.
+//
+// Parameters:
+// - e: The RequestEvent from the HTTP handler
+//
+// Returns:
+// - The requested normalization
+// - An error describing the invalid parameter
+func parseActivityLogNormalization(e *core.RequestEvent) (activityLogNormalization, error) {
+	var normalization activityLogNormalization
+	var err error
+
+	if timezone := e.Request.FormValue("timezone"); timezone != "" {
+		normalization.Location, err = time.LoadLocation(timezone)
+		if err != nil {
+			return activityLogNormalization{}, fmt.Errorf("invalid 'timezone' value '%s'", timezone)
+		}
+	}
+
+	if value := e.Request.FormValue("dst_correction"); value != "" {
+		normalization.DSTCorrection, err = parseBoolParam(value, "dst_correction")
+		if err != nil {
+			return activityLogNormalization{}, err
+		}
+		if normalization.DSTCorrection && normalization.Location == nil {
+			return activityLogNormalization{}, fmt.Errorf("'dst_correction' requires 'timezone'")
+		}
+	}
+
+	if value := e.Request.FormValue("round_to_minute"); value != "" {
+		normalization.RoundToMinute, err = parseBoolParam(value, "round_to_minute")
+		if err != nil {
+			return activityLogNormalization{}, err
+		}
+	}
+
+	if value := e.Request.FormValue("merge_gaps_seconds"); value != "" {
+		seconds, err := strconv.Atoi(value)
+		if err != nil || seconds < 0 {
+			return activityLogNormalization{}, fmt.Errorf("invalid 'merge_gaps_seconds' (integer) parameter. Expected a non-negative number")
+		}
+		normalization.MaxMergedGap = time.Duration(seconds) * time.Second
+	}
+
+	return normalization, nil
+}
+
+// apply normalizes a timestamp.
+//
+// Parameters:
+// - timestamp: The timestamp as stored in the legacy database
+//
+// Returns:
+// - The normalized timestamp
+func (n activityLogNormalization) apply(timestamp time.Time) time.Time {
+	if n.Location != nil {
+		wall := timestamp.UTC()
+		location := n.Location
+		if n.DSTCorrection {
+			location = time.FixedZone("", standardOffset(n.Location, wall.Year()))
+		}
+		timestamp = time.Date(wall.Year(), wall.Month(), wall.Day(), wall.Hour(), wall.Minute(), wall.Second(), wall.Nanosecond(), location)
+	}
+
+	if n.RoundToMinute {
+		timestamp = timestamp.Round(time.Minute)
+	}
+
+	return timestamp
+}
+
+// normalizeActivityLogs normalizes activity logs.
+//
+// Parameters:
+// - logs: The activity logs read from the legacy database
+// - normalization: The normalization to apply
+//
+// Returns:
+// - The normalized activity logs, sorted by their timestamps
+// - The number of merged gaps
+func normalizeActivityLogs(logs []ActivityLog, normalization activityLogNormalization) ([]ActivityLog, int) {
+	normalized := make([]ActivityLog, 0, len(logs))
+	for _, log := range logs {
+		log.Timestamp = normalization.apply(log.Timestamp)
+		normalized = append(normalized, log)
+	}
+
+	slices.SortStableFunc(normalized, func(a, b ActivityLog) int {
+		return a.Timestamp.Compare(b.Timestamp)
+	})
+
+	if normalization.MaxMergedGap <= 0 {
+		return normalized, 0
+	}
+
+	// A clock out directly followed by a clock in within the gap is dropped together with the clock in
+	merged := 0
+	result := make([]ActivityLog, 0, len(normalized))
+	for i := 0; i < len(normalized); i++ {
+		if !normalized[i].Active && i+1 < len(normalized) && normalized[i+1].Active &&
+			normalized[i+1].Timestamp.Sub(normalized[i].Timestamp) < normalization.MaxMergedGap {
+			merged++
+			i++
+			continue
+		}
+		result = append(result, normalized[i])
+	}
+
+	return result, merged
+}
+
+// standardOffset returns the offset of a timezone's standard time in a year. Daylight saving time
+// always has the larger offset, so the smaller of the offsets in January and July is the standard
+// time on both hemispheres.
+//
+// Parameters:
+// - location: The timezone
+// - year: The year
+//
+// Returns:
+// - The offset of the standard time in seconds east of UTC
+func standardOffset(location *time.Location, year int) int {
+	_, january := time.Date(year, time.January, 1, 0, 0, 0, 0, location).Zone()
+	_, july := time.Date(year, time.July, 1, 0, 0, 0, 0, location).Zone()
+	return min(january, july)
+}
@@ -0,0 +1,60 @@
+package backend
+
+import (
+	"testing"
+	"time"
+
+	"github.com/yerTools/simple-frontend-stack/src/backend/backendtest"
+)
+
+func TestTimestampNormalization(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Skipf("timezone database not available: %v", err)
+	}
+
+	stored := backendtest.MustParseTime("2025-07-01T09:00:29.999Z")
+
+	tests := []struct {
+		name          string
+		normalization activityLogNormalization
+		expected      string
+	}{
+		{"as stored", activityLogNormalization{}, "2025-07-01T09:00:29.999Z"},
+		{"timezone", activityLogNormalization{Location: berlin}, "2025-07-01T07:00:29.999Z"},
+		{"dst correction", activityLogNormalization{Location: berlin, DSTCorrection: true}, "2025-07-01T08:00:29.999Z"},
+		{"rounded", activityLogNormalization{Location: berlin, RoundToMinute: true}, "2025-07-01T07:00:00Z"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			normalized := test.normalization.apply(stored)
+			if expected := backendtest.MustParseTime(test.expected); !normalized.Equal(expected) {
+				t.Errorf("expected %s, got %s", expected, normalized.UTC())
+			}
+		})
+	}
+}
+
+func TestNormalizeActivityLogsMergesGaps(t *testing.T) {
+	logs := []ActivityLog{
+		{Timestamp: backendtest.MustParseTime("2025-04-01T09:00:00Z"), Active: true},
+		{Timestamp: backendtest.MustParseTime("2025-04-01T10:00:00Z"), Active: false},
+		{Timestamp: backendtest.MustParseTime("2025-04-01T10:00:05Z"), Active: true},
+		{Timestamp: backendtest.MustParseTime("2025-04-01T12:00:00Z"), Active: false},
+		{Timestamp: backendtest.MustParseTime("2025-04-01T13:00:00Z"), Active: true},
+		{Timestamp: backendtest.MustParseTime("2025-04-01T17:00:00Z"), Active: false},
+	}
+
+	normalized, merged := normalizeActivityLogs(logs, activityLogNormalization{MaxMergedGap: 30 * time.Second})
+
+	if merged != 1 {
+		t.Errorf("expected 1 merged gap, got %d", merged)
+	}
+	if len(normalized) != 4 {
+		t.Fatalf("expected 4 activity logs, got %d", len(normalized))
+	}
+	if !normalized[1].Timestamp.Equal(backendtest.MustParseTime("2025-04-01T12:00:00Z")) {
+		t.Errorf("expected the merged session to end at 12:00, got %s", normalized[1].Timestamp)
+	}
+}
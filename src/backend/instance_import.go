@@ -0,0 +1,503 @@
+// Instance Import Module for PocketBase
+//
+// This module merges the work clock data of another instance into this one, so instances that
+// were run in parallel can be consolidated. It accepts either the JSON export of the other
+// instance (GET /api/work_clock/export?format=json) or a PocketBase backup zip of it.
+//
+// Sessions are merged one by one: sessions that already exist with the same start and end are
+// skipped as duplicates, and sessions overlapping existing records are reported as conflicts
+// instead of failing the whole import. Descriptions and issue references are carried over.
+// Projects and tags are not, since their IDs are specific to the other instance.
+package backend
+
+import (
+	"archive/zip"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tools/types"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// instanceImportMaxSize limits the size of the content read from an uploaded export or backup,
+// and of the database extracted from a backup. The size of the upload itself is limited by the
+// request limits module.
+const instanceImportMaxSize = 200 * 1024 * 1024
+
+// importedSession is a session read from the export or backup of another instance.
+type importedSession struct {
+	Start       time.Time // Start of the session
+	End         time.Time // End of the session, zero for an open session
+	Description string    // Description of the session
+	Issue       string    // Issue reference of the session
+}
+
+// InstanceImportConflict describes a session that was not imported.
+type InstanceImportConflict struct {
+	Start  time.Time  `json:"start"`  // Start of the session
+	End    *time.Time `json:"end"`    // End of the session, nil for an open session
+	Reason string     `json:"reason"` // Why the session was not imported
+}
+
+// InstanceImportResult summarizes a merge of another instance's sessions.
+type InstanceImportResult struct {
+	Imported   int                      `json:"imported"`   // Number of imported sessions
+	Duplicates int                      `json:"duplicates"` // Number of sessions that already existed
+	Conflicts  []InstanceImportConflict `json:"conflicts"`  // Sessions that were not imported
+}
+
+// RegisterInstanceImportAPI registers the instance import endpoint with the PocketBase server.
+// It creates the following route:
+// - POST /api/work_clock/instance_import - Merges the sessions of the uploaded 'file', either a JSON
+// export (.json) or a PocketBase backup (.zip) of another instance, into the clock selected by the
+// optional 'clock' parameter
+//
+// Parameters:
+// - app: The PocketBase application instance
+func RegisterInstanceImportAPI(app core.App) {
+	app.OnServe().BindFunc(func(se *core.ServeEvent) error {
+		se.Router.POST("/api/work_clock/instance_import", func(e *core.RequestEvent) error {
+			return handleInstanceImportPost(app, e)
+		}).Bind(trackedRequest("instance_import"))
+		return se.Next()
+	})
+}
+
+// handleInstanceImportPost reads the sessions of the uploaded export or backup and merges them.
+//
+// Parameters:
+// - app: The App interface (typically a PocketBase instance or transaction)
+// - e: The RequestEvent from the HTTP handler
+//
+// Returns:
+// - An error response if the upload is invalid or the merge fails, otherwise the InstanceImportResult
+func handleInstanceImportPost(app core.App, e *core.RequestEvent) error {
+	if err := e.Request.ParseMultipartForm(32 * 1024 * 1024); err != nil {
+		return e.Error(http.StatusBadRequest, "File too large or invalid multipart form", err)
+	}
+
+	file, header, err := e.Request.FormFile("file")
+	if err != nil {
+		return e.Error(http.StatusBadRequest, "Failed to get uploaded file", err)
+	}
+	defer file.Close()
+
+	clockID, err := requestClock(app, e)
+	if err != nil {
+		return err
+	}
+
+	run := startImportRun(e, "instance", header.Filename)
+
+	_, readSpan := startSpan(e.Request.Context(), "instance_import.read")
+	var sessions []importedSession
+	switch strings.ToLower(filepath.Ext(header.Filename)) {
+	case ".json":
+		sessions, err = readExportSessions(file)
+	case ".zip":
+		sessions, err = readUploadedBackupSessions(e.Request.Context(), file)
+	default:
+		err = fmt.Errorf("invalid file extension: %s", filepath.Ext(header.Filename))
+		endSpan(readSpan, err)
+		return e.Error(http.StatusBadRequest, "Only .json exports and .zip backups are allowed", err)
+	}
+	readSpan.SetAttributes(attribute.Int("import.sessions", len(sessions)))
+	endSpan(readSpan, err)
+	if err != nil {
+		run.finish(app, err)
+		return e.Error(http.StatusBadRequest, fmt.Sprintf("Failed to read sessions: %v", err), err)
+	}
+
+	_, mergeSpan := startSpan(e.Request.Context(), "instance_import.merge")
+	result, err := mergeImportedSessions(e.Request.Context(), app, clockID, run.ID, sessions)
+	endSpan(mergeSpan, err)
+	if err != nil {
+		run.finish(app, err)
+		return e.Error(http.StatusInternalServerError, fmt.Sprintf("Failed to merge sessions: %v", err), err)
+	}
+
+	// Each imported session consists of a clock in and a clock out record
+	run.Records = 2 * result.Imported
+	run.Skipped = result.Duplicates + len(result.Conflicts)
+	run.finish(app, nil)
+
+	return e.JSON(http.StatusOK, result)
+}
+
+// readExportSessions reads the sessions of a JSON export.
+//
+// Parameters:
+// - reader: The JSON export
+//
+// Returns:
+// - The sessions of the export
+// - An error if the export is not valid JSON
+func readExportSessions(reader io.Reader) ([]importedSession, error) {
+	var entries []WorkSessionEntry
+	if err := json.NewDecoder(reader).Decode(&entries); err != nil {
+		return nil, fmt.Errorf("invalid export: %w", err)
+	}
+
+	sessions := make([]importedSession, 0, len(entries))
+	for _, entry := range entries {
+		session := importedSession{
+			Start:       entry.Start,
+			Description: entry.Description,
+			Issue:       entry.Issue,
+		}
+		if entry.End != nil {
+			session.End = *entry.End
+		}
+		sessions = append(sessions, session)
+	}
+
+	return sessions, nil
+}
+
+// readUploadedBackupSessions reads the sessions of an uploaded PocketBase backup.
+//
+// Parameters:
+// - ctx: The context of the import, cancelling it aborts reading the database
+// - file: The uploaded backup zip
+//
+// Returns:
+// - The sessions of the backup
+// - An error if the backup or its database is invalid
+func readUploadedBackupSessions(ctx context.Context, file io.Reader) ([]importedSession, error) {
+	// Zip archives need random access, so the upload is stored first
+	tempDir, err := os.MkdirTemp("", "instance_import_*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temporary directory: %w", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	zipPath := filepath.Join(tempDir, "backup.zip")
+	if err := writeLimitedFile(zipPath, file); err != nil {
+		return nil, err
+	}
+
+	archive, err := zip.OpenReader(zipPath)
+	if err != nil {
+		return nil, fmt.Errorf("invalid backup: %w", err)
+	}
+	defer archive.Close()
+
+	index := slices.IndexFunc(archive.File, func(entry *zip.File) bool { return entry.Name == "data.db" })
+	if index < 0 {
+		return nil, fmt.Errorf("the backup contains no data.db")
+	}
+
+	data, err := archive.File[index].Open()
+	if err != nil {
+		return nil, fmt.Errorf("failed to open data.db: %w", err)
+	}
+	defer data.Close()
+
+	dbPath := filepath.Join(tempDir, "data.db")
+	if err := writeLimitedFile(dbPath, data); err != nil {
+		return nil, err
+	}
+
+	if err := validateSQLiteDatabase(dbPath, instanceImportMaxSize); err != nil {
+		return nil, fmt.Errorf("invalid data.db: %w", err)
+	}
+
+	return readBackupSessions(ctx, dbPath)
+}
+
+// writeLimitedFile writes the content of a reader to a file, failing if it exceeds instanceImportMaxSize.
+//
+// Parameters:
+// - path: The path of the file to create
+// - reader: The content of the file
+//
+// Returns:
+// - An error if the file could not be written or the content is too large
+func writeLimitedFile(path string, reader io.Reader) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create temporary file: %w", err)
+	}
+	defer file.Close()
+
+	// Reading one byte more than allowed detects oversized content, e.g. from a zip bomb
+	written, err := io.Copy(file, io.LimitReader(reader, instanceImportMaxSize+1))
+	if err != nil {
+		return fmt.Errorf("failed to write temporary file: %w", err)
+	}
+	if written > instanceImportMaxSize {
+		return fmt.Errorf("content is larger than %d bytes", instanceImportMaxSize)
+	}
+
+	return nil
+}
+
+// readBackupSessions reads the sessions from the database of a PocketBase backup.
+//
+// Parameters:
+// - ctx: The context of the import, cancelling it aborts all queries
+// - dbPath: Path to the data.db of the backup
+//
+// Returns:
+// - The sessions of the backup
+// - An error if the database can't be read or its records don't alternate between clock in and clock out
+//
+// Backups of older versions may lack the description and issue fields, they are read if present.
+// Of backups with multiple clocks, only the records of the default clock are read.
+func readBackupSessions(ctx context.Context, dbPath string) ([]importedSession, error) {
+	ctx, cancel := context.WithTimeout(ctx, legacyImportTimeout)
+	defer cancel()
+
+	dsn, err := readOnlySQLiteDSN(dbPath)
+	if err != nil {
+		return nil, err
+	}
+
+	db, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database: %w", err)
+	}
+	defer db.Close()
+
+	columnRows, err := db.QueryContext(ctx, "SELECT name FROM pragma_table_info('work_clock')")
+	if err != nil {
+		return nil, fmt.Errorf("failed to query work_clock columns: %w", err)
+	}
+	columns := map[string]bool{}
+	for columnRows.Next() {
+		var name string
+		if err := columnRows.Scan(&name); err != nil {
+			columnRows.Close()
+			return nil, fmt.Errorf("failed to scan column: %w", err)
+		}
+		columns[name] = true
+	}
+	columnRows.Close()
+
+	if !columns["timestamp"] || !columns["clock_in"] {
+		return nil, fmt.Errorf("the backup contains no work_clock collection")
+	}
+
+	selected := []string{"timestamp", "clock_in", "''", "''"}
+	if columns["description"] {
+		selected[2] = "description"
+	}
+	if columns["issue"] {
+		selected[3] = "issue"
+	}
+
+	where := ""
+	if columns["clock"] {
+		where = " WHERE clock = ''"
+	}
+
+	rows, err := db.QueryContext(ctx, "SELECT "+strings.Join(selected, ", ")+" FROM work_clock"+where+" ORDER BY timestamp")
+	if err != nil {
+		return nil, fmt.Errorf("failed to query work_clock records: %w", err)
+	}
+	defer rows.Close()
+
+	var sessions []importedSession
+	var current *importedSession
+	for rows.Next() {
+		if len(sessions) >= legacyImportMaxRecords {
+			return nil, fmt.Errorf("more than %d sessions", legacyImportMaxRecords)
+		}
+
+		var timestampValue, description, issue string
+		var clockIn bool
+		if err := rows.Scan(&timestampValue, &clockIn, &description, &issue); err != nil {
+			return nil, fmt.Errorf("failed to scan work_clock record: %w", err)
+		}
+
+		timestamp, err := types.ParseDateTime(timestampValue)
+		if err != nil || timestamp.IsZero() {
+			return nil, fmt.Errorf("invalid timestamp '%s'", timestampValue)
+		}
+
+		if clockIn == (current != nil) {
+			return nil, fmt.Errorf("the work_clock records of the backup don't alternate at %s", timestampValue)
+		}
+
+		if clockIn {
+			current = &importedSession{Start: timestamp.Time(), Description: description, Issue: issue}
+			continue
+		}
+
+		current.End = timestamp.Time()
+		sessions = append(sessions, *current)
+		current = nil
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating work_clock records: %w", err)
+	}
+
+	if current != nil {
+		sessions = append(sessions, *current)
+	}
+
+	return sessions, nil
+}
+
+// mergeImportedSessions adds the sessions of another instance that neither exist yet nor overlap
+// existing records. All sessions are merged in a single transaction.
+//
+// Parameters:
+// - ctx: The context cancelling the operation, e.g. of the request
+// - app: The App interface (typically a PocketBase instance or transaction)
+// - clockID: The ID of the clock the sessions are merged into, an empty string for the default clock
+// - importRunID: The ID of the import run, the created records are tagged with
+// - sessions: The sessions to merge
+//
+// Returns:
+// - The summary of the merge
+// - An error if querying or saving records fails, in which case nothing is merged
+func mergeImportedSessions(ctx context.Context, app core.App, clockID string, importRunID string, sessions []importedSession) (InstanceImportResult, error) {
+	unlock, err := workClockServiceOf(app).WithContext(ctx).lock(clockID)
+	if err != nil {
+		return InstanceImportResult{}, err
+	}
+	defer unlock()
+
+	sort.Slice(sessions, func(a, b int) bool {
+		return sessions[a].Start.Before(sessions[b].Start)
+	})
+
+	var result InstanceImportResult
+	err = app.RunInTransaction(func(txApp core.App) error {
+		result = InstanceImportResult{Conflicts: []InstanceImportConflict{}}
+
+		collection, err := txApp.FindCollectionByNameOrId("work_clock")
+		if err != nil {
+			return fmt.Errorf("failed to find work clock collection: %w", err)
+		}
+
+		for _, session := range sessions {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+
+			conflict := InstanceImportConflict{Start: session.Start}
+			if !session.End.IsZero() {
+				end := session.End
+				conflict.End = &end
+			}
+
+			if session.End.IsZero() {
+				conflict.Reason = "the session is still open"
+				result.Conflicts = append(result.Conflicts, conflict)
+				continue
+			}
+			if !session.End.After(session.Start) {
+				conflict.Reason = "the session does not end after it starts"
+				result.Conflicts = append(result.Conflicts, conflict)
+				continue
+			}
+
+			reason, duplicate, err := checkImportedSession(txApp, clockID, session)
+			if err != nil {
+				return err
+			}
+			if duplicate {
+				result.Duplicates++
+				continue
+			}
+			if reason != "" {
+				conflict.Reason = reason
+				result.Conflicts = append(result.Conflicts, conflict)
+				continue
+			}
+
+			clockInRecord := core.NewRecord(collection)
+			clockInRecord.Set("clock", clockID)
+			clockInRecord.Set("timestamp", session.Start)
+			clockInRecord.Set("clock_in", true)
+			clockInRecord.Set("description", session.Description)
+			clockInRecord.Set("issue", session.Issue)
+			clockInRecord.Set("import_run", importRunID)
+			if err := txApp.Save(clockInRecord); err != nil {
+				return fmt.Errorf("failed to save clock in record at %s: %w", session.Start.Format(time.RFC3339), err)
+			}
+
+			clockOutRecord := core.NewRecord(collection)
+			clockOutRecord.Set("clock", clockID)
+			clockOutRecord.Set("timestamp", session.End)
+			clockOutRecord.Set("clock_in", false)
+			clockOutRecord.Set("import_run", importRunID)
+			if err := txApp.Save(clockOutRecord); err != nil {
+				return fmt.Errorf("failed to save clock out record at %s: %w", session.End.Format(time.RFC3339), err)
+			}
+
+			for _, record := range []*core.Record{clockInRecord, clockOutRecord} {
+				if err := checkValidity(txApp, record.Id); err != nil {
+					return fmt.Errorf("imported session starting at %s is not valid: %w", session.Start.Format(time.RFC3339), err)
+				}
+			}
+
+			result.Imported++
+		}
+
+		return nil
+	})
+	if err != nil {
+		return InstanceImportResult{}, err
+	}
+
+	return result, nil
+}
+
+// checkImportedSession checks whether an imported session can be added to a clock.
+//
+// Parameters:
+// - app: The core.App interface (typically a PocketBase transaction)
+// - clockID: The ID of the clock, an empty string for the default clock
+// - session: The closed session to check
+//
+// Returns:
+// - The reason why the session conflicts with existing records, empty if it can be added
+// - Whether the same session already exists
+// - An error if querying the records fails
+func checkImportedSession(app core.App, clockID string, session importedSession) (string, bool, error) {
+	params := dbx.Params{
+		"clock": clockParam(clockID),
+		"start": dateTimeParam(session.Start),
+		"end":   dateTimeParam(session.End),
+	}
+
+	// Three records are enough to distinguish an identical session from an overlap
+	records, err := app.FindRecordsByFilter("work_clock", "clock = {:clock} && timestamp >= {:start} && timestamp <= {:end}", "+timestamp", 3, 0, params)
+	if err != nil {
+		return "", false, fmt.Errorf("failed to find overlapping work clock records: %w", err)
+	}
+
+	if len(records) == 2 &&
+		records[0].GetBool("clock_in") && records[0].GetDateTime("timestamp").Time().Equal(session.Start) &&
+		!records[1].GetBool("clock_in") && records[1].GetDateTime("timestamp").Time().Equal(session.End) {
+		return "", true, nil
+	}
+	if len(records) > 0 {
+		return "the session overlaps existing records", false, nil
+	}
+
+	precedingRecords, err := app.FindRecordsByFilter("work_clock", "clock = {:clock} && timestamp < {:start}", "-timestamp", 1, 0, params)
+	if err != nil {
+		return "", false, fmt.Errorf("failed to find preceding work clock record: %w", err)
+	}
+	if len(precedingRecords) > 0 && precedingRecords[0].GetBool("clock_in") {
+		return "the session lies within an existing session", false, nil
+	}
+
+	return "", false, nil
+}
@@ -0,0 +1,317 @@
+// Integrations Module for PocketBase
+//
+// This module manages the runtime configuration of the integrations (webhooks, Slack, MQTT,
+// calendar, push notifications and Matrix). Superusers can enable, disable and configure each
+// integration through the admin endpoints; the configuration is stored in the integrations
+// collection and takes effect immediately, without changing environment variables or redeploying.
+//
+// An integration without a stored configuration falls back to the environment (WEBHOOK_URLS and
+// CALENDAR_ICS_URL), so existing setups keep working. Deleting the stored configuration reverts
+// an integration to the environment again.
+package backend
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"net/http"
+	"net/url"
+	"slices"
+	"sync"
+
+	"github.com/pocketbase/pocketbase/apis"
+	"github.com/pocketbase/pocketbase/core"
+)
+
+// integrationDefinition describes a configurable integration.
+type integrationDefinition struct {
+	decode  func(data []byte, enabled bool) (any, error) // Decodes and, for enabled integrations, validates a configuration
+	fromEnv func() (bool, any)                           // Returns whether the integration is enabled and its configuration from the environment
+}
+
+// integrationState is the effective configuration of an integration.
+type integrationState struct {
+	Enabled bool   // Whether the integration is active
+	Config  any    // The integration specific configuration, e.g. WebhooksConfig
+	Source  string // 'runtime' if stored in the integrations collection, 'environment' otherwise
+}
+
+// IntegrationStatus describes the effective configuration of an integration.
+type IntegrationStatus struct {
+	Name    string `json:"name"`    // Name of the integration
+	Enabled bool   `json:"enabled"` // Whether the integration is active
+	Source  string `json:"source"`  // 'runtime' if configured through the API, 'environment' otherwise
+	Config  any    `json:"config"`  // The integration specific configuration
+}
+
+// integrationUpdateRequest is the JSON body of the integration configuration endpoint.
+type integrationUpdateRequest struct {
+	Enabled bool            `json:"enabled"` // Whether the integration is active
+	Config  json.RawMessage `json:"config"`  // The integration specific configuration
+}
+
+// integrationDefinitions are the configurable integrations by their name.
+var integrationDefinitions = map[string]integrationDefinition{
+	"webhooks": defineIntegration(func() (bool, WebhooksConfig) {
+		return len(settings.WebhookURLs) > 0, WebhooksConfig{URLs: settings.WebhookURLs}
+	}),
+	"slack": defineIntegration(func() (bool, SlackConfig) {
+		return false, SlackConfig{}
+	}),
+	"mqtt": defineIntegration(func() (bool, MQTTConfig) {
+		return false, MQTTConfig{}
+	}),
+	"calendar": defineIntegration(func() (bool, CalendarConfig) {
+		return settings.CalendarICSURL != "", CalendarConfig{ICSURL: settings.CalendarICSURL}
+	}),
+	"push": defineIntegration(func() (bool, PushConfig) {
+		return false, PushConfig{}
+	}),
+	"matrix": defineIntegration(func() (bool, MatrixConfig) {
+		return false, MatrixConfig{}
+	}),
+}
+
+// integrationStates contains the effective configurations by integration name, nil until loaded.
+// It is guarded by integrationStatesMutex and reset whenever an integrations record changes.
+var integrationStates map[string]integrationState
+var integrationStatesMutex = sync.Mutex{}
+
+// RegisterIntegrationsAPI registers the integration admin endpoints with the PocketBase server.
+// All routes require superuser authentication. It creates the following routes:
+// - GET /api/integrations - Returns the effective configuration of all integrations
+// - PUT /api/integrations/{name} - Enables or disables and configures an integration
+// - DELETE /api/integrations/{name} - Removes the stored configuration, reverting to the environment
+//
+// The configuration endpoint expects a JSON body like:
+//
+//	{
+//	  "enabled": true,
+//	  "config": {"webhook_url": "https://hooks.slack.com/services/..."}
+//	}
+//
+// Parameters:
+// - app: The PocketBase application instance
+func RegisterIntegrationsAPI(app core.App) {
+	invalidate := func(e *core.RecordEvent) error {
+		invalidateIntegrationStates()
+		return e.Next()
+	}
+
+	app.OnRecordAfterCreateSuccess("integrations").BindFunc(invalidate)
+	app.OnRecordAfterUpdateSuccess("integrations").BindFunc(invalidate)
+	app.OnRecordAfterDeleteSuccess("integrations").BindFunc(invalidate)
+
+	app.OnServe().BindFunc(func(se *core.ServeEvent) error {
+		group := se.Router.Group("/api/integrations")
+		group.Bind(apis.RequireSuperuserAuth())
+
+		group.GET("", func(e *core.RequestEvent) error {
+			states := loadIntegrationStates(app)
+
+			statuses := make([]IntegrationStatus, 0, len(states))
+			for _, name := range slices.Sorted(maps.Keys(states)) {
+				state := states[name]
+				statuses = append(statuses, IntegrationStatus{Name: name, Enabled: state.Enabled, Source: state.Source, Config: state.Config})
+			}
+
+			return e.JSON(http.StatusOK, statuses)
+		})
+
+		group.PUT("/{name}", func(e *core.RequestEvent) error {
+			name := e.Request.PathValue("name")
+			definition, ok := integrationDefinitions[name]
+			if !ok {
+				return e.Error(http.StatusNotFound, fmt.Sprintf("Unknown integration '%s'", name), nil)
+			}
+
+			var request integrationUpdateRequest
+			if err := e.BindBody(&request); err != nil {
+				return e.Error(http.StatusBadRequest, "Invalid request body", err)
+			}
+
+			config, err := definition.decode(request.Config, request.Enabled)
+			if err != nil {
+				return e.Error(http.StatusBadRequest, err.Error(), nil)
+			}
+
+			if err := saveIntegration(app, name, request.Enabled, config); err != nil {
+				return e.Error(http.StatusInternalServerError, fmt.Sprintf("Failed to save integration: %v", err), err)
+			}
+			return callSucceeded(e)
+		})
+
+		group.DELETE("/{name}", func(e *core.RequestEvent) error {
+			name := e.Request.PathValue("name")
+			if _, ok := integrationDefinitions[name]; !ok {
+				return e.Error(http.StatusNotFound, fmt.Sprintf("Unknown integration '%s'", name), nil)
+			}
+
+			record, err := app.FindFirstRecordByData("integrations", "name", name)
+			if err == nil {
+				if err := app.Delete(record); err != nil {
+					return e.Error(http.StatusInternalServerError, fmt.Sprintf("Failed to delete integration: %v", err), err)
+				}
+			}
+			return callSucceeded(e)
+		})
+
+		return se.Next()
+	})
+}
+
+// defineIntegration creates the definition of an integration with a configuration of type T.
+//
+// Parameters:
+// - fromEnv: Returns whether the integration is enabled and its configuration from the environment
+//
+// Returns:
+// - The integration definition
+func defineIntegration[T interface{ validate() error }](fromEnv func() (bool, T)) integrationDefinition {
+	return integrationDefinition{
+		decode: func(data []byte, enabled bool) (any, error) {
+			var config T
+			if len(data) > 0 {
+				decoder := json.NewDecoder(bytes.NewReader(data))
+				decoder.DisallowUnknownFields()
+				if err := decoder.Decode(&config); err != nil {
+					return nil, fmt.Errorf("invalid 'config' (object) parameter: %w", err)
+				}
+			}
+
+			// Disabled integrations may be stored incomplete, e.g. while being set up
+			if enabled {
+				if err := config.validate(); err != nil {
+					return nil, err
+				}
+			}
+			return config, nil
+		},
+		fromEnv: func() (bool, any) {
+			return fromEnv()
+		},
+	}
+}
+
+// integrationConfig returns the configuration of an enabled integration.
+//
+// Parameters:
+// - app: The App interface used to load the configurations
+// - name: The name of the integration
+//
+// Returns:
+// - The configuration of the integration
+// - false if the integration is disabled
+func integrationConfig[T any](app core.App, name string) (T, bool) {
+	state := loadIntegrationStates(app)[name]
+
+	config, ok := state.Config.(T)
+	return config, ok && state.Enabled
+}
+
+// loadIntegrationStates returns the effective configurations of all integrations, loading them if necessary.
+//
+// Parameters:
+// - app: The App interface used to load the configurations
+//
+// Returns:
+// - The effective configurations by integration name
+func loadIntegrationStates(app core.App) map[string]integrationState {
+	integrationStatesMutex.Lock()
+	defer integrationStatesMutex.Unlock()
+
+	if integrationStates != nil {
+		return integrationStates
+	}
+
+	states := map[string]integrationState{}
+	for name, definition := range integrationDefinitions {
+		enabled, config := definition.fromEnv()
+		states[name] = integrationState{Enabled: enabled, Config: config, Source: "environment"}
+	}
+
+	records, err := app.FindAllRecords("integrations")
+	if err != nil {
+		// The environment configuration is used until the collection can be read
+		app.Logger().Error("failed to load integrations", "error", err)
+		return states
+	}
+
+	for _, record := range records {
+		name := record.GetString("name")
+		definition, ok := integrationDefinitions[name]
+		if !ok {
+			continue
+		}
+
+		data, _ := json.Marshal(record.Get("config"))
+		enabled := record.GetBool("enabled")
+		config, err := definition.decode(data, enabled)
+		if err != nil {
+			// Configurations changed outside of the API may be invalid, these integrations stay disabled
+			app.Logger().Error("invalid integration configuration", "integration", name, "error", err)
+			config, enabled = nil, false
+		}
+		states[name] = integrationState{Enabled: enabled, Config: config, Source: "runtime"}
+	}
+
+	integrationStates = states
+	return states
+}
+
+// invalidateIntegrationStates removes the loaded configurations, so they are reloaded on next use.
+func invalidateIntegrationStates() {
+	integrationStatesMutex.Lock()
+	defer integrationStatesMutex.Unlock()
+
+	integrationStates = nil
+}
+
+// saveIntegration stores the configuration of an integration.
+//
+// Parameters:
+// - app: The App interface (typically a PocketBase instance or transaction)
+// - name: The name of the integration
+// - enabled: Whether the integration is active
+// - config: The decoded configuration
+//
+// Returns:
+// - An error if the record can't be saved
+func saveIntegration(app core.App, name string, enabled bool, config any) error {
+	record, err := app.FindFirstRecordByData("integrations", "name", name)
+	if err != nil {
+		collection, err := app.FindCollectionByNameOrId("integrations")
+		if err != nil {
+			return err
+		}
+		record = core.NewRecord(collection)
+		record.Set("name", name)
+	}
+
+	record.Set("enabled", enabled)
+	record.Set("config", config)
+	return app.Save(record)
+}
+
+// validateIntegrationURL checks that a configuration value is an absolute URL with one of the given schemes.
+//
+// Parameters:
+// - value: The URL to validate
+// - field: The name of the configuration field, used in the error message
+// - schemes: The allowed URL schemes
+//
+// Returns:
+// - An error if the URL is missing, malformed or has another scheme
+func validateIntegrationURL(value string, field string, schemes ...string) error {
+	if value == "" {
+		return fmt.Errorf("missing '%s' (string) configuration", field)
+	}
+
+	parsed, err := url.Parse(value)
+	if err != nil || parsed.Host == "" || !slices.Contains(schemes, parsed.Scheme) {
+		return fmt.Errorf("invalid '%s' (string) configuration. Expected a URL starting with %s://", field, schemes[0])
+	}
+
+	return nil
+}
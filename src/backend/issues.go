@@ -0,0 +1,213 @@
+// Issues Module for PocketBase
+//
+// This module allows linking work clock sessions to external issues, such as Jira keys
+// ("ABC-123"), GitHub issues ("owner/repo#42") or plain issue URLs, and reports the time
+// spent per issue. This provides per-ticket times for sprint reviews.
+//
+// If WORK_CLOCK_VALIDATE_ISSUES is enabled, only issue references in one of the formats
+// above are accepted.
+package backend
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pocketbase/pocketbase/core"
+)
+
+// issueKeyPatterns are the accepted formats of issue references that are not URLs.
+var issueKeyPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`^[A-Z][A-Z0-9_]+-\d+$`),                           // Jira: ABC-123
+	regexp.MustCompile(`^[\w.-]+/[\w.-]+#\d+$`),                           // GitHub: owner/repo#42
+	regexp.MustCompile(`^#\d+$`),                                          // GitHub (repository implied): #42
+	regexp.MustCompile(`^[\w.-]+/[\w.-]+/-/(issues|merge_requests)/\d+$`), // GitLab path: group/project/-/issues/42
+}
+
+// IssueReportEntry contains the time spent on sessions linked to a specific issue.
+type IssueReportEntry struct {
+	Issue           string `json:"issue"`            // The issue reference, empty for sessions without issue
+	DurationSeconds int64  `json:"duration_seconds"` // Total duration of the sessions linked to this issue
+	Duration        string `json:"duration"`         // Total duration formatted in the configured duration format
+	Sessions        int    `json:"sessions"`         // Number of sessions linked to this issue
+}
+
+// IssueReport is the response of the issue report endpoint.
+type IssueReport struct {
+	From     time.Time          `json:"from"`     // Start of the reported range
+	To       time.Time          `json:"to"`       // End of the reported range
+	Issues   []IssueReportEntry `json:"issues"`   // Time per issue, sorted by duration (descending)
+	Unlinked IssueReportEntry   `json:"unlinked"` // Time of sessions without issue
+}
+
+// RegisterIssuesAPI registers the issue endpoints with the PocketBase server.
+// It creates the following routes:
+// - POST /api/work_clock/issue - Sets the 'issue' of a session, by default of the open session
+// - GET /api/work_clock/report/issues?from=&to=&clock= - Aggregates the time per issue for sessions starting within the range, supports conditional requests
+//
+// Parameters:
+// - app: The PocketBase application instance
+func RegisterIssuesAPI(app core.App) {
+	app.OnServe().BindFunc(func(se *core.ServeEvent) error {
+		se.Router.POST("/api/work_clock/issue", func(e *core.RequestEvent) error {
+			// An empty clock in ID refers to the currently open session
+			clockInID := e.Request.FormValue("clock_in_id")
+
+			// An empty issue removes the link
+			issue := strings.TrimSpace(e.Request.FormValue("issue"))
+			if issue != "" && settings.ValidateIssues {
+				if err := validateIssueReference(issue); err != nil {
+					return e.Error(http.StatusBadRequest, err.Error(), nil)
+				}
+			}
+
+			clockID, err := requestClock(app, e)
+			if err != nil {
+				return err
+			}
+
+			if err := setSessionIssue(e.Request.Context(), app, clockID, clockInID, issue); err != nil {
+				return e.Error(http.StatusInternalServerError, fmt.Sprintf("Failed to set issue of session: %v", err), err)
+			}
+			return callSucceeded(e)
+		})
+
+		se.Router.GET("/api/work_clock/report/issues", func(e *core.RequestEvent) error {
+			from, to, err := parseTimeRangeParams(e.Request.URL.Query().Get("from"), e.Request.URL.Query().Get("to"))
+			if err != nil {
+				return e.Error(http.StatusBadRequest, err.Error(), nil)
+			}
+
+			clockID, err := requestClock(app, e)
+			if err != nil {
+				return err
+			}
+
+			cacheKey := fmt.Sprintf("issues|%s|%s|%s", clockID, from.Format(time.RFC3339Nano), to.Format(time.RFC3339Nano))
+			report, err := cachedReport(app, cacheKey, func(now time.Time) (*IssueReport, error) {
+				return getIssueReport(app, clockID, from, to, now)
+			})
+			if err != nil {
+				return e.Error(http.StatusInternalServerError, fmt.Sprintf("Failed to create issue report: %v", err), err)
+			}
+
+			return respondConditionalJSON(e, report, workClockLastModified(app))
+		})
+
+		return se.Next()
+	})
+}
+
+// validateIssueReference checks that an issue reference is an HTTP(S) URL or matches
+// one of the known issue key formats.
+//
+// Parameters:
+// - issue: The issue reference to validate
+//
+// Returns:
+// - An error describing the accepted formats if the reference is invalid
+func validateIssueReference(issue string) error {
+	if strings.HasPrefix(issue, "http://") || strings.HasPrefix(issue, "https://") {
+		if parsedURL, err := url.Parse(issue); err == nil && parsedURL.Host != "" {
+			return nil
+		}
+		return fmt.Errorf("invalid issue URL '%s'", issue)
+	}
+
+	for _, pattern := range issueKeyPatterns {
+		if pattern.MatchString(issue) {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("invalid issue reference '%s'. Expected a URL, a Jira key (ABC-123) or a GitHub issue (owner/repo#42)", issue)
+}
+
+// setSessionIssue links the session started by a clock in record to an external issue.
+//
+// Parameters:
+// - ctx: The context cancelling the operation, e.g. of the request
+// - app: The App interface (typically a PocketBase instance or transaction)
+// - clockID: The ID of the clock of the open session, only used if clockInID is empty
+// - clockInID: The ID of the clock in record starting the session, an empty string refers to the open session
+// - issue: The issue reference, an empty string removes the link
+//
+// Returns:
+// - An error if the record is not a clock in record, there is no open session, or saving fails
+func setSessionIssue(ctx context.Context, app core.App, clockID string, clockInID string, issue string) error {
+	unlock, err := workClockServiceOf(app).WithContext(ctx).lockSession(clockID, clockInID)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	record, err := findClockInRecord(app, clockID, clockInID)
+	if err != nil {
+		return err
+	}
+
+	record.Set("issue", issue)
+	if err := app.Save(record); err != nil {
+		return fmt.Errorf("failed to save work clock record: %w", err)
+	}
+
+	return nil
+}
+
+// getIssueReport aggregates the time per issue for all sessions of a clock starting within a range.
+//
+// Parameters:
+// - app: The App interface (typically a PocketBase instance or transaction)
+// - clockID: The ID of the clock, an empty string for the default clock
+// - from: The start of the range (inclusive)
+// - to: The end of the range (exclusive)
+// - now: The reference time used as end of an open session
+//
+// Returns:
+// - The issue report
+// - An error if the sessions could not be retrieved
+func getIssueReport(app core.App, clockID string, from, to, now time.Time) (*IssueReport, error) {
+	sessions, err := findWorkSessions(app, clockID, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &IssueReport{From: from, To: to, Issues: []IssueReportEntry{}}
+	entries := map[string]*IssueReportEntry{}
+
+	for _, session := range sessions {
+		duration := int64(session.Duration(now).Seconds())
+		issue := session.ClockIn.GetString("issue")
+
+		if issue == "" {
+			report.Unlinked.DurationSeconds += duration
+			report.Unlinked.Sessions++
+			continue
+		}
+
+		entry, ok := entries[issue]
+		if !ok {
+			entry = &IssueReportEntry{Issue: issue}
+			entries[issue] = entry
+		}
+		entry.DurationSeconds += duration
+		entry.Sessions++
+	}
+
+	for _, entry := range entries {
+		entry.Duration = formatResponseDuration(entry.DurationSeconds)
+		report.Issues = append(report.Issues, *entry)
+	}
+	report.Unlinked.Duration = formatResponseDuration(report.Unlinked.DurationSeconds)
+
+	sort.Slice(report.Issues, func(a, b int) bool {
+		return report.Issues[a].DurationSeconds > report.Issues[b].DurationSeconds
+	})
+
+	return report, nil
+}
@@ -0,0 +1,222 @@
+// Journal Module for PocketBase
+//
+// This module composes everything known about a day into a single view for a diary-style page:
+// the sessions with their descriptions, the breaks between them, a Markdown note about the day,
+// and compliance flags pointing out days that violate common working time rules.
+//
+// The compliance flags follow the rules profile of the day (see the compliance rules module).
+// They only point out days worth a second look; breaks shorter than 15 minutes still count.
+// Days outside of the employment (see the employment periods module) are not flagged.
+package backend
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/core"
+)
+
+// Journal is the response of the journal endpoint.
+type Journal struct {
+	Date          string             `json:"date"`           // Day of the journal (YYYY-MM-DD)
+	Sessions      []WorkSessionEntry `json:"sessions"`       // Sessions starting on the day, open sessions last until now
+	WorkedSeconds int64              `json:"worked_seconds"` // Time that counts as work time
+	Worked        string             `json:"worked"`         // Worked time formatted in the configured duration format
+	BreakSeconds  int64              `json:"break_seconds"`  // Time between the sessions of the day
+	Breaks        string             `json:"breaks"`         // Breaks formatted in the configured duration format
+	Note          string             `json:"note"`           // Free-text Markdown note about the day, empty without note
+	NoteHTML      string             `json:"note_html"`      // Note rendered to HTML, empty without note
+	Compliance    []string           `json:"compliance"`     // Compliance flags of the day, empty if the day is fine
+}
+
+// RegisterJournalAPI registers the journal endpoints with the PocketBase server.
+// It creates the following routes:
+// - GET /api/journal/{date}?timezone=&clock= - Composes the journal of a day (YYYY-MM-DD) in the optional 'timezone', supports conditional requests
+// - PATCH /api/journal/{date} - Sets the 'note' of a day, an empty note removes it
+//
+// Parameters:
+// - app: The PocketBase application instance
+func RegisterJournalAPI(app core.App) {
+	app.OnServe().BindFunc(func(se *core.ServeEvent) error {
+		se.Router.GET("/api/journal/{date}", func(e *core.RequestEvent) error {
+			date := e.Request.PathValue("date")
+			dayStart, dayEnd, err := parseDayRange(date, e.Request.URL.Query().Get("timezone"))
+			if err != nil {
+				return e.Error(http.StatusBadRequest, err.Error(), nil)
+			}
+
+			clockID, err := requestClock(app, e)
+			if err != nil {
+				return err
+			}
+
+			journal, err := getJournal(app, clockID, date, dayStart, dayEnd, clockNow(app))
+			if err != nil {
+				return e.Error(http.StatusInternalServerError, fmt.Sprintf("Failed to create journal: %v", err), err)
+			}
+
+			return respondConditionalJSON(e, journal, workClockLastModified(app))
+		})
+
+		se.Router.PATCH("/api/journal/{date}", func(e *core.RequestEvent) error {
+			date := e.Request.PathValue("date")
+			if _, _, err := parseDayRange(date, ""); err != nil {
+				return e.Error(http.StatusBadRequest, err.Error(), nil)
+			}
+
+			clockID, err := requestClock(app, e)
+			if err != nil {
+				return err
+			}
+
+			if err := setDayNote(app, clockID, date, strings.TrimSpace(e.Request.FormValue("note"))); err != nil {
+				return e.Error(http.StatusInternalServerError, fmt.Sprintf("Failed to set note of day: %v", err), err)
+			}
+			return callSucceeded(e)
+		})
+
+		return se.Next()
+	})
+}
+
+// getJournal composes the journal of a day.
+//
+// Parameters:
+// - app: The App interface (typically a PocketBase instance or transaction)
+// - clockID: The ID of the clock, an empty string for the default clock
+// - date: The day (YYYY-MM-DD)
+// - dayStart: The start of the day (inclusive)
+// - dayEnd: The start of the following day (exclusive)
+// - now: The reference time used as end of an open session
+//
+// Returns:
+// - The journal
+// - An error if the sessions, the employment or rule periods or the note could not be retrieved
+func getJournal(app core.App, clockID string, date string, dayStart, dayEnd, now time.Time) (*Journal, error) {
+	sessions, err := findWorkSessions(app, clockID, dayStart, dayEnd)
+	if err != nil {
+		return nil, err
+	}
+
+	journal := &Journal{Date: date, Sessions: []WorkSessionEntry{}, Compliance: []string{}}
+
+	var worked, presence time.Duration
+	for _, session := range sessions {
+		journal.Sessions = append(journal.Sessions, newWorkSessionEntry(session, now))
+
+		duration := session.Duration(now)
+		worked += time.Duration(float64(duration) * categoryFactor(session.ClockIn.GetString("category")))
+		presence += duration
+	}
+
+	var breaks time.Duration
+	if len(sessions) > 0 {
+		breaks = max(sessions[len(sessions)-1].End(now).Sub(sessions[0].Start())-presence, 0)
+	}
+
+	journal.WorkedSeconds = int64(worked.Seconds())
+	journal.Worked = formatResponseDuration(journal.WorkedSeconds)
+	journal.BreakSeconds = int64(breaks.Seconds())
+	journal.Breaks = formatResponseDuration(journal.BreakSeconds)
+
+	// Days outside of the employment are not checked
+	employment, err := findEmploymentPeriods(app, clockID)
+	if err != nil {
+		return nil, err
+	}
+	rules, err := findRulePeriods(app, clockID)
+	if err != nil {
+		return nil, err
+	}
+	if employment.includes(dayStart) {
+		var end time.Time
+		if len(sessions) > 0 {
+			end = sessions[len(sessions)-1].End(now)
+		}
+		journal.Compliance = append(journal.Compliance, rules.on(dayStart).profile().flags(dayStart, worked, breaks, end)...)
+	}
+
+	note, err := findDayNote(app, clockID, date)
+	if err != nil {
+		return nil, err
+	}
+	if note != nil {
+		journal.Note = note.GetString("note")
+		journal.NoteHTML = renderMarkdown(journal.Note)
+	}
+
+	return journal, nil
+}
+
+// findDayNote finds the note of a day.
+//
+// Parameters:
+// - app: The App interface (typically a PocketBase instance or transaction)
+// - clockID: The ID of the clock, an empty string for the default clock
+// - date: The day (YYYY-MM-DD)
+//
+// Returns:
+// - The day_notes record, nil if the day has no note
+// - An error if the database query fails
+func findDayNote(app core.App, clockID string, date string) (*core.Record, error) {
+	records, err := app.FindRecordsByFilter("day_notes", "clock = {:clock} && date = {:date}", "", 1, 0, dbx.Params{
+		"clock": clockParam(clockID),
+		"date":  date,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to find note of day: %w", err)
+	}
+	if len(records) == 0 {
+		return nil, nil
+	}
+	return records[0], nil
+}
+
+// setDayNote sets the note of a day.
+//
+// Parameters:
+// - app: The App interface (typically a PocketBase instance or transaction)
+// - clockID: The ID of the clock, an empty string for the default clock
+// - date: The day (YYYY-MM-DD)
+// - note: The note, an empty string removes the note
+//
+// Returns:
+// - An error if the note could not be saved or removed
+func setDayNote(app core.App, clockID string, date string, note string) error {
+	return app.RunInTransaction(func(txApp core.App) error {
+		record, err := findDayNote(txApp, clockID, date)
+		if err != nil {
+			return err
+		}
+
+		if note == "" {
+			if record == nil {
+				return nil
+			}
+			if err := txApp.Delete(record); err != nil {
+				return fmt.Errorf("failed to delete note of day: %w", err)
+			}
+			return nil
+		}
+
+		if record == nil {
+			collection, err := txApp.FindCollectionByNameOrId("day_notes")
+			if err != nil {
+				return fmt.Errorf("failed to find day_notes collection: %w", err)
+			}
+
+			record = core.NewRecord(collection)
+			record.Set("clock", clockID)
+			record.Set("date", date)
+		}
+
+		record.Set("note", note)
+		if err := txApp.Save(record); err != nil {
+			return fmt.Errorf("failed to save note of day: %w", err)
+		}
+		return nil
+	})
+}
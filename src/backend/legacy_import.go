@@ -5,40 +5,78 @@
 // file uploads, extracts activity logs, and imports them into the work_clock collection.
 //
 // The import process handles the conversion from the legacy data structure to the
-// current PocketBase schema.
+// current PocketBase schema. By default, the import is all-or-nothing: a single invalid record
+// rolls back the whole import. In partial mode ('partial=true'), the valid records are imported
+// and the invalid ones are reported with their row and the reason, so only the broken entries of
+// a large legacy database have to be fixed. The timestamps can be normalized before they are
+// imported, see activityLogNormalization.
 package backend
 
 import (
+	"bytes"
+	"context"
 	"database/sql"
+	"encoding/binary"
 	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
 	"os"
 	"path/filepath"
+	"slices"
 	"time"
 
-	"github.com/pocketbase/pocketbase"
 	"github.com/pocketbase/pocketbase/core"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 
 	_ "modernc.org/sqlite"
 )
 
+const (
+	// legacyImportTimeout limits the time spent reading an uploaded database, so a crafted file can't
+	// keep the handler busy indefinitely
+	legacyImportTimeout = 30 * time.Second
+
+	// legacyImportMaxRecords limits the number of records read from an uploaded database
+	legacyImportMaxRecords = 1_000_000
+)
+
 // ActivityLog represents a record from the activity_log table in legacy databases.
 // It stores the timestamp of an activity event and whether the user was active (clock-in)
 // or inactive (clock-out) at that time.
 type ActivityLog struct {
 	Timestamp time.Time `json:"timestamp"` // Time of the activity event
 	Active    bool      `json:"active"`    // true = clock-in, false = clock-out
+	Table     string    `json:"table"`     // Legacy table the event was read from
+	Row       int       `json:"row"`       // Position of the event's row in the table ordered by time, starting at 1
+}
+
+// LegacyImportRejection describes an activity log that was not imported in partial mode.
+type LegacyImportRejection struct {
+	ActivityLog
+	Reason string `json:"reason"` // Why the activity log was not imported
+}
+
+// LegacyImportResult summarizes a partial legacy import.
+type LegacyImportResult struct {
+	Imported   int                     `json:"imported"`    // Number of imported activity logs
+	Rejected   []LegacyImportRejection `json:"rejected"`    // Activity logs that were not imported, sorted by time
+	MergedGaps int                     `json:"merged_gaps"` // Number of gaps between sessions that were merged
 }
 
 // RegisterLegacyImportAPI registers the legacy import endpoint with the PocketBase server.
 // It creates a POST route at '/api/legacy_import' that accepts database files for import.
-func RegisterLegacyImportAPI(app *pocketbase.PocketBase) {
+// With 'partial=true', valid records are imported even if others are invalid, and the response
+// contains the LegacyImportResult. The timestamps are normalized according to the optional
+// 'timezone', 'dst_correction' and 'round_to_minute' parameters, and sessions separated by gaps
+// shorter than the optional 'merge_gaps_seconds' are merged.
+func RegisterLegacyImportAPI(app core.App) {
 	app.OnServe().BindFunc(func(se *core.ServeEvent) error {
 		se.Router.POST("/api/legacy_import", func(e *core.RequestEvent) error {
 			return handleLegacyImportPost(app, e)
-		})
+		}).Bind(trackedRequest("legacy_import"))
 		return se.Next()
 	})
 }
@@ -48,18 +86,14 @@ func RegisterLegacyImportAPI(app *pocketbase.PocketBase) {
 // and responds with the results of the import operation.
 //
 // Parameters:
-// - app: The PocketBase application instance
+// - app: The App interface (typically a PocketBase instance or transaction)
 // - req: The HTTP request containing the multipart form with the database file
 // - resp: The HTTP response writer to return results to the client
 //
 // Returns an error if any part of the import process fails.
-func handleLegacyImportPost(app *pocketbase.PocketBase, e *core.RequestEvent) error {
-	// Max upload size of 50MB
-	const maxUploadSize = 50 * 1024 * 1024
-	e.Request.Body = http.MaxBytesReader(e.Response, e.Request.Body, maxUploadSize)
-
-	// Parse the multipart form (max 50MB in memory)
-	if err := e.Request.ParseMultipartForm(maxUploadSize); err != nil {
+func handleLegacyImportPost(app core.App, e *core.RequestEvent) error {
+	// Parse the multipart form (max 32MB in memory), the size of the request is limited by the request limits module
+	if err := e.Request.ParseMultipartForm(32 * 1024 * 1024); err != nil {
 		return e.Error(http.StatusBadRequest, "File too large or invalid multipart form", err)
 	}
 
@@ -76,19 +110,19 @@ func handleLegacyImportPost(app *pocketbase.PocketBase, e *core.RequestEvent) er
 			fmt.Errorf("invalid file extension: %s", filepath.Ext(header.Filename)))
 	}
 
-	// Create a temporary directory
+	// Create a temporary directory, removed with everything SQLite creates next to the database
 	tempDir, err := os.MkdirTemp("", "legacy_import_*")
 	if err != nil {
 		return e.Error(http.StatusInternalServerError, "Failed to create temporary directory", err)
 	}
+	defer os.RemoveAll(tempDir)
 
-	// Create a new file in the temp directory
-	tempFilePath := filepath.Join(tempDir, header.Filename)
+	// Create a new file in the temp directory, the uploaded file name is not trusted as a path
+	tempFilePath := filepath.Join(tempDir, "legacy.db")
 	tempFile, err := os.Create(tempFilePath)
 	if err != nil {
 		return e.Error(http.StatusInternalServerError, "Failed to create temporary file", err)
 	}
-	defer os.Remove(tempFilePath) // Clean up the temp file after processing
 	defer tempFile.Close()
 
 	// Copy the uploaded file to the temporary file
@@ -97,20 +131,74 @@ func handleLegacyImportPost(app *pocketbase.PocketBase, e *core.RequestEvent) er
 		return e.Error(http.StatusInternalServerError, "Failed to save uploaded file", err)
 	}
 
+	// Reject anything that isn't a plausible SQLite database before SQLite parses it
+	if err := validateSQLiteDatabase(tempFilePath, settings.ImportMaxBodySize); err != nil {
+		return e.Error(http.StatusBadRequest, fmt.Sprintf("Invalid database file: %v", err), err)
+	}
+
+	clockID, err := requestClock(app, e)
+	if err != nil {
+		return err
+	}
+
+	partial := false
+	if partialValue := e.Request.FormValue("partial"); partialValue != "" {
+		partial, err = parseBoolParam(partialValue, "partial")
+		if err != nil {
+			return e.Error(http.StatusBadRequest, err.Error(), nil)
+		}
+	}
+
+	normalization, err := parseActivityLogNormalization(e)
+	if err != nil {
+		return e.Error(http.StatusBadRequest, err.Error(), nil)
+	}
+
+	run := startImportRun(e, "legacy", header.Filename)
+
 	// Read activity logs from the database
-	activityLogs, err := readActivityLogs(tempFilePath)
+	_, readSpan := startSpan(e.Request.Context(), "legacy_import.read")
+	activityLogs, err := readActivityLogs(e.Request.Context(), tempFilePath)
+	readSpan.SetAttributes(attribute.Int("import.records", len(activityLogs)))
+	endSpan(readSpan, err)
 	if err != nil {
+		run.finish(app, err)
 		return e.Error(http.StatusInternalServerError,
 			fmt.Sprintf("Failed to read activity logs: %v", err), err)
 	}
 
+	activityLogs, mergedGaps := normalizeActivityLogs(activityLogs, normalization)
+
+	_, writeSpan := startSpan(e.Request.Context(), "legacy_import.write",
+		trace.WithAttributes(attribute.Bool("import.partial", partial)))
+	if partial {
+		result, err := importActivityLogsPartially(e.Request.Context(), app, clockID, run.ID, activityLogs)
+		endSpan(writeSpan, err)
+		if err != nil {
+			run.finish(app, err)
+			return e.Error(http.StatusInternalServerError,
+				fmt.Sprintf("Failed to import activity logs: %v", err), err)
+		}
+
+		result.MergedGaps = mergedGaps
+		run.Records = result.Imported
+		run.Skipped = len(result.Rejected)
+		run.finish(app, nil)
+		return e.JSON(http.StatusOK, result)
+	}
+
 	// Import activity logs into the PocketBase collection
-	err = importActivityLogs(app, activityLogs)
+	err = importActivityLogs(e.Request.Context(), app, clockID, run.ID, activityLogs)
+	endSpan(writeSpan, err)
 	if err != nil {
+		run.finish(app, err)
 		return e.Error(http.StatusInternalServerError,
 			fmt.Sprintf("Failed to import activity logs: %v", err), err)
 	}
 
+	run.Records = len(activityLogs)
+	run.finish(app, nil)
+
 	// Return success response
 	e.Response.Header().Set("Content-Type", "application/json")
 	e.Response.WriteHeader(http.StatusOK)
@@ -126,28 +214,50 @@ func handleLegacyImportPost(app *pocketbase.PocketBase, e *core.RequestEvent) er
 // and the 'ActiveChanges' table, combining results from both if they exist.
 //
 // Parameters:
+// - ctx: The context of the import, cancelling it aborts all queries
 // - dbPath: Path to the SQLite database file
 //
 // Returns:
 // - A slice of ActivityLog objects containing the extracted log data
-// - An error if the database could not be opened or queried, or if neither required table exists
-func readActivityLogs(dbPath string) ([]ActivityLog, error) {
+// - An error if the database could not be opened or queried, if it is corrupt, if neither required
+// table exists, or if reading exceeds legacyImportTimeout or legacyImportMaxRecords
+//
+// The database is uploaded by the user, so it may be corrupt or crafted. It is opened read-only
+// without trusting its schema, checked for corruption first, and every query is bounded by the
+// deadline; malformed values are reported as errors.
+func readActivityLogs(ctx context.Context, dbPath string) ([]ActivityLog, error) {
+	ctx, cancel := context.WithTimeout(ctx, legacyImportTimeout)
+	defer cancel()
+
+	dsn, err := readOnlySQLiteDSN(dbPath)
+	if err != nil {
+		return nil, err
+	}
+
 	// Open the SQLite database
-	db, err := sql.Open("sqlite", dbPath)
+	db, err := sql.Open("sqlite", dsn)
 	if err != nil {
 		return nil, fmt.Errorf("failed to open database: %w", err)
 	}
 	defer db.Close()
 
+	var integrity string
+	if err := db.QueryRowContext(ctx, "PRAGMA quick_check(1)").Scan(&integrity); err != nil {
+		return nil, fmt.Errorf("failed to check database integrity: %w", err)
+	}
+	if integrity != "ok" {
+		return nil, fmt.Errorf("database is corrupt: %s", integrity)
+	}
+
 	var activityLogs []ActivityLog
 
 	// Check if the activity_log table exists and read from it
-	activityLogExists, err := readActivityLogTable(db, &activityLogs)
+	activityLogExists, err := readActivityLogTable(ctx, db, &activityLogs)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read activity_log table: %w", err)
 	}
 
-	activeChangesExists, err := readActiveChangesTable(db, &activityLogs)
+	activeChangesExists, err := readActiveChangesTable(ctx, db, &activityLogs)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read ActiveChanges table: %w", err)
 	}
@@ -158,23 +268,102 @@ func readActivityLogs(dbPath string) ([]ActivityLog, error) {
 	return activityLogs, nil
 }
 
+// validateSQLiteDatabase checks that an uploaded file looks like a SQLite database before it is opened.
+//
+// Parameters:
+// - dbPath: Path to the uploaded file
+// - maxSize: The maximum size of the file in bytes
+//
+// Returns:
+// - An error if the file is empty, too large, or doesn't start with a valid SQLite header
+func validateSQLiteDatabase(dbPath string, maxSize int64) error {
+	file, err := os.Open(dbPath)
+	if err != nil {
+		return fmt.Errorf("failed to open file: %w", err)
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return fmt.Errorf("failed to read file info: %w", err)
+	}
+	if info.Size() > maxSize {
+		return fmt.Errorf("file is larger than %d bytes", maxSize)
+	}
+
+	// The database header is 100 bytes long, see https://www.sqlite.org/fileformat.html
+	header := make([]byte, 100)
+	if _, err := io.ReadFull(file, header); err != nil {
+		return fmt.Errorf("file is too short to be a SQLite database")
+	}
+	if !bytes.HasPrefix(header, []byte("SQLite format 3\x00")) {
+		return fmt.Errorf("file is not a SQLite database")
+	}
+
+	// The page size is a power of two between 512 and 32768, or 1 for 65536
+	pageSize := int64(binary.BigEndian.Uint16(header[16:18]))
+	if pageSize == 1 {
+		pageSize = 65536
+	}
+	if pageSize < 512 || pageSize&(pageSize-1) != 0 {
+		return fmt.Errorf("invalid page size %d", pageSize)
+	}
+	if info.Size()%pageSize != 0 {
+		return fmt.Errorf("file size is not a multiple of the page size %d", pageSize)
+	}
+
+	return nil
+}
+
+// readOnlySQLiteDSN builds the data source name to open an uploaded database. The database is opened
+// read-only, and functions or virtual tables referenced by its schema are not trusted.
+//
+// Parameters:
+// - dbPath: Path to the SQLite database file
+//
+// Returns:
+// - The data source name for the sqlite driver
+// - An error if the path can't be made absolute
+func readOnlySQLiteDSN(dbPath string) (string, error) {
+	absolutePath, err := filepath.Abs(dbPath)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve database path: %w", err)
+	}
+
+	path := filepath.ToSlash(absolutePath)
+	if path[0] != '/' {
+		// Windows paths like C:/... need a leading slash in file URIs
+		path = "/" + path
+	}
+
+	query := url.Values{}
+	query.Set("mode", "ro")
+	query.Add("_pragma", "query_only(1)")
+	query.Add("_pragma", "trusted_schema(0)")
+	query.Add("_pragma", "cell_size_check(1)")
+
+	dsn := url.URL{Scheme: "file", Path: path, RawQuery: query.Encode()}
+	return dsn.String(), nil
+}
+
 // readActivityLogTable reads activity logs from the 'activity_log' table in a legacy SQLite database.
 //
 // This function checks if the 'activity_log' table exists and extracts records if found.
 // Each record is converted to the ActivityLog format and appended to the result slice.
 //
 // Parameters:
+// - ctx: The context bounding the queries
 // - db: An open SQLite database connection
 // - result: A pointer to the slice where ActivityLog entries will be appended
 //
 // Returns:
 // - A boolean indicating whether the table exists in the database
-// - An error if querying the database fails
+// - An error if querying the database fails or the table has too many records
 //
 // The function expects the table to have timestamp (nanoseconds since epoch)
 // and active (integer boolean) columns.
-func readActivityLogTable(db *sql.DB, result *[]ActivityLog) (bool, error) {
-	existsRows, err := db.Query("SELECT name FROM sqlite_master WHERE type='table' AND name='activity_log';")
+func readActivityLogTable(ctx context.Context, db *sql.DB, result *[]ActivityLog) (bool, error) {
+	existsRows, err := db.QueryContext(ctx, "SELECT name FROM sqlite_master WHERE type='table' AND name='activity_log';")
 	if err != nil {
 		return false, fmt.Errorf("failed to query existing tables: %w", err)
 	}
@@ -185,14 +374,20 @@ func readActivityLogTable(db *sql.DB, result *[]ActivityLog) (bool, error) {
 	}
 
 	// Query all records from the activity_log table
-	rows, err := db.Query("SELECT timestamp, active FROM activity_log ORDER BY timestamp")
+	rows, err := db.QueryContext(ctx, "SELECT timestamp, active FROM activity_log ORDER BY timestamp")
 	if err != nil {
 		return true, fmt.Errorf("failed to query activity logs: %w", err)
 	}
 	defer rows.Close()
 
+	row := 0
+
 	// Iterate through the results
 	for rows.Next() {
+		if len(*result) >= legacyImportMaxRecords {
+			return true, fmt.Errorf("more than %d records", legacyImportMaxRecords)
+		}
+
 		var timestampNano int64
 		var activeInt int
 
@@ -208,9 +403,12 @@ func readActivityLogTable(db *sql.DB, result *[]ActivityLog) (bool, error) {
 		active := activeInt != 0
 
 		// Append to results
+		row++
 		*result = append(*result, ActivityLog{
 			Timestamp: timestamp,
 			Active:    active,
+			Table:     "activity_log",
+			Row:       row,
 		})
 	}
 
@@ -230,17 +428,18 @@ func readActivityLogTable(db *sql.DB, result *[]ActivityLog) (bool, error) {
 // and appended to the result slice.
 //
 // Parameters:
+// - ctx: The context bounding the queries
 // - db: An open SQLite database connection
 // - result: A pointer to the slice where ActivityLog entries will be appended
 //
 // Returns:
 // - A boolean indicating whether the table exists in the database
-// - An error if querying the database fails
+// - An error if querying the database fails or the table has too many records
 //
 // The function expects the table to have Time (nanoseconds since epoch),
 // Active (integer boolean) and StartEnd (integer) columns.
-func readActiveChangesTable(db *sql.DB, result *[]ActivityLog) (bool, error) {
-	existsRows, err := db.Query("SELECT name FROM sqlite_master WHERE type='table' AND name='ActiveChanges';")
+func readActiveChangesTable(ctx context.Context, db *sql.DB, result *[]ActivityLog) (bool, error) {
+	existsRows, err := db.QueryContext(ctx, "SELECT name FROM sqlite_master WHERE type='table' AND name='ActiveChanges';")
 	if err != nil {
 		return false, fmt.Errorf("failed to query existing tables: %w", err)
 	}
@@ -251,7 +450,7 @@ func readActiveChangesTable(db *sql.DB, result *[]ActivityLog) (bool, error) {
 	}
 
 	// Query all records from the ActiveChanges table
-	rows, err := db.Query("SELECT Time, Active, StartEnd FROM ActiveChanges ORDER BY Time ASC, Active DESC, StartEnd DESC")
+	rows, err := db.QueryContext(ctx, "SELECT Time, Active, StartEnd FROM ActiveChanges ORDER BY Time ASC, Active DESC, StartEnd DESC")
 	if err != nil {
 		return true, fmt.Errorf("failed to query active changes: %w", err)
 	}
@@ -261,13 +460,20 @@ func readActiveChangesTable(db *sql.DB, result *[]ActivityLog) (bool, error) {
 		Timestamp time.Time
 		Active    bool
 		IsSystem  bool
+		Row       int
 	}
 
 	var activeChanges [][]ActiveChange
 	var activeChangesGroup []ActiveChange
+	rowCount := len(*result)
+	row := 0
 
 	// Iterate through the results
 	for rows.Next() {
+		if rowCount++; rowCount > legacyImportMaxRecords {
+			return true, fmt.Errorf("more than %d records", legacyImportMaxRecords)
+		}
+
 		var timestampNano int64
 		var activeInt int
 		var systemInt int
@@ -277,10 +483,12 @@ func readActiveChangesTable(db *sql.DB, result *[]ActivityLog) (bool, error) {
 			return true, fmt.Errorf("failed to scan row: %w", err)
 		}
 
+		row++
 		entry := ActiveChange{
 			Timestamp: time.Unix(0, timestampNano),
 			Active:    activeInt != 0,
 			IsSystem:  systemInt != 0,
+			Row:       row,
 		}
 
 		if len(activeChangesGroup) > 0 && entry.Active && entry.IsSystem {
@@ -352,6 +560,8 @@ func readActiveChangesTable(db *sql.DB, result *[]ActivityLog) (bool, error) {
 			*result = append(*result, ActivityLog{
 				Timestamp: entry.Timestamp,
 				Active:    entry.Active,
+				Table:     "ActiveChanges",
+				Row:       entry.Row,
 			})
 		}
 	}
@@ -362,7 +572,10 @@ func readActiveChangesTable(db *sql.DB, result *[]ActivityLog) (bool, error) {
 // importActivityLogs imports activity logs into the PocketBase work_clock collection.
 //
 // Parameters:
-// - app: The PocketBase application instance
+// - ctx: The context cancelling the operation, e.g. of the request
+// - app: The App interface (typically a PocketBase instance or transaction)
+// - clockID: The ID of the clock the logs are imported into, an empty string for the default clock
+// - importRunID: The ID of the import run, the created records are tagged with
 // - logs: A slice of ActivityLog objects to import
 //
 // Returns:
@@ -373,11 +586,11 @@ func readActiveChangesTable(db *sql.DB, result *[]ActivityLog) (bool, error) {
 // - ActivityLog.Timestamp -> work_clock.timestamp
 // - ActivityLog.Active -> work_clock.clock_in
 //
-// It uses addManyWorkClockRecords to import the logs in a single transaction,
+// It uses AddManyRecords to import the logs in a single transaction,
 // ensuring data consistency and proper validation of the clock in/out sequence.
 // All logs are processed as a single unit, and the transaction will roll back
 // if any record violates the validation rules.
-func importActivityLogs(app *pocketbase.PocketBase, logs []ActivityLog) error {
+func importActivityLogs(ctx context.Context, app core.App, clockID string, importRunID string, logs []ActivityLog) error {
 	clockInTimestamps := make([]time.Time, 0, len(logs))
 	clockOutTimestamps := make([]time.Time, 0, len(logs))
 
@@ -389,9 +602,84 @@ func importActivityLogs(app *pocketbase.PocketBase, logs []ActivityLog) error {
 		}
 	}
 
-	if err := addManyWorkClockRecords(app, clockInTimestamps, clockOutTimestamps); err != nil {
+	if err := workClockServiceOf(app).WithContext(ctx).AddManyRecords(clockID, importRunID, clockInTimestamps, clockOutTimestamps); err != nil {
 		return fmt.Errorf("failed to add work clock records: %w", err)
 	}
 
 	return nil
 }
+
+// importActivityLogsPartially imports the valid activity logs into the PocketBase work_clock
+// collection and reports the invalid ones.
+//
+// Parameters:
+// - ctx: The context cancelling the operation, e.g. of the request
+// - app: The App interface (typically a PocketBase instance or transaction)
+// - clockID: The ID of the clock the logs are imported into, an empty string for the default clock
+// - importRunID: The ID of the import run, the created records are tagged with
+// - logs: A slice of ActivityLog objects to import
+//
+// Returns:
+// - The summary of the import
+// - An error if finding the collection or querying the records fails, in which case nothing is imported
+//
+// The logs are added in chronological order within a single transaction. Each added record is
+// validated against its neighbors right away and removed again if it breaks the alternation of
+// clock in and out records, so the following logs are validated against the valid ones only.
+func importActivityLogsPartially(ctx context.Context, app core.App, clockID string, importRunID string, logs []ActivityLog) (LegacyImportResult, error) {
+	unlock, err := workClockServiceOf(app).WithContext(ctx).lock(clockID)
+	if err != nil {
+		return LegacyImportResult{}, err
+	}
+	defer unlock()
+
+	logs = slices.Clone(logs)
+	slices.SortStableFunc(logs, func(a, b ActivityLog) int {
+		return a.Timestamp.Compare(b.Timestamp)
+	})
+
+	var result LegacyImportResult
+	err = app.RunInTransaction(func(txApp core.App) error {
+		result = LegacyImportResult{Rejected: []LegacyImportRejection{}}
+
+		collection, err := txApp.FindCollectionByNameOrId("work_clock")
+		if err != nil {
+			return fmt.Errorf("failed to find work clock collection: %w", err)
+		}
+
+		importedIDs := map[string]bool{}
+		for _, log := range logs {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+
+			record, err := createImportedWorkClockRecord(txApp, collection, clockID, importRunID, log.Timestamp, log.Active)
+			if err != nil {
+				result.Rejected = append(result.Rejected, LegacyImportRejection{ActivityLog: log, Reason: err.Error()})
+				continue
+			}
+			if record.GetString("import_run") != importRunID || importedIDs[record.Id] {
+				result.Rejected = append(result.Rejected, LegacyImportRejection{ActivityLog: log, Reason: "the record already exists"})
+				continue
+			}
+
+			if err := checkValidity(txApp, record.Id); err != nil {
+				if deleteErr := txApp.Delete(record); deleteErr != nil {
+					return fmt.Errorf("failed to remove invalid work clock record at time '%s': %w", log.Timestamp.Format(time.RFC3339), deleteErr)
+				}
+				result.Rejected = append(result.Rejected, LegacyImportRejection{ActivityLog: log, Reason: err.Error()})
+				continue
+			}
+
+			importedIDs[record.Id] = true
+			result.Imported++
+		}
+
+		return nil
+	})
+	if err != nil {
+		return LegacyImportResult{}, fmt.Errorf("failed to import activity logs partially: %w", err)
+	}
+
+	return result, nil
+}
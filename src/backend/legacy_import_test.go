@@ -0,0 +1,222 @@
+package backend
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/yerTools/simple-frontend-stack/src/backend/backendtest"
+)
+
+// createLegacyDatabase creates a legacy SQLite database with the given statements.
+func createLegacyDatabase(tb testing.TB, statements ...string) string {
+	tb.Helper()
+
+	dbPath := filepath.Join(tb.TempDir(), "legacy.db")
+	db, err := sql.Open("sqlite", dbPath)
+	if err != nil {
+		tb.Fatalf("failed to create legacy database: %v", err)
+	}
+	defer db.Close()
+
+	for _, statement := range statements {
+		if _, err := db.Exec(statement); err != nil {
+			tb.Fatalf("failed to execute '%s': %v", statement, err)
+		}
+	}
+
+	return dbPath
+}
+
+func TestReadActivityLogs(t *testing.T) {
+	clockIn := backendtest.MustParseTime("2025-04-01T09:00:00Z")
+	clockOut := backendtest.MustParseTime("2025-04-01T17:00:00Z")
+
+	dbPath := createLegacyDatabase(t,
+		"CREATE TABLE activity_log (timestamp INTEGER, active INTEGER)",
+		fmt.Sprintf("INSERT INTO activity_log VALUES (%d, 0)", clockOut.UnixNano()),
+		fmt.Sprintf("INSERT INTO activity_log VALUES (%d, 1)", clockIn.UnixNano()),
+	)
+
+	logs, err := readActivityLogs(t.Context(), dbPath)
+	if err != nil {
+		t.Fatalf("failed to read activity logs: %v", err)
+	}
+
+	if len(logs) != 2 {
+		t.Fatalf("expected 2 activity logs, got %d", len(logs))
+	}
+	if !logs[0].Timestamp.Equal(clockIn) || !logs[0].Active {
+		t.Errorf("expected the first log to be the clock in at %s, got %+v", clockIn, logs[0])
+	}
+	if !logs[1].Timestamp.Equal(clockOut) || logs[1].Active {
+		t.Errorf("expected the second log to be the clock out at %s, got %+v", clockOut, logs[1])
+	}
+}
+
+func TestValidateSQLiteDatabase(t *testing.T) {
+	if err := validateSQLiteDatabase(createLegacyDatabase(t, "CREATE TABLE activity_log (timestamp INTEGER, active INTEGER)"), settings.ImportMaxBodySize); err != nil {
+		t.Fatalf("expected a SQLite database to be accepted, got: %v", err)
+	}
+
+	notADatabase := filepath.Join(t.TempDir(), "legacy.db")
+	if err := os.WriteFile(notADatabase, []byte(strings.Repeat("not a database", 100)), 0o600); err != nil {
+		t.Fatalf("failed to write file: %v", err)
+	}
+	if err := validateSQLiteDatabase(notADatabase, settings.ImportMaxBodySize); err == nil {
+		t.Fatal("expected a file without SQLite header to be rejected")
+	}
+}
+
+func TestReadActivityLogsWithoutTables(t *testing.T) {
+	dbPath := createLegacyDatabase(t, "CREATE TABLE unrelated (id INTEGER)")
+
+	if _, err := readActivityLogs(t.Context(), dbPath); err == nil {
+		t.Fatal("expected a database without legacy tables to be rejected")
+	}
+}
+
+func TestImportActivityLogs(t *testing.T) {
+	app := backendtest.NewApp(t)
+
+	logs := []ActivityLog{
+		{Timestamp: backendtest.MustParseTime("2025-04-01T09:00:00Z"), Active: true},
+		{Timestamp: backendtest.MustParseTime("2025-04-01T17:00:00Z"), Active: false},
+		{Timestamp: backendtest.MustParseTime("2025-04-02T09:00:00Z"), Active: true},
+	}
+
+	if err := importActivityLogs(t.Context(), app, "", "", logs); err != nil {
+		t.Fatalf("failed to import activity logs: %v", err)
+	}
+
+	if records := backendtest.Records(t, app); len(records) != 3 {
+		t.Fatalf("expected 3 records, got %d", len(records))
+	}
+	backendtest.AssertAlternating(t, app)
+}
+
+func TestImportActivityLogsRollsBackInvalidSequences(t *testing.T) {
+	app := backendtest.NewApp(t)
+
+	logs := []ActivityLog{
+		{Timestamp: backendtest.MustParseTime("2025-04-01T09:00:00Z"), Active: true},
+		{Timestamp: backendtest.MustParseTime("2025-04-01T10:00:00Z"), Active: true},
+		{Timestamp: backendtest.MustParseTime("2025-04-01T17:00:00Z"), Active: false},
+	}
+
+	if err := importActivityLogs(t.Context(), app, "", "", logs); err == nil {
+		t.Fatal("expected two clock ins in a row to be rejected")
+	}
+
+	if records := backendtest.Records(t, app); len(records) != 0 {
+		t.Fatalf("expected the import to be rolled back, got %d records", len(records))
+	}
+}
+
+func TestImportActivityLogsPartially(t *testing.T) {
+	app := backendtest.NewApp(t)
+
+	logs := []ActivityLog{
+		{Timestamp: backendtest.MustParseTime("2025-04-01T09:00:00Z"), Active: true, Table: "activity_log", Row: 1},
+		{Timestamp: backendtest.MustParseTime("2025-04-01T10:00:00Z"), Active: true, Table: "activity_log", Row: 2},
+		{Timestamp: backendtest.MustParseTime("2025-04-01T17:00:00Z"), Active: false, Table: "activity_log", Row: 3},
+	}
+
+	result, err := importActivityLogsPartially(t.Context(), app, "", "", logs)
+	if err != nil {
+		t.Fatalf("failed to import activity logs: %v", err)
+	}
+
+	if result.Imported != 2 {
+		t.Errorf("expected 2 imported activity logs, got %d", result.Imported)
+	}
+	if len(result.Rejected) != 1 || result.Rejected[0].Row != 2 {
+		t.Fatalf("expected the second clock in to be rejected, got %+v", result.Rejected)
+	}
+
+	if records := backendtest.Records(t, app); len(records) != 2 {
+		t.Fatalf("expected 2 records, got %d", len(records))
+	}
+	backendtest.AssertAlternating(t, app)
+}
+
+func FuzzReadActivityLogs(f *testing.F) {
+	seeds := [][]string{
+		{
+			"CREATE TABLE activity_log (timestamp INTEGER, active INTEGER)",
+			"INSERT INTO activity_log VALUES (1743498000000000000, 1), (1743526800000000000, 0)",
+		},
+		{
+			"CREATE TABLE ActiveChanges (Time INTEGER, Active INTEGER, StartEnd INTEGER)",
+			"INSERT INTO ActiveChanges VALUES (1743498000000000000, 1, 1), (1743526800000000000, 0, 0)",
+		},
+		{
+			"CREATE TABLE activity_log (timestamp TEXT, active TEXT)",
+			"INSERT INTO activity_log VALUES ('yesterday', NULL)",
+		},
+	}
+	for _, statements := range seeds {
+		data, err := os.ReadFile(createLegacyDatabase(f, statements...))
+		if err != nil {
+			f.Fatalf("failed to read seed database: %v", err)
+		}
+		f.Add(data)
+	}
+	f.Add([]byte{})
+	f.Add([]byte("SQLite format 3\x00"))
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		dbPath := filepath.Join(t.TempDir(), "legacy.db")
+		if err := os.WriteFile(dbPath, data, 0o600); err != nil {
+			t.Fatalf("failed to write database: %v", err)
+		}
+
+		// Malformed databases must be rejected with an error, never crash or hang
+		if err := validateSQLiteDatabase(dbPath, settings.ImportMaxBodySize); err != nil {
+			return
+		}
+
+		logs, err := readActivityLogs(t.Context(), dbPath)
+		if err == nil && len(logs) > legacyImportMaxRecords {
+			t.Fatalf("expected at most %d records, got %d", legacyImportMaxRecords, len(logs))
+		}
+	})
+}
+
+func FuzzReadActiveChanges(f *testing.F) {
+	f.Add([]byte{0, 1, 1, 60, 0, 0})
+	f.Add([]byte{0, 1, 1, 0, 1, 0, 30, 0, 0, 30, 0, 0, 10, 1, 1})
+	f.Add([]byte{5, 0, 0, 0, 1, 1, 0, 1, 1})
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		// Every three bytes describe a row: minutes since the previous row, Active and StartEnd
+		statements := []string{"CREATE TABLE ActiveChanges (Time INTEGER, Active INTEGER, StartEnd INTEGER)"}
+		timestamp := backendtest.MustParseTime("2025-04-01T00:00:00Z")
+		var values []string
+		for i := 0; i+2 < len(data) && len(values) < 1000; i += 3 {
+			timestamp = timestamp.Add(time.Duration(data[i]) * time.Minute)
+			values = append(values, fmt.Sprintf("(%d, %d, %d)", timestamp.UnixNano(), data[i+1]%2, data[i+2]%2))
+		}
+		if len(values) > 0 {
+			statements = append(statements, "INSERT INTO ActiveChanges VALUES "+strings.Join(values, ", "))
+		}
+
+		logs, err := readActivityLogs(t.Context(), createLegacyDatabase(t, statements...))
+		if err != nil {
+			t.Fatalf("failed to read well-formed active changes: %v", err)
+		}
+
+		// The work clock has a unique timestamp index, so duplicates would always fail the import
+		seen := map[int64]bool{}
+		for _, log := range logs {
+			if seen[log.Timestamp.UnixMilli()] {
+				t.Fatalf("duplicate timestamp %s in %d records read from %d rows", log.Timestamp, len(logs), len(values))
+			}
+			seen[log.Timestamp.UnixMilli()] = true
+		}
+	})
+}
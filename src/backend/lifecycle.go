@@ -0,0 +1,184 @@
+// Lifecycle Module for PocketBase
+//
+// This module coordinates the shutdown of the work that outlives a single clock operation, so a
+// container restart does not cut off an import halfway or lose the delivery of an event. It tracks
+// three kinds of jobs:
+// - Import requests, which are rejected with 503 once the shutdown started (see trackedRequest)
+// - Scheduled jobs, which are skipped once the shutdown started (see cronJob)
+// - Background deliveries of webhooks and other integrations, which are always accepted, since
+// a finishing import may still cause events (see goJob)
+//
+// On SIGTERM, PocketBase stops accepting connections and cancels the context of all requests,
+// which closes realtime (SSE) connections and aborts imports that are still reading their upload.
+// The jobs that are already writing are then given up to SHUTDOWN_GRACE_PERIOD to finish before
+// the database is closed. Imports write in a single transaction, so an import that still does not
+// finish in time is rolled back completely instead of being left half-committed.
+package backend
+
+import (
+	"net/http"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tools/hook"
+)
+
+// backgroundJobs tracks the running jobs of the application.
+var backgroundJobs = newJobTracker()
+
+// jobTracker tracks running jobs, so the shutdown can wait for them.
+type jobTracker struct {
+	mu       sync.Mutex
+	draining bool             // Whether the shutdown started
+	nextID   int64            // ID of the next started job
+	running  map[int64]string // Names of the running jobs by their ID
+	finished chan struct{}    // Closed and replaced whenever a job finishes
+}
+
+// newJobTracker creates a tracker without running jobs.
+func newJobTracker() *jobTracker {
+	return &jobTracker{running: map[int64]string{}, finished: make(chan struct{})}
+}
+
+// RegisterLifecycleHooks registers the draining of the running jobs on termination with the PocketBase server.
+//
+// Parameters:
+// - app: The PocketBase application instance
+func RegisterLifecycleHooks(app core.App) {
+	app.OnTerminate().BindFunc(func(e *core.TerminateEvent) error {
+		unfinished := backgroundJobs.drain(settings.ShutdownGracePeriod)
+		if len(unfinished) > 0 {
+			app.Logger().Warn("shutdown grace period exceeded, aborting unfinished jobs", "jobs", unfinished)
+		}
+		return e.Next()
+	})
+}
+
+// start registers a job unless the shutdown started.
+//
+// Parameters:
+// - name: The name of the job, used to report unfinished jobs
+//
+// Returns:
+// - The function to call when the job finished
+// - Whether the job may run
+func (t *jobTracker) start(name string) (func(), bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.draining {
+		return nil, false
+	}
+	return t.add(name), true
+}
+
+// add registers a job, the caller must hold the lock.
+func (t *jobTracker) add(name string) func() {
+	id := t.nextID
+	t.nextID++
+	t.running[id] = name
+
+	return func() {
+		t.mu.Lock()
+		defer t.mu.Unlock()
+
+		delete(t.running, id)
+		close(t.finished)
+		t.finished = make(chan struct{})
+	}
+}
+
+// goJob runs a job in the background. Unlike start, it also accepts jobs during the shutdown.
+//
+// Parameters:
+// - name: The name of the job, used to report unfinished jobs
+// - job: The function to run
+func (t *jobTracker) goJob(name string, job func()) {
+	t.mu.Lock()
+	done := t.add(name)
+	t.mu.Unlock()
+
+	go func() {
+		defer done()
+		job()
+	}()
+}
+
+// cronJob wraps a scheduled job, so it is tracked and skipped once the shutdown started.
+//
+// Parameters:
+// - name: The name of the job, used to report unfinished jobs
+// - job: The function to run on schedule
+//
+// Returns:
+// - The function to schedule
+func (t *jobTracker) cronJob(name string, job func()) func() {
+	return func() {
+		done, ok := t.start(name)
+		if !ok {
+			return
+		}
+		defer done()
+		job()
+	}
+}
+
+// drain starts the shutdown and waits for the running jobs.
+//
+// Parameters:
+// - timeout: The maximum duration to wait
+//
+// Returns:
+// - The sorted names of the jobs still running after the timeout, empty if all jobs finished
+func (t *jobTracker) drain(timeout time.Duration) []string {
+	deadline := time.NewTimer(timeout)
+	defer deadline.Stop()
+
+	t.mu.Lock()
+	t.draining = true
+	for len(t.running) > 0 {
+		finished := t.finished
+		t.mu.Unlock()
+
+		select {
+		case <-finished:
+		case <-deadline.C:
+			t.mu.Lock()
+			names := make([]string, 0, len(t.running))
+			for _, name := range t.running {
+				names = append(names, name)
+			}
+			t.mu.Unlock()
+			slices.Sort(names)
+			return names
+		}
+
+		t.mu.Lock()
+	}
+	t.mu.Unlock()
+
+	return nil
+}
+
+// trackedRequest creates a middleware tracking a request as job, so the shutdown waits for it.
+// Requests arriving after the shutdown started are rejected.
+//
+// Parameters:
+// - name: The name of the job, e.g. 'legacy_import'
+//
+// Returns:
+// - The middleware handler
+func trackedRequest(name string) *hook.Handler[*core.RequestEvent] {
+	return &hook.Handler[*core.RequestEvent]{
+		Func: func(e *core.RequestEvent) error {
+			done, ok := backgroundJobs.start(name)
+			if !ok {
+				return e.Error(http.StatusServiceUnavailable, "The server is shutting down, please retry later", nil)
+			}
+			defer done()
+			return e.Next()
+		},
+	}
+}
@@ -0,0 +1,39 @@
+package backend
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestJobTrackerDrain(t *testing.T) {
+	tracker := newJobTracker()
+
+	release := make(chan struct{})
+	tracker.goJob("webhook", func() { <-release })
+	done, ok := tracker.start("legacy_import")
+	if !ok {
+		t.Fatal("expected jobs to start before the shutdown")
+	}
+
+	if unfinished := tracker.drain(10 * time.Millisecond); !slices.Equal(unfinished, []string{"legacy_import", "webhook"}) {
+		t.Errorf("expected both jobs to be unfinished, got %v", unfinished)
+	}
+
+	if _, ok := tracker.start("instance_import"); ok {
+		t.Error("expected jobs to be rejected during the shutdown")
+	}
+	ran := false
+	tracker.cronJob("push_reminders", func() { ran = true })()
+	if ran {
+		t.Error("expected scheduled jobs to be skipped during the shutdown")
+	}
+
+	go func() {
+		done()
+		close(release)
+	}()
+	if unfinished := tracker.drain(time.Second); len(unfinished) != 0 {
+		t.Errorf("expected all jobs to finish, got %v", unfinished)
+	}
+}
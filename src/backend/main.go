@@ -6,12 +6,15 @@ import (
 	"os"
 
 	"github.com/pocketbase/pocketbase"
-	"github.com/pocketbase/pocketbase/apis"
 	"github.com/pocketbase/pocketbase/core"
 	"github.com/pocketbase/pocketbase/plugins/migratecmd"
 )
 
 func Main(isGoRun bool, dist fs.FS) {
+	if settingsError != nil {
+		log.Fatalf("invalid configuration:\n%v", settingsError)
+	}
+
 	app := pocketbase.New()
 
 	migratecmd.MustRegister(app, app.RootCmd, migratecmd.Config{
@@ -21,6 +24,8 @@ func Main(isGoRun bool, dist fs.FS) {
 
 		Dir: "pb_data/../src/backend/migrations",
 	})
+	RegisterDoctor(app)
+	RegisterWorkClockService(app, NewWorkClockService(app).WithClock(defaultClock()))
 
 	var fsList FSList
 	if app.IsDev() {
@@ -29,20 +34,86 @@ func Main(isGoRun bool, dist fs.FS) {
 		fsList = append(fsList, dist, os.DirFS("pb_public"))
 	}
 
-	app.OnServe().BindFunc(func(se *core.ServeEvent) error {
-		se.Router.GET("/{path...}", apis.Static(fsList, true))
-
-		return se.Next()
-	})
-
-	RegisterLegacyImportAPI(app)
-	RegisterWorkClockAPI(app)
+	RegisterFrontend(app, fsList)
+	RegisterAPIs(app)
 
 	if err := app.Start(); err != nil {
 		log.Fatal(err)
 	}
 }
 
+// RegisterAPIs registers the endpoints and hooks of all backend modules with the PocketBase server.
+//
+// Parameters:
+// - app: The PocketBase application instance
+func RegisterAPIs(app core.App) {
+	RegisterLifecycleHooks(app)
+	RegisterTracingHooks(app)
+	RegisterRequestLimitHooks(app)
+	RegisterDelegationHooks(app)
+	RegisterAPITokensAPI(app)
+	RegisterI18nHooks(app)
+	RegisterEpochFieldsHooks(app)
+	RegisterConditionalRequestHooks(app)
+	RegisterReportCacheHooks(app)
+	RegisterClockEventHooks(app)
+	RegisterIntegrationsAPI(app)
+	RegisterFeaturesAPI(app)
+	RegisterAdminOverviewAPI(app)
+	RegisterSupportCorrectionsAPI(app)
+	RegisterTeamsAPI(app)
+	RegisterPresenceAPI(app)
+	RegisterEventsAPI(app)
+	RegisterWebhookHooks(app)
+	RegisterWebhookDeliveriesAPI(app)
+	RegisterSlackHooks(app)
+	RegisterMQTTHooks(app)
+	RegisterPushHooks(app)
+	RegisterMatrixHooks(app)
+	RegisterLegacyImportAPI(app)
+	RegisterInstanceImportAPI(app)
+	RegisterImportHistoryAPI(app)
+	RegisterWorkClockLedgerAPI(app)
+	RegisterWorkClockAPI(app)
+	RegisterWorkClockStatusAPI(app)
+	RegisterStatusBadgeAPI(app)
+	RegisterWorkClockSessionsAPI(app)
+	RegisterWorkClockMoveAPI(app)
+	RegisterWorkClockDayAPI(app)
+	RegisterJournalAPI(app)
+	RegisterSearchAPI(app)
+	RegisterWorkClockTemplatesAPI(app)
+	RegisterProjectsAPI(app)
+	RegisterCostCentersAPI(app)
+	RegisterTagsAPI(app)
+	RegisterIssuesAPI(app)
+	RegisterCategoriesAPI(app)
+	RegisterDailySummaryAPI(app)
+	RegisterWorkScheduleHooks(app)
+	RegisterEmploymentHooks(app)
+	RegisterAbsencesAPI(app)
+	RegisterVacationAPI(app)
+	RegisterComplianceHooks(app)
+	RegisterDailySummaryFeedAPI(app)
+	RegisterReportCompareAPI(app)
+	RegisterForecastAPI(app)
+	RegisterMissingDaysAPI(app)
+	RegisterWeekdayStatsAPI(app)
+	RegisterReportBuilderAPI(app)
+	RegisterGrafanaAPI(app)
+	RegisterSavedReportsAPI(app)
+	RegisterExpensesAPI(app)
+	RegisterRecordCommentsAPI(app)
+	RegisterCalendarAPI(app)
+	RegisterEmailGatewayAPI(app)
+	RegisterShortcutsAPI(app)
+	RegisterCompactAPI(app)
+	RegisterExportAPI(app)
+	RegisterExportTemplates(app)
+	RegisterScheduledExports(app)
+	RegisterSignedExportAPI(app)
+}
+
 type FSList []fs.FS
 
 func (f FSList) Open(name string) (fs.File, error) {
@@ -0,0 +1,34 @@
+// Markdown Module for PocketBase
+//
+// This module renders the Markdown of day notes to HTML with goldmark, following CommonMark.
+// Raw HTML within the notes is omitted and links with dangerous schemes (e.g. "javascript:")
+// are rendered without their target, so the rendered HTML can be inserted into a page without
+// sanitizing it again.
+package backend
+
+import (
+	"bytes"
+	"html"
+
+	"github.com/yuin/goldmark"
+	goldmarkhtml "github.com/yuin/goldmark/renderer/html"
+)
+
+// markdownRenderer renders Markdown to XHTML, omitting raw HTML.
+var markdownRenderer = goldmark.New(goldmark.WithRendererOptions(goldmarkhtml.WithXHTML()))
+
+// renderMarkdown renders Markdown to HTML.
+//
+// Parameters:
+// - text: The Markdown text
+//
+// Returns:
+// - The HTML, safe to be inserted into a page
+func renderMarkdown(text string) string {
+	var rendered bytes.Buffer
+	if err := markdownRenderer.Convert([]byte(text), &rendered); err != nil {
+		// Rendering into a buffer doesn't fail, the escaped text is a safe fallback nonetheless
+		return "<p>" + html.EscapeString(text) + "</p>\n"
+	}
+	return rendered.String()
+}
@@ -0,0 +1,50 @@
+package backend
+
+import "testing"
+
+func TestRenderMarkdown(t *testing.T) {
+	tests := []struct {
+		name     string
+		markdown string
+		expected string
+	}{
+		{
+			name:     "paragraphs",
+			markdown: "First line\nsecond line\n\nNext paragraph",
+			expected: "<p>First line\nsecond line</p>\n<p>Next paragraph</p>\n",
+		},
+		{
+			name:     "heading and list",
+			markdown: "## Done\n- **Release** notes\n- *Review*",
+			expected: "<h2>Done</h2>\n<ul>\n<li><strong>Release</strong> notes</li>\n<li><em>Review</em></li>\n</ul>\n",
+		},
+		{
+			name:     "ordered list",
+			markdown: "1. First\n2. Second",
+			expected: "<ol>\n<li>First</li>\n<li>Second</li>\n</ol>\n",
+		},
+		{
+			name:     "code",
+			markdown: "Run `make **all**`\n```\n<b>x</b>\n```",
+			expected: "<p>Run <code>make **all**</code></p>\n<pre><code>&lt;b&gt;x&lt;/b&gt;\n</code></pre>\n",
+		},
+		{
+			name:     "links",
+			markdown: "[Ticket](https://example.com/1?a=1&b=2) [bad](javascript:alert(1))",
+			expected: "<p><a href=\"https://example.com/1?a=1&amp;b=2\">Ticket</a> <a href=\"\">bad</a></p>\n",
+		},
+		{
+			name:     "html is omitted",
+			markdown: "<script>alert('x')</script>\n\nA <b onclick=\"alert(1)\">bold</b> line<br>",
+			expected: "<!-- raw HTML omitted -->\n<p>A <!-- raw HTML omitted -->bold<!-- raw HTML omitted --> line<!-- raw HTML omitted --></p>\n",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if html := renderMarkdown(test.markdown); html != test.expected {
+				t.Errorf("expected %q, got %q", test.expected, html)
+			}
+		})
+	}
+}
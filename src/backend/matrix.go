@@ -0,0 +1,437 @@
+// Matrix Module for PocketBase
+//
+// This module connects the work clock to a Matrix room (https://matrix.org), so the default clock
+// can be operated from any Matrix client and the day is summarized in the chat. It is configured
+// and enabled through the integrations API with the access token of a bot account that joined
+// the room:
+//
+//	{
+//	  "homeserver": "https://matrix.example.com",
+//	  "access_token": "syt_...",
+//	  "room_id": "!abcdef:example.com",
+//	  "allowed_users": ["@alice:example.com"],
+//	  "summary_time": "18:00"
+//	}
+//
+// The following commands are accepted in the room:
+// - "!clock in", "!clock out" and "!clock toggle" - Clocks the default clock in or out
+// - "!clock status" - Replies with the current state of the default clock
+// - "!clock summary" - Replies with the summary of today
+//
+// Only the users in 'allowed_users' may clock, an empty list allows every member of the room.
+// The summary of the day is posted at the optional 'summary_time' (HH:MM), days without sessions
+// are skipped. The room is read with long-polling syncs while the integration is enabled; commands
+// sent while the server was offline are ignored.
+package backend
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/pocketbase/pocketbase/core"
+)
+
+// matrixSyncTimeout is the duration the homeserver holds a sync request open without new events.
+const matrixSyncTimeout = 30 * time.Second
+
+// matrixRetryDelay is the delay before the next sync after a failed sync or while the integration is disabled.
+const matrixRetryDelay = 30 * time.Second
+
+// MatrixConfig is the configuration of the Matrix integration.
+type MatrixConfig struct {
+	Homeserver   string   `json:"homeserver"`    // URL of the homeserver of the bot account
+	AccessToken  string   `json:"access_token"`  // Access token of the bot account
+	RoomID       string   `json:"room_id"`       // ID of the room the bot reads commands from and posts to
+	AllowedUsers []string `json:"allowed_users"` // IDs of the users allowed to clock, empty for all members of the room
+	SummaryTime  string   `json:"summary_time"`  // Optional local time the summary of the day is posted at (HH:MM)
+}
+
+// validate checks that the homeserver, access token, room and summary time are valid.
+func (c MatrixConfig) validate() error {
+	if err := validateIntegrationURL(c.Homeserver, "homeserver", "https", "http"); err != nil {
+		return err
+	}
+	if c.AccessToken == "" {
+		return fmt.Errorf("missing 'access_token' (string) configuration")
+	}
+	if !strings.HasPrefix(c.RoomID, "!") {
+		return fmt.Errorf("invalid 'room_id' (string) configuration. Expected a room ID like !abcdef:example.com")
+	}
+	if c.SummaryTime != "" {
+		if _, err := time.Parse("15:04", c.SummaryTime); err != nil {
+			return fmt.Errorf("invalid 'summary_time' (string) configuration. Expected a time like 18:00")
+		}
+	}
+	return nil
+}
+
+// matrixSyncResponse is the part of a sync response the integration reads.
+type matrixSyncResponse struct {
+	NextBatch string `json:"next_batch"` // Token of the next sync
+	Rooms     struct {
+		Join map[string]struct {
+			Timeline struct {
+				Events []matrixEvent `json:"events"`
+			} `json:"timeline"`
+		} `json:"join"`
+	} `json:"rooms"`
+}
+
+// matrixEvent is a room event of a sync response.
+type matrixEvent struct {
+	Type    string `json:"type"`   // Type of the event, e.g. "m.room.message"
+	Sender  string `json:"sender"` // ID of the user who sent the event
+	Content struct {
+		Body string `json:"body"` // Plain text body of a message
+	} `json:"content"`
+}
+
+// RegisterMatrixHooks starts reading the commands of the Matrix room and schedules the daily
+// summary with the PocketBase server.
+//
+// Parameters:
+// - app: The PocketBase application instance
+func RegisterMatrixHooks(app core.App) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	app.OnServe().BindFunc(func(se *core.ServeEvent) error {
+		go runMatrixSync(ctx, app)
+		return se.Next()
+	})
+
+	app.OnTerminate().BindFunc(func(e *core.TerminateEvent) error {
+		cancel()
+		return e.Next()
+	})
+
+	app.Cron().MustAdd("matrix_summary", "* * * * *", backgroundJobs.cronJob("matrix_summary", func() {
+		config, ok := integrationConfig[MatrixConfig](app, "matrix")
+		if !ok || config.SummaryTime == "" {
+			return
+		}
+
+		now := clockNow(app)
+		if now.Format("15:04") != config.SummaryTime {
+			return
+		}
+
+		summary, sessions, err := getMatrixSummary(app, now)
+		if err != nil {
+			app.Logger().Error("failed to create matrix summary", "error", err)
+			return
+		}
+		if sessions > 0 {
+			sendMatrixMessage(app, config, summary)
+		}
+	}))
+}
+
+// runMatrixSync reads the events of the configured room until the context is cancelled.
+//
+// Parameters:
+// - ctx: The context stopping the sync when the server terminates
+// - app: The App interface (typically a PocketBase instance or transaction)
+func runMatrixSync(ctx context.Context, app core.App) {
+	var since string
+	var synced MatrixConfig
+
+	for ctx.Err() == nil {
+		config, ok := integrationConfig[MatrixConfig](app, "matrix")
+		if !ok {
+			since = ""
+			sleepContext(ctx, matrixRetryDelay)
+			continue
+		}
+
+		// A changed account or room starts over without replaying its history
+		if config.Homeserver != synced.Homeserver || config.AccessToken != synced.AccessToken || config.RoomID != synced.RoomID {
+			since = ""
+		}
+
+		response, err := syncMatrix(ctx, config, since)
+		if err != nil {
+			if ctx.Err() == nil {
+				app.Logger().Error("failed to sync matrix room", "error", err)
+				sleepContext(ctx, matrixRetryDelay)
+			}
+			continue
+		}
+
+		if since != "" {
+			for _, event := range response.Rooms.Join[config.RoomID].Timeline.Events {
+				handleMatrixEvent(ctx, app, config, event)
+			}
+		}
+
+		since = response.NextBatch
+		synced = config
+	}
+}
+
+// sleepContext waits for a duration or until the context is cancelled.
+//
+// Parameters:
+// - ctx: The context cancelling the wait
+// - duration: The duration to wait
+func sleepContext(ctx context.Context, duration time.Duration) {
+	timer := time.NewTimer(duration)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+	case <-timer.C:
+	}
+}
+
+// syncMatrix requests the new events of the configured room from the homeserver.
+//
+// Parameters:
+// - ctx: The context cancelling the request
+// - config: The configuration of the Matrix integration
+// - since: The token of the previous sync, an empty string for an initial sync without waiting
+//
+// Returns:
+// - The sync response
+// - An error if the request fails or the homeserver doesn't respond with a 2xx status
+func syncMatrix(ctx context.Context, config MatrixConfig, since string) (*matrixSyncResponse, error) {
+	filter, err := json.Marshal(map[string]any{
+		"room": map[string]any{
+			"rooms":    []string{config.RoomID},
+			"timeline": map[string]any{"types": []string{"m.room.message"}},
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode filter: %w", err)
+	}
+
+	query := url.Values{"filter": {string(filter)}}
+	if since != "" {
+		query.Set("since", since)
+		query.Set("timeout", fmt.Sprint(matrixSyncTimeout.Milliseconds()))
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, matrixSyncTimeout+webhookTimeout)
+	defer cancel()
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimSuffix(config.Homeserver, "/")+"/_matrix/client/v3/sync?"+query.Encode(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	request.Header.Set("Authorization", "Bearer "+config.AccessToken)
+
+	response, err := outboundClient().Do(request)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send request: %w", err)
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode < 200 || response.StatusCode >= 300 {
+		return nil, fmt.Errorf("homeserver responded with status %d", response.StatusCode)
+	}
+
+	var sync matrixSyncResponse
+	if err := json.NewDecoder(response.Body).Decode(&sync); err != nil {
+		return nil, fmt.Errorf("failed to decode sync response: %w", err)
+	}
+
+	return &sync, nil
+}
+
+// parseMatrixCommand finds the command of a message.
+//
+// Parameters:
+// - body: The plain text body of the message
+//
+// Returns:
+// - The command ("in", "out", "toggle", "status" or "summary"), an empty string if the message is no command
+// - Whether the message is addressed to the work clock
+func parseMatrixCommand(body string) (string, bool) {
+	fields := strings.Fields(strings.ToLower(body))
+	if len(fields) == 0 || fields[0] != "!clock" {
+		return "", false
+	}
+	if len(fields) == 2 && slices.Contains([]string{"in", "out", "toggle", "status", "summary"}, fields[1]) {
+		return fields[1], true
+	}
+	return "", true
+}
+
+// handleMatrixEvent executes the command of a message and replies with its result.
+//
+// Parameters:
+// - ctx: The context of the sync, cancelling the command on shutdown
+// - app: The App interface (typically a PocketBase instance or transaction)
+// - config: The configuration of the Matrix integration
+// - event: The room event
+func handleMatrixEvent(ctx context.Context, app core.App, config MatrixConfig, event matrixEvent) {
+	command, ok := parseMatrixCommand(event.Content.Body)
+	if !ok {
+		return
+	}
+
+	now := clockNow(app)
+	var reply string
+	switch command {
+	case "":
+		reply = "Unknown command, use !clock in, out, toggle, status or summary"
+	case "status":
+		status, err := getWorkClockStatus(app, "", now)
+		if err != nil {
+			app.Logger().Error("failed to get work clock status for matrix", "error", err)
+			reply = "Failed to get the status"
+		} else {
+			reply = formatMatrixStatus(status)
+		}
+	case "summary":
+		summary, _, err := getMatrixSummary(app, now)
+		if err != nil {
+			app.Logger().Error("failed to create matrix summary", "error", err)
+			reply = "Failed to create the summary"
+		} else {
+			reply = summary
+		}
+	default:
+		if len(config.AllowedUsers) > 0 && !slices.Contains(config.AllowedUsers, event.Sender) {
+			reply = fmt.Sprintf("%s is not allowed to clock", event.Sender)
+			break
+		}
+
+		clockedIn, err := executeShortcutAction(ctx, app, "", command)
+		var tooLongErr *sessionTooLongError
+		switch {
+		case errors.As(err, &tooLongErr):
+			reply = fmt.Sprintf("Not clocked out: %v", tooLongErr)
+		case err != nil:
+			app.Logger().Error("failed to execute matrix command", "sender", event.Sender, "command", command, "error", err)
+			reply = fmt.Sprintf("Failed to clock: %v", err)
+		case clockedIn:
+			reply = fmt.Sprintf("Clocked in at %s", now.Format("15:04"))
+		default:
+			reply = fmt.Sprintf("Clocked out at %s", now.Format("15:04"))
+		}
+	}
+
+	sendMatrixMessage(app, config, reply)
+}
+
+// formatMatrixStatus describes the state of a clock.
+//
+// Parameters:
+// - status: The state of the clock
+//
+// Returns:
+// - The description of the state
+func formatMatrixStatus(status *WorkClockStatus) string {
+	if !status.ClockedIn {
+		return "Clocked out"
+	}
+
+	text := fmt.Sprintf("Clocked in since %s (%s)", status.Since.In(time.Local).Format("15:04"), status.Duration)
+	if status.Stale {
+		text += ", the session seems to be forgotten"
+	}
+	return text
+}
+
+// getMatrixSummary summarizes the day of the default clock.
+//
+// Parameters:
+// - app: The App interface (typically a PocketBase instance or transaction)
+// - now: The reference time, open sessions last until now
+//
+// Returns:
+// - The summary of the day
+// - The number of sessions of the day
+// - An error if the journal or daily summary could not be retrieved
+func getMatrixSummary(app core.App, now time.Time) (string, int, error) {
+	dayStart := startOfLocalDay(now)
+	dayEnd := dayStart.AddDate(0, 0, 1)
+	date := dayStart.Format(time.DateOnly)
+
+	journal, err := getJournal(app, "", date, dayStart, dayEnd, now)
+	if err != nil {
+		return "", 0, err
+	}
+	if len(journal.Sessions) == 0 {
+		return fmt.Sprintf("%s: no sessions", date), 0, nil
+	}
+
+	summary := fmt.Sprintf("%s: worked %s, breaks %s", date, journal.Worked, journal.Breaks)
+
+	// The balance is only known once the sessions of the day are closed
+	summaries, err := findDailySummaries(app, "", dayStart, dayEnd)
+	if err != nil {
+		return "", 0, err
+	}
+	if len(summaries) == 1 && summaries[0].Sessions == len(journal.Sessions) {
+		summary += fmt.Sprintf(", overtime %s, balance %s", summaries[0].Overtime, summaries[0].Balance)
+	}
+
+	if len(journal.Compliance) > 0 {
+		summary += fmt.Sprintf(" (%s)", strings.Join(journal.Compliance, ", "))
+	}
+
+	return summary, len(journal.Sessions), nil
+}
+
+// sendMatrixMessage posts a notice to the configured room in the background.
+//
+// Parameters:
+// - app: The App interface used for logging
+// - config: The configuration of the Matrix integration
+// - text: The plain text of the notice
+func sendMatrixMessage(app core.App, config MatrixConfig, text string) {
+	backgroundJobs.goJob("matrix", func() {
+		if err := deliverMatrixMessage(config, text, fmt.Sprintf("sfs%d", time.Now().UnixNano())); err != nil {
+			recordIntegrationFailure(app, "matrix", err)
+		}
+	})
+}
+
+// deliverMatrixMessage posts a notice to the configured room.
+//
+// Parameters:
+// - config: The configuration of the Matrix integration
+// - text: The plain text of the notice
+// - transactionID: The unique ID of the message, used by the homeserver to deduplicate retries
+//
+// Returns:
+// - An error if the request fails or the homeserver doesn't respond with a 2xx status
+func deliverMatrixMessage(config MatrixConfig, text string, transactionID string) error {
+	body, err := json.Marshal(map[string]string{"msgtype": "m.notice", "body": text})
+	if err != nil {
+		return fmt.Errorf("failed to encode message: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), webhookTimeout)
+	defer cancel()
+
+	endpoint := fmt.Sprintf("%s/_matrix/client/v3/rooms/%s/send/m.room.message/%s",
+		strings.TrimSuffix(config.Homeserver, "/"), url.PathEscape(config.RoomID), url.PathEscape(transactionID))
+	request, err := http.NewRequestWithContext(ctx, http.MethodPut, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("Authorization", "Bearer "+config.AccessToken)
+
+	response, err := outboundClient().Do(request)
+	if err != nil {
+		return fmt.Errorf("failed to send request: %w", err)
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode < 200 || response.StatusCode >= 300 {
+		return fmt.Errorf("homeserver responded with status %d", response.StatusCode)
+	}
+
+	return nil
+}
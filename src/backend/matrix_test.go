@@ -0,0 +1,54 @@
+package backend
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestParseMatrixCommand(t *testing.T) {
+	for body, expected := range map[string]struct {
+		command   string
+		addressed bool
+	}{
+		"!clock in":       {"in", true},
+		"  !Clock  OUT ":  {"out", true},
+		"!clock summary":  {"summary", true},
+		"!clock dance":    {"", true},
+		"!clock":          {"", true},
+		"clock in":        {"", false},
+		"!clockwork in":   {"", false},
+		"see you at noon": {"", false},
+	} {
+		command, addressed := parseMatrixCommand(body)
+		if command != expected.command || addressed != expected.addressed {
+			t.Errorf("expected %q to parse as (%q, %t), got (%q, %t)", body, expected.command, expected.addressed, command, addressed)
+		}
+	}
+}
+
+func TestDeliverMatrixMessage(t *testing.T) {
+	var request *http.Request
+	var body []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		request = r
+		body, _ = io.ReadAll(r.Body)
+	}))
+	defer server.Close()
+
+	config := MatrixConfig{Homeserver: server.URL + "/", AccessToken: "secret", RoomID: "!room:example.com"}
+	if err := deliverMatrixMessage(config, "Clocked in at 09:00", "txn1"); err != nil {
+		t.Fatalf("failed to deliver matrix message: %v", err)
+	}
+
+	var message map[string]string
+	if err := json.Unmarshal(body, &message); err != nil {
+		t.Fatalf("failed to decode matrix message: %v", err)
+	}
+	if request.Method != http.MethodPut || request.URL.Path != "/_matrix/client/v3/rooms/!room:example.com/send/m.room.message/txn1" ||
+		request.Header.Get("Authorization") != "Bearer secret" || message["msgtype"] != "m.notice" || message["body"] != "Clocked in at 09:00" {
+		t.Errorf("unexpected matrix request %s %s %v: %s", request.Method, request.URL.Path, request.Header, body)
+	}
+}
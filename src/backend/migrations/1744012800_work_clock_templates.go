@@ -0,0 +1,100 @@
+/**
+ * Work Clock Templates Migration
+ *
+ * This migration creates the work_clock_templates collection, which stores typical weeks
+ * (e.g. 09:00-12:00 and 13:00-17:00 from Monday to Friday) that can be applied to past weeks
+ * without any recorded data.
+ *
+ * The migration includes:
+ * 1. Creation of the work_clock_templates collection
+ * 2. Definition of the name and sessions fields
+ * 3. Setup of a unique index on the template name
+ * 4. Implementation of both up and down migration functions
+ */
+package migrations
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+)
+
+func init() {
+	m.Register(func(app core.App) error {
+		// Migrate up - Creates the collection and its schema
+		c := &core.Collection{}
+
+		// Collection identification
+		c.Id = "pbc_1744012800_01"
+		c.Name = "work_clock_templates"
+		c.Type = "base"
+
+		// Security rules
+		// Templates are managed by the user just like the work clock records themselves,
+		// so they can be listed, viewed, created, updated and deleted without restrictions.
+		c.CreateRule = ref("")
+		c.DeleteRule = ref("")
+		c.ListRule = ref("")
+		c.UpdateRule = ref("")
+		c.ViewRule = ref("")
+
+		// Field definitions for the work_clock_templates collection
+		c.Fields = []core.Field{
+			// Primary key field - Automatically generated ID
+			&core.TextField{
+				PrimaryKey: true,
+				System:     true,
+				Required:   true,
+
+				Id:   "field_1744012800_01_a",
+				Name: "id",
+
+				AutogeneratePattern: "[a-z0-9]{15}",
+				Min:                 15,
+				Max:                 15,
+				Pattern:             "^[a-z0-9]+$",
+			},
+			// Name field - Human readable name of the template (e.g. "Regular week")
+			&core.TextField{
+				Presentable: true,
+				Required:    true,
+
+				Id:   "field_1744012800_01_b",
+				Name: "name",
+
+				Max: 100,
+			},
+			// Sessions field - List of recurring sessions, for example:
+			// [{"weekdays": [1, 2, 3, 4, 5], "start": "09:00", "end": "12:00"}]
+			// Weekdays are numbered from 1 (Monday) to 7 (Sunday).
+			&core.JSONField{
+				Required: true,
+
+				Id:   "field_1744012800_01_c",
+				Name: "sessions",
+
+				MaxSize: 64 * 1024,
+			},
+		}
+
+		// Database indexes for query optimization and data integrity
+		c.Indexes = []string{
+			// Template names must be unique to be distinguishable in the frontend
+			"CREATE UNIQUE INDEX " +
+				"`idx_1744012800_01_a` " +
+				"ON `work_clock_templates` " +
+				"(`name`)",
+		}
+
+		// Save the collection to the database
+		return app.Save(c)
+	}, func(app core.App) error {
+		// Migrate down - Removes the collection if the migration needs to be rolled back
+		collection, err := app.FindCollectionByNameOrId("pbc_1744012800_01")
+		if err != nil {
+			return err
+		}
+
+		// Delete the collection and all its records
+		return app.Delete(collection)
+	})
+}
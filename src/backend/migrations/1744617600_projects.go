@@ -0,0 +1,133 @@
+/**
+ * Projects Migration
+ *
+ * This migration creates the projects collection and links work clock sessions to projects.
+ * A session is assigned to a project through the clock in record that starts the session.
+ * Projects can have an hour budget, which is monitored by the budget module.
+ *
+ * The migration includes:
+ * 1. Creation of the projects collection with name and budget fields
+ * 2. Addition of the project relation field to the work_clock collection
+ * 3. Setup of a unique index on the project name
+ * 4. Implementation of both up and down migration functions
+ */
+package migrations
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+)
+
+func init() {
+	m.Register(func(app core.App) error {
+		// Migrate up - Creates the projects collection and links it to the work_clock collection
+		c := &core.Collection{}
+
+		// Collection identification
+		c.Id = "pbc_1744617600_01"
+		c.Name = "projects"
+		c.Type = "base"
+
+		// Security rules
+		// Projects are managed by the user just like the work clock records themselves.
+		c.CreateRule = ref("")
+		c.DeleteRule = ref("")
+		c.ListRule = ref("")
+		c.UpdateRule = ref("")
+		c.ViewRule = ref("")
+
+		// Field definitions for the projects collection
+		c.Fields = []core.Field{
+			// Primary key field - Automatically generated ID
+			&core.TextField{
+				PrimaryKey: true,
+				System:     true,
+				Required:   true,
+
+				Id:   "field_1744617600_01_a",
+				Name: "id",
+
+				AutogeneratePattern: "[a-z0-9]{15}",
+				Min:                 15,
+				Max:                 15,
+				Pattern:             "^[a-z0-9]+$",
+			},
+			// Name field - Human readable name of the project
+			&core.TextField{
+				Presentable: true,
+				Required:    true,
+
+				Id:   "field_1744617600_01_b",
+				Name: "name",
+
+				Max: 100,
+			},
+			// Budget field - Hour budget of the project (0 means no budget)
+			&core.NumberField{
+				Id:   "field_1744617600_01_c",
+				Name: "budget_hours",
+
+				Min: ref(0.0),
+			},
+			// Budget alert level field - Highest budget threshold (in percent) an alert was sent for.
+			// Maintained by the budget module to avoid sending the same alert multiple times.
+			&core.NumberField{
+				Id:   "field_1744617600_01_d",
+				Name: "budget_alert_level",
+
+				Min:     ref(0.0),
+				OnlyInt: true,
+			},
+		}
+
+		// Database indexes for query optimization and data integrity
+		c.Indexes = []string{
+			// Project names must be unique to be distinguishable in the frontend
+			"CREATE UNIQUE INDEX " +
+				"`idx_1744617600_01_a` " +
+				"ON `projects` " +
+				"(`name`)",
+		}
+
+		if err := app.Save(c); err != nil {
+			return err
+		}
+
+		workClock, err := app.FindCollectionByNameOrId("pbc_1743167663_01")
+		if err != nil {
+			return err
+		}
+
+		// Project field - Project of the session started by a clock in record.
+		// Only set on clock in records; deleting a project keeps the sessions.
+		workClock.Fields.Add(&core.RelationField{
+			Id:   "field_1743167663_01_d",
+			Name: "project",
+
+			CollectionId:  c.Id,
+			CascadeDelete: false,
+			MaxSelect:     1,
+		})
+
+		return app.Save(workClock)
+	}, func(app core.App) error {
+		// Migrate down - Removes the project field and the projects collection
+		workClock, err := app.FindCollectionByNameOrId("pbc_1743167663_01")
+		if err != nil {
+			return err
+		}
+
+		workClock.Fields.RemoveById("field_1743167663_01_d")
+		if err := app.Save(workClock); err != nil {
+			return err
+		}
+
+		collection, err := app.FindCollectionByNameOrId("pbc_1744617600_01")
+		if err != nil {
+			return err
+		}
+
+		// Delete the collection and all its records
+		return app.Delete(collection)
+	})
+}
@@ -0,0 +1,117 @@
+/**
+ * Tags Migration
+ *
+ * This migration creates the tags collection and allows attaching any number of tags to
+ * work clock sessions. Like the project, the tags of a session are stored on the clock in
+ * record that starts the session.
+ *
+ * The migration includes:
+ * 1. Creation of the tags collection
+ * 2. Addition of the tags relation field to the work_clock collection
+ * 3. Setup of a unique index on the tag name
+ * 4. Implementation of both up and down migration functions
+ */
+package migrations
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+)
+
+func init() {
+	m.Register(func(app core.App) error {
+		// Migrate up - Creates the tags collection and links it to the work_clock collection
+		c := &core.Collection{}
+
+		// Collection identification
+		c.Id = "pbc_1744876800_01"
+		c.Name = "tags"
+		c.Type = "base"
+
+		// Security rules
+		// Tags are managed by the user just like the work clock records themselves.
+		c.CreateRule = ref("")
+		c.DeleteRule = ref("")
+		c.ListRule = ref("")
+		c.UpdateRule = ref("")
+		c.ViewRule = ref("")
+
+		// Field definitions for the tags collection
+		c.Fields = []core.Field{
+			// Primary key field - Automatically generated ID
+			&core.TextField{
+				PrimaryKey: true,
+				System:     true,
+				Required:   true,
+
+				Id:   "field_1744876800_01_a",
+				Name: "id",
+
+				AutogeneratePattern: "[a-z0-9]{15}",
+				Min:                 15,
+				Max:                 15,
+				Pattern:             "^[a-z0-9]+$",
+			},
+			// Name field - Free-form name of the tag (e.g. "meeting", "deep work", "support")
+			&core.TextField{
+				Presentable: true,
+				Required:    true,
+
+				Id:   "field_1744876800_01_b",
+				Name: "name",
+
+				Max: 50,
+			},
+		}
+
+		// Database indexes for query optimization and data integrity
+		c.Indexes = []string{
+			// Tag names must be unique to be distinguishable in the frontend
+			"CREATE UNIQUE INDEX " +
+				"`idx_1744876800_01_a` " +
+				"ON `tags` " +
+				"(`name`)",
+		}
+
+		if err := app.Save(c); err != nil {
+			return err
+		}
+
+		workClock, err := app.FindCollectionByNameOrId("pbc_1743167663_01")
+		if err != nil {
+			return err
+		}
+
+		// Tags field - Tags of the session started by a clock in record.
+		// Only set on clock in records; deleting a tag removes it from all sessions.
+		workClock.Fields.Add(&core.RelationField{
+			Id:   "field_1743167663_01_e",
+			Name: "tags",
+
+			CollectionId:  c.Id,
+			CascadeDelete: false,
+			MaxSelect:     999,
+		})
+
+		return app.Save(workClock)
+	}, func(app core.App) error {
+		// Migrate down - Removes the tags field and the tags collection
+		workClock, err := app.FindCollectionByNameOrId("pbc_1743167663_01")
+		if err != nil {
+			return err
+		}
+
+		workClock.Fields.RemoveById("field_1743167663_01_e")
+		if err := app.Save(workClock); err != nil {
+			return err
+		}
+
+		collection, err := app.FindCollectionByNameOrId("pbc_1744876800_01")
+		if err != nil {
+			return err
+		}
+
+		// Delete the collection and all its records
+		return app.Delete(collection)
+	})
+}
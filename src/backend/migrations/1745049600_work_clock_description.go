@@ -0,0 +1,47 @@
+/**
+ * Work Clock Description Migration
+ *
+ * This migration adds a description field to the work_clock collection, describing what
+ * the user is working on during a session. Like all session metadata, the description is
+ * stored on the clock in record that starts the session.
+ *
+ * The migration includes:
+ * 1. Addition of the description text field to the work_clock collection
+ * 2. Implementation of both up and down migration functions
+ */
+package migrations
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+)
+
+func init() {
+	m.Register(func(app core.App) error {
+		// Migrate up - Adds the description field
+		workClock, err := app.FindCollectionByNameOrId("pbc_1743167663_01")
+		if err != nil {
+			return err
+		}
+
+		// Description field - What the user is working on during the session
+		workClock.Fields.Add(&core.TextField{
+			Id:   "field_1743167663_01_f",
+			Name: "description",
+
+			Max: 500,
+		})
+
+		return app.Save(workClock)
+	}, func(app core.App) error {
+		// Migrate down - Removes the description field
+		workClock, err := app.FindCollectionByNameOrId("pbc_1743167663_01")
+		if err != nil {
+			return err
+		}
+
+		workClock.Fields.RemoveById("field_1743167663_01_f")
+
+		return app.Save(workClock)
+	})
+}
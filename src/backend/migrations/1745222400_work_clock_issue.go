@@ -0,0 +1,47 @@
+/**
+ * Work Clock Issue Migration
+ *
+ * This migration adds an issue field to the work_clock collection, referencing the external
+ * issue (Jira key, GitHub issue or URL) worked on during a session. Like all session metadata,
+ * the issue is stored on the clock in record that starts the session.
+ *
+ * The migration includes:
+ * 1. Addition of the issue text field to the work_clock collection
+ * 2. Implementation of both up and down migration functions
+ */
+package migrations
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+)
+
+func init() {
+	m.Register(func(app core.App) error {
+		// Migrate up - Adds the issue field
+		workClock, err := app.FindCollectionByNameOrId("pbc_1743167663_01")
+		if err != nil {
+			return err
+		}
+
+		// Issue field - External issue reference (e.g. "ABC-123", "owner/repo#42" or a URL)
+		workClock.Fields.Add(&core.TextField{
+			Id:   "field_1743167663_01_g",
+			Name: "issue",
+
+			Max: 2000,
+		})
+
+		return app.Save(workClock)
+	}, func(app core.App) error {
+		// Migrate down - Removes the issue field
+		workClock, err := app.FindCollectionByNameOrId("pbc_1743167663_01")
+		if err != nil {
+			return err
+		}
+
+		workClock.Fields.RemoveById("field_1743167663_01_g")
+
+		return app.Save(workClock)
+	})
+}
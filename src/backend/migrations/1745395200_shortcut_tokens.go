@@ -0,0 +1,113 @@
+/**
+ * Shortcut Tokens Migration
+ *
+ * This migration creates the shortcut_tokens collection. A shortcut token is embedded in a URL
+ * (e.g. /c/{token}/toggle) that can be used from iOS Shortcuts or NFC tags to clock in and out
+ * without authentication headers. Only a SHA-256 hash of the token is stored, so a leaked
+ * database does not leak usable URLs.
+ *
+ * The migration includes:
+ * 1. Creation of the shortcut_tokens collection
+ * 2. Setup of a unique index on the token hash
+ * 3. Implementation of both up and down migration functions
+ */
+package migrations
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+func init() {
+	m.Register(func(app core.App) error {
+		// Migrate up - Creates the shortcut_tokens collection
+		c := &core.Collection{}
+
+		// Collection identification
+		c.Id = "pbc_1745395200_01"
+		c.Name = "shortcut_tokens"
+		c.Type = "base"
+
+		// Security rules
+		// Tokens are created by the shortcut module only, since it generates the token hash.
+		// Listing and deleting (revoking) tokens is up to the user.
+		c.CreateRule = nil
+		c.DeleteRule = ref("")
+		c.ListRule = ref("")
+		c.UpdateRule = nil
+		c.ViewRule = ref("")
+
+		// Field definitions for the shortcut_tokens collection
+		c.Fields = []core.Field{
+			// Primary key field - Automatically generated ID
+			&core.TextField{
+				PrimaryKey: true,
+				System:     true,
+				Required:   true,
+
+				Id:   "field_1745395200_01_a",
+				Name: "id",
+
+				AutogeneratePattern: "[a-z0-9]{15}",
+				Min:                 15,
+				Max:                 15,
+				Pattern:             "^[a-z0-9]+$",
+			},
+			// Name field - Describes where the token is used (e.g. "iPhone", "NFC tag at the door")
+			&core.TextField{
+				Presentable: true,
+				Required:    true,
+
+				Id:   "field_1745395200_01_b",
+				Name: "name",
+
+				Max: 100,
+			},
+			// Token hash field - Hex encoded SHA-256 hash of the token, never exposed via the API
+			&core.TextField{
+				Hidden:   true,
+				Required: true,
+
+				Id:   "field_1745395200_01_c",
+				Name: "token_hash",
+
+				Min: 64,
+				Max: 64,
+			},
+			// Confirm field - Whether the token shows a confirmation page before clocking
+			&core.BoolField{
+				Id:   "field_1745395200_01_d",
+				Name: "confirm",
+			},
+			// Last used field - Timestamp of the last successful use of the token
+			&core.DateField{
+				Id:   "field_1745395200_01_e",
+				Name: "last_used_at",
+
+				Min: types.DateTime{},
+				Max: types.DateTime{},
+			},
+		}
+
+		// Database indexes for query optimization and data integrity
+		c.Indexes = []string{
+			// Tokens are looked up by their hash
+			"CREATE UNIQUE INDEX " +
+				"`idx_1745395200_01_a` " +
+				"ON `shortcut_tokens` " +
+				"(`token_hash`)",
+		}
+
+		return app.Save(c)
+	}, func(app core.App) error {
+		// Migrate down - Removes the shortcut_tokens collection
+		collection, err := app.FindCollectionByNameOrId("pbc_1745395200_01")
+		if err != nil {
+			return err
+		}
+
+		// Delete the collection and all its records
+		return app.Delete(collection)
+	})
+}
@@ -0,0 +1,198 @@
+/**
+ * Payroll Export Migration
+ *
+ * This migration adds an hourly rate and a cost center code to projects and creates the
+ * export_profiles collection. An export profile describes the file layout expected by a payroll
+ * provider (e.g. DATEV-style imports): the columns, the delimiter, and how hours and dates are
+ * formatted. Sessions are exported with the cost center and hourly rate of their project.
+ *
+ * The migration includes:
+ * 1. Addition of the hourly rate and cost center fields to the projects collection
+ * 2. Creation of the export_profiles collection
+ * 3. Setup of a unique index on the profile name
+ * 4. Implementation of both up and down migration functions
+ */
+package migrations
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+)
+
+func init() {
+	m.Register(func(app core.App) error {
+		// Migrate up - Adds the payroll fields to projects and creates the export_profiles collection
+		projects, err := app.FindCollectionByNameOrId("pbc_1744617600_01")
+		if err != nil {
+			return err
+		}
+
+		// Hourly rate field - Rate billed or paid per hour of the project (0 means no rate)
+		projects.Fields.Add(&core.NumberField{
+			Id:   "field_1744617600_01_e",
+			Name: "hourly_rate",
+
+			Min: ref(0.0),
+		})
+
+		// Cost center field - Cost center code the hours of the project are booked on
+		projects.Fields.Add(&core.TextField{
+			Id:   "field_1744617600_01_f",
+			Name: "cost_center",
+
+			Max: 50,
+		})
+
+		if err := app.Save(projects); err != nil {
+			return err
+		}
+
+		c := &core.Collection{}
+
+		// Collection identification
+		c.Id = "pbc_1745568000_01"
+		c.Name = "export_profiles"
+		c.Type = "base"
+
+		// Security rules
+		// Export profiles are managed by the user just like projects.
+		c.CreateRule = ref("")
+		c.DeleteRule = ref("")
+		c.ListRule = ref("")
+		c.UpdateRule = ref("")
+		c.ViewRule = ref("")
+
+		// Field definitions for the export_profiles collection
+		c.Fields = []core.Field{
+			// Primary key field - Automatically generated ID
+			&core.TextField{
+				PrimaryKey: true,
+				System:     true,
+				Required:   true,
+
+				Id:   "field_1745568000_01_a",
+				Name: "id",
+
+				AutogeneratePattern: "[a-z0-9]{15}",
+				Min:                 15,
+				Max:                 15,
+				Pattern:             "^[a-z0-9]+$",
+			},
+			// Name field - Human readable name of the profile (e.g. "DATEV Lohn")
+			&core.TextField{
+				Presentable: true,
+				Required:    true,
+
+				Id:   "field_1745568000_01_b",
+				Name: "name",
+
+				Max: 100,
+			},
+			// Columns field - Ordered list of exported columns, for example:
+			// ["personnel_number", "date", "wage_type", "hours", "cost_center"]
+			&core.JSONField{
+				Required: true,
+
+				Id:   "field_1745568000_01_c",
+				Name: "columns",
+
+				MaxSize: 4 * 1024,
+			},
+			// Delimiter field - Column delimiter, ";" if empty
+			&core.TextField{
+				Id:   "field_1745568000_01_d",
+				Name: "delimiter",
+
+				Max: 1,
+			},
+			// Decimal separator field - Separator of decimal numbers, "," if empty
+			&core.TextField{
+				Id:   "field_1745568000_01_e",
+				Name: "decimal_separator",
+
+				Max: 1,
+			},
+			// Duration format field - Hours as decimal number (7,50) or as hours and minutes (7:30)
+			&core.SelectField{
+				Required: true,
+
+				Id:   "field_1745568000_01_f",
+				Name: "duration_format",
+
+				MaxSelect: 1,
+				Values:    []string{"decimal", "hours_minutes"},
+			},
+			// Group by field - One row per session, or one row per day and project
+			&core.SelectField{
+				Required: true,
+
+				Id:   "field_1745568000_01_g",
+				Name: "group_by",
+
+				MaxSelect: 1,
+				Values:    []string{"session", "day"},
+			},
+			// Date format field - Layout of dates in the Go reference time notation
+			&core.SelectField{
+				Required: true,
+
+				Id:   "field_1745568000_01_h",
+				Name: "date_format",
+
+				MaxSelect: 1,
+				Values:    []string{"02.01.2006", "2006-01-02", "01/02/2006", "20060102"},
+			},
+			// Include header field - Whether the first row contains the column names
+			&core.BoolField{
+				Id:   "field_1745568000_01_i",
+				Name: "include_header",
+			},
+			// Personnel number field - Constant personnel number of the employee in the payroll system
+			&core.TextField{
+				Id:   "field_1745568000_01_j",
+				Name: "personnel_number",
+
+				Max: 50,
+			},
+			// Wage type field - Constant wage type code the hours are booked as (e.g. "1000")
+			&core.TextField{
+				Id:   "field_1745568000_01_k",
+				Name: "wage_type",
+
+				Max: 50,
+			},
+		}
+
+		// Database indexes for query optimization and data integrity
+		c.Indexes = []string{
+			// Profile names must be unique to be distinguishable in the frontend
+			"CREATE UNIQUE INDEX " +
+				"`idx_1745568000_01_a` " +
+				"ON `export_profiles` " +
+				"(`name`)",
+		}
+
+		return app.Save(c)
+	}, func(app core.App) error {
+		// Migrate down - Removes the export_profiles collection and the payroll fields of projects
+		collection, err := app.FindCollectionByNameOrId("pbc_1745568000_01")
+		if err != nil {
+			return err
+		}
+
+		// Delete the collection and all its records
+		if err := app.Delete(collection); err != nil {
+			return err
+		}
+
+		projects, err := app.FindCollectionByNameOrId("pbc_1744617600_01")
+		if err != nil {
+			return err
+		}
+
+		projects.Fields.RemoveById("field_1744617600_01_e")
+		projects.Fields.RemoveById("field_1744617600_01_f")
+
+		return app.Save(projects)
+	})
+}
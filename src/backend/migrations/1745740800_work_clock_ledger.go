@@ -0,0 +1,156 @@
+/**
+ * Work Clock Ledger Migration
+ *
+ * This migration creates the work_clock_ledger collection, an append-only hash chain of all
+ * changes to work clock records. Each entry stores a snapshot of the changed record, the hash of
+ * the previous entry, its own hash and a signature of that hash. Entries are only written by the
+ * ledger module, so changes made directly in the database (bypassing the API) become evident when
+ * the chain is verified.
+ *
+ * The migration includes:
+ * 1. Creation of the work_clock_ledger collection
+ * 2. Setup of a unique index on the entry sequence
+ * 3. Implementation of both up and down migration functions
+ */
+package migrations
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+func init() {
+	m.Register(func(app core.App) error {
+		// Migrate up - Creates the work_clock_ledger collection
+		c := &core.Collection{}
+
+		// Collection identification
+		c.Id = "pbc_1745740800_01"
+		c.Name = "work_clock_ledger"
+		c.Type = "base"
+
+		// Security rules
+		// Entries are appended by the ledger module only and can never be changed through the API.
+		// Listing them is allowed, so the chain can be audited externally.
+		c.CreateRule = nil
+		c.DeleteRule = nil
+		c.ListRule = ref("")
+		c.UpdateRule = nil
+		c.ViewRule = ref("")
+
+		// Field definitions for the work_clock_ledger collection
+		c.Fields = []core.Field{
+			// Primary key field - Automatically generated ID
+			&core.TextField{
+				PrimaryKey: true,
+				System:     true,
+				Required:   true,
+
+				Id:   "field_1745740800_01_a",
+				Name: "id",
+
+				AutogeneratePattern: "[a-z0-9]{15}",
+				Min:                 15,
+				Max:                 15,
+				Pattern:             "^[a-z0-9]+$",
+			},
+			// Sequence field - Position of the entry in the chain, starting at 1
+			&core.NumberField{
+				Required: true,
+
+				Id:   "field_1745740800_01_b",
+				Name: "sequence",
+
+				Min:     ref(1.0),
+				OnlyInt: true,
+			},
+			// Record ID field - ID of the changed work clock record
+			&core.TextField{
+				Required: true,
+
+				Id:   "field_1745740800_01_c",
+				Name: "record_id",
+
+				Max: 15,
+			},
+			// Action field - Kind of the change
+			&core.SelectField{
+				Required: true,
+
+				Id:   "field_1745740800_01_d",
+				Name: "action",
+
+				MaxSelect: 1,
+				Values:    []string{"create", "update", "delete"},
+			},
+			// Data field - JSON snapshot of the record after the change (before it for deletions).
+			// Stored as text, so the hashed bytes are preserved exactly.
+			&core.TextField{
+				Required: true,
+
+				Id:   "field_1745740800_01_e",
+				Name: "data",
+
+				Max: 100000,
+			},
+			// Recorded at field - Time the change was recorded
+			&core.DateField{
+				Required: true,
+
+				Id:   "field_1745740800_01_f",
+				Name: "recorded_at",
+
+				Min: types.DateTime{},
+				Max: types.DateTime{},
+			},
+			// Previous hash field - Hash of the previous entry, empty for the first entry
+			&core.TextField{
+				Id:   "field_1745740800_01_g",
+				Name: "prev_hash",
+
+				Max: 64,
+			},
+			// Hash field - Hex encoded SHA-256 hash over the entry and the previous hash
+			&core.TextField{
+				Required: true,
+
+				Id:   "field_1745740800_01_h",
+				Name: "hash",
+
+				Min: 64,
+				Max: 64,
+			},
+			// Signature field - Hex encoded Ed25519 signature of the hash with the server key
+			&core.TextField{
+				Required: true,
+
+				Id:   "field_1745740800_01_i",
+				Name: "signature",
+
+				Min: 128,
+				Max: 128,
+			},
+		}
+
+		// Database indexes for query optimization and data integrity
+		c.Indexes = []string{
+			// Every position of the chain can only be taken once
+			"CREATE UNIQUE INDEX " +
+				"`idx_1745740800_01_a` " +
+				"ON `work_clock_ledger` " +
+				"(`sequence`)",
+		}
+
+		return app.Save(c)
+	}, func(app core.App) error {
+		// Migrate down - Removes the work_clock_ledger collection
+		collection, err := app.FindCollectionByNameOrId("pbc_1745740800_01")
+		if err != nil {
+			return err
+		}
+
+		// Delete the collection and all its records
+		return app.Delete(collection)
+	})
+}
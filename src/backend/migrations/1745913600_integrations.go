@@ -0,0 +1,101 @@
+/**
+ * Integrations Migration
+ *
+ * This migration creates the integrations collection, which holds the runtime configuration of
+ * the integrations (webhooks, Slack, MQTT and calendar). An integration without a record falls
+ * back to its configuration from the environment, so existing setups keep working unchanged.
+ *
+ * The migration includes:
+ * 1. Creation of the integrations collection
+ * 2. Setup of a unique index on the integration name
+ * 3. Implementation of both up and down migration functions
+ */
+package migrations
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+)
+
+func init() {
+	m.Register(func(app core.App) error {
+		// Migrate up - Creates the integrations collection
+		c := &core.Collection{}
+
+		// Collection identification
+		c.Id = "pbc_1745913600_01"
+		c.Name = "integrations"
+		c.Type = "base"
+
+		// Security rules
+		// The configurations contain secrets (e.g. the Slack webhook URL or MQTT password),
+		// so they can only be accessed by superusers.
+		c.CreateRule = nil
+		c.DeleteRule = nil
+		c.ListRule = nil
+		c.UpdateRule = nil
+		c.ViewRule = nil
+
+		// Field definitions for the integrations collection
+		c.Fields = []core.Field{
+			// Primary key field - Automatically generated ID
+			&core.TextField{
+				PrimaryKey: true,
+				System:     true,
+				Required:   true,
+
+				Id:   "field_1745913600_01_a",
+				Name: "id",
+
+				AutogeneratePattern: "[a-z0-9]{15}",
+				Min:                 15,
+				Max:                 15,
+				Pattern:             "^[a-z0-9]+$",
+			},
+			// Name field - The configured integration
+			&core.SelectField{
+				Presentable: true,
+				Required:    true,
+
+				Id:   "field_1745913600_01_b",
+				Name: "name",
+
+				MaxSelect: 1,
+				Values:    []string{"webhooks", "slack", "mqtt", "calendar"},
+			},
+			// Enabled field - Whether the integration is active
+			&core.BoolField{
+				Id:   "field_1745913600_01_c",
+				Name: "enabled",
+			},
+			// Config field - Integration specific configuration, for example:
+			// {"webhook_url": "https://hooks.slack.com/services/..."}
+			&core.JSONField{
+				Id:   "field_1745913600_01_d",
+				Name: "config",
+
+				MaxSize: 16 * 1024,
+			},
+		}
+
+		// Database indexes for query optimization and data integrity
+		c.Indexes = []string{
+			// Every integration has a single configuration
+			"CREATE UNIQUE INDEX " +
+				"`idx_1745913600_01_a` " +
+				"ON `integrations` " +
+				"(`name`)",
+		}
+
+		return app.Save(c)
+	}, func(app core.App) error {
+		// Migrate down - Removes the integrations collection
+		collection, err := app.FindCollectionByNameOrId("pbc_1745913600_01")
+		if err != nil {
+			return err
+		}
+
+		// Delete the collection and all its records
+		return app.Delete(collection)
+	})
+}
@@ -0,0 +1,48 @@
+/**
+ * User Locale Migration
+ *
+ * This migration adds the preferred language to the users collection. Responses to requests
+ * authenticated as a user are localized in this language, regardless of the Accept-Language
+ * header sent by the client.
+ *
+ * The migration includes:
+ * 1. Addition of the locale field to the users collection
+ * 2. Implementation of both up and down migration functions
+ */
+package migrations
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+)
+
+func init() {
+	m.Register(func(app core.App) error {
+		// Migrate up - Adds the locale field to the users collection
+		users, err := app.FindCollectionByNameOrId("users")
+		if err != nil {
+			return err
+		}
+
+		// Locale field - Preferred language of the user, the client's language is used if empty
+		users.Fields.Add(&core.SelectField{
+			Id:   "field_1746086400_01_a",
+			Name: "locale",
+
+			MaxSelect: 1,
+			Values:    []string{"en", "de"},
+		})
+
+		return app.Save(users)
+	}, func(app core.App) error {
+		// Migrate down - Removes the locale field from the users collection
+		users, err := app.FindCollectionByNameOrId("users")
+		if err != nil {
+			return err
+		}
+
+		users.Fields.RemoveById("field_1746086400_01_a")
+
+		return app.Save(users)
+	})
+}
@@ -0,0 +1,61 @@
+/**
+ * Export Profile Locale Migration
+ *
+ * This migration adds a locale to export profiles and a duration format with units. The locale
+ * determines the decimal separator of a profile unless it is set explicitly, so a profile can
+ * follow the conventions of the payroll provider's country, e.g. 7,50 in German and 7.50 in
+ * English files. Durations can now also be formatted with units (7h30m).
+ *
+ * The migration includes:
+ * 1. Addition of the locale field to the export_profiles collection
+ * 2. Addition of the 'units' duration format
+ * 3. Implementation of both up and down migration functions
+ */
+package migrations
+
+import (
+	"slices"
+
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+)
+
+func init() {
+	m.Register(func(app core.App) error {
+		// Migrate up - Adds the locale field and the 'units' duration format to export profiles
+		c, err := app.FindCollectionByNameOrId("pbc_1745568000_01")
+		if err != nil {
+			return err
+		}
+
+		// Locale field - Conventions of the exported numbers, the decimal separator field takes precedence
+		c.Fields.Add(&core.SelectField{
+			Id:   "field_1745568000_01_l",
+			Name: "locale",
+
+			MaxSelect: 1,
+			Values:    []string{"en", "de"},
+		})
+
+		// Duration format field - Additionally allows hours and minutes with units (7h30m)
+		if durationFormat, ok := c.Fields.GetById("field_1745568000_01_f").(*core.SelectField); ok {
+			durationFormat.Values = []string{"decimal", "hours_minutes", "units"}
+		}
+
+		return app.Save(c)
+	}, func(app core.App) error {
+		// Migrate down - Removes the locale field and the 'units' duration format from export profiles
+		c, err := app.FindCollectionByNameOrId("pbc_1745568000_01")
+		if err != nil {
+			return err
+		}
+
+		c.Fields.RemoveById("field_1745568000_01_l")
+
+		if durationFormat, ok := c.Fields.GetById("field_1745568000_01_f").(*core.SelectField); ok {
+			durationFormat.Values = slices.DeleteFunc(durationFormat.Values, func(value string) bool { return value == "units" })
+		}
+
+		return app.Save(c)
+	})
+}
@@ -0,0 +1,135 @@
+/**
+ * Clocks Migration
+ *
+ * This migration adds support for multiple independent clocks (e.g. a main job and a side
+ * project). It creates the clocks collection and links work clock records to a clock. Records
+ * without a clock belong to the default clock, so existing records keep their meaning.
+ *
+ * Each clock has its own alternating sequence of clock in and out records, so the uniqueness of
+ * timestamps is now enforced per clock.
+ *
+ * The migration includes:
+ * 1. Creation of the clocks collection
+ * 2. Addition of the clock relation field to the work_clock collection
+ * 3. Replacement of the unique timestamp index by a unique index per clock
+ * 4. Implementation of both up and down migration functions
+ */
+package migrations
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+)
+
+func init() {
+	m.Register(func(app core.App) error {
+		// Migrate up - Creates the clocks collection and links it to the work_clock collection
+		c := &core.Collection{}
+
+		// Collection identification
+		c.Id = "pbc_1746432000_01"
+		c.Name = "clocks"
+		c.Type = "base"
+
+		// Security rules
+		// Clocks are managed by the user just like projects.
+		c.CreateRule = ref("")
+		c.DeleteRule = ref("")
+		c.ListRule = ref("")
+		c.UpdateRule = ref("")
+		c.ViewRule = ref("")
+
+		// Field definitions for the clocks collection
+		c.Fields = []core.Field{
+			// Primary key field - Automatically generated ID
+			&core.TextField{
+				PrimaryKey: true,
+				System:     true,
+				Required:   true,
+
+				Id:   "field_1746432000_01_a",
+				Name: "id",
+
+				AutogeneratePattern: "[a-z0-9]{15}",
+				Min:                 15,
+				Max:                 15,
+				Pattern:             "^[a-z0-9]+$",
+			},
+			// Name field - Name of the clock, used as 'clock' parameter of the endpoints (e.g. "side-project")
+			&core.TextField{
+				Presentable: true,
+				Required:    true,
+
+				Id:   "field_1746432000_01_b",
+				Name: "name",
+
+				Max:     50,
+				Pattern: "^[a-z0-9_-]+$",
+			},
+			// Title field - Human readable title of the clock (e.g. "Side Project")
+			&core.TextField{
+				Id:   "field_1746432000_01_c",
+				Name: "title",
+
+				Max: 100,
+			},
+		}
+
+		// Database indexes for query optimization and data integrity
+		c.Indexes = []string{
+			// Clock names must be unique, since clocks are selected by their name
+			"CREATE UNIQUE INDEX " +
+				"`idx_1746432000_01_a` " +
+				"ON `clocks` " +
+				"(`name`)",
+		}
+
+		if err := app.Save(c); err != nil {
+			return err
+		}
+
+		workClock, err := app.FindCollectionByNameOrId("pbc_1743167663_01")
+		if err != nil {
+			return err
+		}
+
+		// Clock field - Clock of the record, empty for the default clock.
+		// Deleting a clock deletes its records, they can't be moved to another clock without
+		// breaking the alternation of that clock.
+		workClock.Fields.Add(&core.RelationField{
+			Id:   "field_1743167663_01_h",
+			Name: "clock",
+
+			CollectionId:  c.Id,
+			CascadeDelete: true,
+			MaxSelect:     1,
+		})
+
+		// Timestamps are unique per clock
+		workClock.RemoveIndex("idx_1743167663_01_a")
+		workClock.AddIndex("idx_1743167663_01_a", true, "`clock`, `timestamp`", "")
+
+		return app.Save(workClock)
+	}, func(app core.App) error {
+		// Migrate down - Removes the clock field and the clocks collection
+		workClock, err := app.FindCollectionByNameOrId("pbc_1743167663_01")
+		if err != nil {
+			return err
+		}
+
+		workClock.RemoveIndex("idx_1743167663_01_a")
+		workClock.AddIndex("idx_1743167663_01_a", true, "`timestamp`", "")
+		workClock.Fields.RemoveById("field_1743167663_01_h")
+		if err := app.Save(workClock); err != nil {
+			return err
+		}
+
+		collection, err := app.FindCollectionByNameOrId("pbc_1746432000_01")
+		if err != nil {
+			return err
+		}
+
+		// Delete the collection and all its records
+		return app.Delete(collection)
+	})
+}
@@ -0,0 +1,50 @@
+/**
+ * Session Category Migration
+ *
+ * This migration adds the category of a session to the work_clock collection. Sessions without
+ * a category are regular work; on-call sessions are tracked as well, but reported separately
+ * and counted with the configured on-call factor.
+ *
+ * Like the other session fields, the category is stored on the clock in record of a session.
+ *
+ * The migration includes:
+ * 1. Addition of the category field to the work_clock collection
+ * 2. Implementation of both up and down migration functions
+ */
+package migrations
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+)
+
+func init() {
+	m.Register(func(app core.App) error {
+		// Migrate up - Adds the category field to the work_clock collection
+		workClock, err := app.FindCollectionByNameOrId("pbc_1743167663_01")
+		if err != nil {
+			return err
+		}
+
+		// Category field - Classification of the session, empty for regular work
+		workClock.Fields.Add(&core.SelectField{
+			Id:   "field_1743167663_01_i",
+			Name: "category",
+
+			MaxSelect: 1,
+			Values:    []string{"on_call"},
+		})
+
+		return app.Save(workClock)
+	}, func(app core.App) error {
+		// Migrate down - Removes the category field from the work_clock collection
+		workClock, err := app.FindCollectionByNameOrId("pbc_1743167663_01")
+		if err != nil {
+			return err
+		}
+
+		workClock.Fields.RemoveById("field_1743167663_01_i")
+
+		return app.Save(workClock)
+	})
+}
@@ -0,0 +1,48 @@
+/**
+ * Travel Category Migration
+ *
+ * This migration adds the travel category for sessions. Travel sessions, e.g. the drive of a
+ * field technician to a customer, are reported separately and counted with the configured
+ * travel factor.
+ *
+ * The migration includes:
+ * 1. Addition of the 'travel' value to the category field of the work_clock collection
+ * 2. Implementation of both up and down migration functions
+ */
+package migrations
+
+import (
+	"slices"
+
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+)
+
+func init() {
+	m.Register(func(app core.App) error {
+		// Migrate up - Adds the 'travel' category
+		workClock, err := app.FindCollectionByNameOrId("pbc_1743167663_01")
+		if err != nil {
+			return err
+		}
+
+		// Category field - Additionally allows travel sessions
+		if category, ok := workClock.Fields.GetById("field_1743167663_01_i").(*core.SelectField); ok {
+			category.Values = []string{"on_call", "travel"}
+		}
+
+		return app.Save(workClock)
+	}, func(app core.App) error {
+		// Migrate down - Removes the 'travel' category
+		workClock, err := app.FindCollectionByNameOrId("pbc_1743167663_01")
+		if err != nil {
+			return err
+		}
+
+		if category, ok := workClock.Fields.GetById("field_1743167663_01_i").(*core.SelectField); ok {
+			category.Values = slices.DeleteFunc(category.Values, func(value string) bool { return value == "travel" })
+		}
+
+		return app.Save(workClock)
+	})
+}
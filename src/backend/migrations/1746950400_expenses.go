@@ -0,0 +1,144 @@
+/**
+ * Expenses Migration
+ *
+ * This migration creates the expenses collection. Expenses are small costs incurred during work,
+ * such as train tickets or hotel nights on work trips, with an optional receipt. An expense is
+ * attached either to a session (through the clock in record that starts the session) or to a
+ * day, and is included in the payroll export of that session or day.
+ *
+ * The migration includes:
+ * 1. Creation of the expenses collection with amount, description and receipt fields
+ * 2. Relations to the session, clock and project an expense belongs to
+ * 3. Setup of an index on the date of the expenses
+ * 4. Implementation of both up and down migration functions
+ */
+package migrations
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+)
+
+func init() {
+	m.Register(func(app core.App) error {
+		// Migrate up - Creates the expenses collection
+		c := &core.Collection{}
+
+		// Collection identification
+		c.Id = "pbc_1746950400_01"
+		c.Name = "expenses"
+		c.Type = "base"
+
+		// Security rules
+		// Expenses are managed by the user just like the work clock records themselves.
+		c.CreateRule = ref("")
+		c.DeleteRule = ref("")
+		c.ListRule = ref("")
+		c.UpdateRule = ref("")
+		c.ViewRule = ref("")
+
+		// Field definitions for the expenses collection
+		c.Fields = []core.Field{
+			// Primary key field - Automatically generated ID
+			&core.TextField{
+				PrimaryKey: true,
+				System:     true,
+				Required:   true,
+
+				Id:   "field_1746950400_01_a",
+				Name: "id",
+
+				AutogeneratePattern: "[a-z0-9]{15}",
+				Min:                 15,
+				Max:                 15,
+				Pattern:             "^[a-z0-9]+$",
+			},
+			// Date field - Day of the expense (local midnight).
+			// Set to the day of the session for expenses attached to a session.
+			&core.DateField{
+				Id:   "field_1746950400_01_b",
+				Name: "date",
+			},
+			// Amount field - Amount of the expense in the currency of the payroll
+			&core.NumberField{
+				Required: true,
+
+				Id:   "field_1746950400_01_c",
+				Name: "amount",
+
+				Min: ref(0.0),
+			},
+			// Description field - What the expense was for (e.g. "Train ticket Berlin")
+			&core.TextField{
+				Presentable: true,
+
+				Id:   "field_1746950400_01_d",
+				Name: "description",
+
+				Max: 500,
+			},
+			// Receipt field - Scan or photo of the receipt, only accessible with a file token
+			&core.FileField{
+				Protected: true,
+
+				Id:   "field_1746950400_01_e",
+				Name: "receipt",
+
+				MaxSelect: 1,
+				MaxSize:   5 * 1024 * 1024,
+				MimeTypes: []string{"application/pdf", "image/jpeg", "image/png", "image/webp"},
+			},
+			// Session field - Clock in record of the session the expense belongs to, empty for expenses of a day.
+			// Deleting the session keeps the expense.
+			&core.RelationField{
+				Id:   "field_1746950400_01_f",
+				Name: "session",
+
+				CollectionId:  "pbc_1743167663_01",
+				CascadeDelete: false,
+				MaxSelect:     1,
+			},
+			// Clock field - Clock the expense belongs to, empty for the default clock.
+			// Set to the clock of the session for expenses attached to a session.
+			&core.RelationField{
+				Id:   "field_1746950400_01_g",
+				Name: "clock",
+
+				CollectionId:  "pbc_1746432000_01",
+				CascadeDelete: true,
+				MaxSelect:     1,
+			},
+			// Project field - Project of an expense of a day.
+			// Expenses attached to a session belong to the project of the session.
+			&core.RelationField{
+				Id:   "field_1746950400_01_h",
+				Name: "project",
+
+				CollectionId:  "pbc_1744617600_01",
+				CascadeDelete: false,
+				MaxSelect:     1,
+			},
+		}
+
+		// Database indexes for query optimization and data integrity
+		c.Indexes = []string{
+			// Expenses are exported by the range of their dates
+			"CREATE INDEX " +
+				"`idx_1746950400_01_a` " +
+				"ON `expenses` " +
+				"(`clock`, `date`)",
+		}
+
+		// Save the collection to the database
+		return app.Save(c)
+	}, func(app core.App) error {
+		// Migrate down - Removes the collection if the migration needs to be rolled back
+		collection, err := app.FindCollectionByNameOrId("pbc_1746950400_01")
+		if err != nil {
+			return err
+		}
+
+		// Delete the collection and all its records
+		return app.Delete(collection)
+	})
+}
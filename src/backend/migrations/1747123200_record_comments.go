@@ -0,0 +1,120 @@
+/**
+ * Record Comments Migration
+ *
+ * This migration creates the record_comments collection. Comments are attached to a work clock
+ * record, e.g. one that was flagged as implausible, so users and admins can document why a record
+ * was corrected or kept.
+ *
+ * Comments are created through the comment endpoint only, which sets their author, so the
+ * collection itself is read-only.
+ *
+ * The migration includes:
+ * 1. Creation of the record_comments collection with record, author and message fields
+ * 2. Setup of an index on the commented record
+ * 3. Implementation of both up and down migration functions
+ */
+package migrations
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+)
+
+func init() {
+	m.Register(func(app core.App) error {
+		// Migrate up - Creates the record_comments collection
+		c := &core.Collection{}
+
+		// Collection identification
+		c.Id = "pbc_1747123200_01"
+		c.Name = "record_comments"
+		c.Type = "base"
+
+		// Security rules
+		// Comments can be read like the work clock records, but only be created through the
+		// comment endpoint and not be changed afterwards, so the thread stays documented.
+		c.CreateRule = nil
+		c.DeleteRule = nil
+		c.ListRule = ref("")
+		c.UpdateRule = nil
+		c.ViewRule = ref("")
+
+		// Field definitions for the record_comments collection
+		c.Fields = []core.Field{
+			// Primary key field - Automatically generated ID
+			&core.TextField{
+				PrimaryKey: true,
+				System:     true,
+				Required:   true,
+
+				Id:   "field_1747123200_01_a",
+				Name: "id",
+
+				AutogeneratePattern: "[a-z0-9]{15}",
+				Min:                 15,
+				Max:                 15,
+				Pattern:             "^[a-z0-9]+$",
+			},
+			// Record field - Work clock record the comment is attached to.
+			// Deleting the record deletes its comments.
+			&core.RelationField{
+				Required: true,
+
+				Id:   "field_1747123200_01_b",
+				Name: "record",
+
+				CollectionId:  "pbc_1743167663_01",
+				CascadeDelete: true,
+				MaxSelect:     1,
+			},
+			// Author field - Whether the comment was written by the user or by an admin (superuser)
+			&core.SelectField{
+				Required: true,
+
+				Id:   "field_1747123200_01_c",
+				Name: "author",
+
+				MaxSelect: 1,
+				Values:    []string{"user", "admin"},
+			},
+			// Message field - Text of the comment
+			&core.TextField{
+				Presentable: true,
+				Required:    true,
+
+				Id:   "field_1747123200_01_d",
+				Name: "message",
+
+				Max: 2000,
+			},
+			// Created field - Time the comment was written, orders the thread
+			&core.AutodateField{
+				Id:   "field_1747123200_01_e",
+				Name: "created",
+
+				OnCreate: true,
+			},
+		}
+
+		// Database indexes for query optimization and data integrity
+		c.Indexes = []string{
+			// Comments are listed per record
+			"CREATE INDEX " +
+				"`idx_1747123200_01_a` " +
+				"ON `record_comments` " +
+				"(`record`, `created`)",
+		}
+
+		// Save the collection to the database
+		return app.Save(c)
+	}, func(app core.App) error {
+		// Migrate down - Removes the collection if the migration needs to be rolled back
+		collection, err := app.FindCollectionByNameOrId("pbc_1747123200_01")
+		if err != nil {
+			return err
+		}
+
+		// Delete the collection and all its records
+		return app.Delete(collection)
+	})
+}
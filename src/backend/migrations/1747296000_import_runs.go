@@ -0,0 +1,152 @@
+/**
+ * Import Runs Migration
+ *
+ * This migration creates the import_runs collection. Every run of an import (legacy database,
+ * instance merge or calendar) is recorded with its source, the uploaded file, the number of
+ * imported and skipped entries, its duration, who started it, and whether it succeeded, so the
+ * origin of imported records can be traced.
+ *
+ * Import runs are recorded by the import endpoints only, so the collection itself is read-only.
+ *
+ * The migration includes:
+ * 1. Creation of the import_runs collection
+ * 2. Setup of an index on the creation time of the runs
+ * 3. Implementation of both up and down migration functions
+ */
+package migrations
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+)
+
+func init() {
+	m.Register(func(app core.App) error {
+		// Migrate up - Creates the import_runs collection
+		c := &core.Collection{}
+
+		// Collection identification
+		c.Id = "pbc_1747296000_01"
+		c.Name = "import_runs"
+		c.Type = "base"
+
+		// Security rules
+		// Import runs can be read like the work clock records, but only be recorded by the import endpoints.
+		c.CreateRule = nil
+		c.DeleteRule = nil
+		c.ListRule = ref("")
+		c.UpdateRule = nil
+		c.ViewRule = ref("")
+
+		// Field definitions for the import_runs collection
+		c.Fields = []core.Field{
+			// Primary key field - Automatically generated ID
+			&core.TextField{
+				PrimaryKey: true,
+				System:     true,
+				Required:   true,
+
+				Id:   "field_1747296000_01_a",
+				Name: "id",
+
+				AutogeneratePattern: "[a-z0-9]{15}",
+				Min:                 15,
+				Max:                 15,
+				Pattern:             "^[a-z0-9]+$",
+			},
+			// Source field - Kind of the import
+			&core.SelectField{
+				Required: true,
+
+				Id:   "field_1747296000_01_b",
+				Name: "source",
+
+				MaxSelect: 1,
+				Values:    []string{"legacy", "instance", "calendar"},
+			},
+			// File name field - Name of the uploaded file, empty for imports without file
+			&core.TextField{
+				Id:   "field_1747296000_01_c",
+				Name: "file_name",
+
+				Max: 255,
+			},
+			// Records field - Number of created work clock records
+			&core.NumberField{
+				Id:   "field_1747296000_01_d",
+				Name: "records",
+
+				Min:     ref(0.0),
+				OnlyInt: true,
+			},
+			// Skipped field - Number of entries of the source that were not imported (duplicates or conflicts)
+			&core.NumberField{
+				Id:   "field_1747296000_01_e",
+				Name: "skipped",
+
+				Min:     ref(0.0),
+				OnlyInt: true,
+			},
+			// Duration field - Duration of the import in milliseconds
+			&core.NumberField{
+				Id:   "field_1747296000_01_f",
+				Name: "duration_ms",
+
+				Min:     ref(0.0),
+				OnlyInt: true,
+			},
+			// Actor field - Who started the import (e.g. "superuser admin@example.com"), "anonymous" without authentication
+			&core.TextField{
+				Id:   "field_1747296000_01_g",
+				Name: "actor",
+
+				Max: 255,
+			},
+			// Outcome field - Whether the import succeeded
+			&core.SelectField{
+				Required: true,
+
+				Id:   "field_1747296000_01_h",
+				Name: "outcome",
+
+				MaxSelect: 1,
+				Values:    []string{"succeeded", "failed"},
+			},
+			// Error field - Why the import failed, empty for successful imports
+			&core.TextField{
+				Id:   "field_1747296000_01_i",
+				Name: "error",
+
+				Max: 2000,
+			},
+			// Created field - Time the import finished
+			&core.AutodateField{
+				Id:   "field_1747296000_01_j",
+				Name: "created",
+
+				OnCreate: true,
+			},
+		}
+
+		// Database indexes for query optimization and data integrity
+		c.Indexes = []string{
+			// The history is listed newest first
+			"CREATE INDEX " +
+				"`idx_1747296000_01_a` " +
+				"ON `import_runs` " +
+				"(`created`)",
+		}
+
+		// Save the collection to the database
+		return app.Save(c)
+	}, func(app core.App) error {
+		// Migrate down - Removes the collection if the migration needs to be rolled back
+		collection, err := app.FindCollectionByNameOrId("pbc_1747296000_01")
+		if err != nil {
+			return err
+		}
+
+		// Delete the collection and all its records
+		return app.Delete(collection)
+	})
+}
@@ -0,0 +1,78 @@
+/**
+ * Import Rollback Migration
+ *
+ * This migration tags the work clock records created by an import with the ID of their import
+ * run, so a bad import can be undone by removing exactly its records. Records created before this
+ * migration or outside of imports are not tagged.
+ *
+ * The migration includes:
+ * 1. Addition of the import_run field and an index on it to the work_clock collection
+ * 2. Addition of the rolled_back field to the import_runs collection
+ * 3. Implementation of both up and down migration functions
+ */
+package migrations
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+)
+
+func init() {
+	m.Register(func(app core.App) error {
+		// Migrate up - Adds the import_run field to the work_clock collection and the rolled_back field to the import_runs collection
+		workClock, err := app.FindCollectionByNameOrId("pbc_1743167663_01")
+		if err != nil {
+			return err
+		}
+
+		// Import run field - ID of the import run that created the record, empty for records not created by an import.
+		// Stored as text, since the records are created before their import run is recorded.
+		workClock.Fields.Add(&core.TextField{
+			Id:   "field_1743167663_01_j",
+			Name: "import_run",
+
+			Max: 15,
+		})
+
+		// The records of an import run are looked up for its rollback
+		workClock.AddIndex("idx_1743167663_01_b", false, "`import_run`", "")
+
+		if err := app.Save(workClock); err != nil {
+			return err
+		}
+
+		importRuns, err := app.FindCollectionByNameOrId("pbc_1747296000_01")
+		if err != nil {
+			return err
+		}
+
+		// Rolled back field - Time the records of the import run were removed, empty if they weren't
+		importRuns.Fields.Add(&core.DateField{
+			Id:   "field_1747296000_01_k",
+			Name: "rolled_back",
+		})
+
+		return app.Save(importRuns)
+	}, func(app core.App) error {
+		// Migrate down - Removes the rolled_back and import_run fields
+		importRuns, err := app.FindCollectionByNameOrId("pbc_1747296000_01")
+		if err != nil {
+			return err
+		}
+
+		importRuns.Fields.RemoveById("field_1747296000_01_k")
+		if err := app.Save(importRuns); err != nil {
+			return err
+		}
+
+		workClock, err := app.FindCollectionByNameOrId("pbc_1743167663_01")
+		if err != nil {
+			return err
+		}
+
+		workClock.RemoveIndex("idx_1743167663_01_b")
+		workClock.Fields.RemoveById("field_1743167663_01_j")
+
+		return app.Save(workClock)
+	})
+}
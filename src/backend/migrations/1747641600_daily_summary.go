@@ -0,0 +1,134 @@
+/**
+ * Daily Summary Migration
+ *
+ * This migration creates the daily_summary collection. It holds the worked time, breaks, target
+ * and overtime of each day and clock, maintained by the daily summary module whenever work clock
+ * records change. Reports can read the summaries instead of pairing the raw records.
+ *
+ * The summaries are derived data, so the collection is read-only.
+ *
+ * The migration includes:
+ * 1. Creation of the daily_summary collection
+ * 2. Setup of a unique index on the clock and date
+ * 3. Implementation of both up and down migration functions
+ */
+package migrations
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+)
+
+func init() {
+	m.Register(func(app core.App) error {
+		// Migrate up - Creates the daily_summary collection
+		c := &core.Collection{}
+
+		// Collection identification
+		c.Id = "pbc_1747641600_01"
+		c.Name = "daily_summary"
+		c.Type = "base"
+
+		// Security rules
+		// Summaries can be read like the work clock records, but are only maintained by the backend.
+		c.CreateRule = nil
+		c.DeleteRule = nil
+		c.ListRule = ref("")
+		c.UpdateRule = nil
+		c.ViewRule = ref("")
+
+		// Field definitions for the daily_summary collection
+		c.Fields = []core.Field{
+			// Primary key field - Automatically generated ID
+			&core.TextField{
+				PrimaryKey: true,
+				System:     true,
+				Required:   true,
+
+				Id:   "field_1747641600_01_a",
+				Name: "id",
+
+				AutogeneratePattern: "[a-z0-9]{15}",
+				Min:                 15,
+				Max:                 15,
+				Pattern:             "^[a-z0-9]+$",
+			},
+			// Clock field - Clock of the summary, empty for the default clock
+			&core.RelationField{
+				Id:   "field_1747641600_01_b",
+				Name: "clock",
+
+				CollectionId:  "pbc_1746432000_01",
+				CascadeDelete: true,
+				MaxSelect:     1,
+			},
+			// Date field - Day of the summary (local midnight)
+			&core.DateField{
+				Required: true,
+
+				Id:   "field_1747641600_01_c",
+				Name: "date",
+			},
+			// Worked field - Time of the closed sessions starting on the day that counts as work time, in seconds
+			&core.NumberField{
+				Id:   "field_1747641600_01_d",
+				Name: "worked_seconds",
+
+				Min:     ref(0.0),
+				OnlyInt: true,
+			},
+			// Breaks field - Time between the sessions of the day, in seconds
+			&core.NumberField{
+				Id:   "field_1747641600_01_e",
+				Name: "break_seconds",
+
+				Min:     ref(0.0),
+				OnlyInt: true,
+			},
+			// Target field - Time that should be worked on the day, in seconds
+			&core.NumberField{
+				Id:   "field_1747641600_01_f",
+				Name: "target_seconds",
+
+				Min:     ref(0.0),
+				OnlyInt: true,
+			},
+			// Overtime field - Worked minus target time, in seconds (negative for undertime)
+			&core.NumberField{
+				Id:   "field_1747641600_01_g",
+				Name: "overtime_seconds",
+
+				OnlyInt: true,
+			},
+			// Sessions field - Number of closed sessions starting on the day
+			&core.NumberField{
+				Id:   "field_1747641600_01_h",
+				Name: "sessions",
+
+				Min:     ref(0.0),
+				OnlyInt: true,
+			},
+		}
+
+		// Database indexes for query optimization and data integrity
+		c.Indexes = []string{
+			// There is one summary per clock and day
+			"CREATE UNIQUE INDEX " +
+				"`idx_1747641600_01_a` " +
+				"ON `daily_summary` " +
+				"(`clock`, `date`)",
+		}
+
+		// Save the collection to the database
+		return app.Save(c)
+	}, func(app core.App) error {
+		// Migrate down - Removes the collection if the migration needs to be rolled back
+		collection, err := app.FindCollectionByNameOrId("pbc_1747641600_01")
+		if err != nil {
+			return err
+		}
+
+		// Delete the collection and all its records
+		return app.Delete(collection)
+	})
+}
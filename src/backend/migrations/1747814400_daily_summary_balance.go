@@ -0,0 +1,50 @@
+/**
+ * Daily Summary Balance Migration
+ *
+ * This migration adds the flextime balance to the daily summaries: the overtime of the clock
+ * accumulated up to and including the day. The daily summary module keeps the balances up to
+ * date by walking the summaries forward from the earliest changed day, so a change to a past
+ * record doesn't require recalculating the whole history.
+ *
+ * Existing summaries start with a balance of zero until they are rebuilt.
+ *
+ * The migration includes:
+ * 1. Addition of the balance_seconds field to the daily_summary collection
+ * 2. Implementation of both up and down migration functions
+ */
+package migrations
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+)
+
+func init() {
+	m.Register(func(app core.App) error {
+		// Migrate up - Adds the balance_seconds field to the daily_summary collection
+		collection, err := app.FindCollectionByNameOrId("pbc_1747641600_01")
+		if err != nil {
+			return err
+		}
+
+		// Balance field - Overtime accumulated up to and including the day, in seconds (negative for undertime)
+		collection.Fields.Add(&core.NumberField{
+			Id:   "field_1747641600_01_i",
+			Name: "balance_seconds",
+
+			OnlyInt: true,
+		})
+
+		return app.Save(collection)
+	}, func(app core.App) error {
+		// Migrate down - Removes the balance_seconds field
+		collection, err := app.FindCollectionByNameOrId("pbc_1747641600_01")
+		if err != nil {
+			return err
+		}
+
+		collection.Fields.RemoveById("field_1747641600_01_i")
+
+		return app.Save(collection)
+	})
+}
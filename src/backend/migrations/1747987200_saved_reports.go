@@ -0,0 +1,116 @@
+/**
+ * Saved Reports Migration
+ *
+ * This migration creates the saved_reports collection, which stores report specs of the report
+ * builder under a name, so a report can be evaluated again or shared as a read-only link without
+ * sending the spec every time.
+ *
+ * Share links are signed tokens containing the share version of the report. Increasing the
+ * version revokes all links shared before.
+ *
+ * The migration includes:
+ * 1. Creation of the saved_reports collection
+ * 2. Setup of a unique index on the report name
+ * 3. Implementation of both up and down migration functions
+ */
+package migrations
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+)
+
+func init() {
+	m.Register(func(app core.App) error {
+		// Migrate up - Creates the saved_reports collection
+		c := &core.Collection{}
+
+		// Collection identification
+		c.Id = "pbc_1747987200_01"
+		c.Name = "saved_reports"
+		c.Type = "base"
+
+		// Security rules
+		// Reports can be listed, viewed and deleted like the templates, but the specs are
+		// validated by the saved reports endpoints, so they can't be created or updated directly.
+		c.CreateRule = nil
+		c.DeleteRule = ref("")
+		c.ListRule = ref("")
+		c.UpdateRule = nil
+		c.ViewRule = ref("")
+
+		// Field definitions for the saved_reports collection
+		c.Fields = []core.Field{
+			// Primary key field - Automatically generated ID
+			&core.TextField{
+				PrimaryKey: true,
+				System:     true,
+				Required:   true,
+
+				Id:   "field_1747987200_01_a",
+				Name: "id",
+
+				AutogeneratePattern: "[a-z0-9]{15}",
+				Min:                 15,
+				Max:                 15,
+				Pattern:             "^[a-z0-9]+$",
+			},
+			// Name field - Human readable name of the report (e.g. "Monthly report")
+			&core.TextField{
+				Presentable: true,
+				Required:    true,
+
+				Id:   "field_1747987200_01_b",
+				Name: "name",
+
+				Max: 100,
+			},
+			// Spec field - Report spec as accepted by the report builder, for example:
+			// {"period": "last_month", "group_by": ["week"], "metrics": ["worked", "overtime"]}
+			&core.JSONField{
+				Required: true,
+
+				Id:   "field_1747987200_01_c",
+				Name: "spec",
+
+				MaxSize: 64 * 1024,
+			},
+			// Share version field - Version contained in share links, increased to revoke them
+			&core.NumberField{
+				Id:   "field_1747987200_01_d",
+				Name: "share_version",
+
+				Min:     ref(0.0),
+				OnlyInt: true,
+			},
+			// Created field - Time the report was saved
+			&core.AutodateField{
+				Id:   "field_1747987200_01_e",
+				Name: "created",
+
+				OnCreate: true,
+			},
+		}
+
+		// Database indexes for query optimization and data integrity
+		c.Indexes = []string{
+			// Report names must be unique to be distinguishable in the frontend
+			"CREATE UNIQUE INDEX " +
+				"`idx_1747987200_01_a` " +
+				"ON `saved_reports` " +
+				"(`name`)",
+		}
+
+		// Save the collection to the database
+		return app.Save(c)
+	}, func(app core.App) error {
+		// Migrate down - Removes the collection if the migration needs to be rolled back
+		collection, err := app.FindCollectionByNameOrId("pbc_1747987200_01")
+		if err != nil {
+			return err
+		}
+
+		// Delete the collection and all its records
+		return app.Delete(collection)
+	})
+}
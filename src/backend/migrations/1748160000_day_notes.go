@@ -0,0 +1,115 @@
+/**
+ * Day Notes Migration
+ *
+ * This migration creates the day_notes collection, which stores a free-text note per day and
+ * clock, shown next to the sessions of the day in the journal.
+ *
+ * Days are stored as plain dates (YYYY-MM-DD), since a note belongs to the day as the user saw
+ * it, independent of the timezone the journal is viewed in.
+ *
+ * The migration includes:
+ * 1. Creation of the day_notes collection
+ * 2. Setup of a unique index on the clock and date
+ * 3. Implementation of both up and down migration functions
+ */
+package migrations
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+)
+
+func init() {
+	m.Register(func(app core.App) error {
+		// Migrate up - Creates the day_notes collection
+		c := &core.Collection{}
+
+		// Collection identification
+		c.Id = "pbc_1748160000_01"
+		c.Name = "day_notes"
+		c.Type = "base"
+
+		// Security rules
+		// Notes can be read and deleted like the work clock records, but are only written by the
+		// journal endpoint, which keeps one note per day.
+		c.CreateRule = nil
+		c.DeleteRule = ref("")
+		c.ListRule = ref("")
+		c.UpdateRule = nil
+		c.ViewRule = ref("")
+
+		// Field definitions for the day_notes collection
+		c.Fields = []core.Field{
+			// Primary key field - Automatically generated ID
+			&core.TextField{
+				PrimaryKey: true,
+				System:     true,
+				Required:   true,
+
+				Id:   "field_1748160000_01_a",
+				Name: "id",
+
+				AutogeneratePattern: "[a-z0-9]{15}",
+				Min:                 15,
+				Max:                 15,
+				Pattern:             "^[a-z0-9]+$",
+			},
+			// Clock field - Clock of the note, empty for the default clock
+			&core.RelationField{
+				Id:   "field_1748160000_01_b",
+				Name: "clock",
+
+				CollectionId:  "pbc_1746432000_01",
+				CascadeDelete: true,
+				MaxSelect:     1,
+			},
+			// Date field - Day of the note (YYYY-MM-DD)
+			&core.TextField{
+				Required: true,
+
+				Id:   "field_1748160000_01_c",
+				Name: "date",
+
+				Pattern: `^\d{4}-\d{2}-\d{2}$`,
+			},
+			// Note field - Free text about the day
+			&core.TextField{
+				Required: true,
+
+				Id:   "field_1748160000_01_d",
+				Name: "note",
+
+				Max: 10000,
+			},
+			// Updated field - Time the note was last changed
+			&core.AutodateField{
+				Id:   "field_1748160000_01_e",
+				Name: "updated",
+
+				OnCreate: true,
+				OnUpdate: true,
+			},
+		}
+
+		// Database indexes for query optimization and data integrity
+		c.Indexes = []string{
+			// There is one note per clock and day
+			"CREATE UNIQUE INDEX " +
+				"`idx_1748160000_01_a` " +
+				"ON `day_notes` " +
+				"(`clock`, `date`)",
+		}
+
+		// Save the collection to the database
+		return app.Save(c)
+	}, func(app core.App) error {
+		// Migrate down - Removes the collection if the migration needs to be rolled back
+		collection, err := app.FindCollectionByNameOrId("pbc_1748160000_01")
+		if err != nil {
+			return err
+		}
+
+		// Delete the collection and all its records
+		return app.Delete(collection)
+	})
+}
@@ -0,0 +1,52 @@
+/**
+ * Note Search Migration
+ *
+ * This migration creates the note_search table, a SQLite FTS5 full-text index over the day notes
+ * and the session descriptions, which is maintained by the note search module whenever notes or
+ * work clock records change.
+ *
+ * The index is derived data and not a PocketBase collection, so it is neither exposed through the
+ * records API nor part of the collection schema. Each row references its source record:
+ * - kind: 'note' for day notes, 'session' for session descriptions
+ * - record: ID of the day_notes or clock in record
+ * - clock: Clock of the record, empty for the default clock
+ * - time: Day of the note (YYYY-MM-DD) or timestamp of the clock in record
+ * - text: The indexed note or description
+ *
+ * The migration includes:
+ * 1. Creation of the note_search FTS5 table
+ * 2. Indexing of the existing notes and session descriptions
+ * 3. Implementation of both up and down migration functions
+ */
+package migrations
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+)
+
+func init() {
+	m.Register(func(app core.App) error {
+		// Migrate up - Creates and fills the note_search table
+		// Diacritics are removed, so a search for 'uber' also finds 'Über'
+		if _, err := app.DB().NewQuery("CREATE VIRTUAL TABLE `note_search` USING fts5(" +
+			"`kind` UNINDEXED, `record` UNINDEXED, `clock` UNINDEXED, `time` UNINDEXED, `text`, " +
+			"tokenize = 'unicode61 remove_diacritics 2')").Execute(); err != nil {
+			return err
+		}
+
+		if _, err := app.DB().NewQuery("INSERT INTO `note_search` (`kind`, `record`, `clock`, `time`, `text`) " +
+			"SELECT 'note', `id`, `clock`, `date`, `note` FROM `day_notes`").Execute(); err != nil {
+			return err
+		}
+
+		_, err := app.DB().NewQuery("INSERT INTO `note_search` (`kind`, `record`, `clock`, `time`, `text`) " +
+			"SELECT 'session', `id`, `clock`, `timestamp`, `description` FROM `work_clock` " +
+			"WHERE `clock_in` = TRUE AND `description` != ''").Execute()
+		return err
+	}, func(app core.App) error {
+		// Migrate down - Removes the table if the migration needs to be rolled back
+		_, err := app.DB().NewQuery("DROP TABLE IF EXISTS `note_search`").Execute()
+		return err
+	})
+}
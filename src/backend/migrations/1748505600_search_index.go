@@ -0,0 +1,45 @@
+/**
+ * Search Index Migration
+ *
+ * This migration turns the note_search table into the search_index table, which also covers the
+ * names of projects and tags, so a single search finds everything the user wrote. The rows of
+ * projects and tags use the kinds 'project' and 'tag', and an empty clock and time, since they
+ * are shared by all clocks.
+ *
+ * The migration includes:
+ * 1. Renaming of the note_search table to search_index
+ * 2. Indexing of the existing project and tag names
+ * 3. Implementation of both up and down migration functions
+ */
+package migrations
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+)
+
+func init() {
+	m.Register(func(app core.App) error {
+		// Migrate up - Renames the table and indexes projects and tags
+		if _, err := app.DB().NewQuery("ALTER TABLE `note_search` RENAME TO `search_index`").Execute(); err != nil {
+			return err
+		}
+
+		if _, err := app.DB().NewQuery("INSERT INTO `search_index` (`kind`, `record`, `clock`, `time`, `text`) " +
+			"SELECT 'project', `id`, '', '', `name` FROM `projects`").Execute(); err != nil {
+			return err
+		}
+
+		_, err := app.DB().NewQuery("INSERT INTO `search_index` (`kind`, `record`, `clock`, `time`, `text`) " +
+			"SELECT 'tag', `id`, '', '', `name` FROM `tags`").Execute()
+		return err
+	}, func(app core.App) error {
+		// Migrate down - Removes projects and tags and restores the previous table name
+		if _, err := app.DB().NewQuery("DELETE FROM `search_index` WHERE `kind` IN ('project', 'tag')").Execute(); err != nil {
+			return err
+		}
+
+		_, err := app.DB().NewQuery("ALTER TABLE `search_index` RENAME TO `note_search`").Execute()
+		return err
+	})
+}
@@ -0,0 +1,116 @@
+/**
+ * Work Schedules Migration
+ *
+ * This migration creates the work_schedules collection, which stores the target hours of a clock
+ * with the day they take effect. A schedule applies from its day until the next schedule of the
+ * same clock, so a contract change only affects the days after it.
+ *
+ * Days are stored as plain dates (YYYY-MM-DD), since a contract change takes effect on the day
+ * as the user saw it, independent of the timezone.
+ *
+ * The migration includes:
+ * 1. Creation of the work_schedules collection
+ * 2. Setup of a unique index on the clock and the effective day
+ * 3. Implementation of both up and down migration functions
+ */
+package migrations
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+)
+
+func init() {
+	m.Register(func(app core.App) error {
+		// Migrate up - Creates the work_schedules collection
+		c := &core.Collection{}
+
+		// Collection identification
+		c.Id = "pbc_1748678400_01"
+		c.Name = "work_schedules"
+		c.Type = "base"
+
+		// Security rules
+		// Schedules are managed by the user just like the work clock records themselves.
+		c.CreateRule = ref("")
+		c.DeleteRule = ref("")
+		c.ListRule = ref("")
+		c.UpdateRule = ref("")
+		c.ViewRule = ref("")
+
+		// Field definitions for the work_schedules collection
+		c.Fields = []core.Field{
+			// Primary key field - Automatically generated ID
+			&core.TextField{
+				PrimaryKey: true,
+				System:     true,
+				Required:   true,
+
+				Id:   "field_1748678400_01_a",
+				Name: "id",
+
+				AutogeneratePattern: "[a-z0-9]{15}",
+				Min:                 15,
+				Max:                 15,
+				Pattern:             "^[a-z0-9]+$",
+			},
+			// Clock field - Clock the schedule belongs to, empty for the default clock
+			&core.RelationField{
+				Id:   "field_1748678400_01_b",
+				Name: "clock",
+
+				CollectionId:  "pbc_1746432000_01",
+				CascadeDelete: true,
+				MaxSelect:     1,
+			},
+			// Valid from field - First day the schedule applies to (YYYY-MM-DD)
+			&core.TextField{
+				Required:    true,
+				Presentable: true,
+
+				Id:   "field_1748678400_01_c",
+				Name: "valid_from",
+
+				Pattern: `^\d{4}-\d{2}-\d{2}$`,
+			},
+			// Workday minutes field - Target of each workday in minutes
+			&core.NumberField{
+				Id:   "field_1748678400_01_d",
+				Name: "workday_minutes",
+
+				Min:     ref(0.0),
+				Max:     ref(24 * 60.0),
+				OnlyInt: true,
+			},
+			// Workdays field - Weekdays with a target, days without one count as days off
+			&core.SelectField{
+				Id:   "field_1748678400_01_e",
+				Name: "workdays",
+
+				MaxSelect: 7,
+				Values:    []string{"monday", "tuesday", "wednesday", "thursday", "friday", "saturday", "sunday"},
+			},
+		}
+
+		// Database indexes for query optimization and data integrity
+		c.Indexes = []string{
+			// A clock has one schedule per effective day
+			"CREATE UNIQUE INDEX " +
+				"`idx_1748678400_01_a` " +
+				"ON `work_schedules` " +
+				"(`clock`, `valid_from`)",
+		}
+
+		// Save the collection to the database
+		return app.Save(c)
+	}, func(app core.App) error {
+		// Migrate down - Removes the collection if the migration needs to be rolled back
+		collection, err := app.FindCollectionByNameOrId("pbc_1748678400_01")
+		if err != nil {
+			return err
+		}
+
+		// Delete the collection and all its records
+		return app.Delete(collection)
+	})
+}
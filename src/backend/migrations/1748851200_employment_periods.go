@@ -0,0 +1,107 @@
+/**
+ * Employment Periods Migration
+ *
+ * This migration creates the employment_periods collection, which stores the days a clock's
+ * owner was employed, from the hire to the termination date. Days outside of all periods have no
+ * target and are skipped by the compliance checks, and the backfill tools refuse to create
+ * sessions on them.
+ *
+ * Days are stored as plain dates (YYYY-MM-DD), since a contract starts and ends on the day as
+ * the user saw it, independent of the timezone.
+ *
+ * The migration includes:
+ * 1. Creation of the employment_periods collection
+ * 2. Setup of a unique index on the clock and the start day
+ * 3. Implementation of both up and down migration functions
+ */
+package migrations
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+)
+
+func init() {
+	m.Register(func(app core.App) error {
+		// Migrate up - Creates the employment_periods collection
+		c := &core.Collection{}
+
+		// Collection identification
+		c.Id = "pbc_1748851200_01"
+		c.Name = "employment_periods"
+		c.Type = "base"
+
+		// Security rules
+		// Employment periods are managed by the user just like the work clock records themselves.
+		c.CreateRule = ref("")
+		c.DeleteRule = ref("")
+		c.ListRule = ref("")
+		c.UpdateRule = ref("")
+		c.ViewRule = ref("")
+
+		// Field definitions for the employment_periods collection
+		c.Fields = []core.Field{
+			// Primary key field - Automatically generated ID
+			&core.TextField{
+				PrimaryKey: true,
+				System:     true,
+				Required:   true,
+
+				Id:   "field_1748851200_01_a",
+				Name: "id",
+
+				AutogeneratePattern: "[a-z0-9]{15}",
+				Min:                 15,
+				Max:                 15,
+				Pattern:             "^[a-z0-9]+$",
+			},
+			// Clock field - Clock the period belongs to, empty for the default clock
+			&core.RelationField{
+				Id:   "field_1748851200_01_b",
+				Name: "clock",
+
+				CollectionId:  "pbc_1746432000_01",
+				CascadeDelete: true,
+				MaxSelect:     1,
+			},
+			// Start field - Hire date, the first day of the employment (YYYY-MM-DD)
+			&core.TextField{
+				Required:    true,
+				Presentable: true,
+
+				Id:   "field_1748851200_01_c",
+				Name: "start",
+
+				Pattern: `^\d{4}-\d{2}-\d{2}$`,
+			},
+			// End field - Termination date, the last day of the employment (YYYY-MM-DD), empty while employed
+			&core.TextField{
+				Id:   "field_1748851200_01_d",
+				Name: "end",
+
+				Pattern: `^\d{4}-\d{2}-\d{2}$`,
+			},
+		}
+
+		// Database indexes for query optimization and data integrity
+		c.Indexes = []string{
+			// A clock has one employment period per start day
+			"CREATE UNIQUE INDEX " +
+				"`idx_1748851200_01_a` " +
+				"ON `employment_periods` " +
+				"(`clock`, `start`)",
+		}
+
+		// Save the collection to the database
+		return app.Save(c)
+	}, func(app core.App) error {
+		// Migrate down - Removes the collection if the migration needs to be rolled back
+		collection, err := app.FindCollectionByNameOrId("pbc_1748851200_01")
+		if err != nil {
+			return err
+		}
+
+		// Delete the collection and all its records
+		return app.Delete(collection)
+	})
+}
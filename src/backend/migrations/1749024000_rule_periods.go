@@ -0,0 +1,121 @@
+/**
+ * Rule Periods Migration
+ *
+ * This migration creates the rule_periods collection, which overrides the compliance rules of a
+ * clock for a period, e.g. the probation period of an apprentice. A period selects the rules
+ * profile the days are checked against and whether overtime accrues on them.
+ *
+ * Days are stored as plain dates (YYYY-MM-DD), since a period starts and ends on the day as the
+ * user saw it, independent of the timezone.
+ *
+ * The migration includes:
+ * 1. Creation of the rule_periods collection
+ * 2. Setup of a unique index on the clock and the start day
+ * 3. Implementation of both up and down migration functions
+ */
+package migrations
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+)
+
+func init() {
+	m.Register(func(app core.App) error {
+		// Migrate up - Creates the rule_periods collection
+		c := &core.Collection{}
+
+		// Collection identification
+		c.Id = "pbc_1749024000_01"
+		c.Name = "rule_periods"
+		c.Type = "base"
+
+		// Security rules
+		// Rule periods are managed by the user just like the work clock records themselves.
+		c.CreateRule = ref("")
+		c.DeleteRule = ref("")
+		c.ListRule = ref("")
+		c.UpdateRule = ref("")
+		c.ViewRule = ref("")
+
+		// Field definitions for the rule_periods collection
+		c.Fields = []core.Field{
+			// Primary key field - Automatically generated ID
+			&core.TextField{
+				PrimaryKey: true,
+				System:     true,
+				Required:   true,
+
+				Id:   "field_1749024000_01_a",
+				Name: "id",
+
+				AutogeneratePattern: "[a-z0-9]{15}",
+				Min:                 15,
+				Max:                 15,
+				Pattern:             "^[a-z0-9]+$",
+			},
+			// Clock field - Clock the period belongs to, empty for the default clock
+			&core.RelationField{
+				Id:   "field_1749024000_01_b",
+				Name: "clock",
+
+				CollectionId:  "pbc_1746432000_01",
+				CascadeDelete: true,
+				MaxSelect:     1,
+			},
+			// Start field - First day of the period (YYYY-MM-DD)
+			&core.TextField{
+				Required:    true,
+				Presentable: true,
+
+				Id:   "field_1749024000_01_c",
+				Name: "start",
+
+				Pattern: `^\d{4}-\d{2}-\d{2}$`,
+			},
+			// End field - Last day of the period (YYYY-MM-DD), empty for an open-ended period
+			&core.TextField{
+				Id:   "field_1749024000_01_d",
+				Name: "end",
+
+				Pattern: `^\d{4}-\d{2}-\d{2}$`,
+			},
+			// Profile field - Rules profile the days of the period are checked against
+			&core.SelectField{
+				Required: true,
+
+				Id:   "field_1749024000_01_e",
+				Name: "profile",
+
+				MaxSelect: 1,
+				Values:    []string{"standard"},
+			},
+			// No overtime field - Whether overtime doesn't accrue, only undertime counts towards the balance
+			&core.BoolField{
+				Id:   "field_1749024000_01_f",
+				Name: "no_overtime",
+			},
+		}
+
+		// Database indexes for query optimization and data integrity
+		c.Indexes = []string{
+			// A clock has one rule period per start day
+			"CREATE UNIQUE INDEX " +
+				"`idx_1749024000_01_a` " +
+				"ON `rule_periods` " +
+				"(`clock`, `start`)",
+		}
+
+		// Save the collection to the database
+		return app.Save(c)
+	}, func(app core.App) error {
+		// Migrate down - Removes the collection if the migration needs to be rolled back
+		collection, err := app.FindCollectionByNameOrId("pbc_1749024000_01")
+		if err != nil {
+			return err
+		}
+
+		// Delete the collection and all its records
+		return app.Delete(collection)
+	})
+}
@@ -0,0 +1,47 @@
+/**
+ * Youth Rules Profile Migration
+ *
+ * This migration adds the youth rules profile for rule periods. Days of apprentices under 18 are
+ * checked against the stricter limits of the German Youth Employment Protection Act.
+ *
+ * The migration includes:
+ * 1. Addition of the 'youth' value to the profile field of the rule_periods collection
+ * 2. Implementation of both up and down migration functions
+ */
+package migrations
+
+import (
+	"slices"
+
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+)
+
+func init() {
+	m.Register(func(app core.App) error {
+		// Migrate up - Adds the 'youth' profile
+		rulePeriods, err := app.FindCollectionByNameOrId("pbc_1749024000_01")
+		if err != nil {
+			return err
+		}
+
+		// Profile field - Additionally allows the youth profile
+		if profile, ok := rulePeriods.Fields.GetById("field_1749024000_01_e").(*core.SelectField); ok {
+			profile.Values = []string{"standard", "youth"}
+		}
+
+		return app.Save(rulePeriods)
+	}, func(app core.App) error {
+		// Migrate down - Removes the 'youth' profile
+		rulePeriods, err := app.FindCollectionByNameOrId("pbc_1749024000_01")
+		if err != nil {
+			return err
+		}
+
+		if profile, ok := rulePeriods.Fields.GetById("field_1749024000_01_e").(*core.SelectField); ok {
+			profile.Values = slices.DeleteFunc(profile.Values, func(value string) bool { return value == "youth" })
+		}
+
+		return app.Save(rulePeriods)
+	})
+}
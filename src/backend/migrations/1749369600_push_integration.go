@@ -0,0 +1,47 @@
+/**
+ * Push Integration Migration
+ *
+ * This migration adds the push notification integration, which sends reminders and compliance
+ * alerts through a self-hosted ntfy or Gotify server.
+ *
+ * The migration includes:
+ * 1. Addition of the 'push' value to the name field of the integrations collection
+ * 2. Implementation of both up and down migration functions
+ */
+package migrations
+
+import (
+	"slices"
+
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+)
+
+func init() {
+	m.Register(func(app core.App) error {
+		// Migrate up - Adds the 'push' integration
+		integrations, err := app.FindCollectionByNameOrId("pbc_1745913600_01")
+		if err != nil {
+			return err
+		}
+
+		// Name field - Additionally allows the push integration
+		if name, ok := integrations.Fields.GetById("field_1745913600_01_b").(*core.SelectField); ok {
+			name.Values = []string{"webhooks", "slack", "mqtt", "calendar", "push"}
+		}
+
+		return app.Save(integrations)
+	}, func(app core.App) error {
+		// Migrate down - Removes the 'push' integration
+		integrations, err := app.FindCollectionByNameOrId("pbc_1745913600_01")
+		if err != nil {
+			return err
+		}
+
+		if name, ok := integrations.Fields.GetById("field_1745913600_01_b").(*core.SelectField); ok {
+			name.Values = slices.DeleteFunc(name.Values, func(value string) bool { return value == "push" })
+		}
+
+		return app.Save(integrations)
+	})
+}
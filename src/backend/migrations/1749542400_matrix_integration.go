@@ -0,0 +1,47 @@
+/**
+ * Matrix Integration Migration
+ *
+ * This migration adds the Matrix integration, which operates the work clock with commands in a
+ * Matrix room and posts the summary of the day there.
+ *
+ * The migration includes:
+ * 1. Addition of the 'matrix' value to the name field of the integrations collection
+ * 2. Implementation of both up and down migration functions
+ */
+package migrations
+
+import (
+	"slices"
+
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+)
+
+func init() {
+	m.Register(func(app core.App) error {
+		// Migrate up - Adds the 'matrix' integration
+		integrations, err := app.FindCollectionByNameOrId("pbc_1745913600_01")
+		if err != nil {
+			return err
+		}
+
+		// Name field - Additionally allows the Matrix integration
+		if name, ok := integrations.Fields.GetById("field_1745913600_01_b").(*core.SelectField); ok {
+			name.Values = []string{"webhooks", "slack", "mqtt", "calendar", "push", "matrix"}
+		}
+
+		return app.Save(integrations)
+	}, func(app core.App) error {
+		// Migrate down - Removes the 'matrix' integration
+		integrations, err := app.FindCollectionByNameOrId("pbc_1745913600_01")
+		if err != nil {
+			return err
+		}
+
+		if name, ok := integrations.Fields.GetById("field_1745913600_01_b").(*core.SelectField); ok {
+			name.Values = slices.DeleteFunc(name.Values, func(value string) bool { return value == "matrix" })
+		}
+
+		return app.Save(integrations)
+	})
+}
@@ -0,0 +1,128 @@
+/**
+ * Events Migration
+ *
+ * This migration creates the events collection, the log of what the background subsystems did:
+ * completed or failed backups and imports, failed webhook and integration deliveries, and
+ * reminders about forgotten sessions. Each event has a machine-readable type, a level, a message
+ * and event specific data.
+ *
+ * Events are recorded by the backend only and may contain URLs of integrations, so the collection
+ * is only accessible for superusers.
+ *
+ * The migration includes:
+ * 1. Creation of the events collection
+ * 2. Setup of indexes on the creation time and the type of the events
+ * 3. Implementation of both up and down migration functions
+ */
+package migrations
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+)
+
+func init() {
+	m.Register(func(app core.App) error {
+		// Migrate up - Creates the events collection
+		c := &core.Collection{}
+
+		// Collection identification
+		c.Id = "pbc_1749715200_01"
+		c.Name = "events"
+		c.Type = "base"
+
+		// Security rules
+		// Events are only accessible for superusers and only recorded by the backend.
+		c.CreateRule = nil
+		c.DeleteRule = nil
+		c.ListRule = nil
+		c.UpdateRule = nil
+		c.ViewRule = nil
+
+		// Field definitions for the events collection
+		c.Fields = []core.Field{
+			// Primary key field - Automatically generated ID
+			&core.TextField{
+				PrimaryKey: true,
+				System:     true,
+				Required:   true,
+
+				Id:   "field_1749715200_01_a",
+				Name: "id",
+
+				AutogeneratePattern: "[a-z0-9]{15}",
+				Min:                 15,
+				Max:                 15,
+				Pattern:             "^[a-z0-9]+$",
+			},
+			// Type field - Machine-readable type of the event (e.g. "backup_completed")
+			&core.TextField{
+				Presentable: true,
+				Required:    true,
+
+				Id:   "field_1749715200_01_b",
+				Name: "type",
+
+				Max: 100,
+			},
+			// Level field - Severity of the event
+			&core.SelectField{
+				Required: true,
+
+				Id:   "field_1749715200_01_c",
+				Name: "level",
+
+				MaxSelect: 1,
+				Values:    []string{"info", "warning", "error"},
+			},
+			// Message field - Human-readable description of the event
+			&core.TextField{
+				Id:   "field_1749715200_01_d",
+				Name: "message",
+
+				Max: 2000,
+			},
+			// Data field - Event specific details, for example:
+			// {"url": "https://example.com/hook", "event": "clock_in"}
+			&core.JSONField{
+				Id:   "field_1749715200_01_e",
+				Name: "data",
+
+				MaxSize: 16 * 1024,
+			},
+			// Created field - Time the event happened
+			&core.AutodateField{
+				Id:   "field_1749715200_01_f",
+				Name: "created",
+
+				OnCreate: true,
+			},
+		}
+
+		// Database indexes for query optimization and data integrity
+		c.Indexes = []string{
+			// The events are listed newest first
+			"CREATE INDEX " +
+				"`idx_1749715200_01_a` " +
+				"ON `events` " +
+				"(`created`)",
+			// The events are filtered by their type
+			"CREATE INDEX " +
+				"`idx_1749715200_01_b` " +
+				"ON `events` " +
+				"(`type`, `created`)",
+		}
+
+		// Save the collection to the database
+		return app.Save(c)
+	}, func(app core.App) error {
+		// Migrate down - Removes the collection if the migration needs to be rolled back
+		collection, err := app.FindCollectionByNameOrId("pbc_1749715200_01")
+		if err != nil {
+			return err
+		}
+
+		// Delete the collection and all its records
+		return app.Delete(collection)
+	})
+}
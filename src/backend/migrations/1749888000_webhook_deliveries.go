@@ -0,0 +1,160 @@
+/**
+ * Webhook Deliveries Migration
+ *
+ * This migration creates the webhook_deliveries collection. Every attempt to deliver a webhook
+ * event is recorded with a snapshot of the request and the response, so failed deliveries can be
+ * inspected and replayed instead of being lost after a log line.
+ *
+ * Deliveries are recorded by the backend only and contain the webhook URLs, which may include
+ * secrets, so the collection is only accessible for superusers.
+ *
+ * The migration includes:
+ * 1. Creation of the webhook_deliveries collection
+ * 2. Setup of indexes on the creation time and the outcome of the deliveries
+ * 3. Implementation of both up and down migration functions
+ */
+package migrations
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+)
+
+func init() {
+	m.Register(func(app core.App) error {
+		// Migrate up - Creates the webhook_deliveries collection
+		c := &core.Collection{}
+
+		// Collection identification
+		c.Id = "pbc_1749888000_01"
+		c.Name = "webhook_deliveries"
+		c.Type = "base"
+
+		// Security rules
+		// Deliveries are only accessible for superusers and only recorded by the backend.
+		c.CreateRule = nil
+		c.DeleteRule = nil
+		c.ListRule = nil
+		c.UpdateRule = nil
+		c.ViewRule = nil
+
+		// Field definitions for the webhook_deliveries collection
+		c.Fields = []core.Field{
+			// Primary key field - Automatically generated ID
+			&core.TextField{
+				PrimaryKey: true,
+				System:     true,
+				Required:   true,
+
+				Id:   "field_1749888000_01_a",
+				Name: "id",
+
+				AutogeneratePattern: "[a-z0-9]{15}",
+				Min:                 15,
+				Max:                 15,
+				Pattern:             "^[a-z0-9]+$",
+			},
+			// URL field - The webhook URL the event was delivered to
+			&core.TextField{
+				Required: true,
+
+				Id:   "field_1749888000_01_b",
+				Name: "url",
+
+				Max: 2000,
+			},
+			// Event field - Name of the delivered event (e.g. "work_clock.clock_in")
+			&core.TextField{
+				Presentable: true,
+				Required:    true,
+
+				Id:   "field_1749888000_01_c",
+				Name: "event",
+
+				Max: 200,
+			},
+			// Request body field - The JSON body that was sent
+			&core.JSONField{
+				Id:   "field_1749888000_01_d",
+				Name: "request_body",
+
+				MaxSize: 64 * 1024,
+			},
+			// Succeeded field - Whether the receiver responded with a 2xx status
+			&core.BoolField{
+				Id:   "field_1749888000_01_e",
+				Name: "succeeded",
+			},
+			// Status field - HTTP status of the response, 0 if no response was received
+			&core.NumberField{
+				Id:   "field_1749888000_01_f",
+				Name: "status",
+
+				Min:     ref(0.0),
+				OnlyInt: true,
+			},
+			// Response body field - The beginning of the response body
+			&core.TextField{
+				Id:   "field_1749888000_01_g",
+				Name: "response_body",
+
+				Max: 2000,
+			},
+			// Error field - Why the delivery failed, empty for successful deliveries
+			&core.TextField{
+				Id:   "field_1749888000_01_h",
+				Name: "error",
+
+				Max: 2000,
+			},
+			// Duration field - Duration of the delivery in milliseconds
+			&core.NumberField{
+				Id:   "field_1749888000_01_i",
+				Name: "duration_ms",
+
+				Min:     ref(0.0),
+				OnlyInt: true,
+			},
+			// Replay of field - ID of the delivery this one replayed, empty for original deliveries
+			&core.TextField{
+				Id:   "field_1749888000_01_j",
+				Name: "replay_of",
+
+				Max: 15,
+			},
+			// Created field - Time of the delivery
+			&core.AutodateField{
+				Id:   "field_1749888000_01_k",
+				Name: "created",
+
+				OnCreate: true,
+			},
+		}
+
+		// Database indexes for query optimization and data integrity
+		c.Indexes = []string{
+			// The deliveries are listed newest first
+			"CREATE INDEX " +
+				"`idx_1749888000_01_a` " +
+				"ON `webhook_deliveries` " +
+				"(`created`)",
+			// The failed deliveries are listed on their own
+			"CREATE INDEX " +
+				"`idx_1749888000_01_b` " +
+				"ON `webhook_deliveries` " +
+				"(`succeeded`, `created`)",
+		}
+
+		// Save the collection to the database
+		return app.Save(c)
+	}, func(app core.App) error {
+		// Migrate down - Removes the collection if the migration needs to be rolled back
+		collection, err := app.FindCollectionByNameOrId("pbc_1749888000_01")
+		if err != nil {
+			return err
+		}
+
+		// Delete the collection and all its records
+		return app.Delete(collection)
+	})
+}
@@ -0,0 +1,66 @@
+/**
+ * Ledger Actor Migration
+ *
+ * This migration adds the identities behind a change to the work_clock_ledger collection. Changes
+ * made by the user themselves leave the fields empty; corrections a superuser makes on behalf of
+ * the user (see the support corrections module) record the superuser, the person the correction
+ * was made for and the reason, so both identities are part of the audit trail.
+ *
+ * The migration includes:
+ * 1. Addition of the actor, on_behalf_of and reason fields to the work_clock_ledger collection
+ * 2. Implementation of both up and down migration functions
+ */
+package migrations
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+)
+
+func init() {
+	m.Register(func(app core.App) error {
+		// Migrate up - Adds the actor fields to the work_clock_ledger collection
+		ledger, err := app.FindCollectionByNameOrId("pbc_1745740800_01")
+		if err != nil {
+			return err
+		}
+
+		// Actor field - Email of the superuser who made the change, empty for changes by the user
+		ledger.Fields.Add(&core.TextField{
+			Id:   "field_1745740800_01_j",
+			Name: "actor",
+
+			Max: 255,
+		})
+
+		// On behalf of field - Person the superuser made the change for
+		ledger.Fields.Add(&core.TextField{
+			Id:   "field_1745740800_01_k",
+			Name: "on_behalf_of",
+
+			Max: 255,
+		})
+
+		// Reason field - Why the superuser made the change
+		ledger.Fields.Add(&core.TextField{
+			Id:   "field_1745740800_01_l",
+			Name: "reason",
+
+			Max: 1000,
+		})
+
+		return app.Save(ledger)
+	}, func(app core.App) error {
+		// Migrate down - Removes the actor fields from the work_clock_ledger collection
+		ledger, err := app.FindCollectionByNameOrId("pbc_1745740800_01")
+		if err != nil {
+			return err
+		}
+
+		ledger.Fields.RemoveById("field_1745740800_01_j")
+		ledger.Fields.RemoveById("field_1745740800_01_k")
+		ledger.Fields.RemoveById("field_1745740800_01_l")
+
+		return app.Save(ledger)
+	})
+}
@@ -0,0 +1,167 @@
+/**
+ * Delegations Migration
+ *
+ * This migration adds owners to clocks and creates the delegations collection. A clock with an
+ * owner can only be changed by that user, by superusers, and by the users the owner delegated
+ * access to for a range of days, e.g. an assistant managing the clock of a manager during their
+ * vacation. Clocks without an owner, including the default clock, stay open to everyone.
+ *
+ * The migration includes:
+ * 1. Addition of the owner field to the clocks collection, which only the owner can change
+ * 2. Creation of the delegations collection, managed by the owners through the records API
+ * 3. Setup of an index on the delegate and owner
+ * 4. Implementation of both up and down migration functions
+ */
+package migrations
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+)
+
+func init() {
+	m.Register(func(app core.App) error {
+		// Migrate up - Adds the owner of clocks and creates the delegations collection
+		users, err := app.FindCollectionByNameOrId("users")
+		if err != nil {
+			return err
+		}
+
+		clocks, err := app.FindCollectionByNameOrId("pbc_1746432000_01")
+		if err != nil {
+			return err
+		}
+
+		// Owner field - User the clock belongs to, empty for clocks open to everyone
+		clocks.Fields.Add(&core.RelationField{
+			Id:   "field_1746432000_01_d",
+			Name: "owner",
+
+			CollectionId:  users.Id,
+			CascadeDelete: false,
+			MaxSelect:     1,
+		})
+
+		// Owned clocks can only be changed and deleted by their owner
+		clocks.UpdateRule = ref("owner = '' || owner = @request.auth.id")
+		clocks.DeleteRule = ref("owner = '' || owner = @request.auth.id")
+
+		if err := app.Save(clocks); err != nil {
+			return err
+		}
+
+		c := &core.Collection{}
+
+		// Collection identification
+		c.Id = "pbc_1750233600_01"
+		c.Name = "delegations"
+		c.Type = "base"
+
+		// Security rules
+		// Owners manage their delegations, delegates can see the delegations granted to them.
+		c.CreateRule = ref("@request.auth.id != '' && owner = @request.auth.id")
+		c.DeleteRule = ref("owner = @request.auth.id")
+		c.ListRule = ref("owner = @request.auth.id || delegate = @request.auth.id")
+		c.UpdateRule = ref("owner = @request.auth.id && @request.body.owner:isset = false")
+		c.ViewRule = ref("owner = @request.auth.id || delegate = @request.auth.id")
+
+		// Field definitions for the delegations collection
+		c.Fields = []core.Field{
+			// Primary key field - Automatically generated ID
+			&core.TextField{
+				PrimaryKey: true,
+				System:     true,
+				Required:   true,
+
+				Id:   "field_1750233600_01_a",
+				Name: "id",
+
+				AutogeneratePattern: "[a-z0-9]{15}",
+				Min:                 15,
+				Max:                 15,
+				Pattern:             "^[a-z0-9]+$",
+			},
+			// Owner field - User granting access to their clocks
+			&core.RelationField{
+				Required: true,
+
+				Id:   "field_1750233600_01_b",
+				Name: "owner",
+
+				CollectionId:  users.Id,
+				CascadeDelete: true,
+				MaxSelect:     1,
+			},
+			// Delegate field - User allowed to clock and edit on behalf of the owner
+			&core.RelationField{
+				Required: true,
+
+				Id:   "field_1750233600_01_c",
+				Name: "delegate",
+
+				CollectionId:  users.Id,
+				CascadeDelete: true,
+				MaxSelect:     1,
+			},
+			// From field - First day of the delegation (YYYY-MM-DD)
+			&core.TextField{
+				Required: true,
+
+				Id:   "field_1750233600_01_d",
+				Name: "from",
+
+				Pattern: `^\d{4}-\d{2}-\d{2}$`,
+			},
+			// To field - Last day of the delegation (YYYY-MM-DD), inclusive
+			&core.TextField{
+				Required: true,
+
+				Id:   "field_1750233600_01_e",
+				Name: "to",
+
+				Pattern: `^\d{4}-\d{2}-\d{2}$`,
+			},
+			// Note field - Optional reason of the delegation
+			&core.TextField{
+				Id:   "field_1750233600_01_f",
+				Name: "note",
+
+				Max: 1000,
+			},
+		}
+
+		// Database indexes for query optimization and data integrity
+		c.Indexes = []string{
+			// Delegations are looked up by the delegate and the owner of a clock
+			"CREATE INDEX " +
+				"`idx_1750233600_01_a` " +
+				"ON `delegations` " +
+				"(`delegate`, `owner`)",
+		}
+
+		// Save the collection to the database
+		return app.Save(c)
+	}, func(app core.App) error {
+		// Migrate down - Removes the delegations collection and the owner of clocks
+		collection, err := app.FindCollectionByNameOrId("pbc_1750233600_01")
+		if err != nil {
+			return err
+		}
+
+		// Delete the collection and all its records
+		if err := app.Delete(collection); err != nil {
+			return err
+		}
+
+		clocks, err := app.FindCollectionByNameOrId("pbc_1746432000_01")
+		if err != nil {
+			return err
+		}
+
+		clocks.UpdateRule = ref("")
+		clocks.DeleteRule = ref("")
+		clocks.Fields.RemoveById("field_1746432000_01_d")
+
+		return app.Save(clocks)
+	})
+}
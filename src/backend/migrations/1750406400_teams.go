@@ -0,0 +1,110 @@
+/**
+ * Teams Migration
+ *
+ * This migration creates the teams collection. A team groups users under one or more leads, who
+ * can see the balances, compliance flags and missing days of the clocks owned by the members
+ * (see the teams module). Teams are managed by superusers; leads and members can see their teams.
+ *
+ * The migration includes:
+ * 1. Creation of the teams collection
+ * 2. Setup of a unique index on the team name
+ * 3. Implementation of both up and down migration functions
+ */
+package migrations
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+)
+
+func init() {
+	m.Register(func(app core.App) error {
+		// Migrate up - Creates the teams collection
+		users, err := app.FindCollectionByNameOrId("users")
+		if err != nil {
+			return err
+		}
+
+		c := &core.Collection{}
+
+		// Collection identification
+		c.Id = "pbc_1750406400_01"
+		c.Name = "teams"
+		c.Type = "base"
+
+		// Security rules
+		// Only superusers manage teams, leads and members can see their teams.
+		c.CreateRule = nil
+		c.DeleteRule = nil
+		c.ListRule = ref("leads.id ?= @request.auth.id || members.id ?= @request.auth.id")
+		c.UpdateRule = nil
+		c.ViewRule = ref("leads.id ?= @request.auth.id || members.id ?= @request.auth.id")
+
+		// Field definitions for the teams collection
+		c.Fields = []core.Field{
+			// Primary key field - Automatically generated ID
+			&core.TextField{
+				PrimaryKey: true,
+				System:     true,
+				Required:   true,
+
+				Id:   "field_1750406400_01_a",
+				Name: "id",
+
+				AutogeneratePattern: "[a-z0-9]{15}",
+				Min:                 15,
+				Max:                 15,
+				Pattern:             "^[a-z0-9]+$",
+			},
+			// Name field - Name of the team
+			&core.TextField{
+				Presentable: true,
+				Required:    true,
+
+				Id:   "field_1750406400_01_b",
+				Name: "name",
+
+				Max: 100,
+			},
+			// Leads field - Users who can see the reports of the team
+			&core.RelationField{
+				Required: true,
+
+				Id:   "field_1750406400_01_c",
+				Name: "leads",
+
+				CollectionId: users.Id,
+				MaxSelect:    999,
+			},
+			// Members field - Users whose clocks are reported
+			&core.RelationField{
+				Id:   "field_1750406400_01_d",
+				Name: "members",
+
+				CollectionId: users.Id,
+				MaxSelect:    999,
+			},
+		}
+
+		// Database indexes for query optimization and data integrity
+		c.Indexes = []string{
+			// Team names must be unique
+			"CREATE UNIQUE INDEX " +
+				"`idx_1750406400_01_a` " +
+				"ON `teams` " +
+				"(`name`)",
+		}
+
+		// Save the collection to the database
+		return app.Save(c)
+	}, func(app core.App) error {
+		// Migrate down - Removes the collection if the migration needs to be rolled back
+		collection, err := app.FindCollectionByNameOrId("pbc_1750406400_01")
+		if err != nil {
+			return err
+		}
+
+		// Delete the collection and all its records
+		return app.Delete(collection)
+	})
+}
@@ -0,0 +1,98 @@
+/**
+ * Holidays Migration
+ *
+ * This migration creates the holidays collection, which stores the public holidays. A holiday has
+ * no target on any clock, so it neither counts as a missing day nor lowers the balance, and
+ * absences spanning it don't use it up (see the absences module).
+ *
+ * Days are stored as plain dates (YYYY-MM-DD), since a holiday is the same day for everyone,
+ * independent of the timezone.
+ *
+ * The migration includes:
+ * 1. Creation of the holidays collection
+ * 2. Setup of a unique index on the day
+ * 3. Implementation of both up and down migration functions
+ */
+package migrations
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+)
+
+func init() {
+	m.Register(func(app core.App) error {
+		// Migrate up - Creates the holidays collection
+		c := &core.Collection{}
+
+		// Collection identification
+		c.Id = "pbc_1750579200_01"
+		c.Name = "holidays"
+		c.Type = "base"
+
+		// Security rules
+		// Holidays are managed by the user just like the work schedules.
+		c.CreateRule = ref("")
+		c.DeleteRule = ref("")
+		c.ListRule = ref("")
+		c.UpdateRule = ref("")
+		c.ViewRule = ref("")
+
+		// Field definitions for the holidays collection
+		c.Fields = []core.Field{
+			// Primary key field - Automatically generated ID
+			&core.TextField{
+				PrimaryKey: true,
+				System:     true,
+				Required:   true,
+
+				Id:   "field_1750579200_01_a",
+				Name: "id",
+
+				AutogeneratePattern: "[a-z0-9]{15}",
+				Min:                 15,
+				Max:                 15,
+				Pattern:             "^[a-z0-9]+$",
+			},
+			// Date field - Day of the holiday (YYYY-MM-DD)
+			&core.TextField{
+				Required: true,
+
+				Id:   "field_1750579200_01_b",
+				Name: "date",
+
+				Pattern: `^\d{4}-\d{2}-\d{2}$`,
+			},
+			// Name field - Name of the holiday, e.g. 'New Year'
+			&core.TextField{
+				Presentable: true,
+
+				Id:   "field_1750579200_01_c",
+				Name: "name",
+
+				Max: 200,
+			},
+		}
+
+		// Database indexes for query optimization and data integrity
+		c.Indexes = []string{
+			// A day is a holiday at most once
+			"CREATE UNIQUE INDEX " +
+				"`idx_1750579200_01_a` " +
+				"ON `holidays` " +
+				"(`date`)",
+		}
+
+		// Save the collection to the database
+		return app.Save(c)
+	}, func(app core.App) error {
+		// Migrate down - Removes the collection if the migration needs to be rolled back
+		collection, err := app.FindCollectionByNameOrId("pbc_1750579200_01")
+		if err != nil {
+			return err
+		}
+
+		// Delete the collection and all its records
+		return app.Delete(collection)
+	})
+}
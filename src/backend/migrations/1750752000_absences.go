@@ -0,0 +1,129 @@
+/**
+ * Absences Migration
+ *
+ * This migration creates the absences collection, which stores the days a clock is absent, e.g.
+ * on vacation or sick. An absence day has no target, so it neither counts as a missing day nor
+ * lowers the balance. Absences spanning several days are stored as one record per day, linked by
+ * a common series, so single days can still be changed or removed.
+ *
+ * Days are stored as plain dates (YYYY-MM-DD), since an absence covers the day as the user saw
+ * it, independent of the timezone.
+ *
+ * The migration includes:
+ * 1. Creation of the absences collection
+ * 2. Setup of a unique index on the clock and the day, and an index on the series
+ * 3. Implementation of both up and down migration functions
+ */
+package migrations
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+)
+
+func init() {
+	m.Register(func(app core.App) error {
+		// Migrate up - Creates the absences collection
+		c := &core.Collection{}
+
+		// Collection identification
+		c.Id = "pbc_1750752000_01"
+		c.Name = "absences"
+		c.Type = "base"
+
+		// Security rules
+		// Absences are managed by the user just like the work clock records themselves.
+		c.CreateRule = ref("")
+		c.DeleteRule = ref("")
+		c.ListRule = ref("")
+		c.UpdateRule = ref("")
+		c.ViewRule = ref("")
+
+		// Field definitions for the absences collection
+		c.Fields = []core.Field{
+			// Primary key field - Automatically generated ID
+			&core.TextField{
+				PrimaryKey: true,
+				System:     true,
+				Required:   true,
+
+				Id:   "field_1750752000_01_a",
+				Name: "id",
+
+				AutogeneratePattern: "[a-z0-9]{15}",
+				Min:                 15,
+				Max:                 15,
+				Pattern:             "^[a-z0-9]+$",
+			},
+			// Clock field - Clock the absence belongs to, empty for the default clock
+			&core.RelationField{
+				Id:   "field_1750752000_01_b",
+				Name: "clock",
+
+				CollectionId:  "pbc_1746432000_01",
+				CascadeDelete: true,
+				MaxSelect:     1,
+			},
+			// Date field - Day of the absence (YYYY-MM-DD)
+			&core.TextField{
+				Required:    true,
+				Presentable: true,
+
+				Id:   "field_1750752000_01_c",
+				Name: "date",
+
+				Pattern: `^\d{4}-\d{2}-\d{2}$`,
+			},
+			// Kind field - Reason of the absence
+			&core.SelectField{
+				Required: true,
+
+				Id:   "field_1750752000_01_d",
+				Name: "kind",
+
+				MaxSelect: 1,
+				Values:    []string{"vacation", "sick", "other"},
+			},
+			// Series field - Common identifier of the days of an absence entered as range
+			&core.TextField{
+				Id:   "field_1750752000_01_e",
+				Name: "series",
+
+				Max: 15,
+			},
+			// Note field - Optional free text, e.g. 'Summer vacation'
+			&core.TextField{
+				Id:   "field_1750752000_01_f",
+				Name: "note",
+
+				Max: 500,
+			},
+		}
+
+		// Database indexes for query optimization and data integrity
+		c.Indexes = []string{
+			// A clock is absent at most once per day
+			"CREATE UNIQUE INDEX " +
+				"`idx_1750752000_01_a` " +
+				"ON `absences` " +
+				"(`clock`, `date`)",
+			// Index for finding the days of a series
+			"CREATE INDEX " +
+				"`idx_1750752000_01_b` " +
+				"ON `absences` " +
+				"(`series`)",
+		}
+
+		// Save the collection to the database
+		return app.Save(c)
+	}, func(app core.App) error {
+		// Migrate down - Removes the collection if the migration needs to be rolled back
+		collection, err := app.FindCollectionByNameOrId("pbc_1750752000_01")
+		if err != nil {
+			return err
+		}
+
+		// Delete the collection and all its records
+		return app.Delete(collection)
+	})
+}
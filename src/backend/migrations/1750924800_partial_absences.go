@@ -0,0 +1,56 @@
+/**
+ * Partial Absences Migration
+ *
+ * This migration adds partial absences to the absences collection. An absence covers the whole
+ * day unless it is marked as half day, which halves the target of the day, or has a number of
+ * minutes, which reduce the target of the day by that amount (see the absences module).
+ *
+ * The migration includes:
+ * 1. Addition of the half_day and minutes fields to the absences collection
+ * 2. Implementation of both up and down migration functions
+ */
+package migrations
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+)
+
+func init() {
+	m.Register(func(app core.App) error {
+		// Migrate up - Adds the fields of partial absences
+		collection, err := app.FindCollectionByNameOrId("pbc_1750752000_01")
+		if err != nil {
+			return err
+		}
+
+		// Half day field - Whether the absence only covers half of the target of the day
+		collection.Fields.Add(&core.BoolField{
+			Id:   "field_1750752000_01_g",
+			Name: "half_day",
+		})
+
+		// Minutes field - Minutes of the target covered by the absence, 0 for the whole day
+		collection.Fields.Add(&core.NumberField{
+			Id:   "field_1750752000_01_h",
+			Name: "minutes",
+
+			Min:     ref(0.0),
+			Max:     ref(24 * 60.0),
+			OnlyInt: true,
+		})
+
+		return app.Save(collection)
+	}, func(app core.App) error {
+		// Migrate down - Removes the fields of partial absences
+		collection, err := app.FindCollectionByNameOrId("pbc_1750752000_01")
+		if err != nil {
+			return err
+		}
+
+		collection.Fields.RemoveById("field_1750752000_01_g")
+		collection.Fields.RemoveById("field_1750752000_01_h")
+
+		return app.Save(collection)
+	})
+}
@@ -0,0 +1,106 @@
+/**
+ * Vacation Entitlements Migration
+ *
+ * This migration creates the vacation_entitlements collection, which stores the number of
+ * vacation days a clock is entitled to per year. Together with the vacation absences and the
+ * carry-over settings it makes up the vacation budget (see the vacation module).
+ *
+ * The migration includes:
+ * 1. Creation of the vacation_entitlements collection
+ * 2. Setup of a unique index on the clock and the year
+ * 3. Implementation of both up and down migration functions
+ */
+package migrations
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+)
+
+func init() {
+	m.Register(func(app core.App) error {
+		// Migrate up - Creates the vacation_entitlements collection
+		c := &core.Collection{}
+
+		// Collection identification
+		c.Id = "pbc_1751097600_01"
+		c.Name = "vacation_entitlements"
+		c.Type = "base"
+
+		// Security rules
+		// Entitlements are managed by the user just like the work schedules.
+		c.CreateRule = ref("")
+		c.DeleteRule = ref("")
+		c.ListRule = ref("")
+		c.UpdateRule = ref("")
+		c.ViewRule = ref("")
+
+		// Field definitions for the vacation_entitlements collection
+		c.Fields = []core.Field{
+			// Primary key field - Automatically generated ID
+			&core.TextField{
+				PrimaryKey: true,
+				System:     true,
+				Required:   true,
+
+				Id:   "field_1751097600_01_a",
+				Name: "id",
+
+				AutogeneratePattern: "[a-z0-9]{15}",
+				Min:                 15,
+				Max:                 15,
+				Pattern:             "^[a-z0-9]+$",
+			},
+			// Clock field - Clock the entitlement belongs to, empty for the default clock
+			&core.RelationField{
+				Id:   "field_1751097600_01_b",
+				Name: "clock",
+
+				CollectionId:  "pbc_1746432000_01",
+				CascadeDelete: true,
+				MaxSelect:     1,
+			},
+			// Year field - Calendar year of the entitlement
+			&core.NumberField{
+				Required:    true,
+				Presentable: true,
+
+				Id:   "field_1751097600_01_c",
+				Name: "year",
+
+				Min:     ref(1970.0),
+				Max:     ref(9999.0),
+				OnlyInt: true,
+			},
+			// Days field - Vacation days of the year, may be fractional for part-time contracts
+			&core.NumberField{
+				Id:   "field_1751097600_01_d",
+				Name: "days",
+
+				Min: ref(0.0),
+				Max: ref(366.0),
+			},
+		}
+
+		// Database indexes for query optimization and data integrity
+		c.Indexes = []string{
+			// A clock has one entitlement per year
+			"CREATE UNIQUE INDEX " +
+				"`idx_1751097600_01_a` " +
+				"ON `vacation_entitlements` " +
+				"(`clock`, `year`)",
+		}
+
+		// Save the collection to the database
+		return app.Save(c)
+	}, func(app core.App) error {
+		// Migrate down - Removes the collection if the migration needs to be rolled back
+		collection, err := app.FindCollectionByNameOrId("pbc_1751097600_01")
+		if err != nil {
+			return err
+		}
+
+		// Delete the collection and all its records
+		return app.Delete(collection)
+	})
+}
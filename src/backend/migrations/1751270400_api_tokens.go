@@ -0,0 +1,156 @@
+/**
+ * API Tokens Migration
+ *
+ * This migration creates the api_tokens collection. An API token authenticates scripts and
+ * devices such as wallboards on behalf of the user who created it, but only for the routes
+ * covered by its scopes and only until it expires. Only a SHA-256 hash of the token is stored,
+ * so a leaked database does not leak usable tokens.
+ *
+ * The migration includes:
+ * 1. Creation of the api_tokens collection
+ * 2. Setup of a unique index on the token hash and an index on the auth record
+ * 3. Implementation of both up and down migration functions
+ */
+package migrations
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+func init() {
+	m.Register(func(app core.App) error {
+		// Migrate up - Creates the api_tokens collection
+		c := &core.Collection{}
+
+		// Collection identification
+		c.Id = "pbc_1751270400_01"
+		c.Name = "api_tokens"
+		c.Type = "base"
+
+		// Security rules
+		// Tokens are managed through the API tokens module only, since it generates the token hash
+		// and restricts every user to their own tokens.
+		c.CreateRule = nil
+		c.DeleteRule = nil
+		c.ListRule = nil
+		c.UpdateRule = nil
+		c.ViewRule = nil
+
+		// Field definitions for the api_tokens collection
+		c.Fields = []core.Field{
+			// Primary key field - Automatically generated ID
+			&core.TextField{
+				PrimaryKey: true,
+				System:     true,
+				Required:   true,
+
+				Id:   "field_1751270400_01_a",
+				Name: "id",
+
+				AutogeneratePattern: "[a-z0-9]{15}",
+				Min:                 15,
+				Max:                 15,
+				Pattern:             "^[a-z0-9]+$",
+			},
+			// Name field - Describes where the token is used (e.g. "Wallboard in the office")
+			&core.TextField{
+				Presentable: true,
+				Required:    true,
+
+				Id:   "field_1751270400_01_b",
+				Name: "name",
+
+				Max: 100,
+			},
+			// Token hash field - Hex encoded SHA-256 hash of the token, never exposed via the API
+			&core.TextField{
+				Hidden:   true,
+				Required: true,
+
+				Id:   "field_1751270400_01_c",
+				Name: "token_hash",
+
+				Min: 64,
+				Max: 64,
+			},
+			// Scopes field - Groups of routes the token may access
+			&core.SelectField{
+				Required: true,
+
+				Id:   "field_1751270400_01_d",
+				Name: "scopes",
+
+				MaxSelect: 4,
+				Values:    []string{"clock:read", "clock:write", "reports:read", "admin:*"},
+			},
+			// Auth collection field - Collection of the user or superuser the token acts for
+			&core.TextField{
+				Required: true,
+
+				Id:   "field_1751270400_01_e",
+				Name: "auth_collection",
+
+				Max: 100,
+			},
+			// Auth record field - ID of the user or superuser the token acts for
+			&core.TextField{
+				Required: true,
+
+				Id:   "field_1751270400_01_f",
+				Name: "auth_record",
+
+				Max: 15,
+			},
+			// Expires field - Timestamp after which the token is rejected, empty if it never expires
+			&core.DateField{
+				Id:   "field_1751270400_01_g",
+				Name: "expires_at",
+
+				Min: types.DateTime{},
+				Max: types.DateTime{},
+			},
+			// Last used field - Timestamp of the last successful use of the token
+			&core.DateField{
+				Id:   "field_1751270400_01_h",
+				Name: "last_used_at",
+
+				Min: types.DateTime{},
+				Max: types.DateTime{},
+			},
+			// Created field - Timestamp when the token was created
+			&core.AutodateField{
+				Id:   "field_1751270400_01_i",
+				Name: "created",
+
+				OnCreate: true,
+			},
+		}
+
+		// Database indexes for query optimization and data integrity
+		c.Indexes = []string{
+			// Tokens are looked up by their hash
+			"CREATE UNIQUE INDEX " +
+				"`idx_1751270400_01_a` " +
+				"ON `api_tokens` " +
+				"(`token_hash`)",
+			// Tokens are listed per auth record
+			"CREATE INDEX " +
+				"`idx_1751270400_01_b` " +
+				"ON `api_tokens` " +
+				"(`auth_collection`, `auth_record`)",
+		}
+
+		return app.Save(c)
+	}, func(app core.App) error {
+		// Migrate down - Removes the api_tokens collection
+		collection, err := app.FindCollectionByNameOrId("pbc_1751270400_01")
+		if err != nil {
+			return err
+		}
+
+		// Delete the collection and all its records
+		return app.Delete(collection)
+	})
+}
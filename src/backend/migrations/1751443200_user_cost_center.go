@@ -0,0 +1,49 @@
+/**
+ * User Cost Center Migration
+ *
+ * This migration adds a cost center code to the users collection, like the one of projects. The
+ * worked time is rolled up per cost center and month for the allocation by the finance department;
+ * sessions without a project cost center are allocated to the cost center of the owner of their
+ * clock (see the cost centers module). Only superusers can change the cost center of a user.
+ *
+ * The migration includes:
+ * 1. Addition of the cost_center field to the users collection
+ * 2. Implementation of both up and down migration functions
+ */
+package migrations
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+)
+
+func init() {
+	m.Register(func(app core.App) error {
+		// Migrate up - Adds the cost_center field to the users collection
+		users, err := app.FindCollectionByNameOrId("users")
+		if err != nil {
+			return err
+		}
+
+		// Cost center field - Cost center code the hours of the user's clocks are booked on
+		// unless their project has a cost center
+		users.Fields.Add(&core.TextField{
+			Id:   "field_1751443200_01_a",
+			Name: "cost_center",
+
+			Max: 50,
+		})
+
+		return app.Save(users)
+	}, func(app core.App) error {
+		// Migrate down - Removes the cost_center field from the users collection
+		users, err := app.FindCollectionByNameOrId("users")
+		if err != nil {
+			return err
+		}
+
+		users.Fields.RemoveById("field_1751443200_01_a")
+
+		return app.Save(users)
+	})
+}
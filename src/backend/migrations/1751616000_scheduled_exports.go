@@ -0,0 +1,272 @@
+/**
+ * Scheduled Exports Migration
+ *
+ * This migration creates the scheduled_exports and scheduled_export_runs collections. A scheduled
+ * export emails an export of the previous day, week or month to its recipients on a cron schedule,
+ * e.g. the CSV of last month to the payroll department on the 1st (see the scheduled exports
+ * module). Every run is recorded with its outcome. Both collections are only accessible for
+ * superusers; the runs are only recorded by the backend.
+ *
+ * The migration includes:
+ * 1. Creation of the scheduled_exports collection
+ * 2. Creation of the scheduled_export_runs collection
+ * 3. Setup of an index listing the runs of an export newest first
+ * 4. Implementation of both up and down migration functions
+ */
+package migrations
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+)
+
+func init() {
+	m.Register(func(app core.App) error {
+		// Migrate up - Creates the scheduled_exports and scheduled_export_runs collections
+		c := &core.Collection{}
+
+		// Collection identification
+		c.Id = "pbc_1751616000_01"
+		c.Name = "scheduled_exports"
+		c.Type = "base"
+
+		// Security rules
+		// Scheduled exports are only managed by superusers, since they send data to any address.
+		c.CreateRule = nil
+		c.DeleteRule = nil
+		c.ListRule = nil
+		c.UpdateRule = nil
+		c.ViewRule = nil
+
+		// Field definitions for the scheduled_exports collection
+		c.Fields = []core.Field{
+			// Primary key field - Automatically generated ID
+			&core.TextField{
+				PrimaryKey: true,
+				System:     true,
+				Required:   true,
+
+				Id:   "field_1751616000_01_a",
+				Name: "id",
+
+				AutogeneratePattern: "[a-z0-9]{15}",
+				Min:                 15,
+				Max:                 15,
+				Pattern:             "^[a-z0-9]+$",
+			},
+			// Name field - Name of the scheduled export, used in the subject of the emails
+			&core.TextField{
+				Presentable: true,
+				Required:    true,
+
+				Id:   "field_1751616000_01_b",
+				Name: "name",
+
+				Max: 100,
+			},
+			// Schedule field - Cron expression of the runs, e.g. "0 6 1 * *" for 6:00 on the 1st
+			&core.TextField{
+				Required: true,
+
+				Id:   "field_1751616000_01_c",
+				Name: "schedule",
+
+				Max: 100,
+			},
+			// Period field - Range of the exported sessions relative to the run
+			&core.SelectField{
+				Required: true,
+
+				Id:   "field_1751616000_01_d",
+				Name: "period",
+
+				MaxSelect: 1,
+				Values:    []string{"previous_day", "previous_week", "previous_month"},
+			},
+			// Format field - Format of the exported file
+			&core.SelectField{
+				Required: true,
+
+				Id:   "field_1751616000_01_e",
+				Name: "format",
+
+				MaxSelect: 1,
+				Values:    []string{"csv", "json", "payroll"},
+			},
+			// Profile field - Export profile of payroll exports
+			&core.RelationField{
+				Id:   "field_1751616000_01_f",
+				Name: "profile",
+
+				CollectionId:  "pbc_1745568000_01",
+				CascadeDelete: false,
+				MaxSelect:     1,
+			},
+			// Clock field - Clock of the exported sessions, empty for the default clock
+			&core.RelationField{
+				Id:   "field_1751616000_01_g",
+				Name: "clock",
+
+				CollectionId:  "pbc_1746432000_01",
+				CascadeDelete: true,
+				MaxSelect:     1,
+			},
+			// Project field - Only sessions of this project are exported, empty for any project
+			&core.RelationField{
+				Id:   "field_1751616000_01_h",
+				Name: "project",
+
+				CollectionId:  "pbc_1744617600_01",
+				CascadeDelete: true,
+				MaxSelect:     1,
+			},
+			// Recipients field - Email addresses the export is sent to, separated by commas
+			&core.TextField{
+				Required: true,
+
+				Id:   "field_1751616000_01_i",
+				Name: "recipients",
+
+				Max: 2000,
+			},
+			// Enabled field - Whether the export runs on its schedule
+			&core.BoolField{
+				Id:   "field_1751616000_01_j",
+				Name: "enabled",
+			},
+		}
+
+		if err := app.Save(c); err != nil {
+			return err
+		}
+
+		runs := &core.Collection{}
+
+		// Collection identification
+		runs.Id = "pbc_1751616000_02"
+		runs.Name = "scheduled_export_runs"
+		runs.Type = "base"
+
+		// Security rules
+		// Runs are only accessible for superusers and only recorded by the backend.
+		runs.CreateRule = nil
+		runs.DeleteRule = nil
+		runs.ListRule = nil
+		runs.UpdateRule = nil
+		runs.ViewRule = nil
+
+		// Field definitions for the scheduled_export_runs collection
+		runs.Fields = []core.Field{
+			// Primary key field - Automatically generated ID
+			&core.TextField{
+				PrimaryKey: true,
+				System:     true,
+				Required:   true,
+
+				Id:   "field_1751616000_02_a",
+				Name: "id",
+
+				AutogeneratePattern: "[a-z0-9]{15}",
+				Min:                 15,
+				Max:                 15,
+				Pattern:             "^[a-z0-9]+$",
+			},
+			// Export field - The scheduled export of the run, runs are deleted with it
+			&core.RelationField{
+				Required: true,
+
+				Id:   "field_1751616000_02_b",
+				Name: "export",
+
+				CollectionId:  c.Id,
+				CascadeDelete: true,
+				MaxSelect:     1,
+			},
+			// Succeeded field - Whether the export was sent
+			&core.BoolField{
+				Id:   "field_1751616000_02_c",
+				Name: "succeeded",
+			},
+			// Manual field - Whether the run was started manually instead of by the schedule
+			&core.BoolField{
+				Id:   "field_1751616000_02_d",
+				Name: "manual",
+			},
+			// From field - Start of the exported range
+			&core.DateField{
+				Id:   "field_1751616000_02_e",
+				Name: "from",
+			},
+			// To field - End of the exported range (exclusive)
+			&core.DateField{
+				Id:   "field_1751616000_02_f",
+				Name: "to",
+			},
+			// File name field - Name of the sent file, empty if the export failed
+			&core.TextField{
+				Id:   "field_1751616000_02_g",
+				Name: "file_name",
+
+				Max: 200,
+			},
+			// Size field - Size of the sent file in bytes
+			&core.NumberField{
+				Id:   "field_1751616000_02_h",
+				Name: "size",
+
+				Min:     ref(0.0),
+				OnlyInt: true,
+			},
+			// Error field - Why the run failed, empty for successful runs
+			&core.TextField{
+				Id:   "field_1751616000_02_i",
+				Name: "error",
+
+				Max: 2000,
+			},
+			// Duration field - Duration of the run in milliseconds
+			&core.NumberField{
+				Id:   "field_1751616000_02_j",
+				Name: "duration_ms",
+
+				Min:     ref(0.0),
+				OnlyInt: true,
+			},
+			// Created field - Time of the run
+			&core.AutodateField{
+				Id:   "field_1751616000_02_k",
+				Name: "created",
+
+				OnCreate: true,
+			},
+		}
+
+		// Database indexes for query optimization and data integrity
+		runs.Indexes = []string{
+			// The runs of an export are listed newest first
+			"CREATE INDEX " +
+				"`idx_1751616000_02_a` " +
+				"ON `scheduled_export_runs` " +
+				"(`export`, `created`)",
+		}
+
+		return app.Save(runs)
+	}, func(app core.App) error {
+		// Migrate down - Removes the collections if the migration needs to be rolled back
+		runs, err := app.FindCollectionByNameOrId("pbc_1751616000_02")
+		if err != nil {
+			return err
+		}
+		if err := app.Delete(runs); err != nil {
+			return err
+		}
+
+		collection, err := app.FindCollectionByNameOrId("pbc_1751616000_01")
+		if err != nil {
+			return err
+		}
+
+		// Delete the collection and all its records
+		return app.Delete(collection)
+	})
+}
@@ -0,0 +1,104 @@
+/**
+ * Scheduled Export Delivery Migration
+ *
+ * This migration adds the delivery targets to the scheduled exports. Besides by email, an export
+ * can be delivered to a directory on the SFTP server or the SMB share configured in the settings,
+ * e.g. the intake directory of the payroll system (see the scheduled exports module). The
+ * recipients are only required for the delivery by email, which is the default. Every run records
+ * where its file was delivered to.
+ *
+ * The migration includes:
+ * 1. Addition of the delivery and directory fields to the scheduled_exports collection
+ * 2. Addition of the delivery and destination fields to the scheduled_export_runs collection
+ * 3. Implementation of both up and down migration functions
+ */
+package migrations
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+)
+
+func init() {
+	m.Register(func(app core.App) error {
+		// Migrate up - Adds the delivery fields to the scheduled_exports and scheduled_export_runs collections
+		exports, err := app.FindCollectionByNameOrId("scheduled_exports")
+		if err != nil {
+			return err
+		}
+
+		// The recipients are validated by the backend, since only emails have recipients
+		if recipients, ok := exports.Fields.GetByName("recipients").(*core.TextField); ok {
+			recipients.Required = false
+		}
+
+		// Delivery field - How the export is delivered, by email if empty
+		exports.Fields.Add(&core.SelectField{
+			Id:   "field_1751788800_01_a",
+			Name: "delivery",
+
+			MaxSelect: 1,
+			Values:    []string{"email", "sftp", "smb"},
+		})
+		// Directory field - Directory on the SFTP server or the SMB share the file is written to
+		exports.Fields.Add(&core.TextField{
+			Id:   "field_1751788800_01_b",
+			Name: "directory",
+
+			Max: 500,
+		})
+
+		if err := app.Save(exports); err != nil {
+			return err
+		}
+
+		runs, err := app.FindCollectionByNameOrId("scheduled_export_runs")
+		if err != nil {
+			return err
+		}
+
+		// Delivery field - How the file of the run was delivered
+		runs.Fields.Add(&core.SelectField{
+			Id:   "field_1751788800_02_a",
+			Name: "delivery",
+
+			MaxSelect: 1,
+			Values:    []string{"email", "sftp", "smb"},
+		})
+		// Destination field - Where the file was delivered to, e.g. the recipients or the remote path
+		runs.Fields.Add(&core.TextField{
+			Id:   "field_1751788800_02_b",
+			Name: "destination",
+
+			Max: 2000,
+		})
+
+		return app.Save(runs)
+	}, func(app core.App) error {
+		// Migrate down - Removes the delivery fields again
+		runs, err := app.FindCollectionByNameOrId("scheduled_export_runs")
+		if err != nil {
+			return err
+		}
+
+		runs.Fields.RemoveById("field_1751788800_02_a")
+		runs.Fields.RemoveById("field_1751788800_02_b")
+
+		if err := app.Save(runs); err != nil {
+			return err
+		}
+
+		exports, err := app.FindCollectionByNameOrId("scheduled_exports")
+		if err != nil {
+			return err
+		}
+
+		exports.Fields.RemoveById("field_1751788800_01_a")
+		exports.Fields.RemoveById("field_1751788800_01_b")
+		if recipients, ok := exports.Fields.GetByName("recipients").(*core.TextField); ok {
+			recipients.Required = true
+		}
+
+		return app.Save(exports)
+	})
+}
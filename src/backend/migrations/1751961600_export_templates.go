@@ -0,0 +1,144 @@
+/**
+ * Export Templates Migration
+ *
+ * This migration creates the export_templates collection. An export template is a Go text
+ * template rendered with the sessions of an export, so payroll formats that neither the CSV export
+ * nor an export profile can produce (e.g. fixed-width records) are created without code changes
+ * (see the export templates module). Templates are uploaded by superusers and can be used by all
+ * authenticated users, both in the export endpoint and in scheduled exports.
+ *
+ * The migration includes:
+ * 1. Creation of the export_templates collection
+ * 2. Setup of a unique index on the template name
+ * 3. Addition of the template format and relation to the scheduled_exports collection
+ * 4. Implementation of both up and down migration functions
+ */
+package migrations
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+)
+
+func init() {
+	m.Register(func(app core.App) error {
+		// Migrate up - Creates the export_templates collection and adds it to the scheduled exports
+		c := &core.Collection{}
+
+		// Collection identification
+		c.Id = "pbc_1751961600_01"
+		c.Name = "export_templates"
+		c.Type = "base"
+
+		// Security rules
+		// Templates are only managed by superusers, since they shape the files sent to payroll,
+		// but can be listed and used by all authenticated users.
+		c.CreateRule = nil
+		c.DeleteRule = nil
+		c.ListRule = ref("@request.auth.id != ''")
+		c.UpdateRule = nil
+		c.ViewRule = ref("@request.auth.id != ''")
+
+		// Field definitions for the export_templates collection
+		c.Fields = []core.Field{
+			// Primary key field - Automatically generated ID
+			&core.TextField{
+				PrimaryKey: true,
+				System:     true,
+				Required:   true,
+
+				Id:   "field_1751961600_01_a",
+				Name: "id",
+
+				AutogeneratePattern: "[a-z0-9]{15}",
+				Min:                 15,
+				Max:                 15,
+				Pattern:             "^[a-z0-9]+$",
+			},
+			// Name field - Human readable name of the template (e.g. "Lohn fixed-width")
+			&core.TextField{
+				Presentable: true,
+				Required:    true,
+
+				Id:   "field_1751961600_01_b",
+				Name: "name",
+
+				Max: 100,
+			},
+			// Template field - Go text template rendered with the exported sessions
+			&core.TextField{
+				Required: true,
+
+				Id:   "field_1751961600_01_c",
+				Name: "template",
+
+				Max: 100000,
+			},
+			// Extension field - File extension of the rendered file, "txt" if empty
+			&core.TextField{
+				Id:   "field_1751961600_01_d",
+				Name: "extension",
+
+				Max:     10,
+				Pattern: "^[a-z0-9]+$",
+			},
+		}
+
+		// Database indexes for query optimization and data integrity
+		c.Indexes = []string{
+			// Template names must be unique to be distinguishable in the frontend
+			"CREATE UNIQUE INDEX " +
+				"`idx_1751961600_01_a` " +
+				"ON `export_templates` " +
+				"(`name`)",
+		}
+
+		if err := app.Save(c); err != nil {
+			return err
+		}
+
+		exports, err := app.FindCollectionByNameOrId("scheduled_exports")
+		if err != nil {
+			return err
+		}
+
+		if format, ok := exports.Fields.GetByName("format").(*core.SelectField); ok {
+			format.Values = append(format.Values, "template")
+		}
+
+		// Template field - Template of exports in the template format
+		exports.Fields.Add(&core.RelationField{
+			Id:   "field_1751961600_02_a",
+			Name: "template",
+
+			CollectionId:  c.Id,
+			CascadeDelete: false,
+			MaxSelect:     1,
+		})
+
+		return app.Save(exports)
+	}, func(app core.App) error {
+		// Migrate down - Removes the template format and the export_templates collection
+		exports, err := app.FindCollectionByNameOrId("scheduled_exports")
+		if err != nil {
+			return err
+		}
+
+		exports.Fields.RemoveById("field_1751961600_02_a")
+		if format, ok := exports.Fields.GetByName("format").(*core.SelectField); ok {
+			format.Values = []string{"csv", "json", "payroll"}
+		}
+
+		if err := app.Save(exports); err != nil {
+			return err
+		}
+
+		collection, err := app.FindCollectionByNameOrId("pbc_1751961600_01")
+		if err != nil {
+			return err
+		}
+
+		// Delete the collection and all its records
+		return app.Delete(collection)
+	})
+}
@@ -0,0 +1,54 @@
+/**
+ * Employment Period Rules Migration
+ *
+ * This migration restricts the changes of employment periods to the owner of their clock. Periods
+ * decide which days have a target, so anyone able to change them could erase the overtime of
+ * another user. Periods of clocks without an owner, including the default clock, stay open to
+ * everyone, and delegates of the owner (see the delegations collection) may change them while
+ * their delegation is active. The days of delegations are compared with the current UTC day.
+ *
+ * The migration includes:
+ * 1. Restriction of the create, update and delete rules of the employment_periods collection
+ * 2. Implementation of both up and down migration functions
+ */
+package migrations
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+)
+
+func init() {
+	m.Register(func(app core.App) error {
+		// Migrate up - Restricts the changes of employment periods to the owner of their clock
+		c, err := app.FindCollectionByNameOrId("pbc_1748851200_01")
+		if err != nil {
+			return err
+		}
+
+		// Plain dates compare lexically with the datetime macros, so a delegation covers today if
+		// it starts no later than the end of today and ends after yesterday's date
+		owned := "clock.owner = '' || clock.owner = @request.auth.id || " +
+			"(@collection.delegations.owner ?= clock.owner && @collection.delegations.delegate ?= @request.auth.id && " +
+			"@collection.delegations.from ?<= @todayEnd && @collection.delegations.to ?> @yesterday)"
+
+		// Periods can't be moved to a clock the user doesn't have access to
+		c.CreateRule = ref(owned)
+		c.DeleteRule = ref(owned)
+		c.UpdateRule = ref("(" + owned + ") && (@request.body.clock:isset = false || @request.body.clock = clock)")
+
+		return app.Save(c)
+	}, func(app core.App) error {
+		// Migrate down - Opens the changes of employment periods to everyone again
+		c, err := app.FindCollectionByNameOrId("pbc_1748851200_01")
+		if err != nil {
+			return err
+		}
+
+		c.CreateRule = ref("")
+		c.DeleteRule = ref("")
+		c.UpdateRule = ref("")
+
+		return app.Save(c)
+	})
+}
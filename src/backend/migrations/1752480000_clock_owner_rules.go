@@ -0,0 +1,87 @@
+/**
+ * Clock Owner Rules Migration
+ *
+ * This migration applies the owners of clocks to the records API. Absences, expenses and work
+ * schedules can only be changed by the owner of their clock and by the owner's delegates while
+ * their delegation is active, like the employment and rule periods. Records of clocks without an
+ * owner, including the default clock, stay open to everyone. The days of delegations are compared
+ * with the current UTC day.
+ *
+ * The owner of a clock can no longer be changed through the records API, so nobody can claim a
+ * clock without an owner or hand their own clock to another user. Owners are assigned by superusers.
+ *
+ * The migration includes:
+ * 1. Restriction of the create, update and delete rules of the absences, expenses and work_schedules collections
+ * 2. Restriction of the create and update rules of the clocks collection
+ * 3. Implementation of both up and down migration functions
+ */
+package migrations
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+)
+
+func init() {
+	m.Register(func(app core.App) error {
+		// Migrate up - Restricts the changes of the records of owned clocks and of the owners
+		// Plain dates compare lexically with the datetime macros, so a delegation covers today if
+		// it starts no later than the end of today and ends after yesterday's date
+		owned := "clock.owner = '' || clock.owner = @request.auth.id || " +
+			"(@collection.delegations.owner ?= clock.owner && @collection.delegations.delegate ?= @request.auth.id && " +
+			"@collection.delegations.from ?<= @todayEnd && @collection.delegations.to ?> @yesterday)"
+
+		// The absences, expenses and work_schedules collections
+		for _, id := range []string{"pbc_1750752000_01", "pbc_1746950400_01", "pbc_1748678400_01"} {
+			c, err := app.FindCollectionByNameOrId(id)
+			if err != nil {
+				return err
+			}
+
+			// Records can't be moved to a clock the user doesn't have access to
+			c.CreateRule = ref(owned)
+			c.DeleteRule = ref(owned)
+			c.UpdateRule = ref("(" + owned + ") && (@request.body.clock:isset = false || @request.body.clock = clock)")
+
+			if err := app.Save(c); err != nil {
+				return err
+			}
+		}
+
+		clocks, err := app.FindCollectionByNameOrId("pbc_1746432000_01")
+		if err != nil {
+			return err
+		}
+
+		clocks.CreateRule = ref("owner = ''")
+		clocks.UpdateRule = ref("(owner = '' || owner = @request.auth.id) && @request.body.owner:isset = false")
+
+		return app.Save(clocks)
+	}, func(app core.App) error {
+		// Migrate down - Opens the records of owned clocks and the owners again
+		for _, id := range []string{"pbc_1750752000_01", "pbc_1746950400_01", "pbc_1748678400_01"} {
+			c, err := app.FindCollectionByNameOrId(id)
+			if err != nil {
+				return err
+			}
+
+			c.CreateRule = ref("")
+			c.DeleteRule = ref("")
+			c.UpdateRule = ref("")
+
+			if err := app.Save(c); err != nil {
+				return err
+			}
+		}
+
+		clocks, err := app.FindCollectionByNameOrId("pbc_1746432000_01")
+		if err != nil {
+			return err
+		}
+
+		clocks.CreateRule = ref("")
+		clocks.UpdateRule = ref("owner = '' || owner = @request.auth.id")
+
+		return app.Save(clocks)
+	})
+}
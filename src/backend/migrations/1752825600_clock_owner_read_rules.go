@@ -0,0 +1,84 @@
+/**
+ * Clock Owner Read Rules Migration
+ *
+ * This migration restricts the reading of owned clocks through the records API. The work clock
+ * records, their comments, the daily summaries, day notes and all other records of a clock with an
+ * owner can only be listed and viewed by the owner, by the owner's delegates while their delegation
+ * is active, and by the leads of the teams the owner is a member of. Records of clocks without an
+ * owner, including the default clock, stay readable by everyone. The days of delegations are
+ * compared with the current UTC day.
+ *
+ * The migration includes:
+ * 1. Restriction of the list and view rules of the collections belonging to a clock
+ * 2. Restriction of the list and view rules of the record_comments collection through their work clock record
+ * 3. Implementation of both up and down migration functions
+ */
+package migrations
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+)
+
+func init() {
+	// The work_clock, daily_summary, day_notes, expenses, work_schedules, employment_periods,
+	// rule_periods, absences and vacation_entitlements collections
+	clockCollections := []string{
+		"pbc_1743167663_01", "pbc_1747641600_01", "pbc_1748160000_01",
+		"pbc_1746950400_01", "pbc_1748678400_01", "pbc_1748851200_01",
+		"pbc_1749024000_01", "pbc_1750752000_01", "pbc_1751097600_01",
+	}
+
+	m.Register(func(app core.App) error {
+		// Migrate up - Restricts the reading of the records of owned clocks
+		// Plain dates compare lexically with the datetime macros, so a delegation covers today if
+		// it starts no later than the end of today and ends after yesterday's date
+		readable := func(clock string) string {
+			return clock + ".owner = '' || " + clock + ".owner = @request.auth.id || " +
+				"(@collection.delegations.owner ?= " + clock + ".owner && @collection.delegations.delegate ?= @request.auth.id && " +
+				"@collection.delegations.from ?<= @todayEnd && @collection.delegations.to ?> @yesterday) || " +
+				"(@collection.teams.leads.id ?= @request.auth.id && @collection.teams.members.id ?= " + clock + ".owner)"
+		}
+
+		for _, id := range clockCollections {
+			c, err := app.FindCollectionByNameOrId(id)
+			if err != nil {
+				return err
+			}
+
+			c.ListRule = ref(readable("clock"))
+			c.ViewRule = ref(readable("clock"))
+
+			if err := app.Save(c); err != nil {
+				return err
+			}
+		}
+
+		comments, err := app.FindCollectionByNameOrId("pbc_1747123200_01")
+		if err != nil {
+			return err
+		}
+
+		comments.ListRule = ref(readable("record.clock"))
+		comments.ViewRule = ref(readable("record.clock"))
+
+		return app.Save(comments)
+	}, func(app core.App) error {
+		// Migrate down - Opens the reading of the records of owned clocks to everyone again
+		for _, id := range append(clockCollections, "pbc_1747123200_01") {
+			c, err := app.FindCollectionByNameOrId(id)
+			if err != nil {
+				return err
+			}
+
+			c.ListRule = ref("")
+			c.ViewRule = ref("")
+
+			if err := app.Save(c); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
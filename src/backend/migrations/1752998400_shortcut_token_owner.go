@@ -0,0 +1,64 @@
+/**
+ * Shortcut Token Owner Migration
+ *
+ * This migration adds the user who created a shortcut token. Shortcut URLs act on behalf of that
+ * user, so they can only clock the clocks the user may change (see the delegation module), and
+ * the tokens can only be listed and revoked by their owner. Tokens created before have no owner
+ * and can only be used for clocks without an owner.
+ *
+ * The migration includes:
+ * 1. Addition of the owner relation field to the shortcut_tokens collection
+ * 2. Restriction of the list, view and delete rules to the owner of the token
+ * 3. Implementation of both up and down migration functions
+ */
+package migrations
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+)
+
+func init() {
+	m.Register(func(app core.App) error {
+		// Migrate up - Adds the owner of shortcut tokens and restricts the tokens to it
+		c, err := app.FindCollectionByNameOrId("pbc_1745395200_01")
+		if err != nil {
+			return err
+		}
+
+		users, err := app.FindCollectionByNameOrId("users")
+		if err != nil {
+			return err
+		}
+
+		// Owner field - User who created the token and on whose behalf it clocks.
+		// Deleting the user revokes their tokens.
+		c.Fields.Add(&core.RelationField{
+			Id:   "field_1745395200_01_f",
+			Name: "owner",
+
+			CollectionId:  users.Id,
+			CascadeDelete: true,
+			MaxSelect:     1,
+		})
+
+		c.DeleteRule = ref("owner = @request.auth.id")
+		c.ListRule = ref("owner = @request.auth.id")
+		c.ViewRule = ref("owner = @request.auth.id")
+
+		return app.Save(c)
+	}, func(app core.App) error {
+		// Migrate down - Removes the owner of shortcut tokens and opens the tokens again
+		c, err := app.FindCollectionByNameOrId("pbc_1745395200_01")
+		if err != nil {
+			return err
+		}
+
+		c.Fields.RemoveById("field_1745395200_01_f")
+		c.DeleteRule = ref("")
+		c.ListRule = ref("")
+		c.ViewRule = ref("")
+
+		return app.Save(c)
+	})
+}
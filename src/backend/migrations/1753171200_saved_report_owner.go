@@ -0,0 +1,71 @@
+/**
+ * Saved Report Owner Migration
+ *
+ * This migration adds the user who saved a report. Saved reports can only be listed, viewed,
+ * deleted, shared and revoked by their owner and by superusers, and the names of the reports only
+ * have to be unique per owner. Reports saved before have no owner and are only available to
+ * superusers.
+ *
+ * The migration includes:
+ * 1. Addition of the owner relation field to the saved_reports collection
+ * 2. Restriction of the list, view and delete rules to the owner of the report
+ * 3. Replacement of the unique index on the report name by one on the owner and the name
+ * 4. Implementation of both up and down migration functions
+ */
+package migrations
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+)
+
+func init() {
+	m.Register(func(app core.App) error {
+		// Migrate up - Adds the owner of saved reports and restricts the reports to it
+		c, err := app.FindCollectionByNameOrId("pbc_1747987200_01")
+		if err != nil {
+			return err
+		}
+
+		users, err := app.FindCollectionByNameOrId("users")
+		if err != nil {
+			return err
+		}
+
+		// Owner field - User who saved the report, empty for reports of superusers.
+		// Deleting the user deletes their reports and with them their share links.
+		c.Fields.Add(&core.RelationField{
+			Id:   "field_1747987200_01_f",
+			Name: "owner",
+
+			CollectionId:  users.Id,
+			CascadeDelete: true,
+			MaxSelect:     1,
+		})
+
+		c.DeleteRule = ref("owner = @request.auth.id")
+		c.ListRule = ref("owner = @request.auth.id")
+		c.ViewRule = ref("owner = @request.auth.id")
+
+		// Report names must be unique per owner to be distinguishable in the frontend
+		c.RemoveIndex("idx_1747987200_01_a")
+		c.AddIndex("idx_1747987200_01_b", true, "`owner`, `name`", "")
+
+		return app.Save(c)
+	}, func(app core.App) error {
+		// Migrate down - Removes the owner of saved reports and opens the reports again
+		c, err := app.FindCollectionByNameOrId("pbc_1747987200_01")
+		if err != nil {
+			return err
+		}
+
+		c.RemoveIndex("idx_1747987200_01_b")
+		c.AddIndex("idx_1747987200_01_a", true, "`name`", "")
+		c.Fields.RemoveById("field_1747987200_01_f")
+		c.DeleteRule = ref("")
+		c.ListRule = ref("")
+		c.ViewRule = ref("")
+
+		return app.Save(c)
+	})
+}
@@ -0,0 +1,117 @@
+// Missing Days Module for PocketBase
+//
+// This module finds the scheduled workdays without any recorded time, e.g. because clocking in was
+// forgotten. A day is missing if it lies within the employment (see the employment periods
+// module), has a target according to the work schedule valid on it (see the work schedules
+// module) that is not fully covered by a holiday or an absence (see the absences module), is
+// already over, and no session starts on it.
+//
+// The missing days are listed by an endpoint, e.g. for a "fill in gaps" flow in the frontend
+// that opens the day editor for each of them.
+package backend
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/pocketbase/pocketbase/core"
+)
+
+// RegisterMissingDaysAPI registers the missing days endpoint with the PocketBase server.
+// It creates the following route:
+// - GET /api/work_clock/missing?from=&to=&clock= - Lists the scheduled workdays within the range without recorded time (YYYY-MM-DD)
+//
+// Parameters:
+// - app: The PocketBase application instance
+func RegisterMissingDaysAPI(app core.App) {
+	app.OnServe().BindFunc(func(se *core.ServeEvent) error {
+		se.Router.GET("/api/work_clock/missing", func(e *core.RequestEvent) error {
+			from, to, err := parseTimeRangeParams(e.Request.URL.Query().Get("from"), e.Request.URL.Query().Get("to"))
+			if err != nil {
+				return e.Error(http.StatusBadRequest, err.Error(), nil)
+			}
+
+			clockID, err := requestClock(app, e)
+			if err != nil {
+				return err
+			}
+
+			missing, err := findMissingDays(app, clockID, from, to, clockNow(app))
+			if err != nil {
+				return e.Error(http.StatusInternalServerError, fmt.Sprintf("Failed to find missing days: %v", err), err)
+			}
+
+			// The missing days also change when a day is over, so only the ETag is used
+			return respondConditionalJSON(e, missing, time.Time{})
+		})
+
+		return se.Next()
+	})
+}
+
+// findMissingDays finds the missing days of a clock within a range.
+//
+// Parameters:
+// - app: The App interface (typically a PocketBase instance or transaction)
+// - clockID: The ID of the clock, an empty string for the default clock
+// - from: The start of the range (inclusive), rounded down to the local midnight
+// - to: The end of the range (exclusive)
+// - now: The current time, days that are not over yet are never missing
+//
+// Returns:
+// - The missing days (YYYY-MM-DD) in ascending order
+// - An error if the sessions, work schedules, employment periods or days off could not be retrieved
+func findMissingDays(app core.App, clockID string, from, to, now time.Time) ([]string, error) {
+	start, end := startOfLocalDay(from), startOfLocalDay(now)
+	if to.Before(end) {
+		end = to
+	}
+
+	missing := []string{}
+	if !start.Before(end) {
+		return missing, nil
+	}
+
+	sessions, err := findWorkSessions(app, clockID, start, end)
+	if err != nil {
+		return nil, err
+	}
+	schedules, err := findWorkSchedules(app, clockID)
+	if err != nil {
+		return nil, err
+	}
+	employment, err := findEmploymentPeriods(app, clockID)
+	if err != nil {
+		return nil, err
+	}
+	off, err := findDaysOff(app, clockID, start, end)
+	if err != nil {
+		return nil, err
+	}
+
+	recorded := recordedDays(sessions)
+
+	for day := start; day.Before(end); day = day.AddDate(0, 0, 1) {
+		date := day.Format(time.DateOnly)
+		if !recorded[date] && employment.includes(day) && off.target(schedules, day) > 0 {
+			missing = append(missing, date)
+		}
+	}
+	return missing, nil
+}
+
+// recordedDays determines the days sessions start on.
+//
+// Parameters:
+// - sessions: The sessions
+//
+// Returns:
+// - The set of days (YYYY-MM-DD) with at least one session starting on them
+func recordedDays(sessions []workSession) map[string]bool {
+	recorded := map[string]bool{}
+	for _, session := range sessions {
+		recorded[startOfLocalDay(session.Start()).Format(time.DateOnly)] = true
+	}
+	return recorded
+}
@@ -0,0 +1,39 @@
+package backend
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/yerTools/simple-frontend-stack/src/backend/backendtest"
+)
+
+func TestFindMissingDays(t *testing.T) {
+	app := backendtest.NewApp(t)
+
+	// Monday and Wednesday are recorded, Tuesday, Thursday and Friday are missing
+	monday := time.Date(2025, time.March, 3, 0, 0, 0, 0, time.Local)
+	backendtest.AddRecords(t, app,
+		backendtest.ClockIn(monday.Add(9*time.Hour).Format(time.RFC3339)),
+		backendtest.ClockOut(monday.Add(17*time.Hour).Format(time.RFC3339)),
+		backendtest.ClockIn(monday.AddDate(0, 0, 2).Add(9*time.Hour).Format(time.RFC3339)),
+		backendtest.ClockOut(monday.AddDate(0, 0, 2).Add(17*time.Hour).Format(time.RFC3339)),
+	)
+
+	missing, err := findMissingDays(app, "", monday, monday.AddDate(0, 0, 7), time.Now())
+	if err != nil {
+		t.Fatalf("failed to find missing days: %v", err)
+	}
+	if expected := []string{"2025-03-04", "2025-03-06", "2025-03-07"}; !slices.Equal(missing, expected) {
+		t.Errorf("expected %v, got %v", expected, missing)
+	}
+
+	// Days that are not over yet are never missing
+	missing, err = findMissingDays(app, "", monday, monday.AddDate(0, 0, 7), monday.AddDate(0, 0, 4).Add(12*time.Hour))
+	if err != nil {
+		t.Fatalf("failed to find missing days: %v", err)
+	}
+	if expected := []string{"2025-03-04", "2025-03-06"}; !slices.Equal(missing, expected) {
+		t.Errorf("expected %v, got %v", expected, missing)
+	}
+}
@@ -0,0 +1,192 @@
+// MQTT Module for PocketBase
+//
+// This module publishes clock events to an MQTT broker, e.g. to drive a status light or a home
+// automation. It is configured and enabled through the integrations API:
+//
+//	{"broker": "tcp://mqtt.local:1883", "topic": "work_clock", "username": "", "password": ""}
+//
+// The events are published as JSON to "<topic>/clock_in", "<topic>/clock_out" and
+// "<topic>/session_closed", with the same payloads as the webhook events. Clock events are rare,
+// so every event opens its own connection and is published with QoS 0 using the Eclipse Paho
+// MQTT 3.1.1 client. Brokers are reached via "tcp://" or, encrypted, via "tls://".
+package backend
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/url"
+	"time"
+
+	mqtt "github.com/eclipse/paho.mqtt.golang"
+	"github.com/pocketbase/pocketbase/core"
+)
+
+// mqttTimeout is the maximum duration of publishing a single event.
+const mqttTimeout = 10 * time.Second
+
+// MQTTConfig is the configuration of the MQTT integration.
+type MQTTConfig struct {
+	Broker   string `json:"broker"`    // URL of the broker, e.g. "tcp://mqtt.local:1883" or "tls://mqtt.example.com:8883"
+	Topic    string `json:"topic"`     // Prefix of the topics the events are published to
+	Username string `json:"username"`  // Optional username
+	Password string `json:"password"`  // Optional password
+	ClientID string `json:"client_id"` // Optional client ID, "sfs-work-clock" if empty
+}
+
+// validate checks that the broker URL and topic are valid.
+func (c MQTTConfig) validate() error {
+	if err := validateIntegrationURL(c.Broker, "broker", "tcp", "tls"); err != nil {
+		return err
+	}
+	if c.Topic == "" {
+		return fmt.Errorf("missing 'topic' (string) configuration")
+	}
+	if c.Password != "" && c.Username == "" {
+		return fmt.Errorf("missing 'username' (string) configuration, a password requires a username")
+	}
+	return nil
+}
+
+// RegisterMQTTHooks subscribes the MQTT integration to the clock events.
+//
+// Parameters:
+// - app: The PocketBase application instance
+func RegisterMQTTHooks(app core.App) {
+	publishClockEvent := func(event string) func(e *ClockEvent) error {
+		return func(e *ClockEvent) error {
+			publishMQTTEvent(e.App, event, map[string]any{
+				"id":        e.Record.Id,
+				"timestamp": e.Record.GetDateTime("timestamp").Time(),
+			})
+			return e.Next()
+		}
+	}
+
+	OnClockIn().BindFunc(publishClockEvent("clock_in"))
+	OnClockOut().BindFunc(publishClockEvent("clock_out"))
+	OnSessionClosed().BindFunc(func(e *SessionClosedEvent) error {
+		publishMQTTEvent(e.App, "session_closed", e.Session)
+		return e.Next()
+	})
+}
+
+// publishMQTTEvent publishes an event to the configured broker in the background.
+//
+// Parameters:
+// - app: The App interface used for logging and loading the configuration
+// - event: The name of the event, appended to the configured topic
+// - data: The event payload, must be serializable to JSON
+func publishMQTTEvent(app core.App, event string, data any) {
+	config, ok := integrationConfig[MQTTConfig](app, "mqtt")
+	if !ok {
+		return
+	}
+
+	payload, err := json.Marshal(data)
+	if err != nil {
+		app.Logger().Error("failed to encode mqtt event", "event", event, "error", err)
+		return
+	}
+
+	backgroundJobs.goJob("mqtt", func() {
+		if err := publishMQTT(config, config.Topic+"/"+event, payload); err != nil {
+			recordIntegrationFailure(app, "mqtt", err)
+		}
+	})
+}
+
+// publishMQTT connects to the broker, publishes a message with QoS 0 and disconnects. The
+// connection is opened through the outbound dialer, so the proxy and the CA bundle of the
+// outbound HTTP client apply to it as well.
+//
+// Parameters:
+// - config: The MQTT configuration
+// - topic: The topic to publish to
+// - payload: The message payload
+//
+// Returns:
+// - An error if connecting, authenticating or publishing fails
+func publishMQTT(config MQTTConfig, topic string, payload []byte) error {
+	clientID := config.ClientID
+	if clientID == "" {
+		clientID = "sfs-work-clock"
+	}
+
+	options := mqtt.NewClientOptions().
+		AddBroker(config.Broker).
+		SetClientID(clientID).
+		SetUsername(config.Username).
+		SetPassword(config.Password).
+		SetProtocolVersion(4).
+		SetCleanSession(true).
+		SetAutoReconnect(false).
+		SetConnectTimeout(mqttTimeout).
+		SetWriteTimeout(mqttTimeout).
+		SetCustomOpenConnectionFn(openMQTTConnection)
+
+	client := mqtt.NewClient(options)
+	if err := waitMQTT(client.Connect()); err != nil {
+		return fmt.Errorf("failed to connect: %w", err)
+	}
+	defer client.Disconnect(250)
+
+	if err := waitMQTT(client.Publish(topic, 0, false, payload)); err != nil {
+		return fmt.Errorf("failed to publish: %w", err)
+	}
+	return nil
+}
+
+// openMQTTConnection opens the connection to a broker, using TLS for "tls://" brokers.
+//
+// Parameters:
+// - broker: The URL of the broker
+// - options: The options of the MQTT client
+//
+// Returns:
+// - The connection to the broker
+// - An error if the connection or the TLS handshake fails
+func openMQTTConnection(broker *url.URL, options mqtt.ClientOptions) (net.Conn, error) {
+	address := broker.Host
+	if broker.Port() == "" {
+		if broker.Scheme == "tls" {
+			address = net.JoinHostPort(broker.Hostname(), "8883")
+		} else {
+			address = net.JoinHostPort(broker.Hostname(), "1883")
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), options.ConnectTimeout)
+	defer cancel()
+
+	conn, err := dialOutbound(ctx, &net.Dialer{}, address)
+	if err != nil {
+		return nil, err
+	}
+
+	if broker.Scheme == "tls" {
+		tlsConn := tls.Client(conn, outboundTLSConfig(broker.Hostname()))
+		if err := tlsConn.HandshakeContext(ctx); err != nil {
+			conn.Close()
+			return nil, err
+		}
+		conn = tlsConn
+	}
+	return conn, nil
+}
+
+// waitMQTT waits for an operation of the MQTT client to complete.
+//
+// Parameters:
+// - token: The token of the operation
+//
+// Returns:
+// - An error if the operation failed or did not complete within mqttTimeout
+func waitMQTT(token mqtt.Token) error {
+	if !token.WaitTimeout(mqttTimeout) {
+		return fmt.Errorf("timed out after %s", mqttTimeout)
+	}
+	return token.Error()
+}
@@ -0,0 +1,178 @@
+// Outbound Connections Module for PocketBase
+//
+// This module provides the HTTP client and the TLS configuration all outbound integrations
+// (webhooks, Slack, push notifications, Matrix, the calendar import, MQTT and the trace export)
+// connect with, so networks that only allow outbound traffic through a proxy, or that intercept
+// TLS with their own certificate authority, are supported in one place.
+//
+// Connections use the proxy configured in OUTBOUND_PROXY, or the standard HTTP_PROXY, HTTPS_PROXY
+// and NO_PROXY environment variables if unset. Certificates are verified against the system
+// certificate pool extended by the PEM bundle configured in OUTBOUND_CA_FILE. MQTT connections
+// are tunneled through the proxy with HTTP CONNECT.
+package backend
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"sync"
+	"time"
+)
+
+// outboundClient returns the HTTP client of all outbound integrations, see newOutboundTransport.
+// It is created on first use from the settings.
+var outboundClient = sync.OnceValue(func() *http.Client {
+	transport, err := newOutboundTransport(settings.OutboundProxy, settings.OutboundCAFile)
+	if err != nil {
+		log.Printf("invalid outbound connection settings, using defaults: %v", err)
+		transport, _ = newOutboundTransport("", "")
+	}
+	return &http.Client{Transport: transport}
+})
+
+// newOutboundTransport creates the HTTP transport of outbound connections.
+//
+// Parameters:
+// - proxy: The URL of the proxy, an empty string to use the proxy environment variables
+// - caFile: The path of a PEM bundle trusted in addition to the system certificates, an empty string for none
+//
+// Returns:
+// - The transport
+// - An error if the proxy URL is invalid or the CA bundle could not be read
+func newOutboundTransport(proxy string, caFile string) (*http.Transport, error) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+
+	if proxy != "" {
+		proxyURL, err := url.Parse(proxy)
+		if err != nil || proxyURL.Host == "" {
+			return nil, fmt.Errorf("invalid proxy URL '%s'", proxy)
+		}
+		transport.Proxy = http.ProxyURL(proxyURL)
+	}
+
+	if caFile != "" {
+		pool, err := loadCertPool(caFile)
+		if err != nil {
+			return nil, err
+		}
+		transport.TLSClientConfig = &tls.Config{RootCAs: pool}
+	}
+
+	return transport, nil
+}
+
+// loadCertPool creates a certificate pool of the system certificates and the certificates of a PEM bundle.
+//
+// Parameters:
+// - caFile: The path of the PEM bundle
+//
+// Returns:
+// - The certificate pool
+// - An error if the bundle could not be read or contains no certificate
+func loadCertPool(caFile string) (*x509.CertPool, error) {
+	pem, err := os.ReadFile(caFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CA bundle: %w", err)
+	}
+
+	pool, err := x509.SystemCertPool()
+	if err != nil {
+		pool = x509.NewCertPool()
+	}
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("CA bundle '%s' contains no PEM certificate", caFile)
+	}
+
+	return pool, nil
+}
+
+// outboundTLSConfig creates the TLS configuration of a raw TLS connection, e.g. to an MQTT broker.
+//
+// Parameters:
+// - serverName: The host name the certificate of the server is verified for
+//
+// Returns:
+// - The TLS configuration trusting the same certificates as the outbound HTTP client
+func outboundTLSConfig(serverName string) *tls.Config {
+	config := &tls.Config{ServerName: serverName}
+	if transport, ok := outboundClient().Transport.(*http.Transport); ok && transport.TLSClientConfig != nil {
+		config.RootCAs = transport.TLSClientConfig.RootCAs
+	}
+	return config
+}
+
+// dialOutbound opens a TCP connection to a host, tunneled through the outbound proxy if one
+// applies to it. The proxy is selected as for an HTTPS request to the host.
+//
+// Parameters:
+// - ctx: The context of the connection attempt
+// - dialer: The dialer used to connect to the host or the proxy
+// - address: The address of the host (host:port)
+//
+// Returns:
+// - The connection
+// - An error if connecting or establishing the tunnel fails
+func dialOutbound(ctx context.Context, dialer *net.Dialer, address string) (net.Conn, error) {
+	var proxyURL *url.URL
+	if transport, ok := outboundClient().Transport.(*http.Transport); ok && transport.Proxy != nil {
+		var err error
+		proxyURL, err = transport.Proxy(&http.Request{URL: &url.URL{Scheme: "https", Host: address}})
+		if err != nil {
+			return nil, fmt.Errorf("failed to select proxy: %w", err)
+		}
+	}
+	if proxyURL == nil {
+		return dialer.DialContext(ctx, "tcp", address)
+	}
+
+	proxyAddress := proxyURL.Host
+	if proxyURL.Port() == "" {
+		proxyAddress = net.JoinHostPort(proxyURL.Hostname(), "80")
+	}
+	conn, err := dialer.DialContext(ctx, "tcp", proxyAddress)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to proxy: %w", err)
+	}
+
+	request := &http.Request{
+		Method: http.MethodConnect,
+		URL:    &url.URL{Opaque: address},
+		Host:   address,
+		Header: http.Header{},
+	}
+	if user := proxyURL.User; user != nil {
+		password, _ := user.Password()
+		request.SetBasicAuth(user.Username(), password)
+		request.Header.Set("Proxy-Authorization", request.Header.Get("Authorization"))
+		request.Header.Del("Authorization")
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+	if err := request.Write(conn); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to send proxy request: %w", err)
+	}
+
+	// The host sends nothing before the client speaks, so the reader buffers no tunneled data
+	response, err := http.ReadResponse(bufio.NewReader(conn), request)
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to read proxy response: %w", err)
+	}
+	response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		conn.Close()
+		return nil, fmt.Errorf("proxy responded with status %d", response.StatusCode)
+	}
+	conn.SetDeadline(time.Time{})
+
+	return conn, nil
+}
@@ -0,0 +1,43 @@
+package backend
+
+import (
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestOutboundTransportTrustsCAFile(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	untrusted, err := newOutboundTransport("", "")
+	if err != nil {
+		t.Fatalf("failed to create transport: %v", err)
+	}
+	if _, err := (&http.Client{Transport: untrusted}).Get(server.URL); err == nil {
+		t.Error("expected the test certificate to be rejected without CA bundle")
+	}
+
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	certificate := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	if err := os.WriteFile(caFile, certificate, 0o600); err != nil {
+		t.Fatalf("failed to write CA bundle: %v", err)
+	}
+
+	trusted, err := newOutboundTransport("", caFile)
+	if err != nil {
+		t.Fatalf("failed to create transport: %v", err)
+	}
+	response, err := (&http.Client{Transport: trusted}).Get(server.URL)
+	if err != nil {
+		t.Fatalf("expected the test certificate to be trusted with CA bundle: %v", err)
+	}
+	response.Body.Close()
+
+	if _, err := newOutboundTransport("proxy.corp", ""); err == nil {
+		t.Error("expected a proxy without scheme to be rejected")
+	}
+}
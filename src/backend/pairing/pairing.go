@@ -0,0 +1,96 @@
+// Package pairing pairs the clock in and clock out records of a work clock into sessions.
+//
+// A session starts with a clock in record and ends with the clock out record following it. The
+// latest session may still be open if the user is currently clocked in. Records that can't be
+// paired properly are reported as Issue:
+// - OrphanClockOut: A clock out record without a preceding clock in record, which is ignored
+// - OverlappingSession: A clock in record followed by another clock in record, so its session
+// has no end and overlaps the following session
+//
+// The pairing is deterministic: records are ordered by their timestamp and, for equal
+// timestamps, by their ID, independent of the order they are passed in. The package has no
+// dependency on PocketBase, so reports, exports, integrity checks and tools share the same rules.
+package pairing
+
+import (
+	"cmp"
+	"slices"
+	"time"
+)
+
+// Codes of the pairing issues.
+const (
+	OrphanClockOut     = "orphan_clock_out"
+	OverlappingSession = "overlapping_session"
+)
+
+// Record is a clock in or clock out record of a work clock.
+type Record struct {
+	ID        string    // ID of the record
+	Timestamp time.Time // Timestamp of the record
+	ClockIn   bool      // true for a clock in record, false for a clock out record
+}
+
+// Session is a pair of a clock in record and the clock out record following it.
+type Session struct {
+	ClockIn  Record  // The clock in record starting the session
+	ClockOut *Record // The clock out record ending the session, nil if the session is still open
+}
+
+// Issue is a record that can't be paired into a proper session.
+type Issue struct {
+	Code      string    `json:"code"`      // Machine-readable code, OrphanClockOut or OverlappingSession
+	RecordID  string    `json:"record_id"` // ID of the affected record
+	Timestamp time.Time `json:"timestamp"` // Timestamp of the affected record
+}
+
+// PairRecords pairs records into sessions.
+//
+// Parameters:
+// - records: The records of a single clock, in any order
+//
+// Returns:
+// - The sessions sorted by their start
+// - The records that can't be paired properly, sorted by their timestamp
+func PairRecords(records []Record) ([]Session, []Issue) {
+	compare := func(a, b Record) int {
+		return cmp.Or(a.Timestamp.Compare(b.Timestamp), cmp.Compare(a.ID, b.ID))
+	}
+	if !slices.IsSortedFunc(records, compare) {
+		records = slices.SortedFunc(slices.Values(records), compare)
+	}
+
+	var sessions []Session
+	var issues []Issue
+
+	for _, record := range records {
+		if record.ClockIn {
+			if len(sessions) > 0 && sessions[len(sessions)-1].ClockOut == nil {
+				issues = append(issues, newIssue(OverlappingSession, sessions[len(sessions)-1].ClockIn))
+			}
+			sessions = append(sessions, Session{ClockIn: record})
+			continue
+		}
+
+		if len(sessions) > 0 && sessions[len(sessions)-1].ClockOut == nil {
+			clockOut := record
+			sessions[len(sessions)-1].ClockOut = &clockOut
+		} else {
+			issues = append(issues, newIssue(OrphanClockOut, record))
+		}
+	}
+
+	return sessions, issues
+}
+
+// newIssue creates the issue of a record.
+//
+// Parameters:
+// - code: The machine-readable code of the issue
+// - record: The affected record
+//
+// Returns:
+// - The issue
+func newIssue(code string, record Record) Issue {
+	return Issue{Code: code, RecordID: record.ID, Timestamp: record.Timestamp}
+}
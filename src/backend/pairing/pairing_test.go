@@ -0,0 +1,97 @@
+package pairing
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+// record creates a record at the given hour of April 1, 2025.
+func record(id string, hour int, clockIn bool) Record {
+	return Record{ID: id, Timestamp: time.Date(2025, time.April, 1, hour, 0, 0, 0, time.UTC), ClockIn: clockIn}
+}
+
+func TestPairRecords(t *testing.T) {
+	testCases := []struct {
+		name     string
+		records  []Record
+		sessions [][2]string // IDs of the clock in and clock out records, empty for an open session
+		issues   []Issue
+	}{
+		{
+			name: "alternating records",
+			records: []Record{
+				record("a", 8, true), record("b", 12, false), record("c", 13, true), record("d", 17, false),
+			},
+			sessions: [][2]string{{"a", "b"}, {"c", "d"}},
+		},
+		{
+			name:     "open session",
+			records:  []Record{record("a", 8, true), record("b", 12, false), record("c", 13, true)},
+			sessions: [][2]string{{"a", "b"}, {"c", ""}},
+		},
+		{
+			name:     "no records",
+			records:  nil,
+			sessions: nil,
+		},
+		{
+			name:     "leading clock out",
+			records:  []Record{record("a", 8, false), record("b", 9, true), record("c", 12, false)},
+			sessions: [][2]string{{"b", "c"}},
+			issues:   []Issue{{Code: OrphanClockOut, RecordID: "a", Timestamp: record("a", 8, false).Timestamp}},
+		},
+		{
+			name:     "two clock outs in a row",
+			records:  []Record{record("a", 8, true), record("b", 12, false), record("c", 13, false)},
+			sessions: [][2]string{{"a", "b"}},
+			issues:   []Issue{{Code: OrphanClockOut, RecordID: "c", Timestamp: record("c", 13, false).Timestamp}},
+		},
+		{
+			name:     "two clock ins in a row",
+			records:  []Record{record("a", 8, true), record("b", 13, true), record("c", 17, false)},
+			sessions: [][2]string{{"a", ""}, {"b", "c"}},
+			issues:   []Issue{{Code: OverlappingSession, RecordID: "a", Timestamp: record("a", 8, true).Timestamp}},
+		},
+		{
+			name:     "unsorted records",
+			records:  []Record{record("c", 13, true), record("b", 12, false), record("a", 8, true)},
+			sessions: [][2]string{{"a", "b"}, {"c", ""}},
+		},
+		{
+			name:     "equal timestamps are ordered by ID",
+			records:  []Record{record("b", 8, false), record("a", 8, true)},
+			sessions: [][2]string{{"a", "b"}},
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			sessions, issues := PairRecords(testCase.records)
+
+			var pairs [][2]string
+			for _, session := range sessions {
+				pair := [2]string{session.ClockIn.ID, ""}
+				if session.ClockOut != nil {
+					pair[1] = session.ClockOut.ID
+				}
+				pairs = append(pairs, pair)
+			}
+			if !slices.Equal(pairs, testCase.sessions) {
+				t.Errorf("expected sessions %v, got %v", testCase.sessions, pairs)
+			}
+			if !slices.Equal(issues, testCase.issues) {
+				t.Errorf("expected issues %v, got %v", testCase.issues, issues)
+			}
+		})
+	}
+}
+
+func TestPairRecordsKeepsInput(t *testing.T) {
+	records := []Record{record("b", 12, false), record("a", 8, true)}
+	PairRecords(records)
+
+	if records[0].ID != "b" || records[1].ID != "a" {
+		t.Errorf("expected the input to keep its order, got %v", records)
+	}
+}
@@ -0,0 +1,369 @@
+// Payroll Export Module for PocketBase
+//
+// This module exports the sessions in the file layout of a payroll provider. The layout is
+// described by an export profile (see the export_profiles collection): the columns, the delimiter,
+// and how hours, decimal numbers and dates are formatted. Rows are either single sessions or the
+// total per day and project, and carry the cost center and hourly rate of the project, so the
+// file can be ingested by the payroll system (e.g. a DATEV-style import) without manual editing.
+// Sessions of a category such as on-call are exported in separate rows; their counted hours and
+// amounts are reduced by the factor of the category.
+// Expenses are reported in the 'expenses' column: expenses of a session in the row of the session,
+// expenses of a day in the row of the day and project, or in a separate row if grouped by session
+// or if there is no such row.
+// Numbers follow the conventions of the profile's locale (7,50 in German, 7.50 in English) unless
+// the profile sets a decimal separator explicitly.
+//
+// Payroll exports are requested through the export endpoint with format=payroll and are
+// streamed like the other export formats. Open sessions are not exported, since they are not
+// payable yet.
+package backend
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/pocketbase/pocketbase/core"
+)
+
+// payrollProfile is the file layout of a payroll export, loaded from an export_profiles record.
+type payrollProfile struct {
+	Columns          []string // Ordered names of the exported columns, see payrollColumns
+	Delimiter        rune     // Column delimiter
+	DecimalSeparator string   // Separator of decimal numbers
+	DurationFormat   string   // 'decimal' (7,50), 'hours_minutes' (7:30) or 'units' (7h30m)
+	GroupBy          string   // 'session' for one row per session, 'day' for one row per day, project and category
+	DateFormat       string   // Layout of dates in the Go reference time notation
+	IncludeHeader    bool     // Whether the first row contains the column names
+	PersonnelNumber  string   // Personnel number of the employee
+	WageType         string   // Wage type code the hours are booked as
+}
+
+// payrollRow is a row of a payroll export, either a single session or the total of a day, project and category.
+type payrollRow struct {
+	Date        time.Time     // Day of the row in local time
+	Start       time.Time     // Start of the session, zero for day totals
+	End         time.Time     // End of the session, zero for day totals
+	Duration    time.Duration // Worked time of the row
+	Project     *core.Record  // Project of the row, nil for sessions without (existing) project
+	Category    string        // Category of the row, empty for regular work
+	Description string        // Description of the session or expense, empty for day totals
+	Expenses    float64       // Total amount of the expenses of the row
+}
+
+// payrollColumns are the columns an export profile can contain, with the functions formatting them.
+var payrollColumns = map[string]func(profile payrollProfile, row payrollRow) string{
+	"personnel_number": func(profile payrollProfile, row payrollRow) string { return profile.PersonnelNumber },
+	"wage_type":        func(profile payrollProfile, row payrollRow) string { return profile.WageType },
+	"date":             func(profile payrollProfile, row payrollRow) string { return row.Date.Format(profile.DateFormat) },
+	"start":            func(profile payrollProfile, row payrollRow) string { return formatPayrollClockTime(row.Start) },
+	"end":              func(profile payrollProfile, row payrollRow) string { return formatPayrollClockTime(row.End) },
+	"hours":            func(profile payrollProfile, row payrollRow) string { return profile.formatDuration(row.Duration) },
+	"counted_hours": func(profile payrollProfile, row payrollRow) string {
+		return profile.formatDuration(row.countedDuration())
+	},
+	"category":    func(profile payrollProfile, row payrollRow) string { return row.Category },
+	"description": func(profile payrollProfile, row payrollRow) string { return row.Description },
+	"expenses": func(profile payrollProfile, row payrollRow) string {
+		if row.Expenses <= 0 {
+			return ""
+		}
+		return profile.formatDecimal(row.Expenses)
+	},
+	"project": func(profile payrollProfile, row payrollRow) string {
+		if row.Project == nil {
+			return ""
+		}
+		return row.Project.GetString("name")
+	},
+	"cost_center": func(profile payrollProfile, row payrollRow) string {
+		if row.Project == nil {
+			return ""
+		}
+		return row.Project.GetString("cost_center")
+	},
+	"hourly_rate": func(profile payrollProfile, row payrollRow) string {
+		if row.Project == nil || row.Project.GetFloat("hourly_rate") <= 0 {
+			return ""
+		}
+		return profile.formatDecimal(row.Project.GetFloat("hourly_rate"))
+	},
+	"amount": func(profile payrollProfile, row payrollRow) string {
+		if row.Project == nil || row.Project.GetFloat("hourly_rate") <= 0 {
+			return ""
+		}
+		return profile.formatDecimal(row.countedDuration().Hours() * row.Project.GetFloat("hourly_rate"))
+	},
+}
+
+// loadPayrollProfile loads and validates an export profile.
+//
+// Parameters:
+// - app: The App interface (typically a PocketBase instance or transaction)
+// - profileID: The ID of the export_profiles record
+//
+// Returns:
+// - The export profile
+// - An error if the profile does not exist or contains unknown columns
+func loadPayrollProfile(app core.App, profileID string) (payrollProfile, error) {
+	record, err := app.FindRecordById("export_profiles", profileID)
+	if err != nil {
+		return payrollProfile{}, fmt.Errorf("export profile with id '%s' does not exist", profileID)
+	}
+
+	profile := payrollProfile{
+		Delimiter:        ';',
+		DecimalSeparator: ",",
+		DurationFormat:   record.GetString("duration_format"),
+		GroupBy:          record.GetString("group_by"),
+		DateFormat:       record.GetString("date_format"),
+		IncludeHeader:    record.GetBool("include_header"),
+		PersonnelNumber:  record.GetString("personnel_number"),
+		WageType:         record.GetString("wage_type"),
+	}
+
+	if delimiter := record.GetString("delimiter"); delimiter != "" {
+		profile.Delimiter, _ = utf8.DecodeRuneInString(delimiter)
+		if strings.ContainsRune("\"\r\n", profile.Delimiter) || profile.Delimiter == utf8.RuneError {
+			return payrollProfile{}, fmt.Errorf("export profile with id '%s' has an invalid delimiter", profileID)
+		}
+	}
+	// An explicit decimal separator takes precedence over the one of the profile's locale
+	if separator, ok := localeDecimalSeparators[record.GetString("locale")]; ok {
+		profile.DecimalSeparator = separator
+	}
+	if separator := record.GetString("decimal_separator"); separator != "" {
+		profile.DecimalSeparator = separator
+	}
+
+	if err := record.UnmarshalJSONField("columns", &profile.Columns); err != nil {
+		return payrollProfile{}, fmt.Errorf("export profile with id '%s' has invalid columns: %w", profileID, err)
+	}
+	if len(profile.Columns) == 0 {
+		return payrollProfile{}, fmt.Errorf("export profile with id '%s' has no columns", profileID)
+	}
+	for _, column := range profile.Columns {
+		if _, ok := payrollColumns[column]; !ok {
+			return payrollProfile{}, fmt.Errorf("export profile with id '%s' has unknown column '%s'", profileID, column)
+		}
+	}
+
+	return profile, nil
+}
+
+// formatDecimal formats a number with two decimal places and the decimal separator of the profile.
+func (p payrollProfile) formatDecimal(value float64) string {
+	return strings.Replace(strconv.FormatFloat(value, 'f', 2, 64), ".", p.DecimalSeparator, 1)
+}
+
+// countedDuration returns the duration of the row that counts as work time, see categoryFactor.
+func (r payrollRow) countedDuration() time.Duration {
+	return time.Duration(float64(r.Duration) * categoryFactor(r.Category))
+}
+
+// formatDuration formats a duration as hours in the duration format of the profile.
+func (p payrollProfile) formatDuration(duration time.Duration) string {
+	return formatDuration(duration, p.DurationFormat, p.DecimalSeparator)
+}
+
+// formatPayrollClockTime formats the local time of day of a session start or end, zero times are empty.
+func formatPayrollClockTime(timestamp time.Time) string {
+	if timestamp.IsZero() {
+		return ""
+	}
+	return timestamp.In(time.Local).Format("15:04")
+}
+
+// streamPayrollExport writes the closed sessions and the expenses within a range as payroll file to a writer,
+// flushing after each batch.
+//
+// Parameters:
+// - app: The App interface (typically a PocketBase instance or transaction)
+// - w: The writer receiving the file, typically the response
+// - flush: Flushes the written data to the client
+// - profile: The export profile describing the file layout
+// - from: The start of the range (inclusive), a zero value means unbounded
+// - to: The end of the range (exclusive), a zero value means unbounded
+// - filter: The filter the exported sessions have to match
+//
+// Returns:
+// - An error if loading the sessions or writing the file fails
+func streamPayrollExport(app core.App, w io.Writer, flush func() error, profile payrollProfile, from, to time.Time, filter exportFilter) error {
+	csvWriter := csv.NewWriter(w)
+	csvWriter.Comma = profile.Delimiter
+
+	if profile.IncludeHeader {
+		if err := csvWriter.Write(profile.Columns); err != nil {
+			return err
+		}
+	}
+
+	writeRow := func(row payrollRow) error {
+		values := make([]string, 0, len(profile.Columns))
+		for _, column := range profile.Columns {
+			values = append(values, payrollColumns[column](profile, row))
+		}
+		return csvWriter.Write(values)
+	}
+
+	projects := map[string]*core.Record{}
+	findProject := func(projectID string) *core.Record {
+		if projectID == "" {
+			return nil
+		}
+		if project, ok := projects[projectID]; ok {
+			return project
+		}
+
+		// Sessions of deleted projects are exported without project
+		project, _ := app.FindRecordById("projects", projectID)
+		projects[projectID] = project
+		return project
+	}
+
+	expenses, err := findExpenses(app, filter.ClockID, from, to)
+	if err != nil {
+		return err
+	}
+
+	// Expenses of sessions are added to the rows of the sessions, expenses of days are kept as
+	// separate rows until the rows of their day are written. Expenses of days have no tags.
+	sessionExpenses := map[string]float64{}
+	var dayExpenseRows []payrollRow
+	for _, expense := range expenses {
+		if sessionID := expense.GetString("session"); sessionID != "" {
+			sessionExpenses[sessionID] += expense.GetFloat("amount")
+			continue
+		}
+		if (filter.ProjectID != "" && expense.GetString("project") != filter.ProjectID) || len(filter.TagIDs) > 0 {
+			continue
+		}
+
+		row := payrollRow{
+			Date:     expenseDate(expense),
+			Project:  findProject(expense.GetString("project")),
+			Expenses: expense.GetFloat("amount"),
+		}
+		if profile.GroupBy != "day" {
+			row.Description = expense.GetString("description")
+		}
+		dayExpenseRows = append(dayExpenseRows, row)
+	}
+
+	// writeDayExpensesBefore writes the rows of the expenses of days before a day, a zero day writes all of them
+	writeDayExpensesBefore := func(day time.Time) error {
+		for len(dayExpenseRows) > 0 && (day.IsZero() || dayExpenseRows[0].Date.Before(day)) {
+			if err := writeRow(dayExpenseRows[0]); err != nil {
+				return err
+			}
+			dayExpenseRows = dayExpenseRows[1:]
+		}
+		return nil
+	}
+
+	// Day totals are collected until the sessions of the next day start, since sessions are sorted by their start
+	var dayRows []payrollRow
+	mergeDayRow := func(row payrollRow) {
+		for i := range dayRows {
+			if dayRows[i].Project == row.Project && dayRows[i].Category == row.Category {
+				dayRows[i].Duration += row.Duration
+				dayRows[i].Expenses += row.Expenses
+				return
+			}
+		}
+		dayRows = append(dayRows, row)
+	}
+	flushDay := func() error {
+		if len(dayRows) == 0 {
+			return nil
+		}
+
+		day := dayRows[0].Date
+		if err := writeDayExpensesBefore(day); err != nil {
+			return err
+		}
+		for len(dayExpenseRows) > 0 && dayExpenseRows[0].Date.Equal(day) {
+			mergeDayRow(dayExpenseRows[0])
+			dayExpenseRows = dayExpenseRows[1:]
+		}
+
+		for _, row := range dayRows {
+			if err := writeRow(row); err != nil {
+				return err
+			}
+		}
+		dayRows = nil
+		return nil
+	}
+
+	var cursor time.Time
+	for {
+		sessions, _, nextCursor, err := findWorkSessionsPage(app, filter.ClockID, from, to, cursor, exportBatchSize)
+		if err != nil {
+			return err
+		}
+
+		for _, session := range sessions {
+			if session.ClockOut == nil || !filter.matches(session) {
+				continue
+			}
+
+			start := session.Start().In(time.Local)
+			row := payrollRow{
+				Date:     time.Date(start.Year(), start.Month(), start.Day(), 0, 0, 0, 0, time.Local),
+				Duration: session.Duration(start),
+				Project:  findProject(session.ClockIn.GetString("project")),
+				Category: session.ClockIn.GetString("category"),
+				Expenses: sessionExpenses[session.ClockIn.Id],
+			}
+
+			if profile.GroupBy != "day" {
+				row.Start = start
+				row.End = session.End(start)
+				row.Description = session.ClockIn.GetString("description")
+				if err := writeDayExpensesBefore(row.Date); err != nil {
+					return err
+				}
+				if err := writeRow(row); err != nil {
+					return err
+				}
+				continue
+			}
+
+			if len(dayRows) > 0 && !dayRows[0].Date.Equal(row.Date) {
+				if err := flushDay(); err != nil {
+					return err
+				}
+			}
+
+			mergeDayRow(row)
+		}
+
+		csvWriter.Flush()
+		if err := csvWriter.Error(); err != nil {
+			return err
+		}
+		if err := flush(); err != nil {
+			return err
+		}
+
+		if nextCursor.IsZero() {
+			break
+		}
+		cursor = nextCursor
+	}
+
+	if err := flushDay(); err != nil {
+		return err
+	}
+	if err := writeDayExpensesBefore(time.Time{}); err != nil {
+		return err
+	}
+
+	csvWriter.Flush()
+	return csvWriter.Error()
+}
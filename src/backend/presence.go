@@ -0,0 +1,173 @@
+// Presence Module for PocketBase
+//
+// This module answers which users are currently clocked in, for many users with a single request,
+// so a team wallboard doesn't need one status request per member and refresh. The state of a user
+// is made up of the status of each clock they own (see the delegation module); a user is present
+// while any of their clocks is clocked in.
+//
+// Users can query themselves and the leads and members of the teams they belong to, superusers
+// can query everyone. Users that don't exist or aren't visible to the requester are listed as not
+// found, so the response doesn't reveal which users exist.
+package backend
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/apis"
+	"github.com/pocketbase/pocketbase/core"
+)
+
+// maxPresenceQueryUsers is the maximum number of users of a presence query.
+const maxPresenceQueryUsers = 200
+
+// presenceQueryRequest is the body of the presence query endpoint.
+type presenceQueryRequest struct {
+	Users []string `json:"users" form:"users"` // IDs of the queried users
+}
+
+// PresenceClock is the state of a clock owned by a queried user.
+type PresenceClock struct {
+	Clock     string     `json:"clock"`      // Name of the clock
+	ClockedIn bool       `json:"clocked_in"` // Whether a session is currently open
+	Since     *time.Time `json:"since"`      // Timestamp of the latest work clock record, null without records
+	Stale     bool       `json:"stale"`      // Whether the open session is longer than a workday
+}
+
+// PresenceEntry is the state of a queried user.
+type PresenceEntry struct {
+	User    string          `json:"user"`    // ID of the user
+	Found   bool            `json:"found"`   // Whether the user exists and is visible to the requester
+	Present bool            `json:"present"` // Whether any clock of the user is clocked in
+	Since   *time.Time      `json:"since"`   // Start of the earliest open session, null if not present
+	Clocks  []PresenceClock `json:"clocks"`  // States of the clocks owned by the user, sorted by name
+}
+
+// RegisterPresenceAPI registers the presence endpoint with the PocketBase server.
+// It creates the following route, only accessible for authenticated users:
+// - POST /api/presence/query - Returns the PresenceEntry of each user in 'users', in the requested order
+//
+// Parameters:
+// - app: The PocketBase application instance
+func RegisterPresenceAPI(app core.App) {
+	app.OnServe().BindFunc(func(se *core.ServeEvent) error {
+		se.Router.POST("/api/presence/query", func(e *core.RequestEvent) error {
+			var request presenceQueryRequest
+			if err := e.BindBody(&request); err != nil {
+				return e.Error(http.StatusBadRequest, "Invalid request body", err)
+			}
+			if len(request.Users) == 0 {
+				return e.Error(http.StatusBadRequest, "missing 'users' (string array) parameter", nil)
+			}
+			if len(request.Users) > maxPresenceQueryUsers {
+				return e.Error(http.StatusBadRequest, fmt.Sprintf("too many users, at most %d can be queried at once", maxPresenceQueryUsers), nil)
+			}
+
+			visible, err := visiblePresenceUsers(app, e)
+			if err != nil {
+				return e.Error(http.StatusInternalServerError, fmt.Sprintf("Failed to query presence: %v", err), err)
+			}
+
+			entries, err := getPresence(app, request.Users, visible)
+			if err != nil {
+				return e.Error(http.StatusInternalServerError, fmt.Sprintf("Failed to query presence: %v", err), err)
+			}
+
+			return e.JSON(http.StatusOK, entries)
+		}).Bind(apis.RequireAuth())
+
+		return se.Next()
+	})
+}
+
+// visiblePresenceUsers determines the users whose presence the requester can see.
+//
+// Parameters:
+// - app: The App interface (typically a PocketBase instance or transaction)
+// - e: The RequestEvent of the authenticated request
+//
+// Returns:
+// - The IDs of the visible users, nil if all users are visible
+// - An error if the teams of the requester could not be retrieved
+func visiblePresenceUsers(app core.App, e *core.RequestEvent) (map[string]bool, error) {
+	if e.HasSuperuserAuth() {
+		return nil, nil
+	}
+
+	teams, err := app.FindAllRecords("teams", dbx.Or(
+		dbx.NewExp("members LIKE {:user}", dbx.Params{"user": "%" + e.Auth.Id + "%"}),
+		dbx.NewExp("leads LIKE {:user}", dbx.Params{"user": "%" + e.Auth.Id + "%"}),
+	))
+	if err != nil {
+		return nil, fmt.Errorf("failed to find teams: %w", err)
+	}
+
+	visible := map[string]bool{e.Auth.Id: true}
+	for _, team := range teams {
+		members := append(team.GetStringSlice("members"), team.GetStringSlice("leads")...)
+		if !slices.Contains(members, e.Auth.Id) {
+			continue
+		}
+		for _, member := range members {
+			visible[member] = true
+		}
+	}
+	return visible, nil
+}
+
+// getPresence determines the state of users.
+//
+// Parameters:
+// - app: The App interface (typically a PocketBase instance or transaction)
+// - userIDs: The IDs of the queried users
+// - visible: The IDs of the users visible to the requester, nil if all users are visible
+//
+// Returns:
+// - The state of each queried user, in the order of userIDs
+// - An error if a query fails
+func getPresence(app core.App, userIDs []string, visible map[string]bool) ([]PresenceEntry, error) {
+	now := clockNow(app)
+
+	entries := make([]PresenceEntry, 0, len(userIDs))
+	for _, userID := range userIDs {
+		entry := PresenceEntry{User: userID, Clocks: []PresenceClock{}}
+		if visible != nil && !visible[userID] {
+			entries = append(entries, entry)
+			continue
+		}
+		if _, err := app.FindRecordById("users", userID); err != nil {
+			entries = append(entries, entry)
+			continue
+		}
+		entry.Found = true
+
+		clocks, err := app.FindRecordsByFilter("clocks", "owner = {:owner}", "+name", 0, 0, dbx.Params{"owner": userID})
+		if err != nil {
+			return nil, fmt.Errorf("failed to find clocks of user '%s': %w", userID, err)
+		}
+
+		for _, clock := range clocks {
+			status, err := getWorkClockStatus(app, clock.Id, now)
+			if err != nil {
+				return nil, fmt.Errorf("failed to get status of clock '%s': %w", clock.GetString("name"), err)
+			}
+
+			entry.Clocks = append(entry.Clocks, PresenceClock{
+				Clock:     clock.GetString("name"),
+				ClockedIn: status.ClockedIn,
+				Since:     status.Since,
+				Stale:     status.Stale,
+			})
+			if status.ClockedIn && (entry.Since == nil || status.Since.Before(*entry.Since)) {
+				entry.Present = true
+				entry.Since = status.Since
+			}
+		}
+		entries = append(entries, entry)
+	}
+
+	return entries, nil
+}
@@ -0,0 +1,91 @@
+package backend
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/yerTools/simple-frontend-stack/src/backend/backendtest"
+)
+
+func TestPresenceQuery(t *testing.T) {
+	app := backendtest.NewApp(t)
+	RegisterPresenceAPI(app)
+	handler := backendtest.NewHandler(t, app)
+
+	lead := backendtest.AddUser(t, app, "lead@example.com")
+	member := backendtest.AddUser(t, app, "member@example.com")
+	outsider := backendtest.AddUser(t, app, "outsider@example.com")
+
+	clocks, err := app.FindCollectionByNameOrId("clocks")
+	if err != nil {
+		t.Fatalf("failed to find clocks collection: %v", err)
+	}
+	clock := core.NewRecord(clocks)
+	clock.Set("name", "member")
+	clock.Set("owner", member.Id)
+	if err := app.Save(clock); err != nil {
+		t.Fatalf("failed to save clock: %v", err)
+	}
+	if err := workClockServiceOf(app).ClockInOut(clock.Id, true, false); err != nil {
+		t.Fatalf("failed to clock in: %v", err)
+	}
+
+	teams, err := app.FindCollectionByNameOrId("teams")
+	if err != nil {
+		t.Fatalf("failed to find teams collection: %v", err)
+	}
+	team := core.NewRecord(teams)
+	team.Set("name", "Support")
+	team.Set("leads", []string{lead.Id})
+	team.Set("members", []string{member.Id})
+	if err := app.Save(team); err != nil {
+		t.Fatalf("failed to save team: %v", err)
+	}
+
+	query := func(user *core.Record, body string) ([]PresenceEntry, int) {
+		request := httptest.NewRequest(http.MethodPost, "/api/presence/query", strings.NewReader(body))
+		request.Header.Set("Content-Type", "application/json")
+		token, err := user.NewAuthToken()
+		if err != nil {
+			t.Fatalf("failed to create token: %v", err)
+		}
+		request.Header.Set("Authorization", token)
+
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, request)
+
+		var entries []PresenceEntry
+		if recorder.Code == http.StatusOK {
+			if err := json.Unmarshal(recorder.Body.Bytes(), &entries); err != nil {
+				t.Fatalf("failed to decode presence: %v", err)
+			}
+		}
+		return entries, recorder.Code
+	}
+
+	entries, code := query(lead, `{"users":["`+member.Id+`","`+outsider.Id+`","missing","`+lead.Id+`"]}`)
+	if code != http.StatusOK || len(entries) != 4 {
+		t.Fatalf("expected 4 entries with status 200, got %d: %+v", code, entries)
+	}
+	if entry := entries[0]; !entry.Found || !entry.Present || entry.Since == nil || len(entry.Clocks) != 1 || entry.Clocks[0].Clock != "member" {
+		t.Errorf("expected the member to be present on their clock, got %+v", entry)
+	}
+	if entries[1].Found || entries[2].Found {
+		t.Errorf("expected users outside of the teams and unknown users to be not found, got %+v and %+v", entries[1], entries[2])
+	}
+	if entry := entries[3]; !entry.Found || entry.Present || len(entry.Clocks) != 0 {
+		t.Errorf("expected the lead to be found without clocks, got %+v", entry)
+	}
+
+	if entries, _ := query(outsider, `{"users":["`+member.Id+`"]}`); len(entries) != 1 || entries[0].Found {
+		t.Errorf("expected the member to be hidden from users outside of the team, got %+v", entries)
+	}
+
+	if _, code := query(lead, `{"users":[]}`); code != http.StatusBadRequest {
+		t.Errorf("expected status 400 without users, got %d", code)
+	}
+}
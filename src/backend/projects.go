@@ -0,0 +1,328 @@
+// Projects Module for PocketBase
+//
+// This module links work clock sessions to the projects collection and monitors project
+// hour budgets. A session belongs to the project set on its clock in record.
+//
+// Whenever a session of a project with a budget changes, the consumed hours are recalculated.
+// If 80% or 100% of the budget are consumed for the first time, a log entry is written and a
+// "project.budget_threshold_reached" webhook event is emitted. A budget status endpoint
+// provides the current consumption of all projects for the dashboard.
+//
+// Records changed by an import or its rollback are not checked one by one, which would recalculate
+// every session of a project for every imported record. Instead, all budgets are checked once when
+// the import run is recorded or rolled back.
+package backend
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/core"
+)
+
+// projectBudgetThresholds are the consumed budget percentages that trigger an alert.
+var projectBudgetThresholds = []int{80, 100}
+
+// ProjectBudgetStatus describes the budget consumption of a project.
+type ProjectBudgetStatus struct {
+	ProjectID       string  `json:"project_id"`       // ID of the project
+	Name            string  `json:"name"`             // Name of the project
+	BudgetHours     float64 `json:"budget_hours"`     // Hour budget of the project, 0 if the project has no budget
+	ConsumedHours   float64 `json:"consumed_hours"`   // Hours worked on the project, including the open session
+	Budget          string  `json:"budget"`           // Hour budget formatted in the configured duration format, empty if the project has no budget
+	Consumed        string  `json:"consumed"`         // Worked hours formatted in the configured duration format
+	ConsumedPercent float64 `json:"consumed_percent"` // Consumed share of the budget in percent, 0 if the project has no budget
+	ThresholdLevel  int     `json:"threshold_level"`  // Highest reached threshold in percent (0, 80 or 100)
+}
+
+// RegisterProjectsAPI registers the project endpoints and budget hooks with the PocketBase server.
+// It creates the following routes:
+// - POST /api/work_clock/project - Assigns a session (by its clock in ID) to a project
+// - GET /api/projects/budgets - Returns the budget status of all projects, supports conditional requests
+//
+// Parameters:
+// - app: The PocketBase application instance
+func RegisterProjectsAPI(app core.App) {
+	app.OnServe().BindFunc(func(se *core.ServeEvent) error {
+		se.Router.POST("/api/work_clock/project", func(e *core.RequestEvent) error {
+			clockInID := e.Request.FormValue("clock_in_id")
+			if clockInID == "" {
+				return e.Error(http.StatusBadRequest, "Missing 'clock_in_id' (string) parameter", nil)
+			}
+
+			// An empty project ID removes the session from its project
+			projectID := e.Request.FormValue("project_id")
+
+			if err := setSessionProject(e.Request.Context(), app, clockInID, projectID); err != nil {
+				return e.Error(http.StatusInternalServerError, fmt.Sprintf("Failed to set project of session: %v", err), err)
+			}
+			return callSucceeded(e)
+		})
+
+		se.Router.GET("/api/projects/budgets", func(e *core.RequestEvent) error {
+			statuses, err := cachedReport(app, "budgets", func(now time.Time) ([]ProjectBudgetStatus, error) {
+				return getProjectBudgetStatuses(app, now)
+			})
+			if err != nil {
+				return e.Error(http.StatusInternalServerError, fmt.Sprintf("Failed to get budget status: %v", err), err)
+			}
+
+			return respondConditionalJSON(e, statuses, workClockLastModified(app))
+		})
+
+		return se.Next()
+	})
+
+	checkBudget := func(e *core.RecordEvent) error {
+		if !isImportRunChange(e.App, e.Record) {
+			checkProjectBudgetsOfRecord(e.App, e.Record)
+		}
+		return e.Next()
+	}
+	app.OnRecordAfterCreateSuccess("work_clock").BindFunc(checkBudget)
+	app.OnRecordAfterUpdateSuccess("work_clock").BindFunc(checkBudget)
+	app.OnRecordAfterDeleteSuccess("work_clock").BindFunc(checkBudget)
+
+	checkAllBudgets := func(e *core.RecordEvent) error {
+		if e.Record.GetString("outcome") == "succeeded" {
+			checkAllProjectBudgets(e.App)
+		}
+		return e.Next()
+	}
+	app.OnRecordAfterCreateSuccess("import_runs").BindFunc(checkAllBudgets)
+	app.OnRecordAfterUpdateSuccess("import_runs").BindFunc(checkAllBudgets)
+}
+
+// setSessionProject assigns the session started by a clock in record to a project.
+//
+// Parameters:
+// - ctx: The context cancelling the operation, e.g. of the request
+// - app: The App interface (typically a PocketBase instance or transaction)
+// - clockInID: The ID of the clock in record starting the session
+// - projectID: The ID of the project, an empty string removes the session from its project
+//
+// Returns:
+// - An error if the record is not a clock in record, the project does not exist, or saving fails
+func setSessionProject(ctx context.Context, app core.App, clockInID string, projectID string) error {
+	unlock, err := workClockServiceOf(app).WithContext(ctx).lockSession("", clockInID)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	record, err := findClockInRecord(app, "", clockInID)
+	if err != nil {
+		return err
+	}
+
+	if projectID != "" {
+		if _, err := app.FindRecordById("projects", projectID); err != nil {
+			return fmt.Errorf("failed to find project with id '%s': %w", projectID, err)
+		}
+	}
+
+	record.Set("project", projectID)
+	if err := app.Save(record); err != nil {
+		return fmt.Errorf("failed to save work clock record: %w", err)
+	}
+
+	return nil
+}
+
+// getProjectBudgetStatuses calculates the budget consumption of all projects.
+//
+// Parameters:
+// - app: The App interface (typically a PocketBase instance or transaction)
+// - now: The reference time used as end of an open session
+//
+// Returns:
+// - The budget status of all projects sorted by their name
+// - An error if the projects or their sessions could not be retrieved
+func getProjectBudgetStatuses(app core.App, now time.Time) ([]ProjectBudgetStatus, error) {
+	projects, err := app.FindRecordsByFilter("projects", "", "+name", 0, 0)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find projects: %w", err)
+	}
+
+	statuses := make([]ProjectBudgetStatus, 0, len(projects))
+	for _, project := range projects {
+		status, err := getProjectBudgetStatus(app, project, now)
+		if err != nil {
+			return nil, err
+		}
+		statuses = append(statuses, status)
+	}
+
+	return statuses, nil
+}
+
+// getProjectBudgetStatus calculates the budget consumption of a project.
+//
+// Parameters:
+// - app: The App interface (typically a PocketBase instance or transaction)
+// - project: The project record
+// - now: The reference time used as end of an open session
+//
+// Returns:
+// - The budget status of the project
+// - An error if the sessions of the project could not be retrieved
+func getProjectBudgetStatus(app core.App, project *core.Record, now time.Time) (ProjectBudgetStatus, error) {
+	status := ProjectBudgetStatus{
+		ProjectID:   project.Id,
+		Name:        project.GetString("name"),
+		BudgetHours: project.GetFloat("budget_hours"),
+	}
+
+	clockInRecords, err := app.FindRecordsByFilter("work_clock", "clock_in = true && project = {:project}", "+timestamp", 0, 0, dbx.Params{
+		"project": project.Id,
+	})
+	if err != nil {
+		return status, fmt.Errorf("failed to find sessions of project '%s': %w", project.Id, err)
+	}
+
+	var consumed time.Duration
+	for _, clockIn := range clockInRecords {
+		session, err := findWorkSessionByClockIn(app, clockIn)
+		if err != nil {
+			return status, err
+		}
+		consumed += session.Duration(now)
+	}
+
+	status.ConsumedHours = consumed.Hours()
+	status.Consumed = formatResponseDuration(int64(consumed.Seconds()))
+	if status.BudgetHours > 0 {
+		status.Budget = formatResponseDuration(int64(status.BudgetHours * 3600))
+		status.ConsumedPercent = status.ConsumedHours / status.BudgetHours * 100
+
+		for _, threshold := range projectBudgetThresholds {
+			if status.ConsumedPercent >= float64(threshold) {
+				status.ThresholdLevel = threshold
+			}
+		}
+	}
+
+	return status, nil
+}
+
+// checkProjectBudgetsOfRecord checks the budgets of all projects affected by a changed work clock record.
+// The project of a clock in record is affected directly, the project of a clock out record is the
+// project of the session it ends. For updated records, the previous project is checked as well.
+//
+// Parameters:
+// - app: The App interface (typically a PocketBase instance or transaction)
+// - record: The created, updated or deleted work clock record
+//
+// Errors are logged instead of returned, since the record change itself already succeeded.
+func checkProjectBudgetsOfRecord(app core.App, record *core.Record) {
+	projectIDs := map[string]struct{}{}
+
+	clockIn, err := findSessionClockIn(app, record)
+	if err != nil {
+		app.Logger().Error("failed to find session of work clock record", "record", record.Id, "error", err)
+		return
+	}
+	if clockIn != nil && clockIn.GetString("project") != "" {
+		projectIDs[clockIn.GetString("project")] = struct{}{}
+	}
+
+	if original := record.Original(); original != nil && original.GetString("project") != "" {
+		projectIDs[original.GetString("project")] = struct{}{}
+	}
+
+	for projectID := range projectIDs {
+		if err := checkProjectBudget(app, projectID); err != nil {
+			app.Logger().Error("failed to check project budget", "project", projectID, "error", err)
+		}
+	}
+}
+
+// isImportRunChange checks whether a work clock record is changed by a running import or by the
+// rollback of an import. A run is recorded when its import has finished, so the records of a
+// running import reference a run that doesn't exist yet. The hooks of a rollback run after its
+// transaction, which marks the run as rolled back.
+//
+// Parameters:
+// - app: The App interface (typically a PocketBase instance or transaction)
+// - record: The created, updated or deleted work clock record
+//
+// Returns:
+// - Whether the change is handled once for the whole import run instead, e.g. by checking the budgets
+func isImportRunChange(app core.App, record *core.Record) bool {
+	runID := record.GetString("import_run")
+	if runID == "" {
+		return false
+	}
+
+	run, err := app.FindRecordById("import_runs", runID)
+	return err != nil || !run.GetDateTime("rolled_back").IsZero()
+}
+
+// checkAllProjectBudgets checks the budgets of all projects with a budget or a raised alert.
+//
+// Parameters:
+// - app: The App interface (typically a PocketBase instance or transaction)
+//
+// Errors are logged instead of returned, since the record changes themselves already succeeded.
+func checkAllProjectBudgets(app core.App) {
+	projects, err := app.FindRecordsByFilter("projects", "budget_hours > 0 || budget_alert_level > 0", "", 0, 0)
+	if err != nil {
+		app.Logger().Error("failed to find projects", "error", err)
+		return
+	}
+
+	for _, project := range projects {
+		if err := checkProjectBudget(app, project.Id); err != nil {
+			app.Logger().Error("failed to check project budget", "project", project.Id, "error", err)
+		}
+	}
+}
+
+// checkProjectBudget recalculates the budget consumption of a project and emits an alert
+// if a budget threshold is reached for the first time.
+//
+// Parameters:
+// - app: The App interface (typically a PocketBase instance or transaction)
+// - projectID: The ID of the project to check
+//
+// Returns:
+// - An error if the project or its sessions could not be retrieved or the project could not be saved
+//
+// The highest alerted threshold is stored in the project's budget_alert_level field. If the
+// consumption drops below an alerted threshold (e.g. after a correction or a budget increase),
+// the level is lowered again, so the threshold is alerted again once it is reached.
+func checkProjectBudget(app core.App, projectID string) error {
+	project, err := app.FindRecordById("projects", projectID)
+	if err != nil {
+		return fmt.Errorf("failed to find project with id '%s': %w", projectID, err)
+	}
+
+	status, err := getProjectBudgetStatus(app, project, clockNow(app))
+	if err != nil {
+		return err
+	}
+
+	alertLevel := project.GetInt("budget_alert_level")
+	if status.ThresholdLevel == alertLevel {
+		return nil
+	}
+
+	if status.ThresholdLevel > alertLevel {
+		app.Logger().Warn("project budget threshold reached",
+			"project", status.Name,
+			"threshold", status.ThresholdLevel,
+			"consumedHours", status.ConsumedHours,
+			"budgetHours", status.BudgetHours)
+		sendWebhookEvent(app, "project.budget_threshold_reached", status)
+	}
+
+	project.Set("budget_alert_level", status.ThresholdLevel)
+	if err := app.Save(project); err != nil {
+		return fmt.Errorf("failed to save budget alert level of project '%s': %w", projectID, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,74 @@
+package backend
+
+import (
+	"testing"
+	"time"
+
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/yerTools/simple-frontend-stack/src/backend/backendtest"
+)
+
+func TestProjectBudgetOfImports(t *testing.T) {
+	app := backendtest.NewApp(t)
+	RegisterProjectsAPI(app)
+
+	projects, err := app.FindCollectionByNameOrId("projects")
+	if err != nil {
+		t.Fatalf("failed to find projects collection: %v", err)
+	}
+	project := core.NewRecord(projects)
+	project.Set("name", "Migration")
+	project.Set("budget_hours", 10)
+	if err := app.Save(project); err != nil {
+		t.Fatalf("failed to save project: %v", err)
+	}
+	alertLevel := func() int {
+		record, err := app.FindRecordById("projects", project.Id)
+		if err != nil {
+			t.Fatalf("failed to find project: %v", err)
+		}
+		return record.GetInt("budget_alert_level")
+	}
+
+	events := []CalendarEvent{
+		{UID: "a", Summary: "Workshop", Start: backendtest.MustParseTime("2025-04-01T08:00:00Z"), End: backendtest.MustParseTime("2025-04-01T16:00:00Z")},
+		{UID: "b", Summary: "Workshop", Start: backendtest.MustParseTime("2025-04-02T08:00:00Z"), End: backendtest.MustParseTime("2025-04-02T12:00:00Z")},
+	}
+	run := &importRun{ID: core.GenerateDefaultRandomId(), Source: "calendar", Started: time.Now()}
+	if err := importCalendarEvents(t.Context(), app, "", run.ID, events, project.Id); err != nil {
+		t.Fatalf("failed to import calendar events: %v", err)
+	}
+
+	// The records of a running import are not checked one by one
+	if level := alertLevel(); level != 0 {
+		t.Fatalf("expected the budget to be checked after the import, got alert level %d", level)
+	}
+
+	run.Records = 2 * len(events)
+	run.finish(app, nil)
+	if level := alertLevel(); level != 100 {
+		t.Fatalf("expected the budget to be exceeded after the import, got alert level %d", level)
+	}
+
+	if _, err := rollbackImportRun(t.Context(), app, run.ID); err != nil {
+		t.Fatalf("failed to roll back import: %v", err)
+	}
+	if level := alertLevel(); level != 0 {
+		t.Fatalf("expected the alert to be reset by the rollback, got alert level %d", level)
+	}
+
+	// Regular records are checked one by one
+	if err := workClockServiceOf(app).AddClockInOutPair("", backendtest.MustParseTime("2025-04-03T08:00:00Z"), backendtest.MustParseTime("2025-04-03T17:00:00Z")); err != nil {
+		t.Fatalf("failed to add session: %v", err)
+	}
+	sessions, err := findWorkSessions(app, "", time.Time{}, time.Time{})
+	if err != nil || len(sessions) != 1 {
+		t.Fatalf("expected a single session, got %d: %v", len(sessions), err)
+	}
+	if err := setSessionProject(t.Context(), app, sessions[0].ClockIn.Id, project.Id); err != nil {
+		t.Fatalf("failed to set project of session: %v", err)
+	}
+	if level := alertLevel(); level != 80 {
+		t.Errorf("expected 80%% of the budget to be consumed, got alert level %d", level)
+	}
+}
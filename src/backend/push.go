@@ -0,0 +1,230 @@
+// Push Notifications Module for PocketBase
+//
+// This module sends push notifications through a self-hosted ntfy (https://ntfy.sh) or Gotify
+// (https://gotify.net) server, so reminders and compliance alerts reach the phone without setting
+// up SMTP. It is configured and enabled through the integrations API:
+//
+//	{"service": "ntfy", "url": "https://ntfy.sh/work_clock", "token": ""}
+//	{"service": "gotify", "url": "https://gotify.example.com", "token": "AbCdEf123"}
+//
+// For ntfy, the URL is the URL of the topic and the token an optional access token. For Gotify,
+// the URL is the URL of the server and the token the token of the application.
+//
+// The following notifications are sent:
+// - A reminder once an open session got stale (see Settings.WorkdayDuration), checked every 15 minutes
+// - A compliance alert with high priority when a closed session leaves its day with compliance flags
+//
+// Notifications are sent asynchronously like webhook events, failed deliveries are logged.
+package backend
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pocketbase/pocketbase/core"
+)
+
+// PushConfig is the configuration of the push notification integration.
+type PushConfig struct {
+	Service string `json:"service"` // The push service, 'ntfy' or 'gotify'
+	URL     string `json:"url"`     // ntfy: URL of the topic, Gotify: URL of the server
+	Token   string `json:"token"`   // ntfy: optional access token, Gotify: token of the application
+}
+
+// validate checks that the service is supported and the URL and token are valid.
+func (c PushConfig) validate() error {
+	if c.Service != "ntfy" && c.Service != "gotify" {
+		return fmt.Errorf("invalid 'service' (string) configuration. Expected 'ntfy' or 'gotify'")
+	}
+	if err := validateIntegrationURL(c.URL, "url", "https", "http"); err != nil {
+		return err
+	}
+	if c.Service == "gotify" && c.Token == "" {
+		return fmt.Errorf("missing 'token' (string) configuration")
+	}
+	return nil
+}
+
+// pushNotification is a notification sent through the push integration.
+type pushNotification struct {
+	Title    string // Title of the notification
+	Message  string // Plain text message of the notification
+	Priority bool   // Whether the notification is sent with high priority
+}
+
+// remindedSessions contains the IDs of the clock in records of the stale sessions a reminder was sent for.
+// It is guarded by remindedSessionsMutex.
+var remindedSessions = map[string]bool{}
+var remindedSessionsMutex = sync.Mutex{}
+
+// RegisterPushHooks subscribes the push integration to the closed sessions and schedules the
+// stale session reminders.
+//
+// Parameters:
+// - app: The PocketBase application instance
+func RegisterPushHooks(app core.App) {
+	OnSessionClosed().BindFunc(func(e *SessionClosedEvent) error {
+		if _, ok := integrationConfig[PushConfig](e.App, "push"); ok {
+			sendComplianceAlert(e.App, e.Session)
+		}
+		return e.Next()
+	})
+
+	app.Cron().MustAdd("push_reminders", "*/15 * * * *", backgroundJobs.cronJob("push_reminders", func() {
+		if _, ok := integrationConfig[PushConfig](app, "push"); ok {
+			sendStaleSessionReminders(app, clockNow(app))
+		}
+	}))
+}
+
+// sendComplianceAlert sends an alert if the day a closed session started on has compliance flags.
+//
+// Parameters:
+// - app: The App interface the session was closed in
+// - session: The closed session
+func sendComplianceAlert(app core.App, session WorkSessionEntry) {
+	dayStart := startOfLocalDay(session.Start)
+	date := dayStart.Format(time.DateOnly)
+
+	journal, err := getJournal(app, session.ClockID, date, dayStart, dayStart.AddDate(0, 0, 1), clockNow(app))
+	if err != nil {
+		app.Logger().Error("failed to check compliance of closed session", "session", session.ClockInID, "error", err)
+		return
+	}
+	if len(journal.Compliance) == 0 {
+		return
+	}
+
+	sendPushNotification(app, pushNotification{
+		Title:    fmt.Sprintf("Compliance alert for %s", date),
+		Message:  fmt.Sprintf("Worked %s with %s of breaks: %s", journal.Worked, journal.Breaks, strings.Join(journal.Compliance, ", ")),
+		Priority: true,
+	})
+}
+
+// sendStaleSessionReminders sends a reminder for every stale open session, once per session.
+//
+// Parameters:
+// - app: The App interface (typically a PocketBase instance or transaction)
+// - now: The reference time the sessions are checked at
+func sendStaleSessionReminders(app core.App, now time.Time) {
+	clocks, err := app.FindAllRecords("clocks")
+	if err != nil {
+		app.Logger().Error("failed to find clocks for reminders", "error", err)
+		return
+	}
+
+	clockNames := map[string]string{"": "default"}
+	for _, clock := range clocks {
+		clockNames[clock.Id] = clock.GetString("name")
+	}
+
+	remindedSessionsMutex.Lock()
+	defer remindedSessionsMutex.Unlock()
+
+	for clockID, name := range clockNames {
+		status, err := getWorkClockStatus(app, clockID, now)
+		if err != nil {
+			app.Logger().Error("failed to get work clock status for reminders", "clock", clockID, "error", err)
+			continue
+		}
+		if !status.Stale || remindedSessions[status.ClockInID] {
+			continue
+		}
+
+		remindedSessions[status.ClockInID] = true
+		recordEvent(app, "stale_session_reminder", "warning", fmt.Sprintf("The %s clock has been running since %s", name, status.Since.Format(time.RFC3339)),
+			map[string]any{"clock_id": clockID, "clock_in_id": status.ClockInID})
+		sendPushNotification(app, pushNotification{
+			Title:   "Still clocked in?",
+			Message: fmt.Sprintf("The %s clock has been running for %s since %s", name, status.Duration, status.Since.In(time.Local).Format("2006-01-02 15:04")),
+		})
+	}
+}
+
+// sendPushNotification sends a notification through the configured push service in the background.
+//
+// Parameters:
+// - app: The App interface used for logging and loading the configuration
+// - notification: The notification to send
+func sendPushNotification(app core.App, notification pushNotification) {
+	config, ok := integrationConfig[PushConfig](app, "push")
+	if !ok {
+		return
+	}
+
+	backgroundJobs.goJob("push", func() {
+		if err := deliverPushNotification(config, notification); err != nil {
+			recordIntegrationFailure(app, "push", err)
+		}
+	})
+}
+
+// deliverPushNotification sends a notification to the push service.
+// ntfy receives the message as plain text with the title and priority as headers, Gotify
+// receives it as JSON message of the application.
+//
+// Parameters:
+// - config: The configuration of the push service
+// - notification: The notification to send
+//
+// Returns:
+// - An error if the request fails or the service doesn't respond with a 2xx status
+func deliverPushNotification(config PushConfig, notification pushNotification) error {
+	ctx, cancel := context.WithTimeout(context.Background(), webhookTimeout)
+	defer cancel()
+
+	var request *http.Request
+	var err error
+	switch config.Service {
+	case "ntfy":
+		request, err = http.NewRequestWithContext(ctx, http.MethodPost, config.URL, strings.NewReader(notification.Message))
+		if err != nil {
+			return fmt.Errorf("failed to create request: %w", err)
+		}
+		request.Header.Set("Title", notification.Title)
+		if notification.Priority {
+			request.Header.Set("Priority", "high")
+		}
+		if config.Token != "" {
+			request.Header.Set("Authorization", "Bearer "+config.Token)
+		}
+	case "gotify":
+		priority := 5
+		if notification.Priority {
+			priority = 8
+		}
+
+		body, err := json.Marshal(map[string]any{"title": notification.Title, "message": notification.Message, "priority": priority})
+		if err != nil {
+			return fmt.Errorf("failed to encode message: %w", err)
+		}
+
+		request, err = http.NewRequestWithContext(ctx, http.MethodPost, strings.TrimSuffix(config.URL, "/")+"/message", bytes.NewReader(body))
+		if err != nil {
+			return fmt.Errorf("failed to create request: %w", err)
+		}
+		request.Header.Set("Content-Type", "application/json")
+		request.Header.Set("X-Gotify-Key", config.Token)
+	default:
+		return fmt.Errorf("unknown push service '%s'", config.Service)
+	}
+
+	response, err := outboundClient().Do(request)
+	if err != nil {
+		return fmt.Errorf("failed to send request: %w", err)
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode < 200 || response.StatusCode >= 300 {
+		return fmt.Errorf("push service responded with status %d", response.StatusCode)
+	}
+
+	return nil
+}
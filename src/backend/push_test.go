@@ -0,0 +1,45 @@
+package backend
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestDeliverPushNotification(t *testing.T) {
+	var request *http.Request
+	var body []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		request = r
+		body, _ = io.ReadAll(r.Body)
+	}))
+	defer server.Close()
+
+	notification := pushNotification{Title: "Compliance alert", Message: "missing_break", Priority: true}
+
+	if err := deliverPushNotification(PushConfig{Service: "ntfy", URL: server.URL + "/work_clock", Token: "secret"}, notification); err != nil {
+		t.Fatalf("failed to deliver ntfy notification: %v", err)
+	}
+	if request.URL.Path != "/work_clock" || request.Header.Get("Title") != "Compliance alert" || request.Header.Get("Priority") != "high" ||
+		request.Header.Get("Authorization") != "Bearer secret" || string(body) != "missing_break" {
+		t.Errorf("unexpected ntfy request %s %v: %s", request.URL.Path, request.Header, body)
+	}
+
+	if err := deliverPushNotification(PushConfig{Service: "gotify", URL: server.URL + "/", Token: "secret"}, notification); err != nil {
+		t.Fatalf("failed to deliver gotify notification: %v", err)
+	}
+	var message struct {
+		Title    string `json:"title"`
+		Message  string `json:"message"`
+		Priority int    `json:"priority"`
+	}
+	if err := json.Unmarshal(body, &message); err != nil {
+		t.Fatalf("failed to decode gotify message: %v", err)
+	}
+	if request.URL.Path != "/message" || request.Header.Get("X-Gotify-Key") != "secret" ||
+		message.Title != "Compliance alert" || message.Message != "missing_break" || message.Priority != 8 {
+		t.Errorf("unexpected gotify request %s %v: %s", request.URL.Path, request.Header, body)
+	}
+}
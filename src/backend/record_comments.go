@@ -0,0 +1,138 @@
+// Record Comments Module for PocketBase
+//
+// This module provides comment threads on work clock records. When a record is flagged, e.g.
+// because a session is implausibly long, the user and the admins can exchange comments attached
+// to that record, so a correction (or the decision to keep the record) has a documented
+// justification. Comments written by superusers are marked as admin comments.
+//
+// Comments can't be edited or deleted through the API, they are only deleted together with their record.
+package backend
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/core"
+)
+
+// RecordComment is a comment of a record's thread as returned by the comment endpoints.
+type RecordComment struct {
+	ID      string    `json:"id"`      // ID of the comment
+	Author  string    `json:"author"`  // 'user' or 'admin'
+	Message string    `json:"message"` // Text of the comment
+	Created time.Time `json:"created"` // Time the comment was written
+}
+
+// RegisterRecordCommentsAPI registers the comment endpoints with the PocketBase server.
+// It creates the following routes:
+// - GET /api/work_clock/comments?record_id= - Lists the comments of a work clock record, oldest first
+// - POST /api/work_clock/comments - Adds the comment 'message' to the work clock record 'record_id'
+//
+// Parameters:
+// - app: The PocketBase application instance
+func RegisterRecordCommentsAPI(app core.App) {
+	app.OnServe().BindFunc(func(se *core.ServeEvent) error {
+		se.Router.GET("/api/work_clock/comments", func(e *core.RequestEvent) error {
+			recordID := e.Request.URL.Query().Get("record_id")
+			if recordID == "" {
+				return e.Error(http.StatusBadRequest, "Missing 'record_id' (string) parameter", nil)
+			}
+
+			comments, err := findRecordComments(app, recordID)
+			if err != nil {
+				return e.Error(http.StatusInternalServerError, fmt.Sprintf("Failed to find comments: %v", err), err)
+			}
+
+			return e.JSON(http.StatusOK, comments)
+		})
+
+		se.Router.POST("/api/work_clock/comments", func(e *core.RequestEvent) error {
+			recordID := e.Request.FormValue("record_id")
+			if recordID == "" {
+				return e.Error(http.StatusBadRequest, "Missing 'record_id' (string) parameter", nil)
+			}
+
+			message := e.Request.FormValue("message")
+			if message == "" {
+				return e.Error(http.StatusBadRequest, "Missing 'message' (string) parameter", nil)
+			}
+
+			author := "user"
+			if e.HasSuperuserAuth() {
+				author = "admin"
+			}
+
+			if err := addRecordComment(app, recordID, author, message); err != nil {
+				return e.Error(http.StatusInternalServerError, fmt.Sprintf("Failed to add comment: %v", err), err)
+			}
+			return callSucceeded(e)
+		})
+
+		return se.Next()
+	})
+}
+
+// findRecordComments finds the comments of a work clock record.
+//
+// Parameters:
+// - app: The App interface (typically a PocketBase instance or transaction)
+// - recordID: The ID of the work clock record
+//
+// Returns:
+// - The comments, oldest first
+// - An error if the record does not exist or the database query fails
+func findRecordComments(app core.App, recordID string) ([]RecordComment, error) {
+	if _, err := app.FindRecordById("work_clock", recordID); err != nil {
+		return nil, fmt.Errorf("failed to find work clock record with id '%s': %w", recordID, err)
+	}
+
+	records, err := app.FindRecordsByFilter("record_comments", "record = {:record}", "+created", 0, 0, dbx.Params{"record": recordID})
+	if err != nil {
+		return nil, fmt.Errorf("failed to find comments of record '%s': %w", recordID, err)
+	}
+
+	comments := make([]RecordComment, 0, len(records))
+	for _, record := range records {
+		comments = append(comments, RecordComment{
+			ID:      record.Id,
+			Author:  record.GetString("author"),
+			Message: record.GetString("message"),
+			Created: record.GetDateTime("created").Time(),
+		})
+	}
+
+	return comments, nil
+}
+
+// addRecordComment adds a comment to the thread of a work clock record.
+//
+// Parameters:
+// - app: The App interface (typically a PocketBase instance or transaction)
+// - recordID: The ID of the work clock record
+// - author: 'user' or 'admin'
+// - message: The text of the comment
+//
+// Returns:
+// - An error if the record does not exist or saving fails
+func addRecordComment(app core.App, recordID string, author string, message string) error {
+	if _, err := app.FindRecordById("work_clock", recordID); err != nil {
+		return fmt.Errorf("failed to find work clock record with id '%s': %w", recordID, err)
+	}
+
+	collection, err := app.FindCollectionByNameOrId("record_comments")
+	if err != nil {
+		return fmt.Errorf("failed to find record_comments collection: %w", err)
+	}
+
+	comment := core.NewRecord(collection)
+	comment.Set("record", recordID)
+	comment.Set("author", author)
+	comment.Set("message", message)
+	if err := app.Save(comment); err != nil {
+		return fmt.Errorf("failed to save comment: %w", err)
+	}
+
+	return nil
+}
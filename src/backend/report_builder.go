@@ -0,0 +1,483 @@
+// Report Builder Module for PocketBase
+//
+// This module evaluates declarative report specs on the server, so new dashboard widgets can
+// describe the report they need instead of each requiring a bespoke endpoint. A spec selects a
+// range, the dimensions the sessions are grouped by, the metrics calculated per group, and
+// filters the sessions have to match. The range is either given by 'from' and 'to', or as a
+// 'period' like those of the report comparison (e.g. 'last_month'), which is resolved whenever
+// the spec is evaluated, so saved specs stay current.
+//
+// Dimensions:
+// - 'day', 'week', 'month': The local day, week (see Settings.WeekStart) or month the session starts in
+// - 'iso_week': The ISO 8601 week the session starts in (e.g. '2025-W14'), always starting on Monday
+// - 'year': The fiscal year the session starts in (see Settings.FiscalYearStart), named after the year it starts in
+// - 'project': The project of the session, empty for sessions without project
+// - 'tag': The tags of the session, a session with several tags counts towards each of them
+// - 'category': The category of the session, empty for regular work
+//
+// Metrics (durations in seconds):
+// - 'worked': The time that counts as work time (see categoryFactor)
+// - 'sessions': The number of sessions
+// - 'breaks': The time between the sessions of the days
+// - 'overtime': The worked minus the target time of the days (see the work schedules module), without
+// overtime during rule periods without overtime accrual (see the compliance rules module)
+//
+// Breaks and overtime are properties of days, so they can only be grouped by 'day', 'week',
+// 'iso_week', 'month' or 'year'. They are calculated from the sessions matching the filters, and
+// days up to today without any matching session count with their full target as undertime. Days
+// outside of the employment (see the employment periods module) are left out.
+package backend
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/pocketbase/pocketbase/core"
+)
+
+// reportDimensions are the dimensions sessions can be grouped by, with the functions returning
+// the group values of a session by its start and clock in record.
+var reportDimensions = map[string]func(start time.Time, clockIn *core.Record) []string{
+	"day": func(start time.Time, clockIn *core.Record) []string {
+		return []string{startOfLocalDay(start).Format(time.DateOnly)}
+	},
+	"week": func(start time.Time, clockIn *core.Record) []string {
+		return []string{startOfLocalWeek(start).Format(time.DateOnly)}
+	},
+	"iso_week": func(start time.Time, clockIn *core.Record) []string {
+		year, week := start.In(time.Local).ISOWeek()
+		return []string{fmt.Sprintf("%d-W%02d", year, week)}
+	},
+	"month": func(start time.Time, clockIn *core.Record) []string {
+		return []string{start.In(time.Local).Format("2006-01")}
+	},
+	"year": func(start time.Time, clockIn *core.Record) []string {
+		return []string{startOfLocalYear(start).Format("2006")}
+	},
+	"project": func(start time.Time, clockIn *core.Record) []string {
+		return []string{clockIn.GetString("project")}
+	},
+	"tag": func(start time.Time, clockIn *core.Record) []string {
+		if tagIDs := clockIn.GetStringSlice("tags"); len(tagIDs) > 0 {
+			return tagIDs
+		}
+		return []string{""}
+	},
+	"category": func(start time.Time, clockIn *core.Record) []string {
+		return []string{clockIn.GetString("category")}
+	},
+}
+
+// reportMetrics are the metrics calculated per group, with whether they are properties of days.
+var reportMetrics = map[string]bool{
+	"worked":   false,
+	"sessions": false,
+	"breaks":   true,
+	"overtime": true,
+}
+
+// reportSpec is a declarative report, as accepted by the report builder endpoint.
+type reportSpec struct {
+	From    string           `json:"from"`     // Start of the range (RFC3339), unless 'period' is given
+	To      string           `json:"to"`       // End of the range (RFC3339, exclusive), unless 'period' is given
+	Period  string           `json:"period"`   // Period instead of the range, see parsePeriodParam
+	Clock   string           `json:"clock"`    // Optional name of the clock, the default clock is used without it
+	GroupBy []string         `json:"group_by"` // Dimensions the sessions are grouped by, no dimension reports the totals only
+	Metrics []string         `json:"metrics"`  // Metrics calculated per group, 'worked' without metrics
+	Filter  reportSpecFilter `json:"filter"`   // Filters the sessions have to match
+}
+
+// reportSpecFilter contains the filters of a report spec.
+type reportSpecFilter struct {
+	ProjectID string   `json:"project_id"` // Only sessions of this project, empty for sessions of any project
+	TagIDs    []string `json:"tag_ids"`    // Only sessions with at least one of these tags, empty for sessions with any tags
+	Category  *string  `json:"category"`   // Only sessions of this category, empty for regular work, null for any category
+}
+
+// CustomReportRow contains the metrics of a group.
+type CustomReportRow struct {
+	Group     map[string]string `json:"group"`     // Value of each dimension of the group
+	Metrics   map[string]int64  `json:"metrics"`   // Value of each requested metric
+	Formatted map[string]string `json:"formatted"` // Duration metrics formatted in the configured duration format
+}
+
+// CustomReport is the response of the report builder endpoint.
+type CustomReport struct {
+	From    time.Time         `json:"from"`     // Start of the reported range
+	To      time.Time         `json:"to"`       // End of the reported range
+	GroupBy []string          `json:"group_by"` // Dimensions the sessions are grouped by
+	Metrics []string          `json:"metrics"`  // Calculated metrics
+	Rows    []CustomReportRow `json:"rows"`     // Metrics per group, sorted by the group values
+	Totals  CustomReportRow   `json:"totals"`   // Metrics of all matching sessions, each session counted once
+}
+
+// customReportDay collects the matching sessions of a day for the day metrics.
+type customReportDay struct {
+	Worked   time.Duration // Time that counts as work time
+	Presence time.Duration // Total duration of the sessions
+	First    time.Time     // Start of the first session
+	Last     time.Time     // End of the last session
+}
+
+// RegisterReportBuilderAPI registers the report builder endpoint with the PocketBase server.
+// It creates the following route:
+// - POST /api/work_clock/report/custom - Evaluates a report spec, supports conditional requests
+//
+// The endpoint expects a JSON body like:
+//
+//	{
+//	  "from": "2025-04-01T00:00:00+02:00",
+//	  "to": "2025-05-01T00:00:00+02:00",
+//	  "group_by": ["week", "project"],
+//	  "metrics": ["worked", "sessions"],
+//	  "filter": {"tag_ids": ["k2m4n6p8r0t2v4x"]}
+//	}
+//
+// Parameters:
+// - app: The PocketBase application instance
+func RegisterReportBuilderAPI(app core.App) {
+	app.OnServe().BindFunc(func(se *core.ServeEvent) error {
+		se.Router.POST("/api/work_clock/report/custom", func(e *core.RequestEvent) error {
+			var spec reportSpec
+			if err := e.BindBody(&spec); err != nil {
+				return e.Error(http.StatusBadRequest, "Invalid request body", err)
+			}
+
+			from, to, clockID, err := validateReportSpec(app, &spec)
+			if err != nil {
+				return e.Error(http.StatusBadRequest, err.Error(), nil)
+			}
+
+			report, err := evaluateCachedReportSpec(app, spec, clockID, from, to)
+			if err != nil {
+				return e.Error(http.StatusInternalServerError, fmt.Sprintf("Failed to create custom report: %v", err), err)
+			}
+
+			return respondConditionalJSON(e, report, workClockLastModified(app))
+		})
+
+		return se.Next()
+	})
+}
+
+// evaluateCachedReportSpec evaluates a validated report spec, reusing cached results.
+//
+// Parameters:
+// - app: The App interface (typically a PocketBase instance or transaction)
+// - spec: The validated report spec
+// - clockID: The ID of the clock, an empty string for the default clock
+// - from: The start of the range (inclusive)
+// - to: The end of the range (exclusive)
+//
+// Returns:
+// - The report
+// - An error if the report could not be created
+func evaluateCachedReportSpec(app core.App, spec reportSpec, clockID string, from, to time.Time) (*CustomReport, error) {
+	key, err := json.Marshal(spec)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode report spec: %w", err)
+	}
+
+	// Periods are resolved to different ranges over time, so the range is part of the key
+	cacheKey := fmt.Sprintf("custom|%s|%s|%s", from.Format(time.RFC3339Nano), to.Format(time.RFC3339Nano), key)
+	return cachedReport(app, cacheKey, func(now time.Time) (*CustomReport, error) {
+		return evaluateReportSpec(app, spec, clockID, from, to, now)
+	})
+}
+
+// validateReportSpec validates a report spec and fills in its defaults.
+//
+// Parameters:
+// - app: The App interface (typically a PocketBase instance or transaction)
+// - spec: The report spec, its metrics default to 'worked'
+//
+// Returns:
+// - The start of the range
+// - The end of the range
+// - The ID of the clock, an empty string for the default clock
+// - An error describing the invalid part of the spec
+func validateReportSpec(app core.App, spec *reportSpec) (time.Time, time.Time, string, error) {
+	var from, to time.Time
+	var err error
+	if spec.Period != "" {
+		from, to, err = parsePeriodParam(spec.Period, "period", clockNow(app))
+	} else {
+		from, to, err = parseTimeRangeParams(spec.From, spec.To)
+	}
+	if err != nil {
+		return time.Time{}, time.Time{}, "", err
+	}
+
+	clockID, err := findClockID(app, spec.Clock)
+	if err != nil {
+		return time.Time{}, time.Time{}, "", err
+	}
+
+	timeOnly := true
+	for i, dimension := range spec.GroupBy {
+		if _, ok := reportDimensions[dimension]; !ok {
+			return time.Time{}, time.Time{}, "", fmt.Errorf("invalid 'group_by' value '%s'. Expected one of: '%s'", dimension, strings.Join(slices.Sorted(maps.Keys(reportDimensions)), "', '"))
+		}
+		if slices.Contains(spec.GroupBy[:i], dimension) {
+			return time.Time{}, time.Time{}, "", fmt.Errorf("'group_by' contains '%s' multiple times", dimension)
+		}
+		if dimension != "day" && dimension != "week" && dimension != "iso_week" && dimension != "month" && dimension != "year" {
+			timeOnly = false
+		}
+	}
+
+	if len(spec.Metrics) == 0 {
+		spec.Metrics = []string{"worked"}
+	}
+	for i, metric := range spec.Metrics {
+		dayMetric, ok := reportMetrics[metric]
+		if !ok {
+			return time.Time{}, time.Time{}, "", fmt.Errorf("invalid 'metrics' value '%s'. Expected one of: '%s'", metric, strings.Join(slices.Sorted(maps.Keys(reportMetrics)), "', '"))
+		}
+		if slices.Contains(spec.Metrics[:i], metric) {
+			return time.Time{}, time.Time{}, "", fmt.Errorf("'metrics' contains '%s' multiple times", metric)
+		}
+		if dayMetric && !timeOnly {
+			return time.Time{}, time.Time{}, "", fmt.Errorf("'%s' can only be grouped by the time dimensions ('day', 'week', 'iso_week', 'month', 'year')", metric)
+		}
+	}
+
+	if err := validateExportFilter(app, exportFilter{ProjectID: spec.Filter.ProjectID, TagIDs: spec.Filter.TagIDs}); err != nil {
+		return time.Time{}, time.Time{}, "", err
+	}
+	if category := spec.Filter.Category; category != nil && *category != "" {
+		if _, ok := sessionCategories[*category]; !ok {
+			return time.Time{}, time.Time{}, "", fmt.Errorf("invalid 'category' (string) parameter. Expected one of: '%s'", strings.Join(slices.Sorted(maps.Keys(sessionCategories)), "', '"))
+		}
+	}
+
+	return from, to, clockID, nil
+}
+
+// evaluateReportSpec evaluates a validated report spec.
+//
+// Parameters:
+// - app: The App interface (typically a PocketBase instance or transaction)
+// - spec: The validated report spec
+// - clockID: The ID of the clock, an empty string for the default clock
+// - from: The start of the range (inclusive)
+// - to: The end of the range (exclusive)
+// - now: The reference time used as end of an open session
+//
+// Returns:
+// - The report
+// - An error if the sessions, work schedules, employment or rule periods could not be retrieved
+func evaluateReportSpec(app core.App, spec reportSpec, clockID string, from, to, now time.Time) (*CustomReport, error) {
+	sessions, err := findWorkSessions(app, clockID, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	filter := exportFilter{ClockID: clockID, ProjectID: spec.Filter.ProjectID, TagIDs: spec.Filter.TagIDs}
+	rows := map[string]*CustomReportRow{}
+	days := map[string]*customReportDay{}
+	report := &CustomReport{From: from, To: to, GroupBy: spec.GroupBy, Metrics: spec.Metrics, Rows: []CustomReportRow{}}
+	report.Totals = newCustomReportRow(nil)
+
+	row := func(group map[string]string) *CustomReportRow {
+		key := customReportRowKey(spec.GroupBy, group)
+		entry, ok := rows[key]
+		if !ok {
+			created := newCustomReportRow(group)
+			entry = &created
+			rows[key] = entry
+		}
+		return entry
+	}
+
+	for _, session := range sessions {
+		if !filter.matches(session) {
+			continue
+		}
+		if category := spec.Filter.Category; category != nil && session.ClockIn.GetString("category") != *category {
+			continue
+		}
+
+		duration := session.Duration(now)
+		worked := time.Duration(float64(duration) * categoryFactor(session.ClockIn.GetString("category")))
+
+		for _, group := range customReportGroups(spec.GroupBy, session.Start(), session.ClockIn) {
+			entry := row(group)
+			entry.Metrics["worked"] += int64(worked.Seconds())
+			entry.Metrics["sessions"]++
+		}
+		report.Totals.Metrics["worked"] += int64(worked.Seconds())
+		report.Totals.Metrics["sessions"]++
+
+		date := startOfLocalDay(session.Start()).Format(time.DateOnly)
+		day, ok := days[date]
+		if !ok {
+			day = &customReportDay{First: session.Start()}
+			days[date] = day
+		}
+		day.Worked += worked
+		day.Presence += duration
+		if end := session.End(now); end.After(day.Last) {
+			day.Last = end
+		}
+	}
+
+	if slices.ContainsFunc(spec.Metrics, func(metric string) bool { return reportMetrics[metric] }) {
+		schedules, err := findWorkSchedules(app, clockID)
+		if err != nil {
+			return nil, err
+		}
+		employment, err := findEmploymentPeriods(app, clockID)
+		if err != nil {
+			return nil, err
+		}
+		rules, err := findRulePeriods(app, clockID)
+		if err != nil {
+			return nil, err
+		}
+		off, err := findDaysOff(app, clockID, startOfLocalDay(from), startOfLocalDay(to).AddDate(0, 0, 1))
+		if err != nil {
+			return nil, err
+		}
+
+		today := startOfLocalDay(now)
+		for date := startOfLocalDay(from); date.Before(to) && !date.After(today); date = date.AddDate(0, 0, 1) {
+			if !employment.includes(date) {
+				continue
+			}
+
+			var breaks time.Duration
+			overtime := -off.target(schedules, date)
+			if day, ok := days[date.Format(time.DateOnly)]; ok {
+				breaks = max(day.Last.Sub(day.First)-day.Presence, 0)
+				overtime += day.Worked
+			}
+			overtime = rules.on(date).overtime(overtime)
+
+			// The dimensions are days, weeks or months, which only depend on the start
+			group := customReportGroups(spec.GroupBy, date, nil)[0]
+			entry := row(group)
+			entry.Metrics["breaks"] += int64(breaks.Seconds())
+			entry.Metrics["overtime"] += int64(overtime.Seconds())
+			report.Totals.Metrics["breaks"] += int64(breaks.Seconds())
+			report.Totals.Metrics["overtime"] += int64(overtime.Seconds())
+		}
+	}
+
+	for _, key := range slices.Sorted(maps.Keys(rows)) {
+		report.Rows = append(report.Rows, finishCustomReportRow(*rows[key], spec.Metrics))
+	}
+	report.Totals = finishCustomReportRow(report.Totals, spec.Metrics)
+
+	return report, nil
+}
+
+// newCustomReportRow creates an empty row of a group.
+//
+// Parameters:
+// - group: The values of the dimensions of the group, nil for the totals
+//
+// Returns:
+// - The row with all metrics at zero
+func newCustomReportRow(group map[string]string) CustomReportRow {
+	if group == nil {
+		group = map[string]string{}
+	}
+	return CustomReportRow{Group: group, Metrics: map[string]int64{}, Formatted: map[string]string{}}
+}
+
+// finishCustomReportRow removes the metrics that weren't requested from a row and formats the durations.
+//
+// Parameters:
+// - row: The row with all calculated metrics
+// - metrics: The requested metrics
+//
+// Returns:
+// - The row with the requested metrics only
+func finishCustomReportRow(row CustomReportRow, metrics []string) CustomReportRow {
+	finished := newCustomReportRow(row.Group)
+	for _, metric := range metrics {
+		finished.Metrics[metric] = row.Metrics[metric]
+		if metric != "sessions" {
+			finished.Formatted[metric] = formatResponseDuration(row.Metrics[metric])
+		}
+	}
+	return finished
+}
+
+// customReportGroups returns the groups a session counts towards. Every combination of the values
+// of the dimensions is a group, so a session with several tags counts towards several groups.
+//
+// Parameters:
+// - dimensions: The dimensions the sessions are grouped by
+// - start: The start of the session
+// - clockIn: The clock in record starting the session
+//
+// Returns:
+// - The values of the dimensions of each group
+func customReportGroups(dimensions []string, start time.Time, clockIn *core.Record) []map[string]string {
+	groups := []map[string]string{{}}
+	for _, dimension := range dimensions {
+		var combined []map[string]string
+		for _, group := range groups {
+			for _, value := range reportDimensions[dimension](start, clockIn) {
+				next := maps.Clone(group)
+				next[dimension] = value
+				combined = append(combined, next)
+			}
+		}
+		groups = combined
+	}
+	return groups
+}
+
+// customReportRowKey returns the key identifying the row of a group.
+//
+// Parameters:
+// - dimensions: The dimensions the sessions are grouped by
+// - group: The values of the dimensions of the group
+//
+// Returns:
+// - The key, the values in the order of the dimensions
+func customReportRowKey(dimensions []string, group map[string]string) string {
+	values := make([]string, 0, len(dimensions))
+	for _, dimension := range dimensions {
+		values = append(values, group[dimension])
+	}
+	return strings.Join(values, "\x00")
+}
+
+// startOfLocalWeek returns the local midnight starting the week of a point in time. Weeks start
+// on the configured first day of the week.
+//
+// Parameters:
+// - t: The point in time
+//
+// Returns:
+// - The start of the week in local time
+func startOfLocalWeek(t time.Time) time.Time {
+	day := startOfLocalDay(t)
+	return day.AddDate(0, 0, -(int(day.Weekday())-int(settings.WeekStart)+7)%7)
+}
+
+// startOfLocalYear returns the local midnight starting the fiscal year of a point in time. Years
+// start on the first day of the configured first month of the fiscal year.
+//
+// Parameters:
+// - t: The point in time
+//
+// Returns:
+// - The start of the fiscal year in local time
+func startOfLocalYear(t time.Time) time.Time {
+	local := t.In(time.Local)
+	year := local.Year()
+	if local.Month() < settings.FiscalYearStart {
+		year--
+	}
+	return time.Date(year, settings.FiscalYearStart, 1, 0, 0, 0, 0, time.Local)
+}
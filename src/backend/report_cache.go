@@ -0,0 +1,116 @@
+// Report Cache Module for PocketBase
+//
+// This module caches the results of expensive report endpoints, which keeps dashboard loads
+// snappy on Raspberry Pi-class hardware. Cached reports are invalidated by record hooks as
+// soon as any record they are based on changes.
+//
+// Reports that contain an open session change with the current time as well, so they are
+// only cached for reportCacheOpenSessionTTL.
+package backend
+
+import (
+	"sync"
+	"time"
+
+	"github.com/pocketbase/pocketbase/core"
+)
+
+// reportCacheOpenSessionTTL is the maximum age of a cached report while a session is open.
+const reportCacheOpenSessionTTL = 30 * time.Second
+
+// reportCacheMaxEntries is the maximum number of cached reports. If it is exceeded, the cache is cleared.
+const reportCacheMaxEntries = 256
+
+// reportCacheCollections are the collections the cached reports are based on.
+var reportCacheCollections = []string{"work_clock", "projects", "tags", "absences"}
+
+// reportCacheEntry is a cached report result.
+type reportCacheEntry struct {
+	value   any       // The cached report
+	expires time.Time // Expiry of the entry, zero if it is valid until invalidated
+}
+
+// reportCache contains the cached reports by their key and is guarded by reportCacheMutex.
+// reportCacheGeneration is incremented on every invalidation, so reports computed from
+// outdated data are not stored.
+var reportCache = map[string]reportCacheEntry{}
+var reportCacheGeneration uint64
+var reportCacheMutex = sync.Mutex{}
+
+// RegisterReportCacheHooks registers the hooks invalidating the report cache.
+//
+// Parameters:
+// - app: The PocketBase application instance
+func RegisterReportCacheHooks(app core.App) {
+	invalidate := func(e *core.RecordEvent) error {
+		invalidateReportCache()
+		return e.Next()
+	}
+
+	app.OnRecordAfterCreateSuccess(reportCacheCollections...).BindFunc(invalidate)
+	app.OnRecordAfterUpdateSuccess(reportCacheCollections...).BindFunc(invalidate)
+	app.OnRecordAfterDeleteSuccess(reportCacheCollections...).BindFunc(invalidate)
+}
+
+// invalidateReportCache removes all cached reports.
+func invalidateReportCache() {
+	reportCacheMutex.Lock()
+	defer reportCacheMutex.Unlock()
+
+	clear(reportCache)
+	reportCacheGeneration++
+}
+
+// cachedReport returns a cached report or computes and caches it.
+//
+// Parameters:
+// - app: The PocketBase application instance
+// - key: The key identifying the report and its parameters
+// - compute: Computes the report for the given reference time
+//
+// Returns:
+// - The cached or computed report
+// - An error if computing the report fails, errors are not cached
+func cachedReport[T any](app core.App, key string, compute func(now time.Time) (T, error)) (T, error) {
+	now := clockNow(app)
+
+	reportCacheMutex.Lock()
+	entry, ok := reportCache[key]
+	generation := reportCacheGeneration
+	reportCacheMutex.Unlock()
+
+	if ok && (entry.expires.IsZero() || now.Before(entry.expires)) {
+		if value, ok := entry.value.(T); ok {
+			return value, nil
+		}
+	}
+
+	clockedIn, err := hasOpenSession(app)
+	if err != nil {
+		var zero T
+		return zero, err
+	}
+
+	value, err := compute(now)
+	if err != nil {
+		return value, err
+	}
+
+	entry = reportCacheEntry{value: value}
+	if clockedIn {
+		entry.expires = now.Add(reportCacheOpenSessionTTL)
+	}
+
+	reportCacheMutex.Lock()
+	defer reportCacheMutex.Unlock()
+
+	if generation != reportCacheGeneration {
+		return value, nil
+	}
+	if len(reportCache) >= reportCacheMaxEntries {
+		clear(reportCache)
+	}
+	reportCache[key] = entry
+
+	return value, nil
+}
@@ -0,0 +1,214 @@
+// Report Comparison Module for PocketBase
+//
+// This module compares the totals of two periods side by side, e.g. this month with last month,
+// so trend widgets get the totals and their differences with a single request. The totals are
+// read from the daily summaries (see the daily summary module), so comparing years is as cheap
+// as comparing weeks.
+//
+// Periods are given as:
+// - 'this_week', 'last_week': The current or previous week (see Settings.WeekStart)
+// - 'this_month', 'last_month': The current or previous calendar month
+// - 'this_year', 'last_year': The current or previous fiscal year (see Settings.FiscalYearStart)
+// - 'YYYY-MM': A specific month
+// - 'YYYY': The fiscal year starting in a specific year
+//
+// All periods are in local time. Periods reaching into the future only contain the days summarized so far.
+package backend
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"time"
+
+	"github.com/pocketbase/pocketbase/core"
+)
+
+// ReportPeriodTotals contains the totals of a compared period.
+type ReportPeriodTotals struct {
+	Period          string    `json:"period"`           // Period as requested
+	From            time.Time `json:"from"`             // Start of the period
+	To              time.Time `json:"to"`               // End of the period (exclusive)
+	WorkedSeconds   int64     `json:"worked_seconds"`   // Time that counts as work time
+	Worked          string    `json:"worked"`           // Worked time formatted in the configured duration format
+	BreakSeconds    int64     `json:"break_seconds"`    // Time between the sessions of the days
+	TargetSeconds   int64     `json:"target_seconds"`   // Time that should have been worked
+	OvertimeSeconds int64     `json:"overtime_seconds"` // Worked minus target time, negative for undertime
+	Overtime        string    `json:"overtime"`         // Overtime formatted in the configured duration format
+	Sessions        int       `json:"sessions"`         // Number of closed sessions
+	WorkedDays      int       `json:"worked_days"`      // Number of days with at least one session
+}
+
+// ReportPeriodDelta contains the differences between two compared periods (a minus b).
+type ReportPeriodDelta struct {
+	WorkedSeconds   int64    `json:"worked_seconds"`   // Difference of the worked time
+	Worked          string   `json:"worked"`           // Difference of the worked time formatted in the configured duration format
+	WorkedPercent   *float64 `json:"worked_percent"`   // Relative change of the worked time in percent, null if b has no worked time
+	BreakSeconds    int64    `json:"break_seconds"`    // Difference of the breaks
+	OvertimeSeconds int64    `json:"overtime_seconds"` // Difference of the overtime
+	Overtime        string   `json:"overtime"`         // Difference of the overtime formatted in the configured duration format
+	Sessions        int      `json:"sessions"`         // Difference of the number of sessions
+	WorkedDays      int      `json:"worked_days"`      // Difference of the number of worked days
+}
+
+// ReportComparison is the response of the report comparison endpoint.
+type ReportComparison struct {
+	A     ReportPeriodTotals `json:"a"`     // Totals of the first period
+	B     ReportPeriodTotals `json:"b"`     // Totals of the second period
+	Delta ReportPeriodDelta  `json:"delta"` // Differences of the totals, a minus b
+}
+
+// RegisterReportCompareAPI registers the report comparison endpoint with the PocketBase server.
+// It creates the following route:
+// - GET /api/work_clock/report/compare?a=&b=&clock= - Compares the totals of two periods, supports conditional requests
+//
+// Parameters:
+// - app: The PocketBase application instance
+func RegisterReportCompareAPI(app core.App) {
+	app.OnServe().BindFunc(func(se *core.ServeEvent) error {
+		se.Router.GET("/api/work_clock/report/compare", func(e *core.RequestEvent) error {
+			now := clockNow(app)
+
+			a := e.Request.URL.Query().Get("a")
+			aFrom, aTo, err := parsePeriodParam(a, "a", now)
+			if err != nil {
+				return e.Error(http.StatusBadRequest, err.Error(), nil)
+			}
+
+			b := e.Request.URL.Query().Get("b")
+			bFrom, bTo, err := parsePeriodParam(b, "b", now)
+			if err != nil {
+				return e.Error(http.StatusBadRequest, err.Error(), nil)
+			}
+
+			clockID, err := requestClock(app, e)
+			if err != nil {
+				return err
+			}
+
+			comparison := ReportComparison{}
+			comparison.A, err = getReportPeriodTotals(app, clockID, a, aFrom, aTo)
+			if err != nil {
+				return e.Error(http.StatusInternalServerError, fmt.Sprintf("Failed to compare periods: %v", err), err)
+			}
+			comparison.B, err = getReportPeriodTotals(app, clockID, b, bFrom, bTo)
+			if err != nil {
+				return e.Error(http.StatusInternalServerError, fmt.Sprintf("Failed to compare periods: %v", err), err)
+			}
+			comparison.Delta = compareReportPeriods(comparison.A, comparison.B)
+
+			return respondConditionalJSON(e, comparison, workClockLastModified(app))
+		})
+
+		return se.Next()
+	})
+}
+
+// parsePeriodParam parses a period parameter of the report comparison endpoint.
+//
+// Parameters:
+// - value: The value of the parameter
+// - name: The name of the parameter, used in the error message
+// - now: The current time, relative periods are resolved against it
+//
+// Returns:
+// - The start of the period (local midnight)
+// - The end of the period (exclusive)
+// - An error if the value is missing or not a known period
+func parsePeriodParam(value string, name string, now time.Time) (time.Time, time.Time, error) {
+	if value == "" {
+		return time.Time{}, time.Time{}, fmt.Errorf("missing '%s' (string) parameter", name)
+	}
+
+	today := startOfLocalDay(now)
+	week := startOfLocalWeek(today)
+	month := time.Date(today.Year(), today.Month(), 1, 0, 0, 0, 0, time.Local)
+	year := startOfLocalYear(today)
+
+	switch value {
+	case "this_week":
+		return week, week.AddDate(0, 0, 7), nil
+	case "last_week":
+		return week.AddDate(0, 0, -7), week, nil
+	case "this_month":
+		return month, month.AddDate(0, 1, 0), nil
+	case "last_month":
+		return month.AddDate(0, -1, 0), month, nil
+	case "this_year":
+		return year, year.AddDate(1, 0, 0), nil
+	case "last_year":
+		return year.AddDate(-1, 0, 0), year, nil
+	}
+
+	if from, err := time.ParseInLocation("2006-01", value, time.Local); err == nil {
+		return from, from.AddDate(0, 1, 0), nil
+	}
+	if from, err := time.ParseInLocation("2006", value, time.Local); err == nil {
+		from = from.AddDate(0, int(settings.FiscalYearStart-time.January), 0)
+		return from, from.AddDate(1, 0, 0), nil
+	}
+
+	return time.Time{}, time.Time{}, fmt.Errorf("invalid '%s' (string) parameter. Expected a period like 'last_month' or YYYY-MM", name)
+}
+
+// getReportPeriodTotals sums up the daily summaries of a period.
+//
+// Parameters:
+// - app: The App interface (typically a PocketBase instance or transaction)
+// - clockID: The ID of the clock, an empty string for the default clock
+// - period: The period as requested
+// - from: The start of the period
+// - to: The end of the period (exclusive)
+//
+// Returns:
+// - The totals of the period
+// - An error if the daily summaries could not be retrieved
+func getReportPeriodTotals(app core.App, clockID string, period string, from, to time.Time) (ReportPeriodTotals, error) {
+	summaries, err := findDailySummaries(app, clockID, from, to)
+	if err != nil {
+		return ReportPeriodTotals{}, err
+	}
+
+	totals := ReportPeriodTotals{Period: period, From: from, To: to}
+	for _, summary := range summaries {
+		totals.WorkedSeconds += summary.WorkedSeconds
+		totals.BreakSeconds += summary.BreakSeconds
+		totals.TargetSeconds += summary.TargetSeconds
+		totals.OvertimeSeconds += summary.OvertimeSeconds
+		totals.Sessions += summary.Sessions
+		if summary.Sessions > 0 {
+			totals.WorkedDays++
+		}
+	}
+	totals.Worked = formatResponseDuration(totals.WorkedSeconds)
+	totals.Overtime = formatResponseDuration(totals.OvertimeSeconds)
+
+	return totals, nil
+}
+
+// compareReportPeriods calculates the differences between the totals of two periods.
+//
+// Parameters:
+// - a: The totals of the first period
+// - b: The totals of the second period
+//
+// Returns:
+// - The differences, a minus b
+func compareReportPeriods(a, b ReportPeriodTotals) ReportPeriodDelta {
+	delta := ReportPeriodDelta{
+		WorkedSeconds:   a.WorkedSeconds - b.WorkedSeconds,
+		BreakSeconds:    a.BreakSeconds - b.BreakSeconds,
+		OvertimeSeconds: a.OvertimeSeconds - b.OvertimeSeconds,
+		Sessions:        a.Sessions - b.Sessions,
+		WorkedDays:      a.WorkedDays - b.WorkedDays,
+	}
+	delta.Worked = formatResponseDuration(delta.WorkedSeconds)
+	delta.Overtime = formatResponseDuration(delta.OvertimeSeconds)
+
+	if b.WorkedSeconds > 0 {
+		percent := math.Round(float64(delta.WorkedSeconds)/float64(b.WorkedSeconds)*1000) / 10
+		delta.WorkedPercent = &percent
+	}
+
+	return delta
+}
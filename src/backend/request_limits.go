@@ -0,0 +1,119 @@
+// Request Limits Module for PocketBase
+//
+// This module applies the maximum body size and the timeout of requests per route group, so an
+// import can upload a large database and take minutes while a clock operation is rejected early
+// when it sends an oversized body or hangs. The following route groups are configured:
+// - 'imports': The legacy import, the instance import, the signed export verification and the
+// calendar import, limited by IMPORT_MAX_BODY_SIZE and IMPORT_TIMEOUT
+// - 'clock': All other work clock endpoints and the shortcut URLs, limited by CLOCK_MAX_BODY_SIZE
+// and CLOCK_TIMEOUT
+//
+// All other routes keep the default body limit of PocketBase and have no timeout. The timeout is
+// applied to the context of the request, so it cancels everything a handler does with that
+// context and turns the resulting error into a 503 response.
+package backend
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/pocketbase/pocketbase/apis"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tools/hook"
+)
+
+// requestLimitGroup is a group of routes sharing their request limits.
+type requestLimitGroup struct {
+	Name        string        // Name of the group
+	Prefixes    []string      // Path prefixes of the routes of the group
+	MaxBodySize int64         // Maximum size of the request body in bytes, 0 for no limit
+	Timeout     time.Duration // Maximum duration of the request, 0 for no timeout
+}
+
+// requestLimitGroups are the route groups with their own request limits. A request belongs to the
+// first group with a matching path prefix.
+var requestLimitGroups = []requestLimitGroup{
+	{
+		Name:        "imports",
+		Prefixes:    []string{"/api/legacy_import", "/api/work_clock/instance_import", "/api/work_clock/export/verify", "/api/calendar/import"},
+		MaxBodySize: settings.ImportMaxBodySize,
+		Timeout:     settings.ImportTimeout,
+	},
+	{
+		Name:        "clock",
+		Prefixes:    []string{"/api/work_clock", "/c/"},
+		MaxBodySize: settings.ClockMaxBodySize,
+		Timeout:     settings.ClockTimeout,
+	},
+}
+
+// RegisterRequestLimitHooks registers the middleware applying the request limits of the route groups.
+// It replaces the default body limit middleware of PocketBase, which is still overridden by
+// routes binding their own body limit, such as the record and backup upload endpoints.
+//
+// Parameters:
+// - app: The PocketBase application instance
+func RegisterRequestLimitHooks(app core.App) {
+	app.OnServe().BindFunc(func(se *core.ServeEvent) error {
+		se.Router.Bind(&hook.Handler[*core.RequestEvent]{
+			Id:       apis.DefaultBodyLimitMiddlewareId,
+			Priority: apis.DefaultBodyLimitMiddlewarePriority,
+			Func: func(e *core.RequestEvent) error {
+				group, ok := findRequestLimitGroup(e.Request.URL.Path)
+				if !ok {
+					return apis.BodyLimit(apis.DefaultMaxBodySize).Func(e)
+				}
+
+				return applyRequestLimits(e, group)
+			},
+		})
+		return se.Next()
+	})
+}
+
+// findRequestLimitGroup finds the route group of a request path.
+//
+// Parameters:
+// - path: The path of the request
+//
+// Returns:
+// - The route group
+// - Whether the path belongs to a route group
+func findRequestLimitGroup(path string) (requestLimitGroup, bool) {
+	for _, group := range requestLimitGroups {
+		for _, prefix := range group.Prefixes {
+			if strings.HasPrefix(path, prefix) {
+				return group, true
+			}
+		}
+	}
+	return requestLimitGroup{}, false
+}
+
+// applyRequestLimits limits the body and the duration of a request and continues with the next handler.
+//
+// Parameters:
+// - e: The RequestEvent from the HTTP handler
+// - group: The route group of the request
+//
+// Returns:
+// - An error response if the body is too large or the timeout is exceeded, otherwise the result of the next handler
+func applyRequestLimits(e *core.RequestEvent, group requestLimitGroup) error {
+	if group.Timeout <= 0 {
+		return apis.BodyLimit(group.MaxBodySize).Func(e)
+	}
+
+	ctx, cancel := context.WithTimeout(e.Request.Context(), group.Timeout)
+	defer cancel()
+	e.Request = e.Request.WithContext(ctx)
+
+	err := apis.BodyLimit(group.MaxBodySize).Func(e)
+	if err != nil && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return e.Error(http.StatusServiceUnavailable, fmt.Sprintf("The request exceeded the timeout of %s", group.Timeout), err)
+	}
+	return err
+}
@@ -0,0 +1,44 @@
+package backend
+
+import "testing"
+
+func TestFindRequestLimitGroup(t *testing.T) {
+	tests := []struct {
+		path  string
+		group string
+	}{
+		{"/api/legacy_import", "imports"},
+		{"/api/work_clock/instance_import", "imports"},
+		{"/api/work_clock/export/verify", "imports"},
+		{"/api/work_clock/clock_in", "clock"},
+		{"/c/token/toggle", "clock"},
+		{"/api/collections/work_clock/records", ""},
+	}
+
+	for _, test := range tests {
+		group, _ := findRequestLimitGroup(test.path)
+		if group.Name != test.group {
+			t.Errorf("expected %s to belong to group '%s', got '%s'", test.path, test.group, group.Name)
+		}
+	}
+}
+
+func TestSettingsByteSize(t *testing.T) {
+	tests := map[string]int64{
+		"":      42,
+		"1024":  1024,
+		"512kb": 512 << 10,
+		"50 MB": 50 << 20,
+		"2GB":   2 << 30,
+		"-1MB":  42,
+		"lots":  42,
+		"1.5MB": 42,
+	}
+
+	for value, expected := range tests {
+		t.Setenv("TEST_BYTE_SIZE", value)
+		if size := newSettingsLoader().byteSize("TEST_BYTE_SIZE", 42); size != expected {
+			t.Errorf("expected '%s' to be %d bytes, got %d", value, expected, size)
+		}
+	}
+}
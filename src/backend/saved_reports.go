@@ -0,0 +1,343 @@
+// Saved Reports Module for PocketBase
+//
+// This module saves report specs of the report builder under a name and shares them as read-only
+// links, so e.g. a monthly report can be sent to a manager without giving them an account. A
+// share link contains a token signed with the server key (see the signed export module), which
+// names the report, the share version of the report and when the link expires. Nothing about
+// the links is stored; increasing the share version of a report revokes all its links at once.
+//
+// Saved reports belong to the user who saved them, only they and superusers can evaluate, share
+// and revoke them. A report can only be saved for a clock the user may read (see the delegation
+// module). Shared reports are evaluated whenever the link is opened, so a report saved with a
+// period like 'last_month' always shows the last month.
+package backend
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pocketbase/pocketbase/apis"
+	"github.com/pocketbase/pocketbase/core"
+)
+
+const (
+	// defaultReportShareDays is the number of days a share link is valid without 'days' parameter
+	defaultReportShareDays = 30
+
+	// maxReportShareDays is the maximum number of days a share link is valid
+	maxReportShareDays = 365
+)
+
+// savedReportRequest is the request body of the save report endpoint.
+type savedReportRequest struct {
+	Name string     `json:"name"` // Name of the report
+	Spec reportSpec `json:"spec"` // Report spec, see the report builder
+}
+
+// reportShareClaims is the signed content of a share token.
+type reportShareClaims struct {
+	ReportID string `json:"report"`  // ID of the shared report
+	Version  int    `json:"version"` // Share version of the report when the link was created
+	Expires  int64  `json:"expires"` // Expiry of the link as Unix time
+}
+
+// ReportShare is the response of the share report endpoint.
+type ReportShare struct {
+	Token   string    `json:"token"`   // Signed share token
+	URL     string    `json:"url"`     // Path of the shared report, relative to the server
+	Expires time.Time `json:"expires"` // Time the link expires
+}
+
+// RegisterSavedReportsAPI registers the saved report endpoints with the PocketBase server.
+// Saved reports are listed and deleted via the records API of the saved_reports collection.
+// It creates the following routes, all but the shared report require authentication:
+// - POST /api/reports - Saves a report spec under a name
+// - GET /api/reports/{id} - Evaluates a saved report, supports conditional requests
+// - POST /api/reports/{id}/share?days= - Creates a read-only share link valid for 'days' days
+// - POST /api/reports/{id}/revoke - Revokes all share links of a saved report
+// - GET /api/shared/reports/{token} - Evaluates a shared report without authentication, supports conditional requests
+//
+// The save endpoint expects a JSON body like:
+//
+//	{
+//	  "name": "Monthly report",
+//	  "spec": {"period": "last_month", "group_by": ["week"], "metrics": ["worked", "overtime"]}
+//	}
+//
+// Parameters:
+// - app: The PocketBase application instance
+func RegisterSavedReportsAPI(app core.App) {
+	app.OnServe().BindFunc(func(se *core.ServeEvent) error {
+		se.Router.POST("/api/reports", func(e *core.RequestEvent) error {
+			var request savedReportRequest
+			if err := e.BindBody(&request); err != nil {
+				return e.Error(http.StatusBadRequest, "Invalid request body", err)
+			}
+
+			request.Name = strings.TrimSpace(request.Name)
+			if request.Name == "" {
+				return e.Error(http.StatusBadRequest, "Missing 'name' (string) parameter", nil)
+			}
+			_, _, clockID, err := validateReportSpec(app, &request.Spec)
+			if err != nil {
+				return e.Error(http.StatusBadRequest, err.Error(), nil)
+			}
+			if clockID != "" {
+				if err := checkClockReadAccess(app, e, clockID, clockNow(app)); err != nil {
+					return err
+				}
+			}
+
+			// Superusers are no users, so their reports have no owner
+			owner := ""
+			if !e.HasSuperuserAuth() {
+				owner = e.Auth.Id
+			}
+
+			record, err := saveReport(app, owner, request.Name, request.Spec)
+			if err != nil {
+				return e.Error(http.StatusInternalServerError, fmt.Sprintf("Failed to save report: %v", err), err)
+			}
+
+			return e.JSON(http.StatusOK, map[string]any{
+				"id":   record.Id,
+				"name": request.Name,
+			})
+		}).Bind(apis.RequireAuth())
+
+		se.Router.GET("/api/reports/{id}", func(e *core.RequestEvent) error {
+			record, err := requestOwnedReport(app, e)
+			if err != nil {
+				return err
+			}
+
+			return respondSavedReport(app, e, record, false)
+		}).Bind(apis.RequireAuth())
+
+		se.Router.POST("/api/reports/{id}/share", func(e *core.RequestEvent) error {
+			days := defaultReportShareDays
+			if daysValue := e.Request.FormValue("days"); daysValue != "" {
+				var err error
+				days, err = strconv.Atoi(daysValue)
+				if err != nil || days < 1 || days > maxReportShareDays {
+					return e.Error(http.StatusBadRequest, fmt.Sprintf("Invalid 'days' (integer) parameter. Expected a value between 1 and %d", maxReportShareDays), nil)
+				}
+			}
+
+			record, err := requestOwnedReport(app, e)
+			if err != nil {
+				return err
+			}
+
+			// The link grants access to the clock of the report, so the owner must still be able to read it
+			var spec reportSpec
+			if err := record.UnmarshalJSONField("spec", &spec); err != nil {
+				return e.Error(http.StatusInternalServerError, fmt.Sprintf("Failed to share report: %v", err), err)
+			}
+			if clockID, err := findClockID(app, spec.Clock); err == nil && clockID != "" {
+				if err := checkClockReadAccess(app, e, clockID, clockNow(app)); err != nil {
+					return err
+				}
+			}
+
+			expires := time.Now().AddDate(0, 0, days)
+			token, err := createReportShareToken(app, record, expires)
+			if err != nil {
+				return e.Error(http.StatusInternalServerError, fmt.Sprintf("Failed to share report: %v", err), err)
+			}
+
+			return e.JSON(http.StatusOK, ReportShare{Token: token, URL: "/api/shared/reports/" + token, Expires: expires})
+		}).Bind(apis.RequireAuth())
+
+		se.Router.POST("/api/reports/{id}/revoke", func(e *core.RequestEvent) error {
+			record, err := requestOwnedReport(app, e)
+			if err != nil {
+				return err
+			}
+
+			record.Set("share_version", record.GetInt("share_version")+1)
+			if err := app.Save(record); err != nil {
+				return e.Error(http.StatusInternalServerError, fmt.Sprintf("Failed to revoke share links: %v", err), err)
+			}
+			return callSucceeded(e)
+		}).Bind(apis.RequireAuth())
+
+		se.Router.GET("/api/shared/reports/{token}", func(e *core.RequestEvent) error {
+			record, err := verifyReportShareToken(app, e.Request.PathValue("token"), time.Now())
+			if err != nil {
+				return e.Error(http.StatusNotFound, "Unknown, expired or revoked share link", nil)
+			}
+
+			return respondSavedReport(app, e, record, true)
+		})
+
+		return se.Next()
+	})
+}
+
+// requestOwnedReport finds the saved report of a request and checks that the requester owns it.
+//
+// Parameters:
+// - app: The App interface (typically a PocketBase instance or transaction)
+// - e: The authenticated RequestEvent with the ID of the report in the path
+//
+// Returns:
+// - The saved_reports record
+// - An error response if the report does not exist or the user is neither its owner nor a superuser
+func requestOwnedReport(app core.App, e *core.RequestEvent) (*core.Record, error) {
+	record, err := app.FindRecordById("saved_reports", e.Request.PathValue("id"))
+	if err != nil {
+		return nil, e.Error(http.StatusNotFound, "Saved report not found", nil)
+	}
+	if !e.HasSuperuserAuth() && record.GetString("owner") != e.Auth.Id {
+		return nil, e.Error(http.StatusForbidden, "Only the owner of the report can use it", nil)
+	}
+	return record, nil
+}
+
+// saveReport saves a validated report spec under a name.
+//
+// Parameters:
+// - app: The App interface (typically a PocketBase instance or transaction)
+// - owner: The ID of the user saving the report, empty for superusers
+// - name: The name of the report
+// - spec: The validated report spec
+//
+// Returns:
+// - The created saved_reports record
+// - An error if saving fails, e.g. because the name is already used
+func saveReport(app core.App, owner string, name string, spec reportSpec) (*core.Record, error) {
+	collection, err := app.FindCollectionByNameOrId("saved_reports")
+	if err != nil {
+		return nil, fmt.Errorf("failed to find saved_reports collection: %w", err)
+	}
+
+	record := core.NewRecord(collection)
+	record.Set("owner", owner)
+	record.Set("name", name)
+	record.Set("spec", spec)
+	if err := app.Save(record); err != nil {
+		return nil, fmt.Errorf("failed to save report '%s': %w", name, err)
+	}
+
+	return record, nil
+}
+
+// respondSavedReport evaluates a saved report and writes it as conditional JSON response.
+//
+// Parameters:
+// - app: The App interface (typically a PocketBase instance or transaction)
+// - e: The RequestEvent from the HTTP handler
+// - record: The saved_reports record
+// - shared: Whether the report is opened through a share link, which grants access to its clock
+//
+// Returns:
+// - An error if the saved spec is not valid anymore, e.g. because its project was deleted, the
+// user may not read its clock, or the report could not be created
+func respondSavedReport(app core.App, e *core.RequestEvent, record *core.Record, shared bool) error {
+	var spec reportSpec
+	if err := record.UnmarshalJSONField("spec", &spec); err != nil {
+		return e.Error(http.StatusInternalServerError, fmt.Sprintf("Failed to create custom report: %v", err), err)
+	}
+
+	from, to, clockID, err := validateReportSpec(app, &spec)
+	if err != nil {
+		return e.Error(http.StatusConflict, fmt.Sprintf("Saved report is not valid anymore: %v", err), nil)
+	}
+	if !shared && clockID != "" {
+		if err := checkClockReadAccess(app, e, clockID, clockNow(app)); err != nil {
+			return err
+		}
+	}
+
+	report, err := evaluateCachedReportSpec(app, spec, clockID, from, to)
+	if err != nil {
+		return e.Error(http.StatusInternalServerError, fmt.Sprintf("Failed to create custom report: %v", err), err)
+	}
+
+	return respondConditionalJSON(e, report, workClockLastModified(app))
+}
+
+// createReportShareToken creates a signed share token for a saved report.
+//
+// Parameters:
+// - app: The App interface used to load the signing key
+// - record: The saved_reports record
+// - expires: The time the token expires
+//
+// Returns:
+// - The token, the base64url encoded claims and their signature separated by a period
+// - An error if the signing key could not be loaded
+func createReportShareToken(app core.App, record *core.Record, expires time.Time) (string, error) {
+	key, err := loadExportSigningKey(app)
+	if err != nil {
+		return "", err
+	}
+
+	claims, err := json.Marshal(reportShareClaims{ReportID: record.Id, Version: record.GetInt("share_version"), Expires: expires.Unix()})
+	if err != nil {
+		return "", fmt.Errorf("failed to encode share token: %w", err)
+	}
+
+	payload := base64.RawURLEncoding.EncodeToString(claims)
+	signature := ed25519.Sign(key, []byte(payload))
+
+	return payload + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
+
+// verifyReportShareToken verifies a share token and finds the shared report.
+//
+// Parameters:
+// - app: The App interface used to load the signing key and the report
+// - token: The share token
+// - now: The current time
+//
+// Returns:
+// - The shared saved_reports record
+// - An error if the token is malformed, its signature is invalid, it expired, the report was
+// deleted, or its links were revoked
+func verifyReportShareToken(app core.App, token string, now time.Time) (*core.Record, error) {
+	key, err := loadExportSigningKey(app)
+	if err != nil {
+		return nil, err
+	}
+
+	payload, encodedSignature, ok := strings.Cut(token, ".")
+	if !ok {
+		return nil, fmt.Errorf("malformed share token")
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(encodedSignature)
+	if err != nil || !ed25519.Verify(key.Public().(ed25519.PublicKey), []byte(payload), signature) {
+		return nil, fmt.Errorf("invalid share token signature")
+	}
+
+	claimsJSON, err := base64.RawURLEncoding.DecodeString(payload)
+	if err != nil {
+		return nil, fmt.Errorf("malformed share token")
+	}
+
+	var claims reportShareClaims
+	if err := json.Unmarshal(claimsJSON, &claims); err != nil {
+		return nil, fmt.Errorf("malformed share token")
+	}
+	if now.Unix() >= claims.Expires {
+		return nil, fmt.Errorf("share token expired")
+	}
+
+	record, err := app.FindRecordById("saved_reports", claims.ReportID)
+	if err != nil {
+		return nil, fmt.Errorf("shared report with id '%s' does not exist", claims.ReportID)
+	}
+	if record.GetInt("share_version") != claims.Version {
+		return nil, fmt.Errorf("share token was revoked")
+	}
+
+	return record, nil
+}
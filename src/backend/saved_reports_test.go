@@ -0,0 +1,127 @@
+package backend
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/yerTools/simple-frontend-stack/src/backend/backendtest"
+)
+
+func TestReportShareToken(t *testing.T) {
+	app := backendtest.NewApp(t)
+
+	record, err := saveReport(app, "", "Monthly report", reportSpec{Period: "last_month", Metrics: []string{"worked"}})
+	if err != nil {
+		t.Fatalf("failed to save report: %v", err)
+	}
+
+	now := time.Now()
+	token, err := createReportShareToken(app, record, now.Add(time.Hour))
+	if err != nil {
+		t.Fatalf("failed to create share token: %v", err)
+	}
+
+	shared, err := verifyReportShareToken(app, token, now)
+	if err != nil {
+		t.Fatalf("failed to verify share token: %v", err)
+	}
+	if shared.Id != record.Id {
+		t.Errorf("expected shared report '%s', got '%s'", record.Id, shared.Id)
+	}
+
+	if _, err := verifyReportShareToken(app, "x"+token, now); err == nil {
+		t.Error("expected a modified share token to be rejected")
+	}
+	if _, err := verifyReportShareToken(app, token, now.Add(2*time.Hour)); err == nil {
+		t.Error("expected an expired share token to be rejected")
+	}
+
+	record.Set("share_version", record.GetInt("share_version")+1)
+	if err := app.Save(record); err != nil {
+		t.Fatalf("failed to revoke share links: %v", err)
+	}
+	if _, err := verifyReportShareToken(app, token, now); err == nil {
+		t.Error("expected a revoked share token to be rejected")
+	}
+}
+
+func TestSavedReportOwners(t *testing.T) {
+	app := backendtest.NewApp(t)
+	RegisterSavedReportsAPI(app)
+	handler := backendtest.NewHandler(t, app)
+
+	owner := backendtest.AddUser(t, app, "owner@example.com")
+	other := backendtest.AddUser(t, app, "other@example.com")
+
+	clocks, err := app.FindCollectionByNameOrId("clocks")
+	if err != nil {
+		t.Fatalf("failed to find clocks collection: %v", err)
+	}
+	clock := core.NewRecord(clocks)
+	clock.Set("name", "owner")
+	clock.Set("owner", owner.Id)
+	if err := app.Save(clock); err != nil {
+		t.Fatalf("failed to save clock: %v", err)
+	}
+
+	send := func(user *core.Record, method string, path string, body string) (int, string) {
+		t.Helper()
+		request := httptest.NewRequest(method, path, strings.NewReader(body))
+		request.Header.Set("Content-Type", "application/json")
+		if user != nil {
+			token, err := user.NewAuthToken()
+			if err != nil {
+				t.Fatalf("failed to create token: %v", err)
+			}
+			request.Header.Set("Authorization", token)
+		}
+
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, request)
+		return recorder.Code, recorder.Body.String()
+	}
+
+	report := `{"name":"Monthly report","spec":{"period":"last_month","metrics":["worked"],"clock":"owner"}}`
+	if code, _ := send(nil, http.MethodPost, "/api/reports", report); code != http.StatusUnauthorized {
+		t.Errorf("expected anonymous users to be rejected from saving reports with 401, got %d", code)
+	}
+	if code, _ := send(other, http.MethodPost, "/api/reports", report); code != http.StatusForbidden {
+		t.Errorf("expected reports of clocks of other users to be rejected with 403, got %d", code)
+	}
+	code, body := send(owner, http.MethodPost, "/api/reports", report)
+	var saved struct {
+		ID string `json:"id"`
+	}
+	if code != http.StatusOK || json.Unmarshal([]byte(body), &saved) != nil {
+		t.Fatalf("failed to save report: %d %s", code, body)
+	}
+	// Names only have to be unique per owner
+	if code, body := send(other, http.MethodPost, "/api/reports", `{"name":"Monthly report","spec":{"period":"last_month","metrics":["worked"]}}`); code != http.StatusOK {
+		t.Errorf("expected another user to save a report of the same name, got %d: %s", code, body)
+	}
+
+	for _, path := range []string{"/api/reports/" + saved.ID, "/api/reports/" + saved.ID + "/share", "/api/reports/" + saved.ID + "/revoke"} {
+		method := http.MethodPost
+		if path == "/api/reports/"+saved.ID {
+			method = http.MethodGet
+		}
+		if code, _ := send(other, method, path, ""); code != http.StatusForbidden {
+			t.Errorf("expected other users to be rejected from %s with 403, got %d", path, code)
+		}
+		if code, body := send(owner, method, path, ""); code != http.StatusOK {
+			t.Errorf("expected the owner to use %s, got %d: %s", path, code, body)
+		}
+	}
+
+	if code, body := send(other, http.MethodGet, "/api/collections/saved_reports/records", ""); code != http.StatusOK || strings.Contains(body, saved.ID) {
+		t.Errorf("expected the list of other users to leave out the report, got %d: %s", code, body)
+	}
+	if code, _ := send(other, http.MethodDelete, "/api/collections/saved_reports/records/"+saved.ID, ""); code == http.StatusNoContent {
+		t.Error("expected other users to be rejected from deleting the report")
+	}
+}
@@ -0,0 +1,239 @@
+// Scheduled Export Delivery Module for PocketBase
+//
+// This module delivers the files of scheduled exports to an SFTP server or an SMB share instead of
+// emailing them, e.g. to the intake directory of the payroll system. The connection is configured
+// in the settings (EXPORT_SFTP_* and EXPORT_SMB_DIRECTORY), so the credentials are not stored in
+// the database; a scheduled export only chooses the delivery and the directory.
+//
+// The SMB share is expected to be mounted on the host, e.g. with mount.cifs or as volume of the
+// container, since the share is then managed with the tools of the operating system.
+//
+// Files are first written with the suffix ".part" and renamed when complete, so an intake process
+// polling the directory never picks up a partial file. An existing file of the same name is
+// replaced, which makes rerunning an export safe.
+package backend
+
+import (
+	"bytes"
+	"cmp"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path"
+	"path/filepath"
+	"time"
+
+	"github.com/pkg/sftp"
+	"github.com/pocketbase/pocketbase/core"
+	"golang.org/x/crypto/ssh"
+)
+
+// sftpDialTimeout is the maximum duration of establishing the SFTP connection.
+const sftpDialTimeout = 30 * time.Second
+
+// partialFileSuffix is the suffix of delivered files while they are written.
+const partialFileSuffix = ".part"
+
+// scheduledExportDeliveries deliver the file of a scheduled export, by the delivery of the export.
+// They return where the file was delivered to.
+var scheduledExportDeliveries = map[string]func(app core.App, export *core.Record, fileName string, file []byte, from, to time.Time) (string, error){
+	"email": sendScheduledExport,
+	"sftp":  uploadScheduledExport,
+	"smb":   writeScheduledExportToShare,
+}
+
+// scheduledExportDelivery returns the delivery of a scheduled export.
+//
+// Parameters:
+// - export: The scheduled_exports record
+//
+// Returns:
+// - 'email', 'sftp' or 'smb'
+func scheduledExportDelivery(export *core.Record) string {
+	return cmp.Or(export.GetString("delivery"), "email")
+}
+
+// validateScheduledExportDelivery checks that the delivery of a scheduled export is configured.
+//
+// Parameters:
+// - export: The scheduled_exports record
+//
+// Returns:
+// - An error if the delivery is not configured or the directory is invalid
+func validateScheduledExportDelivery(export *core.Record) error {
+	switch scheduledExportDelivery(export) {
+	case "sftp":
+		if settings.ExportSFTPAddress == "" {
+			return errors.New("the SFTP delivery is not configured")
+		}
+	case "smb":
+		if settings.ExportSMBDirectory == "" {
+			return errors.New("the SMB delivery is not configured")
+		}
+		if _, err := shareDirectory(export.GetString("directory")); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// uploadScheduledExport uploads the file of a scheduled export to the configured SFTP server.
+//
+// Parameters:
+// - app: The App interface (unused, part of the delivery signature)
+// - export: The scheduled_exports record
+// - fileName: The name of the file
+// - file: The content of the file
+// - from: The start of the exported range (unused, part of the delivery signature)
+// - to: The end of the exported range (unused, part of the delivery signature)
+//
+// Returns:
+// - The URL of the uploaded file
+// - An error if the connection or the upload fails
+func uploadScheduledExport(_ core.App, export *core.Record, fileName string, file []byte, _, _ time.Time) (string, error) {
+	conn, err := dialExportSFTP()
+	if err != nil {
+		return "", err
+	}
+	defer conn.Close()
+
+	client, err := sftp.NewClient(conn)
+	if err != nil {
+		return "", fmt.Errorf("failed to start SFTP session: %w", err)
+	}
+	defer client.Close()
+
+	remotePath := path.Join(export.GetString("directory"), fileName)
+	partial, err := client.OpenFile(remotePath+partialFileSuffix, os.O_WRONLY|os.O_CREATE|os.O_TRUNC)
+	if err != nil {
+		return "", fmt.Errorf("failed to open '%s': %w", remotePath+partialFileSuffix, err)
+	}
+	if _, err := partial.ReadFrom(bytes.NewReader(file)); err != nil {
+		partial.Close()
+		return "", fmt.Errorf("failed to write '%s': %w", remotePath+partialFileSuffix, err)
+	}
+	if err := partial.Close(); err != nil {
+		return "", fmt.Errorf("failed to close '%s': %w", remotePath+partialFileSuffix, err)
+	}
+
+	// The OpenSSH extension replaces an existing file atomically, other servers refuse to rename
+	// onto an existing file
+	if _, ok := client.HasExtension("posix-rename@openssh.com"); ok {
+		if err := client.PosixRename(remotePath+partialFileSuffix, remotePath); err != nil {
+			return "", fmt.Errorf("failed to rename '%s': %w", remotePath+partialFileSuffix, err)
+		}
+	} else {
+		if err := client.Remove(remotePath); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return "", fmt.Errorf("failed to replace '%s': %w", remotePath, err)
+		}
+		if err := client.Rename(remotePath+partialFileSuffix, remotePath); err != nil {
+			return "", fmt.Errorf("failed to rename '%s': %w", remotePath+partialFileSuffix, err)
+		}
+	}
+
+	return fmt.Sprintf("sftp://%s@%s/%s", settings.ExportSFTPUser, settings.ExportSFTPAddress, remotePath), nil
+}
+
+// dialExportSFTP connects to the configured SFTP server.
+//
+// Returns:
+// - The SSH connection, which has to be closed
+// - An error if the settings are incomplete or the connection fails
+func dialExportSFTP() (*ssh.Client, error) {
+	if settings.ExportSFTPAddress == "" {
+		return nil, errors.New("the SFTP delivery is not configured")
+	}
+
+	hostKey, _, _, _, err := ssh.ParseAuthorizedKey([]byte(settings.ExportSFTPHostKey))
+	if err != nil {
+		return nil, fmt.Errorf("invalid SFTP host key: %w", err)
+	}
+
+	var auth []ssh.AuthMethod
+	if settings.ExportSFTPKeyFile != "" {
+		key, err := os.ReadFile(settings.ExportSFTPKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read SFTP key file: %w", err)
+		}
+		signer, err := ssh.ParsePrivateKey(key)
+		if err != nil {
+			return nil, fmt.Errorf("invalid SFTP key: %w", err)
+		}
+		auth = append(auth, ssh.PublicKeys(signer))
+	}
+	if settings.ExportSFTPPassword != "" {
+		auth = append(auth, ssh.Password(settings.ExportSFTPPassword))
+	}
+
+	address := settings.ExportSFTPAddress
+	if _, _, err := net.SplitHostPort(address); err != nil {
+		address = net.JoinHostPort(address, "22")
+	}
+
+	conn, err := ssh.Dial("tcp", address, &ssh.ClientConfig{
+		User:            settings.ExportSFTPUser,
+		Auth:            auth,
+		HostKeyCallback: ssh.FixedHostKey(hostKey),
+		Timeout:         sftpDialTimeout,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to SFTP server: %w", err)
+	}
+	return conn, nil
+}
+
+// writeScheduledExportToShare writes the file of a scheduled export to the mounted SMB share.
+//
+// Parameters:
+// - app: The App interface (unused, part of the delivery signature)
+// - export: The scheduled_exports record
+// - fileName: The name of the file
+// - file: The content of the file
+// - from: The start of the exported range (unused, part of the delivery signature)
+// - to: The end of the exported range (unused, part of the delivery signature)
+//
+// Returns:
+// - The path of the written file
+// - An error if the directory is invalid or writing fails
+func writeScheduledExportToShare(_ core.App, export *core.Record, fileName string, file []byte, _, _ time.Time) (string, error) {
+	directory, err := shareDirectory(export.GetString("directory"))
+	if err != nil {
+		return "", err
+	}
+
+	filePath := filepath.Join(directory, fileName)
+	if err := os.WriteFile(filePath+partialFileSuffix, file, 0o644); err != nil {
+		return "", fmt.Errorf("failed to write to share: %w", err)
+	}
+	// The rename replaces an existing file atomically
+	if err := os.Rename(filePath+partialFileSuffix, filePath); err != nil {
+		os.Remove(filePath + partialFileSuffix)
+		return "", fmt.Errorf("failed to write to share: %w", err)
+	}
+
+	return filePath, nil
+}
+
+// shareDirectory resolves the directory of a scheduled export within the mounted SMB share.
+//
+// Parameters:
+// - directory: The directory relative to the share, empty for the root of the share
+//
+// Returns:
+// - The path of the directory
+// - An error if the delivery is not configured or the directory lies outside of the share
+func shareDirectory(directory string) (string, error) {
+	if settings.ExportSMBDirectory == "" {
+		return "", errors.New("the SMB delivery is not configured")
+	}
+	if directory == "" {
+		return settings.ExportSMBDirectory, nil
+	}
+
+	local := filepath.FromSlash(directory)
+	if !filepath.IsLocal(local) {
+		return "", fmt.Errorf("invalid directory '%s', it has to be a relative path within the share", directory)
+	}
+	return filepath.Join(settings.ExportSMBDirectory, local), nil
+}
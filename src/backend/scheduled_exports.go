@@ -0,0 +1,332 @@
+// Scheduled Exports Module for PocketBase
+//
+// This module emails exports on a schedule, e.g. the CSV of last month to the payroll department
+// on the 1st. The exports are configured in the scheduled_exports collection with a cron
+// expression, the exported period ('previous_day', 'previous_week' or 'previous_month' relative
+// to the run), the format and filters of the export module, and the recipients. The file is
+// created by the export module and sent as attachment with the mail settings of PocketBase, or
+// delivered to an SFTP server or SMB share (see the scheduled export delivery module).
+//
+// Every run is recorded in the scheduled_export_runs collection, which serves as the run history.
+// A failed run is additionally recorded as 'scheduled_export_failed' event and announced through
+// the push service, so a missing payroll file is noticed before the payroll department asks.
+//
+// The schedules are checked every minute against the time of the clock source (see the clock
+// source module). An export can also be run manually, e.g. to test its configuration.
+package backend
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"net/mail"
+	"strings"
+	"time"
+
+	"github.com/pocketbase/pocketbase/apis"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tools/cron"
+	"github.com/pocketbase/pocketbase/tools/mailer"
+)
+
+// maxScheduledExportErrorLength is the maximum length of the error recorded with a run.
+const maxScheduledExportErrorLength = 2000
+
+// scheduledExportPeriods resolve the periods of scheduled exports to the exported range.
+var scheduledExportPeriods = map[string]func(now time.Time) (time.Time, time.Time){
+	"previous_day": func(now time.Time) (time.Time, time.Time) {
+		today := startOfLocalDay(now)
+		return today.AddDate(0, 0, -1), today
+	},
+	"previous_week": func(now time.Time) (time.Time, time.Time) {
+		week := startOfLocalWeek(now)
+		return week.AddDate(0, 0, -7), week
+	},
+	"previous_month": func(now time.Time) (time.Time, time.Time) {
+		today := startOfLocalDay(now)
+		month := today.AddDate(0, 0, 1-today.Day())
+		return month.AddDate(0, -1, 0), month
+	},
+}
+
+// ScheduledExportRun is a run of a scheduled export.
+type ScheduledExportRun struct {
+	ID          string    `json:"id"`          // ID of the run
+	Export      string    `json:"export"`      // ID of the scheduled export
+	Succeeded   bool      `json:"succeeded"`   // Whether the export was delivered
+	Manual      bool      `json:"manual"`      // Whether the run was started manually
+	From        time.Time `json:"from"`        // Start of the exported range
+	To          time.Time `json:"to"`          // End of the exported range (exclusive)
+	FileName    string    `json:"file_name"`   // Name of the delivered file, empty if the export failed
+	Size        int       `json:"size"`        // Size of the delivered file in bytes
+	Delivery    string    `json:"delivery"`    // How the file was delivered: 'email', 'sftp' or 'smb'
+	Destination string    `json:"destination"` // Where the file was delivered to, empty if the export failed
+	Error       string    `json:"error"`       // Why the run failed, empty for successful runs
+	DurationMs  int64     `json:"duration_ms"` // Duration of the run in milliseconds
+}
+
+// RegisterScheduledExports registers the job running the scheduled exports, the hooks validating
+// them and the manual run endpoint with the PocketBase server.
+// It creates the following route, only accessible for superusers:
+// - POST /api/scheduled_exports/{id}/run - Runs a scheduled export now and returns the ScheduledExportRun
+//
+// Parameters:
+// - app: The PocketBase application instance
+func RegisterScheduledExports(app core.App) {
+	app.OnRecordCreate("scheduled_exports").BindFunc(validateScheduledExport)
+	app.OnRecordUpdate("scheduled_exports").BindFunc(validateScheduledExport)
+
+	app.Cron().MustAdd("scheduled_exports", "* * * * *", backgroundJobs.cronJob("scheduled_exports", func() {
+		runDueScheduledExports(app, clockNow(app))
+	}))
+
+	app.OnServe().BindFunc(func(se *core.ServeEvent) error {
+		se.Router.POST("/api/scheduled_exports/{id}/run", func(e *core.RequestEvent) error {
+			export, err := app.FindRecordById("scheduled_exports", e.Request.PathValue("id"))
+			if err != nil {
+				return e.Error(http.StatusNotFound, fmt.Sprintf("scheduled export with id '%s' does not exist", e.Request.PathValue("id")), nil)
+			}
+
+			// A failed run is recorded like a scheduled one, so it is returned instead of an error
+			run, err := runScheduledExport(app, export, clockNow(app), true)
+			if run == nil {
+				return e.Error(http.StatusInternalServerError, fmt.Sprintf("Failed to run scheduled export: %v", err), err)
+			}
+			return e.JSON(http.StatusOK, run)
+		}).Bind(apis.RequireSuperuserAuth())
+
+		return se.Next()
+	})
+}
+
+// validateScheduledExport rejects scheduled exports with an invalid schedule, recipients or
+// delivery, payroll exports without a profile and template exports without a template.
+//
+// Parameters:
+// - e: The RecordEvent of the saved scheduled export
+//
+// Returns:
+// - An error if the scheduled export is invalid or saving fails
+func validateScheduledExport(e *core.RecordEvent) error {
+	if _, err := cron.NewSchedule(e.Record.GetString("schedule")); err != nil {
+		return fmt.Errorf("invalid schedule '%s': %w", e.Record.GetString("schedule"), err)
+	}
+	if scheduledExportDelivery(e.Record) == "email" {
+		if _, err := mail.ParseAddressList(e.Record.GetString("recipients")); err != nil {
+			return fmt.Errorf("invalid recipients: %w", err)
+		}
+	}
+	if err := validateScheduledExportDelivery(e.Record); err != nil {
+		return err
+	}
+	if e.Record.GetString("format") == "payroll" && e.Record.GetString("profile") == "" {
+		return fmt.Errorf("payroll exports require an export profile")
+	}
+	if e.Record.GetString("format") == "template" && e.Record.GetString("template") == "" {
+		return fmt.Errorf("template exports require an export template")
+	}
+	return e.Next()
+}
+
+// runDueScheduledExports runs the enabled scheduled exports whose schedule is due. Failures are
+// recorded with the runs.
+//
+// Parameters:
+// - app: The App interface (typically a PocketBase instance or transaction)
+// - now: The current time
+func runDueScheduledExports(app core.App, now time.Time) {
+	exports, err := app.FindRecordsByFilter("scheduled_exports", "enabled = true", "", 0, 0)
+	if err != nil {
+		app.Logger().Error("failed to find scheduled exports", "error", err)
+		return
+	}
+
+	moment := cron.NewMoment(now)
+	for _, export := range exports {
+		schedule, err := cron.NewSchedule(export.GetString("schedule"))
+		if err != nil {
+			app.Logger().Error("invalid schedule of scheduled export", "export", export.Id, "error", err)
+			continue
+		}
+		if schedule.IsDue(moment) {
+			runScheduledExport(app, export, now, false)
+		}
+	}
+}
+
+// runScheduledExport creates the file of a scheduled export, delivers it and records the run. Failed runs are recorded as event and announced through the push service.
+//
+// Parameters:
+// - app: The App interface (typically a PocketBase instance or transaction)
+// - export: The scheduled_exports record
+// - now: The time of the run, which determines the exported range
+// - manual: Whether the run was started manually
+//
+// Returns:
+// - The recorded run, nil if it could not be recorded
+// - An error if the run failed
+func runScheduledExport(app core.App, export *core.Record, now time.Time, manual bool) (*ScheduledExportRun, error) {
+	started := time.Now()
+	name := export.GetString("name")
+
+	run := &ScheduledExportRun{Export: export.Id, Manual: manual, Delivery: scheduledExportDelivery(export)}
+	period, ok := scheduledExportPeriods[export.GetString("period")]
+	if !ok {
+		period = scheduledExportPeriods["previous_month"]
+	}
+	run.From, run.To = period(now)
+
+	fileName, file, err := createScheduledExportFile(app, export, run.From, run.To)
+	var destination string
+	if err == nil {
+		deliver, ok := scheduledExportDeliveries[run.Delivery]
+		if !ok {
+			deliver = sendScheduledExport
+		}
+		destination, err = deliver(app, export, fileName, file, run.From, run.To)
+	}
+	if err == nil {
+		run.Succeeded = true
+		run.FileName = fileName
+		run.Size = len(file)
+		run.Destination = destination
+	} else {
+		run.Error = err.Error()
+		if len(run.Error) > maxScheduledExportErrorLength {
+			run.Error = strings.ToValidUTF8(run.Error[:maxScheduledExportErrorLength], "")
+		}
+	}
+	run.DurationMs = time.Since(started).Milliseconds()
+
+	data := map[string]any{"export_id": export.Id, "from": run.From, "to": run.To}
+	if err != nil {
+		message := fmt.Sprintf("Failed to deliver scheduled export '%s': %v", name, err)
+		app.Logger().Error("failed to deliver scheduled export", "export", export.Id, "error", err)
+		recordEvent(app, "scheduled_export_failed", "error", message, data)
+		sendPushNotification(app, pushNotification{Title: "Scheduled export failed", Message: message, Priority: true})
+	} else {
+		recordEvent(app, "scheduled_export_completed", "info", fmt.Sprintf("Delivered scheduled export '%s' to %s", name, destination), data)
+	}
+
+	if saveErr := saveScheduledExportRun(app, run); saveErr != nil {
+		app.Logger().Error("failed to record scheduled export run", "export", export.Id, "error", saveErr)
+		return nil, fmt.Errorf("failed to record run: %w", saveErr)
+	}
+	return run, err
+}
+
+// createScheduledExportFile creates the file of a scheduled export with the export module.
+//
+// Parameters:
+// - app: The App interface (typically a PocketBase instance or transaction)
+// - export: The scheduled_exports record
+// - from: The start of the exported range (inclusive)
+// - to: The end of the exported range (exclusive)
+//
+// Returns:
+// - The name of the file
+// - The content of the file
+// - An error if the filters or the profile are invalid or the export fails
+func createScheduledExportFile(app core.App, export *core.Record, from, to time.Time) (string, []byte, error) {
+	request := exportRequest{
+		Format: export.GetString("format"),
+		From:   from,
+		To:     to,
+		Filter: exportFilter{ClockID: export.GetString("clock"), ProjectID: export.GetString("project")},
+	}
+	if err := validateExportFilter(app, request.Filter); err != nil {
+		return "", nil, err
+	}
+
+	var err error
+	switch request.Format {
+	case "payroll":
+		request.Profile, err = loadPayrollProfile(app, export.GetString("profile"))
+	case "template":
+		request.Template, err = loadExportTemplate(app, export.GetString("template"))
+	}
+	if err != nil {
+		return "", nil, err
+	}
+
+	var file bytes.Buffer
+	if err := request.stream(app, &file, func() error { return nil }); err != nil {
+		return "", nil, fmt.Errorf("failed to export sessions: %w", err)
+	}
+	return request.fileName(), file.Bytes(), nil
+}
+
+// sendScheduledExport emails the file of a scheduled export to its recipients.
+//
+// Parameters:
+// - app: The App interface providing the mail client
+// - export: The scheduled_exports record
+// - fileName: The name of the file
+// - file: The content of the file
+// - from: The start of the exported range (inclusive)
+// - to: The end of the exported range (exclusive)
+//
+// Returns:
+// - The recipients
+// - An error if the recipients are invalid or sending fails
+func sendScheduledExport(app core.App, export *core.Record, fileName string, file []byte, from, to time.Time) (string, error) {
+	recipients, err := mail.ParseAddressList(export.GetString("recipients"))
+	if err != nil {
+		return "", fmt.Errorf("invalid recipients: %w", err)
+	}
+
+	// The range is shown with its last day instead of its exclusive end
+	period := fmt.Sprintf("%s to %s", from.Format(time.DateOnly), to.AddDate(0, 0, -1).Format(time.DateOnly))
+
+	addresses := make([]mail.Address, 0, len(recipients))
+	for _, recipient := range recipients {
+		addresses = append(addresses, *recipient)
+	}
+
+	message := &mailer.Message{
+		From:        mail.Address{Address: app.Settings().Meta.SenderAddress, Name: app.Settings().Meta.SenderName},
+		To:          addresses,
+		Subject:     fmt.Sprintf("%s: %s", export.GetString("name"), period),
+		Text:        fmt.Sprintf("Attached is the scheduled export '%s' of the sessions from %s.", export.GetString("name"), period),
+		Attachments: map[string]io.Reader{fileName: bytes.NewReader(file)},
+	}
+	if err := app.NewMailClient().Send(message); err != nil {
+		return "", fmt.Errorf("failed to send email: %w", err)
+	}
+	return export.GetString("recipients"), nil
+}
+
+// saveScheduledExportRun records a run of a scheduled export.
+//
+// Parameters:
+// - app: The App interface (typically a PocketBase instance or transaction)
+// - run: The run, its ID is set once it is saved
+//
+// Returns:
+// - An error if the run could not be saved
+func saveScheduledExportRun(app core.App, run *ScheduledExportRun) error {
+	collection, err := app.FindCollectionByNameOrId("scheduled_export_runs")
+	if err != nil {
+		return fmt.Errorf("failed to find scheduled_export_runs collection: %w", err)
+	}
+
+	record := core.NewRecord(collection)
+	record.Set("export", run.Export)
+	record.Set("succeeded", run.Succeeded)
+	record.Set("manual", run.Manual)
+	record.Set("from", run.From)
+	record.Set("to", run.To)
+	record.Set("file_name", run.FileName)
+	record.Set("size", run.Size)
+	record.Set("delivery", run.Delivery)
+	record.Set("destination", run.Destination)
+	record.Set("error", run.Error)
+	record.Set("duration_ms", run.DurationMs)
+	if err := app.Save(record); err != nil {
+		return err
+	}
+
+	run.ID = record.Id
+	return nil
+}
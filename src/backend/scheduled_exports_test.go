@@ -0,0 +1,313 @@
+package backend
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"errors"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pkg/sftp"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tools/mailer"
+	"github.com/yerTools/simple-frontend-stack/src/backend/backendtest"
+	"golang.org/x/crypto/ssh"
+)
+
+func TestScheduledExports(t *testing.T) {
+	app := backendtest.NewApp(t)
+	RegisterScheduledExports(app)
+
+	var sent []*mailer.Message
+	var sendErr error
+	app.OnMailerSend().BindFunc(func(e *core.MailerEvent) error {
+		if sendErr != nil {
+			return sendErr
+		}
+		sent = append(sent, e.Message)
+		return nil
+	})
+
+	backendtest.AddRecords(t, app,
+		backendtest.ClockIn("2025-03-10T09:00:00Z"), backendtest.ClockOut("2025-03-10T17:00:00Z"),
+		backendtest.ClockIn("2025-04-01T09:00:00Z"), backendtest.ClockOut("2025-04-01T12:00:00Z"),
+	)
+
+	collection, err := app.FindCollectionByNameOrId("scheduled_exports")
+	if err != nil {
+		t.Fatalf("failed to find scheduled_exports collection: %v", err)
+	}
+	export := core.NewRecord(collection)
+	export.Load(map[string]any{
+		"name":       "Payroll",
+		"schedule":   "0 6 1 * *",
+		"period":     "previous_month",
+		"format":     "csv",
+		"recipients": "payroll@example.com, Accounting <accounting@example.com>",
+		"enabled":    true,
+	})
+	if err := app.Save(export); err != nil {
+		t.Fatalf("failed to save scheduled export: %v", err)
+	}
+
+	// The export runs on the 1st at 6:00 with the sessions of the previous month
+	runDueScheduledExports(app, time.Date(2025, 4, 1, 5, 59, 0, 0, time.Local))
+	if len(sent) != 0 {
+		t.Fatalf("expected no export before the schedule is due, got %d", len(sent))
+	}
+	runDueScheduledExports(app, time.Date(2025, 4, 1, 6, 0, 0, 0, time.Local))
+	if len(sent) != 1 {
+		t.Fatalf("expected one sent export, got %d", len(sent))
+	}
+	if len(sent[0].To) != 2 || sent[0].To[1].Address != "accounting@example.com" {
+		t.Errorf("expected the export to be sent to both recipients, got %v", sent[0].To)
+	}
+	attachment, ok := sent[0].Attachments["work_clock_2025-03.csv"]
+	if !ok {
+		t.Fatalf("expected the CSV of March as attachment, got %v", sent[0].Attachments)
+	}
+	file, _ := io.ReadAll(attachment)
+	if lines := strings.Split(strings.TrimSpace(string(file)), "\n"); len(lines) != 2 {
+		t.Errorf("expected the header and the session of March, got %q", file)
+	}
+
+	runs, err := app.FindRecordsByFilter("scheduled_export_runs", "export = {:export}", "+created", 0, 0, map[string]any{"export": export.Id})
+	if err != nil || len(runs) != 1 || !runs[0].GetBool("succeeded") || runs[0].GetString("file_name") != "work_clock_2025-03.csv" {
+		t.Fatalf("expected one successful run, got %v: %v", runs, err)
+	}
+
+	// Failures are recorded with the run and as event
+	sendErr = errors.New("connection refused")
+	run, err := runScheduledExport(app, export, time.Date(2025, 5, 1, 6, 0, 0, 0, time.Local), true)
+	if err == nil || run == nil || run.Succeeded || !run.Manual || !strings.Contains(run.Error, "connection refused") {
+		t.Fatalf("expected a failed manual run, got %+v: %v", run, err)
+	}
+	if events, err := app.FindRecordsByFilter("events", "type = 'scheduled_export_failed'", "", 0, 0); err != nil || len(events) != 1 {
+		t.Errorf("expected a scheduled_export_failed event, got %d: %v", len(events), err)
+	}
+
+	export.Set("schedule", "every monday")
+	if err := app.Save(export); err == nil {
+		t.Error("expected an invalid schedule to be rejected")
+	}
+}
+
+func TestScheduledExportDelivery(t *testing.T) {
+	originalSettings := settings
+	t.Cleanup(func() { settings = originalSettings })
+	settings.ExportSMBDirectory = t.TempDir()
+	settings.ExportSFTPAddress = ""
+
+	app := backendtest.NewApp(t)
+	RegisterScheduledExports(app)
+
+	backendtest.AddRecords(t, app,
+		backendtest.ClockIn("2025-03-10T09:00:00Z"), backendtest.ClockOut("2025-03-10T17:00:00Z"),
+	)
+
+	collection, err := app.FindCollectionByNameOrId("scheduled_exports")
+	if err != nil {
+		t.Fatalf("failed to find scheduled_exports collection: %v", err)
+	}
+	newExport := func(delivery, directory string) *core.Record {
+		export := core.NewRecord(collection)
+		export.Load(map[string]any{
+			"name":      "Payroll intake",
+			"schedule":  "0 6 1 * *",
+			"period":    "previous_month",
+			"format":    "csv",
+			"delivery":  delivery,
+			"directory": directory,
+			"enabled":   true,
+		})
+		return export
+	}
+
+	if err := app.Save(newExport("email", "")); err == nil {
+		t.Error("expected an email delivery without recipients to be rejected")
+	}
+	if err := app.Save(newExport("sftp", "intake")); err == nil {
+		t.Error("expected an unconfigured SFTP delivery to be rejected")
+	}
+	if err := app.Save(newExport("smb", "../intake")); err == nil {
+		t.Error("expected a directory outside of the share to be rejected")
+	}
+
+	export := newExport("smb", "payroll/intake")
+	if err := app.Save(export); err != nil {
+		t.Fatalf("failed to save scheduled export: %v", err)
+	}
+
+	// The run fails until the directory exists on the share
+	run, err := runScheduledExport(app, export, time.Date(2025, 4, 1, 6, 0, 0, 0, time.Local), false)
+	if err == nil || run == nil || run.Succeeded || run.Delivery != "smb" || run.Destination != "" {
+		t.Fatalf("expected a failed run without the directory, got %+v: %v", run, err)
+	}
+
+	directory := filepath.Join(settings.ExportSMBDirectory, "payroll", "intake")
+	if err := os.MkdirAll(directory, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	// A rerun replaces the file of the previous run
+	if err := os.WriteFile(filepath.Join(directory, "work_clock_2025-03.csv"), []byte("outdated"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	run, err = runScheduledExport(app, export, time.Date(2025, 4, 1, 6, 0, 0, 0, time.Local), false)
+	if err != nil || !run.Succeeded {
+		t.Fatalf("expected a successful run, got %+v: %v", run, err)
+	}
+	if want := filepath.Join(directory, "work_clock_2025-03.csv"); run.Destination != want {
+		t.Errorf("expected destination %q, got %q", want, run.Destination)
+	}
+
+	file, err := os.ReadFile(filepath.Join(directory, "work_clock_2025-03.csv"))
+	if err != nil || !strings.Contains(string(file), "2025-03-10T09:00:00Z") {
+		t.Errorf("expected the exported sessions on the share, got %q: %v", file, err)
+	}
+	if entries, _ := os.ReadDir(directory); len(entries) != 1 {
+		t.Errorf("expected only the delivered file on the share, got %v", entries)
+	}
+
+	record, err := app.FindRecordById("scheduled_export_runs", run.ID)
+	if err != nil || record.GetString("delivery") != "smb" || record.GetString("destination") != run.Destination {
+		t.Errorf("expected the delivery to be recorded, got %v: %v", record, err)
+	}
+}
+
+// startSFTPTestServer starts an SFTP server keeping its files in memory, accepting the password
+// "secret", and configures it as delivery of scheduled exports.
+func startSFTPTestServer(t *testing.T) {
+	t.Helper()
+
+	_, hostKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signer, err := ssh.NewSignerFromKey(hostKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	config := &ssh.ServerConfig{
+		PasswordCallback: func(_ ssh.ConnMetadata, password []byte) (*ssh.Permissions, error) {
+			if string(password) != "secret" {
+				return nil, errors.New("invalid password")
+			}
+			return nil, nil
+		},
+	}
+	config.AddHostKey(signer)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	handlers := sftp.InMemHandler()
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				_, channels, requests, err := ssh.NewServerConn(conn, config)
+				if err != nil {
+					return
+				}
+				go ssh.DiscardRequests(requests)
+
+				for newChannel := range channels {
+					channel, channelRequests, err := newChannel.Accept()
+					if err != nil {
+						continue
+					}
+					go func() {
+						for request := range channelRequests {
+							request.Reply(request.Type == "subsystem", nil)
+							if request.Type == "subsystem" {
+								server := sftp.NewRequestServer(channel, handlers)
+								go func() {
+									server.Serve()
+									server.Close()
+								}()
+							}
+						}
+					}()
+				}
+			}()
+		}
+	}()
+
+	settings.ExportSFTPAddress = listener.Addr().String()
+	settings.ExportSFTPUser = "payroll"
+	settings.ExportSFTPPassword = "secret"
+	settings.ExportSFTPKeyFile = ""
+	settings.ExportSFTPHostKey = string(ssh.MarshalAuthorizedKey(signer.PublicKey()))
+}
+
+func TestScheduledExportSFTPDelivery(t *testing.T) {
+	originalSettings := settings
+	t.Cleanup(func() { settings = originalSettings })
+	startSFTPTestServer(t)
+
+	app := backendtest.NewApp(t)
+
+	collection, err := app.FindCollectionByNameOrId("scheduled_exports")
+	if err != nil {
+		t.Fatalf("failed to find scheduled_exports collection: %v", err)
+	}
+	export := core.NewRecord(collection)
+	export.Set("directory", "/")
+
+	// readFile reads a file back from the server
+	readFile := func(name string) (string, error) {
+		conn, err := dialExportSFTP()
+		if err != nil {
+			return "", err
+		}
+		defer conn.Close()
+		client, err := sftp.NewClient(conn)
+		if err != nil {
+			return "", err
+		}
+		defer client.Close()
+
+		file, err := client.Open(name)
+		if err != nil {
+			return "", err
+		}
+		defer file.Close()
+		content, err := io.ReadAll(file)
+		return string(content), err
+	}
+
+	// The content spans multiple packets, and a rerun replaces the file of the previous run
+	for _, content := range []string{"outdated", strings.Repeat("2025-03-10;8:00\n", 5000)} {
+		destination, err := uploadScheduledExport(app, export, "work_clock_2025-03.csv", []byte(content), time.Time{}, time.Time{})
+		if err != nil {
+			t.Fatalf("failed to upload export: %v", err)
+		}
+		if !strings.HasPrefix(destination, "sftp://payroll@127.0.0.1:") || !strings.HasSuffix(destination, "/work_clock_2025-03.csv") {
+			t.Errorf("expected the URL of the uploaded file, got %q", destination)
+		}
+		if uploaded, err := readFile("/work_clock_2025-03.csv"); err != nil || uploaded != content {
+			t.Errorf("expected the uploaded content of %d bytes, got %d bytes: %v", len(content), len(uploaded), err)
+		}
+	}
+	if _, err := readFile("/work_clock_2025-03.csv.part"); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("expected the partial file to be renamed, got %v", err)
+	}
+
+	settings.ExportSFTPPassword = "wrong"
+	if _, err := uploadScheduledExport(app, export, "work_clock_2025-03.csv", []byte("denied"), time.Time{}, time.Time{}); err == nil {
+		t.Error("expected a wrong password to be rejected")
+	}
+}
@@ -0,0 +1,296 @@
+// Search Module for PocketBase
+//
+// This module searches everything the user wrote: day notes, session descriptions and the names
+// of projects and tags, e.g. to find the day a server was migrated or every session spent on a
+// customer. The texts are indexed in the search_index SQLite FTS5 table, which is kept up to date
+// by hooks on the day_notes, work_clock, projects and tags collections.
+//
+// Each word of the query has to occur in a text, a word also matches longer words starting with
+// it. Quotes group words to a phrase, e.g. '"release notes" draft'. Results are ordered by
+// relevance.
+package backend
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+const (
+	// defaultSearchLimit is the number of results returned without 'limit' parameter
+	defaultSearchLimit = 20
+
+	// maxSearchLimit is the maximum number of results returned at once
+	maxSearchLimit = 100
+)
+
+var (
+	// searchKinds are the kinds of indexed records
+	searchKinds = []string{"note", "session", "project", "tag"}
+
+	// journalSearchKinds are the kinds of indexed records searched by the journal search
+	journalSearchKinds = []string{"note", "session"}
+)
+
+// SearchResult is an indexed record matching a search.
+type SearchResult struct {
+	Kind    string     `json:"kind"`            // 'note', 'session', 'project' or 'tag'
+	ID      string     `json:"id"`              // ID of the day_notes, clock in, projects or tags record
+	Date    string     `json:"date,omitempty"`  // Day of the note or session start (YYYY-MM-DD), session starts in the requested timezone, omitted for projects and tags
+	Start   *time.Time `json:"start,omitempty"` // Start of the session, omitted for other kinds
+	Snippet string     `json:"snippet"`         // Excerpt of the text around the matches
+}
+
+// searchIndexRow is a row of the search_index table as returned by the search query.
+type searchIndexRow struct {
+	Kind    string `db:"kind"`
+	Record  string `db:"record"`
+	Time    string `db:"time"`
+	Snippet string `db:"snippet"`
+}
+
+// RegisterSearchAPI registers the search hooks and endpoints with the PocketBase server.
+// It creates the following routes:
+// - GET /api/search?q=&kinds=&limit=&timezone=&clock= - Searches all indexed records for 'q', optionally only the comma separated 'kinds'
+// - GET /api/journal/search?q=&limit=&timezone=&clock= - Searches the day notes and session descriptions for 'q'
+//
+// Parameters:
+// - app: The PocketBase application instance
+func RegisterSearchAPI(app core.App) {
+	index := func(kind string, recordTime func(record *core.Record) string, text func(record *core.Record) string) func(e *core.RecordEvent) error {
+		return func(e *core.RecordEvent) error {
+			if err := updateSearchIndex(e.App, kind, e.Record, recordTime(e.Record), text(e.Record)); err != nil {
+				// The record change itself succeeded, the search just misses it
+				e.App.Logger().Error("failed to update search index", "record", e.Record.Id, "error", err)
+			}
+			return e.Next()
+		}
+	}
+
+	unindex := func(e *core.RecordEvent) error {
+		if err := updateSearchIndex(e.App, "", e.Record, "", ""); err != nil {
+			e.App.Logger().Error("failed to update search index", "record", e.Record.Id, "error", err)
+		}
+		return e.Next()
+	}
+
+	noTime := func(record *core.Record) string { return "" }
+	field := func(name string) func(record *core.Record) string {
+		return func(record *core.Record) string { return record.GetString(name) }
+	}
+
+	indexNote := index("note", field("date"), field("note"))
+	indexProject := index("project", noTime, field("name"))
+	indexTag := index("tag", noTime, field("name"))
+	indexSession := index("session", field("timestamp"), func(record *core.Record) string {
+		if !record.GetBool("clock_in") {
+			return ""
+		}
+		return record.GetString("description")
+	})
+
+	for collection, handler := range map[string]func(e *core.RecordEvent) error{
+		"day_notes":  indexNote,
+		"work_clock": indexSession,
+		"projects":   indexProject,
+		"tags":       indexTag,
+	} {
+		app.OnRecordAfterCreateSuccess(collection).BindFunc(handler)
+		app.OnRecordAfterUpdateSuccess(collection).BindFunc(handler)
+		app.OnRecordAfterDeleteSuccess(collection).BindFunc(unindex)
+	}
+
+	app.OnServe().BindFunc(func(se *core.ServeEvent) error {
+		se.Router.GET("/api/search", func(e *core.RequestEvent) error {
+			kinds := searchKinds
+			if kindsValue := e.Request.URL.Query().Get("kinds"); kindsValue != "" {
+				kinds = nil
+				for _, kind := range strings.Split(kindsValue, ",") {
+					kind = strings.TrimSpace(kind)
+					if !slices.Contains(searchKinds, kind) {
+						return e.Error(http.StatusBadRequest, fmt.Sprintf("Invalid 'kinds' value '%s'. Expected one of: %s", kind, strings.Join(searchKinds, ", ")), nil)
+					}
+					kinds = append(kinds, kind)
+				}
+			}
+
+			return respondSearch(app, e, kinds)
+		})
+
+		se.Router.GET("/api/journal/search", func(e *core.RequestEvent) error {
+			return respondSearch(app, e, journalSearchKinds)
+		})
+
+		return se.Next()
+	})
+}
+
+// respondSearch parses the search parameters of a request, searches and writes the results as
+// JSON response.
+//
+// Parameters:
+// - app: The App interface (typically a PocketBase instance or transaction)
+// - e: The RequestEvent from the HTTP handler
+// - kinds: The kinds of records to search
+//
+// Returns:
+// - An error if a parameter is invalid or the search fails
+func respondSearch(app core.App, e *core.RequestEvent, kinds []string) error {
+	query := e.Request.URL.Query()
+
+	match := searchMatch(query.Get("q"))
+	if match == "" {
+		return e.Error(http.StatusBadRequest, "Missing 'q' (string) parameter", nil)
+	}
+
+	limit := defaultSearchLimit
+	if limitValue := query.Get("limit"); limitValue != "" {
+		var err error
+		limit, err = strconv.Atoi(limitValue)
+		if err != nil || limit < 1 || limit > maxSearchLimit {
+			return e.Error(http.StatusBadRequest, fmt.Sprintf("Invalid 'limit' (integer) parameter. Expected a value between 1 and %d", maxSearchLimit), nil)
+		}
+	}
+
+	location := time.Local
+	if timezone := query.Get("timezone"); timezone != "" {
+		var err error
+		location, err = time.LoadLocation(timezone)
+		if err != nil {
+			return e.Error(http.StatusBadRequest, fmt.Sprintf("Invalid 'timezone' value '%s'", timezone), nil)
+		}
+	}
+
+	clockID, err := requestClock(app, e)
+	if err != nil {
+		return err
+	}
+
+	results, err := search(app, clockID, match, kinds, limit, location)
+	if err != nil {
+		return e.Error(http.StatusInternalServerError, fmt.Sprintf("Failed to search: %v", err), err)
+	}
+
+	return e.JSON(http.StatusOK, results)
+}
+
+// updateSearchIndex replaces the indexed text of a record.
+//
+// Parameters:
+// - app: The App interface (typically a PocketBase instance or transaction)
+// - kind: The kind of the record ('note', 'session', 'project' or 'tag')
+// - record: The day_notes, clock in, projects or tags record
+// - recordTime: The day of a note (YYYY-MM-DD), the timestamp of a clock in record, or empty
+// - text: The text to index, an empty text removes the record from the index
+//
+// Returns:
+// - An error if the index could not be updated
+func updateSearchIndex(app core.App, kind string, record *core.Record, recordTime string, text string) error {
+	if _, err := app.DB().NewQuery("DELETE FROM `search_index` WHERE `record` = {:record}").
+		Bind(dbx.Params{"record": record.Id}).Execute(); err != nil {
+		return fmt.Errorf("failed to remove record '%s' from search index: %w", record.Id, err)
+	}
+
+	if text == "" {
+		return nil
+	}
+
+	if _, err := app.DB().NewQuery("INSERT INTO `search_index` (`kind`, `record`, `clock`, `time`, `text`) " +
+		"VALUES ({:kind}, {:record}, {:clock}, {:time}, {:text})").Bind(dbx.Params{
+		"kind":   kind,
+		"record": record.Id,
+		"clock":  record.GetString("clock"),
+		"time":   recordTime,
+		"text":   text,
+	}).Execute(); err != nil {
+		return fmt.Errorf("failed to add record '%s' to search index: %w", record.Id, err)
+	}
+	return nil
+}
+
+// searchMatch converts a search query to an FTS5 match expression. Every word and quoted phrase
+// is quoted, so the query can't use the FTS5 syntax and never fails to parse, and words match as
+// prefix.
+//
+// Parameters:
+// - query: The search query
+//
+// Returns:
+// - The match expression, empty if the query contains no words
+func searchMatch(query string) string {
+	var terms []string
+	for i, part := range strings.Split(query, `"`) {
+		if i%2 == 1 {
+			// Quoted phrase
+			if phrase := strings.Join(strings.Fields(part), " "); phrase != "" {
+				terms = append(terms, `"`+phrase+`"`)
+			}
+			continue
+		}
+		for _, word := range strings.Fields(part) {
+			terms = append(terms, `"`+word+`"*`)
+		}
+	}
+	return strings.Join(terms, " ")
+}
+
+// search searches the indexed records of a clock. Projects and tags are shared by all clocks.
+//
+// Parameters:
+// - app: The App interface (typically a PocketBase instance or transaction)
+// - clockID: The ID of the clock, an empty string for the default clock
+// - match: The FTS5 match expression, see searchMatch
+// - kinds: The kinds of records to search
+// - limit: The maximum number of results
+// - location: The timezone used for the dates of sessions
+//
+// Returns:
+// - The results, most relevant first
+// - An error if the search fails
+func search(app core.App, clockID string, match string, kinds []string, limit int, location *time.Location) ([]SearchResult, error) {
+	params := dbx.Params{
+		"match": match,
+		"clock": clockID,
+		"limit": limit,
+	}
+	placeholders := make([]string, len(kinds))
+	for i, kind := range kinds {
+		name := "kind" + strconv.Itoa(i)
+		placeholders[i] = "{:" + name + "}"
+		params[name] = kind
+	}
+
+	var rows []searchIndexRow
+	err := app.DB().NewQuery("SELECT `kind`, `record`, `time`, snippet(`search_index`, 4, '', '', '…', 16) AS `snippet` " +
+		"FROM `search_index` WHERE `search_index` MATCH {:match} " +
+		"AND `kind` IN (" + strings.Join(placeholders, ", ") + ") " +
+		"AND (`clock` = {:clock} OR `kind` IN ('project', 'tag')) " +
+		"ORDER BY rank LIMIT {:limit}").Bind(params).All(&rows)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query search index: %w", err)
+	}
+
+	results := make([]SearchResult, 0, len(rows))
+	for _, row := range rows {
+		result := SearchResult{Kind: row.Kind, ID: row.Record, Date: row.Time, Snippet: row.Snippet}
+		if row.Kind == "session" {
+			start, err := types.ParseDateTime(row.Time)
+			if err != nil {
+				return nil, fmt.Errorf("invalid timestamp of session '%s' in search index: %w", row.Record, err)
+			}
+			startTime := start.Time()
+			result.Start = &startTime
+			result.Date = startTime.In(location).Format(time.DateOnly)
+		}
+		results = append(results, result)
+	}
+
+	return results, nil
+}
@@ -0,0 +1,461 @@
+// Settings Module for PocketBase
+//
+// This module centralizes the runtime configuration of the backend modules. All settings
+// are read from environment variables once at startup. Missing or invalid values fall back
+// to sensible defaults, so the application can be started without any configuration at all.
+package backend
+
+import (
+	"cmp"
+	"maps"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Settings contains the runtime configuration of the backend modules.
+type Settings struct {
+	// MaxFutureOffset is the maximum duration a manually entered timestamp may lie in the future.
+	// Timestamps further in the future are rejected unless a superuser explicitly overrides the check.
+	// Configured via WORK_CLOCK_MAX_FUTURE_OFFSET (e.g. "5m", "1h").
+	MaxFutureOffset time.Duration
+
+	// MaxSessionDuration is the maximum length of a session that can be closed by a plain clock out.
+	// Longer sessions must be confirmed or closed with an explicit end time. A value of 0 disables the cap.
+	// Configured via WORK_CLOCK_MAX_SESSION_DURATION (e.g. "16h").
+	MaxSessionDuration time.Duration
+
+	// WorkdayDuration is the length of a regular workday. Open sessions that are longer are
+	// reported as stale by the status endpoint. A value of 0 disables the detection.
+	// It is also the target of Mondays to Fridays before the first work schedule of a clock.
+	// Configured via WORK_CLOCK_WORKDAY_DURATION (e.g. "8h").
+	WorkdayDuration time.Duration
+
+	// WebhookURLs are the URLs all webhook events are delivered to, unless the webhooks
+	// integration is configured at runtime. Configured via WEBHOOK_URLS as a comma separated list.
+	WebhookURLs []string
+
+	// ValidateIssues enables the format validation of issue references linked to sessions.
+	// Configured via WORK_CLOCK_VALIDATE_ISSUES ("true" or "false").
+	ValidateIssues bool
+
+	// CalendarICSURL is the iCalendar URL meetings are imported from, unless the calendar
+	// integration is configured at runtime. Configured via CALENDAR_ICS_URL, the import is disabled if unset.
+	CalendarICSURL string
+
+	// EmailGatewaySecret is the secret part of the inbound email webhook URL.
+	// Configured via EMAIL_GATEWAY_SECRET, the email gateway is disabled if unset.
+	EmailGatewaySecret string
+
+	// EmailAllowedSenders are the email addresses allowed to use the email gateway.
+	// Configured via EMAIL_ALLOWED_SENDERS as a comma separated list.
+	EmailAllowedSenders []string
+
+	// ExportSigningKeyFile is the PEM file holding the Ed25519 key signed exports are signed with.
+	// Configured via EXPORT_SIGNING_KEY_FILE, a key in the data directory is generated if unset.
+	ExportSigningKeyFile string
+
+	// DefaultLocale is the locale of responses to clients without a preferred supported language.
+	// Configured via DEFAULT_LOCALE (e.g. "de"), English is used if unset or unsupported.
+	DefaultLocale string
+
+	// DurationFormat is the format of the formatted durations in API responses: 'hours_minutes'
+	// (7:30), 'decimal' (7.50) or 'units' (7h30m). Configured via DURATION_FORMAT.
+	DurationFormat string
+
+	// OnCallFactor is the share of on-call sessions that counts as work time in percent.
+	// Configured via ON_CALL_FACTOR (e.g. "25"), on-call sessions count completely if unset.
+	OnCallFactor float64
+
+	// TravelFactor is the share of travel sessions that counts as work time in percent.
+	// Configured via TRAVEL_FACTOR (e.g. "50"), travel sessions count completely if unset.
+	TravelFactor float64
+
+	// BadgeToken protects the status badge, which then has to be requested with it as 'token'.
+	// Configured via BADGE_TOKEN, the badge is public if unset.
+	BadgeToken string
+
+	// FeedToken protects the daily summary feed, which then has to be requested with it as 'token'.
+	// Configured via FEED_TOKEN, the feed is public if unset.
+	FeedToken string
+
+	// WeekStart is the first day of the week used by all weekly aggregations.
+	// Configured via WEEK_START ("monday", "sunday" or "saturday"), weeks start on Monday if unset.
+	WeekStart time.Weekday
+
+	// FiscalYearStart is the first month of the fiscal year used by all yearly aggregations.
+	// Configured via FISCAL_YEAR_START (e.g. "4" for April), years start in January if unset.
+	FiscalYearStart time.Month
+
+	// VacationCarryOverLimit is the maximum number of remaining vacation days carried over into the
+	// next fiscal year. Configured via VACATION_CARRY_OVER_LIMIT (e.g. "5"), all days are carried over if unset (-1).
+	VacationCarryOverLimit float64
+
+	// VacationCarryOverExpiry is the day of the fiscal year (MM-DD) the days carried over from the
+	// previous fiscal year expire if they were not used until then. Configured via VACATION_CARRY_OVER_EXPIRY
+	// (e.g. "03-31"), carried over days never expire if unset.
+	VacationCarryOverExpiry string
+
+	// TracingEndpoint is the base URL of the OTLP/HTTP receiver traces are exported to.
+	// Configured via OTEL_EXPORTER_OTLP_ENDPOINT (e.g. "http://otel-collector:4318"), tracing is disabled if unset.
+	TracingEndpoint string
+
+	// TracingServiceName is the service name the traces are exported with.
+	// Configured via OTEL_SERVICE_NAME, "sfs-work-clock" if unset.
+	TracingServiceName string
+
+	// OutboundProxy is the URL of the proxy all outbound integrations connect through.
+	// Configured via OUTBOUND_PROXY (e.g. "http://proxy.corp:3128"), the standard HTTP_PROXY,
+	// HTTPS_PROXY and NO_PROXY variables are used if unset.
+	OutboundProxy string
+
+	// OutboundCAFile is a PEM bundle of certificate authorities trusted by all outbound integrations
+	// in addition to the system certificates, e.g. of a proxy intercepting TLS.
+	// Configured via OUTBOUND_CA_FILE, only the system certificates are trusted if unset.
+	OutboundCAFile string
+
+	// ImportMaxBodySize is the maximum size of a request to the import endpoints in bytes.
+	// Configured via IMPORT_MAX_BODY_SIZE (e.g. "200MB"), see the request limits module.
+	ImportMaxBodySize int64
+
+	// ImportTimeout is the maximum duration of a request to the import endpoints.
+	// Configured via IMPORT_TIMEOUT (e.g. "10m"), a value of 0 disables the timeout.
+	ImportTimeout time.Duration
+
+	// ClockMaxBodySize is the maximum size of a request to the clock endpoints in bytes.
+	// Configured via CLOCK_MAX_BODY_SIZE (e.g. "1MB"), see the request limits module.
+	ClockMaxBodySize int64
+
+	// ClockTimeout is the maximum duration of a request to the clock endpoints.
+	// Configured via CLOCK_TIMEOUT (e.g. "30s"), a value of 0 disables the timeout.
+	ClockTimeout time.Duration
+
+	// ShutdownGracePeriod is the maximum duration the shutdown waits for running imports, scheduled
+	// jobs and deliveries. It should be shorter than the time the container runtime waits before
+	// killing the process. Configured via SHUTDOWN_GRACE_PERIOD (e.g. "25s").
+	ShutdownGracePeriod time.Duration
+
+	// DemoClockSpeed enables the demo mode, in which the time starts at the startup and passes the
+	// given number of times faster than real time, see the clock source module.
+	// Configured via DEMO_CLOCK_SPEED (e.g. "60" for an hour per minute), the real time is used if unset.
+	DemoClockSpeed float64
+
+	// ExportSFTPAddress is the address of the SFTP server scheduled exports can be delivered to.
+	// Configured via EXPORT_SFTP_ADDRESS (e.g. "payroll.corp:22"), the SFTP delivery is disabled if unset.
+	ExportSFTPAddress string
+
+	// ExportSFTPUser is the user scheduled exports are delivered to the SFTP server as.
+	// Configured via EXPORT_SFTP_USER.
+	ExportSFTPUser string
+
+	// ExportSFTPPassword is the password of the SFTP user.
+	// Configured via EXPORT_SFTP_PASSWORD, only the key is used if unset.
+	ExportSFTPPassword string
+
+	// ExportSFTPKeyFile is the PEM file holding the private key of the SFTP user.
+	// Configured via EXPORT_SFTP_KEY_FILE, only the password is used if unset.
+	ExportSFTPKeyFile string
+
+	// ExportSFTPHostKey is the public key of the SFTP server in the authorized_keys format, e.g. the
+	// output of ssh-keyscan without the host name. Connections to servers with another key are refused.
+	// Configured via EXPORT_SFTP_HOST_KEY, which is required for the SFTP delivery.
+	ExportSFTPHostKey string
+
+	// ExportSMBDirectory is the directory the SMB share for scheduled exports is mounted at, e.g. with
+	// mount.cifs or as volume of the container. Configured via EXPORT_SMB_DIRECTORY, the SMB
+	// delivery is disabled if unset.
+	ExportSMBDirectory string
+}
+
+// weekStartDays are the supported first days of the week.
+var weekStartDays = map[string]time.Weekday{
+	"monday":   time.Monday,
+	"sunday":   time.Sunday,
+	"saturday": time.Saturday,
+}
+
+// settings holds the configuration loaded at startup and is used by all backend modules.
+// settingsError describes all invalid values, the affected settings keep their defaults.
+var settings, settingsError = LoadSettings()
+
+// LoadSettings reads the backend settings from the config file and the environment.
+// Environment variables override the values of the config file, see the config file module.
+//
+// Returns:
+// - The loaded settings, with defaults applied for all unset or invalid values
+// - An error listing all invalid values and unknown config file keys, nil if the configuration is valid
+func LoadSettings() (Settings, error) {
+	loader := newSettingsLoader()
+
+	loaded := Settings{
+		MaxFutureOffset:         loader.duration("WORK_CLOCK_MAX_FUTURE_OFFSET", 5*time.Minute),
+		MaxSessionDuration:      loader.duration("WORK_CLOCK_MAX_SESSION_DURATION", 16*time.Hour),
+		WorkdayDuration:         loader.duration("WORK_CLOCK_WORKDAY_DURATION", 8*time.Hour),
+		WebhookURLs:             loader.list("WEBHOOK_URLS"),
+		ValidateIssues:          loader.bool("WORK_CLOCK_VALIDATE_ISSUES", false),
+		CalendarICSURL:          loader.string("CALENDAR_ICS_URL"),
+		EmailGatewaySecret:      loader.string("EMAIL_GATEWAY_SECRET"),
+		EmailAllowedSenders:     loader.list("EMAIL_ALLOWED_SENDERS"),
+		ExportSigningKeyFile:    loader.string("EXPORT_SIGNING_KEY_FILE"),
+		DefaultLocale:           strings.ToLower(loader.string("DEFAULT_LOCALE")),
+		DurationFormat:          loader.choice("DURATION_FORMAT", "hours_minutes", durationFormats),
+		OnCallFactor:            loader.percent("ON_CALL_FACTOR", 100),
+		TravelFactor:            loader.percent("TRAVEL_FACTOR", 100),
+		BadgeToken:              loader.string("BADGE_TOKEN"),
+		FeedToken:               loader.string("FEED_TOKEN"),
+		WeekStart:               weekStartDays[loader.choice("WEEK_START", "monday", slices.Sorted(maps.Keys(weekStartDays)))],
+		FiscalYearStart:         loader.month("FISCAL_YEAR_START", time.January),
+		VacationCarryOverLimit:  loader.number("VACATION_CARRY_OVER_LIMIT", -1),
+		VacationCarryOverExpiry: loader.monthDay("VACATION_CARRY_OVER_EXPIRY"),
+		TracingEndpoint:         loader.string("OTEL_EXPORTER_OTLP_ENDPOINT"),
+		TracingServiceName:      cmp.Or(loader.string("OTEL_SERVICE_NAME"), "sfs-work-clock"),
+		OutboundProxy:           loader.string("OUTBOUND_PROXY"),
+		OutboundCAFile:          loader.string("OUTBOUND_CA_FILE"),
+		ImportMaxBodySize:       loader.byteSize("IMPORT_MAX_BODY_SIZE", 200<<20),
+		ImportTimeout:           loader.duration("IMPORT_TIMEOUT", 10*time.Minute),
+		ClockMaxBodySize:        loader.byteSize("CLOCK_MAX_BODY_SIZE", 1<<20),
+		ClockTimeout:            loader.duration("CLOCK_TIMEOUT", 30*time.Second),
+		ShutdownGracePeriod:     loader.duration("SHUTDOWN_GRACE_PERIOD", 25*time.Second),
+		DemoClockSpeed:          loader.number("DEMO_CLOCK_SPEED", 0),
+		ExportSFTPAddress:       loader.string("EXPORT_SFTP_ADDRESS"),
+		ExportSFTPUser:          loader.string("EXPORT_SFTP_USER"),
+		ExportSFTPPassword:      loader.string("EXPORT_SFTP_PASSWORD"),
+		ExportSFTPKeyFile:       loader.string("EXPORT_SFTP_KEY_FILE"),
+		ExportSFTPHostKey:       loader.string("EXPORT_SFTP_HOST_KEY"),
+		ExportSMBDirectory:      loader.string("EXPORT_SMB_DIRECTORY"),
+	}
+
+	return loaded, loader.finish()
+}
+
+// byteSizeUnits are the supported units of byte sizes, longest suffix first.
+var byteSizeUnits = []struct {
+	suffix string
+	factor int64
+}{{"GB", 1 << 30}, {"MB", 1 << 20}, {"KB", 1 << 10}, {"B", 1}}
+
+// string reads a trimmed string setting.
+//
+// Parameters:
+// - name: The name of the setting
+//
+// Returns:
+// - The value or an empty string if the setting is unset
+func (l *settingsLoader) string(name string) string {
+	value, _ := l.lookup(name)
+	return value
+}
+
+// duration reads a duration setting like "8h".
+//
+// Parameters:
+// - name: The name of the setting
+// - fallback: The value to use if the setting is unset or invalid
+//
+// Returns:
+// - The parsed duration or the fallback value
+func (l *settingsLoader) duration(name string, fallback time.Duration) time.Duration {
+	value, ok := l.lookup(name)
+	if !ok {
+		return fallback
+	}
+
+	duration, err := time.ParseDuration(value)
+	if err != nil || duration < 0 {
+		l.invalid(name, value, "a non-negative duration like 30s or 8h")
+		return fallback
+	}
+
+	return duration
+}
+
+// bool reads a boolean setting.
+//
+// Parameters:
+// - name: The name of the setting
+// - fallback: The value to use if the setting is unset or invalid
+//
+// Returns:
+// - The parsed boolean or the fallback value
+func (l *settingsLoader) bool(name string, fallback bool) bool {
+	value, ok := l.lookup(name)
+	if !ok {
+		return fallback
+	}
+
+	boolValue, err := strconv.ParseBool(value)
+	if err != nil {
+		l.invalid(name, value, "true or false")
+		return fallback
+	}
+
+	return boolValue
+}
+
+// percent reads a percentage setting like "25" or "25%".
+//
+// Parameters:
+// - name: The name of the setting
+// - fallback: The value to use if the setting is unset or invalid
+//
+// Returns:
+// - The parsed percentage or the fallback value
+func (l *settingsLoader) percent(name string, fallback float64) float64 {
+	value, ok := l.lookup(name)
+	if !ok {
+		return fallback
+	}
+
+	percent, err := strconv.ParseFloat(strings.TrimSpace(strings.TrimSuffix(value, "%")), 64)
+	if err != nil || percent < 0 {
+		l.invalid(name, value, "a non-negative percentage like 25")
+		return fallback
+	}
+
+	return percent
+}
+
+// number reads a non-negative number setting like "5" or "2.5".
+//
+// Parameters:
+// - name: The name of the setting
+// - fallback: The value to use if the setting is unset or invalid
+//
+// Returns:
+// - The parsed number or the fallback value
+func (l *settingsLoader) number(name string, fallback float64) float64 {
+	value, ok := l.lookup(name)
+	if !ok {
+		return fallback
+	}
+
+	number, err := strconv.ParseFloat(value, 64)
+	if err != nil || number < 0 {
+		l.invalid(name, value, "a non-negative number like 5")
+		return fallback
+	}
+
+	return number
+}
+
+// monthDay reads a day of the year setting like "03-31".
+//
+// Parameters:
+// - name: The name of the setting
+//
+// Returns:
+// - The day (MM-DD) or an empty string if the setting is unset or invalid
+func (l *settingsLoader) monthDay(name string) string {
+	value, ok := l.lookup(name)
+	if !ok {
+		return ""
+	}
+
+	// A leap year accepts February 29, which then falls back to March 1 in other years
+	if _, err := time.Parse("2006-01-02", "2024-"+value); err != nil {
+		l.invalid(name, value, "a day of the year like 03-31")
+		return ""
+	}
+
+	return value
+}
+
+// byteSize reads a byte size setting like "50MB".
+// The units KB, MB and GB are multiples of 1024, a plain number is a number of bytes.
+//
+// Parameters:
+// - name: The name of the setting
+// - fallback: The value to use if the setting is unset or invalid
+//
+// Returns:
+// - The parsed size in bytes or the fallback value
+func (l *settingsLoader) byteSize(name string, fallback int64) int64 {
+	value, ok := l.lookup(name)
+	if !ok {
+		return fallback
+	}
+
+	number, factor := strings.ToUpper(value), int64(1)
+	for _, unit := range byteSizeUnits {
+		if trimmed, ok := strings.CutSuffix(number, unit.suffix); ok {
+			number, factor = strings.TrimSpace(trimmed), unit.factor
+			break
+		}
+	}
+
+	size, err := strconv.ParseInt(number, 10, 64)
+	if err != nil || size <= 0 || size > math.MaxInt64/factor {
+		l.invalid(name, value, "a positive size like 1MB")
+		return fallback
+	}
+
+	return size * factor
+}
+
+// month reads a month number setting.
+//
+// Parameters:
+// - name: The name of the setting
+// - fallback: The value to use if the setting is unset or invalid
+//
+// Returns:
+// - The parsed month or the fallback value
+func (l *settingsLoader) month(name string, fallback time.Month) time.Month {
+	value, ok := l.lookup(name)
+	if !ok {
+		return fallback
+	}
+
+	month, err := strconv.Atoi(value)
+	if err != nil || month < 1 || month > 12 {
+		l.invalid(name, value, "a month number between 1 and 12")
+		return fallback
+	}
+
+	return time.Month(month)
+}
+
+// choice reads a setting that is one of a set of values.
+//
+// Parameters:
+// - name: The name of the setting
+// - fallback: The value to use if the setting is unset or not one of the choices
+// - choices: The allowed values
+//
+// Returns:
+// - The chosen value or the fallback value
+func (l *settingsLoader) choice(name string, fallback string, choices []string) string {
+	value, ok := l.lookup(name)
+	if !ok {
+		return fallback
+	}
+
+	if !slices.Contains(choices, value) {
+		l.invalid(name, value, "one of "+strings.Join(choices, ", "))
+		return fallback
+	}
+
+	return value
+}
+
+// list reads a comma separated list setting. In the config file, it can also be an array of strings.
+//
+// Parameters:
+// - name: The name of the setting
+//
+// Returns:
+// - The trimmed, non-empty list entries or nil if the setting is unset
+func (l *settingsLoader) list(name string) []string {
+	entries, _ := l.lookupList(name)
+
+	var list []string
+	for _, entry := range entries {
+		if entry = strings.TrimSpace(entry); entry != "" {
+			list = append(list, entry)
+		}
+	}
+
+	return list
+}
@@ -0,0 +1,297 @@
+// Shortcuts Module for PocketBase
+//
+// This module provides URLs for one-tap clocking from iOS Shortcuts, NFC tags or bookmarks,
+// which cannot send authentication headers. Each URL contains a random token, e.g.
+//
+//	/c/{token}/toggle
+//
+// Tokens are created per device by a signed in user and act on behalf of that user, so they can
+// only clock the clocks the user may change (see the delegation module). They can be revoked at
+// any time by deleting them from the shortcut_tokens collection, and are rate limited, so an NFC tag that is read repeatedly
+// does not clock in and out in quick succession. Tokens can optionally require confirmation,
+// in which case opening the URL shows a small page with a confirm button instead of clocking
+// immediately. Other clocks than the default clock are selected with the 'clock' query
+// parameter, e.g. /c/{token}/toggle?clock=side-project.
+package backend
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"html/template"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/apis"
+	"github.com/pocketbase/pocketbase/core"
+)
+
+// shortcutRateLimit is the maximum number of uses of a single token within shortcutRateWindow.
+const shortcutRateLimit = 5
+
+// shortcutRateWindow is the time window of the shortcut rate limit.
+const shortcutRateWindow = time.Minute
+
+// shortcutUses contains the recent uses of each token by its record ID and is guarded by shortcutUsesMutex.
+var shortcutUses = map[string][]time.Time{}
+var shortcutUsesMutex = sync.Mutex{}
+
+// shortcutConfirmTemplate is the confirmation page shown for tokens that require confirmation.
+var shortcutConfirmTemplate = template.Must(template.New("confirm").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{.Title}}</title>
+<style>
+body { font-family: system-ui, sans-serif; display: flex; align-items: center; justify-content: center; min-height: 100vh; margin: 0; }
+button { font-size: 1.5rem; padding: 1rem 2rem; }
+</style>
+</head>
+<body>
+<form method="post">
+<button type="submit">{{.Title}}</button>
+</form>
+</body>
+</html>
+`))
+
+// shortcutActionTitles are the titles of the confirmation page per action.
+var shortcutActionTitles = map[string]string{
+	"in":     "Clock in",
+	"out":    "Clock out",
+	"toggle": "Toggle clock",
+}
+
+// RegisterShortcutsAPI registers the shortcut endpoints with the PocketBase server.
+// It creates the following routes:
+// - POST /api/shortcut_tokens - Creates a token of the authenticated user with the given 'name' and optional 'confirm' flag
+// - GET /c/{token}/{action} - Clocks in, out or toggles ('in', 'out' or 'toggle'), or shows the confirmation page
+// - POST /c/{token}/{action} - Clocks in, out or toggles after confirmation
+//
+// The token itself is only returned once on creation. Revoking a token is done by deleting its record.
+//
+// Parameters:
+// - app: The PocketBase application instance
+func RegisterShortcutsAPI(app core.App) {
+	app.OnServe().BindFunc(func(se *core.ServeEvent) error {
+		se.Router.POST("/api/shortcut_tokens", func(e *core.RequestEvent) error {
+			name := strings.TrimSpace(e.Request.FormValue("name"))
+			if name == "" {
+				return e.Error(http.StatusBadRequest, "Missing 'name' (string) parameter", nil)
+			}
+
+			confirm := false
+			if confirmValue := e.Request.FormValue("confirm"); confirmValue != "" {
+				var err error
+				confirm, err = parseBoolParam(confirmValue, "confirm")
+				if err != nil {
+					return e.Error(http.StatusBadRequest, err.Error(), nil)
+				}
+			}
+
+			record, token, err := createShortcutToken(app, e.Auth.Id, name, confirm)
+			if err != nil {
+				return e.Error(http.StatusInternalServerError, fmt.Sprintf("Failed to create shortcut token: %v", err), err)
+			}
+
+			return e.JSON(http.StatusOK, map[string]any{
+				"id":     record.Id,
+				"name":   name,
+				"token":  token,
+				"toggle": "/c/" + token + "/toggle",
+			})
+		}).Bind(apis.RequireAuth())
+
+		se.Router.GET("/c/{token}/{action}", func(e *core.RequestEvent) error {
+			return handleShortcut(app, e, false)
+		})
+
+		se.Router.POST("/c/{token}/{action}", func(e *core.RequestEvent) error {
+			return handleShortcut(app, e, true)
+		})
+
+		return se.Next()
+	})
+}
+
+// handleShortcut executes the action of a shortcut URL and writes a plain text response,
+// which is displayed by iOS Shortcuts.
+//
+// Parameters:
+// - app: The App interface (typically a PocketBase instance or transaction)
+// - e: The RequestEvent from the HTTP handler
+// - confirmed: Whether the request was sent from the confirmation page
+//
+// Returns:
+// - An error if the token is invalid, rate limited, or the action fails
+func handleShortcut(app core.App, e *core.RequestEvent, confirmed bool) error {
+	action := e.Request.PathValue("action")
+	title, ok := shortcutActionTitles[action]
+	if !ok {
+		return e.Error(http.StatusNotFound, "Unknown shortcut action", nil)
+	}
+
+	record, err := app.FindFirstRecordByFilter("shortcut_tokens", "token_hash = {:hash}", dbx.Params{
+		"hash": hashShortcutToken(e.Request.PathValue("token")),
+	})
+	if err != nil {
+		return e.Error(http.StatusNotFound, "Unknown or revoked shortcut token", nil)
+	}
+
+	if record.GetBool("confirm") && !confirmed {
+		e.Response.Header().Set("Content-Type", "text/html; charset=utf-8")
+		return shortcutConfirmTemplate.Execute(e.Response, map[string]string{"Title": title})
+	}
+
+	if !allowShortcutUse(record.Id, time.Now()) {
+		return e.Error(http.StatusTooManyRequests, "The shortcut token was used too often, please try again later", nil)
+	}
+
+	clockID, err := requestClock(app, e)
+	if err != nil {
+		return err
+	}
+
+	// The token acts on behalf of the user who created it, tokens without owner only clock clocks without owner
+	var owner *core.Record
+	if ownerID := record.GetString("owner"); ownerID != "" {
+		if owner, err = app.FindRecordById("users", ownerID); err != nil {
+			return e.Error(http.StatusNotFound, "Unknown or revoked shortcut token", nil)
+		}
+	}
+	var access clockAccess
+	if clockID != "" {
+		if access, err = checkClockAccess(app, e, owner, clockID, clockNow(app)); err != nil {
+			return err
+		}
+	}
+
+	clockedIn, err := executeShortcutAction(e.Request.Context(), app, clockID, action)
+	if err != nil {
+		var tooLongErr *sessionTooLongError
+		if errors.As(err, &tooLongErr) {
+			return e.Error(http.StatusConflict, fmt.Sprintf("Failed to clock out: %v", tooLongErr), nil)
+		}
+		return e.Error(http.StatusInternalServerError, fmt.Sprintf("Failed to execute shortcut: %v", err), err)
+	}
+
+	// The token is left out of the recorded path, since it can be used by anyone knowing it
+	if access.Delegation != "" {
+		recordDelegatedChange(app, owner, access, e.Request.Method, "/c/{token}/"+action)
+	}
+
+	record.Set("last_used_at", time.Now())
+	if err := app.Save(record); err != nil {
+		app.Logger().Error("failed to save last use of shortcut token", "token", record.Id, "error", err)
+	}
+
+	return e.String(http.StatusOK, map[bool]string{true: "Clocked in", false: "Clocked out"}[clockedIn])
+}
+
+// executeShortcutAction clocks in, out or toggles the clock state.
+//
+// Parameters:
+// - ctx: The context cancelling the operation, e.g. of the request
+// - app: The App interface (typically a PocketBase instance or transaction)
+// - clockID: The ID of the clock, an empty string for the default clock
+// - action: The action of the shortcut ("in", "out" or "toggle")
+//
+// Returns:
+// - Whether the user is clocked in afterwards
+// - An error if clocking in or out fails
+func executeShortcutAction(ctx context.Context, app core.App, clockID string, action string) (bool, error) {
+	service := workClockServiceOf(app).WithContext(ctx)
+	clockIn := action == "in"
+	if action == "toggle" {
+		clockedIn, err := service.IsClockedIn(clockID)
+		if err != nil {
+			return false, err
+		}
+		clockIn = !clockedIn
+	}
+
+	return clockIn, service.ClockInOut(clockID, clockIn, false)
+}
+
+// createShortcutToken generates a new shortcut token and stores its hash.
+//
+// Parameters:
+// - app: The App interface (typically a PocketBase instance or transaction)
+// - owner: The ID of the user the token acts on behalf of
+// - name: The name describing where the token is used
+// - confirm: Whether the token shows a confirmation page before clocking
+//
+// Returns:
+// - The created shortcut_tokens record
+// - The token, which cannot be retrieved again later
+// - An error if generating or saving the token fails
+func createShortcutToken(app core.App, owner string, name string, confirm bool) (*core.Record, string, error) {
+	collection, err := app.FindCollectionByNameOrId("shortcut_tokens")
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to find shortcut tokens collection: %w", err)
+	}
+
+	tokenBytes := make([]byte, 32)
+	if _, err := rand.Read(tokenBytes); err != nil {
+		return nil, "", fmt.Errorf("failed to generate token: %w", err)
+	}
+	token := hex.EncodeToString(tokenBytes)
+
+	record := core.NewRecord(collection)
+	record.Set("owner", owner)
+	record.Set("name", name)
+	record.Set("token_hash", hashShortcutToken(token))
+	record.Set("confirm", confirm)
+	if err := app.Save(record); err != nil {
+		return nil, "", fmt.Errorf("failed to save shortcut token: %w", err)
+	}
+
+	return record, token, nil
+}
+
+// hashShortcutToken returns the hex encoded SHA-256 hash of a token.
+//
+// Parameters:
+// - token: The token to hash
+//
+// Returns:
+// - The hash as stored in the token_hash field
+func hashShortcutToken(token string) string {
+	hash := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(hash[:])
+}
+
+// allowShortcutUse records a use of a token if it is within the rate limit.
+//
+// Parameters:
+// - tokenID: The record ID of the token
+// - now: The time of the use
+//
+// Returns:
+// - Whether the use is allowed
+func allowShortcutUse(tokenID string, now time.Time) bool {
+	shortcutUsesMutex.Lock()
+	defer shortcutUsesMutex.Unlock()
+
+	var recentUses []time.Time
+	for _, use := range shortcutUses[tokenID] {
+		if now.Sub(use) < shortcutRateWindow {
+			recentUses = append(recentUses, use)
+		}
+	}
+
+	if len(recentUses) >= shortcutRateLimit {
+		shortcutUses[tokenID] = recentUses
+		return false
+	}
+
+	shortcutUses[tokenID] = append(recentUses, now)
+	return true
+}
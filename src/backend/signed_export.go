@@ -0,0 +1,409 @@
+// Signed Export Module for PocketBase
+//
+// This module signs exports with a server key, so submitted timesheets can later be verified as
+// unmodified. A signed export is a zip archive containing:
+// - the exported file, in any of the export formats
+// - SHA256SUMS, the SHA-256 checksum of the file in the format of sha256sum
+// - SHA256SUMS.sig, the raw Ed25519 signature of SHA256SUMS
+// - public_key.pem, the public key of the server
+//
+// The archive can be verified by the server or without it:
+//
+//	sha256sum -c SHA256SUMS
+//	openssl pkeyutl -verify -pubin -inkey public_key.pem -rawin -in SHA256SUMS -sigfile SHA256SUMS.sig
+//
+// The public key inside the archive only proves anything if it matches the key published by the
+// server, since whoever modifies the archive could also replace the key.
+package backend
+
+import (
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/pocketbase/pocketbase/core"
+)
+
+const (
+	// signedExportChecksumsName is the name of the checksum file inside signed exports
+	signedExportChecksumsName = "SHA256SUMS"
+
+	// signedExportSignatureName is the name of the signature file inside signed exports
+	signedExportSignatureName = "SHA256SUMS.sig"
+
+	// signedExportPublicKeyName is the name of the public key file inside signed exports
+	signedExportPublicKeyName = "public_key.pem"
+
+	// signedExportMaxFileSize limits the uncompressed size of a file within an uploaded signed export
+	signedExportMaxFileSize = 1024 * 1024 * 1024
+)
+
+var (
+	// exportSigningKeyMutex guards loading or generating the signing key
+	exportSigningKeyMutex sync.Mutex
+
+	// exportSigningKey is the loaded signing key, nil until first use
+	exportSigningKey ed25519.PrivateKey
+)
+
+// SignedExportVerification is the result of verifying a signed export.
+type SignedExportVerification struct {
+	Valid bool     `json:"valid"` // Whether the signature and all checksums are valid
+	Files []string `json:"files"` // The verified files of the archive
+	Error string   `json:"error"` // Why the archive is not valid, empty if it is
+}
+
+// RegisterSignedExportAPI registers the signed export endpoints with the PocketBase server.
+// It creates the following routes:
+// - GET /api/work_clock/export/signed - Streams a signed zip archive of an export, accepts all
+// parameters of GET /api/work_clock/export (e.g. 'month=2025-04')
+// - GET /api/work_clock/export/public_key - Returns the PEM encoded public key exports are signed with
+// - POST /api/work_clock/export/verify - Verifies the uploaded signed export 'file' against the server key
+//
+// Parameters:
+// - app: The PocketBase application instance
+func RegisterSignedExportAPI(app core.App) {
+	app.OnServe().BindFunc(func(se *core.ServeEvent) error {
+		se.Router.GET("/api/work_clock/export/signed", func(e *core.RequestEvent) error {
+			request, err := parseExportRequest(app, e)
+			if err != nil {
+				return e.Error(http.StatusBadRequest, err.Error(), nil)
+			}
+
+			key, err := loadExportSigningKey(app)
+			if err != nil {
+				return e.Error(http.StatusInternalServerError, "Failed to load the signing key", err)
+			}
+
+			fileName := request.fileName()
+			archiveName := strings.TrimSuffix(fileName, filepath.Ext(fileName)) + "_signed.zip"
+
+			header := e.Response.Header()
+			header.Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, archiveName))
+			header.Set("Content-Type", "application/zip")
+
+			if err := streamSignedExport(app, e, request, key); err != nil {
+				if !e.Written() {
+					return e.Error(http.StatusInternalServerError, fmt.Sprintf("Failed to export sessions: %v", err), err)
+				}
+
+				// The archive is incomplete without the signature, which makes the error visible to the client
+				app.Logger().Error("failed to stream signed export", "format", request.Format, "error", err)
+			}
+			return nil
+		})
+
+		se.Router.GET("/api/work_clock/export/public_key", func(e *core.RequestEvent) error {
+			key, err := loadExportSigningKey(app)
+			if err != nil {
+				return e.Error(http.StatusInternalServerError, "Failed to load the signing key", err)
+			}
+
+			publicKey, err := encodeExportPublicKey(key)
+			if err != nil {
+				return e.Error(http.StatusInternalServerError, "Failed to encode the public key", err)
+			}
+
+			return e.Blob(http.StatusOK, "application/x-pem-file", publicKey)
+		})
+
+		se.Router.POST("/api/work_clock/export/verify", func(e *core.RequestEvent) error {
+			if err := e.Request.ParseMultipartForm(32 * 1024 * 1024); err != nil {
+				return e.Error(http.StatusBadRequest, "File too large or invalid multipart form", err)
+			}
+
+			file, header, err := e.Request.FormFile("file")
+			if err != nil {
+				return e.Error(http.StatusBadRequest, "Failed to get uploaded file", err)
+			}
+			defer file.Close()
+
+			key, err := loadExportSigningKey(app)
+			if err != nil {
+				return e.Error(http.StatusInternalServerError, "Failed to load the signing key", err)
+			}
+
+			files, err := verifySignedExport(file, header.Size, key.Public().(ed25519.PublicKey))
+			if err != nil {
+				return e.JSON(http.StatusOK, SignedExportVerification{Valid: false, Files: []string{}, Error: err.Error()})
+			}
+
+			return e.JSON(http.StatusOK, SignedExportVerification{Valid: true, Files: files})
+		})
+
+		return se.Next()
+	})
+}
+
+// loadExportSigningKey loads the key exports are signed with. If no key file exists yet, a new
+// key is generated and stored, so the key stays the same across restarts.
+//
+// Parameters:
+// - app: The PocketBase application instance, its data directory holds the default key file
+//
+// Returns:
+// - The Ed25519 signing key
+// - An error if the key file can't be read, parsed or created
+func loadExportSigningKey(app core.App) (ed25519.PrivateKey, error) {
+	exportSigningKeyMutex.Lock()
+	defer exportSigningKeyMutex.Unlock()
+
+	if exportSigningKey != nil {
+		return exportSigningKey, nil
+	}
+
+	path := settings.ExportSigningKeyFile
+	if path == "" {
+		path = filepath.Join(app.DataDir(), "export_signing_key.pem")
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return generateExportSigningKey(path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read signing key: %w", err)
+	}
+
+	block, _ := pem.Decode(data)
+	if block == nil || block.Type != "PRIVATE KEY" {
+		return nil, fmt.Errorf("signing key file '%s' contains no PEM encoded private key", path)
+	}
+
+	parsedKey, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse signing key: %w", err)
+	}
+
+	key, ok := parsedKey.(ed25519.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("signing key file '%s' contains no Ed25519 key", path)
+	}
+
+	exportSigningKey = key
+	return key, nil
+}
+
+// generateExportSigningKey generates a new signing key and stores it. The caller must hold exportSigningKeyMutex.
+//
+// Parameters:
+// - path: The path of the key file to create, it must not exist yet
+//
+// Returns:
+// - The generated Ed25519 signing key
+// - An error if generating or storing the key fails
+func generateExportSigningKey(path string) (ed25519.PrivateKey, error) {
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate signing key: %w", err)
+	}
+
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode signing key: %w", err)
+	}
+
+	// O_EXCL never overwrites an existing key, which would invalidate all previously signed exports
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create signing key file: %w", err)
+	}
+	defer file.Close()
+
+	if err := pem.Encode(file, &pem.Block{Type: "PRIVATE KEY", Bytes: der}); err != nil {
+		return nil, fmt.Errorf("failed to write signing key: %w", err)
+	}
+
+	exportSigningKey = key
+	return key, nil
+}
+
+// encodeExportPublicKey encodes the public key of a signing key as PEM.
+//
+// Parameters:
+// - key: The signing key
+//
+// Returns:
+// - The PEM encoded public key
+// - An error if encoding fails
+func encodeExportPublicKey(key ed25519.PrivateKey) ([]byte, error) {
+	der, err := x509.MarshalPKIXPublicKey(key.Public())
+	if err != nil {
+		return nil, err
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}), nil
+}
+
+// streamSignedExport writes a signed zip archive of an export to the response. The export is
+// streamed into the archive and hashed on the fly, the checksum and signature are appended at the end.
+//
+// Parameters:
+// - app: The App interface (typically a PocketBase instance or transaction)
+// - e: The RequestEvent from the HTTP handler
+// - request: The parsed export request
+// - key: The signing key
+//
+// Returns:
+// - An error if exporting, signing or writing the archive fails
+func streamSignedExport(app core.App, e *core.RequestEvent, request exportRequest, key ed25519.PrivateKey) error {
+	archive := zip.NewWriter(e.Response)
+	fileName := request.fileName()
+
+	fileWriter, err := archive.Create(fileName)
+	if err != nil {
+		return err
+	}
+
+	hash := sha256.New()
+	flush := func() error {
+		if err := archive.Flush(); err != nil {
+			return err
+		}
+		return e.Flush()
+	}
+	if err := request.stream(app, io.MultiWriter(fileWriter, hash), flush); err != nil {
+		return err
+	}
+
+	checksums := []byte(fmt.Sprintf("%x  %s\n", hash.Sum(nil), fileName))
+
+	publicKey, err := encodeExportPublicKey(key)
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range []struct {
+		name string
+		data []byte
+	}{
+		{signedExportChecksumsName, checksums},
+		{signedExportSignatureName, ed25519.Sign(key, checksums)},
+		{signedExportPublicKeyName, publicKey},
+	} {
+		entryWriter, err := archive.Create(entry.name)
+		if err != nil {
+			return err
+		}
+		if _, err := entryWriter.Write(entry.data); err != nil {
+			return err
+		}
+	}
+
+	return archive.Close()
+}
+
+// verifySignedExport verifies the signature and checksums of a signed export.
+//
+// Parameters:
+// - reader: The zip archive
+// - size: The size of the archive in bytes
+// - publicKey: The public key the archive must be signed with
+//
+// Returns:
+// - The names of the verified files
+// - An error describing why the archive is not valid, e.g. because it contains unsigned files
+func verifySignedExport(reader io.ReaderAt, size int64, publicKey ed25519.PublicKey) ([]string, error) {
+	archive, err := zip.NewReader(reader, size)
+	if err != nil {
+		return nil, fmt.Errorf("the file is not a zip archive: %w", err)
+	}
+
+	files := map[string]*zip.File{}
+	for _, file := range archive.File {
+		files[file.Name] = file
+	}
+
+	readSmallFile := func(name string) ([]byte, error) {
+		file, ok := files[name]
+		if !ok {
+			return nil, fmt.Errorf("the archive contains no %s", name)
+		}
+
+		content, err := file.Open()
+		if err != nil {
+			return nil, fmt.Errorf("failed to open %s: %w", name, err)
+		}
+		defer content.Close()
+
+		return io.ReadAll(io.LimitReader(content, 64*1024))
+	}
+
+	checksums, err := readSmallFile(signedExportChecksumsName)
+	if err != nil {
+		return nil, err
+	}
+	signature, err := readSmallFile(signedExportSignatureName)
+	if err != nil {
+		return nil, err
+	}
+
+	if !ed25519.Verify(publicKey, checksums, signature) {
+		return nil, fmt.Errorf("the signature is invalid or was not created by this server")
+	}
+
+	var verified []string
+	scanner := bufio.NewScanner(bytes.NewReader(checksums))
+	for scanner.Scan() {
+		expected, name, ok := strings.Cut(scanner.Text(), "  ")
+		if !ok {
+			return nil, fmt.Errorf("malformed checksum line '%s'", scanner.Text())
+		}
+
+		file, ok := files[name]
+		if !ok {
+			return nil, fmt.Errorf("the signed file %s is missing", name)
+		}
+
+		content, err := file.Open()
+		if err != nil {
+			return nil, fmt.Errorf("failed to open %s: %w", name, err)
+		}
+
+		hash := sha256.New()
+		written, err := io.Copy(hash, io.LimitReader(content, signedExportMaxFileSize+1))
+		content.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", name, err)
+		}
+		if written > signedExportMaxFileSize {
+			return nil, fmt.Errorf("%s is larger than %d bytes", name, signedExportMaxFileSize)
+		}
+
+		if hex.EncodeToString(hash.Sum(nil)) != expected {
+			return nil, fmt.Errorf("%s was modified after signing", name)
+		}
+		verified = append(verified, name)
+	}
+
+	if len(verified) == 0 {
+		return nil, fmt.Errorf("the archive contains no signed files")
+	}
+
+	// Files added after signing would pass as part of the verified export
+	for name := range files {
+		switch {
+		case name == signedExportChecksumsName, name == signedExportSignatureName, name == signedExportPublicKeyName:
+		case strings.HasSuffix(name, "/"), slices.Contains(verified, name):
+		default:
+			return nil, fmt.Errorf("the file %s is not signed", name)
+		}
+	}
+
+	return verified, nil
+}
@@ -0,0 +1,154 @@
+package backend
+
+import (
+	"archive/zip"
+	"bytes"
+	"crypto/ed25519"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/yerTools/simple-frontend-stack/src/backend/backendtest"
+)
+
+// rewriteSignedExport copies a zip archive, replacing the content of its files by the result of
+// change, which drops files returning nil, and adding the extra files.
+func rewriteSignedExport(t *testing.T, archive []byte, change func(name string, content []byte) []byte, extra map[string]string) []byte {
+	t.Helper()
+
+	reader, err := zip.NewReader(bytes.NewReader(archive), int64(len(archive)))
+	if err != nil {
+		t.Fatalf("failed to read archive: %v", err)
+	}
+
+	var buffer bytes.Buffer
+	writer := zip.NewWriter(&buffer)
+	write := func(name string, content []byte) {
+		entry, err := writer.Create(name)
+		if err != nil {
+			t.Fatalf("failed to create %s: %v", name, err)
+		}
+		if _, err := entry.Write(content); err != nil {
+			t.Fatalf("failed to write %s: %v", name, err)
+		}
+	}
+
+	for _, file := range reader.File {
+		entry, err := file.Open()
+		if err != nil {
+			t.Fatalf("failed to open %s: %v", file.Name, err)
+		}
+		content, err := io.ReadAll(entry)
+		entry.Close()
+		if err != nil {
+			t.Fatalf("failed to read %s: %v", file.Name, err)
+		}
+
+		if content = change(file.Name, content); content != nil {
+			write(file.Name, content)
+		}
+	}
+	for name, content := range extra {
+		write(name, []byte(content))
+	}
+
+	if err := writer.Close(); err != nil {
+		t.Fatalf("failed to close archive: %v", err)
+	}
+	return buffer.Bytes()
+}
+
+func TestVerifySignedExport(t *testing.T) {
+	app := backendtest.NewApp(t)
+	RegisterSignedExportAPI(app)
+	handler := backendtest.NewHandler(t, app)
+
+	backendtest.AddRecords(t, app,
+		backendtest.ClockIn("2025-04-01T09:00:00Z"),
+		backendtest.ClockOut("2025-04-01T17:00:00Z"),
+	)
+
+	request := httptest.NewRequest(http.MethodGet, "/api/work_clock/export/signed?month=2025-04", nil)
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, request)
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("expected a signed export, got %d: %s", recorder.Code, recorder.Body.String())
+	}
+	archive := recorder.Body.Bytes()
+
+	key, err := loadExportSigningKey(app)
+	if err != nil {
+		t.Fatalf("failed to load signing key: %v", err)
+	}
+	publicKey := key.Public().(ed25519.PublicKey)
+
+	files, err := verifySignedExport(bytes.NewReader(archive), int64(len(archive)), publicKey)
+	if err != nil || len(files) != 1 || files[0] != "work_clock_2025-04.csv" {
+		t.Fatalf("expected the export to be valid, got %v: %v", files, err)
+	}
+
+	keep := func(name string, content []byte) []byte { return content }
+	foreignPublicKey, _, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+
+	testCases := []struct {
+		name      string
+		archive   []byte
+		publicKey ed25519.PublicKey
+		wantErr   string
+	}{
+		{
+			name: "modified payload",
+			archive: rewriteSignedExport(t, archive, func(name string, content []byte) []byte {
+				if name == "work_clock_2025-04.csv" {
+					return bytes.Replace(content, []byte("2025-04-01"), []byte("2025-04-02"), 1)
+				}
+				return content
+			}, nil),
+			publicKey: publicKey,
+			wantErr:   "was modified after signing",
+		},
+		{
+			name: "missing file",
+			archive: rewriteSignedExport(t, archive, func(name string, content []byte) []byte {
+				if name == "work_clock_2025-04.csv" {
+					return nil
+				}
+				return content
+			}, nil),
+			publicKey: publicKey,
+			wantErr:   "is missing",
+		},
+		{
+			name:      "extra file",
+			archive:   rewriteSignedExport(t, archive, keep, map[string]string{"corrections.csv": "2025-04-03;8:00\n"}),
+			publicKey: publicKey,
+			wantErr:   "corrections.csv is not signed",
+		},
+		{
+			name:      "foreign key",
+			archive:   archive,
+			publicKey: foreignPublicKey,
+			wantErr:   "signature is invalid",
+		},
+		{
+			name:      "not a zip archive",
+			archive:   []byte("date;duration\n"),
+			publicKey: publicKey,
+			wantErr:   "not a zip archive",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := verifySignedExport(bytes.NewReader(tc.archive), int64(len(tc.archive)), tc.publicKey)
+			if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+				t.Errorf("expected an error containing %q, got %v", tc.wantErr, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,72 @@
+// Slack Module for PocketBase
+//
+// This module posts clock events to a Slack channel through an incoming webhook
+// (https://api.slack.com/messaging/webhooks), so a team can see when someone starts and ends
+// work. It is configured and enabled through the integrations API:
+//
+//	{"webhook_url": "https://hooks.slack.com/services/T000/B000/XXXX"}
+//
+// Messages are posted asynchronously like webhook events, failed deliveries are logged.
+package backend
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/pocketbase/pocketbase/core"
+)
+
+// SlackConfig is the configuration of the Slack integration.
+type SlackConfig struct {
+	WebhookURL string `json:"webhook_url"` // The incoming webhook URL of the channel
+}
+
+// validate checks that the webhook URL is a valid HTTPS URL.
+func (c SlackConfig) validate() error {
+	return validateIntegrationURL(c.WebhookURL, "webhook_url", "https")
+}
+
+// RegisterSlackHooks subscribes the Slack integration to the clock events.
+// A message is posted when clocking in and when a session is closed.
+//
+// Parameters:
+// - app: The PocketBase application instance
+func RegisterSlackHooks(app core.App) {
+	OnClockIn().BindFunc(func(e *ClockEvent) error {
+		start := e.Record.GetDateTime("timestamp").Time().In(time.Local)
+		sendSlackMessage(e.App, fmt.Sprintf(":large_green_circle: Clocked in at %s", start.Format("15:04")))
+		return e.Next()
+	})
+
+	OnSessionClosed().BindFunc(func(e *SessionClosedEvent) error {
+		end := e.Session.End.In(time.Local)
+		duration := time.Duration(e.Session.DurationSeconds) * time.Second
+		sendSlackMessage(e.App, fmt.Sprintf(":red_circle: Clocked out at %s after %s", end.Format("15:04"), duration.Round(time.Minute)))
+		return e.Next()
+	})
+}
+
+// sendSlackMessage posts a message to the configured Slack channel in the background.
+//
+// Parameters:
+// - app: The App interface used for logging and loading the configuration
+// - text: The message text in Slack's mrkdwn format
+func sendSlackMessage(app core.App, text string) {
+	config, ok := integrationConfig[SlackConfig](app, "slack")
+	if !ok {
+		return
+	}
+
+	body, err := json.Marshal(map[string]string{"text": text})
+	if err != nil {
+		app.Logger().Error("failed to encode slack message", "error", err)
+		return
+	}
+
+	backgroundJobs.goJob("slack", func() {
+		if err := deliverWebhook(config.WebhookURL, body); err != nil {
+			recordIntegrationFailure(app, "slack", err)
+		}
+	})
+}
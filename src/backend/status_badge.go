@@ -0,0 +1,131 @@
+// Status Badge Module for PocketBase
+//
+// This module renders the clock state as a small SVG badge ("clocked in since 09:02" or "off"),
+// which can be embedded as an image in a personal homepage or a team wiki. The badge is public
+// unless a token is configured (see Settings.BadgeToken), in which case the embedding URL has to
+// contain it.
+package backend
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"html/template"
+	"net/http"
+	"time"
+	"unicode/utf8"
+
+	"github.com/pocketbase/pocketbase/core"
+)
+
+// statusBadgeTemplate is the SVG badge, laid out like the common flat badges.
+var statusBadgeTemplate = template.Must(template.New("badge").Parse(`<svg xmlns="http://www.w3.org/2000/svg" width="{{.Width}}" height="20" role="img" aria-label="{{.Label}}: {{.Message}}">
+<title>{{.Label}}: {{.Message}}</title>
+<linearGradient id="s" x2="0" y2="100%"><stop offset="0" stop-color="#bbb" stop-opacity=".1"/><stop offset="1" stop-opacity=".1"/></linearGradient>
+<clipPath id="r"><rect width="{{.Width}}" height="20" rx="3" fill="#fff"/></clipPath>
+<g clip-path="url(#r)">
+<rect width="{{.LabelWidth}}" height="20" fill="#555"/>
+<rect x="{{.LabelWidth}}" width="{{.MessageWidth}}" height="20" fill="{{.Color}}"/>
+<rect width="{{.Width}}" height="20" fill="url(#s)"/>
+</g>
+<g fill="#fff" text-anchor="middle" font-family="Verdana,Geneva,DejaVu Sans,sans-serif" font-size="11">
+<text x="{{.LabelX}}" y="14">{{.Label}}</text>
+<text x="{{.MessageX}}" y="14">{{.Message}}</text>
+</g>
+</svg>
+`))
+
+// statusBadge holds the values the badge template is rendered with.
+type statusBadge struct {
+	Label        string // Text of the left part
+	Message      string // Text of the right part
+	Color        string // Background color of the right part
+	LabelWidth   int    // Width of the left part in pixels
+	MessageWidth int    // Width of the right part in pixels
+	Width        int    // Total width in pixels
+	LabelX       int    // Center of the label
+	MessageX     int    // Center of the message
+}
+
+// RegisterStatusBadgeAPI registers the status badge endpoint with the PocketBase server.
+// It creates the following route:
+// - GET /api/badge/status.svg?label=&timezone=&token=&clock= - Renders the clock state as SVG badge, the
+// time is shown in 'timezone' (IANA name, server timezone if empty), 'token' is required if configured
+//
+// Parameters:
+// - app: The PocketBase application instance
+func RegisterStatusBadgeAPI(app core.App) {
+	app.OnServe().BindFunc(func(se *core.ServeEvent) error {
+		se.Router.GET("/api/badge/status.svg", func(e *core.RequestEvent) error {
+			query := e.Request.URL.Query()
+			if settings.BadgeToken != "" && subtle.ConstantTimeCompare([]byte(query.Get("token")), []byte(settings.BadgeToken)) != 1 {
+				return e.Error(http.StatusForbidden, "Invalid badge token", nil)
+			}
+
+			location := time.Local
+			if timezone := query.Get("timezone"); timezone != "" {
+				var err error
+				location, err = time.LoadLocation(timezone)
+				if err != nil {
+					return e.Error(http.StatusBadRequest, fmt.Sprintf("Invalid 'timezone' value '%s'", timezone), nil)
+				}
+			}
+
+			clockID, err := requestClock(app, e)
+			if err != nil {
+				return err
+			}
+
+			record, err := workClockServiceOf(app).LatestRecord(clockID)
+			if err != nil {
+				return e.Error(http.StatusInternalServerError, fmt.Sprintf("Failed to get work clock status: %v", err), err)
+			}
+
+			locale := requestLocale(e)
+			label := query.Get("label")
+			if label == "" {
+				label = translate(locale, "work")
+			}
+
+			message, color := translate(locale, "off"), "#9f9f9f"
+			if record != nil && record.GetBool("clock_in") {
+				since := record.GetDateTime("timestamp").Time().In(location)
+				message, color = translate(locale, fmt.Sprintf("clocked in since %s", since.Format("15:04"))), "#4c1"
+			}
+
+			// Badges are embedded in other pages, so they must not be cached by the browser or image proxies
+			header := e.Response.Header()
+			header.Set("Content-Type", "image/svg+xml; charset=utf-8")
+			header.Set("Cache-Control", "no-cache, max-age=0")
+
+			return statusBadgeTemplate.Execute(e.Response, newStatusBadge(label, message, color))
+		})
+
+		return se.Next()
+	})
+}
+
+// newStatusBadge lays out a badge. The text widths are estimated, since the font is chosen by the viewer.
+//
+// Parameters:
+// - label: The text of the left part
+// - message: The text of the right part
+// - color: The background color of the right part
+//
+// Returns:
+// - The badge values for the template
+func newStatusBadge(label, message, color string) statusBadge {
+	// Verdana at 11px is about 7px wide per character, with 5px padding on both sides
+	labelWidth := utf8.RuneCountInString(label)*7 + 10
+	messageWidth := utf8.RuneCountInString(message)*7 + 10
+
+	return statusBadge{
+		Label:        label,
+		Message:      message,
+		Color:        color,
+		LabelWidth:   labelWidth,
+		MessageWidth: messageWidth,
+		Width:        labelWidth + messageWidth,
+		LabelX:       labelWidth / 2,
+		MessageX:     labelWidth + messageWidth/2,
+	}
+}
@@ -0,0 +1,165 @@
+// Support Corrections Module for PocketBase
+//
+// This module lets superusers correct the work clock records on behalf of the user, e.g. to close
+// the open session of an employee who is out sick and can't fix it themselves. A correction
+// replaces the records of a day like the day editor does, but also records the superuser, the
+// person the correction was made for and the reason in the work clock ledger, so the audit trail
+// shows both identities. The corrections made this way can be listed for review.
+package backend
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/pocketbase/pocketbase/apis"
+	"github.com/pocketbase/pocketbase/core"
+)
+
+// maxSupportCorrections is the maximum number of listed corrections.
+const maxSupportCorrections = 500
+
+// supportDayRequest is the JSON body of the support correction endpoint.
+type supportDayRequest struct {
+	workClockDayRequest
+	OnBehalfOf string `json:"on_behalf_of"` // Person the correction is made for
+	Reason     string `json:"reason"`       // Why the correction is made
+}
+
+// SupportCorrection is a change of a work clock record made by a superuser on behalf of the user.
+type SupportCorrection struct {
+	Sequence   int       `json:"sequence"`     // Position of the change in the ledger
+	RecordID   string    `json:"record_id"`    // ID of the changed work clock record
+	Action     string    `json:"action"`       // Kind of the change ('create', 'update' or 'delete')
+	Actor      string    `json:"actor"`        // Email of the superuser
+	OnBehalfOf string    `json:"on_behalf_of"` // Person the change was made for
+	Reason     string    `json:"reason"`       // Why the change was made
+	RecordedAt time.Time `json:"recorded_at"`  // Time of the change
+}
+
+// RegisterSupportCorrectionsAPI registers the support correction endpoints with the PocketBase server.
+// It creates the following routes, only accessible for superusers:
+// - POST /api/admin/support/work_clock/day - Replaces all records of a day on behalf of the user
+// - GET /api/admin/support/corrections - Lists the corrections made on behalf of the user, newest first
+//
+// The correction endpoint expects the body of the day editor (see RegisterWorkClockDayAPI)
+// together with the person and the reason, e.g.:
+//
+//	{
+//	  "date": "2025-04-01",
+//	  "timezone": "Europe/Berlin",
+//	  "sessions": [{"clock_in": "2025-04-01T09:00:00+02:00", "clock_out": "2025-04-01T17:00:00+02:00"}],
+//	  "on_behalf_of": "Jane Doe",
+//	  "reason": "Out sick, forgot to clock out"
+//	}
+//
+// Parameters:
+// - app: The PocketBase application instance
+func RegisterSupportCorrectionsAPI(app core.App) {
+	app.OnServe().BindFunc(func(se *core.ServeEvent) error {
+		group := se.Router.Group("/api/admin/support")
+		group.Bind(apis.RequireSuperuserAuth())
+
+		group.POST("/work_clock/day", func(e *core.RequestEvent) error {
+			var request supportDayRequest
+			if err := e.BindBody(&request); err != nil {
+				return e.Error(http.StatusBadRequest, "Invalid request body", err)
+			}
+
+			actor := ledgerActor{Actor: e.Auth.Email(), OnBehalfOf: strings.TrimSpace(request.OnBehalfOf), Reason: strings.TrimSpace(request.Reason)}
+			if actor.OnBehalfOf == "" {
+				return e.Error(http.StatusBadRequest, "missing 'on_behalf_of' (string) parameter", nil)
+			}
+			if actor.Reason == "" {
+				return e.Error(http.StatusBadRequest, "missing 'reason' (string) parameter", nil)
+			}
+
+			clockID, dayStart, dayEnd, sessions, err := parseWorkClockDayRequest(app, e, request.workClockDayRequest)
+			if err != nil {
+				return err
+			}
+
+			if err := replaceWorkClockDayOnBehalf(e.Request.Context(), app, actor, clockID, dayStart, dayEnd, sessions); err != nil {
+				return e.Error(http.StatusInternalServerError, fmt.Sprintf("Failed to replace work clock day: %v", err), err)
+			}
+			return callSucceeded(e)
+		})
+
+		group.GET("/corrections", func(e *core.RequestEvent) error {
+			corrections, err := findSupportCorrections(app)
+			if err != nil {
+				return e.Error(http.StatusInternalServerError, fmt.Sprintf("Failed to find support corrections: %v", err), err)
+			}
+
+			return e.JSON(http.StatusOK, corrections)
+		})
+
+		return se.Next()
+	})
+}
+
+// replaceWorkClockDayOnBehalf replaces all work clock records of a clock within a day on behalf
+// of the user, recording the actor in the ledger entries of the changes.
+//
+// Parameters:
+// - ctx: The context cancelling the operation, e.g. of the request
+// - app: The App interface (typically a PocketBase instance or transaction)
+// - actor: The superuser making the correction, the person and the reason
+// - clockID: The ID of the clock, an empty string for the default clock
+// - dayStart: The start of the day (inclusive)
+// - dayEnd: The start of the following day (exclusive)
+// - sessions: The new sessions of the day, sorted and free of overlaps
+//
+// Returns:
+// - An error if the operation fails or if the resulting records violate sequence constraints
+func replaceWorkClockDayOnBehalf(ctx context.Context, app core.App, actor ledgerActor, clockID string, dayStart, dayEnd time.Time, sessions []clockInOutPair) error {
+	unlock, err := workClockServiceOf(app).WithContext(ctx).lock(clockID)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	err = app.RunInTransaction(func(txApp core.App) error {
+		ledgerActors.Store(txApp, actor)
+		defer ledgerActors.Delete(txApp)
+
+		return replaceWorkClockRange(txApp, clockID, dayStart, dayEnd, sessions)
+	})
+
+	if err != nil {
+		return fmt.Errorf("failed to replace work clock records of %s: %w", dayStart.Format(time.DateOnly), err)
+	}
+
+	return nil
+}
+
+// findSupportCorrections finds the latest ledger entries made on behalf of the user.
+//
+// Parameters:
+// - app: The App interface used to query the ledger
+//
+// Returns:
+// - The corrections, newest first
+// - An error if the query fails
+func findSupportCorrections(app core.App) ([]SupportCorrection, error) {
+	entries, err := app.FindRecordsByFilter("work_clock_ledger", "actor != ''", "-sequence", maxSupportCorrections, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	corrections := make([]SupportCorrection, 0, len(entries))
+	for _, entry := range entries {
+		corrections = append(corrections, SupportCorrection{
+			Sequence:   entry.GetInt("sequence"),
+			RecordID:   entry.GetString("record_id"),
+			Action:     entry.GetString("action"),
+			Actor:      entry.GetString("actor"),
+			OnBehalfOf: entry.GetString("on_behalf_of"),
+			Reason:     entry.GetString("reason"),
+			RecordedAt: entry.GetDateTime("recorded_at").Time(),
+		})
+	}
+	return corrections, nil
+}
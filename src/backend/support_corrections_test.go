@@ -0,0 +1,62 @@
+package backend
+
+import (
+	"testing"
+
+	"github.com/yerTools/simple-frontend-stack/src/backend/backendtest"
+)
+
+func TestReplaceWorkClockDayOnBehalf(t *testing.T) {
+	app := backendtest.NewApp(t)
+	RegisterWorkClockLedgerAPI(app)
+
+	backendtest.AddRecords(t, app, backendtest.ClockIn("2025-04-01T09:00:00Z"))
+
+	dayStart := backendtest.MustParseTime("2025-04-01T00:00:00Z")
+	session := clockInOutPair{ClockIn: backendtest.MustParseTime("2025-04-01T09:00:00Z"), ClockOut: backendtest.MustParseTime("2025-04-01T17:00:00Z")}
+	actor := ledgerActor{Actor: "admin@example.com", OnBehalfOf: "Jane Doe", Reason: "Out sick"}
+
+	if err := replaceWorkClockDayOnBehalf(t.Context(), app, actor, "", dayStart, dayStart.AddDate(0, 0, 1), []clockInOutPair{session}); err != nil {
+		t.Fatalf("failed to replace day: %v", err)
+	}
+
+	corrections, err := findSupportCorrections(app)
+	if err != nil {
+		t.Fatalf("failed to find corrections: %v", err)
+	}
+	// The open session is deleted and replaced by a clock in and a clock out record
+	if len(corrections) != 3 {
+		t.Fatalf("expected 3 corrections, got %+v", corrections)
+	}
+	for _, correction := range corrections {
+		if correction.Actor != actor.Actor || correction.OnBehalfOf != actor.OnBehalfOf || correction.Reason != actor.Reason {
+			t.Errorf("expected both identities to be recorded, got %+v", correction)
+		}
+	}
+
+	// Changes outside of the correction are recorded without an actor
+	backendtest.AddRecords(t, app, backendtest.ClockIn("2025-04-02T09:00:00Z"))
+	if corrections, err := findSupportCorrections(app); err != nil || len(corrections) != 3 {
+		t.Errorf("expected the later change not to be a correction, got %d corrections (%v)", len(corrections), err)
+	}
+
+	verification, err := verifyLedger(app)
+	if err != nil {
+		t.Fatalf("failed to verify ledger: %v", err)
+	}
+	if !verification.Valid {
+		t.Errorf("expected the ledger to be valid, got %v", verification.Problems)
+	}
+
+	// Changing the recorded identities breaks the chain
+	if _, err := app.DB().NewQuery("UPDATE work_clock_ledger SET on_behalf_of = 'John Doe' WHERE actor != ''").Execute(); err != nil {
+		t.Fatalf("failed to change ledger: %v", err)
+	}
+	verification, err = verifyLedger(app)
+	if err != nil {
+		t.Fatalf("failed to verify ledger: %v", err)
+	}
+	if verification.Valid {
+		t.Error("expected the changed ledger to be invalid")
+	}
+}
@@ -0,0 +1,197 @@
+// Tags Module for PocketBase
+//
+// This module allows attaching free-form tags (such as "meeting", "deep work" or "support")
+// to work clock sessions and reports the time spent per tag. Unlike projects, a session can
+// have any number of tags, so the time of a session is counted for each of its tags.
+package backend
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/pocketbase/pocketbase/core"
+)
+
+// TagReportEntry contains the time spent on sessions with a specific tag.
+type TagReportEntry struct {
+	TagID           string `json:"tag_id"`           // ID of the tag, empty for untagged sessions
+	Name            string `json:"name"`             // Name of the tag, empty for untagged sessions
+	DurationSeconds int64  `json:"duration_seconds"` // Total duration of the sessions with this tag
+	Duration        string `json:"duration"`         // Total duration formatted in the configured duration format
+	Sessions        int    `json:"sessions"`         // Number of sessions with this tag
+}
+
+// TagReport is the response of the tag report endpoint.
+type TagReport struct {
+	From     time.Time        `json:"from"`     // Start of the reported range
+	To       time.Time        `json:"to"`       // End of the reported range
+	Tags     []TagReportEntry `json:"tags"`     // Time per tag, sorted by duration (descending)
+	Untagged TagReportEntry   `json:"untagged"` // Time of sessions without any tag
+}
+
+// RegisterTagsAPI registers the tag endpoints with the PocketBase server.
+// It creates the following routes:
+// - POST /api/work_clock/tags - Replaces the tags of a session (by its clock in ID) with the given 'tag_ids'
+// - GET /api/work_clock/report/tags?from=&to=&clock= - Aggregates the time per tag for sessions starting within the range, supports conditional requests
+//
+// Parameters:
+// - app: The PocketBase application instance
+func RegisterTagsAPI(app core.App) {
+	app.OnServe().BindFunc(func(se *core.ServeEvent) error {
+		se.Router.POST("/api/work_clock/tags", func(e *core.RequestEvent) error {
+			clockInID := e.Request.FormValue("clock_in_id")
+			if clockInID == "" {
+				return e.Error(http.StatusBadRequest, "Missing 'clock_in_id' (string) parameter", nil)
+			}
+
+			if err := e.Request.ParseForm(); err != nil {
+				return e.Error(http.StatusBadRequest, "Invalid form data", err)
+			}
+
+			// Multiple tags are passed as repeated 'tag_ids' values, no value removes all tags
+			tagIDs := e.Request.Form["tag_ids"]
+
+			if err := setSessionTags(e.Request.Context(), app, clockInID, tagIDs); err != nil {
+				return e.Error(http.StatusInternalServerError, fmt.Sprintf("Failed to set tags of session: %v", err), err)
+			}
+			return callSucceeded(e)
+		})
+
+		se.Router.GET("/api/work_clock/report/tags", func(e *core.RequestEvent) error {
+			from, to, err := parseTimeRangeParams(e.Request.URL.Query().Get("from"), e.Request.URL.Query().Get("to"))
+			if err != nil {
+				return e.Error(http.StatusBadRequest, err.Error(), nil)
+			}
+
+			clockID, err := requestClock(app, e)
+			if err != nil {
+				return err
+			}
+
+			cacheKey := fmt.Sprintf("tags|%s|%s|%s", clockID, from.Format(time.RFC3339Nano), to.Format(time.RFC3339Nano))
+			report, err := cachedReport(app, cacheKey, func(now time.Time) (*TagReport, error) {
+				return getTagReport(app, clockID, from, to, now)
+			})
+			if err != nil {
+				return e.Error(http.StatusInternalServerError, fmt.Sprintf("Failed to create tag report: %v", err), err)
+			}
+
+			return respondConditionalJSON(e, report, workClockLastModified(app))
+		})
+
+		return se.Next()
+	})
+}
+
+// setSessionTags replaces the tags of the session started by a clock in record.
+//
+// Parameters:
+// - ctx: The context cancelling the operation, e.g. of the request
+// - app: The App interface (typically a PocketBase instance or transaction)
+// - clockInID: The ID of the clock in record starting the session
+// - tagIDs: The IDs of the new tags, an empty slice removes all tags
+//
+// Returns:
+// - An error if the record is not a clock in record, a tag does not exist, or saving fails
+func setSessionTags(ctx context.Context, app core.App, clockInID string, tagIDs []string) error {
+	unlock, err := workClockServiceOf(app).WithContext(ctx).lockSession("", clockInID)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	record, err := findClockInRecord(app, "", clockInID)
+	if err != nil {
+		return err
+	}
+
+	if len(tagIDs) > 0 {
+		tags, err := app.FindRecordsByIds("tags", tagIDs)
+		if err != nil {
+			return fmt.Errorf("failed to find tags: %w", err)
+		}
+		if len(tags) != len(tagIDs) {
+			return fmt.Errorf("not all tags exist or tags were passed multiple times")
+		}
+	}
+
+	record.Set("tags", tagIDs)
+	if err := app.Save(record); err != nil {
+		return fmt.Errorf("failed to save work clock record: %w", err)
+	}
+
+	return nil
+}
+
+// getTagReport aggregates the time per tag for all sessions of a clock starting within a range.
+//
+// Parameters:
+// - app: The App interface (typically a PocketBase instance or transaction)
+// - clockID: The ID of the clock, an empty string for the default clock
+// - from: The start of the range (inclusive)
+// - to: The end of the range (exclusive)
+// - now: The reference time used as end of an open session
+//
+// Returns:
+// - The tag report
+// - An error if the sessions or tags could not be retrieved
+func getTagReport(app core.App, clockID string, from, to, now time.Time) (*TagReport, error) {
+	sessions, err := findWorkSessions(app, clockID, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &TagReport{From: from, To: to, Tags: []TagReportEntry{}}
+	entries := map[string]*TagReportEntry{}
+
+	for _, session := range sessions {
+		duration := int64(session.Duration(now).Seconds())
+		tagIDs := session.ClockIn.GetStringSlice("tags")
+
+		if len(tagIDs) == 0 {
+			report.Untagged.DurationSeconds += duration
+			report.Untagged.Sessions++
+			continue
+		}
+
+		for _, tagID := range tagIDs {
+			entry, ok := entries[tagID]
+			if !ok {
+				entry = &TagReportEntry{TagID: tagID}
+				entries[tagID] = entry
+			}
+			entry.DurationSeconds += duration
+			entry.Sessions++
+		}
+	}
+
+	if len(entries) > 0 {
+		tagIDs := make([]string, 0, len(entries))
+		for tagID := range entries {
+			tagIDs = append(tagIDs, tagID)
+		}
+
+		tags, err := app.FindRecordsByIds("tags", tagIDs)
+		if err != nil {
+			return nil, fmt.Errorf("failed to find tags: %w", err)
+		}
+		for _, tag := range tags {
+			entries[tag.Id].Name = tag.GetString("name")
+		}
+	}
+
+	for _, entry := range entries {
+		entry.Duration = formatResponseDuration(entry.DurationSeconds)
+		report.Tags = append(report.Tags, *entry)
+	}
+	report.Untagged.Duration = formatResponseDuration(report.Untagged.DurationSeconds)
+
+	sort.Slice(report.Tags, func(a, b int) bool {
+		return report.Tags[a].DurationSeconds > report.Tags[b].DurationSeconds
+	})
+
+	return report, nil
+}
@@ -0,0 +1,418 @@
+// Teams Module for PocketBase
+//
+// This module provides the reports of a team to its leads, so a manager can see at a glance which
+// of their direct reports has built up overtime, violated working time rules or forgot to record
+// days. A team groups users under one or more leads in the teams collection; the reports cover
+// the clocks owned by the members (see the delegation module). Members without an owned clock
+// are listed without clocks.
+//
+// The capacity report sums up the members per week as planning input: the time scheduled by their
+// work schedules, the part of it covered by holidays and absences, the remaining available time and
+// the time actually worked. Days outside of the employment of a member have no scheduled time.
+// Weeks at the edges of the range only cover the days within it.
+//
+// The reports are only available to the leads of the team and to superusers. Since the clocks of
+// the members can't be read by other users either (see the delegation module), the leads are the
+// only ones besides the members, their delegates and superusers who see their times.
+package backend
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/apis"
+	"github.com/pocketbase/pocketbase/core"
+)
+
+// TeamMember is a member of a team with the report of each of their clocks.
+type TeamMember[T any] struct {
+	User   string `json:"user"`   // ID of the user
+	Email  string `json:"email"`  // Email of the user
+	Clocks []T    `json:"clocks"` // Reports of the clocks owned by the user
+}
+
+// TeamOvertimeEntry is the overtime report of a clock of a team member.
+type TeamOvertimeEntry struct {
+	Clock           string `json:"clock"`            // Name of the clock
+	WorkedSeconds   int64  `json:"worked_seconds"`   // Time that counts as work time within the range
+	Worked          string `json:"worked"`           // Worked time formatted in the configured duration format
+	OvertimeSeconds int64  `json:"overtime_seconds"` // Overtime accrued within the range, negative for undertime
+	Overtime        string `json:"overtime"`         // Overtime formatted in the configured duration format
+	BalanceSeconds  int64  `json:"balance_seconds"`  // Flextime balance at the end of the range
+	Balance         string `json:"balance"`          // Balance formatted in the configured duration format
+	MissingDays     int    `json:"missing_days"`     // Number of missing days within the range
+}
+
+// TeamComplianceEntry is the compliance report of a clock of a team member.
+type TeamComplianceEntry struct {
+	Clock        string          `json:"clock"`         // Name of the clock
+	Anomalies    []ComplianceDay `json:"anomalies"`     // Days within the range violating the rules
+	MissingDays  []string        `json:"missing_days"`  // Days within the range without recorded time (YYYY-MM-DD)
+	StaleSession bool            `json:"stale_session"` // Whether the clock has an open session longer than a workday
+}
+
+// TeamCapacityWeek is the capacity of a team in a week.
+type TeamCapacityWeek struct {
+	Week             string `json:"week"`              // First day of the week (YYYY-MM-DD)
+	ScheduledSeconds int64  `json:"scheduled_seconds"` // Target time of the work schedules of the members
+	Scheduled        string `json:"scheduled"`         // Scheduled time formatted in the configured duration format
+	AbsenceSeconds   int64  `json:"absence_seconds"`   // Scheduled time covered by holidays and absences
+	Absences         string `json:"absences"`          // Absence time formatted in the configured duration format
+	AvailableSeconds int64  `json:"available_seconds"` // Scheduled time not covered by holidays and absences
+	Available        string `json:"available"`         // Available time formatted in the configured duration format
+	WorkedSeconds    int64  `json:"worked_seconds"`    // Time that counts as work time
+	Worked           string `json:"worked"`            // Worked time formatted in the configured duration format
+}
+
+// TeamCapacity is the capacity report of a team.
+type TeamCapacity struct {
+	Team  string             `json:"team"`  // Name of the team
+	Weeks []TeamCapacityWeek `json:"weeks"` // Capacity of each week of the range, sorted by week
+}
+
+// teamCapacityTotals are the summed up durations of a week.
+type teamCapacityTotals struct {
+	Scheduled time.Duration // Target time of the work schedules
+	Absences  time.Duration // Scheduled time covered by holidays and absences
+	Worked    time.Duration // Time that counts as work time
+}
+
+// RegisterTeamsAPI registers the team report endpoints with the PocketBase server.
+// It creates the following routes, only accessible for the leads of the team and superusers:
+// - GET /api/teams/{id}/overtime?from=&to= - Lists the worked time, overtime, balance and number of missing days of the members
+// - GET /api/teams/{id}/compliance?from=&to= - Lists the compliance flags, missing days and stale sessions of the members
+// - GET /api/teams/{id}/capacity?from=&to= - Returns the TeamCapacity with the scheduled, absent, available and worked time per week
+//
+// Parameters:
+// - app: The PocketBase application instance
+func RegisterTeamsAPI(app core.App) {
+	app.OnServe().BindFunc(func(se *core.ServeEvent) error {
+		group := se.Router.Group("/api/teams/{id}")
+		group.Bind(apis.RequireAuth())
+
+		group.GET("/overtime", func(e *core.RequestEvent) error {
+			return respondTeamReport(app, e, getTeamOvertime)
+		})
+
+		group.GET("/compliance", func(e *core.RequestEvent) error {
+			return respondTeamReport(app, e, getTeamCompliance)
+		})
+
+		group.GET("/capacity", func(e *core.RequestEvent) error {
+			from, to, err := parseTimeRangeParams(e.Request.URL.Query().Get("from"), e.Request.URL.Query().Get("to"))
+			if err != nil {
+				return e.Error(http.StatusBadRequest, err.Error(), nil)
+			}
+
+			team, err := requestLedTeam(app, e)
+			if err != nil {
+				return err
+			}
+
+			capacity, err := getTeamCapacity(app, team, from, to)
+			if err != nil {
+				return e.Error(http.StatusInternalServerError, fmt.Sprintf("Failed to create team report: %v", err), err)
+			}
+
+			return e.JSON(http.StatusOK, capacity)
+		})
+
+		return se.Next()
+	})
+}
+
+// respondTeamReport checks the access to a team and responds with a report of its members.
+//
+// Parameters:
+// - app: The PocketBase application instance
+// - e: The RequestEvent from the HTTP handler
+// - report: The function creating the report of a clock
+//
+// Returns:
+// - An error if the request is invalid, the user is not a lead of the team, or the report fails
+func respondTeamReport[T any](app core.App, e *core.RequestEvent, report func(app core.App, clock *core.Record, from, to, now time.Time) (T, error)) error {
+	from, to, err := parseTimeRangeParams(e.Request.URL.Query().Get("from"), e.Request.URL.Query().Get("to"))
+	if err != nil {
+		return e.Error(http.StatusBadRequest, err.Error(), nil)
+	}
+
+	team, err := requestLedTeam(app, e)
+	if err != nil {
+		return err
+	}
+
+	members, err := getTeamMembers(app, team, func(clock *core.Record) (T, error) {
+		return report(app, clock, from, to, clockNow(app))
+	})
+	if err != nil {
+		return e.Error(http.StatusInternalServerError, fmt.Sprintf("Failed to create team report: %v", err), err)
+	}
+
+	return e.JSON(http.StatusOK, members)
+}
+
+// requestLedTeam finds the team of a request and checks that the requester may see its reports.
+//
+// Parameters:
+// - app: The PocketBase application instance
+// - e: The RequestEvent with the ID of the team in the path
+//
+// Returns:
+// - The teams record
+// - An error response if the team does not exist or the user is not a lead of the team
+func requestLedTeam(app core.App, e *core.RequestEvent) (*core.Record, error) {
+	team, err := app.FindRecordById("teams", e.Request.PathValue("id"))
+	if err != nil {
+		return nil, e.Error(http.StatusNotFound, fmt.Sprintf("team with id '%s' does not exist", e.Request.PathValue("id")), nil)
+	}
+	if !e.HasSuperuserAuth() && !slices.Contains(team.GetStringSlice("leads"), e.Auth.Id) {
+		return nil, e.Error(http.StatusForbidden, "Only the leads of the team can see its reports", nil)
+	}
+	return team, nil
+}
+
+// getTeamMembers creates the reports of the clocks owned by the members of a team.
+//
+// Parameters:
+// - app: The App interface used to find the members and their clocks
+// - team: The teams record
+// - report: The function creating the report of a clock
+//
+// Returns:
+// - The members in the order of the team, with the reports of their clocks sorted by name
+// - An error if a query or a report fails
+func getTeamMembers[T any](app core.App, team *core.Record, report func(clock *core.Record) (T, error)) ([]TeamMember[T], error) {
+	members := []TeamMember[T]{}
+	for _, userID := range team.GetStringSlice("members") {
+		user, err := app.FindRecordById("users", userID)
+		if err != nil {
+			continue
+		}
+
+		clocks, err := app.FindRecordsByFilter("clocks", "owner = {:owner}", "+name", 0, 0, dbx.Params{"owner": userID})
+		if err != nil {
+			return nil, fmt.Errorf("failed to find clocks of user '%s': %w", user.Email(), err)
+		}
+
+		member := TeamMember[T]{User: user.Id, Email: user.Email(), Clocks: []T{}}
+		for _, clock := range clocks {
+			entry, err := report(clock)
+			if err != nil {
+				return nil, fmt.Errorf("failed to create report of clock '%s': %w", clock.GetString("name"), err)
+			}
+			member.Clocks = append(member.Clocks, entry)
+		}
+		members = append(members, member)
+	}
+
+	return members, nil
+}
+
+// getTeamOvertime creates the overtime report of a clock.
+//
+// Parameters:
+// - app: The App interface (typically a PocketBase instance or transaction)
+// - clock: The clocks record
+// - from: The start of the range (inclusive)
+// - to: The end of the range (exclusive)
+// - now: The current time
+//
+// Returns:
+// - The overtime report
+// - An error if the daily summaries or the missing days could not be retrieved
+func getTeamOvertime(app core.App, clock *core.Record, from, to, now time.Time) (TeamOvertimeEntry, error) {
+	summaries, err := findDailySummaries(app, clock.Id, from, to)
+	if err != nil {
+		return TeamOvertimeEntry{}, err
+	}
+
+	var worked, overtime, balance int64
+	for _, summary := range summaries {
+		worked += summary.WorkedSeconds
+		overtime += summary.OvertimeSeconds
+		balance = summary.BalanceSeconds
+	}
+
+	if len(summaries) == 0 {
+		// Without a summary in the range, the balance is the one of the latest day before it
+		previous, err := app.FindRecordsByFilter("daily_summary", "clock = {:clock} && date < {:from}", "-date", 1, 0, dbx.Params{
+			"clock": clockParam(clock.Id),
+			"from":  dateTimeParam(from),
+		})
+		if err != nil {
+			return TeamOvertimeEntry{}, fmt.Errorf("failed to find daily summaries: %w", err)
+		}
+		if len(previous) > 0 {
+			balance = int64(previous[0].GetInt("balance_seconds"))
+		}
+	}
+
+	missing, err := findMissingDays(app, clock.Id, from, to, now)
+	if err != nil {
+		return TeamOvertimeEntry{}, err
+	}
+
+	return TeamOvertimeEntry{
+		Clock:           clock.GetString("name"),
+		WorkedSeconds:   worked,
+		Worked:          formatResponseDuration(worked),
+		OvertimeSeconds: overtime,
+		Overtime:        formatResponseDuration(overtime),
+		BalanceSeconds:  balance,
+		Balance:         formatResponseDuration(balance),
+		MissingDays:     len(missing),
+	}, nil
+}
+
+// getTeamCompliance creates the compliance report of a clock.
+//
+// Parameters:
+// - app: The App interface (typically a PocketBase instance or transaction)
+// - clock: The clocks record
+// - from: The start of the range (inclusive)
+// - to: The end of the range (exclusive)
+// - now: The current time
+//
+// Returns:
+// - The compliance report
+// - An error if the sessions, the missing days or the status could not be retrieved
+func getTeamCompliance(app core.App, clock *core.Record, from, to, now time.Time) (TeamComplianceEntry, error) {
+	anomalies, err := findComplianceDays(app, clock.Id, from, to, now)
+	if err != nil {
+		return TeamComplianceEntry{}, err
+	}
+
+	missing, err := findMissingDays(app, clock.Id, from, to, now)
+	if err != nil {
+		return TeamComplianceEntry{}, err
+	}
+
+	status, err := getWorkClockStatus(app, clock.Id, now)
+	if err != nil {
+		return TeamComplianceEntry{}, err
+	}
+
+	return TeamComplianceEntry{
+		Clock:        clock.GetString("name"),
+		Anomalies:    anomalies,
+		MissingDays:  missing,
+		StaleSession: status.Stale,
+	}, nil
+}
+
+// getTeamCapacity creates the capacity report of a team.
+//
+// Parameters:
+// - app: The App interface (typically a PocketBase instance or transaction)
+// - team: The teams record
+// - from: The start of the range (inclusive)
+// - to: The end of the range (exclusive)
+//
+// Returns:
+// - The capacity of each week of the range, summed up over the clocks of the members
+// - An error if a query or the capacity of a clock fails
+func getTeamCapacity(app core.App, team *core.Record, from, to time.Time) (*TeamCapacity, error) {
+	members, err := getTeamMembers(app, team, func(clock *core.Record) (map[string]teamCapacityTotals, error) {
+		return getClockCapacity(app, clock.Id, from, to)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	capacity := &TeamCapacity{Team: team.GetString("name"), Weeks: []TeamCapacityWeek{}}
+	for day := startOfLocalDay(from); day.Before(to); day = day.AddDate(0, 0, 1) {
+		week := startOfLocalWeek(day).Format(time.DateOnly)
+		if len(capacity.Weeks) > 0 && capacity.Weeks[len(capacity.Weeks)-1].Week == week {
+			continue
+		}
+
+		var totals teamCapacityTotals
+		for _, member := range members {
+			for _, clock := range member.Clocks {
+				totals.Scheduled += clock[week].Scheduled
+				totals.Absences += clock[week].Absences
+				totals.Worked += clock[week].Worked
+			}
+		}
+
+		scheduled := int64(totals.Scheduled.Seconds())
+		absences := int64(totals.Absences.Seconds())
+		worked := int64(totals.Worked.Seconds())
+		capacity.Weeks = append(capacity.Weeks, TeamCapacityWeek{
+			Week:             week,
+			ScheduledSeconds: scheduled,
+			Scheduled:        formatResponseDuration(scheduled),
+			AbsenceSeconds:   absences,
+			Absences:         formatResponseDuration(absences),
+			AvailableSeconds: scheduled - absences,
+			Available:        formatResponseDuration(scheduled - absences),
+			WorkedSeconds:    worked,
+			Worked:           formatResponseDuration(worked),
+		})
+	}
+
+	return capacity, nil
+}
+
+// getClockCapacity sums up the scheduled, absent and worked time of a clock per week.
+//
+// Parameters:
+// - app: The App interface (typically a PocketBase instance or transaction)
+// - clockID: The ID of the clock
+// - from: The start of the range (inclusive)
+// - to: The end of the range (exclusive)
+//
+// Returns:
+// - The totals by the first day of the week (YYYY-MM-DD)
+// - An error if the work schedules, employment, days off or daily summaries could not be retrieved
+func getClockCapacity(app core.App, clockID string, from, to time.Time) (map[string]teamCapacityTotals, error) {
+	schedules, err := findWorkSchedules(app, clockID)
+	if err != nil {
+		return nil, err
+	}
+	employment, err := findEmploymentPeriods(app, clockID)
+	if err != nil {
+		return nil, err
+	}
+
+	first := startOfLocalDay(from)
+	off, err := findDaysOff(app, clockID, first, to)
+	if err != nil {
+		return nil, err
+	}
+
+	totals := map[string]teamCapacityTotals{}
+	for day := first; day.Before(to); day = day.AddDate(0, 0, 1) {
+		if !employment.includes(day) {
+			continue
+		}
+
+		week := startOfLocalWeek(day).Format(time.DateOnly)
+		total := totals[week]
+		scheduled := schedules.target(day)
+		total.Scheduled += scheduled
+		total.Absences += scheduled - off.target(schedules, day)
+		totals[week] = total
+	}
+
+	summaries, err := findDailySummaries(app, clockID, first, to)
+	if err != nil {
+		return nil, err
+	}
+	for _, summary := range summaries {
+		day, err := time.ParseInLocation(time.DateOnly, summary.Date, time.Local)
+		if err != nil {
+			return nil, fmt.Errorf("invalid date of daily summary '%s': %w", summary.Date, err)
+		}
+
+		week := startOfLocalWeek(day).Format(time.DateOnly)
+		total := totals[week]
+		total.Worked += time.Duration(summary.WorkedSeconds) * time.Second
+		totals[week] = total
+	}
+
+	return totals, nil
+}
@@ -0,0 +1,139 @@
+package backend
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/yerTools/simple-frontend-stack/src/backend/backendtest"
+)
+
+func TestTeamReports(t *testing.T) {
+	app := backendtest.NewApp(t)
+	RegisterTeamsAPI(app)
+	RegisterDailySummaryAPI(app)
+	RegisterAbsencesAPI(app)
+	handler := backendtest.NewHandler(t, app)
+
+	lead := backendtest.AddUser(t, app, "lead@example.com")
+	member := backendtest.AddUser(t, app, "member@example.com")
+
+	clocks, err := app.FindCollectionByNameOrId("clocks")
+	if err != nil {
+		t.Fatalf("failed to find clocks collection: %v", err)
+	}
+	clock := core.NewRecord(clocks)
+	clock.Set("name", "member")
+	clock.Set("owner", member.Id)
+	if err := app.Save(clock); err != nil {
+		t.Fatalf("failed to save clock: %v", err)
+	}
+
+	teams, err := app.FindCollectionByNameOrId("teams")
+	if err != nil {
+		t.Fatalf("failed to find teams collection: %v", err)
+	}
+	team := core.NewRecord(teams)
+	team.Set("name", "Support")
+	team.Set("leads", []string{lead.Id})
+	team.Set("members", []string{member.Id})
+	if err := app.Save(team); err != nil {
+		t.Fatalf("failed to save team: %v", err)
+	}
+
+	// Monday to Saturday of a past week without any recorded time
+	query := url.Values{
+		"from": {time.Date(2025, 3, 3, 0, 0, 0, 0, time.Local).Format(time.RFC3339)},
+		"to":   {time.Date(2025, 3, 9, 0, 0, 0, 0, time.Local).Format(time.RFC3339)},
+	}
+	get := func(user *core.Record, report string) *httptest.ResponseRecorder {
+		request := httptest.NewRequest(http.MethodGet, "/api/teams/"+team.Id+"/"+report+"?"+query.Encode(), nil)
+		token, err := user.NewAuthToken()
+		if err != nil {
+			t.Fatalf("failed to create token: %v", err)
+		}
+		request.Header.Set("Authorization", token)
+
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, request)
+		return recorder
+	}
+
+	if recorder := get(member, "overtime"); recorder.Code != http.StatusForbidden {
+		t.Errorf("expected members to be rejected with 403, got %d", recorder.Code)
+	}
+
+	recorder := get(lead, "compliance")
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", recorder.Code, recorder.Body.String())
+	}
+	var compliance []TeamMember[TeamComplianceEntry]
+	if err := json.Unmarshal(recorder.Body.Bytes(), &compliance); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if len(compliance) != 1 || compliance[0].Email != "member@example.com" || len(compliance[0].Clocks) != 1 {
+		t.Fatalf("expected one member with one clock, got %+v", compliance)
+	}
+	if missing := compliance[0].Clocks[0].MissingDays; len(missing) != 5 || missing[0] != "2025-03-03" || missing[4] != "2025-03-07" {
+		t.Errorf("expected the workdays of the week to be missing, got %v", missing)
+	}
+
+	recorder = get(lead, "overtime")
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", recorder.Code, recorder.Body.String())
+	}
+	var overtime []TeamMember[TeamOvertimeEntry]
+	if err := json.Unmarshal(recorder.Body.Bytes(), &overtime); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if len(overtime) != 1 || len(overtime[0].Clocks) != 1 || overtime[0].Clocks[0].MissingDays != 5 {
+		t.Errorf("expected five missing days in the overtime report, got %+v", overtime)
+	}
+
+	// A vacation on Tuesday and four hours worked on Monday
+	absences, err := app.FindCollectionByNameOrId("absences")
+	if err != nil {
+		t.Fatalf("failed to find absences collection: %v", err)
+	}
+	absence := core.NewRecord(absences)
+	absence.Set("clock", clock.Id)
+	absence.Set("date", "2025-03-04")
+	absence.Set("kind", "vacation")
+	if err := app.Save(absence); err != nil {
+		t.Fatalf("failed to save absence: %v", err)
+	}
+	if err := workClockServiceOf(app).AddClockInOutPair(clock.Id, time.Date(2025, 3, 3, 9, 0, 0, 0, time.Local), time.Date(2025, 3, 3, 13, 0, 0, 0, time.Local)); err != nil {
+		t.Fatalf("failed to add session: %v", err)
+	}
+
+	if recorder := get(member, "capacity"); recorder.Code != http.StatusForbidden {
+		t.Errorf("expected members to be rejected with 403, got %d", recorder.Code)
+	}
+
+	recorder = get(lead, "capacity")
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", recorder.Code, recorder.Body.String())
+	}
+	var capacity TeamCapacity
+	if err := json.Unmarshal(recorder.Body.Bytes(), &capacity); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+
+	workday := int64(settings.WorkdayDuration.Seconds())
+	var scheduled, absent, worked int64
+	for _, week := range capacity.Weeks {
+		scheduled += week.ScheduledSeconds
+		absent += week.AbsenceSeconds
+		worked += week.WorkedSeconds
+		if week.AvailableSeconds != week.ScheduledSeconds-week.AbsenceSeconds {
+			t.Errorf("expected the available time to be the scheduled time without absences, got %+v", week)
+		}
+	}
+	if capacity.Team != "Support" || scheduled != 5*workday || absent != workday || worked != 4*60*60 {
+		t.Errorf("expected 5 scheduled days, 1 absent day and 4 worked hours, got %+v", capacity)
+	}
+}
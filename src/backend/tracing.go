@@ -0,0 +1,266 @@
+// Tracing Module for PocketBase
+//
+// This module records OpenTelemetry traces of the backend and exports them to an OTLP collector,
+// so slow clock operations on large datasets can be diagnosed in an existing tracing stack.
+// Tracing is enabled by setting OTEL_EXPORTER_OTLP_ENDPOINT to the base URL of the collector's
+// OTLP/HTTP receiver (e.g. "http://otel-collector:4318"); the service is named after
+// OTEL_SERVICE_NAME.
+//
+// The following spans are recorded:
+// - A server span for every HTTP request, continuing the trace of an incoming 'traceparent' header
+// - A span for every SQL statement executed with the context of a span, and for every statement
+// slower than tracingSlowStatement otherwise, since most statements of PocketBase carry no context.
+// Statements are described by their operation and table only, since PocketBase logs them with the
+// values of their parameters, which may be personal data.
+// - Spans for the reading and writing phases of the importers
+//
+// Spans are recorded with the OpenTelemetry SDK and exported in batches by its OTLP/HTTP exporter.
+// Without an endpoint the global no-op tracer provider stays in place, so spans cost nothing.
+package backend
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/core"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracerName is the name of the instrumentation scope the spans of the backend are recorded with.
+const tracerName = "github.com/yerTools/simple-frontend-stack/src/backend"
+
+// tracingSlowStatement is the duration from which SQL statements without a span in their context are recorded.
+const tracingSlowStatement = 100 * time.Millisecond
+
+// tracedRequestKey is the context key of the request traced by the request tracing middleware.
+type tracedRequestKey struct{}
+
+// tracedRequest is a request passed through the HTTP handler of otelhttp.
+type tracedRequest struct {
+	event *core.RequestEvent // Event of the request
+	err   error              // Error returned by the remaining handlers
+}
+
+// RegisterTracingHooks registers the request tracing middleware, the SQL statement tracing and
+// the span exporter with the PocketBase server, if tracing is configured.
+//
+// Parameters:
+// - app: The PocketBase application instance
+func RegisterTracingHooks(app core.App) {
+	if settings.TracingEndpoint == "" {
+		return
+	}
+
+	var provider *sdktrace.TracerProvider
+
+	app.OnBootstrap().BindFunc(func(e *core.BootstrapEvent) error {
+		if err := e.Next(); err != nil {
+			return err
+		}
+
+		exporter, err := otlptracehttp.New(context.Background(),
+			otlptracehttp.WithEndpointURL(strings.TrimSuffix(settings.TracingEndpoint, "/")+"/v1/traces"),
+		)
+		if err != nil {
+			return err
+		}
+
+		provider = sdktrace.NewTracerProvider(
+			sdktrace.WithBatcher(exporter),
+			sdktrace.WithResource(resource.NewSchemaless(attribute.String("service.name", settings.TracingServiceName))),
+		)
+		otel.SetTracerProvider(provider)
+		otel.SetTextMapPropagator(propagation.TraceContext{})
+
+		for _, builder := range []dbx.Builder{e.App.DB(), e.App.NonconcurrentDB()} {
+			if db, ok := builder.(*dbx.DB); ok {
+				traceDBStatements(db)
+			}
+		}
+		return nil
+	})
+
+	app.OnServe().BindFunc(func(se *core.ServeEvent) error {
+		se.Router.BindFunc(traceRequests())
+		return se.Next()
+	})
+
+	app.OnTerminate().BindFunc(func(e *core.TerminateEvent) error {
+		// Shutting down the provider exports the remaining spans
+		if provider != nil {
+			if err := provider.Shutdown(context.Background()); err != nil {
+				app.Logger().Error("failed to export spans", "error", err)
+			}
+		}
+		return e.Next()
+	})
+}
+
+// traceRequests creates the middleware recording a server span for every request, using the
+// global tracer provider and propagator.
+//
+// Returns:
+// - The middleware passing the requests through the HTTP handler of otelhttp
+func traceRequests() func(e *core.RequestEvent) error {
+	handler := otelhttp.NewHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		traced := r.Context().Value(tracedRequestKey{}).(*tracedRequest)
+		traced.event.Response, traced.event.Request = w, r
+
+		// Errors are written to the response after the span ended, so they are recorded here
+		traced.err = traced.event.Next()
+		if traced.err != nil {
+			requestSpan := trace.SpanFromContext(r.Context())
+			requestSpan.RecordError(traced.err)
+			requestSpan.SetStatus(codes.Error, traced.err.Error())
+		}
+	}), "http.server", otelhttp.WithSpanNameFormatter(func(_ string, r *http.Request) string {
+		return r.Method + " " + requestRoute(r)
+	}))
+
+	return func(e *core.RequestEvent) error {
+		response := e.Response
+		traced := &tracedRequest{event: e}
+		handler.ServeHTTP(e.Response, e.Request.WithContext(context.WithValue(e.Request.Context(), tracedRequestKey{}, traced)))
+		e.Response = response
+		return traced.err
+	}
+}
+
+// startSpan starts a span of the backend as child of the span in the context.
+//
+// Parameters:
+// - ctx: The context of the operation
+// - name: The name of the operation
+// - options: The options of the span, e.g. its attributes
+//
+// Returns:
+// - The context carrying the started span
+// - The started span
+func startSpan(ctx context.Context, name string, options ...trace.SpanStartOption) (context.Context, trace.Span) {
+	return otel.Tracer(tracerName).Start(ctx, name, options...)
+}
+
+// endSpan ends a span, marking it as failed if the operation returned an error.
+//
+// Parameters:
+// - span: The span to end
+// - err: The error the operation failed with, nil if it succeeded
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// traceDBStatements records the SQL statements of a database as spans, keeping its existing log functions.
+//
+// Parameters:
+// - db: The database of PocketBase
+func traceDBStatements(db *dbx.DB) {
+	queryLog := db.QueryLogFunc
+	db.QueryLogFunc = func(ctx context.Context, t time.Duration, statement string, rows *sql.Rows, err error) {
+		recordDBSpan(ctx, t, statement, err)
+		if queryLog != nil {
+			queryLog(ctx, t, statement, rows, err)
+		}
+	}
+
+	execLog := db.ExecLogFunc
+	db.ExecLogFunc = func(ctx context.Context, t time.Duration, statement string, result sql.Result, err error) {
+		recordDBSpan(ctx, t, statement, err)
+		if execLog != nil {
+			execLog(ctx, t, statement, result, err)
+		}
+	}
+}
+
+// recordDBSpan records an executed SQL statement as span. Statements without a span in their
+// context are only recorded as their own trace if they are slow.
+//
+// Parameters:
+// - ctx: The context the statement was executed with, may be nil
+// - duration: The duration of the statement
+// - statement: The SQL statement
+// - err: The error of the statement, if any
+func recordDBSpan(ctx context.Context, duration time.Duration, statement string, err error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if !trace.SpanContextFromContext(ctx).IsValid() && duration < tracingSlowStatement {
+		return
+	}
+
+	operation, table := describeStatement(statement)
+	attributes := []attribute.KeyValue{
+		attribute.String("db.system", "sqlite"),
+		attribute.String("db.operation.name", operation),
+	}
+	if table != "" {
+		attributes = append(attributes, attribute.String("db.collection.name", table))
+	}
+
+	_, dbSpan := startSpan(ctx, strings.TrimSpace("db "+operation+" "+table),
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithTimestamp(time.Now().Add(-duration)),
+		trace.WithAttributes(attributes...),
+	)
+	endSpan(dbSpan, err)
+}
+
+// describeStatement determines the operation and the table of an SQL statement, which describe
+// it without the values of its parameters.
+//
+// Parameters:
+// - statement: The SQL statement
+//
+// Returns:
+// - The operation in upper case, e.g. "SELECT"
+// - The first table the statement reads from or writes to, an empty string if there is none
+func describeStatement(statement string) (string, string) {
+	fields := strings.Fields(statement)
+	if len(fields) == 0 {
+		return "", ""
+	}
+
+	operation := strings.ToUpper(fields[0])
+	for i := 0; i+1 < len(fields); i++ {
+		switch strings.ToUpper(fields[i]) {
+		case "FROM", "INTO", "UPDATE", "JOIN":
+			// Subqueries are skipped, their table follows later
+			if table := strings.Trim(fields[i+1], "`\"[],;"); table != "" && !strings.HasPrefix(table, "(") {
+				return operation, table
+			}
+		}
+	}
+	return operation, ""
+}
+
+// requestRoute returns the route pattern of a request, which groups the requests of an endpoint.
+//
+// Parameters:
+// - request: The HTTP request
+//
+// Returns:
+// - The path pattern of the matched route, the path if no route matched
+func requestRoute(request *http.Request) string {
+	if _, pattern, ok := strings.Cut(request.Pattern, " "); ok {
+		return pattern
+	}
+	if request.Pattern != "" {
+		return request.Pattern
+	}
+	return request.URL.Path
+}
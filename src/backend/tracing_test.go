@@ -0,0 +1,127 @@
+package backend
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/yerTools/simple-frontend-stack/src/backend/backendtest"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+// recordSpans records the spans of the test in memory instead of exporting them.
+func recordSpans(t *testing.T) *tracetest.SpanRecorder {
+	t.Helper()
+
+	recorder := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() {
+		otel.SetTracerProvider(noop.NewTracerProvider())
+		otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator())
+	})
+	return recorder
+}
+
+func TestSpansContinueIncomingTrace(t *testing.T) {
+	originalSettings := settings
+	t.Cleanup(func() {
+		settings = originalSettings
+	})
+	settings.TracingEndpoint = "http://localhost:4318"
+	recorder := recordSpans(t)
+
+	// The app is already bootstrapped, so no exporter is created
+	app := backendtest.NewApp(t)
+	RegisterTracingHooks(app)
+	app.OnServe().BindFunc(func(se *core.ServeEvent) error {
+		se.Router.GET("/api/tracing_test/{id}", func(e *core.RequestEvent) error {
+			_, child := startSpan(e.Request.Context(), "legacy_import.read")
+			err := errors.New("corrupt database")
+			endSpan(child, err)
+			return e.Error(http.StatusInternalServerError, "Failed to read activity logs", err)
+		})
+		return se.Next()
+	})
+	handler := backendtest.NewHandler(t, app)
+
+	request := httptest.NewRequest("GET", "/api/tracing_test/abc", nil)
+	request.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	handler.ServeHTTP(httptest.NewRecorder(), request)
+
+	spans := recorder.Ended()
+	if len(spans) != 2 {
+		t.Fatalf("expected 2 ended spans, got %d", len(spans))
+	}
+	childSpan, parentSpan := spans[0], spans[1]
+	if parentSpan.Name() != "GET /api/tracing_test/{id}" {
+		t.Errorf("expected the request span to be named after its route, got %q", parentSpan.Name())
+	}
+	if parentSpan.SpanContext().TraceID().String() != "4bf92f3577b34da6a3ce929d0e0e4736" || parentSpan.Parent().SpanID().String() != "00f067aa0ba902b7" {
+		t.Errorf("expected the request span to continue the incoming trace, got trace %s with parent %s", parentSpan.SpanContext().TraceID(), parentSpan.Parent().SpanID())
+	}
+	if parentSpan.Status().Code != codes.Error {
+		t.Errorf("expected the request span to have an error status, got %+v", parentSpan.Status())
+	}
+	if childSpan.SpanContext().TraceID() != parentSpan.SpanContext().TraceID() || childSpan.Parent().SpanID() != parentSpan.SpanContext().SpanID() {
+		t.Errorf("expected the child span to belong to the request span, got trace %s with parent %s", childSpan.SpanContext().TraceID(), childSpan.Parent().SpanID())
+	}
+	if childSpan.Status().Code != codes.Error || childSpan.Status().Description != "corrupt database" {
+		t.Errorf("expected the child span to have an error status, got %+v", childSpan.Status())
+	}
+}
+
+func TestDBSpansOmitParameterValues(t *testing.T) {
+	testCases := []struct {
+		statement string
+		name      string
+		table     string
+	}{
+		{"SELECT `work_clock`.* FROM `work_clock` WHERE `description`='Doctor''s appointment' LIMIT 1", "db SELECT work_clock", "work_clock"},
+		{"SELECT count(*) FROM (SELECT `id` FROM `daily_summary` WHERE `clock`='')", "db SELECT daily_summary", "daily_summary"},
+		{"INSERT INTO `work_clock` (`id`, `description`) VALUES ('abc', 'Doctor''s appointment')", "db INSERT work_clock", "work_clock"},
+		{"UPDATE `clocks` SET `owner`='u1' WHERE `id`='c1'", "db UPDATE clocks", "clocks"},
+		{"PRAGMA optimize", "db PRAGMA", ""},
+	}
+
+	for _, tc := range testCases {
+		recorder := recordSpans(t)
+		recordDBSpan(context.Background(), tracingSlowStatement, tc.statement, nil)
+		spans := recorder.Ended()
+		if len(spans) != 1 {
+			t.Fatalf("expected a span of %q, got %d", tc.statement, len(spans))
+		}
+
+		dbSpan := spans[0]
+		if dbSpan.Name() != tc.name {
+			t.Errorf("expected the span of %q to be named %q, got %q", tc.statement, tc.name, dbSpan.Name())
+		}
+		var table string
+		for _, attribute := range dbSpan.Attributes() {
+			if value := attribute.Value.Emit(); strings.Contains(value, "Doctor") || strings.Contains(value, "u1") {
+				t.Errorf("expected the span of %q to omit the parameter values, got attribute %s=%s", tc.statement, attribute.Key, value)
+			}
+			if attribute.Key == "db.collection.name" {
+				table = attribute.Value.AsString()
+			}
+		}
+		if table != tc.table {
+			t.Errorf("expected the span of %q to name table %q, got %q", tc.statement, tc.table, table)
+		}
+	}
+
+	recorder := recordSpans(t)
+	recordDBSpan(context.Background(), tracingSlowStatement/2, "SELECT 1", nil)
+	if spans := recorder.Ended(); len(spans) != 0 {
+		t.Errorf("expected fast statements without a span to be skipped, got %d spans", len(spans))
+	}
+}
@@ -0,0 +1,326 @@
+// Vacation Module for PocketBase
+//
+// This module keeps the vacation budget of each clock and fiscal year (see Settings.FiscalYearStart),
+// named after the year it starts in: the days of the entitlement in the vacation_entitlements
+// collection, plus the days carried over from the previous year, minus the vacation days taken or
+// planned (see the absences module). Half day absences count as half
+// a day, absences with a number of minutes as the share of the target of their day they cover.
+//
+// Remaining days are carried over into the next year up to Settings.VacationCarryOverLimit. If
+// Settings.VacationCarryOverExpiry is set, the carried over days that were not used until that
+// day of the fiscal year expire; vacation always uses up the carried over days first.
+//
+// A daily job warns through an event and a push notification when carried over days are about
+// to expire, and towards the end of the fiscal year when remaining days exceed the carry-over limit.
+// Each warning is sent once per clock and year while the server is running.
+package backend
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/core"
+)
+
+// vacationWarningDays is the number of days before an expiry or the end of the year the
+// vacation warnings are sent.
+const vacationWarningDays = 30
+
+// VacationBudget is the vacation budget of a clock in a fiscal year. All values are in days.
+type VacationBudget struct {
+	Year        int     `json:"year"`         // Fiscal year of the budget, named after the year it starts in
+	Entitlement float64 `json:"entitlement"`  // Vacation days of the year
+	CarriedOver float64 `json:"carried_over"` // Remaining days carried over from the previous year
+	ExpiresOn   string  `json:"expires_on"`   // Day the carried over days expire (YYYY-MM-DD), empty if they don't
+	Expiring    float64 `json:"expiring"`     // Carried over days not used yet that will expire
+	Expired     float64 `json:"expired"`      // Carried over days that expired unused
+	Used        float64 `json:"used"`         // Vacation days of the year, including planned ones
+	Planned     float64 `json:"planned"`      // Vacation days after today
+	Remaining   float64 `json:"remaining"`    // Days left to be planned
+}
+
+// warnedVacations contains the keys of the vacation warnings already sent.
+// It is guarded by warnedVacationsMutex.
+var warnedVacations = map[string]bool{}
+var warnedVacationsMutex = sync.Mutex{}
+
+// RegisterVacationAPI registers the vacation budget endpoint and the daily vacation warnings
+// with the PocketBase server.
+// It creates the following route:
+// - GET /api/work_clock/vacation?year=&clock= - Returns the VacationBudget of the fiscal year, the current one if not given
+//
+// Parameters:
+// - app: The PocketBase application instance
+func RegisterVacationAPI(app core.App) {
+	app.OnServe().BindFunc(func(se *core.ServeEvent) error {
+		se.Router.GET("/api/work_clock/vacation", func(e *core.RequestEvent) error {
+			now := clockNow(app)
+			year := startOfLocalYear(now).Year()
+			if yearValue := e.Request.URL.Query().Get("year"); yearValue != "" {
+				var err error
+				year, err = strconv.Atoi(yearValue)
+				if err != nil || year < 1970 || year > 9999 {
+					return e.Error(http.StatusBadRequest, "Invalid 'year' (integer) parameter. Expected a year like 2025", nil)
+				}
+			}
+
+			clockID, err := requestClock(app, e)
+			if err != nil {
+				return err
+			}
+
+			budget, err := getVacationBudget(app, clockID, year, now)
+			if err != nil {
+				return e.Error(http.StatusInternalServerError, fmt.Sprintf("Failed to get vacation budget: %v", err), err)
+			}
+
+			// The budget also changes when carried over days expire, so only the ETag is used
+			return respondConditionalJSON(e, budget, time.Time{})
+		})
+
+		return se.Next()
+	})
+
+	app.Cron().MustAdd("vacation_warnings", "0 8 * * *", backgroundJobs.cronJob("vacation_warnings", func() {
+		sendVacationWarnings(app, clockNow(app))
+	}))
+}
+
+// getVacationBudget computes the vacation budget of a clock in a fiscal year. The carried over days
+// are computed year by year from the first fiscal year with an entitlement or a vacation.
+//
+// Parameters:
+// - app: The App interface (typically a PocketBase instance or transaction)
+// - clockID: The ID of the clock, an empty string for the default clock
+// - year: The fiscal year, named after the year it starts in
+// - now: The current time, which decides which days are planned and whether days expired
+//
+// Returns:
+// - The budget
+// - An error if the entitlements, vacations or work schedules could not be retrieved
+func getVacationBudget(app core.App, clockID string, year int, now time.Time) (*VacationBudget, error) {
+	entitlementRecords, err := app.FindRecordsByFilter("vacation_entitlements", "clock = {:clock} && year <= {:year}", "", 0, 0, dbx.Params{
+		"clock": clockParam(clockID),
+		"year":  year,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to find vacation entitlements: %w", err)
+	}
+
+	vacations, err := app.FindRecordsByFilter("absences", "clock = {:clock} && kind = 'vacation' && date < {:end}", "+date", 0, 0, dbx.Params{
+		"clock": clockParam(clockID),
+		"end":   time.Date(year+1, settings.FiscalYearStart, 1, 0, 0, 0, 0, time.Local).Format(time.DateOnly),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to find vacations: %w", err)
+	}
+
+	schedules, err := findWorkSchedules(app, clockID)
+	if err != nil {
+		return nil, err
+	}
+
+	first := year
+	entitlements := map[int]float64{}
+	for _, record := range entitlementRecords {
+		entitlements[record.GetInt("year")] = record.GetFloat("days")
+		first = min(first, record.GetInt("year"))
+	}
+
+	today := startOfLocalDay(now).Format(time.DateOnly)
+	budgets := map[int]*VacationBudget{}
+	usedBeforeExpiry := map[int]float64{}
+	for _, vacation := range vacations {
+		day, err := time.ParseInLocation(time.DateOnly, vacation.GetString("date"), time.Local)
+		if err != nil {
+			continue
+		}
+		vacationYear := startOfLocalYear(day).Year()
+		first = min(first, vacationYear)
+
+		budget, ok := budgets[vacationYear]
+		if !ok {
+			budget = &VacationBudget{Year: vacationYear}
+			budgets[vacationYear] = budget
+		}
+
+		days := vacationDays(absenceOf(vacation), schedules.target(day))
+		budget.Used += days
+		if vacation.GetString("date") > today {
+			budget.Planned += days
+		}
+		if expiresOn := vacationExpiry(vacationYear); expiresOn != "" && vacation.GetString("date") <= expiresOn {
+			usedBeforeExpiry[vacationYear] += days
+		}
+	}
+
+	var budget *VacationBudget
+	carriedOver := 0.0
+	for y := first; y <= year; y++ {
+		budget = budgets[y]
+		if budget == nil {
+			budget = &VacationBudget{Year: y}
+		}
+		budget.Entitlement = entitlements[y]
+		budget.CarriedOver = carriedOver
+		budget.ExpiresOn = vacationExpiry(y)
+
+		if budget.ExpiresOn != "" {
+			unused := max(budget.CarriedOver-usedBeforeExpiry[y], 0)
+			if today > budget.ExpiresOn {
+				budget.Expired = unused
+			} else {
+				budget.Expiring = unused
+			}
+		}
+
+		budget.Remaining = budget.Entitlement + budget.CarriedOver - budget.Used - budget.Expired
+		carriedOver = max(budget.Remaining, 0)
+		if settings.VacationCarryOverLimit >= 0 {
+			carriedOver = min(carriedOver, settings.VacationCarryOverLimit)
+		}
+	}
+
+	for _, value := range []*float64{&budget.Entitlement, &budget.CarriedOver, &budget.Expiring, &budget.Expired, &budget.Used, &budget.Planned, &budget.Remaining} {
+		*value = math.Round(*value*100) / 100
+	}
+	return budget, nil
+}
+
+// vacationDays determines how many vacation days an absence uses up.
+//
+// Parameters:
+// - absence: The absence
+// - target: The target of the day according to the work schedule
+//
+// Returns:
+// - 1 for a whole day, 0.5 for a half day, the covered share of the target for a number of minutes
+func vacationDays(absence dayOff, target time.Duration) float64 {
+	switch {
+	case absence.HalfDay:
+		return 0.5
+	case absence.Covered > 0:
+		if target <= 0 {
+			return 0
+		}
+		return min(absence.Covered.Seconds()/target.Seconds(), 1)
+	default:
+		return 1
+	}
+}
+
+// vacationExpiry returns the day the days carried over into a fiscal year expire.
+//
+// Parameters:
+// - year: The fiscal year, named after the year it starts in
+//
+// Returns:
+// - The day (YYYY-MM-DD), empty if carried over days don't expire
+func vacationExpiry(year int) string {
+	if settings.VacationCarryOverExpiry == "" {
+		return ""
+	}
+
+	dayIn := func(calendarYear int) time.Time {
+		day, err := time.Parse(time.DateOnly, fmt.Sprintf("%04d-%s", calendarYear, settings.VacationCarryOverExpiry))
+		if err != nil {
+			// February 29 in a year that is not a leap year
+			return time.Date(calendarYear, time.March, 1, 0, 0, 0, 0, time.UTC)
+		}
+		return day
+	}
+
+	// Days before the first month of the fiscal year lie in the following calendar year
+	day := dayIn(year)
+	if day.Month() < settings.FiscalYearStart {
+		day = dayIn(year + 1)
+	}
+	return day.Format(time.DateOnly)
+}
+
+// sendVacationWarnings warns about carried over days about to expire and, towards the end of the
+// fiscal year, about remaining days exceeding the carry-over limit. Each warning is sent once per
+// clock and fiscal year.
+//
+// Parameters:
+// - app: The App interface (typically a PocketBase instance or transaction)
+// - now: The reference time the budgets are checked at
+func sendVacationWarnings(app core.App, now time.Time) {
+	clocks, err := app.FindAllRecords("clocks")
+	if err != nil {
+		app.Logger().Error("failed to find clocks for vacation warnings", "error", err)
+		return
+	}
+
+	clockNames := map[string]string{"": "default"}
+	for _, clock := range clocks {
+		clockNames[clock.Id] = clock.GetString("name")
+	}
+
+	warnedVacationsMutex.Lock()
+	defer warnedVacationsMutex.Unlock()
+
+	today := startOfLocalDay(now)
+	for clockID, name := range clockNames {
+		budget, err := getVacationBudget(app, clockID, startOfLocalYear(today).Year(), now)
+		if err != nil {
+			app.Logger().Error("failed to get vacation budget for warnings", "clock", clockID, "error", err)
+			continue
+		}
+
+		for _, warning := range vacationWarnings(budget, name, today) {
+			key := fmt.Sprintf("%s/%d/%s", clockID, budget.Year, warning.Type)
+			if warnedVacations[key] {
+				continue
+			}
+			warnedVacations[key] = true
+
+			recordEvent(app, "vacation_warning", "warning", warning.Message, map[string]any{"clock_id": clockID, "year": budget.Year, "warning": warning.Type})
+			sendPushNotification(app, pushNotification{Title: "Vacation days", Message: warning.Message})
+		}
+	}
+}
+
+// vacationWarning is a warning about vacation days that will be lost.
+type vacationWarning struct {
+	Type    string // Machine-readable type, 'expiring' or 'over_carry_over_limit'
+	Message string // Human-readable message
+}
+
+// vacationWarnings determines the warnings of a vacation budget.
+//
+// Parameters:
+// - budget: The budget of the current fiscal year
+// - name: The name of the clock
+// - today: The local midnight of today
+//
+// Returns:
+// - The warnings, empty if no days will be lost soon
+func vacationWarnings(budget *VacationBudget, name string, today time.Time) []vacationWarning {
+	var warnings []vacationWarning
+
+	if budget.Expiring > 0 {
+		expiresOn, err := time.ParseInLocation(time.DateOnly, budget.ExpiresOn, time.Local)
+		if err == nil && !today.AddDate(0, 0, vacationWarningDays).Before(expiresOn) {
+			warnings = append(warnings, vacationWarning{
+				Type:    "expiring",
+				Message: fmt.Sprintf("%v carried over vacation days of the %s clock expire on %s", budget.Expiring, name, budget.ExpiresOn),
+			})
+		}
+	}
+
+	yearEnd := time.Date(budget.Year+1, settings.FiscalYearStart, 1, 0, 0, 0, 0, time.Local)
+	if limit := settings.VacationCarryOverLimit; limit >= 0 && budget.Remaining > limit && !today.AddDate(0, 0, vacationWarningDays).Before(yearEnd) {
+		warnings = append(warnings, vacationWarning{
+			Type:    "over_carry_over_limit",
+			Message: fmt.Sprintf("%v remaining vacation days of the %s clock exceed the carry-over limit of %v and will be lost at the end of the year", math.Round((budget.Remaining-limit)*100)/100, name, limit),
+		})
+	}
+
+	return warnings
+}
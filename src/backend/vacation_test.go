@@ -0,0 +1,129 @@
+package backend
+
+import (
+	"testing"
+	"time"
+
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/yerTools/simple-frontend-stack/src/backend/backendtest"
+)
+
+func TestVacationCarryOver(t *testing.T) {
+	originalSettings := settings
+	t.Cleanup(func() { settings = originalSettings })
+	settings.VacationCarryOverLimit = 5
+	settings.VacationCarryOverExpiry = "03-31"
+
+	app := backendtest.NewApp(t)
+
+	entitlements, err := app.FindCollectionByNameOrId("vacation_entitlements")
+	if err != nil {
+		t.Fatalf("failed to find vacation_entitlements collection: %v", err)
+	}
+	for year, days := range map[int]float64{2024: 10, 2025: 30} {
+		entitlement := core.NewRecord(entitlements)
+		entitlement.Set("year", year)
+		entitlement.Set("days", days)
+		if err := app.Save(entitlement); err != nil {
+			t.Fatalf("failed to save vacation entitlement: %v", err)
+		}
+	}
+
+	absences, err := app.FindCollectionByNameOrId("absences")
+	if err != nil {
+		t.Fatalf("failed to find absences collection: %v", err)
+	}
+	addVacation := func(date string, halfDay bool, minutes int) {
+		absence := core.NewRecord(absences)
+		absence.Set("date", date)
+		absence.Set("kind", "vacation")
+		absence.Set("half_day", halfDay)
+		absence.Set("minutes", minutes)
+		if err := app.Save(absence); err != nil {
+			t.Fatalf("failed to save absence: %v", err)
+		}
+	}
+	// 3 of 10 days in 2024, so 7 remain and 5 are carried over
+	addVacation("2024-07-01", false, 0)
+	addVacation("2024-07-02", false, 0)
+	addVacation("2024-07-03", false, 0)
+	// 1.5 days before the carried over days expire, 0.5 days after
+	addVacation("2025-02-03", false, 0)
+	addVacation("2025-02-04", true, 0)
+	addVacation("2025-05-05", false, 240)
+
+	budget, err := getVacationBudget(app, "", 2025, time.Date(2025, time.March, 10, 12, 0, 0, 0, time.Local))
+	if err != nil {
+		t.Fatalf("failed to get vacation budget: %v", err)
+	}
+	expected := VacationBudget{Year: 2025, Entitlement: 30, CarriedOver: 5, ExpiresOn: "2025-03-31", Expiring: 3.5, Used: 2, Planned: 0.5, Remaining: 33}
+	if *budget != expected {
+		t.Errorf("expected budget %+v before the expiry, got %+v", expected, *budget)
+	}
+	if warnings := vacationWarnings(budget, "default", time.Date(2025, time.March, 10, 0, 0, 0, 0, time.Local)); len(warnings) != 1 || warnings[0].Type != "expiring" {
+		t.Errorf("expected a single expiring warning, got %v", warnings)
+	}
+
+	budget, err = getVacationBudget(app, "", 2025, time.Date(2025, time.June, 1, 12, 0, 0, 0, time.Local))
+	if err != nil {
+		t.Fatalf("failed to get vacation budget: %v", err)
+	}
+	expected = VacationBudget{Year: 2025, Entitlement: 30, CarriedOver: 5, ExpiresOn: "2025-03-31", Expired: 3.5, Used: 2, Remaining: 29.5}
+	if *budget != expected {
+		t.Errorf("expected budget %+v after the expiry, got %+v", expected, *budget)
+	}
+	if warnings := vacationWarnings(budget, "default", time.Date(2025, time.December, 10, 0, 0, 0, 0, time.Local)); len(warnings) != 1 || warnings[0].Type != "over_carry_over_limit" {
+		t.Errorf("expected a single carry-over limit warning, got %v", warnings)
+	}
+}
+
+func TestVacationFiscalYear(t *testing.T) {
+	originalSettings := settings
+	t.Cleanup(func() { settings = originalSettings })
+	settings.FiscalYearStart = time.April
+	settings.VacationCarryOverLimit = -1
+	settings.VacationCarryOverExpiry = "06-30"
+
+	app := backendtest.NewApp(t)
+
+	entitlements, err := app.FindCollectionByNameOrId("vacation_entitlements")
+	if err != nil {
+		t.Fatalf("failed to find vacation_entitlements collection: %v", err)
+	}
+	for year, days := range map[int]float64{2024: 10, 2025: 20} {
+		entitlement := core.NewRecord(entitlements)
+		entitlement.Set("year", year)
+		entitlement.Set("days", days)
+		if err := app.Save(entitlement); err != nil {
+			t.Fatalf("failed to save vacation entitlement: %v", err)
+		}
+	}
+
+	absences, err := app.FindCollectionByNameOrId("absences")
+	if err != nil {
+		t.Fatalf("failed to find absences collection: %v", err)
+	}
+	// January to March belong to the fiscal year that started in April of the previous year
+	for _, date := range []string{"2025-02-03", "2025-03-31", "2025-05-05", "2026-01-12", "2026-04-01"} {
+		absence := core.NewRecord(absences)
+		absence.Set("date", date)
+		absence.Set("kind", "vacation")
+		if err := app.Save(absence); err != nil {
+			t.Fatalf("failed to save absence: %v", err)
+		}
+	}
+
+	budget, err := getVacationBudget(app, "", 2025, time.Date(2025, time.August, 1, 12, 0, 0, 0, time.Local))
+	if err != nil {
+		t.Fatalf("failed to get vacation budget: %v", err)
+	}
+	expected := VacationBudget{Year: 2025, Entitlement: 20, CarriedOver: 8, ExpiresOn: "2025-06-30", Expired: 7, Used: 2, Planned: 1, Remaining: 19}
+	if *budget != expected {
+		t.Errorf("expected budget %+v, got %+v", expected, *budget)
+	}
+
+	settings.VacationCarryOverExpiry = "02-15"
+	if expiresOn := vacationExpiry(2025); expiresOn != "2026-02-15" {
+		t.Errorf("expected the carried over days to expire within the fiscal year, got %s", expiresOn)
+	}
+}
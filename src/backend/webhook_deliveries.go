@@ -0,0 +1,258 @@
+// Webhook Deliveries Module for PocketBase
+//
+// This module records every attempt to deliver a webhook event in the webhook_deliveries
+// collection, with the request body, the response status, the beginning of the response body and
+// the error of failed attempts. Superusers can list the deliveries to find out why a receiver
+// missed an event, and replay a delivery once the receiver is fixed. A replay sends the recorded
+// request body to the recorded URL again and is recorded as a new delivery referring to the
+// original one.
+//
+// Deliveries older than webhookDeliveriesRetention are removed daily.
+package backend
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/apis"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+const (
+	// webhookResponseSnapshotSize is the maximum number of bytes of a response body that are recorded
+	webhookResponseSnapshotSize = 2000
+
+	// webhookDeliveriesRetention is the duration deliveries are kept for
+	webhookDeliveriesRetention = 30 * 24 * time.Hour
+
+	// defaultWebhookDeliveriesLimit is the number of deliveries listed without 'limit' parameter
+	defaultWebhookDeliveriesLimit = 50
+
+	// maxWebhookDeliveriesLimit is the maximum number of deliveries listed at once
+	maxWebhookDeliveriesLimit = 500
+)
+
+// WebhookDeliveryEntry is a delivery attempt as returned by the webhook deliveries endpoints.
+type WebhookDeliveryEntry struct {
+	ID           string          `json:"id"`            // ID of the delivery
+	URL          string          `json:"url"`           // The webhook URL the event was delivered to
+	Event        string          `json:"event"`         // Name of the delivered event
+	RequestBody  json.RawMessage `json:"request_body"`  // The JSON body that was sent
+	Succeeded    bool            `json:"succeeded"`     // Whether the receiver responded with a 2xx status
+	Status       int             `json:"status"`        // HTTP status of the response, 0 if no response was received
+	ResponseBody string          `json:"response_body"` // The beginning of the response body
+	Error        string          `json:"error"`         // Why the delivery failed, empty for successful deliveries
+	DurationMs   int64           `json:"duration_ms"`   // Duration of the delivery in milliseconds
+	ReplayOf     string          `json:"replay_of"`     // ID of the replayed delivery, empty for original deliveries
+	Created      time.Time       `json:"created"`       // Time of the delivery
+}
+
+// RegisterWebhookDeliveriesAPI registers the webhook delivery endpoints and the daily removal of
+// old deliveries with the PocketBase server. All routes require superuser authentication.
+// It creates the following routes:
+// - GET /api/webhooks/deliveries?failed=&event=&limit= - Lists the latest deliveries, newest first, 'failed=true' lists only failures
+// - POST /api/webhooks/deliveries/{id}/replay - Delivers the request of a delivery again and returns the new delivery
+//
+// Parameters:
+// - app: The PocketBase application instance
+func RegisterWebhookDeliveriesAPI(app core.App) {
+	app.Cron().MustAdd("webhook_deliveries_cleanup", "30 3 * * *", backgroundJobs.cronJob("webhook_deliveries_cleanup", func() {
+		if err := deleteOldWebhookDeliveries(app, time.Now().Add(-webhookDeliveriesRetention)); err != nil {
+			app.Logger().Error("failed to delete old webhook deliveries", "error", err)
+		}
+	}))
+
+	app.OnServe().BindFunc(func(se *core.ServeEvent) error {
+		group := se.Router.Group("/api/webhooks/deliveries")
+		group.Bind(apis.RequireSuperuserAuth())
+
+		group.GET("", func(e *core.RequestEvent) error {
+			query := e.Request.URL.Query()
+
+			failed := false
+			if failedValue := query.Get("failed"); failedValue != "" {
+				var err error
+				failed, err = parseBoolParam(failedValue, "failed")
+				if err != nil {
+					return e.Error(http.StatusBadRequest, err.Error(), nil)
+				}
+			}
+
+			limit := defaultWebhookDeliveriesLimit
+			if limitValue := query.Get("limit"); limitValue != "" {
+				var err error
+				limit, err = strconv.Atoi(limitValue)
+				if err != nil || limit < 1 || limit > maxWebhookDeliveriesLimit {
+					return e.Error(http.StatusBadRequest, fmt.Sprintf("Invalid 'limit' (integer) parameter. Expected a value between 1 and %d", maxWebhookDeliveriesLimit), nil)
+				}
+			}
+
+			deliveries, err := findWebhookDeliveries(app, failed, query.Get("event"), limit)
+			if err != nil {
+				return e.Error(http.StatusInternalServerError, fmt.Sprintf("Failed to find webhook deliveries: %v", err), err)
+			}
+
+			return e.JSON(http.StatusOK, deliveries)
+		})
+
+		group.POST("/{id}/replay", func(e *core.RequestEvent) error {
+			delivery, err := replayWebhookDelivery(app, e.Request.PathValue("id"))
+			if err != nil {
+				return e.Error(http.StatusNotFound, err.Error(), nil)
+			}
+
+			return e.JSON(http.StatusOK, delivery)
+		})
+
+		return se.Next()
+	})
+}
+
+// recordWebhookDelivery delivers an encoded webhook event to a single URL and records the attempt.
+// Failing to record the attempt is only logged.
+//
+// Parameters:
+// - app: The App interface used to save the delivery
+// - url: The webhook URL
+// - event: The name of the event
+// - body: The JSON encoded webhook event
+// - replayOf: The ID of the replayed delivery, an empty string for original deliveries
+//
+// Returns:
+// - The delivery, its ID is empty if it could not be recorded
+func recordWebhookDelivery(app core.App, url string, event string, body []byte, replayOf string) WebhookDeliveryEntry {
+	started := time.Now()
+	status, responseBody, err := postWebhook(url, body)
+
+	delivery := WebhookDeliveryEntry{
+		URL:          url,
+		Event:        event,
+		RequestBody:  body,
+		Succeeded:    err == nil,
+		Status:       status,
+		ResponseBody: strings.ToValidUTF8(responseBody, ""),
+		DurationMs:   time.Since(started).Milliseconds(),
+		ReplayOf:     replayOf,
+		Created:      started,
+	}
+	if err != nil {
+		delivery.Error = err.Error()
+	}
+
+	collection, err := app.FindCollectionByNameOrId("webhook_deliveries")
+	if err != nil {
+		app.Logger().Error("failed to find webhook_deliveries collection", "error", err)
+		return delivery
+	}
+
+	record := core.NewRecord(collection)
+	record.Set("url", delivery.URL)
+	record.Set("event", delivery.Event)
+	record.Set("request_body", types.JSONRaw(body))
+	record.Set("succeeded", delivery.Succeeded)
+	record.Set("status", delivery.Status)
+	record.Set("response_body", delivery.ResponseBody)
+	record.Set("error", delivery.Error)
+	record.Set("duration_ms", delivery.DurationMs)
+	record.Set("replay_of", delivery.ReplayOf)
+
+	if err := app.Save(record); err != nil {
+		app.Logger().Error("failed to record webhook delivery", "event", event, "url", url, "error", err)
+		return delivery
+	}
+
+	delivery.ID = record.Id
+	delivery.Created = record.GetDateTime("created").Time()
+	return delivery
+}
+
+// replayWebhookDelivery delivers the request of a recorded delivery again.
+//
+// Parameters:
+// - app: The App interface (typically a PocketBase instance or transaction)
+// - deliveryID: The ID of the delivery to replay
+//
+// Returns:
+// - The new delivery, which failed if the receiver still rejects the event
+// - An error if the delivery does not exist
+func replayWebhookDelivery(app core.App, deliveryID string) (*WebhookDeliveryEntry, error) {
+	record, err := app.FindRecordById("webhook_deliveries", deliveryID)
+	if err != nil {
+		return nil, fmt.Errorf("webhook delivery with id '%s' does not exist", deliveryID)
+	}
+
+	delivery := recordWebhookDelivery(app, record.GetString("url"), record.GetString("event"), []byte(record.GetString("request_body")), record.Id)
+	return &delivery, nil
+}
+
+// findWebhookDeliveries finds the latest webhook deliveries.
+//
+// Parameters:
+// - app: The App interface (typically a PocketBase instance or transaction)
+// - failed: Whether only failed deliveries are listed
+// - event: The name of the event, an empty string for all events
+// - limit: The maximum number of deliveries
+//
+// Returns:
+// - The deliveries, newest first
+// - An error if the database query fails
+func findWebhookDeliveries(app core.App, failed bool, event string, limit int) ([]WebhookDeliveryEntry, error) {
+	var conditions []string
+	params := dbx.Params{}
+
+	if failed {
+		conditions = append(conditions, "succeeded = false")
+	}
+	if event != "" {
+		conditions = append(conditions, "event = {:event}")
+		params["event"] = event
+	}
+
+	records, err := app.FindRecordsByFilter("webhook_deliveries", strings.Join(conditions, " && "), "-created", limit, 0, params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find webhook deliveries: %w", err)
+	}
+
+	deliveries := make([]WebhookDeliveryEntry, 0, len(records))
+	for _, record := range records {
+		deliveries = append(deliveries, WebhookDeliveryEntry{
+			ID:           record.Id,
+			URL:          record.GetString("url"),
+			Event:        record.GetString("event"),
+			RequestBody:  json.RawMessage(record.GetString("request_body")),
+			Succeeded:    record.GetBool("succeeded"),
+			Status:       record.GetInt("status"),
+			ResponseBody: record.GetString("response_body"),
+			Error:        record.GetString("error"),
+			DurationMs:   int64(record.GetInt("duration_ms")),
+			ReplayOf:     record.GetString("replay_of"),
+			Created:      record.GetDateTime("created").Time(),
+		})
+	}
+
+	return deliveries, nil
+}
+
+// deleteOldWebhookDeliveries removes the deliveries recorded before a point in time.
+//
+// Parameters:
+// - app: The App interface (typically a PocketBase instance or transaction)
+// - before: The time before which deliveries are removed
+//
+// Returns:
+// - An error if the database query fails
+func deleteOldWebhookDeliveries(app core.App, before time.Time) error {
+	_, err := app.DB().NewQuery("DELETE FROM webhook_deliveries WHERE created < {:before}").
+		Bind(dbx.Params{"before": dateTimeParam(before)}).
+		Execute()
+	if err != nil {
+		return fmt.Errorf("failed to delete webhook deliveries: %w", err)
+	}
+	return nil
+}
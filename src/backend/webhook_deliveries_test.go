@@ -0,0 +1,53 @@
+package backend
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/yerTools/simple-frontend-stack/src/backend/backendtest"
+)
+
+func TestWebhookDeliveryReplay(t *testing.T) {
+	app := backendtest.NewApp(t)
+
+	var healthy atomic.Bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !healthy.Load() {
+			http.Error(w, "receiver unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+
+	failed := recordWebhookDelivery(app, server.URL, "clock_in", []byte(`{"event":"clock_in"}`), "")
+	if failed.ID == "" || failed.Succeeded || failed.Status != http.StatusServiceUnavailable {
+		t.Fatalf("expected a recorded failed delivery, got %+v", failed)
+	}
+	if failed.ResponseBody != "receiver unavailable\n" {
+		t.Errorf("expected the response body to be recorded, got %q", failed.ResponseBody)
+	}
+
+	healthy.Store(true)
+	replayed, err := replayWebhookDelivery(app, failed.ID)
+	if err != nil {
+		t.Fatalf("failed to replay webhook delivery: %v", err)
+	}
+	if !replayed.Succeeded || replayed.ReplayOf != failed.ID || string(replayed.RequestBody) != `{"event":"clock_in"}` {
+		t.Errorf("unexpected replayed delivery %+v", replayed)
+	}
+
+	failures, err := findWebhookDeliveries(app, true, "", defaultWebhookDeliveriesLimit)
+	if err != nil {
+		t.Fatalf("failed to find webhook deliveries: %v", err)
+	}
+	if len(failures) != 1 || failures[0].ID != failed.ID {
+		t.Errorf("expected only the original delivery to be listed as failure, got %+v", failures)
+	}
+
+	if _, err := replayWebhookDelivery(app, "missing"); err == nil {
+		t.Error("expected replaying an unknown delivery to fail")
+	}
+}
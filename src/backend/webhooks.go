@@ -0,0 +1,158 @@
+// Webhooks Module for PocketBase
+//
+// This module delivers events of the backend modules (such as budget alerts) and the clock events
+// to external systems. Every event is sent as a JSON POST request to all configured webhook URLs:
+//
+//	{"event": "project.budget_threshold_reached", "timestamp": "2025-04-14T10:00:00Z", "data": {...}}
+//
+// The webhook URLs are configured through the integrations API or in WEBHOOK_URLS.
+// Deliveries happen asynchronously, so a slow or unreachable receiver never blocks a clock operation.
+// Every delivery is recorded with a snapshot of the request and response (see the webhook
+// deliveries module), so failed deliveries can be inspected and replayed.
+package backend
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/pocketbase/pocketbase/core"
+)
+
+// webhookTimeout is the maximum duration of a single webhook delivery.
+const webhookTimeout = 10 * time.Second
+
+// WebhooksConfig is the configuration of the webhooks integration.
+type WebhooksConfig struct {
+	URLs []string `json:"urls"` // The URLs all webhook events are delivered to
+}
+
+// validate checks that at least one webhook URL is configured and all of them are valid.
+func (c WebhooksConfig) validate() error {
+	if len(c.URLs) == 0 {
+		return fmt.Errorf("missing 'urls' (string array) configuration")
+	}
+	for _, url := range c.URLs {
+		if err := validateIntegrationURL(url, "urls", "https", "http"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// webhookEvent is the JSON body sent to the webhook URLs.
+type webhookEvent struct {
+	Event     string    `json:"event"`     // Name of the event, e.g. "project.budget_threshold_reached"
+	Timestamp time.Time `json:"timestamp"` // Time the event occurred
+	Data      any       `json:"data"`      // Event specific payload
+}
+
+// RegisterWebhookHooks subscribes the webhooks to the clock events. The following events are sent:
+// - "work_clock.clock_in" and "work_clock.clock_out" with the ID and timestamp of the created record
+// - "work_clock.session_closed" with the closed session
+//
+// Parameters:
+// - app: The PocketBase application instance
+func RegisterWebhookHooks(app core.App) {
+	sendClockEvent := func(event string) func(e *ClockEvent) error {
+		return func(e *ClockEvent) error {
+			sendWebhookEvent(e.App, event, map[string]any{
+				"id":        e.Record.Id,
+				"timestamp": e.Record.GetDateTime("timestamp").Time(),
+			})
+			return e.Next()
+		}
+	}
+
+	OnClockIn().BindFunc(sendClockEvent("work_clock.clock_in"))
+	OnClockOut().BindFunc(sendClockEvent("work_clock.clock_out"))
+	OnSessionClosed().BindFunc(func(e *SessionClosedEvent) error {
+		sendWebhookEvent(e.App, "work_clock.session_closed", e.Session)
+		return e.Next()
+	})
+}
+
+// sendWebhookEvent delivers an event to all configured webhook URLs in the background.
+//
+// Parameters:
+// - app: The App interface used for logging and loading the configuration
+// - event: The name of the event
+// - data: The event specific payload, must be serializable to JSON
+func sendWebhookEvent(app core.App, event string, data any) {
+	config, ok := integrationConfig[WebhooksConfig](app, "webhooks")
+	if !ok {
+		return
+	}
+
+	body, err := json.Marshal(webhookEvent{
+		Event:     event,
+		Timestamp: time.Now(),
+		Data:      data,
+	})
+	if err != nil {
+		app.Logger().Error("failed to encode webhook event", "event", event, "error", err)
+		return
+	}
+
+	for _, url := range config.URLs {
+		backgroundJobs.goJob("webhook", func() {
+			delivery := recordWebhookDelivery(app, url, event, body, "")
+			if delivery.Error != "" {
+				app.Logger().Error("failed to deliver webhook event", "event", event, "url", url, "error", delivery.Error)
+				recordEvent(app, "webhook_failed", "error", fmt.Sprintf("Failed to deliver %s event: %s", event, delivery.Error),
+					map[string]any{"event": event, "url": url, "delivery_id": delivery.ID})
+			}
+		})
+	}
+}
+
+// deliverWebhook posts an encoded webhook event to a single URL.
+//
+// Parameters:
+// - url: The webhook URL
+// - body: The JSON encoded webhook event
+//
+// Returns:
+// - An error if the request fails or the receiver does not respond with a 2xx status code
+func deliverWebhook(url string, body []byte) error {
+	_, _, err := postWebhook(url, body)
+	return err
+}
+
+// postWebhook posts an encoded webhook event to a single URL and keeps the beginning of the response.
+//
+// Parameters:
+// - url: The webhook URL
+// - body: The JSON encoded webhook event
+//
+// Returns:
+// - The HTTP status of the response, 0 if no response was received
+// - The beginning of the response body, at most webhookResponseSnapshotSize bytes
+// - An error if the request fails or the receiver does not respond with a 2xx status code
+func postWebhook(url string, body []byte) (int, string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), webhookTimeout)
+	defer cancel()
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return 0, "", fmt.Errorf("failed to create request: %w", err)
+	}
+	request.Header.Set("Content-Type", "application/json")
+
+	response, err := outboundClient().Do(request)
+	if err != nil {
+		return 0, "", fmt.Errorf("failed to send request: %w", err)
+	}
+	defer response.Body.Close()
+
+	snapshot, _ := io.ReadAll(io.LimitReader(response.Body, webhookResponseSnapshotSize))
+	if response.StatusCode < 200 || response.StatusCode >= 300 {
+		return response.StatusCode, string(snapshot), fmt.Errorf("receiver responded with status %d", response.StatusCode)
+	}
+
+	return response.StatusCode, string(snapshot), nil
+}
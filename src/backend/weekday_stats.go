@@ -0,0 +1,165 @@
+// Weekday Statistics Module for PocketBase
+//
+// This module averages the working habits per weekday: when work usually starts and ends, and
+// how long is worked. Reminders can use the averages to pick sensible default times, e.g. to remind
+// about clocking out shortly after the usual end of a Friday.
+//
+// Only worked days within the window are averaged, so days off don't move the averages. Start
+// and end are the first clock in and the last clock out of the sessions starting on a day, as
+// local time of day. Open sessions are ignored.
+package backend
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pocketbase/pocketbase/core"
+)
+
+const (
+	// defaultWeekdayStatsDays is the number of days before today averaged without 'days' parameter
+	defaultWeekdayStatsDays = 90
+
+	// maxWeekdayStatsDays is the maximum number of days averaged at once
+	maxWeekdayStatsDays = 730
+)
+
+// WeekdayStatsEntry contains the averages of a weekday.
+type WeekdayStatsEntry struct {
+	Weekday              string `json:"weekday"`                // Name of the weekday in lower case, e.g. 'monday'
+	Days                 int    `json:"days"`                   // Number of worked days averaged
+	AverageStartSeconds  int64  `json:"average_start_seconds"`  // Average start as seconds since local midnight
+	AverageStart         string `json:"average_start"`          // Average start as local time of day (HH:MM), empty without worked days
+	AverageEndSeconds    int64  `json:"average_end_seconds"`    // Average end as seconds since local midnight, may exceed a day for sessions ending after midnight
+	AverageEnd           string `json:"average_end"`            // Average end as local time of day (HH:MM), empty without worked days
+	AverageWorkedSeconds int64  `json:"average_worked_seconds"` // Average time that counts as work time
+	AverageWorked        string `json:"average_worked"`         // Average worked time formatted in the configured duration format
+}
+
+// RegisterWeekdayStatsAPI registers the weekday statistics endpoint with the PocketBase server.
+// It creates the following route:
+// - GET /api/work_clock/stats/weekdays?days=&clock= - Averages start, end and worked time per weekday over the last 'days' days, first day of the week first, supports conditional requests
+//
+// Parameters:
+// - app: The PocketBase application instance
+func RegisterWeekdayStatsAPI(app core.App) {
+	app.OnServe().BindFunc(func(se *core.ServeEvent) error {
+		se.Router.GET("/api/work_clock/stats/weekdays", func(e *core.RequestEvent) error {
+			days := defaultWeekdayStatsDays
+			if daysValue := e.Request.URL.Query().Get("days"); daysValue != "" {
+				var err error
+				days, err = strconv.Atoi(daysValue)
+				if err != nil || days < 1 || days > maxWeekdayStatsDays {
+					return e.Error(http.StatusBadRequest, fmt.Sprintf("Invalid 'days' (integer) parameter. Expected a value between 1 and %d", maxWeekdayStatsDays), nil)
+				}
+			}
+
+			clockID, err := requestClock(app, e)
+			if err != nil {
+				return err
+			}
+
+			today := startOfLocalDay(clockNow(app))
+			cacheKey := fmt.Sprintf("weekday_stats|%s|%s|%d", clockID, today.Format(time.DateOnly), days)
+			stats, err := cachedReport(app, cacheKey, func(now time.Time) ([]WeekdayStatsEntry, error) {
+				return getWeekdayStats(app, clockID, today.AddDate(0, 0, -days), today)
+			})
+			if err != nil {
+				return e.Error(http.StatusInternalServerError, fmt.Sprintf("Failed to get weekday statistics: %v", err), err)
+			}
+
+			return respondConditionalJSON(e, stats, workClockLastModified(app))
+		})
+
+		return se.Next()
+	})
+}
+
+// getWeekdayStats averages the worked days within a range per weekday.
+//
+// Parameters:
+// - app: The App interface (typically a PocketBase instance or transaction)
+// - clockID: The ID of the clock, an empty string for the default clock
+// - from: The local midnight starting the range (inclusive)
+// - to: The local midnight ending the range (exclusive)
+//
+// Returns:
+// - The averages of all weekdays, the configured first day of the week first
+// - An error if the sessions could not be retrieved
+func getWeekdayStats(app core.App, clockID string, from, to time.Time) ([]WeekdayStatsEntry, error) {
+	sessions, err := findWorkSessions(app, clockID, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	// workedDay collects the sessions of a day
+	type workedDay struct {
+		Weekday time.Weekday
+		Start   int64 // First clock in as seconds since local midnight
+		End     int64 // Last clock out as seconds since local midnight
+		Worked  time.Duration
+	}
+
+	var days []workedDay
+	var current time.Time
+	for _, session := range sessions {
+		if session.ClockOut == nil {
+			continue
+		}
+
+		day := startOfLocalDay(session.Start())
+		if len(days) == 0 || !day.Equal(current) {
+			current = day
+			days = append(days, workedDay{Weekday: day.Weekday(), Start: int64(session.Start().Sub(day).Seconds())})
+		}
+
+		last := &days[len(days)-1]
+		last.End = max(last.End, int64(session.End(time.Time{}).Sub(day).Seconds()))
+		last.Worked += time.Duration(float64(session.Duration(time.Time{})) * categoryFactor(session.ClockIn.GetString("category")))
+	}
+
+	stats := make([]WeekdayStatsEntry, 0, 7)
+	for i := range 7 {
+		weekday := time.Weekday((int(settings.WeekStart) + i) % 7)
+		entry := WeekdayStatsEntry{Weekday: strings.ToLower(weekday.String())}
+
+		var start, end int64
+		var worked time.Duration
+		for _, day := range days {
+			if day.Weekday != weekday {
+				continue
+			}
+			start += day.Start
+			end += day.End
+			worked += day.Worked
+			entry.Days++
+		}
+
+		if entry.Days > 0 {
+			entry.AverageStartSeconds = start / int64(entry.Days)
+			entry.AverageStart = formatTimeOfDay(entry.AverageStartSeconds)
+			entry.AverageEndSeconds = end / int64(entry.Days)
+			entry.AverageEnd = formatTimeOfDay(entry.AverageEndSeconds)
+			entry.AverageWorkedSeconds = int64(worked.Seconds()) / int64(entry.Days)
+		}
+		entry.AverageWorked = formatResponseDuration(entry.AverageWorkedSeconds)
+
+		stats = append(stats, entry)
+	}
+
+	return stats, nil
+}
+
+// formatTimeOfDay formats seconds since midnight as time of day.
+//
+// Parameters:
+// - seconds: The seconds since midnight, values of a day or more wrap around
+//
+// Returns:
+// - The time of day (HH:MM)
+func formatTimeOfDay(seconds int64) string {
+	return fmt.Sprintf("%02d:%02d", seconds/3600%24, seconds%3600/60)
+}
@@ -12,21 +12,18 @@ package backend
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"strconv"
-	"sync"
+	"strings"
 	"time"
 
 	"github.com/pocketbase/dbx"
-	"github.com/pocketbase/pocketbase"
 	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tools/types"
 )
 
-// workClockMutex provides thread-safety for clock operations to prevent race conditions
-// when multiple requests attempt to modify the clock state simultaneously.
-var workClockMutex = sync.Mutex{}
-
 // callSucceeded returns a success response to the client.
 // It sets HTTP status code 200 and returns a JSON response with success: true
 //
@@ -63,7 +60,18 @@ func parseBoolParam(paramValue string, paramName string) (bool, error) {
 	return boolValue, nil
 }
 
+// timeParamLayouts are the text formats accepted for time parameters, in order of precedence.
+var timeParamLayouts = []string{time.RFC3339, time.RFC3339Nano, "2006-01-02 15:04:05.999Z", "2006-01-02 15:04:05Z"}
+
 // parseTimeParam parses a time parameter from form data with validation.
+// Hardware clients often find epoch integers easier to produce than RFC3339, so the following
+// formats are accepted, in order of precedence:
+// 1. RFC3339 (or the PocketBase date format)
+// 2. Epoch integers with an explicit unit, e.g. 1743498000s or 1743498000000ms
+// 3. Plain epoch integers, which are seconds with up to 10 digits and milliseconds with 13 digits
+//
+// Plain integers of other lengths are rejected as ambiguous, since e.g. 12 digits are either
+// milliseconds before 2001 or seconds after the year 5000.
 //
 // Parameters:
 // - paramValue: The string value from the form
@@ -71,33 +79,201 @@ func parseBoolParam(paramValue string, paramName string) (bool, error) {
 //
 // Returns:
 // - A time.Time representing the parsed timestamp
-// - An error if the value is missing or not in RFC3339 format
+// - An error if the value is missing, not in one of the accepted formats or ambiguous
 func parseTimeParam(paramValue string, paramName string) (time.Time, error) {
 	if paramValue == "" {
 		return time.Time{}, fmt.Errorf("missing '%s' (string) parameter", paramName)
 	}
 
-	timeValue, err := time.Parse(time.RFC3339, paramValue)
-	if err != nil {
-		var secondErr error
-		for _, layout := range []string{time.RFC3339Nano, "2006-01-02 15:04:05.999Z", "2006-01-02 15:04:05Z"} {
-			timeValue, secondErr = time.Parse(layout, paramValue)
-			if secondErr == nil {
-				err = nil
-				break
+	for _, layout := range timeParamLayouts {
+		if timeValue, err := time.Parse(layout, paramValue); err == nil {
+			return timeValue, nil
+		}
+	}
+
+	digits, unit := paramValue, ""
+	if value, ok := strings.CutSuffix(paramValue, "ms"); ok {
+		digits, unit = value, "ms"
+	} else if value, ok := strings.CutSuffix(paramValue, "s"); ok {
+		digits, unit = value, "s"
+	}
+
+	epoch, err := strconv.ParseInt(digits, 10, 64)
+	if err != nil || strings.Trim(digits, "0123456789") != "" {
+		return time.Time{}, fmt.Errorf("invalid '%s' format. Expected RFC3339, epoch seconds or epoch milliseconds", paramName)
+	}
+
+	if unit == "" {
+		switch {
+		case len(digits) <= 10:
+			unit = "s"
+		case len(digits) == 13:
+			unit = "ms"
+		default:
+			return time.Time{}, fmt.Errorf("ambiguous '%s' value '%s'. Expected epoch seconds with up to 10 digits, epoch milliseconds with 13 digits or a unit suffix like 's' or 'ms'", paramName, paramValue)
+		}
+	}
+
+	if unit == "s" {
+		return time.Unix(epoch, 0).UTC(), nil
+	}
+	return time.UnixMilli(epoch).UTC(), nil
+}
+
+// validateNotInFuture ensures that a manually entered timestamp does not lie further in the
+// future than the configured maximum offset. Such timestamps are almost always typos (e.g. 2035
+// instead of 2025) and would otherwise become the "latest" record, breaking the clock state.
+//
+// Superusers can bypass the check by passing 'allow_future=true', e.g. to enter pre-planned absences.
+//
+// Parameters:
+// - e: The RequestEvent from the HTTP handler
+// - paramName: The name of the parameter (used in error messages)
+// - timestamp: The timestamp to validate
+//
+// Returns:
+// - An API error if the timestamp is too far in the future and the check was not overridden
+func validateNotInFuture(e *core.RequestEvent, paramName string, timestamp time.Time) error {
+	service := workClockServiceOf(e.App)
+	maxFutureOffset := service.Settings().MaxFutureOffset
+	if !timestamp.After(service.Now().Add(maxFutureOffset)) {
+		return nil
+	}
+
+	if allowFutureValue := e.Request.FormValue("allow_future"); allowFutureValue != "" {
+		allowFuture, err := parseBoolParam(allowFutureValue, "allow_future")
+		if err != nil {
+			return e.Error(http.StatusBadRequest, err.Error(), nil)
+		}
+
+		if allowFuture {
+			if !e.HasSuperuserAuth() {
+				return e.Error(http.StatusForbidden, "Only superusers can override the future timestamp check", nil)
 			}
+			return nil
 		}
+	}
 
+	return e.Error(http.StatusBadRequest, fmt.Sprintf("'%s' lies more than %s in the future. Superusers can override this check with 'allow_future=true'", paramName, maxFutureOffset), nil)
+}
+
+// sessionTooLongError is returned when a plain clock out would close a session that exceeds
+// the configured maximum session duration. This usually means that the user forgot to clock out.
+type sessionTooLongError struct {
+	Start       time.Time     // Start of the open session
+	Duration    time.Duration // Duration the session would have when closed now
+	MaxDuration time.Duration // Configured maximum session duration
+}
+
+// Error implements the error interface.
+func (err *sessionTooLongError) Error() string {
+	return fmt.Sprintf("the open session started at %s would last %s, exceeding the maximum session duration of %s",
+		err.Start.Format(time.RFC3339), err.Duration.Round(time.Minute), err.MaxDuration)
+}
+
+// recordChangedError is returned by modifying or deleting a work clock record whose timestamp
+// differs from the timestamp the client expects, because the record was changed since the client
+// read it.
+type recordChangedError struct {
+	RecordID string    // ID of the changed record
+	Expected time.Time // Timestamp the client expected
+	Actual   time.Time // Current timestamp of the record
+}
+
+// Error implements the error interface.
+func (err *recordChangedError) Error() string {
+	return fmt.Sprintf("the work clock record with id '%s' was changed in the meantime, its timestamp is %s instead of %s",
+		err.RecordID, err.Actual.Format(time.RFC3339), err.Expected.Format(time.RFC3339))
+}
+
+// checkExpectedTimestamp checks that a record still has the timestamp the client read.
+// Timestamps are compared at millisecond precision, which is the precision they are stored with.
+//
+// Parameters:
+// - record: The work clock record
+// - expectedTimestamp: The timestamp the client expects, the zero time skips the check
+//
+// Returns:
+// - A *recordChangedError if the record has another timestamp
+func checkExpectedTimestamp(record *core.Record, expectedTimestamp time.Time) error {
+	if expectedTimestamp.IsZero() {
+		return nil
 	}
-	if err != nil {
 
+	actual := record.GetDateTime("timestamp").Time()
+	if actual.Truncate(time.Millisecond).Equal(expectedTimestamp.Truncate(time.Millisecond)) {
+		return nil
+	}
+	return &recordChangedError{RecordID: record.Id, Expected: expectedTimestamp, Actual: actual}
+}
+
+// expectedTimestampParam reads the optional 'expected_timestamp' parameter, the timestamp the
+// client read the record with, so changing it fails with 409 Conflict if it was changed since.
+//
+// Parameters:
+// - e: The RequestEvent from the HTTP handler
+//
+// Returns:
+// - The expected timestamp, the zero time if the parameter is unset
+// - An API error if the parameter is invalid
+func expectedTimestampParam(e *core.RequestEvent) (time.Time, error) {
+	value := e.Request.FormValue("expected_timestamp")
+	if value == "" {
+		return time.Time{}, nil
 	}
 
+	expectedTimestamp, err := parseTimeParam(value, "expected_timestamp")
 	if err != nil {
-		return time.Time{}, fmt.Errorf("invalid '%s' format. Expected RFC3339", paramName)
+		return time.Time{}, e.Error(http.StatusBadRequest, err.Error(), nil)
 	}
+	return expectedTimestamp, nil
+}
 
-	return timeValue, nil
+// handleClockOut closes the currently open session and writes the response.
+// If the open session exceeds the maximum session duration, the client must either confirm
+// the session with 'confirm=true' or supply the actual end time with 'end' (RFC3339).
+//
+// Parameters:
+// - app: The App interface (typically a PocketBase instance or transaction)
+// - e: The RequestEvent from the HTTP handler
+// - clockID: The ID of the clock, an empty string for the default clock
+// - failureMessage: The message prefix used if clocking out fails
+//
+// Returns:
+// - An error if the parameters are invalid or clocking out fails
+func handleClockOut(app core.App, e *core.RequestEvent, clockID string, failureMessage string) error {
+	if endValue := e.Request.FormValue("end"); endValue != "" {
+		end, err := parseTimeParam(endValue, "end")
+		if err != nil {
+			return e.Error(http.StatusBadRequest, err.Error(), nil)
+		}
+		if err := validateNotInFuture(e, "end", end); err != nil {
+			return err
+		}
+
+		if err := requestWorkClockService(app, e).ClockInOutAt(clockID, false, end); err != nil {
+			return e.Error(http.StatusInternalServerError, fmt.Sprintf("%s: %v", failureMessage, err), err)
+		}
+		return callSucceeded(e)
+	}
+
+	confirmed := false
+	if confirmValue := e.Request.FormValue("confirm"); confirmValue != "" {
+		var err error
+		confirmed, err = parseBoolParam(confirmValue, "confirm")
+		if err != nil {
+			return e.Error(http.StatusBadRequest, err.Error(), nil)
+		}
+	}
+
+	if err := requestWorkClockService(app, e).ClockInOut(clockID, false, confirmed); err != nil {
+		var tooLongErr *sessionTooLongError
+		if errors.As(err, &tooLongErr) {
+			return e.Error(http.StatusConflict, fmt.Sprintf("%s: %v. Confirm the session with 'confirm=true' or supply the actual end time with 'end'", failureMessage, tooLongErr), nil)
+		}
+		return e.Error(http.StatusInternalServerError, fmt.Sprintf("%s: %v", failureMessage, err), err)
+	}
+	return callSucceeded(e)
 }
 
 // RegisterWorkClockAPI registers the work clock API endpoints with the PocketBase server.
@@ -112,10 +288,19 @@ func parseTimeParam(paramValue string, paramName string) (time.Time, error) {
 // - POST /api/work_clock/add_clock_in_out_pair - Adds a clock in/out pair with specified timestamps
 //
 // All endpoints return a success response on success or an appropriate error response on failure.
+// The endpoints creating records operate on the clock selected by the optional 'clock' parameter
+// (see requestClock), the endpoints referring to existing records on the clock of these records.
+// Manually entered timestamps must not lie further in the future than the configured maximum offset
+// (see validateNotInFuture).
+// Clocking out of a session longer than the maximum session duration requires either 'confirm=true'
+// or an explicit 'end' timestamp (see handleClockOut).
+// Clocking in on a vacation or sick day requires 'confirm_absence=true' (see validateNotAbsent).
+// Deleting and modifying records accept the timestamp the client read the record with as
+// 'expected_timestamp' and fail with 409 Conflict if the record was changed since (see expectedTimestampParam).
 //
 // Parameters:
 // - app: The PocketBase application instance
-func RegisterWorkClockAPI(app *pocketbase.PocketBase) {
+func RegisterWorkClockAPI(app core.App) {
 	app.OnServe().BindFunc(func(se *core.ServeEvent) error {
 		se.Router.POST("/api/work_clock", func(e *core.RequestEvent) error {
 			clockInBool, err := parseBoolParam(e.Request.FormValue("clock_in"), "clock_in")
@@ -123,32 +308,66 @@ func RegisterWorkClockAPI(app *pocketbase.PocketBase) {
 				return e.Error(http.StatusBadRequest, err.Error(), nil)
 			}
 
-			if err := clockInOut(app, clockInBool); err != nil {
+			clockID, err := requestClock(app, e)
+			if err != nil {
+				return err
+			}
+
+			if !clockInBool {
+				return handleClockOut(app, e, clockID, "Failed to clock in/out")
+			}
+
+			if err := validateNotAbsent(app, e, clockID, clockNow(app)); err != nil {
+				return err
+			}
+			if err := requestWorkClockService(app, e).ClockInOut(clockID, true, false); err != nil {
 				return e.Error(http.StatusInternalServerError, fmt.Sprintf("Failed to clock in/out: %v", err), err)
 			}
 			return callSucceeded(e)
 		})
 
 		se.Router.GET("/api/work_clock/clock_in", func(e *core.RequestEvent) error {
-			if err := clockInOut(app, true); err != nil {
+			clockID, err := requestClock(app, e)
+			if err != nil {
+				return err
+			}
+
+			if err := validateNotAbsent(app, e, clockID, clockNow(app)); err != nil {
+				return err
+			}
+			if err := requestWorkClockService(app, e).ClockInOut(clockID, true, false); err != nil {
 				return e.Error(http.StatusInternalServerError, fmt.Sprintf("Failed to clock in: %v", err), err)
 			}
 			return callSucceeded(e)
 		})
 		se.Router.GET("/api/work_clock/clock_out", func(e *core.RequestEvent) error {
-			if err := clockInOut(app, false); err != nil {
-				return e.Error(http.StatusInternalServerError, fmt.Sprintf("Failed to clock out: %v", err), err)
+			clockID, err := requestClock(app, e)
+			if err != nil {
+				return err
 			}
-			return callSucceeded(e)
+
+			return handleClockOut(app, e, clockID, "Failed to clock out")
 		})
 
 		se.Router.GET("/api/work_clock/toggle", func(e *core.RequestEvent) error {
-			clockedIn, err := isCurrentlyClockedIn(app)
+			clockID, err := requestClock(app, e)
+			if err != nil {
+				return err
+			}
+
+			clockedIn, err := workClockServiceOf(app).IsClockedIn(clockID)
 			if err != nil {
 				return e.Error(http.StatusInternalServerError, fmt.Sprintf("Failed to check current clock status: %v", err), err)
 			}
 
-			if err := clockInOut(app, !clockedIn); err != nil {
+			if clockedIn {
+				return handleClockOut(app, e, clockID, "Failed to toggle clock status")
+			}
+
+			if err := validateNotAbsent(app, e, clockID, clockNow(app)); err != nil {
+				return err
+			}
+			if err := requestWorkClockService(app, e).ClockInOut(clockID, true, false); err != nil {
 				return e.Error(http.StatusInternalServerError, fmt.Sprintf("Failed to toggle clock status: %v", err), err)
 			}
 			return callSucceeded(e)
@@ -160,7 +379,16 @@ func RegisterWorkClockAPI(app *pocketbase.PocketBase) {
 				return e.Error(http.StatusBadRequest, "Missing 'clock_in_id' (string) parameter", nil)
 			}
 
-			if err := deleteClockInOutPair(app, clockInID); err != nil {
+			expectedTimestamp, err := expectedTimestampParam(e)
+			if err != nil {
+				return err
+			}
+
+			if err := requestWorkClockService(app, e).DeleteClockInOutPair(clockInID, expectedTimestamp); err != nil {
+				var changedErr *recordChangedError
+				if errors.As(err, &changedErr) {
+					return e.Error(http.StatusConflict, fmt.Sprintf("Failed to delete clock in/out pair: %v", changedErr), nil)
+				}
 				return e.Error(http.StatusInternalServerError, fmt.Sprintf("Failed to delete clock in/out pair: %v", err), err)
 			}
 
@@ -178,7 +406,16 @@ func RegisterWorkClockAPI(app *pocketbase.PocketBase) {
 				return e.Error(http.StatusBadRequest, err.Error(), nil)
 			}
 
-			if err := modifyWorkClockTimestamp(app, workClockID, newTimestamp); err != nil {
+			expectedTimestamp, err := expectedTimestampParam(e)
+			if err != nil {
+				return err
+			}
+
+			if err := requestWorkClockService(app, e).ModifyTimestamp(workClockID, newTimestamp, expectedTimestamp); err != nil {
+				var changedErr *recordChangedError
+				if errors.As(err, &changedErr) {
+					return e.Error(http.StatusConflict, fmt.Sprintf("Failed to modify work clock timestamp: %v", changedErr), nil)
+				}
 				return e.Error(http.StatusInternalServerError, fmt.Sprintf("Failed to modify work clock timestamp: %v", err), err)
 			}
 			return callSucceeded(e)
@@ -194,8 +431,22 @@ func RegisterWorkClockAPI(app *pocketbase.PocketBase) {
 			if err != nil {
 				return e.Error(http.StatusBadRequest, err.Error(), nil)
 			}
+			if err := validateNotInFuture(e, "timestamp", timestamp); err != nil {
+				return err
+			}
+
+			clockID, err := requestClock(app, e)
+			if err != nil {
+				return err
+			}
+
+			if clockInBool {
+				if err := validateNotAbsent(app, e, clockID, timestamp); err != nil {
+					return err
+				}
+			}
 
-			if err := clockInOutAt(app, clockInBool, timestamp); err != nil {
+			if err := requestWorkClockService(app, e).ClockInOutAt(clockID, clockInBool, timestamp); err != nil {
 				return e.Error(http.StatusInternalServerError, fmt.Sprintf("Failed to clock %s at %s: %v", map[bool]string{true: "in", false: "out"}[clockInBool], timestamp.Format(time.RFC3339), err), err)
 			}
 			return callSucceeded(e)
@@ -212,7 +463,23 @@ func RegisterWorkClockAPI(app *pocketbase.PocketBase) {
 				return e.Error(http.StatusBadRequest, err.Error(), nil)
 			}
 
-			if err := addClockInOutPair(app, clockInTimestamp, clockOutTimestamp); err != nil {
+			if err := validateNotInFuture(e, "clock_in_timestamp", clockInTimestamp); err != nil {
+				return err
+			}
+			if err := validateNotInFuture(e, "clock_out_timestamp", clockOutTimestamp); err != nil {
+				return err
+			}
+
+			clockID, err := requestClock(app, e)
+			if err != nil {
+				return err
+			}
+
+			if err := validateNotAbsent(app, e, clockID, clockInTimestamp); err != nil {
+				return err
+			}
+
+			if err := requestWorkClockService(app, e).AddClockInOutPair(clockID, clockInTimestamp, clockOutTimestamp); err != nil {
 				return e.Error(http.StatusInternalServerError, fmt.Sprintf("Failed to add clock in/out pair: %v", err), err)
 			}
 			return callSucceeded(e)
@@ -223,57 +490,93 @@ func RegisterWorkClockAPI(app *pocketbase.PocketBase) {
 
 }
 
-// isCurrentlyClockedIn checks if the user is currently clocked in by retrieving
-// the most recent record from the work_clock collection.
+// IsClockedIn checks if the user is currently clocked in by retrieving
+// the most recent record of a clock from the work_clock collection.
 //
 // Parameters:
-// - app: The PocketBase application instance
+// - clockID: The ID of the clock, an empty string for the default clock
 //
 // Returns:
 // - A boolean indicating whether the user is clocked in (true) or out (false)
 // - An error if the database query fails
 //
 // If no records exist, the function returns false, indicating the user is not clocked in.
-func isCurrentlyClockedIn(app *pocketbase.PocketBase) (bool, error) {
-	records, err := app.FindRecordsByFilter("work_clock", "", "-timestamp", 1, 0)
+func (s *WorkClockService) IsClockedIn(clockID string) (bool, error) {
+	record, err := s.LatestRecord(clockID)
+	if err != nil {
+		return false, err
+	}
+
+	return record != nil && record.GetBool("clock_in"), nil
+}
+
+// LatestRecord retrieves the most recent record of a clock from the work_clock collection.
+//
+// Parameters:
+// - clockID: The ID of the clock, an empty string for the default clock
+//
+// Returns:
+// - The latest work clock record or nil if no records exist
+// - An error if the database query fails
+func (s *WorkClockService) LatestRecord(clockID string) (*core.Record, error) {
+	records, err := s.app.FindRecordsByFilter("work_clock", "clock = {:clock}", "-timestamp", 1, 0, dbx.Params{"clock": clockParam(clockID)})
 	if err != nil {
-		return false, fmt.Errorf("failed to find latest work clock record: %w", err)
+		return nil, fmt.Errorf("failed to find latest work clock record: %w", err)
 	}
 
 	if len(records) == 0 {
-		return false, nil
+		return nil, nil
 	}
 
-	return records[0].GetBool("clock_in"), nil
+	return records[0], nil
 }
 
-// clockInOut performs the clock in or clock out operation based on the provided flag.
-// This function ensures thread-safety using a mutex and prevents invalid state transitions
+// ClockInOut performs the clock in or clock out operation based on the provided flag.
+// The clock is locked during the operation, which prevents invalid state transitions
 // (such as clocking in when already clocked in).
 //
 // Parameters:
-// - app: The PocketBase application instance
+// - clockID: The ID of the clock, an empty string for the default clock
 // - clockIn: A boolean flag indicating the desired clock state (true = clock in, false = clock out)
+// - confirmLongSession: Allows clocking out of a session that exceeds the maximum session duration
 //
 // Returns:
 // - An error if the operation fails or if the requested state matches the current state
+// - A *sessionTooLongError if clocking out would close a session longer than the maximum session
+// duration and confirmLongSession is false
 //
 // The function creates a new record in the work_clock collection with the current timestamp
 // and the requested clock state.
-func clockInOut(app *pocketbase.PocketBase, clockIn bool) error {
-	workClockMutex.Lock()
-	defer workClockMutex.Unlock()
+func (s *WorkClockService) ClockInOut(clockID string, clockIn bool, confirmLongSession bool) error {
+	unlock, err := s.lock(clockID)
+	if err != nil {
+		return err
+	}
+	defer unlock()
 
-	isClockedIn, err := isCurrentlyClockedIn(app)
+	latestRecord, err := s.LatestRecord(clockID)
 	if err != nil {
 		return fmt.Errorf("failed to check current clock status: %w", err)
 	}
 
+	isClockedIn := latestRecord != nil && latestRecord.GetBool("clock_in")
 	if isClockedIn == clockIn {
 		return fmt.Errorf("already clocked %s", map[bool]string{true: "in", false: "out"}[isClockedIn])
 	}
 
-	_, err = createWorkClockRecord(app, nil, time.Now(), clockIn)
+	now := s.Now()
+	if !clockIn && !confirmLongSession && s.settings.MaxSessionDuration > 0 {
+		start := latestRecord.GetDateTime("timestamp").Time()
+		if duration := now.Sub(start); duration > s.settings.MaxSessionDuration {
+			return &sessionTooLongError{
+				Start:       start,
+				Duration:    duration,
+				MaxDuration: s.settings.MaxSessionDuration,
+			}
+		}
+	}
+
+	_, err = createWorkClockRecord(s.app, nil, clockID, now, clockIn)
 	if err != nil {
 		return fmt.Errorf("failed to create work clock record: %w", err)
 	}
@@ -281,31 +584,39 @@ func clockInOut(app *pocketbase.PocketBase, clockIn bool) error {
 	return nil
 }
 
-// deleteClockInOutPair deletes a clock in record and its corresponding clock out record.
+// DeleteClockInOutPair deletes a clock in record and its corresponding clock out record.
 // It requires the ID of the clock in record and will automatically find and delete the matching
 // clock out record if it exists.
 //
 // Parameters:
-// - app: The PocketBase application instance
 // - clockInID: The ID of the clock in record to delete
+// - expectedTimestamp: The timestamp the clock in record is expected to have, the zero time skips the check
 //
 // Returns:
 // - An error if the operation fails, the record doesn't exist, or if it's not a clock in record
+// - A *recordChangedError if the clock in record doesn't have the expected timestamp
 //
 // The operation is performed within a transaction to ensure data consistency.
-func deleteClockInOutPair(app *pocketbase.PocketBase, clockInID string) error {
-	workClockMutex.Lock()
-	defer workClockMutex.Unlock()
+func (s *WorkClockService) DeleteClockInOutPair(clockInID string, expectedTimestamp time.Time) error {
+	unlock, err := s.lockRecordClock(clockInID)
+	if err != nil {
+		return err
+	}
+	defer unlock()
 
-	record, err := app.FindRecordById("work_clock", clockInID)
+	record, err := s.app.FindRecordById("work_clock", clockInID)
 	if err != nil {
 		return fmt.Errorf("failed to find work clock record with id '%s': %w", clockInID, err)
 	}
 	if !record.GetBool("clock_in") {
 		return fmt.Errorf("record with id '%s' is not a clock in record", clockInID)
 	}
+	if err := checkExpectedTimestamp(record, expectedTimestamp); err != nil {
+		return err
+	}
 
-	succeedingRecords, err := app.FindRecordsByFilter("work_clock", "timestamp > {:clockIn}", "+timestamp", 1, 0, dbx.Params{
+	succeedingRecords, err := s.app.FindRecordsByFilter("work_clock", "clock = {:clock} && timestamp > {:clockIn}", "+timestamp", 1, 0, dbx.Params{
+		"clock":   clockParam(record.GetString("clock")),
 		"clockIn": record.GetDateTime("timestamp"),
 	})
 	if err != nil {
@@ -316,7 +627,7 @@ func deleteClockInOutPair(app *pocketbase.PocketBase, clockInID string) error {
 		return fmt.Errorf("succeeding record with id '%s' is not a clock out record", succeedingRecords[0].Id)
 	}
 
-	err = app.RunInTransaction(func(txApp core.App) error {
+	err = s.app.RunInTransaction(func(txApp core.App) error {
 		if err := txApp.Delete(record); err != nil {
 			return fmt.Errorf("failed to delete clock in record: %w", err)
 		}
@@ -336,8 +647,8 @@ func deleteClockInOutPair(app *pocketbase.PocketBase, clockInID string) error {
 	return nil
 }
 
-// checkValidity verifies that a work clock record maintains logical sequence with adjacent records.
-// It ensures that:
+// checkValidity verifies that a work clock record maintains logical sequence with adjacent records
+// of the same clock. It ensures that:
 // - Clock in records are followed by clock out records
 // - Clock out records are followed by clock in records
 // - Clock in records are preceded by clock out records
@@ -364,9 +675,12 @@ func checkValidity(app core.App, workClockID string) error {
 		return fmt.Errorf("failed to find work clock record with id '%s': %w", workClockID, err)
 	}
 
-	succeedingRecords, err := app.FindRecordsByFilter("work_clock", "timestamp > {:clockIn}", "+timestamp", 1, 0, dbx.Params{
+	params := dbx.Params{
+		"clock":   clockParam(record.GetString("clock")),
 		"clockIn": record.GetDateTime("timestamp"),
-	})
+	}
+
+	succeedingRecords, err := app.FindRecordsByFilter("work_clock", "clock = {:clock} && timestamp > {:clockIn}", "+timestamp", 1, 0, params)
 	if err != nil {
 		return fmt.Errorf("failed to find succeeding work clock record: %w", err)
 	}
@@ -379,9 +693,7 @@ func checkValidity(app core.App, workClockID string) error {
 		}
 	}
 
-	precedingRecords, err := app.FindRecordsByFilter("work_clock", "timestamp < {:clockIn}", "-timestamp", 1, 0, dbx.Params{
-		"clockIn": record.GetDateTime("timestamp"),
-	})
+	precedingRecords, err := app.FindRecordsByFilter("work_clock", "clock = {:clock} && timestamp < {:clockIn}", "-timestamp", 1, 0, params)
 	if err != nil {
 		return fmt.Errorf("failed to find preceding work clock record: %w", err)
 	}
@@ -401,29 +713,43 @@ func checkValidity(app core.App, workClockID string) error {
 	return nil
 }
 
-// modifyWorkClockTimestamp updates the timestamp of an existing work clock record.
+// ModifyTimestamp updates the timestamp of an existing work clock record.
 // After modifying the timestamp, it validates that the record maintains proper sequence
 // with adjacent records to ensure data integrity.
 //
 // Parameters:
-// - app: The PocketBase application instance
 // - workClockID: The ID of the work clock record to modify
 // - newTimestamp: The new timestamp to set for the record
+// - expectedTimestamp: The timestamp the record is expected to have, the zero time skips the check
 //
 // Returns:
 // - An error if the update fails or if the modified record violates sequence constraints
+// - A *recordChangedError if the record doesn't have the expected timestamp
 //
 // The operation is performed within a transaction to ensure data consistency.
-func modifyWorkClockTimestamp(app *pocketbase.PocketBase, workClockID string, newTimestamp time.Time) error {
-	workClockMutex.Lock()
-	defer workClockMutex.Unlock()
+func (s *WorkClockService) ModifyTimestamp(workClockID string, newTimestamp time.Time, expectedTimestamp time.Time) error {
+	unlock, err := s.lockRecordClock(workClockID)
+	if err != nil {
+		return err
+	}
+	defer unlock()
 
-	record, err := app.FindRecordById("work_clock", workClockID)
+	record, err := s.app.FindRecordById("work_clock", workClockID)
 	if err != nil {
 		return fmt.Errorf("failed to find work clock record with id '%s': %w", workClockID, err)
 	}
+	if err := checkExpectedTimestamp(record, expectedTimestamp); err != nil {
+		return err
+	}
+
+	// Moving a record past one of its neighbors makes the former neighbors adjacent to each other,
+	// so they have to be validated as well
+	neighborIDs, err := findNeighborWorkClockRecordIDs(s.app, record)
+	if err != nil {
+		return err
+	}
 
-	err = app.RunInTransaction(func(txApp core.App) error {
+	err = s.app.RunInTransaction(func(txApp core.App) error {
 		record.Set("timestamp", newTimestamp)
 		if err := txApp.Save(record); err != nil {
 			return fmt.Errorf("failed to save work clock record with new timestamp: %w", err)
@@ -433,6 +759,12 @@ func modifyWorkClockTimestamp(app *pocketbase.PocketBase, workClockID string, ne
 			return fmt.Errorf("modified work clock record with id '%s' is not valid anymore: %w", workClockID, err)
 		}
 
+		for _, neighborID := range neighborIDs {
+			if err := checkValidity(txApp, neighborID); err != nil {
+				return fmt.Errorf("former neighbor with id '%s' is not valid anymore: %w", neighborID, err)
+			}
+		}
+
 		return nil
 	})
 
@@ -443,12 +775,42 @@ func modifyWorkClockTimestamp(app *pocketbase.PocketBase, workClockID string, ne
 	return nil
 }
 
-// clockInOutAt creates a new clock in or clock out record with a specific timestamp.
+// findNeighborWorkClockRecordIDs finds the records of the same clock directly preceding and succeeding a work clock record.
+//
+// Parameters:
+// - app: The core.App interface (typically a PocketBase instance or transaction)
+// - record: The work clock record
+//
+// Returns:
+// - The IDs of the preceding and succeeding records, omitting those that don't exist
+// - An error if the query fails
+func findNeighborWorkClockRecordIDs(app core.App, record *core.Record) ([]string, error) {
+	params := dbx.Params{"clock": clockParam(record.GetString("clock")), "timestamp": record.GetDateTime("timestamp")}
+
+	precedingRecords, err := app.FindRecordsByFilter("work_clock", "clock = {:clock} && timestamp < {:timestamp}", "-timestamp", 1, 0, params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find preceding work clock record: %w", err)
+	}
+
+	succeedingRecords, err := app.FindRecordsByFilter("work_clock", "clock = {:clock} && timestamp > {:timestamp}", "+timestamp", 1, 0, params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find succeeding work clock record: %w", err)
+	}
+
+	neighborIDs := make([]string, 0, 2)
+	for _, neighbor := range append(precedingRecords, succeedingRecords...) {
+		neighborIDs = append(neighborIDs, neighbor.Id)
+	}
+
+	return neighborIDs, nil
+}
+
+// ClockInOutAt creates a new clock in or clock out record with a specific timestamp.
 // This allows for manual time entries when the actual clock in/out didn't occur in real-time.
 // The function validates that the new record maintains proper sequence with existing records.
 //
 // Parameters:
-// - app: The PocketBase application instance
+// - clockID: The ID of the clock, an empty string for the default clock
 // - clockIn: Boolean flag indicating whether this is a clock in (true) or clock out (false) record
 // - timestamp: The specific timestamp to use for the record
 //
@@ -456,12 +818,15 @@ func modifyWorkClockTimestamp(app *pocketbase.PocketBase, workClockID string, ne
 // - An error if the operation fails or if adding the record would violate sequence constraints
 //
 // The operation is performed within a transaction to ensure data consistency.
-func clockInOutAt(app *pocketbase.PocketBase, clockIn bool, timestamp time.Time) error {
-	workClockMutex.Lock()
-	defer workClockMutex.Unlock()
+func (s *WorkClockService) ClockInOutAt(clockID string, clockIn bool, timestamp time.Time) error {
+	unlock, err := s.lock(clockID)
+	if err != nil {
+		return err
+	}
+	defer unlock()
 
-	err := app.RunInTransaction(func(txApp core.App) error {
-		record, err := createWorkClockRecord(txApp, nil, timestamp, clockIn)
+	err = s.app.RunInTransaction(func(txApp core.App) error {
+		record, err := createWorkClockRecord(txApp, nil, clockID, timestamp, clockIn)
 		if err != nil {
 			return fmt.Errorf("failed to create work clock record: %w", err)
 		}
@@ -480,12 +845,12 @@ func clockInOutAt(app *pocketbase.PocketBase, clockIn bool, timestamp time.Time)
 	return nil
 }
 
-// addClockInOutPair creates a pair of clock in and clock out records with specified timestamps.
+// AddClockInOutPair creates a pair of clock in and clock out records with specified timestamps.
 // This is useful for entering historical or pre-planned work periods.
 // Both records are validated to ensure they maintain proper sequence with existing records.
 //
 // Parameters:
-// - app: The PocketBase application instance
+// - clockID: The ID of the clock, an empty string for the default clock
 // - clockInTimestamp: The timestamp for the clock in record
 // - clockOutTimestamp: The timestamp for the clock out record
 //
@@ -495,22 +860,25 @@ func clockInOutAt(app *pocketbase.PocketBase, clockIn bool, timestamp time.Time)
 // The operation is performed within a transaction to ensure data consistency. There is no
 // requirement that clockInTimestamp must be before clockOutTimestamp, allowing for flexibility
 // in special cases like splitting an existing time period.
-func addClockInOutPair(app *pocketbase.PocketBase, clockInTimestamp, clockOutTimestamp time.Time) error {
-	workClockMutex.Lock()
-	defer workClockMutex.Unlock()
+func (s *WorkClockService) AddClockInOutPair(clockID string, clockInTimestamp, clockOutTimestamp time.Time) error {
+	unlock, err := s.lock(clockID)
+	if err != nil {
+		return err
+	}
+	defer unlock()
 
-	err := app.RunInTransaction(func(txApp core.App) error {
+	err = s.app.RunInTransaction(func(txApp core.App) error {
 		collection, err := txApp.FindCollectionByNameOrId("work_clock")
 		if err != nil {
 			return fmt.Errorf("failed to find work clock collection: %w", err)
 		}
 
-		clockInRecord, err := createWorkClockRecord(txApp, collection, clockInTimestamp, true)
+		clockInRecord, err := createWorkClockRecord(txApp, collection, clockID, clockInTimestamp, true)
 		if err != nil {
 			return fmt.Errorf("failed to create clock in record: %w", err)
 		}
 
-		clockOutRecord, err := createWorkClockRecord(txApp, collection, clockOutTimestamp, false)
+		clockOutRecord, err := createWorkClockRecord(txApp, collection, clockID, clockOutTimestamp, false)
 		if err != nil {
 			return fmt.Errorf("failed to create clock out record: %w", err)
 		}
@@ -535,19 +903,39 @@ func addClockInOutPair(app *pocketbase.PocketBase, clockInTimestamp, clockOutTim
 
 // createWorkClockRecord creates a new work_clock record with the specified parameters.
 // This function centralizes the creation of work_clock records to eliminate code duplication.
-// If a record with the same timestamp and clock_in value already exists, it returns the existing
-// record instead of creating a duplicate.
+// If a record of the same clock with the same timestamp and clock_in value already exists, it returns
+// the existing record instead of creating a duplicate.
 //
 // Parameters:
 // - app: The App interface (typically a PocketBase instance or transaction)
 // - collection: The work_clock collection (optional, can be nil)
+// - clockID: The ID of the clock, an empty string for the default clock
 // - timestamp: The timestamp for the record
 // - clockIn: Boolean flag indicating whether this is a clock in (true) or clock out (false) record
 //
 // Returns:
 // - The newly created record or the existing record if a duplicate is found
 // - An error if the operation fails
-func createWorkClockRecord(app core.App, collection *core.Collection, timestamp time.Time, clockIn bool) (*core.Record, error) {
+func createWorkClockRecord(app core.App, collection *core.Collection, clockID string, timestamp time.Time, clockIn bool) (*core.Record, error) {
+	return createImportedWorkClockRecord(app, collection, clockID, "", timestamp, clockIn)
+}
+
+// createImportedWorkClockRecord creates a new work_clock record like createWorkClockRecord and tags
+// it with the import run that created it. An existing duplicate is returned without being tagged,
+// so rolling back the import keeps it.
+//
+// Parameters:
+// - app: The App interface (typically a PocketBase instance or transaction)
+// - collection: The work_clock collection (optional, can be nil)
+// - clockID: The ID of the clock, an empty string for the default clock
+// - importRunID: The ID of the import run, an empty string for records not created by an import
+// - timestamp: The timestamp for the record
+// - clockIn: Boolean flag indicating whether this is a clock in (true) or clock out (false) record
+//
+// Returns:
+// - The newly created record or the existing record if a duplicate is found
+// - An error if the operation fails
+func createImportedWorkClockRecord(app core.App, collection *core.Collection, clockID string, importRunID string, timestamp time.Time, clockIn bool) (*core.Record, error) {
 	var err error
 	if collection == nil {
 		collection, err = app.FindCollectionByNameOrId("work_clock")
@@ -557,11 +945,14 @@ func createWorkClockRecord(app core.App, collection *core.Collection, timestamp
 	}
 
 	record := core.NewRecord(collection)
+	record.Set("clock", clockID)
 	record.Set("timestamp", timestamp)
 	record.Set("clock_in", clockIn)
+	record.Set("import_run", importRunID)
 
 	if err := app.Save(record); err != nil {
-		existingRecord, existingErr := app.FindFirstRecordByFilter(collection, "timestamp = {:timestamp} && clock_in = {:clockIn}", dbx.Params{
+		existingRecord, existingErr := app.FindFirstRecordByFilter(collection, "clock = {:clock} && timestamp = {:timestamp} && clock_in = {:clockIn}", dbx.Params{
+			"clock":     clockParam(clockID),
 			"timestamp": record.GetDateTime("timestamp"),
 			"clockIn":   record.GetBool("clock_in"),
 		})
@@ -575,12 +966,26 @@ func createWorkClockRecord(app core.App, collection *core.Collection, timestamp
 	return record, nil
 }
 
-// addManyWorkClockRecords creates multiple clock in and clock out records with the specified timestamps.
+// dateTimeParam converts a time.Time into the PocketBase DateTime representation,
+// so it can be compared against stored date fields in filter parameters.
+//
+// Parameters:
+// - value: The time to convert
+//
+// Returns:
+// - The DateTime representation of the time in UTC
+func dateTimeParam(value time.Time) types.DateTime {
+	dateTime, _ := types.ParseDateTime(value)
+	return dateTime
+}
+
+// AddManyRecords creates multiple clock in and clock out records with the specified timestamps.
 // This is useful for bulk importing or migrating historical work time data from another system.
 // All records are validated to ensure they maintain proper sequence with existing records.
 //
 // Parameters:
-// - app: The PocketBase application instance
+// - clockID: The ID of the clock, an empty string for the default clock
+// - importRunID: The ID of the import run creating the records, an empty string outside of imports
 // - clockInTimestamps: A slice of timestamps for the clock in records
 // - clockOutTimestamps: A slice of timestamps for the clock out records
 //
@@ -591,11 +996,14 @@ func createWorkClockRecord(app core.App, collection *core.Collection, timestamp
 // All records are created in the order provided in the slices, and each record is validated against
 // the existing records to ensure proper alternation of clock in/out states.
 // If any validation fails, the entire transaction is rolled back and no records are added.
-func addManyWorkClockRecords(app *pocketbase.PocketBase, clockInTimestamps, clockOutTimestamps []time.Time) error {
-	workClockMutex.Lock()
-	defer workClockMutex.Unlock()
+func (s *WorkClockService) AddManyRecords(clockID string, importRunID string, clockInTimestamps, clockOutTimestamps []time.Time) error {
+	unlock, err := s.lock(clockID)
+	if err != nil {
+		return err
+	}
+	defer unlock()
 
-	err := app.RunInTransaction(func(txApp core.App) error {
+	err = s.app.RunInTransaction(func(txApp core.App) error {
 		collection, err := txApp.FindCollectionByNameOrId("work_clock")
 		if err != nil {
 			return fmt.Errorf("failed to find work clock collection: %w", err)
@@ -604,7 +1012,11 @@ func addManyWorkClockRecords(app *pocketbase.PocketBase, clockInTimestamps, cloc
 		clockInRecordIDs := make([]string, len(clockInTimestamps))
 
 		for i, clockInTimestamp := range clockInTimestamps {
-			record, err := createWorkClockRecord(txApp, collection, clockInTimestamp, true)
+			if err := s.ctx.Err(); err != nil {
+				return err
+			}
+
+			record, err := createImportedWorkClockRecord(txApp, collection, clockID, importRunID, clockInTimestamp, true)
 			if err != nil {
 				return fmt.Errorf("failed to create clock in record at time '%s': %w", clockInTimestamp.Format(time.RFC3339), err)
 			}
@@ -615,7 +1027,11 @@ func addManyWorkClockRecords(app *pocketbase.PocketBase, clockInTimestamps, cloc
 		clockOutRecordIDs := make([]string, len(clockOutTimestamps))
 
 		for i, clockOutTimestamp := range clockOutTimestamps {
-			record, err := createWorkClockRecord(txApp, collection, clockOutTimestamp, false)
+			if err := s.ctx.Err(); err != nil {
+				return err
+			}
+
+			record, err := createImportedWorkClockRecord(txApp, collection, clockID, importRunID, clockOutTimestamp, false)
 			if err != nil {
 				return fmt.Errorf("failed to create clock out record at time '%s': %w", clockOutTimestamp.Format(time.RFC3339), err)
 			}
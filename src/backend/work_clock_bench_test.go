@@ -0,0 +1,129 @@
+package backend
+
+import (
+	"testing"
+	"time"
+
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/yerTools/simple-frontend-stack/src/backend/backendtest"
+)
+
+// benchmarkSeedStart is the first day of the seeded work clock data.
+var benchmarkSeedStart = time.Date(2020, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+// seedWorkClockDays creates a 9:00 to 17:00 session for each day starting at benchmarkSeedStart.
+func seedWorkClockDays(b *testing.B, app core.App, days int) {
+	b.Helper()
+
+	clockIns := make([]time.Time, 0, days)
+	clockOuts := make([]time.Time, 0, days)
+	for day := range days {
+		date := benchmarkSeedStart.AddDate(0, 0, day)
+		clockIns = append(clockIns, date.Add(9*time.Hour))
+		clockOuts = append(clockOuts, date.Add(17*time.Hour))
+	}
+
+	if err := workClockServiceOf(app).AddManyRecords("", "", clockIns, clockOuts); err != nil {
+		b.Fatalf("failed to seed work clock records: %v", err)
+	}
+}
+
+func BenchmarkAddClockInOutPair(b *testing.B) {
+	app := backendtest.NewApp(b)
+	seedWorkClockDays(b, app, 365)
+
+	start := benchmarkSeedStart.AddDate(1, 0, 0)
+
+	b.ResetTimer()
+	for i := range b.N {
+		clockIn := start.Add(time.Duration(i) * time.Hour)
+		if err := workClockServiceOf(app).AddClockInOutPair("", clockIn, clockIn.Add(30*time.Minute)); err != nil {
+			b.Fatalf("failed to add clock in/out pair: %v", err)
+		}
+	}
+}
+
+func BenchmarkCheckValidity(b *testing.B) {
+	app := backendtest.NewApp(b)
+	seedWorkClockDays(b, app, 365)
+
+	records, err := app.FindRecordsByFilter("work_clock", "", "+timestamp", 0, 0)
+	if err != nil {
+		b.Fatalf("failed to find records: %v", err)
+	}
+
+	b.ResetTimer()
+	for i := range b.N {
+		if err := checkValidity(app, records[i%len(records)].Id); err != nil {
+			b.Fatalf("seeded record is invalid: %v", err)
+		}
+	}
+}
+
+func BenchmarkGetWorkClockStatus(b *testing.B) {
+	app := backendtest.NewApp(b)
+	seedWorkClockDays(b, app, 365)
+
+	now := benchmarkSeedStart.AddDate(1, 0, 0)
+
+	b.ResetTimer()
+	for range b.N {
+		if _, err := getWorkClockStatus(app, "", now); err != nil {
+			b.Fatalf("failed to get status: %v", err)
+		}
+	}
+}
+
+func BenchmarkFindWorkSessionsMonth(b *testing.B) {
+	app := backendtest.NewApp(b)
+	seedWorkClockDays(b, app, 3*365)
+
+	from := benchmarkSeedStart.AddDate(1, 5, 0)
+	to := from.AddDate(0, 1, 0)
+
+	b.ResetTimer()
+	for range b.N {
+		sessions, err := findWorkSessions(app, "", from, to)
+		if err != nil {
+			b.Fatalf("failed to find sessions: %v", err)
+		}
+		if len(sessions) == 0 {
+			b.Fatal("expected sessions within the month")
+		}
+	}
+}
+
+func BenchmarkFindWorkSessionsPage(b *testing.B) {
+	app := backendtest.NewApp(b)
+	seedWorkClockDays(b, app, 3*365)
+
+	b.ResetTimer()
+	for range b.N {
+		var cursor time.Time
+		for {
+			_, _, nextCursor, err := findWorkSessionsPage(app, "", time.Time{}, time.Time{}, cursor, defaultSessionsPageSize)
+			if err != nil {
+				b.Fatalf("failed to find sessions page: %v", err)
+			}
+			if nextCursor.IsZero() {
+				break
+			}
+			cursor = nextCursor
+		}
+	}
+}
+
+func BenchmarkGetTagReportYear(b *testing.B) {
+	app := backendtest.NewApp(b)
+	seedWorkClockDays(b, app, 365)
+
+	from := benchmarkSeedStart
+	to := from.AddDate(1, 0, 0)
+
+	b.ResetTimer()
+	for range b.N {
+		if _, err := getTagReport(app, "", from, to, to); err != nil {
+			b.Fatalf("failed to create tag report: %v", err)
+		}
+	}
+}
@@ -0,0 +1,338 @@
+// Work Clock Day Editor Module for PocketBase
+//
+// This module provides an endpoint to replace all work clock records of a single day at once.
+// The frontend's day editor previously had to issue a sequence of delete and add calls, which
+// could fail halfway and leave the day in an inconsistent state. Here, the whole day is replaced
+// within a single transaction and validated against the surrounding records before committing.
+package backend
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/core"
+)
+
+// workClockDayRequest is the JSON body of the day editor endpoint.
+type workClockDayRequest struct {
+	Date     string                `json:"date"`     // Day to replace in the format YYYY-MM-DD
+	Timezone string                `json:"timezone"` // Optional IANA timezone of the day (defaults to the server timezone)
+	Clock    string                `json:"clock"`    // Optional name of the clock (defaults to the default clock)
+	Sessions []workClockDaySession `json:"sessions"` // New sessions of the day, may be empty
+}
+
+// workClockDaySession is a single session within a workClockDayRequest.
+type workClockDaySession struct {
+	ClockIn  string `json:"clock_in"`  // Clock in timestamp (RFC3339)
+	ClockOut string `json:"clock_out"` // Clock out timestamp (RFC3339), may be empty for an open last session
+}
+
+// clockInOutPair is a validated session with parsed timestamps.
+// A zero ClockOut marks an open session.
+type clockInOutPair struct {
+	ClockIn  time.Time
+	ClockOut time.Time
+}
+
+// RegisterWorkClockDayAPI registers the day editor endpoint with the PocketBase server.
+// It creates the following route:
+// - POST /api/work_clock/day - Atomically replaces all records of a day with the given sessions
+//
+// The endpoint expects a JSON body like:
+//
+//	{
+//	  "date": "2025-04-01",
+//	  "timezone": "Europe/Berlin",
+//	  "sessions": [
+//	    {"clock_in": "2025-04-01T09:00:00+02:00", "clock_out": "2025-04-01T12:00:00+02:00"},
+//	    {"clock_in": "2025-04-01T13:00:00+02:00", "clock_out": "2025-04-01T17:00:00+02:00"}
+//	  ]
+//	}
+//
+// The optional 'clock' field selects the clock by its name, the default clock is used without it.
+// Sessions on a vacation or sick day require 'confirm_absence=true' in the query (see validateNotAbsent).
+//
+// Parameters:
+// - app: The PocketBase application instance
+func RegisterWorkClockDayAPI(app core.App) {
+	app.OnServe().BindFunc(func(se *core.ServeEvent) error {
+		se.Router.POST("/api/work_clock/day", func(e *core.RequestEvent) error {
+			var request workClockDayRequest
+			if err := e.BindBody(&request); err != nil {
+				return e.Error(http.StatusBadRequest, "Invalid request body", err)
+			}
+
+			clockID, dayStart, dayEnd, sessions, err := parseWorkClockDayRequest(app, e, request)
+			if err != nil {
+				return err
+			}
+
+			if err := replaceWorkClockDay(e.Request.Context(), app, clockID, dayStart, dayEnd, sessions); err != nil {
+				return e.Error(http.StatusInternalServerError, fmt.Sprintf("Failed to replace work clock day: %v", err), err)
+			}
+			return callSucceeded(e)
+		})
+
+		return se.Next()
+	})
+}
+
+// parseWorkClockDayRequest validates the body of a day editor request.
+//
+// Parameters:
+// - app: The App interface (typically a PocketBase instance or transaction)
+// - e: The RequestEvent from the HTTP handler
+// - request: The bound body of the request
+//
+// Returns:
+// - The ID of the clock, an empty string for the default clock
+// - The start of the day (inclusive)
+// - The start of the following day (exclusive)
+// - The sessions of the day, sorted and free of overlaps
+// - An error response if the request is invalid
+func parseWorkClockDayRequest(app core.App, e *core.RequestEvent, request workClockDayRequest) (string, time.Time, time.Time, []clockInOutPair, error) {
+	dayStart, dayEnd, err := parseDayRange(request.Date, request.Timezone)
+	if err != nil {
+		return "", time.Time{}, time.Time{}, nil, e.Error(http.StatusBadRequest, err.Error(), nil)
+	}
+
+	sessions, err := parseDaySessions(request.Sessions, dayStart, dayEnd)
+	if err != nil {
+		return "", time.Time{}, time.Time{}, nil, e.Error(http.StatusBadRequest, err.Error(), nil)
+	}
+
+	clockID, err := findClockID(app, request.Clock)
+	if err != nil {
+		return "", time.Time{}, time.Time{}, nil, e.Error(http.StatusBadRequest, err.Error(), nil)
+	}
+
+	for i, session := range sessions {
+		if err := validateNotInFuture(e, fmt.Sprintf("sessions[%d].clock_in", i), session.ClockIn); err != nil {
+			return "", time.Time{}, time.Time{}, nil, err
+		}
+		if err := validateNotInFuture(e, fmt.Sprintf("sessions[%d].clock_out", i), session.ClockOut); err != nil {
+			return "", time.Time{}, time.Time{}, nil, err
+		}
+	}
+
+	for _, session := range sessions {
+		if err := validateNotAbsent(app, e, clockID, session.ClockIn); err != nil {
+			return "", time.Time{}, time.Time{}, nil, err
+		}
+	}
+
+	return clockID, dayStart, dayEnd, sessions, nil
+}
+
+// parseDayRange parses a date in the format YYYY-MM-DD and returns the boundaries of that day.
+//
+// Parameters:
+// - date: The date to parse
+// - timezone: An optional IANA timezone name, the server timezone is used if empty
+//
+// Returns:
+// - The start of the day (inclusive)
+// - The start of the following day (exclusive)
+// - An error if the date or timezone is invalid
+func parseDayRange(date string, timezone string) (time.Time, time.Time, error) {
+	if date == "" {
+		return time.Time{}, time.Time{}, fmt.Errorf("missing 'date' (string) parameter")
+	}
+
+	location := time.Local
+	if timezone != "" {
+		var err error
+		location, err = time.LoadLocation(timezone)
+		if err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("invalid 'timezone' value '%s'", timezone)
+		}
+	}
+
+	dayStart, err := time.ParseInLocation(time.DateOnly, date, location)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("invalid 'date' format. Expected YYYY-MM-DD")
+	}
+
+	return dayStart, dayStart.AddDate(0, 0, 1), nil
+}
+
+// parseDaySessions parses and validates the sessions of a day editor request.
+//
+// Parameters:
+// - sessions: The sessions from the request body
+// - dayStart: The start of the day (inclusive)
+// - dayEnd: The start of the following day (exclusive)
+//
+// Returns:
+// - The parsed sessions sorted by their clock in timestamp
+// - An error if a timestamp is invalid, outside the day, or if sessions overlap
+//
+// Only the last session of the day may be open (without clock out timestamp).
+func parseDaySessions(sessions []workClockDaySession, dayStart, dayEnd time.Time) ([]clockInOutPair, error) {
+	pairs := make([]clockInOutPair, len(sessions))
+
+	for i, session := range sessions {
+		clockIn, err := parseTimeParam(session.ClockIn, fmt.Sprintf("sessions[%d].clock_in", i))
+		if err != nil {
+			return nil, err
+		}
+		pairs[i].ClockIn = clockIn
+
+		if session.ClockOut != "" {
+			clockOut, err := parseTimeParam(session.ClockOut, fmt.Sprintf("sessions[%d].clock_out", i))
+			if err != nil {
+				return nil, err
+			}
+			if !clockOut.After(clockIn) {
+				return nil, fmt.Errorf("session %d must end after it starts", i)
+			}
+			pairs[i].ClockOut = clockOut
+		}
+
+		if clockIn.Before(dayStart) || !clockIn.Before(dayEnd) || !pairs[i].ClockOut.Before(dayEnd) {
+			return nil, fmt.Errorf("session %d is not within the day %s", i, dayStart.Format(time.DateOnly))
+		}
+	}
+
+	sort.Slice(pairs, func(a, b int) bool {
+		return pairs[a].ClockIn.Before(pairs[b].ClockIn)
+	})
+
+	for i := 1; i < len(pairs); i++ {
+		if pairs[i-1].ClockOut.IsZero() {
+			return nil, fmt.Errorf("only the last session of the day can be open")
+		}
+		if !pairs[i].ClockIn.After(pairs[i-1].ClockOut) {
+			return nil, fmt.Errorf("sessions must not overlap or touch each other")
+		}
+	}
+
+	return pairs, nil
+}
+
+// replaceWorkClockDay replaces all work clock records of a clock within a day by the given sessions.
+//
+// Parameters:
+// - ctx: The context cancelling the operation, e.g. of the request
+// - app: The App interface (typically a PocketBase instance or transaction)
+// - clockID: The ID of the clock, an empty string for the default clock
+// - dayStart: The start of the day (inclusive)
+// - dayEnd: The start of the following day (exclusive)
+// - sessions: The new sessions of the day, sorted and free of overlaps
+//
+// Returns:
+// - An error if the operation fails or if the resulting records violate sequence constraints
+//
+// The operation is performed within a transaction, see replaceWorkClockRange.
+func replaceWorkClockDay(ctx context.Context, app core.App, clockID string, dayStart, dayEnd time.Time, sessions []clockInOutPair) error {
+	unlock, err := workClockServiceOf(app).WithContext(ctx).lock(clockID)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	err = app.RunInTransaction(func(txApp core.App) error {
+		return replaceWorkClockRange(txApp, clockID, dayStart, dayEnd, sessions)
+	})
+
+	if err != nil {
+		return fmt.Errorf("failed to replace work clock records of %s: %w", dayStart.Format(time.DateOnly), err)
+	}
+
+	return nil
+}
+
+// replaceWorkClockRange replaces all work clock records of a clock within a time range by the given sessions.
+//
+// Parameters:
+// - txApp: The transaction the records are replaced in
+// - clockID: The ID of the clock, an empty string for the default clock
+// - start: The start of the range (inclusive)
+// - end: The end of the range (exclusive)
+// - sessions: The new sessions of the range, sorted and free of overlaps
+//
+// Returns:
+// - An error if the operation fails, a session starts outside of the employment, or if the
+// resulting records violate sequence constraints
+//
+// All new records as well as the records directly surrounding the range are validated,
+// so the whole transaction is rolled back if the change would break the alternation of
+// clock in and clock out records. The caller is responsible for locking the clock.
+func replaceWorkClockRange(txApp core.App, clockID string, start, end time.Time, sessions []clockInOutPair) error {
+	if err := checkEmployedDuringSessions(txApp, clockID, sessions); err != nil {
+		return err
+	}
+
+	collection, err := txApp.FindCollectionByNameOrId("work_clock")
+	if err != nil {
+		return fmt.Errorf("failed to find work clock collection: %w", err)
+	}
+
+	existingRecords, err := txApp.FindRecordsByFilter(collection, "clock = {:clock} && timestamp >= {:start} && timestamp < {:end}", "+timestamp", 0, 0, dbx.Params{
+		"clock": clockParam(clockID),
+		"start": dateTimeParam(start),
+		"end":   dateTimeParam(end),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to find existing work clock records: %w", err)
+	}
+
+	for _, record := range existingRecords {
+		if err := txApp.Delete(record); err != nil {
+			return fmt.Errorf("failed to delete work clock record with id '%s': %w", record.Id, err)
+		}
+	}
+
+	var recordIDs []string
+	for _, session := range sessions {
+		record, err := createWorkClockRecord(txApp, collection, clockID, session.ClockIn, true)
+		if err != nil {
+			return fmt.Errorf("failed to create clock in record at time '%s': %w", session.ClockIn.Format(time.RFC3339), err)
+		}
+		recordIDs = append(recordIDs, record.Id)
+
+		if session.ClockOut.IsZero() {
+			continue
+		}
+
+		record, err = createWorkClockRecord(txApp, collection, clockID, session.ClockOut, false)
+		if err != nil {
+			return fmt.Errorf("failed to create clock out record at time '%s': %w", session.ClockOut.Format(time.RFC3339), err)
+		}
+		recordIDs = append(recordIDs, record.Id)
+	}
+
+	precedingRecords, err := txApp.FindRecordsByFilter(collection, "clock = {:clock} && timestamp < {:start}", "-timestamp", 1, 0, dbx.Params{
+		"clock": clockParam(clockID),
+		"start": dateTimeParam(start),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to find preceding work clock record: %w", err)
+	}
+	for _, record := range precedingRecords {
+		recordIDs = append(recordIDs, record.Id)
+	}
+
+	succeedingRecords, err := txApp.FindRecordsByFilter(collection, "clock = {:clock} && timestamp >= {:end}", "+timestamp", 1, 0, dbx.Params{
+		"clock": clockParam(clockID),
+		"end":   dateTimeParam(end),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to find succeeding work clock record: %w", err)
+	}
+	for _, record := range succeedingRecords {
+		recordIDs = append(recordIDs, record.Id)
+	}
+
+	for _, recordID := range recordIDs {
+		if err := checkValidity(txApp, recordID); err != nil {
+			return fmt.Errorf("work clock record with id '%s' is not valid after replacing the records: %w", recordID, err)
+		}
+	}
+
+	return nil
+}
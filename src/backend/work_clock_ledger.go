@@ -0,0 +1,384 @@
+// Work Clock Ledger Module for PocketBase
+//
+// This module keeps an append-only hash chain of all changes to work clock records, providing
+// tamper evidence for audits. Clock records are legitimately modified and deleted, so the chain is
+// not stored on the records themselves but in the work_clock_ledger collection: every created,
+// updated or deleted record appends an entry with a snapshot of the record, the hash of the
+// previous entry and its own hash. The hash is signed with the server key (the same key exports
+// are signed with), which is stored outside the database.
+//
+// Someone with access to the database but not to the key file can't change a record or an entry
+// without the verification noticing: a changed record no longer matches the latest snapshot in the
+// chain, a changed or removed entry breaks the chain, and a recomputed chain lacks valid signatures.
+//
+// Corrections a superuser makes on behalf of the user (see the support corrections module) also
+// record the superuser, the person and the reason in the entry, covered by its hash.
+package backend
+
+import (
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"net/http"
+	"slices"
+	"strconv"
+	"sync"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+const (
+	// ledgerBatchSize is the number of ledger entries loaded at once during verification
+	ledgerBatchSize = 1000
+
+	// ledgerMaxProblems limits the number of problems reported by a verification
+	ledgerMaxProblems = 100
+)
+
+// WorkClockLedgerVerification is the result of verifying the work clock ledger.
+type WorkClockLedgerVerification struct {
+	Valid    bool     `json:"valid"`     // Whether the chain is intact and matches the current records
+	Entries  int      `json:"entries"`   // The number of entries in the chain
+	HeadHash string   `json:"head_hash"` // The hash of the latest entry, empty if the chain is empty
+	Problems []string `json:"problems"`  // The problems found, at most ledgerMaxProblems
+}
+
+// ledgerActor identifies a superuser making changes on behalf of the user.
+type ledgerActor struct {
+	Actor      string // Email of the superuser
+	OnBehalfOf string // Person the changes are made for
+	Reason     string // Why the changes are made
+}
+
+// ledgerActors contains the actors of the transactions that make changes on behalf of the user,
+// by their transactional App. Entries appended within such a transaction record the actor.
+var ledgerActors sync.Map
+
+// ledgerSnapshot is the state of a work clock record stored in a ledger entry.
+// The field order is fixed, so the same state always results in the same JSON.
+type ledgerSnapshot struct {
+	Timestamp   string   `json:"timestamp"`
+	ClockIn     bool     `json:"clock_in"`
+	Project     string   `json:"project"`
+	Tags        []string `json:"tags"`
+	Description string   `json:"description"`
+	Issue       string   `json:"issue"`
+	Clock       string   `json:"clock,omitempty"`    // Omitted for the default clock, so entries recorded before multiple clocks stay valid
+	Category    string   `json:"category,omitempty"` // Omitted for regular work, so entries recorded before categories stay valid
+}
+
+// RegisterWorkClockLedgerAPI registers the ledger hooks and the verification endpoint with the PocketBase server.
+// Every change of a work clock record is appended to the ledger within the same transaction.
+// It creates the following route:
+// - GET /api/work_clock/ledger/verify - Verifies the chain and compares it to the current records
+//
+// Parameters:
+// - app: The PocketBase application instance
+func RegisterWorkClockLedgerAPI(app core.App) {
+	appendEntry := func(action string) func(e *core.RecordEvent) error {
+		return func(e *core.RecordEvent) error {
+			if err := e.Next(); err != nil {
+				return err
+			}
+
+			// The entry is appended in a transaction, so reading the head and appending is serialized with other writes
+			if e.App.IsTransactional() {
+				return appendLedgerEntry(e.App, action, e.Record)
+			}
+			return e.App.RunInTransaction(func(txApp core.App) error {
+				return appendLedgerEntry(txApp, action, e.Record)
+			})
+		}
+	}
+
+	app.OnRecordCreateExecute("work_clock").BindFunc(appendEntry("create"))
+	app.OnRecordUpdateExecute("work_clock").BindFunc(appendEntry("update"))
+	app.OnRecordDeleteExecute("work_clock").BindFunc(appendEntry("delete"))
+
+	app.OnServe().BindFunc(func(se *core.ServeEvent) error {
+		if err := initializeLedger(app); err != nil {
+			return fmt.Errorf("failed to initialize the work clock ledger: %w", err)
+		}
+
+		se.Router.GET("/api/work_clock/ledger/verify", func(e *core.RequestEvent) error {
+			verification, err := verifyLedger(app)
+			if err != nil {
+				return e.Error(http.StatusInternalServerError, "Failed to verify the ledger", err)
+			}
+
+			return e.JSON(http.StatusOK, verification)
+		})
+
+		return se.Next()
+	})
+}
+
+// initializeLedger starts the chain with the existing work clock records if the ledger is still
+// empty, so records created before the ledger existed are covered as well.
+//
+// Parameters:
+// - app: The App interface (typically a PocketBase instance or transaction)
+//
+// Returns:
+// - An error if loading the records or appending the entries fails
+func initializeLedger(app core.App) error {
+	return app.RunInTransaction(func(txApp core.App) error {
+		head, err := findLedgerHead(txApp)
+		if err != nil || head != nil {
+			return err
+		}
+
+		records, err := txApp.FindRecordsByFilter("work_clock", "", "+timestamp", 0, 0)
+		if err != nil {
+			return err
+		}
+
+		for _, record := range records {
+			if err := appendLedgerEntry(txApp, "create", record); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// findLedgerHead finds the latest entry of the ledger.
+//
+// Parameters:
+// - app: The App interface (typically a PocketBase instance or transaction)
+//
+// Returns:
+// - The latest ledger entry, nil if the ledger is empty
+// - An error if the query fails
+func findLedgerHead(app core.App) (*core.Record, error) {
+	records, err := app.FindRecordsByFilter("work_clock_ledger", "", "-sequence", 1, 0)
+	if err != nil {
+		return nil, err
+	}
+	if len(records) == 0 {
+		return nil, nil
+	}
+	return records[0], nil
+}
+
+// appendLedgerEntry appends a change of a work clock record to the ledger.
+// It must be called within a transaction.
+//
+// Parameters:
+// - txApp: The transactional PocketBase application instance
+// - action: The kind of the change ('create', 'update' or 'delete')
+// - record: The work clock record after the change, or before it for deletions
+//
+// Returns:
+// - An error if signing or saving the entry fails
+func appendLedgerEntry(txApp core.App, action string, record *core.Record) error {
+	key, err := loadExportSigningKey(txApp)
+	if err != nil {
+		return err
+	}
+
+	collection, err := txApp.FindCollectionByNameOrId("work_clock_ledger")
+	if err != nil {
+		return err
+	}
+
+	head, err := findLedgerHead(txApp)
+	if err != nil {
+		return err
+	}
+
+	sequence, prevHash := 1, ""
+	if head != nil {
+		sequence, prevHash = head.GetInt("sequence")+1, head.GetString("hash")
+	}
+
+	data, err := json.Marshal(newLedgerSnapshot(record))
+	if err != nil {
+		return err
+	}
+	recordedAt := types.NowDateTime()
+
+	var actor ledgerActor
+	if value, ok := ledgerActors.Load(txApp); ok {
+		actor = value.(ledgerActor)
+	}
+
+	hash := ledgerEntryHash(sequence, action, record.Id, string(data), recordedAt.String(), prevHash, actor)
+
+	entry := core.NewRecord(collection)
+	entry.Set("sequence", sequence)
+	entry.Set("record_id", record.Id)
+	entry.Set("action", action)
+	entry.Set("data", string(data))
+	entry.Set("recorded_at", recordedAt)
+	entry.Set("prev_hash", prevHash)
+	entry.Set("actor", actor.Actor)
+	entry.Set("on_behalf_of", actor.OnBehalfOf)
+	entry.Set("reason", actor.Reason)
+	entry.Set("hash", hash)
+	entry.Set("signature", hex.EncodeToString(ed25519.Sign(key, []byte(hash))))
+
+	if err := txApp.Save(entry); err != nil {
+		return fmt.Errorf("failed to append to the work clock ledger: %w", err)
+	}
+	return nil
+}
+
+// newLedgerSnapshot creates the snapshot of a work clock record.
+//
+// Parameters:
+// - record: The work clock record
+//
+// Returns:
+// - The snapshot of the record
+func newLedgerSnapshot(record *core.Record) ledgerSnapshot {
+	tags := record.GetStringSlice("tags")
+	if tags == nil {
+		tags = []string{}
+	}
+
+	return ledgerSnapshot{
+		Timestamp:   record.GetDateTime("timestamp").String(),
+		ClockIn:     record.GetBool("clock_in"),
+		Project:     record.GetString("project"),
+		Tags:        tags,
+		Description: record.GetString("description"),
+		Issue:       record.GetString("issue"),
+		Clock:       record.GetString("clock"),
+		Category:    record.GetString("category"),
+	}
+}
+
+// ledgerEntryHash computes the hex encoded SHA-256 hash of a ledger entry.
+// Including the previous hash chains each entry to all entries before it.
+//
+// Parameters:
+// - sequence: The position of the entry in the chain
+// - action: The kind of the change
+// - recordID: The ID of the changed work clock record
+// - data: The JSON snapshot of the record
+// - recordedAt: The time the change was recorded
+// - prevHash: The hash of the previous entry, empty for the first entry
+// - actor: The superuser who made the change on behalf of the user, empty for changes by the user
+//
+// Returns:
+// - The hex encoded hash
+func ledgerEntryHash(sequence int, action, recordID, data, recordedAt, prevHash string, actor ledgerActor) string {
+	parts := []string{strconv.Itoa(sequence), action, recordID, recordedAt, prevHash, data}
+	// Only hashed if set, so the hashes of entries recorded before actors existed stay valid
+	if actor != (ledgerActor{}) {
+		parts = append(parts, actor.Actor, actor.OnBehalfOf, actor.Reason)
+	}
+
+	hash := sha256.New()
+	for _, part := range parts {
+		hash.Write([]byte(part))
+		hash.Write([]byte{'\n'})
+	}
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// verifyLedger verifies the chain of the ledger and compares the latest snapshot of every record to
+// the current work clock records.
+//
+// Parameters:
+// - app: The App interface (typically a PocketBase instance or transaction)
+//
+// Returns:
+// - The verification result
+// - An error if loading the entries, records or signing key fails
+func verifyLedger(app core.App) (WorkClockLedgerVerification, error) {
+	key, err := loadExportSigningKey(app)
+	if err != nil {
+		return WorkClockLedgerVerification{}, err
+	}
+	publicKey := key.Public().(ed25519.PublicKey)
+
+	verification := WorkClockLedgerVerification{Problems: []string{}}
+	addProblem := func(format string, args ...any) {
+		if len(verification.Problems) < ledgerMaxProblems {
+			verification.Problems = append(verification.Problems, fmt.Sprintf(format, args...))
+		}
+	}
+
+	// Latest snapshot of every record that was not deleted
+	snapshots := map[string]string{}
+
+	for {
+		entries, err := app.FindRecordsByFilter("work_clock_ledger", "sequence > {:sequence}", "+sequence", ledgerBatchSize, 0, dbx.Params{
+			"sequence": verification.Entries,
+		})
+		if err != nil {
+			return WorkClockLedgerVerification{}, err
+		}
+
+		for _, entry := range entries {
+			sequence := entry.GetInt("sequence")
+			if sequence != verification.Entries+1 {
+				addProblem("entry %d is missing", verification.Entries+1)
+			}
+			if entry.GetString("prev_hash") != verification.HeadHash {
+				addProblem("entry %d is not chained to the previous entry", sequence)
+			}
+
+			hash := ledgerEntryHash(sequence, entry.GetString("action"), entry.GetString("record_id"),
+				entry.GetString("data"), entry.GetDateTime("recorded_at").String(), entry.GetString("prev_hash"),
+				ledgerActor{Actor: entry.GetString("actor"), OnBehalfOf: entry.GetString("on_behalf_of"), Reason: entry.GetString("reason")})
+			if hash != entry.GetString("hash") {
+				addProblem("entry %d was modified", sequence)
+			}
+
+			signature, err := hex.DecodeString(entry.GetString("signature"))
+			if err != nil || !ed25519.Verify(publicKey, []byte(entry.GetString("hash")), signature) {
+				addProblem("entry %d has an invalid signature", sequence)
+			}
+
+			if entry.GetString("action") == "delete" {
+				delete(snapshots, entry.GetString("record_id"))
+			} else {
+				snapshots[entry.GetString("record_id")] = entry.GetString("data")
+			}
+
+			verification.Entries = sequence
+			verification.HeadHash = entry.GetString("hash")
+		}
+
+		if len(entries) < ledgerBatchSize {
+			break
+		}
+	}
+
+	records, err := app.FindRecordsByFilter("work_clock", "", "+timestamp", 0, 0)
+	if err != nil {
+		return WorkClockLedgerVerification{}, err
+	}
+
+	for _, record := range records {
+		snapshot, ok := snapshots[record.Id]
+		if !ok {
+			addProblem("record with id '%s' was created outside of the ledger", record.Id)
+			continue
+		}
+		delete(snapshots, record.Id)
+
+		data, err := json.Marshal(newLedgerSnapshot(record))
+		if err != nil {
+			return WorkClockLedgerVerification{}, err
+		}
+		if string(data) != snapshot {
+			addProblem("record with id '%s' was modified outside of the ledger", record.Id)
+		}
+	}
+
+	for _, recordID := range slices.Sorted(maps.Keys(snapshots)) {
+		addProblem("record with id '%s' was deleted outside of the ledger", recordID)
+	}
+
+	verification.Valid = len(verification.Problems) == 0
+	return verification, nil
+}
@@ -0,0 +1,141 @@
+package backend
+
+import (
+	"encoding/hex"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/yerTools/simple-frontend-stack/src/backend/backendtest"
+)
+
+func TestVerifyLedger(t *testing.T) {
+	// execute runs a statement directly on the database, bypassing the hooks like someone with
+	// access to the database file would
+	execute := func(t *testing.T, app core.App, query string, params dbx.Params) {
+		t.Helper()
+		if _, err := app.DB().NewQuery(query).Bind(params).Execute(); err != nil {
+			t.Fatalf("failed to execute %q: %v", query, err)
+		}
+	}
+
+	testCases := []struct {
+		name   string
+		tamper func(t *testing.T, app core.App, records []*core.Record)
+		want   []string // Substrings of the expected problems
+	}{
+		{
+			name:   "untouched",
+			tamper: func(t *testing.T, app core.App, records []*core.Record) {},
+		},
+		{
+			name: "tampered entry",
+			tamper: func(t *testing.T, app core.App, records []*core.Record) {
+				execute(t, app, "UPDATE work_clock_ledger SET data = replace(data, '09:00', '08:00') WHERE sequence = 1", nil)
+			},
+			want: []string{"entry 1 was modified", "was modified outside of the ledger"},
+		},
+		{
+			name: "deleted middle entry",
+			tamper: func(t *testing.T, app core.App, records []*core.Record) {
+				execute(t, app, "DELETE FROM work_clock_ledger WHERE sequence = 2", nil)
+			},
+			want: []string{"entry 2 is missing", "entry 3 is not chained to the previous entry", "was created outside of the ledger"},
+		},
+		{
+			name: "record changed outside of the ledger",
+			tamper: func(t *testing.T, app core.App, records []*core.Record) {
+				execute(t, app, "UPDATE work_clock SET description = 'Overtime' WHERE id = {:id}", dbx.Params{"id": records[0].Id})
+			},
+			want: []string{"was modified outside of the ledger"},
+		},
+		{
+			name: "category changed outside of the ledger",
+			tamper: func(t *testing.T, app core.App, records []*core.Record) {
+				execute(t, app, "UPDATE work_clock SET category = 'on_call' WHERE id = {:id}", dbx.Params{"id": records[2].Id})
+			},
+			want: []string{"was modified outside of the ledger"},
+		},
+		{
+			name: "invalid signature",
+			tamper: func(t *testing.T, app core.App, records []*core.Record) {
+				// The hash is recomputed like someone without the key would, so only the signature reveals the change
+				entry, err := app.FindFirstRecordByFilter("work_clock_ledger", "sequence = 3")
+				if err != nil {
+					t.Fatalf("failed to find entry: %v", err)
+				}
+				data := strings.Replace(entry.GetString("data"), "13:00", "12:30", 1)
+				hash := ledgerEntryHash(3, entry.GetString("action"), entry.GetString("record_id"), data,
+					entry.GetDateTime("recorded_at").String(), entry.GetString("prev_hash"), ledgerActor{})
+				execute(t, app, "UPDATE work_clock_ledger SET data = {:data}, hash = {:hash}, signature = {:signature} WHERE sequence = 3", dbx.Params{
+					"data":      data,
+					"hash":      hash,
+					"signature": hex.EncodeToString(make([]byte, 64)),
+				})
+			},
+			want: []string{"entry 3 has an invalid signature", "entry 4 is not chained to the previous entry", "was modified outside of the ledger"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			app := backendtest.NewApp(t)
+			RegisterWorkClockLedgerAPI(app)
+
+			records := backendtest.AddRecords(t, app,
+				backendtest.ClockIn("2025-04-01T09:00:00Z"),
+				backendtest.ClockOut("2025-04-01T12:00:00Z"),
+				backendtest.ClockIn("2025-04-01T13:00:00Z"),
+				backendtest.ClockOut("2025-04-01T17:00:00Z"),
+			)
+			tc.tamper(t, app, records)
+
+			verification, err := verifyLedger(app)
+			if err != nil {
+				t.Fatalf("failed to verify ledger: %v", err)
+			}
+			if verification.Entries != 4 {
+				t.Errorf("expected 4 entries, got %d", verification.Entries)
+			}
+
+			matches := slices.EqualFunc(verification.Problems, tc.want, strings.Contains)
+			if verification.Valid != (len(tc.want) == 0) || !matches {
+				t.Errorf("expected problems %q, got valid=%t with %q", tc.want, verification.Valid, verification.Problems)
+			}
+		})
+	}
+}
+
+func TestLedgerRecordsCategory(t *testing.T) {
+	app := backendtest.NewApp(t)
+	RegisterWorkClockLedgerAPI(app)
+
+	records := backendtest.AddRecords(t, app,
+		backendtest.ClockIn("2025-04-01T09:00:00Z"),
+		backendtest.ClockOut("2025-04-01T17:00:00Z"),
+	)
+
+	// Reclassifying a session changes its counted hours, so it has to be part of the chain
+	records[0].Set("category", "on_call")
+	if err := app.Save(records[0]); err != nil {
+		t.Fatalf("failed to change category: %v", err)
+	}
+
+	head, err := findLedgerHead(app)
+	if err != nil || head == nil {
+		t.Fatalf("failed to find ledger head: %v", err)
+	}
+	if head.GetInt("sequence") != 3 || head.GetString("action") != "update" || !strings.Contains(head.GetString("data"), `"category":"on_call"`) {
+		t.Errorf("expected an update entry with the category, got %d %s %s", head.GetInt("sequence"), head.GetString("action"), head.GetString("data"))
+	}
+
+	verification, err := verifyLedger(app)
+	if err != nil {
+		t.Fatalf("failed to verify ledger: %v", err)
+	}
+	if !verification.Valid {
+		t.Errorf("expected a valid ledger, got %q", verification.Problems)
+	}
+}
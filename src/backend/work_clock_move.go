@@ -0,0 +1,162 @@
+// Work Clock Move Module for PocketBase
+//
+// This module provides an endpoint to reassign an existing session to another clock and/or
+// project, e.g. when time was tracked on the main job's clock but belongs to the side project.
+// Both records of the session are moved within a single transaction, which is rolled back if the
+// session doesn't fit into the sequence of the target clock. The reports and budgets of both
+// clocks and projects are derived from the records, so they are updated by the move as well.
+package backend
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"slices"
+
+	"github.com/pocketbase/pocketbase/core"
+)
+
+// workSessionMove describes where a session is moved to.
+type workSessionMove struct {
+	ChangeClock   bool   // Whether the session is moved to another clock
+	ClockID       string // ID of the target clock, empty for the default clock
+	ChangeProject bool   // Whether the session is assigned to another project
+	ProjectID     string // ID of the target project, empty to remove the session from its project
+}
+
+// RegisterWorkClockMoveAPI registers the move endpoint with the PocketBase server.
+// It creates the following route:
+// - POST /api/work_clock/move - Moves a session (by its 'clock_in_id') to the clock 'to_clock'
+// and/or the project 'project_id'
+//
+// Only the passed parameters are changed. An empty 'to_clock' moves the session to the default
+// clock, an empty 'project_id' removes the session from its project.
+//
+// Parameters:
+// - app: The PocketBase application instance
+func RegisterWorkClockMoveAPI(app core.App) {
+	app.OnServe().BindFunc(func(se *core.ServeEvent) error {
+		se.Router.POST("/api/work_clock/move", func(e *core.RequestEvent) error {
+			clockInID := e.Request.FormValue("clock_in_id")
+			if clockInID == "" {
+				return e.Error(http.StatusBadRequest, "Missing 'clock_in_id' (string) parameter", nil)
+			}
+
+			if err := e.Request.ParseForm(); err != nil {
+				return e.Error(http.StatusBadRequest, "Invalid form data", err)
+			}
+
+			// An empty value is a valid target, so the presence of the parameters is checked
+			var move workSessionMove
+			var err error
+			if _, move.ChangeClock = e.Request.Form["to_clock"]; move.ChangeClock {
+				move.ClockID, err = findClockID(app, e.Request.Form.Get("to_clock"))
+				if err != nil {
+					return e.Error(http.StatusBadRequest, err.Error(), nil)
+				}
+			}
+			if _, move.ChangeProject = e.Request.Form["project_id"]; move.ChangeProject {
+				move.ProjectID = e.Request.Form.Get("project_id")
+			}
+
+			if !move.ChangeClock && !move.ChangeProject {
+				return e.Error(http.StatusBadRequest, "Missing 'to_clock' or 'project_id' (string) parameter", nil)
+			}
+
+			if err := moveWorkSession(e.Request.Context(), app, clockInID, move); err != nil {
+				return e.Error(http.StatusInternalServerError, fmt.Sprintf("Failed to move session: %v", err), err)
+			}
+			return callSucceeded(e)
+		})
+
+		return se.Next()
+	})
+}
+
+// moveWorkSession moves the session started by a clock in record to another clock and/or project.
+//
+// Parameters:
+// - ctx: The context cancelling the operation, e.g. of the request
+// - app: The App interface (typically a PocketBase instance or transaction)
+// - clockInID: The ID of the clock in record starting the session
+// - move: The target of the session
+//
+// Returns:
+// - An error if the record is not a clock in record, the project does not exist, the operation
+// fails, or if the session overlaps the sessions of the target clock
+//
+// The operation is performed within a transaction. The moved records as well as their former
+// neighbors, which become adjacent to each other, are validated before committing.
+func moveWorkSession(ctx context.Context, app core.App, clockInID string, move workSessionMove) error {
+	unlock, err := workClockServiceOf(app).WithContext(ctx).lockAll()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	err = app.RunInTransaction(func(txApp core.App) error {
+		record, err := findClockInRecord(txApp, "", clockInID)
+		if err != nil {
+			return err
+		}
+
+		session, err := findWorkSessionByClockIn(txApp, record)
+		if err != nil {
+			return err
+		}
+
+		if move.ChangeProject {
+			if move.ProjectID != "" {
+				if _, err := txApp.FindRecordById("projects", move.ProjectID); err != nil {
+					return fmt.Errorf("failed to find project with id '%s': %w", move.ProjectID, err)
+				}
+			}
+			session.ClockIn.Set("project", move.ProjectID)
+		}
+
+		if !move.ChangeClock || move.ClockID == session.ClockIn.GetString("clock") {
+			if err := txApp.Save(session.ClockIn); err != nil {
+				return fmt.Errorf("failed to save clock in record: %w", err)
+			}
+			return nil
+		}
+
+		records := []*core.Record{session.ClockIn}
+		if session.ClockOut != nil {
+			records = append(records, session.ClockOut)
+		}
+
+		var recordIDs []string
+		for _, record := range records {
+			neighborIDs, err := findNeighborWorkClockRecordIDs(txApp, record)
+			if err != nil {
+				return err
+			}
+			recordIDs = append(recordIDs, neighborIDs...)
+		}
+
+		for _, record := range records {
+			record.Set("clock", move.ClockID)
+			if err := txApp.Save(record); err != nil {
+				return fmt.Errorf("failed to save work clock record with id '%s': %w", record.Id, err)
+			}
+			recordIDs = append(recordIDs, record.Id)
+		}
+
+		// The records of a session are neighbors of each other, they are only validated once
+		slices.Sort(recordIDs)
+		for _, recordID := range slices.Compact(recordIDs) {
+			if err := checkValidity(txApp, recordID); err != nil {
+				return fmt.Errorf("work clock record with id '%s' is not valid after moving the session: %w", recordID, err)
+			}
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return fmt.Errorf("failed to move session starting with record '%s': %w", clockInID, err)
+	}
+
+	return nil
+}
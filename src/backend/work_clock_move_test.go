@@ -0,0 +1,97 @@
+package backend
+
+import (
+	"testing"
+	"time"
+
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/yerTools/simple-frontend-stack/src/backend/backendtest"
+)
+
+func TestMoveWorkSession(t *testing.T) {
+	app := backendtest.NewApp(t)
+	service := workClockServiceOf(app)
+
+	clocks, err := app.FindCollectionByNameOrId("clocks")
+	if err != nil {
+		t.Fatalf("failed to find clocks collection: %v", err)
+	}
+	clock := core.NewRecord(clocks)
+	clock.Set("name", "side")
+	if err := app.Save(clock); err != nil {
+		t.Fatalf("failed to save clock: %v", err)
+	}
+
+	projects, err := app.FindCollectionByNameOrId("projects")
+	if err != nil {
+		t.Fatalf("failed to find projects collection: %v", err)
+	}
+	project := core.NewRecord(projects)
+	project.Set("name", "Side project")
+	if err := app.Save(project); err != nil {
+		t.Fatalf("failed to save project: %v", err)
+	}
+
+	addSession := func(clockID, start, end string) workSession {
+		t.Helper()
+		if err := service.AddClockInOutPair(clockID, backendtest.MustParseTime(start), backendtest.MustParseTime(end)); err != nil {
+			t.Fatalf("failed to add session %s - %s: %v", start, end, err)
+		}
+		sessions, err := findWorkSessions(app, clockID, backendtest.MustParseTime(start), backendtest.MustParseTime(end))
+		if err != nil || len(sessions) != 1 {
+			t.Fatalf("expected the added session, got %d: %v", len(sessions), err)
+		}
+		return sessions[0]
+	}
+	morning := addSession("", "2025-04-01T09:00:00Z", "2025-04-01T12:00:00Z")
+	afternoon := addSession("", "2025-04-01T13:00:00Z", "2025-04-01T17:00:00Z")
+	addSession(clock.Id, "2025-04-01T14:00:00Z", "2025-04-01T15:00:00Z")
+
+	findRecord := func(id string) *core.Record {
+		t.Helper()
+		record, err := app.FindRecordById("work_clock", id)
+		if err != nil {
+			t.Fatalf("failed to find record: %v", err)
+		}
+		return record
+	}
+
+	// The afternoon overlaps the session of the side clock, so nothing is changed
+	err = moveWorkSession(t.Context(), app, afternoon.ClockIn.Id, workSessionMove{ChangeClock: true, ClockID: clock.Id, ChangeProject: true, ProjectID: project.Id})
+	if err == nil {
+		t.Fatal("expected a session overlapping the target clock to be rejected")
+	}
+	for _, id := range []string{afternoon.ClockIn.Id, afternoon.ClockOut.Id} {
+		if record := findRecord(id); record.GetString("clock") != "" || record.GetString("project") != "" {
+			t.Errorf("expected record %s to be rolled back, got clock %q and project %q", id, record.GetString("clock"), record.GetString("project"))
+		}
+	}
+
+	err = moveWorkSession(t.Context(), app, morning.ClockIn.Id, workSessionMove{ChangeClock: true, ClockID: clock.Id, ChangeProject: true, ProjectID: project.Id})
+	if err != nil {
+		t.Fatalf("failed to move session: %v", err)
+	}
+	if record := findRecord(morning.ClockIn.Id); record.GetString("clock") != clock.Id || record.GetString("project") != project.Id {
+		t.Errorf("expected the clock in to be moved, got clock %q and project %q", record.GetString("clock"), record.GetString("project"))
+	}
+	if record := findRecord(morning.ClockOut.Id); record.GetString("clock") != clock.Id {
+		t.Errorf("expected the clock out to be moved, got clock %q", record.GetString("clock"))
+	}
+	for clockID, want := range map[string]int{"": 1, clock.Id: 2} {
+		if sessions, err := findWorkSessions(app, clockID, morning.Start(), afternoon.End(time.Now())); err != nil || len(sessions) != want {
+			t.Errorf("expected %d sessions on clock %q, got %d: %v", want, clockID, len(sessions), err)
+		}
+	}
+
+	// A project-only move keeps the session on its clock
+	if err := moveWorkSession(t.Context(), app, afternoon.ClockIn.Id, workSessionMove{ChangeProject: true, ProjectID: project.Id}); err != nil {
+		t.Fatalf("failed to move session to project: %v", err)
+	}
+	if record := findRecord(afternoon.ClockIn.Id); record.GetString("clock") != "" || record.GetString("project") != project.Id {
+		t.Errorf("expected only the project to change, got clock %q and project %q", record.GetString("clock"), record.GetString("project"))
+	}
+
+	if err := moveWorkSession(t.Context(), app, afternoon.ClockIn.Id, workSessionMove{ChangeProject: true, ProjectID: "missingproject0"}); err == nil {
+		t.Error("expected a missing project to be rejected")
+	}
+}
@@ -0,0 +1,168 @@
+package backend
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/yerTools/simple-frontend-stack/src/backend/backendtest"
+	"pgregory.net/rapid"
+)
+
+// propertyBase is the start of the time window used for generated timestamps.
+var propertyBase = time.Date(2025, time.April, 1, 0, 0, 0, 0, time.UTC)
+
+// propertyWindowMinutes is the size of the time window used for generated timestamps. It is kept
+// small, so generated operations frequently overlap, collide, and cross existing records.
+const propertyWindowMinutes = 24 * 60
+
+// clockOperationKind identifies a mutating work clock operation.
+type clockOperationKind int
+
+const (
+	opAddPair clockOperationKind = iota
+	opClockAt
+	opDeletePair
+	opModifyTimestamp
+	opAddMany
+	clockOperationKinds
+)
+
+// clockOperation is a randomly generated call of a mutating work clock operation. Records are
+// referenced by an index into the current records, so operations stay meaningful for any state.
+type clockOperation struct {
+	Kind    clockOperationKind
+	ClockIn bool
+	Index   int
+	Times   []time.Time
+}
+
+// String describes the operation, so failing sequences are readable in the test output.
+func (op clockOperation) String() string {
+	times := make([]string, 0, len(op.Times))
+	for _, timestamp := range op.Times {
+		times = append(times, timestamp.Format("15:04"))
+	}
+
+	switch op.Kind {
+	case opAddPair:
+		return fmt.Sprintf("addPair(%s, %s)", times[0], times[1])
+	case opClockAt:
+		return fmt.Sprintf("clockAt(in=%t, %s)", op.ClockIn, times[0])
+	case opDeletePair:
+		return fmt.Sprintf("deletePair(#%d)", op.Index)
+	case opModifyTimestamp:
+		return fmt.Sprintf("modify(#%d, %s)", op.Index, times[0])
+	default:
+		return fmt.Sprintf("addMany(%v)", times)
+	}
+}
+
+// clockOperationGenerator generates random calls of the mutating work clock operations.
+func clockOperationGenerator() *rapid.Generator[clockOperation] {
+	return rapid.Custom(func(t *rapid.T) clockOperation {
+		randomTime := func(label string) time.Time {
+			return propertyBase.Add(time.Duration(rapid.IntRange(0, propertyWindowMinutes-1).Draw(t, label)) * time.Minute)
+		}
+
+		op := clockOperation{
+			Kind:    clockOperationKind(rapid.IntRange(0, int(clockOperationKinds)-1).Draw(t, "kind")),
+			ClockIn: rapid.Bool().Draw(t, "clock_in"),
+			Index:   rapid.IntRange(0, 50).Draw(t, "index"),
+		}
+
+		switch op.Kind {
+		case opAddPair:
+			op.Times = []time.Time{randomTime("clock_in"), randomTime("clock_out")}
+		case opClockAt, opModifyTimestamp:
+			op.Times = []time.Time{randomTime("timestamp")}
+		case opAddMany:
+			// Clock ins first, clock outs second, like AddManyRecords expects them
+			op.Times = make([]time.Time, 2*rapid.IntRange(1, 3).Draw(t, "pairs"))
+			for j := range op.Times {
+				op.Times[j] = randomTime(fmt.Sprintf("time_%d", j))
+			}
+		}
+
+		return op
+	})
+}
+
+// apply executes the operation. Errors are expected, since most random operations violate the sequence.
+func (op clockOperation) apply(t *testing.T, app core.App) error {
+	t.Helper()
+
+	switch op.Kind {
+	case opAddPair:
+		return workClockServiceOf(app).AddClockInOutPair("", op.Times[0], op.Times[1])
+	case opClockAt:
+		return workClockServiceOf(app).ClockInOutAt("", op.ClockIn, op.Times[0])
+	case opAddMany:
+		half := len(op.Times) / 2
+		return workClockServiceOf(app).AddManyRecords("", "", op.Times[:half], op.Times[half:])
+	}
+
+	records, err := app.FindRecordsByFilter("work_clock", "", "+timestamp", 0, 0)
+	if err != nil {
+		t.Fatalf("failed to find work clock records: %v", err)
+	}
+	if len(records) == 0 {
+		return nil
+	}
+	record := records[op.Index%len(records)]
+
+	if op.Kind == opDeletePair {
+		return workClockServiceOf(app).DeleteClockInOutPair(record.Id, time.Time{})
+	}
+	return workClockServiceOf(app).ModifyTimestamp(record.Id, op.Times[0], time.Time{})
+}
+
+func TestWorkClockOperationsKeepAlternation(t *testing.T) {
+	app := backendtest.NewApp(t)
+
+	collection, err := app.FindCollectionByNameOrId("work_clock")
+	if err != nil {
+		t.Fatalf("failed to find work clock collection: %v", err)
+	}
+
+	rapid.Check(t, func(rt *rapid.T) {
+		if err := app.TruncateCollection(collection); err != nil {
+			t.Fatalf("failed to reset work clock records: %v", err)
+		}
+
+		ops := rapid.SliceOfN(clockOperationGenerator(), 0, 30).Draw(rt, "ops")
+		for i, op := range ops {
+			before := backendtest.Records(t, app)
+
+			if err := op.apply(t, app); err != nil {
+				// A rejected operation must not leave any partial changes behind
+				if after := backendtest.Records(t, app); !slices.EqualFunc(before, after, sameRecord) {
+					rt.Fatalf("rejected operation %d %s changed the records: %v", i, op, err)
+				}
+				continue
+			}
+
+			if !isAlternating(backendtest.Records(t, app)) {
+				rt.Fatalf("operation %d %s broke the alternation", i, op)
+			}
+		}
+	})
+}
+
+// sameRecord reports whether two records have the same timestamp and clock state.
+func sameRecord(a, b backendtest.Record) bool {
+	return a.Timestamp.Equal(b.Timestamp) && a.ClockIn == b.ClockIn
+}
+
+// isAlternating reports whether the records alternate between clock in and clock out,
+// starting with a clock in record.
+func isAlternating(records []backendtest.Record) bool {
+	for i, record := range records {
+		if record.ClockIn != (i%2 == 0) {
+			return false
+		}
+	}
+	return true
+}
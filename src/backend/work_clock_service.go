@@ -0,0 +1,355 @@
+// Work Clock Service Module for PocketBase
+//
+// This module provides the WorkClockService, which carries everything the clock operations
+// depend on: the app (or transaction) they run in, the source of the current time (see the clock
+// source module), the settings and the bus the clock events are triggered on. The service is
+// registered once per app with RegisterWorkClockService and looked up by route handlers and hooks
+// with workClockServiceOf, so tests can register a service with a simulated clock or their own
+// settings instead of changing package state.
+//
+// Changes of the work clock records are serialized per clock: operations on one clock don't wait
+// for operations on another clock, while operations affecting several or unknown clocks (such as
+// moving a session to another clock or rolling back an import) lock all clocks. Operations wait at
+// most workClockLockTimeout for their clocks and stop waiting once the context of the service,
+// e.g. of the request, is done.
+package backend
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"time"
+
+	"github.com/pocketbase/pocketbase/core"
+)
+
+// workClockServiceKey is the key of the registered WorkClockService in the app store.
+const workClockServiceKey = "workClockService"
+
+// workClockLockTimeout is the maximum duration an operation waits for the clocks it changes, so an
+// operation queued behind a long import fails instead of waiting indefinitely.
+const workClockLockTimeout = 30 * time.Second
+
+// errClockBusy is returned if an operation can't lock its clocks in time.
+var errClockBusy = errors.New("the clock is busy with another operation")
+
+// WorkClockService performs the clock operations of an app.
+type WorkClockService struct {
+	app      core.App        // The app or transaction the operations run in
+	clock    Clock           // The source of the current time
+	settings *Settings       // The settings the operations are checked against
+	events   *ClockEvents    // The bus the clock events are triggered on
+	locks    *clockLocks     // The locks serializing the changes per clock
+	ctx      context.Context // The context cancelling the operations, e.g. of the request
+}
+
+// NewWorkClockService creates a service for an app using the system clock, the loaded settings
+// and the default clock event bus.
+//
+// Parameters:
+// - app: The PocketBase application instance
+//
+// Returns:
+// - The service
+func NewWorkClockService(app core.App) *WorkClockService {
+	return &WorkClockService{
+		app:      app,
+		clock:    SystemClock{},
+		settings: &settings,
+		events:   defaultClockEvents,
+		locks:    newClockLocks(),
+		ctx:      context.Background(),
+	}
+}
+
+// WithClock returns a copy of the service using another source of the current time, e.g. a
+// simulated clock in tests. The copy shares the locks with the service.
+//
+// Parameters:
+// - clock: The source of the current time
+//
+// Returns:
+// - The copy of the service
+func (s *WorkClockService) WithClock(clock Clock) *WorkClockService {
+	service := *s
+	service.clock = clock
+	return &service
+}
+
+// Now returns the current time of the service's clock.
+func (s *WorkClockService) Now() time.Time {
+	return s.clock.Now()
+}
+
+// Settings returns the settings the service checks its operations against.
+func (s *WorkClockService) Settings() *Settings {
+	return s.settings
+}
+
+// WithSettings returns a copy of the service checking the operations against other settings.
+// The copy shares the locks with the service.
+//
+// Parameters:
+// - settings: The settings
+//
+// Returns:
+// - The copy of the service
+func (s *WorkClockService) WithSettings(settings *Settings) *WorkClockService {
+	service := *s
+	service.settings = settings
+	return &service
+}
+
+// WithContext returns a copy of the service whose operations are cancelled with the context, e.g.
+// of the request they are performed for. A cancelled operation stops waiting for its clocks and
+// rolls back the changes it didn't commit yet. The copy shares the locks with the service.
+//
+// Parameters:
+// - ctx: The context
+//
+// Returns:
+// - The copy of the service
+func (s *WorkClockService) WithContext(ctx context.Context) *WorkClockService {
+	service := *s
+	service.ctx = ctx
+	return &service
+}
+
+// withApp returns a copy of the service running its operations in another app, typically a
+// transaction of the app the service is registered with. The copy shares the locks with the service.
+//
+// Parameters:
+// - app: The app or transaction
+//
+// Returns:
+// - The copy of the service
+func (s *WorkClockService) withApp(app core.App) *WorkClockService {
+	service := *s
+	service.app = app
+	return &service
+}
+
+// RegisterWorkClockService registers the service used by the route handlers and hooks of an app.
+// Without registration, a service created by NewWorkClockService is used.
+//
+// Parameters:
+// - app: The PocketBase application instance
+// - service: The service
+func RegisterWorkClockService(app core.App, service *WorkClockService) {
+	app.Store().Set(workClockServiceKey, service)
+}
+
+// workClockServiceOf returns the service registered with an app, running its operations in the
+// given app or transaction.
+//
+// Parameters:
+// - app: The app or a transaction of it
+//
+// Returns:
+// - The service
+func workClockServiceOf(app core.App) *WorkClockService {
+	service, _ := app.Store().GetOrSet(workClockServiceKey, func() any {
+		return NewWorkClockService(app)
+	}).(*WorkClockService)
+
+	if service.app == app {
+		return service
+	}
+	return service.withApp(app)
+}
+
+// requestWorkClockService returns the service of an app, whose operations are cancelled with the request.
+//
+// Parameters:
+// - app: The App interface (typically a PocketBase instance or transaction)
+// - e: The RequestEvent from the HTTP handler
+//
+// Returns:
+// - The service
+func requestWorkClockService(app core.App, e *core.RequestEvent) *WorkClockService {
+	return workClockServiceOf(app).WithContext(e.Request.Context())
+}
+
+// lock locks the given clocks until the returned function is called.
+//
+// Parameters:
+// - clockIDs: The IDs of the clocks, an empty string for the default clock
+//
+// Returns:
+// - The function unlocking the clocks
+// - An error if the context of the service is done or the clocks stay busy for workClockLockTimeout
+func (s *WorkClockService) lock(clockIDs ...string) (func(), error) {
+	ctx, cancel := context.WithTimeout(s.ctx, workClockLockTimeout)
+	defer cancel()
+
+	return s.locks.lock(ctx, clockIDs...)
+}
+
+// lockAll locks all clocks until the returned function is called.
+//
+// Returns:
+// - The function unlocking the clocks
+// - An error if the context of the service is done or the clocks stay busy for workClockLockTimeout
+func (s *WorkClockService) lockAll() (func(), error) {
+	ctx, cancel := context.WithTimeout(s.ctx, workClockLockTimeout)
+	defer cancel()
+
+	return s.locks.lockAll(ctx)
+}
+
+// lockRecordClock locks the clock of a work clock record until the returned function is called.
+// If the record doesn't exist, all clocks are locked and the operation reports the missing record.
+//
+// Parameters:
+// - recordID: The ID of the work clock record
+//
+// Returns:
+// - The function unlocking the clock
+// - An error if the context of the service is done or the clock stays busy for workClockLockTimeout
+func (s *WorkClockService) lockRecordClock(recordID string) (func(), error) {
+	record, err := s.app.FindRecordById("work_clock", recordID)
+	if err != nil {
+		return s.lockAll()
+	}
+	return s.lock(record.GetString("clock"))
+}
+
+// lockSession locks the clock of a session until the returned function is called.
+//
+// Parameters:
+// - clockID: The ID of the clock of the open session, only used if clockInID is empty
+// - clockInID: The ID of the clock in record starting the session, an empty string refers to the open session
+//
+// Returns:
+// - The function unlocking the clock
+// - An error if the context of the service is done or the clock stays busy for workClockLockTimeout
+func (s *WorkClockService) lockSession(clockID string, clockInID string) (func(), error) {
+	if clockInID == "" {
+		return s.lock(clockID)
+	}
+	return s.lockRecordClock(clockInID)
+}
+
+// contextMutex is a mutex whose locking can be cancelled by a context.
+type contextMutex chan struct{}
+
+// lock locks the mutex.
+//
+// Parameters:
+// - ctx: The context cancelling the wait for the mutex
+//
+// Returns:
+// - An error if the context is done before the mutex was locked
+func (m contextMutex) lock(ctx context.Context) error {
+	select {
+	case m <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("%w: %w", errClockBusy, ctx.Err())
+	}
+}
+
+// unlock unlocks the mutex.
+func (m contextMutex) unlock() {
+	<-m
+}
+
+// clockLocks serializes the changes of the work clock records per clock.
+type clockLocks struct {
+	gate   contextMutex            // Held while looking up the clock locks, and by operations on all clocks
+	clocks map[string]contextMutex // Locks per clock ID, guarded by gate
+}
+
+// newClockLocks creates the locks of the clocks.
+//
+// Returns:
+// - The locks
+func newClockLocks() *clockLocks {
+	return &clockLocks{gate: make(contextMutex, 1), clocks: map[string]contextMutex{}}
+}
+
+// lock locks the given clocks in a fixed order, so concurrent operations can't deadlock.
+//
+// Parameters:
+// - ctx: The context cancelling the wait for the clocks
+// - clockIDs: The IDs of the clocks, an empty string for the default clock
+//
+// Returns:
+// - The function unlocking the clocks
+// - An error if the context is done before all clocks were locked
+func (l *clockLocks) lock(ctx context.Context, clockIDs ...string) (func(), error) {
+	if err := l.gate.lock(ctx); err != nil {
+		return nil, err
+	}
+	mutexes := make([]contextMutex, 0, len(clockIDs))
+	for _, clockID := range slices.Compact(slices.Sorted(slices.Values(clockIDs))) {
+		mutex, ok := l.clocks[clockID]
+		if !ok {
+			mutex = make(contextMutex, 1)
+			l.clocks[clockID] = mutex
+		}
+		mutexes = append(mutexes, mutex)
+	}
+	l.gate.unlock()
+
+	return lockMutexes(ctx, mutexes)
+}
+
+// lockAll locks all clocks. The gate stays locked, so no operation can lock a clock before all
+// clocks are unlocked again.
+//
+// Parameters:
+// - ctx: The context cancelling the wait for the clocks
+//
+// Returns:
+// - The function unlocking the clocks
+// - An error if the context is done before all clocks were locked
+func (l *clockLocks) lockAll(ctx context.Context) (func(), error) {
+	if err := l.gate.lock(ctx); err != nil {
+		return nil, err
+	}
+
+	mutexes := make([]contextMutex, 0, len(l.clocks))
+	for _, clockID := range slices.Sorted(maps.Keys(l.clocks)) {
+		mutexes = append(mutexes, l.clocks[clockID])
+	}
+
+	unlock, err := lockMutexes(ctx, mutexes)
+	if err != nil {
+		l.gate.unlock()
+		return nil, err
+	}
+
+	return func() {
+		unlock()
+		l.gate.unlock()
+	}, nil
+}
+
+// lockMutexes locks the mutexes in the given order.
+//
+// Parameters:
+// - ctx: The context cancelling the wait for the mutexes
+// - mutexes: The mutexes
+//
+// Returns:
+// - The function unlocking the mutexes
+// - An error if the context is done before all mutexes were locked, in which case none stay locked
+func lockMutexes(ctx context.Context, mutexes []contextMutex) (func(), error) {
+	unlock := func(locked []contextMutex) {
+		for _, mutex := range slices.Backward(locked) {
+			mutex.unlock()
+		}
+	}
+
+	for i, mutex := range mutexes {
+		if err := mutex.lock(ctx); err != nil {
+			unlock(mutexes[:i])
+			return nil, err
+		}
+	}
+
+	return func() { unlock(mutexes) }, nil
+}
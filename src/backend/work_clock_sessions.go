@@ -0,0 +1,530 @@
+// Work Clock Sessions Module for PocketBase
+//
+// This module pairs the raw clock in and clock out records of the work_clock collection into
+// sessions using the pairing package. A session starts with a clock in record and ends with the
+// clock out record following it. The latest session may still be open if the user is currently
+// clocked in.
+//
+// Sessions are the basis for all reports and for the metadata attached to a period of work
+// (such as the project), which is stored on the clock in record of the session.
+//
+// The sessions listing is paginated with a cursor, so clients can page through multi-year
+// datasets without loading them at once. Records that can't be paired properly are reported with
+// the page ('orphan_clock_out' or 'overlapping_session', see pairing.Issue), so clients can offer
+// to repair them instead of silently showing mis-paired sessions.
+package backend
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/yerTools/simple-frontend-stack/src/backend/pairing"
+)
+
+// defaultSessionsPageSize is the number of sessions per page if no limit is requested.
+const defaultSessionsPageSize = 100
+
+// maxSessionsPageSize is the maximum number of sessions per page.
+const maxSessionsPageSize = 1000
+
+// WorkSessionEntry is the representation of a session in the sessions listing and exports.
+type WorkSessionEntry struct {
+	ClockInID       string     `json:"clock_in_id"`      // ID of the clock in record starting the session
+	ClockOutID      string     `json:"clock_out_id"`     // ID of the clock out record, empty for an open session
+	ClockID         string     `json:"clock_id"`         // ID of the clock of the session, empty for the default clock
+	Start           time.Time  `json:"start"`            // Start of the session
+	End             *time.Time `json:"end"`              // End of the session, nil for an open session
+	DurationSeconds int64      `json:"duration_seconds"` // Duration of the session, open sessions last until now
+	Duration        string     `json:"duration"`         // Duration formatted in the configured duration format
+	Description     string     `json:"description"`      // Description of the session
+	ProjectID       string     `json:"project_id"`       // ID of the project of the session
+	TagIDs          []string   `json:"tag_ids"`          // IDs of the tags of the session
+	Issue           string     `json:"issue"`            // Issue reference of the session
+	Category        string     `json:"category"`         // Category of the session, empty for regular work
+}
+
+// WorkSessionsPage is a page of the sessions listing.
+type WorkSessionsPage struct {
+	Sessions   []WorkSessionEntry `json:"sessions"`    // Sessions of the page, sorted by their start
+	Issues     []pairing.Issue    `json:"issues"`      // Records of the page that can't be paired properly, sorted by their timestamp
+	NextCursor string             `json:"next_cursor"` // Cursor of the next page, empty on the last page
+}
+
+// RegisterWorkClockSessionsAPI registers the sessions listing with the PocketBase server.
+// It creates the following route:
+// - GET /api/work_clock/sessions?from=&to=&cursor=&limit=&clock= - Lists the sessions starting within the
+// optional range page by page, supports conditional requests
+//
+// The first page is requested without cursor. Each page contains the cursor of the next page,
+// which is empty on the last page.
+//
+// Parameters:
+// - app: The PocketBase application instance
+func RegisterWorkClockSessionsAPI(app core.App) {
+	app.OnServe().BindFunc(func(se *core.ServeEvent) error {
+		se.Router.GET("/api/work_clock/sessions", func(e *core.RequestEvent) error {
+			query := e.Request.URL.Query()
+
+			from, to, err := parseOptionalTimeRangeParams(query.Get("from"), query.Get("to"))
+			if err != nil {
+				return e.Error(http.StatusBadRequest, err.Error(), nil)
+			}
+
+			var cursor time.Time
+			if cursorValue := query.Get("cursor"); cursorValue != "" {
+				cursor, err = parseTimeParam(cursorValue, "cursor")
+				if err != nil {
+					return e.Error(http.StatusBadRequest, err.Error(), nil)
+				}
+			}
+
+			limit := defaultSessionsPageSize
+			if limitValue := query.Get("limit"); limitValue != "" {
+				limit, err = strconv.Atoi(limitValue)
+				if err != nil || limit < 1 || limit > maxSessionsPageSize {
+					return e.Error(http.StatusBadRequest, fmt.Sprintf("Invalid 'limit' (integer) parameter. Expected a value between 1 and %d", maxSessionsPageSize), nil)
+				}
+			}
+
+			clockID, err := requestClock(app, e)
+			if err != nil {
+				return err
+			}
+
+			sessions, issues, nextCursor, err := findWorkSessionsPage(app, clockID, from, to, cursor, limit)
+			if err != nil {
+				return e.Error(http.StatusInternalServerError, fmt.Sprintf("Failed to find sessions: %v", err), err)
+			}
+
+			now := clockNow(app)
+			page := WorkSessionsPage{Sessions: make([]WorkSessionEntry, 0, len(sessions)), Issues: issues}
+			if page.Issues == nil {
+				page.Issues = []pairing.Issue{}
+			}
+			for _, session := range sessions {
+				page.Sessions = append(page.Sessions, newWorkSessionEntry(session, now))
+			}
+			if !nextCursor.IsZero() {
+				page.NextCursor = nextCursor.Format(time.RFC3339Nano)
+			}
+
+			return respondConditionalJSON(e, page, workClockLastModified(app))
+		})
+
+		return se.Next()
+	})
+}
+
+// newWorkSessionEntry creates the listing representation of a session.
+//
+// Parameters:
+// - session: The session
+// - now: The reference time used as end of an open session
+//
+// Returns:
+// - The session entry
+func newWorkSessionEntry(session workSession, now time.Time) WorkSessionEntry {
+	durationSeconds := int64(session.Duration(now).Seconds())
+
+	entry := WorkSessionEntry{
+		ClockInID:       session.ClockIn.Id,
+		ClockID:         session.ClockIn.GetString("clock"),
+		Start:           session.Start(),
+		DurationSeconds: durationSeconds,
+		Duration:        formatResponseDuration(durationSeconds),
+		Description:     session.ClockIn.GetString("description"),
+		ProjectID:       session.ClockIn.GetString("project"),
+		TagIDs:          session.ClockIn.GetStringSlice("tags"),
+		Issue:           session.ClockIn.GetString("issue"),
+		Category:        session.ClockIn.GetString("category"),
+	}
+
+	if session.ClockOut != nil {
+		end := session.End(now)
+		entry.ClockOutID = session.ClockOut.Id
+		entry.End = &end
+	}
+
+	return entry
+}
+
+// workSession is a pair of a clock in record and the clock out record following it.
+type workSession struct {
+	ClockIn  *core.Record // The clock in record starting the session
+	ClockOut *core.Record // The clock out record ending the session, nil if the session is still open
+}
+
+// Start returns the start of the session.
+func (s workSession) Start() time.Time {
+	return s.ClockIn.GetDateTime("timestamp").Time()
+}
+
+// End returns the end of the session. For open sessions, the given reference time is returned.
+//
+// Parameters:
+// - now: The reference time used as end of open sessions
+func (s workSession) End(now time.Time) time.Time {
+	if s.ClockOut == nil {
+		return now
+	}
+	return s.ClockOut.GetDateTime("timestamp").Time()
+}
+
+// Duration returns the length of the session. Open sessions last until the given reference time.
+//
+// Parameters:
+// - now: The reference time used as end of open sessions
+func (s workSession) Duration(now time.Time) time.Duration {
+	return s.End(now).Sub(s.Start())
+}
+
+// findWorkSessions finds all sessions of a clock starting within the given time range.
+//
+// Parameters:
+// - app: The App interface (typically a PocketBase instance or transaction)
+// - clockID: The ID of the clock, an empty string for the default clock
+// - from: The start of the range (inclusive), a zero value means unbounded
+// - to: The end of the range (exclusive), a zero value means unbounded
+//
+// Returns:
+// - The sessions sorted by their start
+// - An error if the database query fails
+//
+// A session starting within the range but ending after it is returned completely.
+// A leading clock out record belonging to a session that started before the range is ignored.
+func findWorkSessions(app core.App, clockID string, from, to time.Time) ([]workSession, error) {
+	conditions := []string{"clock = {:clock}"}
+	params := dbx.Params{"clock": clockParam(clockID)}
+
+	if !from.IsZero() {
+		conditions = append(conditions, "timestamp >= {:from}")
+		params["from"] = dateTimeParam(from)
+	}
+	if !to.IsZero() {
+		conditions = append(conditions, "timestamp < {:to}")
+		params["to"] = dateTimeParam(to)
+	}
+
+	records, err := app.FindRecordsByFilter("work_clock", strings.Join(conditions, " && "), "+timestamp", 0, 0, params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find work clock records: %w", err)
+	}
+
+	if len(records) > 0 && records[len(records)-1].GetBool("clock_in") && !to.IsZero() {
+		succeedingRecords, err := app.FindRecordsByFilter("work_clock", "clock = {:clock} && timestamp >= {:to}", "+timestamp", 1, 0, params)
+		if err != nil {
+			return nil, fmt.Errorf("failed to find succeeding work clock record: %w", err)
+		}
+		records = append(records, succeedingRecords...)
+	}
+
+	sessions, _ := pairWorkClockRecords(records)
+	return sessions, nil
+}
+
+// findWorkSessionsPage finds a page of the sessions of a clock starting within the given time range.
+//
+// Parameters:
+// - app: The App interface (typically a PocketBase instance or transaction)
+// - clockID: The ID of the clock, an empty string for the default clock
+// - from: The start of the range (inclusive), a zero value means unbounded
+// - to: The end of the range (exclusive), a zero value means unbounded
+// - cursor: The start of the last session of the previous page, a zero value requests the first page
+// - limit: The maximum number of sessions of the page
+//
+// Returns:
+// - The sessions of the page sorted by their start
+// - The records of the page that can't be paired properly
+// - The cursor of the next page, a zero value if this is the last page
+// - An error if the database query fails
+//
+// Only the clock in records of the page and the records up to the first clock in record of the
+// next page are loaded, so the memory usage is bounded by the page size. Clock out records before
+// the first session of the range are not checked, since they may end a session started before it.
+func findWorkSessionsPage(app core.App, clockID string, from, to, cursor time.Time, limit int) ([]workSession, []pairing.Issue, time.Time, error) {
+	conditions := []string{"clock = {:clock}", "clock_in = true"}
+	params := dbx.Params{"clock": clockParam(clockID)}
+
+	if !from.IsZero() {
+		conditions = append(conditions, "timestamp >= {:from}")
+		params["from"] = dateTimeParam(from)
+	}
+	if !to.IsZero() {
+		conditions = append(conditions, "timestamp < {:to}")
+		params["to"] = dateTimeParam(to)
+	}
+	if !cursor.IsZero() {
+		conditions = append(conditions, "timestamp > {:cursor}")
+		params["cursor"] = dateTimeParam(cursor)
+	}
+
+	clockInRecords, err := app.FindRecordsByFilter("work_clock", strings.Join(conditions, " && "), "+timestamp", limit+1, 0, params)
+	if err != nil {
+		return nil, nil, time.Time{}, fmt.Errorf("failed to find clock in records: %w", err)
+	}
+	if len(clockInRecords) == 0 {
+		return nil, nil, time.Time{}, nil
+	}
+
+	hasMore := len(clockInRecords) > limit
+	if hasMore {
+		clockInRecords = clockInRecords[:limit]
+	}
+
+	// Load the records from the first clock in of the page up to the last clock in of the page.
+	// Without a next page, the records up to the end of the range are loaded and the clock out of
+	// the last session is added separately.
+	recordConditions := []string{"clock = {:clock}", "timestamp >= {:start}"}
+	recordParams := dbx.Params{"clock": clockParam(clockID), "start": clockInRecords[0].GetDateTime("timestamp")}
+	if hasMore {
+		recordConditions = append(recordConditions, "timestamp <= {:end}")
+		recordParams["end"] = clockInRecords[len(clockInRecords)-1].GetDateTime("timestamp")
+	} else if !to.IsZero() {
+		recordConditions = append(recordConditions, "timestamp < {:to}")
+		recordParams["to"] = dateTimeParam(to)
+	}
+
+	records, err := app.FindRecordsByFilter("work_clock", strings.Join(recordConditions, " && "), "+timestamp", 0, 0, recordParams)
+	if err != nil {
+		return nil, nil, time.Time{}, fmt.Errorf("failed to find work clock records: %w", err)
+	}
+
+	if !hasMore && !to.IsZero() && len(records) > 0 && records[len(records)-1].GetBool("clock_in") {
+		succeedingRecords, err := app.FindRecordsByFilter("work_clock", "clock = {:clock} && timestamp >= {:to}", "+timestamp", 1, 0, recordParams)
+		if err != nil {
+			return nil, nil, time.Time{}, fmt.Errorf("failed to find succeeding work clock record: %w", err)
+		}
+		// A succeeding clock in starts a session outside of the range
+		if len(succeedingRecords) > 0 && !succeedingRecords[0].GetBool("clock_in") {
+			records = append(records, succeedingRecords[0])
+		}
+	}
+
+	sessions, issues := pairWorkClockRecords(records)
+
+	var nextCursor time.Time
+	if hasMore {
+		// The last session of the page is completed separately, since its clock out lies after the loaded records.
+		// The first clock in of the next page follows it, so a session without clock out overlaps it.
+		lastSession, err := findWorkSessionByClockIn(app, sessions[len(sessions)-1].ClockIn)
+		if err != nil {
+			return nil, nil, time.Time{}, err
+		}
+		sessions[len(sessions)-1] = lastSession
+		nextCursor = lastSession.Start()
+
+		if lastSession.ClockOut == nil {
+			issues = append(issues, pairing.Issue{
+				Code:      pairing.OverlappingSession,
+				RecordID:  lastSession.ClockIn.Id,
+				Timestamp: lastSession.Start(),
+			})
+		}
+	}
+
+	return sessions, issues, nextCursor, nil
+}
+
+// pairWorkClockRecords pairs records sorted by their timestamp into sessions.
+//
+// Parameters:
+// - records: The work clock records of a single clock sorted by their timestamp
+//
+// Returns:
+// - The sessions formed by the records
+// - The records that can't be paired properly, sorted by their timestamp
+//
+// The records are paired by pairing.PairRecords. Clock out records without a preceding clock in
+// record are ignored and a clock in record that is not followed by a clock out record forms an
+// open session.
+func pairWorkClockRecords(records []*core.Record) ([]workSession, []pairing.Issue) {
+	pairingRecords := make([]pairing.Record, len(records))
+	recordsByID := make(map[string]*core.Record, len(records))
+	for i, record := range records {
+		pairingRecords[i] = newPairingRecord(record)
+		recordsByID[record.Id] = record
+	}
+
+	pairs, issues := pairing.PairRecords(pairingRecords)
+
+	sessions := make([]workSession, 0, len(pairs))
+	for _, pair := range pairs {
+		session := workSession{ClockIn: recordsByID[pair.ClockIn.ID]}
+		if pair.ClockOut != nil {
+			session.ClockOut = recordsByID[pair.ClockOut.ID]
+		}
+		sessions = append(sessions, session)
+	}
+
+	return sessions, issues
+}
+
+// newPairingRecord converts a work clock record for the pairing package.
+//
+// Parameters:
+// - record: The work clock record
+//
+// Returns:
+// - The record as pairing.Record
+func newPairingRecord(record *core.Record) pairing.Record {
+	return pairing.Record{
+		ID:        record.Id,
+		Timestamp: record.GetDateTime("timestamp").Time(),
+		ClockIn:   record.GetBool("clock_in"),
+	}
+}
+
+// findWorkSessionByClockIn completes a session from its clock in record.
+//
+// Parameters:
+// - app: The App interface (typically a PocketBase instance or transaction)
+// - clockIn: The clock in record starting the session
+//
+// Returns:
+// - The session, which is open if no clock out record follows the clock in record
+// - An error if the database query fails
+func findWorkSessionByClockIn(app core.App, clockIn *core.Record) (workSession, error) {
+	session := workSession{ClockIn: clockIn}
+
+	succeedingRecords, err := app.FindRecordsByFilter("work_clock", "clock = {:clock} && timestamp > {:clockIn}", "+timestamp", 1, 0, dbx.Params{
+		"clock":   clockParam(clockIn.GetString("clock")),
+		"clockIn": clockIn.GetDateTime("timestamp"),
+	})
+	if err != nil {
+		return session, fmt.Errorf("failed to find succeeding work clock record: %w", err)
+	}
+
+	if len(succeedingRecords) > 0 && !succeedingRecords[0].GetBool("clock_in") {
+		session.ClockOut = succeedingRecords[0]
+	}
+
+	return session, nil
+}
+
+// findClockInRecord finds the clock in record starting a session.
+//
+// Parameters:
+// - app: The App interface (typically a PocketBase instance or transaction)
+// - clockID: The ID of the clock of the open session, only used if clockInID is empty
+// - clockInID: The ID of the clock in record, an empty string refers to the currently open session
+//
+// Returns:
+// - The clock in record
+// - An error if the record does not exist, is not a clock in record, or if there is no open session
+func findClockInRecord(app core.App, clockID string, clockInID string) (*core.Record, error) {
+	if clockInID == "" {
+		records, err := app.FindRecordsByFilter("work_clock", "clock = {:clock}", "-timestamp", 1, 0, dbx.Params{"clock": clockParam(clockID)})
+		if err != nil {
+			return nil, fmt.Errorf("failed to find latest work clock record: %w", err)
+		}
+		if len(records) == 0 || !records[0].GetBool("clock_in") {
+			return nil, fmt.Errorf("currently not clocked in")
+		}
+		return records[0], nil
+	}
+
+	record, err := app.FindRecordById("work_clock", clockInID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find work clock record with id '%s': %w", clockInID, err)
+	}
+	if !record.GetBool("clock_in") {
+		return nil, fmt.Errorf("record with id '%s' is not a clock in record", clockInID)
+	}
+
+	return record, nil
+}
+
+// findSessionClockIn finds the clock in record of the session a record belongs to.
+//
+// Parameters:
+// - app: The App interface (typically a PocketBase instance or transaction)
+// - record: A clock in or clock out record
+//
+// Returns:
+// - The clock in record itself or the clock in record preceding the clock out record,
+// nil if a clock out record has no preceding clock in record
+// - An error if the database query fails
+func findSessionClockIn(app core.App, record *core.Record) (*core.Record, error) {
+	if record.GetBool("clock_in") {
+		return record, nil
+	}
+
+	precedingRecords, err := app.FindRecordsByFilter("work_clock", "clock = {:clock} && timestamp < {:timestamp}", "-timestamp", 1, 0, dbx.Params{
+		"clock":     clockParam(record.GetString("clock")),
+		"timestamp": record.GetDateTime("timestamp"),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to find preceding work clock record: %w", err)
+	}
+
+	if len(precedingRecords) == 0 || !precedingRecords[0].GetBool("clock_in") {
+		return nil, nil
+	}
+
+	return precedingRecords[0], nil
+}
+
+// parseTimeRangeParams parses the 'from' and 'to' parameters of report endpoints.
+//
+// Parameters:
+// - fromValue: The value of the 'from' parameter (RFC3339)
+// - toValue: The value of the 'to' parameter (RFC3339)
+//
+// Returns:
+// - The start of the range (inclusive)
+// - The end of the range (exclusive)
+// - An error if a value is missing, invalid, or if the range is empty
+func parseTimeRangeParams(fromValue, toValue string) (time.Time, time.Time, error) {
+	from, err := parseTimeParam(fromValue, "from")
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+
+	to, err := parseTimeParam(toValue, "to")
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+
+	if !to.After(from) {
+		return time.Time{}, time.Time{}, fmt.Errorf("'to' must be after 'from'")
+	}
+
+	return from, to, nil
+}
+
+// parseOptionalTimeRangeParams parses the optional 'from' and 'to' parameters of listing endpoints.
+//
+// Parameters:
+// - fromValue: The value of the 'from' parameter (RFC3339), may be empty
+// - toValue: The value of the 'to' parameter (RFC3339), may be empty
+//
+// Returns:
+// - The start of the range (inclusive), a zero value if unbounded
+// - The end of the range (exclusive), a zero value if unbounded
+// - An error if a value is invalid, or if the range is empty
+func parseOptionalTimeRangeParams(fromValue, toValue string) (time.Time, time.Time, error) {
+	var from, to time.Time
+	var err error
+
+	if fromValue != "" {
+		if from, err = parseTimeParam(fromValue, "from"); err != nil {
+			return time.Time{}, time.Time{}, err
+		}
+	}
+	if toValue != "" {
+		if to, err = parseTimeParam(toValue, "to"); err != nil {
+			return time.Time{}, time.Time{}, err
+		}
+	}
+
+	if !from.IsZero() && !to.IsZero() && !to.After(from) {
+		return time.Time{}, time.Time{}, fmt.Errorf("'to' must be after 'from'")
+	}
+
+	return from, to, nil
+}
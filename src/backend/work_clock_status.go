@@ -0,0 +1,323 @@
+// Work Clock Status Module for PocketBase
+//
+// This module exposes the current state of the work clock in a single call, so clients do not
+// have to derive it from the raw work_clock records. Besides the plain clock state, it detects
+// stale sessions (open sessions that are older than a regular workday) and suggests a clock out
+// timestamp that the frontend can offer as a one-click correction via clock_in_out_at.
+//
+// It also allows describing what the user is currently working on, so the status contains
+// everything needed for a "currently working on X since 9:02" widget.
+//
+// Clients that can't use the realtime API, e.g. behind proxies buffering event streams, can long
+// poll the status: with 'wait', the request is held open until the status differs from the one
+// the client already has (its ETag in If-None-Match, otherwise the status at the time of the
+// request) or the wait elapses.
+package backend
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pocketbase/pocketbase/core"
+)
+
+// WorkClockStatus describes the current state of the work clock.
+type WorkClockStatus struct {
+	ClockedIn         bool       `json:"clocked_in"`          // Whether a session is currently open
+	Since             *time.Time `json:"since"`               // Timestamp of the latest work clock record
+	ClockInID         string     `json:"clock_in_id"`         // ID of the clock in record of the open session
+	Description       string     `json:"description"`         // Description of the open session
+	DurationSeconds   int64      `json:"duration_seconds"`    // Duration of the open session so far
+	Duration          string     `json:"duration"`            // Duration formatted in the configured duration format
+	Stale             bool       `json:"stale"`               // Whether the open session is longer than a workday
+	SuggestedClockOut *time.Time `json:"suggested_clock_out"` // Suggested end of a stale session
+}
+
+// statusMaxWait is the maximum duration a long polling status request is held open.
+const statusMaxWait = 60 * time.Second
+
+// statusWaitMargin is the time left before the timeout of a request when a long polling status
+// request responds at the latest, so the response is sent before the request times out.
+const statusWaitMargin = time.Second
+
+// workClockChanges notifies the long polling status requests about changed work clock records.
+var workClockChanges = newChangeNotifier()
+
+// changeNotifier notifies any number of waiting goroutines about a change.
+type changeNotifier struct {
+	mutex   sync.Mutex
+	changed chan struct{} // Closed on the next change
+}
+
+// newChangeNotifier creates a notifier.
+//
+// Returns:
+// - The notifier
+func newChangeNotifier() *changeNotifier {
+	return &changeNotifier{changed: make(chan struct{})}
+}
+
+// next returns a channel that is closed on the next change.
+//
+// Returns:
+// - The channel
+func (n *changeNotifier) next() <-chan struct{} {
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+
+	return n.changed
+}
+
+// notify wakes up everyone waiting for the next change.
+func (n *changeNotifier) notify() {
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+
+	close(n.changed)
+	n.changed = make(chan struct{})
+}
+
+// RegisterWorkClockStatusAPI registers the work clock status endpoint with the PocketBase server.
+// It creates the following routes:
+// - GET /api/work_clock/status - Returns the current WorkClockStatus, supports conditional requests
+// and long polling with 'wait' (e.g. "30s", at most one minute)
+// - POST /api/work_clock/description - Sets the 'description' of a session, by default of the open session
+//
+// Parameters:
+// - app: The PocketBase application instance
+func RegisterWorkClockStatusAPI(app core.App) {
+	notify := func(e *core.RecordEvent) error {
+		workClockChanges.notify()
+		return e.Next()
+	}
+	app.OnRecordAfterCreateSuccess("work_clock").BindFunc(notify)
+	app.OnRecordAfterUpdateSuccess("work_clock").BindFunc(notify)
+	app.OnRecordAfterDeleteSuccess("work_clock").BindFunc(notify)
+
+	app.OnServe().BindFunc(func(se *core.ServeEvent) error {
+		se.Router.GET("/api/work_clock/status", func(e *core.RequestEvent) error {
+			clockID, err := requestClock(app, e)
+			if err != nil {
+				return err
+			}
+
+			wait, err := parseWaitParam(e.Request.URL.Query().Get("wait"))
+			if err != nil {
+				return e.Error(http.StatusBadRequest, err.Error(), nil)
+			}
+
+			status, etag, err := waitForWorkClockStatus(e.Request.Context(), app, clockID, e.Request.Header.Get("If-None-Match"), wait)
+			if err != nil {
+				return e.Error(http.StatusInternalServerError, fmt.Sprintf("Failed to get work clock status: %v", err), err)
+			}
+			if checkNotModified(e, etag, workClockLastModified(app)) {
+				return e.NoContent(http.StatusNotModified)
+			}
+
+			return e.JSON(http.StatusOK, status)
+		})
+
+		se.Router.POST("/api/work_clock/description", func(e *core.RequestEvent) error {
+			// An empty clock in ID refers to the currently open session
+			clockInID := e.Request.FormValue("clock_in_id")
+			description := strings.TrimSpace(e.Request.FormValue("description"))
+
+			clockID, err := requestClock(app, e)
+			if err != nil {
+				return err
+			}
+
+			if err := setSessionDescription(e.Request.Context(), app, clockID, clockInID, description); err != nil {
+				return e.Error(http.StatusInternalServerError, fmt.Sprintf("Failed to set description of session: %v", err), err)
+			}
+			return callSucceeded(e)
+		})
+
+		return se.Next()
+	})
+}
+
+// parseWaitParam parses the duration a long polling request waits for a change.
+//
+// Parameters:
+// - value: The value of the parameter, a duration like "30s" or a number of seconds
+//
+// Returns:
+// - The duration, 0 if the parameter is unset, at most statusMaxWait
+// - An error if the value is neither a duration nor a number of seconds, or negative
+func parseWaitParam(value string) (time.Duration, error) {
+	if value == "" {
+		return 0, nil
+	}
+
+	wait, err := time.ParseDuration(value)
+	if seconds, secondsErr := strconv.Atoi(value); secondsErr == nil {
+		wait, err = time.Duration(seconds)*time.Second, nil
+	}
+	if err != nil || wait < 0 {
+		return 0, fmt.Errorf("invalid 'wait' parameter '%s'. Expected a duration like 30s", value)
+	}
+
+	return min(wait, statusMaxWait), nil
+}
+
+// waitForWorkClockStatus returns the status of a clock once it differs from the status the client
+// already has, or the current status once the wait elapsed.
+//
+// Parameters:
+// - ctx: The context of the request, the status is returned right away once it is done
+// - app: The App interface (typically a PocketBase instance or transaction)
+// - clockID: The ID of the clock, an empty string for the default clock
+// - ifNoneMatch: The ETags of the statuses the client has, an empty string for the status at the time of the call
+// - wait: The maximum duration to wait for a change, 0 to return the current status right away
+//
+// Returns:
+// - The status
+// - The ETag of the status, see workClockStatusETag
+// - An error if the status could not be determined
+func waitForWorkClockStatus(ctx context.Context, app core.App, clockID string, ifNoneMatch string, wait time.Duration) (*WorkClockStatus, string, error) {
+	deadline := time.Now().Add(wait)
+	if requestDeadline, ok := ctx.Deadline(); ok && requestDeadline.Add(-statusWaitMargin).Before(deadline) {
+		deadline = requestDeadline.Add(-statusWaitMargin)
+	}
+
+	for {
+		// The channel is taken before the status is read, so a change in between isn't missed
+		changed := workClockChanges.next()
+
+		now := clockNow(app)
+		status, err := getWorkClockStatus(app, clockID, now)
+		if err != nil {
+			return nil, "", err
+		}
+		etag, err := workClockStatusETag(status)
+		if err != nil {
+			return nil, "", err
+		}
+
+		if ifNoneMatch == "" {
+			ifNoneMatch = etag
+		}
+		timeout := time.Until(deadline)
+		if !matchesETag(ifNoneMatch, etag) || timeout <= 0 {
+			return status, etag, nil
+		}
+
+		// An open session becomes stale without a change of the records
+		if workdayDuration := workClockServiceOf(app).Settings().WorkdayDuration; status.ClockedIn && !status.Stale && workdayDuration > 0 {
+			timeout = min(timeout, status.Since.Add(workdayDuration).Sub(now)+time.Second)
+		}
+
+		timer := time.NewTimer(timeout)
+		select {
+		case <-changed:
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return status, etag, nil
+		}
+		timer.Stop()
+	}
+}
+
+// workClockStatusETag calculates the ETag of a status. The running duration is excluded, so
+// polling clients only receive a new status if the clock state changes. They calculate the
+// running duration from 'since'.
+//
+// Parameters:
+// - status: The status
+//
+// Returns:
+// - The quoted ETag
+// - An error if the status could not be serialized
+func workClockStatusETag(status *WorkClockStatus) (string, error) {
+	stableStatus := *status
+	stableStatus.DurationSeconds = 0
+	stableStatus.Duration = ""
+	return jsonETag(stableStatus)
+}
+
+// getWorkClockStatus determines the current state of a clock.
+//
+// Parameters:
+// - app: The App interface (typically a PocketBase instance or transaction)
+// - clockID: The ID of the clock, an empty string for the default clock
+// - now: The reference time used to calculate durations
+//
+// Returns:
+// - The current work clock status
+// - An error if the latest work clock record could not be retrieved
+//
+// An open session is considered stale if it is longer than the configured workday duration.
+// For stale sessions, the scheduled end of the session (clock in + workday duration) is
+// suggested as clock out timestamp.
+func getWorkClockStatus(app core.App, clockID string, now time.Time) (*WorkClockStatus, error) {
+	service := workClockServiceOf(app)
+	record, err := service.LatestRecord(clockID)
+	if err != nil {
+		return nil, err
+	}
+
+	status := &WorkClockStatus{}
+	if record == nil {
+		return status, nil
+	}
+
+	since := record.GetDateTime("timestamp").Time()
+	status.Since = &since
+
+	if !record.GetBool("clock_in") {
+		return status, nil
+	}
+
+	duration := now.Sub(since)
+	status.ClockedIn = true
+	status.ClockInID = record.Id
+	status.Description = record.GetString("description")
+	status.DurationSeconds = int64(duration.Seconds())
+	status.Duration = formatResponseDuration(status.DurationSeconds)
+
+	if workdayDuration := service.Settings().WorkdayDuration; workdayDuration > 0 && duration > workdayDuration {
+		suggestedClockOut := since.Add(workdayDuration)
+		status.Stale = true
+		status.SuggestedClockOut = &suggestedClockOut
+	}
+
+	return status, nil
+}
+
+// setSessionDescription sets the description of the session started by a clock in record.
+//
+// Parameters:
+// - ctx: The context cancelling the operation, e.g. of the request
+// - app: The App interface (typically a PocketBase instance or transaction)
+// - clockID: The ID of the clock of the open session, only used if clockInID is empty
+// - clockInID: The ID of the clock in record starting the session, an empty string refers to the open session
+// - description: The new description, an empty string removes the description
+//
+// Returns:
+// - An error if the record is not a clock in record, there is no open session, or saving fails
+func setSessionDescription(ctx context.Context, app core.App, clockID string, clockInID string, description string) error {
+	unlock, err := workClockServiceOf(app).WithContext(ctx).lockSession(clockID, clockInID)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	record, err := findClockInRecord(app, clockID, clockInID)
+	if err != nil {
+		return err
+	}
+
+	record.Set("description", description)
+	if err := app.Save(record); err != nil {
+		return fmt.Errorf("failed to save work clock record: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,72 @@
+package backend
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/yerTools/simple-frontend-stack/src/backend/backendtest"
+)
+
+func TestStatusLongPolling(t *testing.T) {
+	app := backendtest.NewApp(t)
+	RegisterWorkClockStatusAPI(app)
+	handler := backendtest.NewHandler(t, app)
+
+	get := func(path string, etag string) *httptest.ResponseRecorder {
+		request := httptest.NewRequest(http.MethodGet, path, nil)
+		if etag != "" {
+			request.Header.Set("If-None-Match", etag)
+		}
+
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, request)
+		return recorder
+	}
+
+	recorder := get("/api/work_clock/status", "")
+	etag := recorder.Header().Get("ETag")
+	if recorder.Code != http.StatusOK || etag == "" {
+		t.Fatalf("expected status 200 with an ETag, got %d: %s", recorder.Code, recorder.Body.String())
+	}
+
+	// Without a change, the request is answered once the wait elapsed
+	started := time.Now()
+	if recorder := get("/api/work_clock/status?wait=200ms", etag); recorder.Code != http.StatusNotModified {
+		t.Fatalf("expected status 304 after the wait, got %d: %s", recorder.Code, recorder.Body.String())
+	}
+	if elapsed := time.Since(started); elapsed < 200*time.Millisecond {
+		t.Errorf("expected the request to wait 200ms, answered after %s", elapsed)
+	}
+
+	// A change answers the waiting request right away
+	done := make(chan *httptest.ResponseRecorder, 1)
+	go func() { done <- get("/api/work_clock/status?wait=30", etag) }()
+
+	time.Sleep(50 * time.Millisecond)
+	if err := workClockServiceOf(app).ClockInOut("", true, false); err != nil {
+		t.Fatalf("failed to clock in: %v", err)
+	}
+
+	select {
+	case recorder := <-done:
+		if recorder.Code != http.StatusOK {
+			t.Fatalf("expected status 200 after the change, got %d: %s", recorder.Code, recorder.Body.String())
+		}
+		var status WorkClockStatus
+		if err := json.Unmarshal(recorder.Body.Bytes(), &status); err != nil {
+			t.Fatalf("failed to decode status: %v", err)
+		}
+		if !status.ClockedIn {
+			t.Errorf("expected the changed status to be clocked in, got %+v", status)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("expected the waiting request to be answered after clocking in")
+	}
+
+	if recorder := get("/api/work_clock/status?wait=soon", ""); recorder.Code != http.StatusBadRequest {
+		t.Errorf("expected an invalid wait to be rejected with 400, got %d", recorder.Code)
+	}
+}
@@ -0,0 +1,229 @@
+// Work Clock Templates Module for PocketBase
+//
+// This module allows applying a typical week, stored in the work_clock_templates collection,
+// to a past week without any recorded data. All sessions of the template are generated as
+// clock in/out pairs and validated within a single transaction, so a week is either filled
+// completely or not at all.
+//
+// Templates are managed through the regular PocketBase collection API. Each template holds a
+// list of recurring sessions, for example:
+//
+//	[
+//	  {"weekdays": [1, 2, 3, 4, 5], "start": "09:00", "end": "12:00"},
+//	  {"weekdays": [1, 2, 3, 4, 5], "start": "13:00", "end": "17:00"}
+//	]
+//
+// Weekdays are numbered from 1 (Monday) to 7 (Sunday).
+package backend
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/core"
+)
+
+// workClockTemplateSession is a recurring session of a work clock template.
+type workClockTemplateSession struct {
+	Weekdays []int  `json:"weekdays"` // Days of the week from 1 (Monday) to 7 (Sunday)
+	Start    string `json:"start"`    // Start time of the session in the format HH:MM
+	End      string `json:"end"`      // End time of the session in the format HH:MM
+}
+
+// weekNotEmptyError is returned by applying a template to a week that already contains records.
+type weekNotEmptyError struct {
+	WeekStart time.Time // Start of the week
+}
+
+// Error implements the error interface.
+func (err *weekNotEmptyError) Error() string {
+	return fmt.Sprintf("the week of %s already contains work clock records", err.WeekStart.Format(time.DateOnly))
+}
+
+// RegisterWorkClockTemplatesAPI registers the work clock template endpoints with the PocketBase server.
+// It creates the following route:
+// - POST /api/work_clock/templates/apply - Applies a template to a past week without any records
+//
+// The apply endpoint accepts the form values 'template_id', 'week' (any date within the week
+// in the format YYYY-MM-DD), an optional 'timezone' (IANA name, defaults to the server timezone)
+// and an optional 'clock' (see requestClock).
+//
+// Parameters:
+// - app: The PocketBase application instance
+func RegisterWorkClockTemplatesAPI(app core.App) {
+	app.OnServe().BindFunc(func(se *core.ServeEvent) error {
+		se.Router.POST("/api/work_clock/templates/apply", func(e *core.RequestEvent) error {
+			templateID := e.Request.FormValue("template_id")
+			if templateID == "" {
+				return e.Error(http.StatusBadRequest, "Missing 'template_id' (string) parameter", nil)
+			}
+
+			weekStart, weekEnd, err := parseWeekRange(e.Request.FormValue("week"), e.Request.FormValue("timezone"))
+			if err != nil {
+				return e.Error(http.StatusBadRequest, err.Error(), nil)
+			}
+
+			if weekEnd.After(clockNow(app)) {
+				return e.Error(http.StatusBadRequest, "Templates can only be applied to weeks that are already over", nil)
+			}
+
+			clockID, err := requestClock(app, e)
+			if err != nil {
+				return err
+			}
+
+			template, err := app.FindRecordById("work_clock_templates", templateID)
+			if err != nil {
+				return e.Error(http.StatusNotFound, fmt.Sprintf("Template with id '%s' not found", templateID), err)
+			}
+
+			var templateSessions []workClockTemplateSession
+			if err := template.UnmarshalJSONField("sessions", &templateSessions); err != nil {
+				return e.Error(http.StatusBadRequest, fmt.Sprintf("Template with id '%s' has invalid sessions", templateID), err)
+			}
+
+			sessions, err := expandTemplateSessions(templateSessions, weekStart)
+			if err != nil {
+				return e.Error(http.StatusBadRequest, fmt.Sprintf("Template with id '%s' is invalid: %v", templateID, err), nil)
+			}
+
+			if err := applyWorkClockTemplate(e.Request.Context(), app, clockID, weekStart, weekEnd, sessions); err != nil {
+				var notEmptyErr *weekNotEmptyError
+				if errors.As(err, &notEmptyErr) {
+					return e.Error(http.StatusConflict, fmt.Sprintf("Failed to apply template: %v", notEmptyErr), nil)
+				}
+				return e.Error(http.StatusInternalServerError, fmt.Sprintf("Failed to apply template: %v", err), err)
+			}
+			return callSucceeded(e)
+		})
+
+		return se.Next()
+	})
+}
+
+// parseWeekRange determines the boundaries of the week containing the given date.
+// Weeks start on Monday.
+//
+// Parameters:
+// - date: Any date within the week in the format YYYY-MM-DD
+// - timezone: An optional IANA timezone name, the server timezone is used if empty
+//
+// Returns:
+// - The start of the week (inclusive)
+// - The start of the following week (exclusive)
+// - An error if the date or timezone is invalid
+func parseWeekRange(date string, timezone string) (time.Time, time.Time, error) {
+	dayStart, _, err := parseDayRange(date, timezone)
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+
+	daysSinceMonday := (int(dayStart.Weekday()) + 6) % 7
+	weekStart := dayStart.AddDate(0, 0, -daysSinceMonday)
+
+	return weekStart, weekStart.AddDate(0, 0, 7), nil
+}
+
+// expandTemplateSessions generates the concrete sessions of a template for a specific week.
+//
+// Parameters:
+// - templateSessions: The recurring sessions of the template
+// - weekStart: The start of the week (Monday, 00:00)
+//
+// Returns:
+// - The generated sessions sorted by their clock in timestamp
+// - An error if a session is malformed or if sessions overlap
+func expandTemplateSessions(templateSessions []workClockTemplateSession, weekStart time.Time) ([]clockInOutPair, error) {
+	var sessions []clockInOutPair
+
+	for i, templateSession := range templateSessions {
+		start, err := time.Parse("15:04", templateSession.Start)
+		if err != nil {
+			return nil, fmt.Errorf("session %d has an invalid start time '%s'. Expected HH:MM", i, templateSession.Start)
+		}
+
+		end, err := time.Parse("15:04", templateSession.End)
+		if err != nil {
+			return nil, fmt.Errorf("session %d has an invalid end time '%s'. Expected HH:MM", i, templateSession.End)
+		}
+
+		if !end.After(start) {
+			return nil, fmt.Errorf("session %d must end after it starts", i)
+		}
+
+		for _, weekday := range templateSession.Weekdays {
+			if weekday < 1 || weekday > 7 {
+				return nil, fmt.Errorf("session %d has an invalid weekday %d. Expected 1 (Monday) to 7 (Sunday)", i, weekday)
+			}
+
+			day := weekStart.AddDate(0, 0, weekday-1)
+			sessions = append(sessions, clockInOutPair{
+				ClockIn:  time.Date(day.Year(), day.Month(), day.Day(), start.Hour(), start.Minute(), 0, 0, day.Location()),
+				ClockOut: time.Date(day.Year(), day.Month(), day.Day(), end.Hour(), end.Minute(), 0, 0, day.Location()),
+			})
+		}
+	}
+
+	sort.Slice(sessions, func(a, b int) bool {
+		return sessions[a].ClockIn.Before(sessions[b].ClockIn)
+	})
+
+	for i := 1; i < len(sessions); i++ {
+		if !sessions[i].ClockIn.After(sessions[i-1].ClockOut) {
+			return nil, fmt.Errorf("sessions on %s overlap or touch each other", sessions[i].ClockIn.Format(time.DateOnly))
+		}
+	}
+
+	return sessions, nil
+}
+
+// applyWorkClockTemplate creates the generated sessions of a template within a week of a clock.
+//
+// Parameters:
+// - ctx: The context cancelling the operation, e.g. of the request
+// - app: The App interface (typically a PocketBase instance or transaction)
+// - clockID: The ID of the clock, an empty string for the default clock
+// - weekStart: The start of the week (inclusive)
+// - weekEnd: The start of the following week (exclusive)
+// - sessions: The generated sessions, sorted and free of overlaps
+//
+// Returns:
+// - A *weekNotEmptyError if the week already contains records, or an error if the operation
+// fails or the resulting records violate sequence constraints
+//
+// The operation is performed within a single transaction.
+func applyWorkClockTemplate(ctx context.Context, app core.App, clockID string, weekStart, weekEnd time.Time, sessions []clockInOutPair) error {
+	unlock, err := workClockServiceOf(app).WithContext(ctx).lock(clockID)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	err = app.RunInTransaction(func(txApp core.App) error {
+		existingRecords, err := txApp.FindRecordsByFilter("work_clock", "clock = {:clock} && timestamp >= {:start} && timestamp < {:end}", "", 1, 0, dbx.Params{
+			"clock": clockParam(clockID),
+			"start": dateTimeParam(weekStart),
+			"end":   dateTimeParam(weekEnd),
+		})
+		if err != nil {
+			return fmt.Errorf("failed to find existing work clock records: %w", err)
+		}
+
+		if len(existingRecords) > 0 {
+			return &weekNotEmptyError{WeekStart: weekStart}
+		}
+
+		return replaceWorkClockRange(txApp, clockID, weekStart, weekEnd, sessions)
+	})
+
+	if err != nil {
+		return fmt.Errorf("failed to apply template to the week of %s: %w", weekStart.Format(time.DateOnly), err)
+	}
+
+	return nil
+}
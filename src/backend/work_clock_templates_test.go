@@ -0,0 +1,54 @@
+package backend
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/yerTools/simple-frontend-stack/src/backend/backendtest"
+)
+
+func TestApplyWorkClockTemplate(t *testing.T) {
+	app := backendtest.NewApp(t)
+	RegisterWorkClockTemplatesAPI(app)
+	handler := backendtest.NewHandler(t, app)
+
+	collection, err := app.FindCollectionByNameOrId("work_clock_templates")
+	if err != nil {
+		t.Fatalf("failed to find work clock templates collection: %v", err)
+	}
+	template := core.NewRecord(collection)
+	template.Set("name", "Mornings")
+	template.Set("sessions", `[{"weekdays": [1, 2], "start": "09:00", "end": "12:00"}]`)
+	if err := app.Save(template); err != nil {
+		t.Fatalf("failed to save template: %v", err)
+	}
+
+	apply := func(week string) *httptest.ResponseRecorder {
+		form := url.Values{"template_id": {template.Id}, "week": {week}, "timezone": {"UTC"}}
+		request := httptest.NewRequest(http.MethodPost, "/api/work_clock/templates/apply", strings.NewReader(form.Encode()))
+		request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, request)
+		return recorder
+	}
+
+	if recorder := apply("2025-03-12"); recorder.Code != http.StatusOK {
+		t.Fatalf("expected the template to be applied to an empty week, got %d: %s", recorder.Code, recorder.Body.String())
+	}
+	if records := backendtest.Records(t, app); len(records) != 4 {
+		t.Fatalf("expected two sessions, got %d records", len(records))
+	}
+
+	// A week with records is a conflict of the client, not a server error
+	recorder := apply("2025-03-13")
+	if recorder.Code != http.StatusConflict || !strings.Contains(recorder.Body.String(), "already contains work clock records") {
+		t.Errorf("expected status 409 for a week with records, got %d: %s", recorder.Code, recorder.Body.String())
+	}
+	if records := backendtest.Records(t, app); len(records) != 4 {
+		t.Errorf("expected the records to be unchanged, got %d records", len(records))
+	}
+}
@@ -0,0 +1,484 @@
+package backend
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/yerTools/simple-frontend-stack/src/backend/backendtest"
+)
+
+func TestCheckValidity(t *testing.T) {
+	testCases := []struct {
+		name    string
+		records []backendtest.Record
+		check   int
+		wantErr bool
+	}{
+		{
+			name: "alternating records",
+			records: []backendtest.Record{
+				backendtest.ClockIn("2025-04-01T09:00:00Z"),
+				backendtest.ClockOut("2025-04-01T12:00:00Z"),
+				backendtest.ClockIn("2025-04-01T13:00:00Z"),
+			},
+			check: 1,
+		},
+		{
+			name: "open session",
+			records: []backendtest.Record{
+				backendtest.ClockIn("2025-04-01T09:00:00Z"),
+			},
+			check: 0,
+		},
+		{
+			name: "two clock ins in a row",
+			records: []backendtest.Record{
+				backendtest.ClockIn("2025-04-01T09:00:00Z"),
+				backendtest.ClockIn("2025-04-01T12:00:00Z"),
+			},
+			check:   1,
+			wantErr: true,
+		},
+		{
+			name: "two clock outs in a row",
+			records: []backendtest.Record{
+				backendtest.ClockIn("2025-04-01T09:00:00Z"),
+				backendtest.ClockOut("2025-04-01T12:00:00Z"),
+				backendtest.ClockOut("2025-04-01T13:00:00Z"),
+			},
+			check:   1,
+			wantErr: true,
+		},
+		{
+			name: "first record is a clock out",
+			records: []backendtest.Record{
+				backendtest.ClockOut("2025-04-01T09:00:00Z"),
+				backendtest.ClockIn("2025-04-01T12:00:00Z"),
+			},
+			check:   0,
+			wantErr: true,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			app := backendtest.NewApp(t)
+			records := backendtest.AddRecords(t, app, testCase.records...)
+
+			err := checkValidity(app, records[testCase.check].Id)
+			if testCase.wantErr && err == nil {
+				t.Fatal("expected an error, got none")
+			}
+			if !testCase.wantErr && err != nil {
+				t.Fatalf("expected no error, got: %v", err)
+			}
+		})
+	}
+}
+
+func TestClockInOut(t *testing.T) {
+	app := backendtest.NewApp(t)
+
+	if err := workClockServiceOf(app).ClockInOut("", false, false); err == nil {
+		t.Fatal("expected clocking out without open session to fail")
+	}
+
+	if err := workClockServiceOf(app).ClockInOut("", true, false); err != nil {
+		t.Fatalf("failed to clock in: %v", err)
+	}
+
+	if err := workClockServiceOf(app).ClockInOut("", true, false); err == nil {
+		t.Fatal("expected clocking in twice to fail")
+	}
+
+	clockedIn, err := workClockServiceOf(app).IsClockedIn("")
+	if err != nil {
+		t.Fatalf("failed to check clock status: %v", err)
+	}
+	if !clockedIn {
+		t.Fatal("expected to be clocked in")
+	}
+
+	// Timestamps are stored with millisecond precision and must be unique
+	time.Sleep(5 * time.Millisecond)
+
+	if err := workClockServiceOf(app).ClockInOut("", false, false); err != nil {
+		t.Fatalf("failed to clock out: %v", err)
+	}
+
+	if records := backendtest.Records(t, app); len(records) != 2 {
+		t.Fatalf("expected 2 records, got %d", len(records))
+	}
+	backendtest.AssertAlternating(t, app)
+}
+
+func TestClockInOutRejectsLongSession(t *testing.T) {
+	originalSettings := settings
+	t.Cleanup(func() { settings = originalSettings })
+	settings.MaxSessionDuration = 16 * time.Hour
+
+	app := backendtest.NewApp(t)
+	backendtest.AddRecords(t, app, backendtest.Record{Timestamp: time.Now().Add(-20 * time.Hour), ClockIn: true})
+
+	err := workClockServiceOf(app).ClockInOut("", false, false)
+	var tooLongErr *sessionTooLongError
+	if !errors.As(err, &tooLongErr) {
+		t.Fatalf("expected a sessionTooLongError, got: %v", err)
+	}
+
+	if err := workClockServiceOf(app).ClockInOut("", false, true); err != nil {
+		t.Fatalf("failed to clock out with confirmation: %v", err)
+	}
+	backendtest.AssertAlternating(t, app)
+}
+
+func TestClocksAreIndependent(t *testing.T) {
+	app := backendtest.NewApp(t)
+
+	collection, err := app.FindCollectionByNameOrId("clocks")
+	if err != nil {
+		t.Fatalf("failed to find clocks collection: %v", err)
+	}
+	clock := core.NewRecord(collection)
+	clock.Set("name", "side-project")
+	if err := app.Save(clock); err != nil {
+		t.Fatalf("failed to create clock: %v", err)
+	}
+
+	clockID, err := findClockID(app, "side-project")
+	if err != nil || clockID != clock.Id {
+		t.Fatalf("expected clock id %q, got %q (error: %v)", clock.Id, clockID, err)
+	}
+	if _, err := findClockID(app, "unknown"); err == nil {
+		t.Fatal("expected an unknown clock to be rejected")
+	}
+
+	if err := workClockServiceOf(app).ClockInOut("", true, false); err != nil {
+		t.Fatalf("failed to clock in the default clock: %v", err)
+	}
+	if err := workClockServiceOf(app).ClockInOut(clockID, true, false); err != nil {
+		t.Fatalf("failed to clock in the second clock while the default clock is clocked in: %v", err)
+	}
+
+	time.Sleep(5 * time.Millisecond)
+
+	if err := workClockServiceOf(app).ClockInOut("", false, false); err != nil {
+		t.Fatalf("failed to clock out the default clock: %v", err)
+	}
+
+	clockedIn, err := workClockServiceOf(app).IsClockedIn(clockID)
+	if err != nil {
+		t.Fatalf("failed to check clock status: %v", err)
+	}
+	if !clockedIn {
+		t.Fatal("expected the second clock to stay clocked in")
+	}
+
+	sessions, err := findWorkSessions(app, clockID, time.Time{}, time.Time{})
+	if err != nil {
+		t.Fatalf("failed to find sessions: %v", err)
+	}
+	if len(sessions) != 1 || sessions[0].ClockOut != nil {
+		t.Fatalf("expected a single open session on the second clock, got %d sessions", len(sessions))
+	}
+}
+
+func TestAddClockInOutPair(t *testing.T) {
+	app := backendtest.NewApp(t)
+	backendtest.AddRecords(t, app,
+		backendtest.ClockIn("2025-04-01T09:00:00Z"),
+		backendtest.ClockOut("2025-04-01T17:00:00Z"),
+	)
+
+	if err := workClockServiceOf(app).AddClockInOutPair("", backendtest.MustParseTime("2025-04-02T09:00:00Z"), backendtest.MustParseTime("2025-04-02T17:00:00Z")); err != nil {
+		t.Fatalf("failed to add clock in/out pair: %v", err)
+	}
+
+	// An overlapping pair must be rejected and rolled back completely
+	if err := workClockServiceOf(app).AddClockInOutPair("", backendtest.MustParseTime("2025-04-01T12:00:00Z"), backendtest.MustParseTime("2025-04-01T13:00:00Z")); err == nil {
+		t.Fatal("expected an overlapping pair to be rejected")
+	}
+
+	if records := backendtest.Records(t, app); len(records) != 4 {
+		t.Fatalf("expected 4 records, got %d", len(records))
+	}
+	backendtest.AssertAlternating(t, app)
+}
+
+func TestModifyWorkClockTimestampValidatesFormerNeighbors(t *testing.T) {
+	app := backendtest.NewApp(t)
+	records := backendtest.AddRecords(t, app,
+		backendtest.ClockIn("2025-04-01T09:00:00Z"),
+		backendtest.ClockOut("2025-04-01T12:00:00Z"),
+		backendtest.ClockIn("2025-04-01T13:00:00Z"),
+		backendtest.ClockOut("2025-04-01T17:00:00Z"),
+	)
+
+	// Moving the first clock in behind all other records is valid on its own,
+	// but leaves a clock out as the first record
+	if err := workClockServiceOf(app).ModifyTimestamp(records[0].Id, backendtest.MustParseTime("2025-04-01T18:00:00Z"), time.Time{}); err == nil {
+		t.Fatal("expected moving a record past its neighbors to be rejected")
+	}
+
+	backendtest.AssertAlternating(t, app)
+}
+
+func TestSessionsPairingIssues(t *testing.T) {
+	app := backendtest.NewApp(t)
+	backendtest.AddRecords(t, app,
+		backendtest.ClockIn("2025-04-01T09:00:00Z"),
+		backendtest.ClockOut("2025-04-01T12:00:00Z"),
+		backendtest.ClockOut("2025-04-01T12:30:00Z"),
+		backendtest.ClockIn("2025-04-02T09:00:00Z"),
+		backendtest.ClockIn("2025-04-03T09:00:00Z"),
+		backendtest.ClockOut("2025-04-03T17:00:00Z"),
+		backendtest.ClockIn("2025-04-04T09:00:00Z"),
+	)
+
+	// The second clock in of the first page is only followed by the first clock in of the next page
+	sessions, issues, cursor, err := findWorkSessionsPage(app, "", time.Time{}, time.Time{}, time.Time{}, 2)
+	if err != nil {
+		t.Fatalf("failed to find sessions: %v", err)
+	}
+	if len(sessions) != 2 || cursor.IsZero() {
+		t.Fatalf("expected 2 sessions and a next page, got %d sessions and cursor %v", len(sessions), cursor)
+	}
+	expected := []struct {
+		code      string
+		timestamp string
+	}{
+		{"orphan_clock_out", "2025-04-01T12:30:00Z"},
+		{"overlapping_session", "2025-04-02T09:00:00Z"},
+	}
+	if len(issues) != len(expected) {
+		t.Fatalf("expected %d issues, got %v", len(expected), issues)
+	}
+	for i, issue := range issues {
+		if issue.Code != expected[i].code || !issue.Timestamp.Equal(backendtest.MustParseTime(expected[i].timestamp)) {
+			t.Errorf("expected issue %d to be %s at %s, got %s at %v", i, expected[i].code, expected[i].timestamp, issue.Code, issue.Timestamp)
+		}
+	}
+
+	// The open session at the end is not an issue
+	sessions, issues, cursor, err = findWorkSessionsPage(app, "", time.Time{}, time.Time{}, cursor, 2)
+	if err != nil {
+		t.Fatalf("failed to find sessions: %v", err)
+	}
+	if len(sessions) != 2 || !cursor.IsZero() || len(issues) != 0 {
+		t.Errorf("expected 2 sessions on the last page without issues, got %d sessions and issues %v", len(sessions), issues)
+	}
+}
+
+func TestClockInOutWithinTransaction(t *testing.T) {
+	app := backendtest.NewApp(t)
+
+	errRollback := errors.New("rollback")
+	err := app.RunInTransaction(func(txApp core.App) error {
+		if err := workClockServiceOf(txApp).ClockInOut("", true, false); err != nil {
+			return err
+		}
+		return errRollback
+	})
+	if !errors.Is(err, errRollback) {
+		t.Fatalf("expected the transaction to be rolled back, got: %v", err)
+	}
+	if records := backendtest.Records(t, app); len(records) != 0 {
+		t.Errorf("expected the clock in to be rolled back, got %d records", len(records))
+	}
+
+	err = app.RunInTransaction(func(txApp core.App) error {
+		return workClockServiceOf(txApp).ClockInOut("", true, false)
+	})
+	if err != nil {
+		t.Fatalf("failed to clock in within a transaction: %v", err)
+	}
+	if records := backendtest.Records(t, app); len(records) != 1 || !records[0].ClockIn {
+		t.Errorf("expected a single clock in record, got %v", records)
+	}
+}
+
+func TestWorkClockService(t *testing.T) {
+	app := backendtest.NewApp(t)
+
+	clock := NewSimulatedClock(backendtest.MustParseTime("2025-04-01T08:00:00Z"), 0)
+	serviceSettings := settings
+	serviceSettings.MaxSessionDuration = 10 * time.Hour
+	serviceSettings.WorkdayDuration = 10 * time.Hour
+	serviceSettings.MaxFutureOffset = time.Minute
+	RegisterWorkClockService(app, NewWorkClockService(app).WithClock(clock).WithSettings(&serviceSettings))
+	RegisterWorkClockAPI(app)
+	handler := backendtest.NewHandler(t, app)
+
+	if err := workClockServiceOf(app).ClockInOut("", true, false); err != nil {
+		t.Fatalf("failed to clock in: %v", err)
+	}
+	if records := backendtest.Records(t, app); len(records) != 1 || !records[0].Timestamp.Equal(clock.Now()) {
+		t.Fatalf("expected a clock in at %s, got %v", clock.Now(), records)
+	}
+
+	clock.Advance(12 * time.Hour)
+	var tooLongErr *sessionTooLongError
+	if err := workClockServiceOf(app).ClockInOut("", false, false); !errors.As(err, &tooLongErr) {
+		t.Fatalf("expected the service settings to reject the session, got: %v", err)
+	}
+	status, err := getWorkClockStatus(app, "", clock.Now())
+	if err != nil {
+		t.Fatalf("failed to get status: %v", err)
+	}
+	if !status.Stale || !status.SuggestedClockOut.Equal(backendtest.MustParseTime("2025-04-01T18:00:00Z")) {
+		t.Errorf("expected the session to be stale after the workday of the service settings, got %+v", status)
+	}
+
+	form := url.Values{"clock_in": {"false"}, "timestamp": {clock.Now().Add(3 * time.Minute).Format(time.RFC3339)}}
+	request := httptest.NewRequest(http.MethodPost, "/api/work_clock/clock_in_out_at", strings.NewReader(form.Encode()))
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, request)
+	if recorder.Code != http.StatusBadRequest || !strings.Contains(recorder.Body.String(), "in the future") {
+		t.Errorf("expected the future offset of the service settings to reject the timestamp, got %d: %s", recorder.Code, recorder.Body.String())
+	}
+
+	// A locked clock doesn't block the other clocks
+	unlock, err := workClockServiceOf(app).lock("other")
+	if err != nil {
+		t.Fatalf("failed to lock the other clock: %v", err)
+	}
+	defer unlock()
+
+	done := make(chan error, 1)
+	go func() { done <- workClockServiceOf(app).ClockInOut("", false, true) }()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("failed to clock out: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected clocking out of the default clock not to wait for another clock")
+	}
+	backendtest.AssertAlternating(t, app)
+}
+
+func TestWorkClockServiceCancellation(t *testing.T) {
+	app := backendtest.NewApp(t)
+
+	unlock, err := workClockServiceOf(app).lockAll()
+	if err != nil {
+		t.Fatalf("failed to lock all clocks: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(t.Context(), 50*time.Millisecond)
+	defer cancel()
+	if err := workClockServiceOf(app).WithContext(ctx).ClockInOut("", true, false); !errors.Is(err, errClockBusy) || !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected the clock in to stop waiting for the locked clock, got: %v", err)
+	}
+
+	// A cancelled import neither waits for the clock nor keeps it locked
+	canceled, cancel := context.WithCancel(t.Context())
+	cancel()
+	if _, err := importActivityLogsPartially(canceled, app, "", "", []ActivityLog{{Timestamp: backendtest.MustParseTime("2025-04-01T09:00:00Z"), Active: true}}); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected the import to be cancelled, got: %v", err)
+	}
+
+	unlock()
+	if err := workClockServiceOf(app).ClockInOut("", true, false); err != nil {
+		t.Fatalf("failed to clock in after unlocking: %v", err)
+	}
+	if records := backendtest.Records(t, app); len(records) != 1 {
+		t.Errorf("expected only the clock in after unlocking, got %v", records)
+	}
+}
+
+func TestModifyExpectedTimestamp(t *testing.T) {
+	app := backendtest.NewApp(t)
+	RegisterWorkClockAPI(app)
+	handler := backendtest.NewHandler(t, app)
+	backendtest.AddRecords(t, app,
+		backendtest.ClockIn("2025-04-01T09:00:00Z"),
+		backendtest.ClockOut("2025-04-01T17:00:00Z"),
+	)
+	records, err := app.FindRecordsByFilter("work_clock", "", "+timestamp", 0, 0)
+	if err != nil {
+		t.Fatalf("failed to find records: %v", err)
+	}
+
+	post := func(path string, form url.Values) *httptest.ResponseRecorder {
+		request := httptest.NewRequest(http.MethodPost, path, strings.NewReader(form.Encode()))
+		request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, request)
+		return recorder
+	}
+
+	// The first admin moves the clock out to 18:00
+	recorder := post("/api/work_clock/modify", url.Values{
+		"work_clock_id":      {records[1].Id},
+		"new_timestamp":      {"2025-04-01T18:00:00Z"},
+		"expected_timestamp": {"2025-04-01T17:00:00Z"},
+	})
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", recorder.Code, recorder.Body.String())
+	}
+
+	// The second admin still sees 17:00 and is rejected
+	recorder = post("/api/work_clock/modify", url.Values{
+		"work_clock_id":      {records[1].Id},
+		"new_timestamp":      {"2025-04-01T16:30:00Z"},
+		"expected_timestamp": {"2025-04-01T17:00:00Z"},
+	})
+	if recorder.Code != http.StatusConflict {
+		t.Fatalf("expected status 409, got %d: %s", recorder.Code, recorder.Body.String())
+	}
+	if records := backendtest.Records(t, app); !records[1].Timestamp.Equal(backendtest.MustParseTime("2025-04-01T18:00:00Z")) {
+		t.Errorf("expected the first correction to be kept, got %v", records)
+	}
+
+	recorder = post("/api/work_clock/delete", url.Values{"clock_in_id": {records[0].Id}, "expected_timestamp": {"2025-04-01T08:00:00Z"}})
+	if recorder.Code != http.StatusConflict {
+		t.Fatalf("expected status 409, got %d: %s", recorder.Code, recorder.Body.String())
+	}
+	recorder = post("/api/work_clock/delete", url.Values{"clock_in_id": {records[0].Id}, "expected_timestamp": {"2025-04-01T09:00:00.000Z"}})
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", recorder.Code, recorder.Body.String())
+	}
+	if records := backendtest.Records(t, app); len(records) != 0 {
+		t.Errorf("expected the pair to be deleted, got %v", records)
+	}
+}
+
+func TestParseTimeParam(t *testing.T) {
+	expected := backendtest.MustParseTime("2025-04-01T09:00:00Z")
+
+	for _, value := range []string{"2025-04-01T11:00:00+02:00", "2025-04-01 09:00:00.000Z", "1743498000", "1743498000000", "1743498000s", "1743498000000ms"} {
+		timestamp, err := parseTimeParam(value, "timestamp")
+		if err != nil {
+			t.Errorf("failed to parse %q: %v", value, err)
+			continue
+		}
+		if !timestamp.Equal(expected) {
+			t.Errorf("expected %q to be %s, got %s", value, expected, timestamp)
+		}
+	}
+
+	// Plain integers that are neither 10 digit seconds nor 13 digit milliseconds are ambiguous,
+	// unless their unit is explicit
+	if _, err := parseTimeParam("174349800000", "timestamp"); err == nil || !strings.Contains(err.Error(), "ambiguous") {
+		t.Errorf("expected an ambiguity error for 12 digits, got %v", err)
+	}
+	if timestamp, err := parseTimeParam("174349800000ms", "timestamp"); err != nil || timestamp.UnixMilli() != 174349800000 {
+		t.Errorf("expected explicit milliseconds to be accepted, got %s, %v", timestamp, err)
+	}
+
+	for _, value := range []string{"", "-1743498000", "1743498000.5", "ms", "2025-04-01", "+1743498000"} {
+		if _, err := parseTimeParam(value, "timestamp"); err == nil {
+			t.Errorf("expected an error for %q", value)
+		}
+	}
+}
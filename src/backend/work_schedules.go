@@ -0,0 +1,134 @@
+// Work Schedules Module for PocketBase
+//
+// This module resolves the target hours of a day from the work_schedules collection. A schedule
+// sets the target of each workday and the weekdays that are workdays, and applies from its
+// 'valid_from' day until the next schedule of the same clock. Days before the first schedule use
+// the workday duration (see Settings.WorkdayDuration) on Mondays to Fridays, so a clock without
+// schedules behaves as before.
+//
+// Since each day is measured against the schedule valid then, a contract change (e.g. switching
+// to part-time) only changes the targets from its day on. Whenever a schedule is created, modified
+// or deleted, the daily summaries from its day on are recomputed and the balances walked forward.
+package backend
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/core"
+)
+
+// workSchedule is the target hours of a clock from a day on.
+type workSchedule struct {
+	ValidFrom string        // First day the schedule applies to (YYYY-MM-DD), empty for the default schedule
+	Workday   time.Duration // Target of each workday
+	Workdays  []string      // Lowercase names of the weekdays with a target
+}
+
+// defaultWorkSchedule is the schedule of the days before the first schedule of a clock.
+func defaultWorkSchedule() workSchedule {
+	return workSchedule{
+		Workday:  settings.WorkdayDuration,
+		Workdays: []string{"monday", "tuesday", "wednesday", "thursday", "friday"},
+	}
+}
+
+// target returns the target of a day according to the schedule.
+//
+// Parameters:
+// - day: The day
+//
+// Returns:
+// - The target of the day, 0 if the day is not a workday
+func (s workSchedule) target(day time.Time) time.Duration {
+	if !slices.Contains(s.Workdays, strings.ToLower(day.Weekday().String())) {
+		return 0
+	}
+	return s.Workday
+}
+
+// workSchedules are the schedules of a clock, sorted by the day they take effect.
+type workSchedules []workSchedule
+
+// on returns the schedule valid on a day.
+//
+// Parameters:
+// - day: The local midnight of the day
+//
+// Returns:
+// - The latest schedule taking effect on or before the day, the default schedule if there is none
+func (s workSchedules) on(day time.Time) workSchedule {
+	date := day.Format(time.DateOnly)
+	for i := len(s) - 1; i >= 0; i-- {
+		if s[i].ValidFrom <= date {
+			return s[i]
+		}
+	}
+	return defaultWorkSchedule()
+}
+
+// target returns the target of a day according to the schedule valid on it.
+//
+// Parameters:
+// - day: The local midnight of the day
+//
+// Returns:
+// - The target of the day, 0 if the day is not a workday
+func (s workSchedules) target(day time.Time) time.Duration {
+	return s.on(day).target(day)
+}
+
+// RegisterWorkScheduleHooks registers the hooks recomputing the daily summaries of changed
+// schedules with the PocketBase server. The schedules themselves are managed through the
+// collection API of work_schedules.
+//
+// Parameters:
+// - app: The PocketBase application instance
+func RegisterWorkScheduleHooks(app core.App) {
+	update := func(e *core.RecordEvent) error {
+		started := time.Now()
+		days, err := updateDailySummariesFromRecordDate(e.App, e.Record, "valid_from")
+		recordDailySummaryUpdate(started, days, err)
+		if err != nil {
+			// The schedule change itself succeeded, a rebuild repairs the summaries
+			e.App.Logger().Error("failed to update daily summaries", "schedule", e.Record.Id, "error", err)
+		}
+		return e.Next()
+	}
+
+	app.OnRecordAfterCreateSuccess("work_schedules").BindFunc(update)
+	app.OnRecordAfterUpdateSuccess("work_schedules").BindFunc(update)
+	app.OnRecordAfterDeleteSuccess("work_schedules").BindFunc(update)
+}
+
+// findWorkSchedules finds the schedules of a clock.
+//
+// Parameters:
+// - app: The App interface (typically a PocketBase instance or transaction)
+// - clockID: The ID of the clock, an empty string for the default clock
+//
+// Returns:
+// - The schedules sorted by the day they take effect
+// - An error if the database query fails
+func findWorkSchedules(app core.App, clockID string) (workSchedules, error) {
+	records, err := app.FindRecordsByFilter("work_schedules", "clock = {:clock}", "+valid_from", 0, 0, dbx.Params{
+		"clock": clockParam(clockID),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to find work schedules: %w", err)
+	}
+
+	schedules := make(workSchedules, 0, len(records))
+	for _, record := range records {
+		schedules = append(schedules, workSchedule{
+			ValidFrom: record.GetString("valid_from"),
+			Workday:   time.Duration(record.GetInt("workday_minutes")) * time.Minute,
+			Workdays:  record.GetStringSlice("workdays"),
+		})
+	}
+
+	return schedules, nil
+}
@@ -0,0 +1,44 @@
+package backend
+
+import (
+	"testing"
+	"time"
+
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/yerTools/simple-frontend-stack/src/backend/backendtest"
+)
+
+func TestDailySummaryUsesScheduleValidThen(t *testing.T) {
+	app := backendtest.NewApp(t)
+
+	collection, err := app.FindCollectionByNameOrId("work_schedules")
+	if err != nil {
+		t.Fatalf("failed to find work_schedules collection: %v", err)
+	}
+	schedule := core.NewRecord(collection)
+	schedule.Set("valid_from", "2025-04-07")
+	schedule.Set("workday_minutes", 240)
+	schedule.Set("workdays", []string{"monday", "tuesday", "wednesday", "thursday"})
+	if err := app.Save(schedule); err != nil {
+		t.Fatalf("failed to save schedule: %v", err)
+	}
+
+	for date, expected := range map[string]time.Duration{
+		"2025-04-04": settings.WorkdayDuration, // Friday before the change
+		"2025-04-08": 4 * time.Hour,            // Tuesday after the change
+		"2025-04-11": 0,                        // Friday after the change
+	} {
+		day, _ := time.ParseInLocation(time.DateOnly, date, time.Local)
+		if err := updateDailySummary(app, "", day); err != nil {
+			t.Fatalf("failed to update daily summary of %s: %v", date, err)
+		}
+
+		summaries, err := findDailySummaries(app, "", day, day.AddDate(0, 0, 1))
+		if err != nil || len(summaries) != 1 {
+			t.Fatalf("failed to find daily summary of %s: %v", date, err)
+		}
+		if summaries[0].TargetSeconds != int64(expected.Seconds()) {
+			t.Errorf("expected a target of %s on %s, got %ds", expected, date, summaries[0].TargetSeconds)
+		}
+	}
+}
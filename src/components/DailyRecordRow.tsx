@@ -139,7 +139,11 @@ const EntryPairRow: Component<EntryPairRowProps> = (props) => {
     setDeleteError("");
     setShowConfirmModal(false);
 
-    Effect.runPromise(deleteTimeEntry(props.pair.clockInId)).then(
+    const expectedTimestamp =
+      props.pair.clockIn != null ? new Date(props.pair.clockIn) : undefined;
+    Effect.runPromise(
+      deleteTimeEntry(props.pair.clockInId, expectedTimestamp),
+    ).then(
       (success) => {
         setIsDeleting(false);
         if (!success) {
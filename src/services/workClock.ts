@@ -105,6 +105,7 @@ type DeleteTimeEntryError = Error<"deleteTimeEntry"> & {
  * by sending a request to the server's `/api/work_clock/delete` endpoint.
  *
  * @param {string} clockInId - The unique identifier of the clock in time entry to delete
+ * @param {Date} [expectedTimestamp] - The timestamp the clock in entry was read with; the server rejects the deletion with 409 if it was changed since
  * @returns {Effect.Effect<boolean, DeleteTimeEntryError>} An Effect that yields true if successful, or fails with DeleteTimeEntryError
  * @example
  * // Delete a time entry (pair)
@@ -115,12 +116,16 @@ type DeleteTimeEntryError = Error<"deleteTimeEntry"> & {
  */
 export function deleteTimeEntry(
   clockInId: string,
+  expectedTimestamp?: Date,
 ): Effect.Effect<boolean, DeleteTimeEntryError> {
   return Effect.tryPromise({
     try: async () => {
       // Create FormData with the clock_in_id parameter
       const formData = new FormData();
       formData.append("clock_in_id", clockInId);
+      if (expectedTimestamp != null) {
+        formData.append("expected_timestamp", expectedTimestamp.toISOString());
+      }
 
       // Use the validated API endpoint
       const response = await fetch("/api/work_clock/delete", {
@@ -400,6 +405,7 @@ export type ModifyTimestampError =
  *
  * @param workClockId - The unique identifier of the work clock record to modify
  * @param newTimestamp - The new Date object to set as the timestamp
+ * @param expectedTimestamp - The timestamp the record was read with; the server rejects the change with 409 if it was changed since
  * @returns An Effect that yields true on success, or fails with ModifyTimestampError
  * @example
  * // Modify a work clock record timestamp
@@ -410,6 +416,7 @@ export type ModifyTimestampError =
 export function modifyWorkClockTimestamp(
   workClockId: string,
   newTimestamp: Date,
+  expectedTimestamp?: Date,
 ): Effect.Effect<boolean, ModifyTimestampError> {
   return pipe(
     dateToRFC3339(newTimestamp),
@@ -420,6 +427,12 @@ export function modifyWorkClockTimestamp(
           const formData = new FormData();
           formData.append("work_clock_id", workClockId);
           formData.append("new_timestamp", timestampStr);
+          if (expectedTimestamp != null) {
+            formData.append(
+              "expected_timestamp",
+              expectedTimestamp.toISOString(),
+            );
+          }
 
           // Call the API endpoint
           const response = await fetch("/api/work_clock/modify", {